@@ -0,0 +1,396 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
+	"github.com/starbops/gottodo/pkg/client"
+)
+
+// newFlagSet creates the flag set of a command
+func (a *app) newFlagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(a.stderr, "Usage: gottodo %s %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// login stores a session token, either given directly or issued for the
+// user's email and password
+func (a *app) login(ctx context.Context, args []string) error {
+	fs := a.newFlagSet("login", "[-email EMAIL] [-token TOKEN]")
+	email := fs.String("email", "", "account email, prompted for when empty")
+	token := fs.String("token", "", "store an existing token instead of logging in")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var expiresAt *time.Time
+	if *token != "" {
+		a.client.SetToken(*token)
+		if _, err := a.client.ListTodos(ctx); err != nil {
+			if errors.Is(err, client.ErrUnauthorized) {
+				return errors.New("the token was rejected by the server")
+			}
+			return err
+		}
+	} else {
+		reader := bufio.NewReader(a.stdin)
+		if *email == "" {
+			fmt.Fprint(a.stderr, "Email: ")
+			line, err := reader.ReadString('\n')
+			if err != nil && line == "" {
+				return fmt.Errorf("failed to read email: %w", err)
+			}
+			*email = strings.TrimSpace(line)
+		}
+
+		password, err := a.readPassword(reader)
+		if err != nil {
+			return err
+		}
+
+		session, err := a.client.Login(ctx, *email, password)
+		if err != nil {
+			if errors.Is(err, client.ErrUnauthorized) {
+				return errors.New("invalid email or password")
+			}
+			return err
+		}
+		expiresAt = &session.ExpiresAt
+	}
+
+	a.config.Server = a.server
+	a.config.Token = a.client.Token()
+	a.config.TokenExpiresAt = expiresAt
+	a.config.UndoToken = ""
+	if err := a.config.save(a.configPath); err != nil {
+		return err
+	}
+
+	if expiresAt != nil {
+		fmt.Fprintf(a.stdout, "Logged in to %s until %s\n", a.server, formatTime(*expiresAt))
+		return nil
+	}
+	fmt.Fprintf(a.stdout, "Logged in to %s\n", a.server)
+	return nil
+}
+
+// readPassword prompts for the password without echoing it when reading from
+// a terminal
+func (a *app) readPassword(reader *bufio.Reader) (string, error) {
+	fmt.Fprint(a.stderr, "Password: ")
+
+	if f, ok := a.stdin.(*os.File); ok && isTerminal(f) {
+		if err := stty(f, "-echo"); err == nil {
+			defer func() {
+				stty(f, "echo")
+				fmt.Fprintln(a.stderr)
+			}()
+		}
+	}
+
+	line, err := reader.ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// isTerminal reports whether f is a character device
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// stty changes the settings of the terminal f
+func stty(f *os.File, setting string) error {
+	cmd := exec.Command("stty", setting)
+	cmd.Stdin = f
+	return cmd.Run()
+}
+
+// logout revokes the stored token and removes it from the configuration
+func (a *app) logout(ctx context.Context, args []string) error {
+	fs := a.newFlagSet("logout", "")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// A token the server no longer knows is forgotten all the same
+	if err := a.client.Logout(ctx); err != nil && !errors.Is(err, client.ErrUnauthorized) {
+		return err
+	}
+
+	a.config.Token = ""
+	a.config.TokenExpiresAt = nil
+	a.config.UndoToken = ""
+	if err := a.config.save(a.configPath); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(a.stdout, "Logged out")
+	return nil
+}
+
+// add creates a todo from the remaining arguments
+func (a *app) add(ctx context.Context, args []string) error {
+	fs := a.newFlagSet("add", "[-d DESCRIPTION] [-json] TITLE")
+	description := fs.String("d", "", "description of the todo")
+	asJSON := fs.Bool("json", false, "print the todo as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	title := strings.TrimSpace(strings.Join(fs.Args(), " "))
+	if title == "" {
+		fs.Usage()
+		return errors.New("a title is required")
+	}
+
+	todo, err := a.client.CreateTodo(ctx, title, *description)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return writeJSON(a.stdout, todo)
+	}
+	fmt.Fprintf(a.stdout, "Added %s %s\n", shortID(todo.ID), todo.Title)
+	return nil
+}
+
+// list prints the user's todos
+func (a *app) list(ctx context.Context, args []string) error {
+	fs := a.newFlagSet("ls", "[-all | -done] [-grep TEXT] [-json]")
+	all := fs.Bool("all", false, "include completed todos")
+	done := fs.Bool("done", false, "only show completed todos")
+	grep := fs.String("grep", "", "only show todos whose title or description contains TEXT")
+	asJSON := fs.Bool("json", false, "print the todos as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *all && *done {
+		return errors.New("-all and -done cannot be combined")
+	}
+
+	todos, err := a.client.ListTodos(ctx)
+	if err != nil {
+		return err
+	}
+
+	needle := strings.ToLower(*grep)
+	filtered := make([]*models.Todo, 0, len(todos))
+	for _, todo := range todos {
+		if !*all && todo.Completed != *done {
+			continue
+		}
+		if needle != "" && !strings.Contains(strings.ToLower(todo.Title+"\n"+todo.Description), needle) {
+			continue
+		}
+		filtered = append(filtered, todo)
+	}
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return filtered[i].CreatedAt.Before(filtered[j].CreatedAt)
+	})
+
+	if *asJSON {
+		return writeJSON(a.stdout, filtered)
+	}
+	return writeTable(a.stdout, filtered)
+}
+
+// done marks todos as completed
+func (a *app) done(ctx context.Context, args []string) error {
+	return a.eachTodo(ctx, "done", "Completed", args, func(id string) (*models.Todo, string, error) {
+		return a.client.CompleteTodo(ctx, id)
+	})
+}
+
+// remove moves todos to the trash
+func (a *app) remove(ctx context.Context, args []string) error {
+	return a.eachTodo(ctx, "rm", "Deleted", args, func(id string) (*models.Todo, string, error) {
+		todo, err := a.client.GetTodo(ctx, id)
+		if err != nil {
+			return nil, "", err
+		}
+		undoToken, err := a.client.DeleteTodo(ctx, id)
+		return todo, undoToken, err
+	})
+}
+
+// eachTodo applies an undoable action to every todo given as argument and
+// remembers the undo token of the last one
+func (a *app) eachTodo(ctx context.Context, name, verb string, args []string, action func(id string) (*models.Todo, string, error)) error {
+	fs := a.newFlagSet(name, "[-json] ID...")
+	asJSON := fs.Bool("json", false, "print the todos as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("at least one todo ID is required")
+	}
+
+	ids, err := a.resolveIDs(ctx, fs.Args())
+	if err != nil {
+		return err
+	}
+
+	todos := make([]*models.Todo, 0, len(ids))
+	for _, id := range ids {
+		todo, undoToken, err := action(id)
+		if err != nil {
+			return fmt.Errorf("%s: %w", shortID(id), err)
+		}
+		todos = append(todos, todo)
+
+		if undoToken != "" {
+			a.config.UndoToken = undoToken
+			if err := a.config.save(a.configPath); err != nil {
+				return err
+			}
+		}
+		if !*asJSON {
+			fmt.Fprintf(a.stdout, "%s %s %s\n", verb, shortID(todo.ID), todo.Title)
+		}
+	}
+
+	if *asJSON {
+		return writeJSON(a.stdout, todos)
+	}
+	return nil
+}
+
+// undo reverts the last done or rm, or the change of the given undo token
+func (a *app) undo(ctx context.Context, args []string) error {
+	fs := a.newFlagSet("undo", "[-json] [TOKEN]")
+	asJSON := fs.Bool("json", false, "print the restored todo as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	token := fs.Arg(0)
+	if token == "" {
+		token = a.config.UndoToken
+	}
+	if token == "" {
+		return errors.New("nothing to undo")
+	}
+
+	todo, err := a.client.Undo(ctx, token)
+	if token == a.config.UndoToken && (err == nil || errors.Is(err, repositories.ErrUndoTokenNotFound)) {
+		a.config.UndoToken = ""
+		if saveErr := a.config.save(a.configPath); saveErr != nil {
+			return saveErr
+		}
+	}
+	if errors.Is(err, repositories.ErrUndoTokenNotFound) {
+		return errors.New("nothing to undo")
+	}
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return writeJSON(a.stdout, todo)
+	}
+	fmt.Fprintf(a.stdout, "Restored %s %s\n", shortID(todo.ID), todo.Title)
+	return nil
+}
+
+// edit opens a todo in the user's editor and saves the result
+func (a *app) edit(ctx context.Context, args []string) error {
+	fs := a.newFlagSet("edit", "[-json] ID")
+	asJSON := fs.Bool("json", false, "print the saved todo as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("exactly one todo ID is required")
+	}
+
+	ids, err := a.resolveIDs(ctx, fs.Args())
+	if err != nil {
+		return err
+	}
+
+	todo, err := a.client.GetTodo(ctx, ids[0])
+	if err != nil {
+		return err
+	}
+
+	title, description, err := editTodo(todo, a.stdin, a.stdout, a.stderr)
+	if err != nil {
+		return err
+	}
+	if title == todo.Title && description == todo.Description {
+		fmt.Fprintln(a.stderr, "No changes")
+		return nil
+	}
+
+	// The version makes the save fail if the todo changed while editing
+	todo.Title = title
+	todo.Description = description
+	if err := a.client.UpdateTodo(ctx, todo); err != nil {
+		if errors.Is(err, repositories.ErrConflict) {
+			return errors.New("the todo was changed by someone else while editing; run edit again")
+		}
+		return err
+	}
+
+	if *asJSON {
+		return writeJSON(a.stdout, todo)
+	}
+	fmt.Fprintf(a.stdout, "Saved %s %s\n", shortID(todo.ID), todo.Title)
+	return nil
+}
+
+// resolveIDs expands ID prefixes to the IDs of the user's todos
+func (a *app) resolveIDs(ctx context.Context, prefixes []string) ([]string, error) {
+	var todos []*models.Todo
+	ids := make([]string, len(prefixes))
+	for i, prefix := range prefixes {
+		if models.IsValidUUID(prefix) {
+			ids[i] = prefix
+			continue
+		}
+
+		if todos == nil {
+			var err error
+			if todos, err = a.client.ListTodos(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		var matches []string
+		for _, todo := range todos {
+			if strings.HasPrefix(todo.ID, strings.ToLower(prefix)) {
+				matches = append(matches, todo.ID)
+			}
+		}
+		switch len(matches) {
+		case 0:
+			return nil, fmt.Errorf("%s: %w", prefix, repositories.ErrTodoNotFound)
+		case 1:
+			ids[i] = matches[0]
+		default:
+			return nil, fmt.Errorf("%s: ambiguous ID matches %d todos", prefix, len(matches))
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// cliConfig is the state the CLI keeps between invocations
+type cliConfig struct {
+	Server    string `json:"server,omitempty"`
+	Token     string `json:"token,omitempty"`
+	UndoToken string `json:"undo_token,omitempty"`
+
+	// TokenExpiresAt is when the session of the token ends, unknown for
+	// tokens stored with login -token
+	TokenExpiresAt *time.Time `json:"token_expires_at,omitempty"`
+}
+
+// configPath returns the location of the CLI configuration, following the
+// XDG base directory specification
+func configPath() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to locate config directory: %w", err)
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "gottodo", "config.json"), nil
+}
+
+// loadConfig reads the CLI configuration, returning an empty one if the file
+// does not exist yet
+func loadConfig(path string) (*cliConfig, error) {
+	cfg := &cliConfig{}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// save writes the configuration, readable only by the current user since it
+// holds the session token
+func (cfg *cliConfig) save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/starbops/gottodo/internal/models"
+)
+
+// editorHelp is appended to the file opened in the editor
+const editorHelp = `
+# The first line is the title, the following lines are the description.
+# Lines starting with '#' are ignored. An empty title aborts the edit.
+`
+
+// editTodo lets the user change a todo's title and description in $VISUAL or
+// $EDITOR
+func editTodo(todo *models.Todo, stdin io.Reader, stdout, stderr io.Writer) (string, string, error) {
+	f, err := os.CreateTemp("", "gottodo-*.txt")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	_, err = fmt.Fprintf(f, "%s\n\n%s\n%s", todo.Title, todo.Description, editorHelp)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to write temporary file: %w", err)
+	}
+
+	// The editor command may carry arguments, as in "code --wait"
+	cmd := exec.Command("sh", "-c", editorCommand()+` "$1"`, "sh", f.Name())
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = stdin, stdout, stderr
+	if err := cmd.Run(); err != nil {
+		return "", "", fmt.Errorf("editor failed: %w", err)
+	}
+
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read temporary file: %w", err)
+	}
+	return parseEditedTodo(string(data))
+}
+
+// editorCommand returns the user's preferred editor
+func editorCommand() string {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if editor := os.Getenv(name); editor != "" {
+			return editor
+		}
+	}
+	return "vi"
+}
+
+// parseEditedTodo reads the title and description back from an edited file
+func parseEditedTodo(content string) (string, string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return "", "", err
+	}
+
+	// Skip leading blank lines before the title
+	for len(lines) > 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
+	if len(lines) == 0 {
+		return "", "", errors.New("empty title, edit aborted")
+	}
+
+	title := strings.TrimSpace(lines[0])
+	description := strings.TrimSpace(strings.Join(lines[1:], "\n"))
+	return title, description, nil
+}
@@ -0,0 +1,164 @@
+// Command gottodo manages todos of a GotToDo server from the terminal.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/starbops/gottodo/pkg/client"
+)
+
+// defaultServer is used when no server is given by flag, environment or config
+const defaultServer = "http://localhost:8080"
+
+const usage = `Usage: gottodo [-server URL] <command> [arguments]
+
+Commands:
+  login [-token TOKEN]         Log in and store the session token
+  logout                       Revoke and forget the session token
+  add [-d DESCRIPTION] TITLE   Create a todo
+  ls [-all|-done] [-grep TEXT] List todos, pending ones by default
+  done ID...                   Mark todos as completed
+  rm ID...                     Move todos to the trash
+  undo [TOKEN]                 Revert the last done or rm
+  edit ID                      Edit a todo in $EDITOR
+
+IDs may be shortened to any unique prefix. Commands that print todos accept
+-json for machine-readable output.
+
+The server defaults to $GOTTODO_SERVER, then to the server of the last login.
+`
+
+// app holds the state shared by all commands
+type app struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+
+	configPath string
+	config     *cliConfig
+	server     string
+	client     *client.Client
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	a := &app{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+	if err := a.run(ctx, os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(2)
+		}
+		fmt.Fprintf(os.Stderr, "gottodo: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run parses the global flags and dispatches to a command
+func (a *app) run(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("gottodo", flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	fs.Usage = func() { fmt.Fprint(a.stderr, usage) }
+	server := fs.String("server", "", "URL of the GotToDo server")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	if err := a.setup(*server); err != nil {
+		return err
+	}
+
+	name, args := fs.Arg(0), fs.Args()[1:]
+	commands := map[string]func(context.Context, []string) error{
+		"login":  a.login,
+		"logout": a.logout,
+		"add":    a.add,
+		"ls":     a.list,
+		"done":   a.done,
+		"rm":     a.remove,
+		"undo":   a.undo,
+		"edit":   a.edit,
+	}
+	command, exists := commands[name]
+	if !exists {
+		fs.Usage()
+		return fmt.Errorf("unknown command %q", name)
+	}
+	return a.describeError(command(ctx, args))
+}
+
+// setup loads the configuration and creates the API client
+func (a *app) setup(server string) error {
+	if a.configPath == "" {
+		path, err := configPath()
+		if err != nil {
+			return err
+		}
+		a.configPath = path
+	}
+
+	cfg, err := loadConfig(a.configPath)
+	if err != nil {
+		return err
+	}
+	a.config = cfg
+
+	for _, candidate := range []string{server, os.Getenv("GOTTODO_SERVER"), cfg.Server, defaultServer} {
+		if candidate != "" {
+			a.server = candidate
+			break
+		}
+	}
+
+	// The stored token belongs to the server it was issued by
+	var opts []client.Option
+	if cfg.Token != "" && cfg.Server == a.server {
+		opts = append(opts, client.WithToken(cfg.Token))
+	}
+
+	a.client, err = client.New(a.server, opts...)
+	return err
+}
+
+// sessionError replaces an authentication error of the server with advice
+// on logging in, and unwraps to it
+type sessionError struct {
+	advice string
+	err    error
+}
+
+func (e *sessionError) Error() string {
+	return e.advice
+}
+
+func (e *sessionError) Unwrap() error {
+	return e.err
+}
+
+// describeError turns API errors into hints for the user, telling apart not
+// being logged in from a stored session that ended
+func (a *app) describeError(err error) error {
+	if !errors.Is(err, client.ErrUnauthorized) {
+		return err
+	}
+
+	switch expiresAt := a.config.TokenExpiresAt; {
+	case a.config.Token == "" || a.config.Server != a.server:
+		return &sessionError{advice: fmt.Sprintf("not logged in to %s; run \"gottodo login\" first", a.server), err: err}
+	case expiresAt != nil && !expiresAt.After(time.Now()):
+		return &sessionError{advice: fmt.Sprintf("the session expired at %s; run \"gottodo login\" again", formatTime(*expiresAt)), err: err}
+	default:
+		return &sessionError{advice: "the stored session has expired or was revoked; run \"gottodo login\" again", err: err}
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/starbops/gottodo/internal/handlers"
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
+	"github.com/starbops/gottodo/internal/services"
+	"github.com/starbops/gottodo/pkg/auth"
+	"github.com/starbops/gottodo/pkg/client"
+	"github.com/starbops/gottodo/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newServer starts the real application router on top of memory repositories
+// and registers a user
+func newServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	cfg := config.DefaultConfig()
+	repos, err := repositories.NewRepositories(cfg)
+	require.NoError(t, err)
+
+	todoService := services.NewTodoService(repos.Todos, services.WithActivityRepository(repos.Activity))
+	undoService := services.NewUndoService(todoService, repos.Undo, time.Minute)
+	authService := auth.NewAuthService(cfg)
+
+	e := echo.New()
+	handlers.RegisterRoutes(e, &handlers.Routes{
+		Todo:        handlers.NewTodoHandler(todoService, undoService),
+		Page:        handlers.NewPageHandler(todoService, undoService, authService),
+		Auth:        handlers.NewAuthHandler(authService),
+		Activity:    handlers.NewActivityHandler(todoService),
+		Docs:        handlers.NewDocsHandler(),
+		Idempotency: services.NewIdempotencyService(repos.Idempotency, time.Hour),
+	})
+	server := httptest.NewServer(e)
+	t.Cleanup(server.Close)
+
+	c, err := client.New(server.URL)
+	require.NoError(t, err)
+	_, err = c.Register(context.Background(), "user@example.com", "secret")
+	require.NoError(t, err)
+
+	return server
+}
+
+// gottodo runs the CLI with the given stdin and returns its output
+func gottodo(t *testing.T, stdin string, args ...string) (string, error) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	a := &app{stdin: strings.NewReader(stdin), stdout: &stdout, stderr: &stderr}
+	err := a.run(context.Background(), args)
+	return stdout.String(), err
+}
+
+func TestCLI(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("GOTTODO_SERVER", newServer(t).URL)
+
+	// Commands fail until logged in
+	_, err := gottodo(t, "", "ls")
+	assert.ErrorIs(t, err, client.ErrUnauthorized)
+
+	_, err = gottodo(t, "user@example.com\nwrong\n", "login")
+	assert.EqualError(t, err, "invalid email or password")
+
+	out, err := gottodo(t, "secret\n", "login", "-email", "user@example.com")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Logged in")
+
+	path, err := configPath()
+	require.NoError(t, err)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// Add two todos
+	out, err = gottodo(t, "", "add", "-json", "-d", "Two liters", "Buy", "milk")
+	require.NoError(t, err)
+	var milk models.Todo
+	require.NoError(t, json.Unmarshal([]byte(out), &milk))
+	assert.Equal(t, "Buy milk", milk.Title)
+	assert.Equal(t, "Two liters", milk.Description)
+
+	out, err = gottodo(t, "", "add", "Walk the dog")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Added")
+
+	// Complete one by its short ID and undo it
+	out, err = gottodo(t, "", "done", shortID(milk.ID))
+	require.NoError(t, err)
+	assert.Equal(t, "Completed "+shortID(milk.ID)+" Buy milk\n", out)
+
+	out, err = gottodo(t, "", "ls")
+	require.NoError(t, err)
+	assert.NotContains(t, out, "Buy milk")
+	assert.Contains(t, out, "Walk the dog")
+
+	out, err = gottodo(t, "", "ls", "-done")
+	require.NoError(t, err)
+	assert.Regexp(t, `\[x\]\s+Buy milk`, out)
+
+	_, err = gottodo(t, "", "undo")
+	require.NoError(t, err)
+	_, err = gottodo(t, "", "undo")
+	assert.EqualError(t, err, "nothing to undo")
+
+	out, err = gottodo(t, "", "ls", "-json", "-grep", "MILK")
+	require.NoError(t, err)
+	var todos []*models.Todo
+	require.NoError(t, json.Unmarshal([]byte(out), &todos))
+	require.Len(t, todos, 1)
+	assert.False(t, todos[0].Completed)
+
+	// Edit through a scripted editor
+	editor := filepath.Join(t.TempDir(), "editor.sh")
+	require.NoError(t, os.WriteFile(editor, []byte("#!/bin/sh\nprintf 'Buy oat milk\\n\\nOne liter\\n' > \"$1\"\n"), 0700))
+	t.Setenv("VISUAL", editor)
+
+	out, err = gottodo(t, "", "edit", milk.ID)
+	require.NoError(t, err)
+	assert.Contains(t, out, "Saved")
+
+	// Delete, then restore it
+	_, err = gottodo(t, "", "rm", milk.ID[:4])
+	require.NoError(t, err)
+	_, err = gottodo(t, "", "done", milk.ID)
+	assert.ErrorIs(t, err, repositories.ErrTodoNotFound)
+
+	out, err = gottodo(t, "", "undo")
+	require.NoError(t, err)
+	assert.Equal(t, "Restored "+shortID(milk.ID)+" Buy oat milk\n", out)
+
+	// Logging out forgets the token
+	_, err = gottodo(t, "", "logout")
+	require.NoError(t, err)
+	_, err = gottodo(t, "", "ls")
+	assert.ErrorIs(t, err, client.ErrUnauthorized)
+	assert.ErrorContains(t, err, "not logged in to")
+}
+
+func TestCLI_EndedSession(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	server := newServer(t).URL
+	t.Setenv("GOTTODO_SERVER", server)
+
+	path, err := configPath()
+	require.NoError(t, err)
+	expired := time.Date(2024, time.March, 1, 9, 30, 0, 0, time.Local)
+
+	// The stored expiry tells why the server rejects the token
+	cfg := &cliConfig{Server: server, Token: "ended", TokenExpiresAt: &expired}
+	require.NoError(t, cfg.save(path))
+	_, err = gottodo(t, "", "ls")
+	assert.ErrorIs(t, err, client.ErrUnauthorized)
+	assert.EqualError(t, err, `the session expired at 2024-03-01 09:30:00; run "gottodo login" again`)
+
+	// Tokens stored with -token have no known expiry
+	cfg.TokenExpiresAt = nil
+	require.NoError(t, cfg.save(path))
+	_, err = gottodo(t, "", "ls")
+	assert.ErrorIs(t, err, client.ErrUnauthorized)
+	assert.EqualError(t, err, `the stored session has expired or was revoked; run "gottodo login" again`)
+
+	// Logging in records when the new session ends
+	out, err := gottodo(t, "secret\n", "login", "-email", "user@example.com")
+	require.NoError(t, err)
+	assert.Contains(t, out, "until")
+	cfg, err = loadConfig(path)
+	require.NoError(t, err)
+	require.NotNil(t, cfg.TokenExpiresAt)
+	assert.True(t, cfg.TokenExpiresAt.After(time.Now()))
+}
+
+func TestParseEditedTodo(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		title       string
+		description string
+		wantErr     bool
+	}{
+		{
+			name:    "title only",
+			content: "Buy milk\n" + editorHelp,
+			title:   "Buy milk",
+		},
+		{
+			name:        "title and description",
+			content:     "\nBuy milk  \n\nTwo liters\nSemi-skimmed\n\n" + editorHelp,
+			title:       "Buy milk",
+			description: "Two liters\nSemi-skimmed",
+		},
+		{
+			name:    "empty",
+			content: "\n\n" + editorHelp,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			title, description, err := parseEditedTodo(tt.content)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.title, title)
+			assert.Equal(t, tt.description, description)
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/starbops/gottodo/internal/models"
+)
+
+// writeJSON prints v as indented JSON
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// writeTable prints todos as an aligned table
+func writeTable(w io.Writer, todos []*models.Todo) error {
+	if len(todos) == 0 {
+		_, err := fmt.Fprintln(w, "No todos")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tDONE\tTITLE\tUPDATED")
+	for _, todo := range todos {
+		done := " "
+		if todo.Completed {
+			done = "x"
+		}
+		fmt.Fprintf(tw, "%s\t[%s]\t%s\t%s\n", shortID(todo.ID), done, oneLine(todo.Title), formatTime(todo.UpdatedAt))
+	}
+	return tw.Flush()
+}
+
+// formatTime prints a timestamp in local time, or "-" when the server did
+// not report one
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format(time.DateTime)
+}
+
+// shortID abbreviates a todo ID for display; commands accept any unique
+// prefix of an ID
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// oneLine keeps tabs and line breaks from breaking the table layout
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
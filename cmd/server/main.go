@@ -1,10 +1,13 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
@@ -13,6 +16,7 @@ import (
 	"github.com/starbops/gottodo/internal/services"
 	"github.com/starbops/gottodo/pkg/auth"
 	"github.com/starbops/gottodo/pkg/config"
+	"github.com/starbops/gottodo/pkg/database"
 )
 
 func main() {
@@ -32,61 +36,151 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	// Apply schema migrations on request, or at startup if configured
+	if flag.Arg(0) == "migrate" {
+		if err := migrate(cfg, flag.Args()[1:]); err != nil {
+			log.Fatalf("Failed to migrate the database: %v", err)
+		}
+		return
+	}
+	if cfg.Repository.Type == config.SupabaseRepository && cfg.Database.AutoMigrate {
+		if err := migrate(cfg, []string{"up"}); err != nil {
+			log.Fatalf("Failed to migrate the database: %v", err)
+		}
+	}
+
 	log.Printf("Using repository type: %s", cfg.Repository.Type)
 
 	// Create a new Echo instance
 	e := echo.New()
 
+	// Rate limits tell clients apart by address. Forwarded addresses are
+	// only trusted from a proxy, as clients could make them up otherwise.
+	if cfg.Server.BehindProxy {
+		e.IPExtractor = echo.ExtractIPFromXFFHeader()
+	} else {
+		e.IPExtractor = echo.ExtractIPDirect()
+	}
+
 	// Middleware
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
-	e.Use(middleware.CORS())
+	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
+		// CalDAV clients discover the server with OPTIONS requests, which
+		// the CalDAV routes answer themselves
+		Skipper: func(c echo.Context) bool {
+			path := c.Request().URL.Path
+			return strings.HasPrefix(path, "/dav/") || strings.HasPrefix(path, "/.well-known/")
+		},
+	}))
 
 	// Initialize repositories
-	todoRepo, err := repositories.NewTodoRepository(cfg)
+	repos, err := repositories.NewRepositories(cfg)
 	if err != nil {
 		log.Fatalf("Failed to create repository: %v", err)
 	}
 
-	// Initialize services
-	todoService := services.NewTodoService(todoRepo)
+	// Initialize services. Background jobs work across users, so they run
+	// on the system repositories, which bypass row-level security. The
+	// webhook worker belongs to the service that enqueues the deliveries,
+	// so that it sends them right away.
+	webhookService := services.NewWebhookService(repos.Webhooks,
+		services.WithWebhookWorkerRepository(repos.System.Webhooks),
+		services.WithWebhookTimeout(time.Duration(cfg.Webhooks.TimeoutSeconds)*time.Second),
+		services.WithWebhookMaxAttempts(cfg.Webhooks.MaxAttempts),
+		services.WithWebhookBackoff(time.Duration(cfg.Webhooks.BackoffSeconds)*time.Second),
+	)
+	eventHub := services.NewEventHub(
+		services.WithEventBufferSize(cfg.Events.BufferSize),
+		services.WithEventReplaySize(cfg.Events.ReplaySize),
+		services.WithEventReplayTTL(time.Duration(cfg.Events.ReplayMinutes)*time.Minute),
+	)
+	todoOptions := []services.TodoServiceOption{
+		services.WithWebhookService(webhookService),
+	}
+	if cfg.Repository.Type == config.SupabaseRepository {
+		// Changes of every instance reach the hub through Postgres notifications
+		relay := services.NewEventRelay(repos.System.Todos, eventHub)
+		listener := database.NewListener(cfg.GetSupabaseDBURL(), repositories.TodoChangesChannel, relay.Relay,
+			database.WithReconnectHandler(relay.Reset),
+		)
+		go func() {
+			if err := listener.Run(context.Background()); err != nil {
+				log.Printf("Live updates from other instances are disabled: %v", err)
+			}
+		}()
+	} else {
+		todoOptions = append(todoOptions, services.WithEventHub(eventHub))
+	}
+	newTodoService := func(repos *repositories.Repositories) *services.TodoService {
+		return services.NewTodoService(repos.Todos, append([]services.TodoServiceOption{
+			services.WithActivityRepository(repos.Activity),
+			services.WithUnitOfWork(repos.UnitOfWork),
+		}, todoOptions...)...)
+	}
+	todoService := newTodoService(repos)
+	systemTodoService := newTodoService(repos.System)
+	undoWindow := time.Duration(cfg.Undo.WindowSeconds) * time.Second
+	undoService := services.NewUndoService(todoService, repos.Undo, undoWindow)
+	captureService := services.NewCaptureService(repos.Capture, repos.System.Capture, todoService)
+	calendarService := services.NewCalendarService(repos.Calendar, repos.System.Calendar, todoService)
+	appPasswordService := services.NewAppPasswordService(repos.AppPasswords)
+	importService := services.NewImportService(todoService)
+	idempotencyTTL := time.Duration(cfg.Idempotency.TTLHours) * time.Hour
+	idempotencyService := services.NewIdempotencyService(repos.Idempotency, idempotencyTTL)
+
+	// Purge expired trash in the background
+	if cfg.Trash.RetentionDays > 0 {
+		retention := time.Duration(cfg.Trash.RetentionDays) * 24 * time.Hour
+		go systemTodoService.RunTrashPurger(context.Background(), retention, time.Hour)
+	}
+
+	// Deliver webhook events in the background
+	go webhookService.RunWorker(context.Background(), time.Duration(cfg.Webhooks.PollSeconds)*time.Second)
 
 	// Initialize auth service
 	authService := auth.NewAuthService(cfg)
 
+	// Delete accounts whose grace period has ended in the background
+	deletionGrace := time.Duration(cfg.Account.DeletionGraceDays) * 24 * time.Hour
+	accountService := services.NewAccountService(repos, authService, todoService, deletionGrace)
+	accountPurger := services.NewAccountService(repos.System, authService, systemTodoService, deletionGrace)
+	go accountPurger.RunAccountPurger(context.Background(), time.Hour)
+
 	// Initialize handlers
-	todoHandler := handlers.NewTodoHandler(todoService)
-	pageHandler := handlers.NewPageHandler(todoService, authService)
+	todoHandler := handlers.NewTodoHandler(todoService, undoService)
+	pageHandler := handlers.NewPageHandler(todoService, undoService, authService)
 	authHandler := handlers.NewAuthHandler(authService)
-
-	// Auth middleware
-	authMiddleware := authHandler.AuthMiddleware
+	activityHandler := handlers.NewActivityHandler(todoService)
+	docsHandler := handlers.NewDocsHandler()
+	eventsHandler := handlers.NewEventsHandler(eventHub, time.Duration(cfg.Events.HeartbeatSeconds)*time.Second)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	captureHandler := handlers.NewCaptureHandler(captureService, cfg.Server.BaseURL, cfg.Capture.RequestsPerMinute, cfg.Capture.Burst)
+	calendarHandler := handlers.NewCalendarHandler(calendarService, todoService, cfg.Server.BaseURL)
+	calDAVHandler := handlers.NewCalDAVHandler(todoService, appPasswordService, authService)
+	appPasswordHandler := handlers.NewAppPasswordHandler(appPasswordService, cfg.Server.BaseURL)
+	importHandler := handlers.NewImportHandler(importService)
+	exportHandler := handlers.NewExportHandler(todoService)
+	accountHandler := handlers.NewAccountHandler(accountService, authService)
 
 	// Routes
-	// Public routes
-	e.GET("/", pageHandler.Home)
-	e.GET("/login", pageHandler.Login)
-	e.GET("/register", pageHandler.Register)
-
-	// Auth routes
-	e.GET("/auth/github", authHandler.GitHubAuth)
-	e.GET("/auth/github/callback", authHandler.GitHubCallback)
-	e.POST("/auth/login", authHandler.Login)
-	e.POST("/auth/register", authHandler.Register)
-	e.POST("/auth/logout", authHandler.Logout)
-
-	// Protected routes
-	e.GET("/dashboard", pageHandler.Dashboard, authMiddleware)
-
-	// Todo API routes
-	todoGroup := e.Group("/todos", authMiddleware)
-	todoGroup.GET("", todoHandler.GetAllTodos)
-	todoGroup.GET("/:id", todoHandler.GetTodo)
-	todoGroup.POST("", todoHandler.CreateTodo)
-	todoGroup.PUT("/:id", todoHandler.UpdateTodo)
-	todoGroup.PUT("/:id/complete", todoHandler.UpdateTodoStatus)
-	todoGroup.PUT("/:id/incomplete", todoHandler.UpdateTodoStatus)
-	todoGroup.DELETE("/:id", todoHandler.DeleteTodo)
+	handlers.RegisterRoutes(e, &handlers.Routes{
+		Todo:        todoHandler,
+		Page:        pageHandler,
+		Auth:        authHandler,
+		Activity:    activityHandler,
+		Docs:        docsHandler,
+		Events:      eventsHandler,
+		Webhooks:    webhookHandler,
+		Capture:     captureHandler,
+		Calendar:    calendarHandler,
+		CalDAV:      calDAVHandler,
+		AppPassword: appPasswordHandler,
+		Import:      importHandler,
+		Export:      exportHandler,
+		Account:     accountHandler,
+		Idempotency: idempotencyService,
+	})
 
 	// Start the server
 	port := cfg.Server.Port
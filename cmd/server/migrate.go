@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/starbops/gottodo/migrations"
+	"github.com/starbops/gottodo/pkg/config"
+	"github.com/starbops/gottodo/pkg/database"
+)
+
+// migrate runs the migrate subcommand: "up" applies the pending migrations,
+// "down [steps]" reverts the last ones, "status" lists them and
+// "baseline <version>" marks those up to version as applied by hand
+func migrate(cfg *config.Config, args []string) error {
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	db, err := database.ConnectToSupabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := database.NewMigrator(db, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	switch command {
+	case "up":
+		count, err := migrator.Up(ctx)
+		log.Printf("Applied %d migrations", count)
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+		}
+		count, err := migrator.Down(ctx, steps)
+		log.Printf("Reverted %d migrations", count)
+		return err
+	case "baseline":
+		if len(args) < 2 {
+			return fmt.Errorf("baseline needs the version the database is at")
+		}
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		count, err := migrator.Baseline(ctx, version)
+		log.Printf("Recorded %d migrations as applied", count)
+		return err
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = status.AppliedAt.Local().Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%03d\t%s\t%s\n", status.Version, status.Name, applied)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown migrate command %q, expected up, down, status or baseline", command)
+	}
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/starbops/gottodo/internal/services"
+	"github.com/starbops/gottodo/pkg/auth"
+	"github.com/starbops/gottodo/ui/templates"
+)
+
+// AccountHandler handles HTTP requests for deleting accounts and downloading
+// their data
+type AccountHandler struct {
+	accountService *services.AccountService
+	authService    *auth.AuthService
+}
+
+// NewAccountHandler creates a new AccountHandler
+func NewAccountHandler(accountService *services.AccountService, authService *auth.AuthService) *AccountHandler {
+	return &AccountHandler{
+		accountService: accountService,
+		authService:    authService,
+	}
+}
+
+// DeleteAccountRequest represents the request body for deleting an account
+type DeleteAccountRequest struct {
+	// Password confirms the deletion; users without a password must have
+	// logged in recently instead
+	Password string `json:"password" form:"password"`
+}
+
+// GetAccount handles GET /account, rendering the account page for browsers
+func (h *AccountHandler) GetAccount(c echo.Context) error {
+	user := c.Get("user").(*auth.User)
+
+	deletion, err := h.accountService.GetDeletion(c.Request().Context(), user.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	if wantsJSON(c) {
+		profile := accountProfile(user)
+		profile.Deletion = deletion
+		return c.JSON(http.StatusOK, profile)
+	}
+	return templates.Account(user.Email, deletion, user.PasswordHash != "", h.graceDays()).Render(c.Request().Context(), c.Response().Writer)
+}
+
+// RequestDeletion handles POST /account/deletion. After the user confirms it
+// is them, the account is deleted at the end of the grace period, or at
+// once if there is none.
+func (h *AccountHandler) RequestDeletion(c echo.Context) error {
+	user := c.Get("user").(*auth.User)
+	ctx := c.Request().Context()
+
+	var req DeleteAccountRequest
+	if err := c.Bind(&req); err != nil {
+		return h.renderError(c, user, http.StatusBadRequest, "Invalid request body")
+	}
+
+	token, _ := sessionToken(c)
+	if err := h.authService.Reauthenticate(ctx, token, req.Password); err != nil {
+		switch {
+		case errors.Is(err, auth.ErrInvalidCredentials):
+			return h.renderError(c, user, http.StatusForbidden, "The password is incorrect")
+		case errors.Is(err, auth.ErrReauthenticationRequired):
+			return h.renderError(c, user, http.StatusForbidden,
+				fmt.Sprintf("Log in again, then delete your account within %d minutes", int(auth.ReauthenticationWindow.Minutes())))
+		default:
+			return h.renderError(c, user, http.StatusUnauthorized, "Invalid authentication token")
+		}
+	}
+
+	deletion, err := h.accountService.RequestDeletion(ctx, user.ID)
+	if err != nil {
+		return h.renderError(c, user, http.StatusInternalServerError, fmt.Sprintf("Failed to delete account: %v", err))
+	}
+
+	if deletion.DeleteAt.After(time.Now()) {
+		if wantsJSON(c) {
+			return c.JSON(http.StatusAccepted, deletion)
+		}
+		return templates.AccountDeletion(deletion, user.PasswordHash != "", h.graceDays(), "").Render(ctx, c.Response().Writer)
+	}
+
+	// The account is gone together with the session
+	clearAuthCookie(c)
+	if wantsJSON(c) {
+		return c.NoContent(http.StatusNoContent)
+	}
+	c.Response().Header().Set("HX-Redirect", "/")
+	return c.NoContent(http.StatusOK)
+}
+
+// CancelDeletion handles DELETE /account/deletion, keeping the account
+func (h *AccountHandler) CancelDeletion(c echo.Context) error {
+	user := c.Get("user").(*auth.User)
+
+	if err := h.accountService.CancelDeletion(c.Request().Context(), user.ID); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrNoAccountDeletion) {
+			status = http.StatusNotFound
+		}
+		return h.renderError(c, user, status, err.Error())
+	}
+
+	if wantsJSON(c) {
+		return c.NoContent(http.StatusNoContent)
+	}
+	return templates.AccountDeletion(nil, user.PasswordHash != "", h.graceDays(), "").Render(c.Request().Context(), c.Response().Writer)
+}
+
+// DownloadData handles GET /account/data, downloading a zip archive of JSON
+// files with all data of the user
+func (h *AccountHandler) DownloadData(c echo.Context) error {
+	user := c.Get("user").(*auth.User)
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "application/zip")
+	res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="gottodo-data-%s.zip"`, time.Now().UTC().Format("2006-01-02")))
+
+	if err := h.accountService.WriteArchive(c.Request().Context(), accountProfile(user), res); err != nil {
+		if !res.Committed {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		log.Printf("Failed to write data archive of user %s: %v", user.ID, err)
+	}
+	return nil
+}
+
+// renderError reports a failed account action to API clients, and in the
+// deletion section of the account page for browsers
+func (h *AccountHandler) renderError(c echo.Context, user *auth.User, status int, message string) error {
+	if wantsJSON(c) {
+		return c.JSON(status, map[string]string{
+			"error": message,
+		})
+	}
+
+	// The form is shown again without the pending deletion if it cannot be loaded
+	deletion, _ := h.accountService.GetDeletion(c.Request().Context(), user.ID)
+	return templates.AccountDeletion(deletion, user.PasswordHash != "", h.graceDays(), message).Render(c.Request().Context(), c.Response().Writer)
+}
+
+// graceDays returns the grace period of account deletions in days
+func (h *AccountHandler) graceDays() int {
+	return int(h.accountService.GracePeriod().Hours() / 24)
+}
+
+// accountProfile returns the profile of a user for their data archive
+func accountProfile(user *auth.User) services.AccountProfile {
+	return services.AccountProfile{
+		ID:          user.ID,
+		Email:       user.Email,
+		CreatedAt:   user.CreatedAt,
+		HasPassword: user.PasswordHash != "",
+	}
+}
+
+// sessionToken returns the token the request is authenticated with, from the
+// Authorization header or the auth_token cookie
+func sessionToken(c echo.Context) (string, bool) {
+	if token, ok := bearerToken(c); ok {
+		return token, true
+	}
+	cookie, err := c.Cookie("auth_token")
+	if err != nil {
+		return "", false
+	}
+	return cookie.Value, true
+}
+
+// clearAuthCookie removes the auth_token cookie from the browser
+func clearAuthCookie(c echo.Context) {
+	cookie := new(http.Cookie)
+	cookie.Name = "auth_token"
+	cookie.Value = ""
+	cookie.Expires = time.Now().Add(-1 * time.Hour)
+	cookie.Path = "/"
+	cookie.HttpOnly = true
+	cookie.SameSite = http.SameSiteStrictMode
+	c.SetCookie(cookie)
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
+	"github.com/starbops/gottodo/internal/services"
+	"github.com/starbops/gottodo/pkg/auth"
+	"github.com/starbops/gottodo/pkg/config"
+)
+
+// newAccountTestServer serves the account routes behind the auth middleware
+// and returns the session token of a registered user with a todo
+func newAccountTestServer(t *testing.T, grace time.Duration) (*echo.Echo, *auth.AuthService, *repositories.Repositories, string) {
+	t.Helper()
+	ctx := context.Background()
+
+	authService := auth.NewAuthService(&config.Config{})
+	user, err := authService.Register(ctx, "ann@example.com", "secret")
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	session, err := authService.Login(ctx, "ann@example.com", "secret")
+	if err != nil {
+		t.Fatalf("Failed to log in: %v", err)
+	}
+
+	repos := repositories.NewMemoryRepositories()
+	todoService := services.NewTodoService(repos.Todos, services.WithActivityRepository(repos.Activity))
+	if err := todoService.CreateTodo(ctx, models.NewTodo(user.ID, "Buy milk", "")); err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+	handler := NewAccountHandler(services.NewAccountService(repos, authService, todoService, grace), authService)
+
+	e := echo.New()
+	group := e.Group("/account", NewAuthHandler(authService).AuthMiddleware)
+	group.GET("", handler.GetAccount)
+	group.POST("/deletion", handler.RequestDeletion)
+	group.DELETE("/deletion", handler.CancelDeletion)
+	group.GET("/data", handler.DownloadData)
+	return e, authService, repos, session.Token
+}
+
+// serveAccountRequest serves a JSON API request authenticated with token
+func serveAccountRequest(e *echo.Echo, method, path, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	req.Header.Set(echo.HeaderAccept, echo.MIMEApplicationJSON)
+	if body != "" {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAccountHandler_Deletion(t *testing.T) {
+	e, authService, _, token := newAccountTestServer(t, 14*24*time.Hour)
+
+	// The password must be entered again
+	rec := serveAccountRequest(e, http.MethodPost, "/account/deletion", token, `{"password":"wrong"}`)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("Expected 403 for a wrong password, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = serveAccountRequest(e, http.MethodPost, "/account/deletion", token, `{"password":"secret"}`)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = serveAccountRequest(e, http.MethodGet, "/account", token, "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"deletion":`) {
+		t.Errorf("Expected the pending deletion in the profile, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// The account is kept once the deletion is cancelled
+	rec = serveAccountRequest(e, http.MethodDelete, "/account/deletion", token, "")
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = serveAccountRequest(e, http.MethodDelete, "/account/deletion", token, "")
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 without a pending deletion, got %d", rec.Code)
+	}
+	if _, err := authService.GetUser(context.Background(), token); err != nil {
+		t.Errorf("Expected the user to remain, got %v", err)
+	}
+}
+
+func TestAccountHandler_ImmediateDeletion(t *testing.T) {
+	e, authService, repos, token := newAccountTestServer(t, 0)
+	user, _ := authService.GetUser(context.Background(), token)
+
+	rec := serveAccountRequest(e, http.MethodPost, "/account/deletion", token, `{"password":"secret"}`)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if _, err := authService.GetUserByEmail(context.Background(), "ann@example.com"); err == nil {
+		t.Errorf("Expected the user to be deleted")
+	}
+	count := 0
+	repos.Todos.StreamUserTodos(context.Background(), user.ID, func(*models.Todo) error { count++; return nil })
+	if count != 0 {
+		t.Errorf("Expected the todos to be deleted, got %d", count)
+	}
+
+	// The session went with the account
+	rec = serveAccountRequest(e, http.MethodGet, "/account", token, "")
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 after the deletion, got %d", rec.Code)
+	}
+}
+
+func TestAccountHandler_DownloadData(t *testing.T) {
+	e, _, _, token := newAccountTestServer(t, time.Hour)
+
+	rec := serveAccountRequest(e, http.MethodGet, "/account/data", token, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get(echo.HeaderContentType); got != "application/zip" {
+		t.Errorf("Expected a zip archive, got %q", got)
+	}
+	if got := rec.Header().Get(echo.HeaderContentDisposition); !strings.HasPrefix(got, `attachment; filename="gottodo-data-`) {
+		t.Errorf("Expected the archive as an attachment, got %q", got)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	if err != nil {
+		t.Fatalf("Failed to read archive: %v", err)
+	}
+	names := map[string]bool{}
+	for _, f := range archive.File {
+		names[f.Name] = true
+	}
+	for _, name := range []string{"profile.json", "todos.json", "activity.json", "webhooks.json", "app_passwords.json"} {
+		if !names[name] {
+			t.Errorf("Expected %s in the archive, got %v", name, names)
+		}
+	}
+}
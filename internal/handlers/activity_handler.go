@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/starbops/gottodo/internal/services"
+)
+
+// ActivityHandler handles HTTP requests for the activity feed
+type ActivityHandler struct {
+	todoService *services.TodoService
+}
+
+// NewActivityHandler creates a new ActivityHandler
+func NewActivityHandler(todoService *services.TodoService) *ActivityHandler {
+	return &ActivityHandler{
+		todoService: todoService,
+	}
+}
+
+// GetActivityFeed handles GET /activity?limit=&offset=
+func (h *ActivityHandler) GetActivityFeed(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+
+	limit, err := queryInt(c, "limit")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid limit parameter",
+		})
+	}
+
+	offset, err := queryInt(c, "offset")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid offset parameter",
+		})
+	}
+
+	page, err := h.todoService.GetActivityFeed(c.Request().Context(), userID, limit, offset)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, page)
+}
+
+// queryInt parses an optional integer query parameter, returning 0 when it is absent
+func queryInt(c echo.Context, name string) (int, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
+	"github.com/starbops/gottodo/internal/services"
+	"github.com/starbops/gottodo/pkg/auth"
+	"github.com/starbops/gottodo/ui/templates"
+)
+
+// AppPasswordHandler handles HTTP requests for the app passwords sync
+// clients log in with
+type AppPasswordHandler struct {
+	appPasswordService *services.AppPasswordService
+	baseURL            string
+}
+
+// NewAppPasswordHandler creates a new AppPasswordHandler for the server at baseURL
+func NewAppPasswordHandler(appPasswordService *services.AppPasswordService, baseURL string) *AppPasswordHandler {
+	return &AppPasswordHandler{
+		appPasswordService: appPasswordService,
+		baseURL:            strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// CreateAppPasswordRequest represents the request body for creating an app password
+type CreateAppPasswordRequest struct {
+	Name string `json:"name" form:"name"`
+}
+
+// CreatedAppPassword is a new app password together with the password,
+// which is only ever shown once
+type CreatedAppPassword struct {
+	*models.AppPassword
+	Password string `json:"password"`
+}
+
+// appPasswordErrorStatus maps errors of app password actions to HTTP status codes
+func appPasswordErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repositories.ErrAppPasswordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrEmptyAppPasswordName):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GetAppPasswords handles GET /app-passwords, rendering the sync page for browsers
+func (h *AppPasswordHandler) GetAppPasswords(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+
+	appPasswords, err := h.appPasswordService.GetAppPasswords(c.Request().Context(), userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	if wantsJSON(c) {
+		if appPasswords == nil {
+			appPasswords = []*models.AppPassword{}
+		}
+		return c.JSON(http.StatusOK, appPasswords)
+	}
+
+	user := c.Get("user").(*auth.User)
+	return templates.AppPasswords(appPasswords, h.baseURL+calDAVHome, user.Email).Render(c.Request().Context(), c.Response().Writer)
+}
+
+// CreateAppPassword handles POST /app-passwords
+func (h *AppPasswordHandler) CreateAppPassword(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+
+	var req CreateAppPasswordRequest
+	if err := c.Bind(&req); err != nil {
+		if wantsJSON(c) {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body",
+			})
+		}
+		return h.renderList(c, userID, "", "Invalid form data")
+	}
+
+	appPassword, password, err := h.appPasswordService.CreateAppPassword(c.Request().Context(), userID, req.Name)
+	if err != nil {
+		if wantsJSON(c) {
+			return c.JSON(appPasswordErrorStatus(err), map[string]string{
+				"error": err.Error(),
+			})
+		}
+		return h.renderList(c, userID, "", fmt.Sprintf("Failed to create app password: %v", err))
+	}
+
+	if wantsJSON(c) {
+		return c.JSON(http.StatusCreated, CreatedAppPassword{AppPassword: appPassword, Password: password})
+	}
+	return h.renderList(c, userID, password, "")
+}
+
+// DeleteAppPassword handles DELETE /app-passwords/:id, signing out the apps
+// using the password
+func (h *AppPasswordHandler) DeleteAppPassword(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+
+	if err := h.appPasswordService.DeleteAppPassword(c.Request().Context(), c.Param("id"), userID); err != nil {
+		if wantsJSON(c) {
+			return c.JSON(appPasswordErrorStatus(err), map[string]string{
+				"error": err.Error(),
+			})
+		}
+		return h.renderList(c, userID, "", fmt.Sprintf("Failed to delete app password: %v", err))
+	}
+
+	if wantsJSON(c) {
+		return c.NoContent(http.StatusNoContent)
+	}
+	return h.renderList(c, userID, "", "")
+}
+
+// renderList renders the app password list, optionally with a password
+// that was just created or an error message
+func (h *AppPasswordHandler) renderList(c echo.Context, userID, newPassword, errorMessage string) error {
+	appPasswords, err := h.appPasswordService.GetAppPasswords(c.Request().Context(), userID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("Failed to get app passwords: %v", err))
+	}
+	return templates.AppPasswordList(appPasswords, newPassword, errorMessage).Render(c.Request().Context(), c.Response().Writer)
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/starbops/gottodo/internal/repositories"
+	"github.com/starbops/gottodo/internal/services"
+	"github.com/starbops/gottodo/pkg/auth"
+)
+
+// newAppPasswordTestServer serves the app password routes, managing the app
+// passwords of user1
+func newAppPasswordTestServer(t *testing.T) (*echo.Echo, *services.AppPasswordService) {
+	t.Helper()
+
+	appPasswordService := services.NewAppPasswordService(repositories.NewMemoryAppPasswordRepository())
+	handler := NewAppPasswordHandler(appPasswordService, "https://todo.example.com")
+
+	e := echo.New()
+	group := e.Group("/app-passwords", func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("user_id", "user1")
+			c.Set("user", &auth.User{ID: "user1", Email: "ann@example.com"})
+			return next(c)
+		}
+	})
+	group.GET("", handler.GetAppPasswords)
+	group.POST("", handler.CreateAppPassword)
+	group.DELETE("/:id", handler.DeleteAppPassword)
+	return e, appPasswordService
+}
+
+func TestAppPasswordHandler_JSON(t *testing.T) {
+	e, appPasswordService := newAppPasswordTestServer(t)
+
+	rec := serveWebhookRequest(e, http.MethodPost, "/app-passwords", echo.MIMEApplicationJSON, `{"name":"  "}`, true)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for an empty name, got %d", rec.Code)
+	}
+
+	// The password is returned once, and works for the user
+	rec = serveWebhookRequest(e, http.MethodPost, "/app-passwords", echo.MIMEApplicationJSON, `{"name":"Phone"}`, true)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created struct {
+		ID       string `json:"id"`
+		Name     string `json:"name"`
+		Password string `json:"password"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Failed to decode app password: %v", err)
+	}
+	if created.Name != "Phone" || !strings.HasPrefix(created.Password, "app_") {
+		t.Fatalf("Expected the new app password, got %+v", created)
+	}
+	if err := appPasswordService.Authenticate(context.Background(), "user1", created.Password); err != nil {
+		t.Errorf("Expected the password to authenticate user1, got %v", err)
+	}
+
+	rec = serveWebhookRequest(e, http.MethodGet, "/app-passwords", "", "", true)
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), created.Password) || !strings.Contains(rec.Body.String(), created.ID) {
+		t.Errorf("Expected the app password to be listed without its password, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Revoked passwords stop working
+	if rec := serveWebhookRequest(e, http.MethodDelete, "/app-passwords/"+created.ID, "", "", true); rec.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d", rec.Code)
+	}
+	if err := appPasswordService.Authenticate(context.Background(), "user1", created.Password); err == nil {
+		t.Error("Expected the revoked password to be refused")
+	}
+	if rec := serveWebhookRequest(e, http.MethodDelete, "/app-passwords/"+created.ID, "", "", true); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a revoked password, got %d", rec.Code)
+	}
+}
+
+func TestAppPasswordHandler_HTML(t *testing.T) {
+	e, _ := newAppPasswordTestServer(t)
+
+	rec := serveWebhookRequest(e, http.MethodGet, "/app-passwords", "", "", false)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "https://todo.example.com/dav/") {
+		t.Fatalf("Expected the sync page with the server URL, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = serveWebhookRequest(e, http.MethodPost, "/app-passwords", echo.MIMEApplicationForm, "name=Laptop", false)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Laptop") || !strings.Contains(rec.Body.String(), `value="app_`) {
+		t.Errorf("Expected the list showing the new password, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
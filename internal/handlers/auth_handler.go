@@ -4,10 +4,12 @@ import (
 	"context"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
 	"github.com/starbops/gottodo/pkg/auth"
 	"github.com/starbops/gottodo/ui/templates"
 )
@@ -41,19 +43,32 @@ func (h *AuthHandler) Register(c echo.Context) error {
 	var req RegisterRequest
 	if err := c.Bind(&req); err != nil {
 		c.Logger().Error("Register binding error:", err)
+		if wantsJSON(c) {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body",
+			})
+		}
 		component := templates.RegisterErrorForm("Invalid form data. Please check your inputs.", req.Email)
 		return component.Render(context.Background(), c.Response().Writer)
 	}
 
-	// Register the user but we don't need the returned user object
-	_, err := h.service.Register(c.Request().Context(), req.Email, req.Password)
+	// Register the user
+	user, err := h.service.Register(c.Request().Context(), req.Email, req.Password)
 	if err != nil {
 		c.Logger().Error("Registration error:", err)
+		if wantsJSON(c) {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+		}
 		component := templates.RegisterErrorForm(err.Error(), req.Email)
 		return component.Render(context.Background(), c.Response().Writer)
 	}
 
 	// For successful registration, show success message with countdown
+	if wantsJSON(c) {
+		return c.JSON(http.StatusCreated, user)
+	}
 	if c.Request().Header.Get("HX-Request") == "true" {
 		// Return success component for HTMX requests
 		component := templates.RegisterSuccessForm(req.Email)
@@ -106,8 +121,39 @@ func renderLoginError(c echo.Context, email string) error {
 	return templates.LoginErrorForm(email).Render(c.Request().Context(), c.Response().Writer)
 }
 
+// Token handles POST /auth/token, issuing a session token for API clients
+func (h *AuthHandler) Token(c echo.Context) error {
+	var req LoginRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	session, err := h.service.Login(c.Request().Context(), req.Email, req.Password)
+	if err != nil {
+		// Do not reveal whether the account exists
+		log.Printf("Token request failed for email %s: %v", req.Email, err)
+		return c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "Invalid credentials",
+		})
+	}
+
+	return c.JSON(http.StatusOK, session)
+}
+
 // Logout handles POST /auth/logout
 func (h *AuthHandler) Logout(c echo.Context) error {
+	// API clients only need their token revoked
+	if token, ok := bearerToken(c); ok {
+		if err := h.service.Logout(c.Request().Context(), token); err != nil {
+			return c.JSON(http.StatusUnauthorized, map[string]string{
+				"error": "Invalid authentication token",
+			})
+		}
+		return c.NoContent(http.StatusNoContent)
+	}
+
 	cookie, err := c.Cookie("auth_token")
 	if err != nil {
 		return c.Redirect(http.StatusFound, "/login")
@@ -211,8 +257,18 @@ func (h *AuthHandler) GitHubCallback(c echo.Context) error {
 // AuthMiddleware is middleware for authenticating requests
 func (h *AuthHandler) AuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
+		// API clients send their token in the Authorization header
+		if token, ok := bearerToken(c); ok {
+			return h.authenticateBearer(c, token, next)
+		}
+
 		cookie, err := c.Cookie("auth_token")
 		if err != nil {
+			if wantsJSON(c) {
+				return c.JSON(http.StatusUnauthorized, map[string]string{
+					"error": "Authentication required",
+				})
+			}
 			// Redirect to login page instead of returning JSON error
 			return c.Redirect(http.StatusFound, "/login")
 		}
@@ -245,9 +301,41 @@ func (h *AuthHandler) AuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 			return c.Redirect(http.StatusFound, "/login")
 		}
 
-		c.Set("user", user)
-		c.Set("user_id", user.ID)
+		setUser(c, user)
 
 		return next(c)
 	}
 }
+
+// authenticateBearer authenticates an API request, answering failures with
+// 401 Unauthorized instead of redirecting to the login page
+func (h *AuthHandler) authenticateBearer(c echo.Context, token string, next echo.HandlerFunc) error {
+	user, err := h.service.GetUser(c.Request().Context(), token)
+	if err != nil || !models.IsValidUUID(user.ID) {
+		return c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "Invalid authentication token",
+		})
+	}
+
+	setUser(c, user)
+
+	return next(c)
+}
+
+// setUser stores the authenticated user for the handlers and restricts the
+// database work of the request to the user's todos
+func setUser(c echo.Context, user *auth.User) {
+	c.Set("user", user)
+	c.Set("user_id", user.ID)
+	c.SetRequest(c.Request().WithContext(repositories.WithUserID(c.Request().Context(), user.ID)))
+}
+
+// bearerToken returns the token of an "Authorization: Bearer" header
+func bearerToken(c echo.Context) (string, bool) {
+	header := c.Request().Header.Get(echo.HeaderAuthorization)
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
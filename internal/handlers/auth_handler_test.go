@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/starbops/gottodo/internal/repositories"
+	"github.com/starbops/gottodo/pkg/auth"
+	"github.com/starbops/gottodo/pkg/config"
+)
+
+func TestAuthHandler_AuthMiddleware_Scope(t *testing.T) {
+	ctx := context.Background()
+	authService := auth.NewAuthService(&config.Config{})
+	user, err := authService.Register(ctx, "ann@example.com", "secret")
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	session, err := authService.Login(ctx, "ann@example.com", "secret")
+	if err != nil {
+		t.Fatalf("Failed to log in: %v", err)
+	}
+
+	var scope repositories.Scope
+	e := echo.New()
+	e.GET("/scope", func(c echo.Context) error {
+		scope = repositories.ScopeFromContext(c.Request().Context())
+		return c.NoContent(http.StatusNoContent)
+	}, NewAuthHandler(authService).AuthMiddleware)
+
+	// The database work of the request is restricted to the user's todos
+	for name, setAuth := range map[string]func(req *http.Request){
+		"bearer": func(req *http.Request) { req.Header.Set(echo.HeaderAuthorization, "Bearer "+session.Token) },
+		"cookie": func(req *http.Request) { req.AddCookie(&http.Cookie{Name: "auth_token", Value: session.Token}) },
+	} {
+		scope = repositories.Scope{}
+		req := httptest.NewRequest(http.MethodGet, "/scope", nil)
+		setAuth(req)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("%s: expected 204, got %d: %s", name, rec.Code, rec.Body.String())
+		}
+		if scope.UserID != user.ID {
+			t.Errorf("%s: expected the request to be scoped to %s, got %+v", name, user.ID, scope)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// XML namespaces of the WebDAV and CalDAV elements
+const (
+	davNS       = "DAV:"
+	calDAVNS    = "urn:ietf:params:xml:ns:caldav"
+	calServerNS = "http://calendarserver.org/ns/"
+)
+
+// Properties served by the CalDAV endpoints
+var (
+	propResourceType          = xml.Name{Space: davNS, Local: "resourcetype"}
+	propDisplayName           = xml.Name{Space: davNS, Local: "displayname"}
+	propGetETag               = xml.Name{Space: davNS, Local: "getetag"}
+	propGetContentType        = xml.Name{Space: davNS, Local: "getcontenttype"}
+	propGetLastModified       = xml.Name{Space: davNS, Local: "getlastmodified"}
+	propCurrentUserPrincipal  = xml.Name{Space: davNS, Local: "current-user-principal"}
+	propPrincipalURL          = xml.Name{Space: davNS, Local: "principal-URL"}
+	propCurrentUserPrivileges = xml.Name{Space: davNS, Local: "current-user-privilege-set"}
+	propSupportedReportSet    = xml.Name{Space: davNS, Local: "supported-report-set"}
+	propCalendarHomeSet       = xml.Name{Space: calDAVNS, Local: "calendar-home-set"}
+	propSupportedComponents   = xml.Name{Space: calDAVNS, Local: "supported-calendar-component-set"}
+	propCalendarData          = xml.Name{Space: calDAVNS, Local: "calendar-data"}
+	propGetCTag               = xml.Name{Space: calServerNS, Local: "getctag"}
+)
+
+// davElement is an XML element in a WebDAV response
+type davElement struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr   `xml:",attr"`
+	Text     string       `xml:",chardata"`
+	Children []davElement `xml:",any"`
+}
+
+// davText creates an element holding text
+func davText(name xml.Name, text string) davElement {
+	return davElement{XMLName: name, Text: text}
+}
+
+// davHref creates an element holding a single href
+func davHref(name xml.Name, href string) davElement {
+	return davElement{XMLName: name, Children: []davElement{
+		davText(xml.Name{Space: davNS, Local: "href"}, href),
+	}}
+}
+
+// davParent creates an element holding other elements
+func davParent(name xml.Name, children ...davElement) davElement {
+	return davElement{XMLName: name, Children: children}
+}
+
+// davEmpty creates an element without content, such as a property name
+func davEmpty(space, local string) davElement {
+	return davElement{XMLName: xml.Name{Space: space, Local: local}}
+}
+
+// davMultistatus is the body of a 207 Multi-Status response
+type davMultistatus struct {
+	XMLName   xml.Name      `xml:"DAV: multistatus"`
+	Responses []davResponse `xml:"response"`
+}
+
+// davResponse describes the properties, or the status, of a single resource
+type davResponse struct {
+	Href     string        `xml:"href"`
+	Status   string        `xml:"status,omitempty"`
+	Propstat []davPropstat `xml:"propstat,omitempty"`
+}
+
+// davPropstat groups properties with the status of looking them up
+type davPropstat struct {
+	Prop   davProp `xml:"prop"`
+	Status string  `xml:"status"`
+}
+
+// davProp holds the properties of a propstat
+type davProp struct {
+	Props []davElement `xml:",any"`
+}
+
+// davStatus formats a status line as used in multistatus responses
+func davStatus(code int) string {
+	return fmt.Sprintf("HTTP/1.1 %d %s", code, http.StatusText(code))
+}
+
+// propResponse builds the response for a resource from the values of the
+// properties it has. Requested properties without a value are reported as
+// not found; with no properties requested, all values are returned.
+func propResponse(href string, requested []xml.Name, values map[xml.Name]davElement) davResponse {
+	response := davResponse{Href: href}
+
+	var found, missing []davElement
+	if requested == nil {
+		for _, name := range sortedPropNames(values) {
+			found = append(found, values[name])
+		}
+	}
+	for _, name := range requested {
+		if value, ok := values[name]; ok {
+			found = append(found, value)
+		} else {
+			missing = append(missing, davElement{XMLName: name})
+		}
+	}
+
+	if len(found) > 0 {
+		response.Propstat = append(response.Propstat, davPropstat{Prop: davProp{Props: found}, Status: davStatus(http.StatusOK)})
+	}
+	if len(missing) > 0 {
+		response.Propstat = append(response.Propstat, davPropstat{Prop: davProp{Props: missing}, Status: davStatus(http.StatusNotFound)})
+	}
+	return response
+}
+
+// sortedPropNames lists property names in a stable order
+func sortedPropNames(values map[xml.Name]davElement) []xml.Name {
+	names := make([]xml.Name, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if names[i].Space != names[j].Space {
+			return names[i].Space < names[j].Space
+		}
+		return names[i].Local < names[j].Local
+	})
+	return names
+}
+
+// writeMultistatus responds with a 207 Multi-Status body
+func writeMultistatus(c echo.Context, responses []davResponse) error {
+	body, err := xml.Marshal(davMultistatus{Responses: responses})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	return c.Blob(http.StatusMultiStatus, echo.MIMEApplicationXMLCharsetUTF8, append([]byte(xml.Header), body...))
+}
+
+// writeDAVError responds with a WebDAV precondition error naming the
+// violated condition
+func writeDAVError(c echo.Context, status int, condition xml.Name) error {
+	body, err := xml.Marshal(davParent(xml.Name{Space: davNS, Local: "error"}, davElement{XMLName: condition}))
+	if err != nil {
+		return c.NoContent(status)
+	}
+	return c.Blob(status, echo.MIMEApplicationXMLCharsetUTF8, append([]byte(xml.Header), body...))
+}
+
+// davPropNames collects the names of the elements inside a prop element
+type davPropNames []xml.Name
+
+// UnmarshalXML reads the property names, skipping their content
+func (p *davPropNames) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			*p = append(*p, t.Name)
+			if err := d.Skip(); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// davPropfind is the body of a PROPFIND request
+type davPropfind struct {
+	XMLName  xml.Name     `xml:"DAV: propfind"`
+	AllProp  *struct{}    `xml:"DAV: allprop"`
+	PropName *struct{}    `xml:"DAV: propname"`
+	Prop     davPropNames `xml:"DAV: prop"`
+}
+
+// calendarQuery is the body of a calendar-query REPORT
+type calendarQuery struct {
+	Prop   davPropNames `xml:"DAV: prop"`
+	Filter struct {
+		CompFilter compFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+	} `xml:"urn:ietf:params:xml:ns:caldav filter"`
+}
+
+// compFilter selects calendar components in a calendar-query
+type compFilter struct {
+	Name         string       `xml:"name,attr"`
+	IsNotDefined *struct{}    `xml:"urn:ietf:params:xml:ns:caldav is-not-defined"`
+	CompFilters  []compFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+	PropFilters  []propFilter `xml:"urn:ietf:params:xml:ns:caldav prop-filter"`
+}
+
+// propFilter selects calendar components by a property in a calendar-query
+type propFilter struct {
+	Name         string    `xml:"name,attr"`
+	IsNotDefined *struct{} `xml:"urn:ietf:params:xml:ns:caldav is-not-defined"`
+}
+
+// calendarMultiget is the body of a calendar-multiget REPORT
+type calendarMultiget struct {
+	Prop  davPropNames `xml:"DAV: prop"`
+	Hrefs []string     `xml:"DAV: href"`
+}
+
+// readPropfind reads the properties a PROPFIND request asks for. It returns
+// nil when all properties are wanted, which is also what an empty body means.
+func readPropfind(body io.Reader) ([]xml.Name, error) {
+	var propfind davPropfind
+	if err := xml.NewDecoder(body).Decode(&propfind); err != nil {
+		if err == io.EOF {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if propfind.AllProp != nil || propfind.PropName != nil || len(propfind.Prop) == 0 {
+		return nil, nil
+	}
+	return propfind.Prop, nil
+}
+
+// davDepth reads the Depth header, treating infinity like 1 as the calendar
+// tree is only two levels deep
+func davDepth(c echo.Context) int {
+	if strings.TrimSpace(c.Request().Header.Get("Depth")) == "0" {
+		return 0
+	}
+	return 1
+}
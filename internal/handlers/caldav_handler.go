@@ -0,0 +1,550 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
+	"github.com/starbops/gottodo/internal/services"
+	"github.com/starbops/gottodo/pkg/auth"
+	"github.com/starbops/gottodo/pkg/ical"
+)
+
+const (
+	// calDAVHome is both the principal and the calendar home of the
+	// authenticated user
+	calDAVHome = "/dav/"
+
+	// calDAVTodos is the calendar collection holding the user's todos
+	calDAVTodos = "/dav/todos/"
+
+	// maxCalDAVBodySize bounds the size of CalDAV request bodies
+	maxCalDAVBodySize = 1 << 20
+
+	// calDAVTaskContentType is the media type of a todo resource
+	calDAVTaskContentType = ical.ContentType + "; component=VTODO"
+)
+
+// CalDAVHandler serves the todos of a user as a CalDAV calendar of tasks, so
+// that task apps can sync them both ways
+type CalDAVHandler struct {
+	todoService        *services.TodoService
+	appPasswordService *services.AppPasswordService
+	authService        *auth.AuthService
+}
+
+// NewCalDAVHandler creates a new CalDAVHandler
+func NewCalDAVHandler(todoService *services.TodoService, appPasswordService *services.AppPasswordService, authService *auth.AuthService) *CalDAVHandler {
+	return &CalDAVHandler{
+		todoService:        todoService,
+		appPasswordService: appPasswordService,
+		authService:        authService,
+	}
+}
+
+// BasicAuth authenticates CalDAV clients, which log in with the account's
+// email address and one of its app passwords
+func (h *CalDAVHandler) BasicAuth(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		email, password, ok := c.Request().BasicAuth()
+		if ok {
+			user, err := h.authService.GetUserByEmail(c.Request().Context(), email)
+			if err == nil {
+				err = h.appPasswordService.Authenticate(c.Request().Context(), user.ID, password)
+				if err == nil {
+					setUser(c, user)
+					return next(c)
+				}
+				if !errors.Is(err, services.ErrInvalidAppPassword) {
+					return c.JSON(http.StatusInternalServerError, map[string]string{
+						"error": err.Error(),
+					})
+				}
+			}
+		}
+
+		c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Basic realm="gottodo", charset="UTF-8"`)
+		return c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "Authentication required",
+		})
+	}
+}
+
+// WellKnown handles /.well-known/caldav, pointing clients at the calendar home
+func (h *CalDAVHandler) WellKnown(c echo.Context) error {
+	return c.Redirect(http.StatusMovedPermanently, calDAVHome)
+}
+
+// Options handles OPTIONS requests, advertising CalDAV support
+func (h *CalDAVHandler) Options(c echo.Context) error {
+	c.Response().Header().Set("DAV", "1, 3, calendar-access")
+	c.Response().Header().Set(echo.HeaderAllow, "OPTIONS, PROPFIND, REPORT, GET, PUT, DELETE")
+	return c.NoContent(http.StatusOK)
+}
+
+// PropfindHome handles PROPFIND /dav/, describing the user's principal and
+// calendar home, and with depth 1 also the todo calendar
+func (h *CalDAVHandler) PropfindHome(c echo.Context) error {
+	requested, err := readPropfind(c.Request().Body)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid PROPFIND body",
+		})
+	}
+
+	user := c.Get("user").(*auth.User)
+	responses := []davResponse{propResponse(calDAVHome, requested, homeProps(user))}
+	if davDepth(c) > 0 {
+		todos, err := h.todoService.GetUserTodos(c.Request().Context(), user.ID)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		responses = append(responses, propResponse(calDAVTodos, requested, calendarProps(todos)))
+	}
+	return writeMultistatus(c, responses)
+}
+
+// PropfindTodos handles PROPFIND /dav/todos/, describing the todo calendar,
+// and with depth 1 also every todo in it
+func (h *CalDAVHandler) PropfindTodos(c echo.Context) error {
+	requested, err := readPropfind(c.Request().Body)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid PROPFIND body",
+		})
+	}
+
+	userID := c.Get("user_id").(string)
+	todos, err := h.todoService.GetUserTodos(c.Request().Context(), userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	responses := []davResponse{propResponse(calDAVTodos, requested, calendarProps(todos))}
+	if davDepth(c) > 0 {
+		for _, todo := range todos {
+			responses = append(responses, todoResponse(todo, requested))
+		}
+	}
+	return writeMultistatus(c, responses)
+}
+
+// PropfindTodo handles PROPFIND /dav/todos/:resource, describing a single todo
+func (h *CalDAVHandler) PropfindTodo(c echo.Context) error {
+	requested, err := readPropfind(c.Request().Body)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid PROPFIND body",
+		})
+	}
+
+	todo, ok := h.findTodo(c, c.Param("resource"))
+	if !ok {
+		return c.NoContent(http.StatusNotFound)
+	}
+	return writeMultistatus(c, []davResponse{todoResponse(todo, requested)})
+}
+
+// Report handles REPORT /dav/todos/, answering calendar-query and
+// calendar-multiget reports
+func (h *CalDAVHandler) Report(c echo.Context) error {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Response(), c.Request().Body, maxCalDAVBodySize))
+	if err != nil {
+		return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{
+			"error": "Request body is too large",
+		})
+	}
+
+	report, err := reportName(body)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid REPORT body",
+		})
+	}
+
+	switch report {
+	case xml.Name{Space: calDAVNS, Local: "calendar-query"}:
+		var query calendarQuery
+		if err := xml.Unmarshal(body, &query); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid calendar-query",
+			})
+		}
+		return h.calendarQuery(c, &query)
+	case xml.Name{Space: calDAVNS, Local: "calendar-multiget"}:
+		var multiget calendarMultiget
+		if err := xml.Unmarshal(body, &multiget); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid calendar-multiget",
+			})
+		}
+		return h.calendarMultiget(c, &multiget)
+	default:
+		return writeDAVError(c, http.StatusForbidden, xml.Name{Space: davNS, Local: "supported-report"})
+	}
+}
+
+// calendarQuery responds with the todos matching the filter of a
+// calendar-query. Time ranges and text matches are not supported and
+// match every todo.
+func (h *CalDAVHandler) calendarQuery(c echo.Context, query *calendarQuery) error {
+	userID := c.Get("user_id").(string)
+	todos, err := h.todoService.GetUserTodos(c.Request().Context(), userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	responses := []davResponse{}
+	for _, todo := range todos {
+		if matchesCompFilter(todo, &query.Filter.CompFilter) {
+			responses = append(responses, todoResponse(todo, query.Prop))
+		}
+	}
+	return writeMultistatus(c, responses)
+}
+
+// calendarMultiget responds with the todos named by the hrefs of a
+// calendar-multiget
+func (h *CalDAVHandler) calendarMultiget(c echo.Context, multiget *calendarMultiget) error {
+	responses := make([]davResponse, 0, len(multiget.Hrefs))
+	for _, href := range multiget.Hrefs {
+		href = strings.TrimSpace(href)
+		resource := href
+		if parsed, err := url.Parse(href); err == nil {
+			resource = parsed.Path
+		}
+
+		todo, ok := h.findTodo(c, path.Base(resource))
+		if !ok {
+			responses = append(responses, davResponse{Href: href, Status: davStatus(http.StatusNotFound)})
+			continue
+		}
+		responses = append(responses, todoResponse(todo, multiget.Prop))
+	}
+	return writeMultistatus(c, responses)
+}
+
+// GetTodo handles GET /dav/todos/:resource, returning a todo as a calendar
+// holding a single task
+func (h *CalDAVHandler) GetTodo(c echo.Context) error {
+	todo, ok := h.findTodo(c, c.Param("resource"))
+	if !ok {
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	setTodoETag(c, todo)
+	if !todo.UpdatedAt.IsZero() {
+		c.Response().Header().Set(echo.HeaderLastModified, todo.UpdatedAt.UTC().Format(http.TimeFormat))
+	}
+	return writeCalendar(c, todoCalendarResource(todo))
+}
+
+// PutTodo handles PUT /dav/todos/:resource, creating the todo if the
+// resource does not exist yet and replacing it otherwise. If-Match and
+// If-None-Match: * guard against overwriting changes made elsewhere.
+func (h *CalDAVHandler) PutTodo(c echo.Context) error {
+	todoID, ok := resourceTodoID(c.Param("resource"))
+	if !ok {
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": "Resource names must be a UUID followed by .ics",
+		})
+	}
+
+	cal, err := ical.NewDecoder(http.MaxBytesReader(c.Response(), c.Request().Body, maxCalDAVBodySize)).Decode()
+	if err != nil {
+		return writeDAVError(c, http.StatusForbidden, xml.Name{Space: calDAVNS, Local: "valid-calendar-data"})
+	}
+	replacement, err := services.ParseCalendarTask(cal)
+	if errors.Is(err, services.ErrNoCalendarTask) {
+		return writeDAVError(c, http.StatusForbidden, xml.Name{Space: calDAVNS, Local: "supported-calendar-component"})
+	}
+	if err != nil {
+		return writeDAVError(c, http.StatusForbidden, xml.Name{Space: calDAVNS, Local: "valid-calendar-object-resource"})
+	}
+
+	userID := c.Get("user_id").(string)
+	existing, err := h.todoService.GetTodo(c.Request().Context(), todoID, userID)
+	switch {
+	case errors.Is(err, repositories.ErrTodoNotFound):
+		return h.createTodo(c, todoID, userID, replacement)
+	case err != nil:
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	if c.Request().Header.Get("If-None-Match") == "*" || !ifMatchSatisfied(c, existing) {
+		setTodoETag(c, existing)
+		return c.NoContent(http.StatusPreconditionFailed)
+	}
+
+	// Calendars know nothing of projects
+	replacement.Project = existing.Project
+
+	todo, _, err := h.todoService.ReplaceTodo(c.Request().Context(), todoID, userID, replacement, existing.Version)
+	if err != nil {
+		if errors.Is(err, repositories.ErrConflict) {
+			return c.NoContent(http.StatusPreconditionFailed)
+		}
+		return writeTodoError(c, err)
+	}
+
+	setTodoETag(c, todo)
+	return c.NoContent(http.StatusNoContent)
+}
+
+// createTodo creates the todo uploaded to a new resource
+func (h *CalDAVHandler) createTodo(c echo.Context, todoID, userID string, todo *models.Todo) error {
+	if hasIfMatch(c) {
+		return c.NoContent(http.StatusPreconditionFailed)
+	}
+
+	now := time.Now()
+	todo.ID = todoID
+	todo.UserID = userID
+	todo.CreatedAt = now
+	todo.UpdatedAt = now
+	if err := h.todoService.CreateTodo(c.Request().Context(), todo); err != nil {
+		if errors.Is(err, repositories.ErrTodoExists) {
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		return writeTodoError(c, err)
+	}
+
+	setTodoETag(c, todo)
+	return c.NoContent(http.StatusCreated)
+}
+
+// DeleteTodo handles DELETE /dav/todos/:resource, moving the todo to the trash
+func (h *CalDAVHandler) DeleteTodo(c echo.Context) error {
+	todo, ok := h.findTodo(c, c.Param("resource"))
+	if !ok {
+		return c.NoContent(http.StatusNotFound)
+	}
+	if !ifMatchSatisfied(c, todo) {
+		setTodoETag(c, todo)
+		return c.NoContent(http.StatusPreconditionFailed)
+	}
+
+	userID := c.Get("user_id").(string)
+	if err := h.todoService.DeleteTodo(c.Request().Context(), todo.ID, userID, todo.Version); err != nil {
+		if errors.Is(err, repositories.ErrConflict) {
+			return c.NoContent(http.StatusPreconditionFailed)
+		}
+		return c.JSON(todoErrorStatus(err), map[string]string{
+			"error": err.Error(),
+		})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// findTodo looks up the user's todo stored at a resource, reporting whether
+// there is one
+func (h *CalDAVHandler) findTodo(c echo.Context, resource string) (*models.Todo, bool) {
+	todoID, ok := resourceTodoID(resource)
+	if !ok {
+		return nil, false
+	}
+	todo, err := h.todoService.GetTodo(c.Request().Context(), todoID, c.Get("user_id").(string))
+	if err != nil {
+		return nil, false
+	}
+	return todo, true
+}
+
+// writeTodoError responds to a todo that could not be stored, mostly
+// because the task lacks a summary or has an invalid recurrence
+func writeTodoError(c echo.Context, err error) error {
+	if isInvalidTodo(err) {
+		return writeDAVError(c, http.StatusForbidden, xml.Name{Space: calDAVNS, Local: "valid-calendar-object-resource"})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{
+		"error": err.Error(),
+	})
+}
+
+// resourceTodoID returns the ID of the todo stored at a resource named
+// "<id>.ics"
+func resourceTodoID(resource string) (string, bool) {
+	id, err := uuid.Parse(strings.TrimSuffix(resource, ".ics"))
+	if err != nil {
+		return "", false
+	}
+	return id.String(), true
+}
+
+// todoHref returns the path of the resource a todo is stored at
+func todoHref(todo *models.Todo) string {
+	return calDAVTodos + todo.ID + ".ics"
+}
+
+// todoCalendarResource returns the calendar stored at a todo's resource
+func todoCalendarResource(todo *models.Todo) *ical.Calendar {
+	return &ical.Calendar{
+		ProdID:     services.CalendarProdID,
+		Components: []*ical.Component{services.CalendarTask(todo)},
+	}
+}
+
+// homeProps returns the properties of the user's principal and calendar home
+func homeProps(user *auth.User) map[xml.Name]davElement {
+	return map[xml.Name]davElement{
+		propResourceType: davParent(propResourceType,
+			davEmpty(davNS, "collection"),
+			davEmpty(davNS, "principal"),
+		),
+		propDisplayName:           davText(propDisplayName, user.Email),
+		propCurrentUserPrincipal:  davHref(propCurrentUserPrincipal, calDAVHome),
+		propPrincipalURL:          davHref(propPrincipalURL, calDAVHome),
+		propCalendarHomeSet:       davHref(propCalendarHomeSet, calDAVHome),
+		propCurrentUserPrivileges: currentUserPrivileges(),
+	}
+}
+
+// calendarProps returns the properties of the todo calendar. Its ctag
+// changes whenever a todo is added, changed or removed.
+func calendarProps(todos []*models.Todo) map[xml.Name]davElement {
+	return map[xml.Name]davElement{
+		propResourceType: davParent(propResourceType,
+			davEmpty(davNS, "collection"),
+			davEmpty(calDAVNS, "calendar"),
+		),
+		propDisplayName:          davText(propDisplayName, services.CalendarName),
+		propCurrentUserPrincipal: davHref(propCurrentUserPrincipal, calDAVHome),
+		propSupportedComponents: davParent(propSupportedComponents, davElement{
+			XMLName: xml.Name{Space: calDAVNS, Local: "comp"},
+			Attrs:   []xml.Attr{{Name: xml.Name{Local: "name"}, Value: "VTODO"}},
+		}),
+		propSupportedReportSet: davParent(propSupportedReportSet,
+			supportedReport(calDAVNS, "calendar-query"),
+			supportedReport(calDAVNS, "calendar-multiget"),
+		),
+		propCurrentUserPrivileges: currentUserPrivileges(),
+		propGetCTag:               davText(propGetCTag, calendarCTag(todos)),
+	}
+}
+
+// todoResponse describes a todo. Its calendar data is only included when
+// asked for, as listing every property would otherwise send whole calendars.
+func todoResponse(todo *models.Todo, requested []xml.Name) davResponse {
+	values := map[xml.Name]davElement{
+		propResourceType:   {XMLName: propResourceType},
+		propGetETag:        davText(propGetETag, todoETag(todo)),
+		propGetContentType: davText(propGetContentType, calDAVTaskContentType),
+	}
+	if !todo.UpdatedAt.IsZero() {
+		values[propGetLastModified] = davText(propGetLastModified, todo.UpdatedAt.UTC().Format(http.TimeFormat))
+	}
+	for _, name := range requested {
+		if name == propCalendarData {
+			var buf bytes.Buffer
+			if err := ical.NewEncoder(&buf).Encode(todoCalendarResource(todo)); err == nil {
+				values[propCalendarData] = davText(propCalendarData, buf.String())
+			}
+		}
+	}
+	return propResponse(todoHref(todo), requested, values)
+}
+
+// currentUserPrivileges tells clients that the user may read and write
+func currentUserPrivileges() davElement {
+	privilege := func(local string) davElement {
+		return davParent(xml.Name{Space: davNS, Local: "privilege"}, davEmpty(davNS, local))
+	}
+	return davParent(propCurrentUserPrivileges, privilege("read"), privilege("write"))
+}
+
+// supportedReport describes a report in a supported-report-set
+func supportedReport(space, local string) davElement {
+	return davParent(xml.Name{Space: davNS, Local: "supported-report"},
+		davParent(xml.Name{Space: davNS, Local: "report"}, davEmpty(space, local)),
+	)
+}
+
+// calendarCTag summarises the versions of all todos in the calendar
+func calendarCTag(todos []*models.Todo) string {
+	versions := make([]string, 0, len(todos))
+	for _, todo := range todos {
+		versions = append(versions, todo.ID+":"+strconv.Itoa(todo.Version))
+	}
+	sort.Strings(versions)
+
+	sum := sha256.Sum256([]byte(strings.Join(versions, ",")))
+	return hex.EncodeToString(sum[:16])
+}
+
+// reportName returns the name of the root element of a REPORT body
+func reportName(body []byte) (xml.Name, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return xml.Name{}, err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name, nil
+		}
+	}
+}
+
+// matchesCompFilter reports whether a todo passes the comp-filter of a
+// calendar-query, which starts at the VCALENDAR. An empty filter matches
+// every todo.
+func matchesCompFilter(todo *models.Todo, filter *compFilter) bool {
+	if filter.Name == "" {
+		return true
+	}
+	if !strings.EqualFold(filter.Name, "VCALENDAR") || filter.IsNotDefined != nil {
+		return false
+	}
+
+	task := services.CalendarTask(todo)
+	for i := range filter.CompFilters {
+		if !matchesTaskFilter(task, &filter.CompFilters[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesTaskFilter reports whether a task passes a comp-filter nested in
+// the VCALENDAR. Filters on other components only pass when they ask for
+// the component not to be defined.
+func matchesTaskFilter(task *ical.Component, filter *compFilter) bool {
+	if !strings.EqualFold(filter.Name, task.Name) {
+		return filter.IsNotDefined != nil
+	}
+	if filter.IsNotDefined != nil {
+		return false
+	}
+
+	for _, propFilter := range filter.PropFilters {
+		defined := task.Get(propFilter.Name) != nil
+		if defined == (propFilter.IsNotDefined != nil) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,322 @@
+package handlers
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
+	"github.com/starbops/gottodo/internal/services"
+	"github.com/starbops/gottodo/pkg/auth"
+	"github.com/starbops/gottodo/pkg/config"
+)
+
+// calDAVTestServer serves the CalDAV routes for a registered user with an
+// app password
+type calDAVTestServer struct {
+	e           *echo.Echo
+	todoService *services.TodoService
+	user        *auth.User
+	password    string
+}
+
+func newCalDAVTestServer(t *testing.T) *calDAVTestServer {
+	t.Helper()
+	ctx := context.Background()
+
+	authService := auth.NewAuthService(&config.Config{})
+	user, err := authService.Register(ctx, "ann@example.com", "secret123")
+	if err != nil {
+		t.Fatalf("Failed to register user: %v", err)
+	}
+	appPasswordService := services.NewAppPasswordService(repositories.NewMemoryAppPasswordRepository())
+	_, password, err := appPasswordService.CreateAppPassword(ctx, user.ID, "Phone")
+	if err != nil {
+		t.Fatalf("Failed to create app password: %v", err)
+	}
+
+	todoService := services.NewTodoService(repositories.NewMemoryTodoRepository())
+	handler := NewCalDAVHandler(todoService, appPasswordService, authService)
+
+	e := echo.New()
+	e.GET("/.well-known/caldav", handler.WellKnown)
+	e.OPTIONS("/dav/", handler.Options)
+	e.Add("PROPFIND", "/dav/", handler.PropfindHome, handler.BasicAuth)
+	e.Add("PROPFIND", "/dav/todos/", handler.PropfindTodos, handler.BasicAuth)
+	e.Add("REPORT", "/dav/todos/", handler.Report, handler.BasicAuth)
+	e.Add("PROPFIND", "/dav/todos/:resource", handler.PropfindTodo, handler.BasicAuth)
+	e.GET("/dav/todos/:resource", handler.GetTodo, handler.BasicAuth)
+	e.PUT("/dav/todos/:resource", handler.PutTodo, handler.BasicAuth)
+	e.DELETE("/dav/todos/:resource", handler.DeleteTodo, handler.BasicAuth)
+
+	return &calDAVTestServer{e: e, todoService: todoService, user: user, password: password}
+}
+
+// serve sends a request authenticated with the user's app password, with
+// headers given as name and value pairs
+func (s *calDAVTestServer) serve(method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.SetBasicAuth(s.user.Email, s.password)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	rec := httptest.NewRecorder()
+	s.e.ServeHTTP(rec, req)
+	return rec
+}
+
+// createTodo creates a todo of the user
+func (s *calDAVTestServer) createTodo(t *testing.T, title string, completed bool) *models.Todo {
+	t.Helper()
+
+	todo := &models.Todo{Title: title, UserID: s.user.ID, Completed: completed}
+	if err := s.todoService.CreateTodo(context.Background(), todo); err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+	return todo
+}
+
+// multistatusOf decodes a 207 Multi-Status response
+func multistatusOf(t *testing.T, rec *httptest.ResponseRecorder) *davMultistatus {
+	t.Helper()
+
+	if rec.Code != http.StatusMultiStatus {
+		t.Fatalf("Expected 207, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var multistatus davMultistatus
+	if err := xml.Unmarshal(rec.Body.Bytes(), &multistatus); err != nil {
+		t.Fatalf("Failed to decode multistatus: %v", err)
+	}
+	return &multistatus
+}
+
+// hrefsOf lists the hrefs of a multistatus response
+func hrefsOf(multistatus *davMultistatus) []string {
+	hrefs := make([]string, 0, len(multistatus.Responses))
+	for _, response := range multistatus.Responses {
+		hrefs = append(hrefs, response.Href)
+	}
+	return hrefs
+}
+
+func TestCalDAVHandler_BasicAuth(t *testing.T) {
+	s := newCalDAVTestServer(t)
+
+	tests := []struct {
+		name     string
+		email    string
+		password string
+		want     int
+	}{
+		{name: "app password", email: s.user.Email, password: s.password, want: http.StatusMultiStatus},
+		{name: "account password", email: s.user.Email, password: "secret123", want: http.StatusUnauthorized},
+		{name: "unknown user", email: "bob@example.com", password: s.password, want: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("PROPFIND", "/dav/", nil)
+			req.SetBasicAuth(tt.email, tt.password)
+			rec := httptest.NewRecorder()
+			s.e.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("Expected %d, got %d", tt.want, rec.Code)
+			}
+			if tt.want == http.StatusUnauthorized && !strings.HasPrefix(rec.Header().Get(echo.HeaderWWWAuthenticate), "Basic ") {
+				t.Errorf("Expected a Basic challenge, got %q", rec.Header().Get(echo.HeaderWWWAuthenticate))
+			}
+		})
+	}
+
+	// Discovery works without logging in
+	rec := httptest.NewRecorder()
+	s.e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/.well-known/caldav", nil))
+	if rec.Code != http.StatusMovedPermanently || rec.Header().Get(echo.HeaderLocation) != "/dav/" {
+		t.Errorf("Expected a redirect to /dav/, got %d %q", rec.Code, rec.Header().Get(echo.HeaderLocation))
+	}
+	rec = httptest.NewRecorder()
+	s.e.ServeHTTP(rec, httptest.NewRequest(http.MethodOptions, "/dav/", nil))
+	if !strings.Contains(rec.Header().Get("DAV"), "calendar-access") {
+		t.Errorf("Expected CalDAV support to be advertised, got %q", rec.Header().Get("DAV"))
+	}
+}
+
+func TestCalDAVHandler_Propfind(t *testing.T) {
+	s := newCalDAVTestServer(t)
+	todo := s.createTodo(t, "Water plants", false)
+
+	// The principal leads to the calendar home, which holds the todo calendar
+	body := `<?xml version="1.0"?><d:propfind xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">` +
+		`<d:prop><d:current-user-principal/><c:calendar-home-set/><d:quota-used-bytes/></d:prop></d:propfind>`
+	multistatus := multistatusOf(t, s.serve("PROPFIND", "/dav/", body, "Depth", "0"))
+	if len(multistatus.Responses) != 1 {
+		t.Fatalf("Expected the home alone with depth 0, got %v", hrefsOf(multistatus))
+	}
+	propstats := multistatus.Responses[0].Propstat
+	if len(propstats) != 2 || !strings.Contains(propstats[0].Status, "200") || !strings.Contains(propstats[1].Status, "404") {
+		t.Fatalf("Expected found and missing properties, got %+v", propstats)
+	}
+	if home := propstats[0].Prop.Props[1]; home.XMLName != propCalendarHomeSet || home.Children[0].Text != "/dav/" {
+		t.Errorf("Expected /dav/ as the calendar home, got %+v", home)
+	}
+
+	// The calendar lists its todos, and its ctag follows their changes
+	rec := s.serve("PROPFIND", "/dav/todos/", "", "Depth", "1")
+	multistatus = multistatusOf(t, rec)
+	if got := hrefsOf(multistatus); len(got) != 2 || got[1] != "/dav/todos/"+todo.ID+".ics" {
+		t.Fatalf("Expected the calendar and its todo, got %v", got)
+	}
+	if !strings.Contains(rec.Body.String(), `<comp xmlns="urn:ietf:params:xml:ns:caldav" name="VTODO">`) {
+		t.Errorf("Expected VTODO as the supported component, got %s", rec.Body.String())
+	}
+	ctag := calendarCTag([]*models.Todo{todo})
+	if !strings.Contains(rec.Body.String(), ctag) {
+		t.Errorf("Expected the ctag %s, got %s", ctag, rec.Body.String())
+	}
+
+	if _, err := s.todoService.UpdateTodo(context.Background(), todo.ID, s.user.ID, "Water all plants", "", 0); err != nil {
+		t.Fatalf("Failed to update todo: %v", err)
+	}
+	if rec := s.serve("PROPFIND", "/dav/todos/", "", "Depth", "0"); strings.Contains(rec.Body.String(), ctag) {
+		t.Error("Expected the ctag to change with the todo")
+	}
+
+	// Todos of other users are not found
+	other := &models.Todo{Title: "Not mine", UserID: "user2"}
+	if err := s.todoService.CreateTodo(context.Background(), other); err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+	if rec := s.serve("PROPFIND", "/dav/todos/"+other.ID+".ics", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for another user's todo, got %d", rec.Code)
+	}
+}
+
+func TestCalDAVHandler_Report(t *testing.T) {
+	s := newCalDAVTestServer(t)
+	open := s.createTodo(t, "Open", false)
+	done := s.createTodo(t, "Done", true)
+
+	// A query for open tasks skips the completed todo
+	query := `<c:calendar-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">` +
+		`<d:prop><d:getetag/><c:calendar-data/></d:prop>` +
+		`<c:filter><c:comp-filter name="VCALENDAR"><c:comp-filter name="VTODO">` +
+		`<c:prop-filter name="COMPLETED"><c:is-not-defined/></c:prop-filter>` +
+		`</c:comp-filter></c:comp-filter></c:filter></c:calendar-query>`
+	rec := s.serve("REPORT", "/dav/todos/", query, "Depth", "1")
+	multistatus := multistatusOf(t, rec)
+	if got := hrefsOf(multistatus); len(got) != 1 || got[0] != todoHref(open) {
+		t.Fatalf("Expected only the open todo, got %v", got)
+	}
+	if !strings.Contains(rec.Body.String(), "SUMMARY:Open") {
+		t.Errorf("Expected the calendar data of the todo, got %s", rec.Body.String())
+	}
+
+	// Events are never found
+	events := strings.Replace(query, `name="VTODO"`, `name="VEVENT"`, 1)
+	if got := hrefsOf(multistatusOf(t, s.serve("REPORT", "/dav/todos/", events))); len(got) != 0 {
+		t.Errorf("Expected no events, got %v", got)
+	}
+
+	// A multiget answers every href, missing todos included
+	missing := "/dav/todos/" + uuid.New().String() + ".ics"
+	multiget := `<c:calendar-multiget xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">` +
+		`<d:prop><d:getetag/></d:prop><d:href>` + todoHref(done) + `</d:href><d:href>` + missing + `</d:href>` +
+		`</c:calendar-multiget>`
+	multistatus = multistatusOf(t, s.serve("REPORT", "/dav/todos/", multiget))
+	if len(multistatus.Responses) != 2 || multistatus.Responses[0].Href != todoHref(done) ||
+		!strings.Contains(multistatus.Responses[1].Status, "404") {
+		t.Errorf("Expected the completed todo and a missing one, got %+v", multistatus.Responses)
+	}
+
+	rec = s.serve("REPORT", "/dav/todos/", `<d:sync-collection xmlns:d="DAV:"/>`)
+	if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "supported-report") {
+		t.Errorf("Expected unsupported reports to be refused, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestCalDAVHandler_Resources(t *testing.T) {
+	s := newCalDAVTestServer(t)
+	id := uuid.New().String()
+	path := "/dav/todos/" + id + ".ics"
+	task := func(summary string) string {
+		return "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//test//EN\r\nBEGIN:VTODO\r\nUID:" + id +
+			"\r\nSUMMARY:" + summary + "\r\nDUE;VALUE=DATE:20250314\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"
+	}
+
+	// PUT creates a todo at a new resource
+	rec := s.serve(http.MethodPut, path, task("Pay rent"), "If-None-Match", "*")
+	if rec.Code != http.StatusCreated || rec.Header().Get("ETag") != `"1"` {
+		t.Fatalf("Expected 201 with an ETag, got %d %q: %s", rec.Code, rec.Header().Get("ETag"), rec.Body.String())
+	}
+	if rec := s.serve(http.MethodPut, path, task("Pay rent"), "If-None-Match", "*"); rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expected 412 when creating an existing todo, got %d", rec.Code)
+	}
+
+	rec = s.serve(http.MethodGet, path, "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "UID:"+id+"\r\n") ||
+		!strings.Contains(rec.Body.String(), "DUE;VALUE=DATE:20250314\r\n") {
+		t.Fatalf("Expected the todo as a task, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// PUT replaces the todo unless it changed meanwhile
+	title := "Pay the rent"
+	if _, _, err := s.todoService.PatchTodo(context.Background(), id, s.user.ID, &models.TodoPatch{Title: &title}, 0); err != nil {
+		t.Fatalf("Failed to patch todo: %v", err)
+	}
+	if rec := s.serve(http.MethodPut, path, task("Pay rent now"), "If-Match", `"1"`); rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expected 412 for a stale ETag, got %d", rec.Code)
+	}
+	rec = s.serve(http.MethodPut, path, task("Pay rent now"), "If-Match", `"2"`)
+	if rec.Code != http.StatusNoContent || rec.Header().Get("ETag") != `"3"` {
+		t.Fatalf("Expected 204 with the new ETag, got %d %q: %s", rec.Code, rec.Header().Get("ETag"), rec.Body.String())
+	}
+	todo, err := s.todoService.GetTodo(context.Background(), id, s.user.ID)
+	if err != nil || todo.Title != "Pay rent now" || todo.DueAt == nil {
+		t.Errorf("Expected the replaced todo, got %+v (%v)", todo, err)
+	}
+
+	// Calendars know nothing of projects, so replacing a todo keeps its project
+	filed := &models.Todo{ID: uuid.New().String(), Title: "Tidy", UserID: s.user.ID, Project: "Home"}
+	if err := s.todoService.CreateTodo(context.Background(), filed); err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+	if rec := s.serve(http.MethodPut, todoHref(filed), task("Tidy up")); rec.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if todo, err := s.todoService.GetTodo(context.Background(), filed.ID, s.user.ID); err != nil || todo.Title != "Tidy up" || todo.Project != "Home" {
+		t.Errorf("Expected the todo to stay in its project, got %+v (%v)", todo, err)
+	}
+
+	// Invalid uploads are refused
+	for name, body := range map[string]string{
+		"not a calendar": "hello",
+		"no task":        "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nSUMMARY:x\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n",
+		"no summary":     "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nUID:x\r\nEND:VTODO\r\nEND:VCALENDAR\r\n",
+		"bad recurrence": "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nSUMMARY:x\r\nRRULE:FREQ=SOMETIMES\r\nEND:VTODO\r\nEND:VCALENDAR\r\n",
+		"long category":  "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nSUMMARY:x\r\nCATEGORIES:" + strings.Repeat("x", 51) + "\r\nEND:VTODO\r\nEND:VCALENDAR\r\n",
+	} {
+		if rec := s.serve(http.MethodPut, "/dav/todos/"+uuid.New().String()+".ics", body); rec.Code != http.StatusForbidden {
+			t.Errorf("Expected 403 for %s, got %d", name, rec.Code)
+		}
+	}
+	if rec := s.serve(http.MethodPut, "/dav/todos/not-a-uuid.ics", task("x")); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a resource name that is no UUID, got %d", rec.Code)
+	}
+
+	// DELETE moves the todo to the trash
+	if rec := s.serve(http.MethodDelete, path, "", "If-Match", `"1"`); rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expected 412 for a stale ETag, got %d", rec.Code)
+	}
+	if rec := s.serve(http.MethodDelete, path, ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := s.serve(http.MethodGet, path, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a deleted todo, got %d", rec.Code)
+	}
+}
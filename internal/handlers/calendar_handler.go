@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
+	"github.com/starbops/gottodo/internal/services"
+	"github.com/starbops/gottodo/pkg/auth"
+	"github.com/starbops/gottodo/pkg/ical"
+	"github.com/starbops/gottodo/ui/templates"
+)
+
+// CalendarHandler handles HTTP requests for calendar feeds and downloads
+type CalendarHandler struct {
+	calendarService *services.CalendarService
+	todoService     *services.TodoService
+	baseURL         string
+}
+
+// NewCalendarHandler creates a new CalendarHandler for the server at baseURL
+func NewCalendarHandler(calendarService *services.CalendarService, todoService *services.TodoService, baseURL string) *CalendarHandler {
+	return &CalendarHandler{
+		calendarService: calendarService,
+		todoService:     todoService,
+		baseURL:         strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// CalendarURL is a user's calendar feed URL
+type CalendarURL struct {
+	URL string `json:"url"`
+
+	// WebcalURL is the feed URL with the webcal scheme, which makes
+	// browsers hand it over to a calendar app
+	WebcalURL string    `json:"webcal_url"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Feed handles GET /calendar/:feed, serving the todos of the feed's owner as
+// an iCalendar feed. Todos with a due date are events unless tasks=1 asks for
+// every todo as a task.
+func (h *CalendarHandler) Feed(c echo.Context) error {
+	secret := strings.TrimSuffix(c.Param("feed"), ".ics")
+
+	todos, err := h.calendarService.FeedTodos(c.Request().Context(), secret)
+	if err != nil {
+		if errors.Is(err, repositories.ErrCalendarFeedNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Unknown calendar feed",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	tasksOnly := c.QueryParam("tasks") == "1" || c.QueryParam("tasks") == "true"
+	return writeCalendar(c, services.TodoCalendar(todos, !tasksOnly))
+}
+
+// Download handles GET /todos.ics, downloading all the user's todos as tasks
+// in an iCalendar file
+func (h *CalendarHandler) Download(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+
+	todos, err := h.todoService.GetUserTodos(c.Request().Context(), userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="todos.ics"`)
+	return writeCalendar(c, services.TodoCalendar(todos, false))
+}
+
+// GetCalendarURL handles GET /calendar, rendering the calendar page for browsers
+func (h *CalendarHandler) GetCalendarURL(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+
+	feed, err := h.calendarService.GetCalendarFeed(c.Request().Context(), userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	calendarURL := h.calendarURLOf(feed)
+	if wantsJSON(c) {
+		return c.JSON(http.StatusOK, calendarURL)
+	}
+
+	user := c.Get("user").(*auth.User)
+	return templates.Calendar(calendarURL.URL, calendarURL.WebcalURL, user.Email).Render(c.Request().Context(), c.Response().Writer)
+}
+
+// RotateCalendarURL handles POST /calendar, replacing the user's calendar feed URL
+func (h *CalendarHandler) RotateCalendarURL(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+
+	feed, err := h.calendarService.RotateCalendarFeed(c.Request().Context(), userID)
+	if err != nil {
+		if wantsJSON(c) {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("Failed to rotate calendar URL: %v", err))
+	}
+
+	calendarURL := h.calendarURLOf(feed)
+	if wantsJSON(c) {
+		return c.JSON(http.StatusOK, calendarURL)
+	}
+	return templates.CalendarSettings(calendarURL.URL, calendarURL.WebcalURL).Render(c.Request().Context(), c.Response().Writer)
+}
+
+// calendarURLOf builds the absolute feed URLs of a calendar feed
+func (h *CalendarHandler) calendarURLOf(feed *models.CalendarFeed) *CalendarURL {
+	feedURL := h.baseURL + "/calendar/" + feed.Secret + ".ics"
+	return &CalendarURL{
+		URL:       feedURL,
+		WebcalURL: "webcal://" + strings.TrimPrefix(strings.TrimPrefix(feedURL, "https://"), "http://"),
+		CreatedAt: feed.CreatedAt,
+	}
+}
+
+// writeCalendar responds with an encoded calendar
+func writeCalendar(c echo.Context, cal *ical.Calendar) error {
+	var buf bytes.Buffer
+	if err := ical.NewEncoder(&buf).Encode(cal); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	return c.Blob(http.StatusOK, ical.ContentType, buf.Bytes())
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
+	"github.com/starbops/gottodo/internal/services"
+	"github.com/starbops/gottodo/pkg/ical"
+)
+
+// newCalendarTestServer serves the calendar routes, managing the calendar
+// feed of user1
+func newCalendarTestServer(t *testing.T) (*echo.Echo, *services.TodoService) {
+	t.Helper()
+
+	todoService := services.NewTodoService(repositories.NewMemoryTodoRepository())
+	feedRepo := repositories.NewMemoryCalendarFeedRepository()
+	calendarService := services.NewCalendarService(feedRepo, feedRepo, todoService)
+	handler := NewCalendarHandler(calendarService, todoService, "https://todo.example.com")
+
+	e := echo.New()
+	e.GET("/calendar/:feed", handler.Feed)
+	asUser := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("user_id", "user1")
+			return next(c)
+		}
+	}
+	e.GET("/calendar", handler.GetCalendarURL, asUser)
+	e.POST("/calendar", handler.RotateCalendarURL, asUser)
+	e.GET("/todos.ics", handler.Download, asUser)
+	return e, todoService
+}
+
+// calendarFeedPath requests the calendar feed URL of user1 and returns its path
+func calendarFeedPath(t *testing.T, e *echo.Echo, method string) string {
+	t.Helper()
+
+	rec := serveWebhookRequest(e, method, "/calendar", "", "", true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var calendarURL CalendarURL
+	if err := json.Unmarshal(rec.Body.Bytes(), &calendarURL); err != nil {
+		t.Fatalf("Failed to decode calendar URL: %v", err)
+	}
+	// The URL is built on the configured base URL, not the Host of the request
+	parsed, err := url.Parse(calendarURL.URL)
+	if err != nil || parsed.Scheme != "https" || parsed.Host != "todo.example.com" {
+		t.Fatalf("Expected an absolute feed URL on the base URL, got %q", calendarURL.URL)
+	}
+	if calendarURL.WebcalURL != "webcal://todo.example.com"+parsed.Path {
+		t.Errorf("Expected the webcal URL of the feed, got %q", calendarURL.WebcalURL)
+	}
+	return parsed.Path
+}
+
+func TestCalendarHandler_Feed(t *testing.T) {
+	e, todoService := newCalendarTestServer(t)
+	ctx := context.Background()
+
+	due := time.Date(2025, time.March, 14, 0, 0, 0, 0, time.UTC)
+	for _, todo := range []*models.Todo{
+		{Title: "Pay rent", UserID: "user1", DueAt: &due},
+		{Title: "Someday", UserID: "user1"},
+		{Title: "Not mine", UserID: "user2"},
+	} {
+		if err := todoService.CreateTodo(ctx, todo); err != nil {
+			t.Fatalf("Failed to create todo: %v", err)
+		}
+	}
+
+	path := calendarFeedPath(t, e, http.MethodGet)
+	if !strings.HasSuffix(path, ".ics") {
+		t.Errorf("Expected the feed URL to end in .ics, got %q", path)
+	}
+
+	rec := serveWebhookRequest(e, http.MethodGet, path, "", "", false)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get(echo.HeaderContentType); ct != ical.ContentType {
+		t.Errorf("Expected %q, got %q", ical.ContentType, ct)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{"BEGIN:VCALENDAR\r\n", "BEGIN:VEVENT\r\n", "SUMMARY:Pay rent\r\n", "DTSTART;VALUE=DATE:20250314\r\n", "BEGIN:VTODO\r\n", "SUMMARY:Someday\r\n"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %q in the feed, got %s", want, body)
+		}
+	}
+	if strings.Contains(body, "Not mine") {
+		t.Error("Expected only the todos of user1 in the feed")
+	}
+
+	// The feed works without the .ics suffix, and can list tasks only
+	rec = serveWebhookRequest(e, http.MethodGet, strings.TrimSuffix(path, ".ics")+"?tasks=1", "", "", false)
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "VEVENT") || !strings.Contains(rec.Body.String(), "DUE;VALUE=DATE:20250314\r\n") {
+		t.Errorf("Expected every todo as a task, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Rotating the URL disables the previous one
+	rotated := calendarFeedPath(t, e, http.MethodPost)
+	if rotated == path {
+		t.Fatal("Expected a new feed URL")
+	}
+	if rec := serveWebhookRequest(e, http.MethodGet, path, "", "", false); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for the old feed URL, got %d", rec.Code)
+	}
+	if rec := serveWebhookRequest(e, http.MethodGet, rotated, "", "", false); rec.Code != http.StatusOK {
+		t.Errorf("Expected 200 for the new feed URL, got %d", rec.Code)
+	}
+}
+
+func TestCalendarHandler_Download(t *testing.T) {
+	e, todoService := newCalendarTestServer(t)
+
+	due := time.Date(2025, time.March, 15, 16, 0, 0, 0, time.UTC)
+	todo := &models.Todo{Title: "Call Ann", UserID: "user1", DueAt: &due}
+	if err := todoService.CreateTodo(context.Background(), todo); err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+
+	rec := serveWebhookRequest(e, http.MethodGet, "/todos.ics", "", "", false)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if cd := rec.Header().Get(echo.HeaderContentDisposition); !strings.HasPrefix(cd, "attachment") {
+		t.Errorf("Expected an attachment, got %q", cd)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{"BEGIN:VTODO\r\n", "UID:" + todo.ID + "\r\n", "DUE:20250315T160000Z\r\n", "STATUS:NEEDS-ACTION\r\n"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %q in the download, got %s", want, body)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	echomiddleware "github.com/labstack/echo/v4/middleware"
+	"github.com/starbops/gottodo/internal/repositories"
+	"github.com/starbops/gottodo/internal/services"
+	"github.com/starbops/gottodo/pkg/auth"
+	"github.com/starbops/gottodo/ui/templates"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// DefaultCaptureRequestsPerMinute is how many captures a client address
+	// may send per minute
+	DefaultCaptureRequestsPerMinute = 30
+
+	// DefaultCaptureBurst is how many captures a client address may send at once
+	DefaultCaptureBurst = 10
+
+	// maxCaptureBodySize bounds the size of captured messages
+	maxCaptureBodySize = 64 << 10
+)
+
+// CaptureHandler handles HTTP requests for capture URLs
+type CaptureHandler struct {
+	captureService *services.CaptureService
+	baseURL        string
+	limiter        echo.MiddlewareFunc
+}
+
+// NewCaptureHandler creates a new CaptureHandler for the server at baseURL,
+// allowing requestsPerMinute captures per client address, with bursts of up
+// to burst requests
+func NewCaptureHandler(captureService *services.CaptureService, baseURL string, requestsPerMinute, burst int) *CaptureHandler {
+	if requestsPerMinute <= 0 {
+		requestsPerMinute = DefaultCaptureRequestsPerMinute
+	}
+	if burst <= 0 {
+		burst = DefaultCaptureBurst
+	}
+	// Seconds until the next request is allowed again, rounded up
+	retryAfter := strconv.Itoa((60 + requestsPerMinute - 1) / requestsPerMinute)
+
+	store := echomiddleware.NewRateLimiterMemoryStoreWithConfig(echomiddleware.RateLimiterMemoryStoreConfig{
+		Rate:      rate.Limit(float64(requestsPerMinute) / 60),
+		Burst:     burst,
+		ExpiresIn: 10 * time.Minute,
+	})
+
+	return &CaptureHandler{
+		captureService: captureService,
+		baseURL:        strings.TrimSuffix(baseURL, "/"),
+		limiter: echomiddleware.RateLimiterWithConfig(echomiddleware.RateLimiterConfig{
+			Store: store,
+			// Limit each client rather than each capture URL, which would
+			// let guessing clients allocate a limit per guessed secret
+			IdentifierExtractor: func(c echo.Context) (string, error) {
+				return c.RealIP(), nil
+			},
+			DenyHandler: func(c echo.Context, identifier string, err error) error {
+				c.Response().Header().Set("Retry-After", retryAfter)
+				return c.JSON(http.StatusTooManyRequests, map[string]string{
+					"error": "Too many captures, try again later",
+				})
+			},
+		}),
+	}
+}
+
+// CaptureRequest represents a todo sent to a capture URL. Email gateways
+// send a subject and body, which are parsed when no title is given.
+type CaptureRequest struct {
+	Title       string `json:"title" form:"title"`
+	Description string `json:"description" form:"description"`
+	Subject     string `json:"subject" form:"subject"`
+	Body        string `json:"body" form:"body"`
+}
+
+// CaptureURL is a user's capture URL
+type CaptureURL struct {
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RateLimit limits the capture requests of each client address
+func (h *CaptureHandler) RateLimit(next echo.HandlerFunc) echo.HandlerFunc {
+	return h.limiter(next)
+}
+
+// Capture handles POST /capture/:secret, creating a todo from a plain text,
+// JSON or form body
+func (h *CaptureHandler) Capture(c echo.Context) error {
+	req := c.Request()
+	req.Body = http.MaxBytesReader(c.Response(), req.Body, maxCaptureBodySize)
+
+	message, err := readCaptureMessage(c)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{
+				"error": "Message is too large",
+			})
+		}
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	todo, err := h.captureService.Capture(req.Context(), c.Param("secret"), message)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrCaptureSecretNotFound):
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Unknown capture URL",
+			})
+		case errors.Is(err, services.ErrEmptyTitle):
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Title is required",
+			})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": err.Error(),
+			})
+		}
+	}
+
+	return c.JSON(http.StatusCreated, todo)
+}
+
+// GetCaptureURL handles GET /capture, rendering the capture page for browsers
+func (h *CaptureHandler) GetCaptureURL(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+
+	secret, err := h.captureService.GetCaptureSecret(c.Request().Context(), userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	captureURL := h.captureURLOf(secret.Secret, secret.CreatedAt)
+	if wantsJSON(c) {
+		return c.JSON(http.StatusOK, captureURL)
+	}
+
+	user := c.Get("user").(*auth.User)
+	return templates.Capture(captureURL.URL, user.Email).Render(c.Request().Context(), c.Response().Writer)
+}
+
+// RotateCaptureURL handles POST /capture, replacing the user's capture URL
+func (h *CaptureHandler) RotateCaptureURL(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+
+	secret, err := h.captureService.RotateCaptureSecret(c.Request().Context(), userID)
+	if err != nil {
+		if wantsJSON(c) {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("Failed to rotate capture URL: %v", err))
+	}
+
+	captureURL := h.captureURLOf(secret.Secret, secret.CreatedAt)
+	if wantsJSON(c) {
+		return c.JSON(http.StatusOK, captureURL)
+	}
+	return templates.CaptureSettings(captureURL.URL).Render(c.Request().Context(), c.Response().Writer)
+}
+
+// captureURLOf builds the absolute capture URL of a secret
+func (h *CaptureHandler) captureURLOf(secret string, createdAt time.Time) *CaptureURL {
+	return &CaptureURL{
+		URL:       h.baseURL + "/capture/" + secret,
+		CreatedAt: createdAt,
+	}
+}
+
+// readCaptureMessage reads the todo from a capture request body
+func readCaptureMessage(c echo.Context) (services.CaptureMessage, error) {
+	var req CaptureRequest
+
+	mediaType, _, _ := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	switch mediaType {
+	case echo.MIMEApplicationJSON:
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			return services.CaptureMessage{}, err
+		}
+	case echo.MIMEApplicationForm, echo.MIMEMultipartForm:
+		if err := (&echo.DefaultBinder{}).BindBody(c, &req); err != nil {
+			return services.CaptureMessage{}, err
+		}
+	default:
+		// Anything else is plain text: a title line followed by the description
+		text, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return services.CaptureMessage{}, err
+		}
+		req.Body = string(text)
+	}
+
+	if req.Title != "" {
+		return services.CaptureMessage{Title: req.Title, Description: req.Description}, nil
+	}
+	return services.ParseCaptureMessage(req.Subject, req.Body), nil
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
+	"github.com/starbops/gottodo/internal/services"
+)
+
+// newCaptureTestServer serves the capture routes, managing the capture URL
+// of user1
+func newCaptureTestServer(t *testing.T, requestsPerMinute, burst int) (*echo.Echo, *services.TodoService) {
+	t.Helper()
+
+	todoService := services.NewTodoService(repositories.NewMemoryTodoRepository())
+	captureRepo := repositories.NewMemoryCaptureRepository()
+	handler := NewCaptureHandler(services.NewCaptureService(captureRepo, captureRepo, todoService), "https://todo.example.com/", requestsPerMinute, burst)
+
+	e := echo.New()
+	e.POST("/capture/:secret", handler.Capture, handler.RateLimit)
+	asUser := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("user_id", "user1")
+			return next(c)
+		}
+	}
+	e.GET("/capture", handler.GetCaptureURL, asUser)
+	e.POST("/capture", handler.RotateCaptureURL, asUser)
+	return e, todoService
+}
+
+// captureURLPath requests the capture URL of user1 and returns its path
+func captureURLPath(t *testing.T, e *echo.Echo, method string) string {
+	t.Helper()
+
+	req := httptest.NewRequest(method, "/capture", nil)
+	req.Header.Set(echo.HeaderAccept, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var captureURL CaptureURL
+	if err := json.Unmarshal(rec.Body.Bytes(), &captureURL); err != nil {
+		t.Fatalf("Failed to decode capture URL: %v", err)
+	}
+	// The URL is built on the configured base URL, not the Host of the request
+	parsed, err := url.Parse(captureURL.URL)
+	if err != nil || parsed.Scheme != "https" || parsed.Host != "todo.example.com" {
+		t.Fatalf("Expected an absolute capture URL on the base URL, got %q", captureURL.URL)
+	}
+	return parsed.Path
+}
+
+// capture posts a body to a capture URL path
+func capture(e *echo.Echo, path, contentType, body string) *httptest.ResponseRecorder {
+	return captureFrom(e, "192.0.2.1:1234", path, contentType, body)
+}
+
+// captureFrom posts a body to a capture URL path from a client address
+func captureFrom(e *echo.Echo, remoteAddr, path, contentType, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.RemoteAddr = remoteAddr
+	if contentType != "" {
+		req.Header.Set(echo.HeaderContentType, contentType)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCaptureHandler_Capture(t *testing.T) {
+	e, todoService := newCaptureTestServer(t, 600, 100)
+	path := captureURLPath(t, e, http.MethodGet)
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantTitle   string
+		wantDesc    string
+	}{
+		{name: "plain text", contentType: "text/plain; charset=utf-8", body: "Buy milk\nTwo litres", wantTitle: "Buy milk", wantDesc: "Two litres"},
+		{name: "no content type", body: "Water plants", wantTitle: "Water plants"},
+		{name: "JSON", contentType: echo.MIMEApplicationJSON, body: `{"title":"Book flights","description":"Window seat"}`, wantTitle: "Book flights", wantDesc: "Window seat"},
+		{name: "JSON email", contentType: echo.MIMEApplicationJSON, body: `{"subject":"Fwd: Pay rent","body":"Due Friday\n-- \nSent from my phone"}`, wantTitle: "Pay rent", wantDesc: "Due Friday"},
+		{name: "form", contentType: echo.MIMEApplicationForm, body: url.Values{"subject": {"Re: Call Sam"}, "body": {"About the move"}}.Encode(), wantTitle: "Call Sam", wantDesc: "About the move"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := capture(e, path, tt.contentType, tt.body)
+			if rec.Code != http.StatusCreated {
+				t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body.String())
+			}
+			var todo models.Todo
+			if err := json.Unmarshal(rec.Body.Bytes(), &todo); err != nil {
+				t.Fatalf("Failed to decode todo: %v", err)
+			}
+			if todo.Title != tt.wantTitle || todo.Description != tt.wantDesc || todo.UserID != "user1" {
+				t.Errorf("Expected %q / %q for user1, got %q / %q for %s", tt.wantTitle, tt.wantDesc, todo.Title, todo.Description, todo.UserID)
+			}
+		})
+	}
+
+	todos, _ := todoService.GetUserTodos(context.Background(), "user1")
+	if len(todos) != len(tests) {
+		t.Errorf("Expected %d captured todos, got %d", len(tests), len(todos))
+	}
+
+	if rec := capture(e, path, "text/plain", "  \n"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an empty message, got %d", rec.Code)
+	}
+	if rec := capture(e, path, echo.MIMEApplicationJSON, "{"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid JSON, got %d", rec.Code)
+	}
+	if rec := capture(e, path, "text/plain", strings.Repeat("x", maxCaptureBodySize+1)); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413 for an oversized message, got %d", rec.Code)
+	}
+
+	// Rotating the URL disables the previous one
+	rotated := captureURLPath(t, e, http.MethodPost)
+	if rotated == path {
+		t.Fatal("Expected a new capture URL")
+	}
+	if rec := capture(e, path, "text/plain", "Too late"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for the old capture URL, got %d", rec.Code)
+	}
+	if rec := capture(e, rotated, "text/plain", "Just in time"); rec.Code != http.StatusCreated {
+		t.Errorf("Expected 201 for the new capture URL, got %d", rec.Code)
+	}
+}
+
+func TestCaptureHandler_RateLimit(t *testing.T) {
+	e, _ := newCaptureTestServer(t, 1, 2)
+	path := captureURLPath(t, e, http.MethodGet)
+
+	for i := 0; i < 2; i++ {
+		if rec := capture(e, path, "text/plain", "Burst"); rec.Code != http.StatusCreated {
+			t.Fatalf("Expected the burst to be accepted, got %d", rec.Code)
+		}
+	}
+
+	rec := capture(e, path, "text/plain", "Flood")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429 after the burst, got %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") != "60" {
+		t.Errorf("Expected Retry-After of 60 seconds, got %q", rec.Header().Get("Retry-After"))
+	}
+
+	// Guessing other capture URLs counts against the same limit
+	if rec := capture(e, "/capture/cap_other", "text/plain", "Elsewhere"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected other URLs to be limited for the client, got %d", rec.Code)
+	}
+
+	// Other clients have their own limit
+	if rec := captureFrom(e, "198.51.100.7:1234", path, "text/plain", "Elsewhere"); rec.Code != http.StatusCreated {
+		t.Errorf("Expected other clients not to be limited, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/starbops/gottodo/internal/openapi"
+)
+
+// docsPage renders the OpenAPI document without loading anything from the network
+//
+//go:embed docs.html
+var docsPage []byte
+
+// DocsHandler serves the OpenAPI document and its documentation page
+type DocsHandler struct {
+	spec *openapi.Document
+}
+
+// NewDocsHandler creates a new DocsHandler
+func NewDocsHandler() *DocsHandler {
+	return &DocsHandler{
+		spec: OpenAPISpec(),
+	}
+}
+
+// OpenAPI handles GET /openapi.json
+func (h *DocsHandler) OpenAPI(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.spec)
+}
+
+// Docs handles GET /docs
+func (h *DocsHandler) Docs(c echo.Context) error {
+	return c.HTMLBlob(http.StatusOK, docsPage)
+}
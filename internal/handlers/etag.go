@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/starbops/gottodo/internal/models"
+)
+
+// todoETag returns the entity tag describing the current version of a todo
+func todoETag(todo *models.Todo) string {
+	return `"` + strconv.Itoa(todo.Version) + `"`
+}
+
+// setTodoETag sets the ETag response header for a todo
+func setTodoETag(c echo.Context, todo *models.Todo) {
+	c.Response().Header().Set("ETag", todoETag(todo))
+}
+
+// hasIfMatch reports whether the request carries an If-Match precondition
+func hasIfMatch(c echo.Context) bool {
+	return strings.TrimSpace(c.Request().Header.Get("If-Match")) != ""
+}
+
+// ifMatchVersion returns the todo version required by the If-Match header.
+// It returns 0 when the header is absent or "*", meaning any version is
+// acceptable, and -1 when the header names no version we could have issued.
+// If-Match uses the strong comparison (RFC 9110, section 13.1.1), so weak
+// entity tags never match.
+func ifMatchVersion(c echo.Context) int {
+	header := strings.TrimSpace(c.Request().Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return 0
+	}
+
+	// Only a single strong entity tag is ever issued per todo
+	if !strings.HasPrefix(header, `"`) || !strings.HasSuffix(header, `"`) || len(header) < 2 {
+		return -1
+	}
+	version, err := strconv.Atoi(header[1 : len(header)-1])
+	if err != nil || version <= 0 {
+		return -1
+	}
+	return version
+}
+
+// ifMatchSatisfied reports whether the If-Match header allows changing the todo
+func ifMatchSatisfied(c echo.Context, todo *models.Todo) bool {
+	version := ifMatchVersion(c)
+	return version == 0 || version == todo.Version
+}
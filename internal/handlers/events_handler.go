@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/starbops/gottodo/internal/services"
+	"github.com/starbops/gottodo/ui/templates"
+)
+
+// DefaultHeartbeat is how often idle event streams send a keep-alive comment
+const DefaultHeartbeat = 15 * time.Second
+
+// Server-sent event names the dashboard listens for. Updates and deletions
+// are scoped to a single todo so each TodoItem only reacts to its own.
+const (
+	eventTodoCreated = "todo-created"
+	eventTodoUpdated = "todo-updated-"
+	eventTodoDeleted = "todo-deleted-"
+	eventTodoReset   = "todo-reset"
+)
+
+// EventsHandler streams live todo changes as server-sent events
+type EventsHandler struct {
+	hub       *services.EventHub
+	heartbeat time.Duration
+}
+
+// NewEventsHandler creates a new EventsHandler
+func NewEventsHandler(hub *services.EventHub, heartbeat time.Duration) *EventsHandler {
+	if heartbeat <= 0 {
+		heartbeat = DefaultHeartbeat
+	}
+
+	return &EventsHandler{
+		hub:       hub,
+		heartbeat: heartbeat,
+	}
+}
+
+// Stream handles GET /events. Clients reconnecting with a Last-Event-ID
+// header receive the events they missed, or a todo-reset event when those
+// are no longer available or the ID was issued by another instance.
+func (h *EventsHandler) Stream(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+	ctx := c.Request().Context()
+
+	// Subscribe before replaying so no event falls in between
+	sub := h.hub.Subscribe(userID)
+	defer sub.Close()
+
+	w := c.Response()
+	w.Header().Set(echo.HeaderContentType, "text/event-stream")
+	w.Header().Set(echo.HeaderCacheControl, "no-cache")
+	w.Header().Set(echo.HeaderConnection, "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	var lastEventID uint64
+	if header := c.Request().Header.Get("Last-Event-ID"); header != "" {
+		var missed []*services.TodoEvent
+		var ok bool
+		lastEventID, ok = h.parseEventID(header)
+		if ok {
+			missed, ok = h.hub.Since(userID, lastEventID)
+		}
+		if !ok {
+			// The reloaded list covers everything published so far
+			lastEventID = h.hub.LastID()
+			if err := writeEvent(w, h.formatEventID(lastEventID), eventTodoReset, "reset"); err != nil {
+				return nil
+			}
+		}
+		for _, event := range missed {
+			if err := h.send(c, event); err != nil {
+				return nil
+			}
+			lastEventID = event.ID
+		}
+	}
+	w.Flush()
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return nil
+			}
+			w.Flush()
+		case event, ok := <-sub.Events():
+			if !ok {
+				// Dropped for falling behind; the client reconnects and catches up
+				return nil
+			}
+			if event.ID <= lastEventID {
+				continue
+			}
+			if err := h.send(c, event); err != nil {
+				return nil
+			}
+			lastEventID = event.ID
+			w.Flush()
+		}
+	}
+}
+
+// send writes a todo event, rendering the changed todo as a TodoItem
+func (h *EventsHandler) send(c echo.Context, event *services.TodoEvent) error {
+	id := h.formatEventID(event.ID)
+	w := c.Response()
+
+	switch event.Type {
+	case services.TodoEventReset:
+		return writeEvent(w, id, eventTodoReset, "reset")
+	case services.TodoEventDeleted:
+		return writeEvent(w, id, eventTodoDeleted+event.TodoID, event.TodoID)
+	case services.TodoEventCreated, services.TodoEventUpdated:
+		var buf bytes.Buffer
+		if err := templates.TodoItem(event.Todo).Render(c.Request().Context(), &buf); err != nil {
+			log.Printf("Failed to render %s event for todo %s: %v", event.Type, event.TodoID, err)
+			return nil
+		}
+		name := eventTodoCreated
+		if event.Type == services.TodoEventUpdated {
+			name = eventTodoUpdated + event.TodoID
+		}
+		return writeEvent(w, id, name, buf.String())
+	default:
+		return nil
+	}
+}
+
+// formatEventID qualifies an event ID with the hub instance that issued it
+func (h *EventsHandler) formatEventID(id uint64) string {
+	return h.hub.Instance() + "-" + strconv.FormatUint(id, 10)
+}
+
+// parseEventID reads an event ID, reporting false for IDs issued by another
+// instance or a previous run of the server
+func (h *EventsHandler) parseEventID(value string) (uint64, bool) {
+	instance, id, found := strings.Cut(value, "-")
+	if !found || instance != h.hub.Instance() {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(id, 10, 64)
+	return n, err == nil
+}
+
+// writeEvent writes a single server-sent event, splitting multi-line data
+// into several data fields
+func writeEvent(w io.Writer, id, name, data string) error {
+	var b strings.Builder
+	if id != "" {
+		fmt.Fprintf(&b, "id: %s\n", id)
+	}
+	fmt.Fprintf(&b, "event: %s\n", name)
+	for _, line := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		fmt.Fprintf(&b, "data: %s\n", line)
+	}
+	b.WriteString("\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/services"
+)
+
+// sseEvent is a server-sent event as seen by a client
+type sseEvent struct {
+	id, name, data string
+}
+
+// readEvent reads the next event from a stream, skipping comments
+func readEvent(t *testing.T, r *bufio.Reader) sseEvent {
+	t.Helper()
+
+	var event sseEvent
+	var data []string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read event: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+
+		switch {
+		case line == "":
+			if event.name == "" {
+				continue
+			}
+			event.data = strings.Join(data, "\n")
+			return event
+		case strings.HasPrefix(line, ":"):
+		case strings.HasPrefix(line, "id: "):
+			event.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			event.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = append(data, strings.TrimPrefix(line, "data: "))
+		}
+	}
+}
+
+// openStream connects to the events endpoint as user1
+func openStream(t *testing.T, server *httptest.Server, lastEventID string) *bufio.Reader {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/events", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+
+	if ct := resp.Header.Get(echo.HeaderContentType); ct != "text/event-stream" {
+		t.Fatalf("Expected an event stream, got %q", ct)
+	}
+	return bufio.NewReader(resp.Body)
+}
+
+func TestEventsHandler_Stream(t *testing.T) {
+	hub := services.NewEventHub(services.WithEventReplaySize(2))
+	handler := NewEventsHandler(hub, 10*time.Millisecond)
+
+	e := echo.New()
+	e.GET("/events", handler.Stream, func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("user_id", "user1")
+			return next(c)
+		}
+	})
+	// Registered first so it runs after the streams are closed
+	server := httptest.NewServer(e)
+	t.Cleanup(server.Close)
+
+	todo := &models.Todo{ID: "todo1", UserID: "user1", Title: "Live", Version: 1}
+	stream := openStream(t, server, "")
+
+	// Idle streams are kept alive with heartbeat comments
+	if line, err := stream.ReadString('\n'); err != nil || line != ": heartbeat\n" {
+		t.Fatalf("Expected a heartbeat, got %q (%v)", line, err)
+	}
+
+	hub.Publish(&services.TodoEvent{Type: services.TodoEventCreated, UserID: "user1", TodoID: "todo1", Todo: todo})
+	hub.Publish(&services.TodoEvent{Type: services.TodoEventCreated, UserID: "user2", TodoID: "todo2", Todo: todo})
+	hub.Publish(&services.TodoEvent{Type: services.TodoEventUpdated, UserID: "user1", TodoID: "todo1", Todo: todo})
+	hub.Publish(&services.TodoEvent{Type: services.TodoEventDeleted, UserID: "user1", TodoID: "todo1"})
+
+	id := func(n int) string {
+		return fmt.Sprintf("%s-%d", hub.Instance(), n)
+	}
+
+	created := readEvent(t, stream)
+	if created.id != id(1) || created.name != "todo-created" || !strings.Contains(created.data, `id="todo-todo1"`) {
+		t.Errorf("Expected the created todo item, got %+v", created)
+	}
+	updated := readEvent(t, stream)
+	if updated.id != id(3) || updated.name != "todo-updated-todo1" {
+		t.Errorf("Expected the update of todo1, got %+v", updated)
+	}
+	deleted := readEvent(t, stream)
+	if deleted.id != id(4) || deleted.name != "todo-deleted-todo1" || deleted.data != "todo1" {
+		t.Errorf("Expected the deletion of todo1, got %+v", deleted)
+	}
+
+	// Reconnecting replays the missed events
+	replayed := readEvent(t, openStream(t, server, id(3)))
+	if replayed.id != id(4) || replayed.name != "todo-deleted-todo1" {
+		t.Errorf("Expected the deletion to be replayed, got %+v", replayed)
+	}
+
+	// IDs of another instance or a previous run make the client reload
+	reset := readEvent(t, openStream(t, server, "elsewhere-3"))
+	if reset.id != id(4) || reset.name != "todo-reset" {
+		t.Errorf("Expected a reset, got %+v", reset)
+	}
+
+	// So do lost events, also on open streams
+	hub.Reset()
+	if event := readEvent(t, stream); event.id != id(5) || event.name != "todo-reset" {
+		t.Errorf("Expected a reset on the open stream, got %+v", event)
+	}
+	if event := readEvent(t, openStream(t, server, id(4))); event.name != "todo-reset" {
+		t.Errorf("Expected events before the reset to be unavailable, got %+v", event)
+	}
+}
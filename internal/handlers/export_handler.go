@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+
+	"github.com/labstack/echo/v4"
+	"github.com/starbops/gottodo/internal/services"
+)
+
+// ExportHandler handles HTTP requests for exporting the todos of a user
+type ExportHandler struct {
+	todoService *services.TodoService
+}
+
+// NewExportHandler creates a new ExportHandler
+func NewExportHandler(todoService *services.TodoService) *ExportHandler {
+	return &ExportHandler{
+		todoService: todoService,
+	}
+}
+
+// Export handles GET /export, downloading all todos of the user in the
+// format given by the format query parameter, JSON by default. The export
+// is streamed as it is read, so errors after the first todo can only cut it
+// short.
+func (h *ExportHandler) Export(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+
+	format := services.ExportFormat(c.QueryParam("format"))
+	if format == "" {
+		format = services.ExportJSON
+	}
+	if !slices.Contains(services.ExportFormats, format) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Choose a format: json, csv, markdown or todo.txt",
+		})
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, format.ContentType())
+	res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, format.FileName()))
+
+	if err := h.todoService.Export(c.Request().Context(), userID, format, res); err != nil {
+		if !res.Committed {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		log.Printf("Failed to export todos of user %s: %v", userID, err)
+	}
+	return nil
+}
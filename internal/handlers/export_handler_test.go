@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
+	"github.com/starbops/gottodo/internal/services"
+)
+
+// newExportTestServer serves the export route, exporting the todos of user1
+func newExportTestServer(t *testing.T) *echo.Echo {
+	t.Helper()
+
+	todoService := services.NewTodoService(repositories.NewMemoryTodoRepository())
+	for _, title := range []string{"Buy milk", "Call Ann"} {
+		if err := todoService.CreateTodo(context.Background(), &models.Todo{UserID: "user1", Title: title}); err != nil {
+			t.Fatalf("Failed to create todo: %v", err)
+		}
+	}
+	handler := NewExportHandler(todoService)
+
+	e := echo.New()
+	e.GET("/export", handler.Export, func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("user_id", "user1")
+			return next(c)
+		}
+	})
+	return e
+}
+
+func TestExportHandler_Export(t *testing.T) {
+	e := newExportTestServer(t)
+
+	// JSON is the default
+	rec := serveWebhookRequest(e, http.MethodGet, "/export", "", "", false)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get(echo.HeaderContentDisposition); got != `attachment; filename="todos.json"` {
+		t.Errorf("Expected the export as an attachment, got %q", got)
+	}
+	var exported struct {
+		Todos []services.ExportedTodo `json:"todos"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &exported); err != nil || len(exported.Todos) != 2 {
+		t.Errorf("Expected both todos, got %s (%v)", rec.Body.String(), err)
+	}
+
+	for format, want := range map[string]string{
+		"csv":      "id,title,description,",
+		"markdown": "- [ ] Buy milk\n",
+		"todo.txt": "Buy milk",
+	} {
+		rec = serveWebhookRequest(e, http.MethodGet, "/export?format="+format, "", "", false)
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("Expected the %s export, got %d: %s", format, rec.Code, rec.Body.String())
+		}
+		if got, want := rec.Header().Get(echo.HeaderContentType), services.ExportFormat(format).ContentType(); got != want {
+			t.Errorf("Expected %s exports as %q, got %q", format, want, got)
+		}
+	}
+
+	rec = serveWebhookRequest(e, http.MethodGet, "/export?format=pdf", "", "", false)
+	if rec.Code != http.StatusBadRequest || rec.Header().Get(echo.HeaderContentDisposition) != "" {
+		t.Errorf("Expected 400 for an unknown format, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
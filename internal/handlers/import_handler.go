@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/starbops/gottodo/internal/services"
+	"github.com/starbops/gottodo/pkg/auth"
+	"github.com/starbops/gottodo/pkg/todoimport"
+	"github.com/starbops/gottodo/ui/templates"
+)
+
+// maxImportBodySize bounds the size of uploaded exports
+const maxImportBodySize = 5 << 20
+
+// ImportHandler handles HTTP requests for importing todos from other apps
+type ImportHandler struct {
+	importService *services.ImportService
+}
+
+// NewImportHandler creates a new ImportHandler
+func NewImportHandler(importService *services.ImportService) *ImportHandler {
+	return &ImportHandler{
+		importService: importService,
+	}
+}
+
+// ImportFormats lists what can be imported
+type ImportFormats struct {
+	Formats []todoimport.Format `json:"formats"`
+
+	// Fields are the todo fields CSV columns can be mapped to with the
+	// column_<field> parameters
+	Fields []string `json:"fields"`
+}
+
+// GetImport handles GET /import, rendering the import page for browsers
+func (h *ImportHandler) GetImport(c echo.Context) error {
+	if wantsJSON(c) {
+		return c.JSON(http.StatusOK, ImportFormats{Formats: todoimport.Formats, Fields: todoimport.Fields})
+	}
+
+	user := c.Get("user").(*auth.User)
+	return templates.Import(user.Email).Render(c.Request().Context(), c.Response().Writer)
+}
+
+// Import handles POST /import. The export is uploaded as the file of a
+// multipart form, or as the raw request body with the other parameters in
+// the query. With dry_run=1 nothing is imported, and the response previews
+// what would be.
+func (h *ImportHandler) Import(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+	req := c.Request()
+	req.Body = http.MaxBytesReader(c.Response(), req.Body, maxImportBodySize)
+
+	file, err := importFile(c)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return h.renderError(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("The file is too large, the limit is %d MB", maxImportBodySize>>20))
+		}
+		return h.renderError(c, http.StatusBadRequest, "Choose a file to import")
+	}
+	defer file.Close()
+
+	opts := services.ImportOptions{
+		DryRun:  c.FormValue("dry_run") == "1" || c.FormValue("dry_run") == "true",
+		Columns: make(map[string]string),
+		Project: c.FormValue("project"),
+	}
+	for _, field := range todoimport.Fields {
+		if header := c.FormValue("column_" + field); header != "" {
+			opts.Columns[field] = header
+		}
+	}
+	if loc, err := time.LoadLocation(c.FormValue("time_zone")); err == nil {
+		opts.Location = loc
+	}
+
+	result, err := h.importService.Import(req.Context(), userID, todoimport.Format(c.FormValue("format")), file, opts)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			return h.renderError(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("The file is too large, the limit is %d MB", maxImportBodySize>>20))
+		case errors.Is(err, todoimport.ErrUnknownFormat):
+			return h.renderError(c, http.StatusBadRequest, "Choose a format: todotxt, csv, todoist, mstodo or json")
+		case errors.Is(err, services.ErrInvalidImport):
+			return h.renderError(c, http.StatusBadRequest, err.Error())
+		default:
+			return h.renderError(c, http.StatusInternalServerError, fmt.Sprintf("Failed to import todos: %v", err))
+		}
+	}
+
+	if wantsJSON(c) {
+		status := http.StatusCreated
+		if result.DryRun {
+			status = http.StatusOK
+		}
+		return c.JSON(status, result)
+	}
+	return templates.ImportPreview(result, "").Render(req.Context(), c.Response().Writer)
+}
+
+// importFile opens the uploaded export: the file of a multipart form, or
+// the request body
+func importFile(c echo.Context) (io.ReadCloser, error) {
+	mediaType, _, _ := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	if mediaType != echo.MIMEMultipartForm {
+		return c.Request().Body, nil
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		return nil, err
+	}
+	return header.Open()
+}
+
+// renderError reports a failed import to API clients, and in the preview
+// area of the import page for browsers
+func (h *ImportHandler) renderError(c echo.Context, status int, message string) error {
+	if wantsJSON(c) {
+		return c.JSON(status, map[string]string{
+			"error": message,
+		})
+	}
+	return templates.ImportPreview(nil, message).Render(c.Request().Context(), c.Response().Writer)
+}
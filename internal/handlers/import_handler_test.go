@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/starbops/gottodo/internal/repositories"
+	"github.com/starbops/gottodo/internal/services"
+	"github.com/starbops/gottodo/pkg/auth"
+)
+
+// newImportTestServer serves the import routes, importing todos for user1
+func newImportTestServer(t *testing.T) (*echo.Echo, *services.TodoService) {
+	t.Helper()
+
+	todoService := services.NewTodoService(repositories.NewMemoryTodoRepository())
+	handler := NewImportHandler(services.NewImportService(todoService))
+
+	e := echo.New()
+	group := e.Group("/import", func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("user_id", "user1")
+			c.Set("user", &auth.User{ID: "user1", Email: "ann@example.com"})
+			return next(c)
+		}
+	})
+	group.GET("", handler.GetImport)
+	group.POST("", handler.Import)
+	return e, todoService
+}
+
+// importForm builds a multipart import form uploading file
+func importForm(t *testing.T, file string, fields map[string]string) (string, string) {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for name, value := range fields {
+		if err := writer.WriteField(name, value); err != nil {
+			t.Fatalf("Failed to write form field: %v", err)
+		}
+	}
+	part, err := writer.CreateFormFile("file", "export")
+	if err != nil {
+		t.Fatalf("Failed to create form file: %v", err)
+	}
+	part.Write([]byte(file))
+	writer.Close()
+	return writer.FormDataContentType(), body.String()
+}
+
+func TestImportHandler_JSON(t *testing.T) {
+	e, todoService := newImportTestServer(t)
+	csvFile := "Title,Due,Tags\nBuy milk,2025-03-14,food\n,2025-03-15,\nCall Ann,,\n"
+
+	// A dry run previews the rows without creating todos
+	contentType, body := importForm(t, csvFile, map[string]string{"format": "csv", "dry_run": "1"})
+	rec := serveWebhookRequest(e, http.MethodPost, "/import", contentType, body, true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 for a dry run, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var preview services.ImportResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &preview); err != nil {
+		t.Fatalf("Failed to decode preview: %v", err)
+	}
+	if !preview.DryRun || preview.Valid != 2 || preview.Invalid != 1 || preview.Rows[1].Row != 3 || preview.Rows[1].Error == "" {
+		t.Errorf("Expected 2 valid rows and an error on line 3, got %+v", preview)
+	}
+	if todos, _ := todoService.GetUserTodos(context.Background(), "user1"); len(todos) != 0 {
+		t.Fatalf("Expected no todos after a dry run, got %d", len(todos))
+	}
+
+	// The raw file can be sent as the body too
+	rec = serveWebhookRequest(e, http.MethodPost, "/import?format=csv&project=Groceries", "text/csv", csvFile, true)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	todos, _ := todoService.GetUserTodos(context.Background(), "user1")
+	if len(todos) != 2 {
+		t.Fatalf("Expected 2 imported todos, got %d", len(todos))
+	}
+	for _, todo := range todos {
+		if todo.Project != "Groceries" {
+			t.Errorf("Expected the todos in the default project, got %+v", todo)
+		}
+	}
+
+	// Files that cannot be read at all are rejected
+	rec = serveWebhookRequest(e, http.MethodPost, "/import?format=csv", "text/csv", "Due\n2025-03-14\n", true)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "no title column") {
+		t.Errorf("Expected 400 for a CSV file without titles, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = serveWebhookRequest(e, http.MethodPost, "/import?format=evernote", "text/plain", "Note", true)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown format, got %d", rec.Code)
+	}
+	contentType, body = importForm(t, strings.Repeat("x", maxImportBodySize), map[string]string{"format": "todotxt"})
+	rec = serveWebhookRequest(e, http.MethodPost, "/import", contentType, body, true)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413 for a large file, got %d", rec.Code)
+	}
+}
+
+func TestImportHandler_HTML(t *testing.T) {
+	e, _ := newImportTestServer(t)
+
+	rec := serveWebhookRequest(e, http.MethodGet, "/import", "", "", false)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `name="column_title"`) {
+		t.Fatalf("Expected the import page, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	contentType, body := importForm(t, "(A) Call Ann +Family\nBroken due:soon\n", map[string]string{"format": "todotxt", "dry_run": "1"})
+	rec = serveWebhookRequest(e, http.MethodPost, "/import", contentType, body, false)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Call Ann") || !strings.Contains(rec.Body.String(), "soon") {
+		t.Errorf("Expected the preview with the todo and the error, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Forms without a file show an error in the preview
+	contentType, body = importForm(t, "", map[string]string{"format": "todotxt"})
+	rec = serveWebhookRequest(e, http.MethodPost, "/import", contentType, strings.Replace(body, `name="file"`, `name="other"`, 1), false)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Choose a file") {
+		t.Errorf("Expected the error in the preview, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
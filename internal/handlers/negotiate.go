@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
+	"github.com/starbops/gottodo/internal/services"
+)
+
+// wantsJSON reports whether the client asked for JSON instead of the HTML
+// fragments rendered for htmx
+func wantsJSON(c echo.Context) bool {
+	return strings.Contains(c.Request().Header.Get(echo.HeaderAccept), echo.MIMEApplicationJSON)
+}
+
+// todoErrorStatus maps errors of todo actions to HTTP status codes for API clients
+func todoErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repositories.ErrTodoNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repositories.ErrConflict):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// undoErrorStatus maps errors of undo requests to HTTP status codes for API clients
+func undoErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repositories.ErrUndoTokenNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrUndoExpired):
+		return http.StatusGone
+	default:
+		return todoErrorStatus(err)
+	}
+}
+
+// setUndoToken tells API clients how to revert the action they just performed
+func setUndoToken(c echo.Context, undo *models.UndoToken) {
+	if undo != nil {
+		c.Response().Header().Set("Undo-Token", undo.Token)
+	}
+}
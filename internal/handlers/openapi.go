@@ -0,0 +1,785 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/openapi"
+	"github.com/starbops/gottodo/internal/services"
+	"github.com/starbops/gottodo/pkg/auth"
+	"github.com/starbops/gottodo/pkg/todoimport"
+)
+
+// ErrorResponse is the body of JSON error responses
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// OpenAPISpec describes every route served by the application
+func OpenAPISpec() *openapi.Document {
+	doc := openapi.NewDocument(openapi.Info{
+		Title:       "GotToDo API",
+		Version:     "1.0.0",
+		Description: "Routes of the GotToDo web application. Routes marked as HTML return htmx fragments or pages.",
+	})
+	doc.Components.SecuritySchemes["cookieAuth"] = &openapi.SecurityScheme{
+		Type:        "apiKey",
+		In:          "cookie",
+		Name:        "auth_token",
+		Description: "Session token set by POST /auth/login or the GitHub callback",
+	}
+	doc.Components.SecuritySchemes["bearerAuth"] = &openapi.SecurityScheme{
+		Type:        "http",
+		Scheme:      "bearer",
+		Description: "Session token issued by POST /auth/token",
+	}
+	doc.Components.SecuritySchemes["basicAuth"] = &openapi.SecurityScheme{
+		Type:        "http",
+		Scheme:      "basic",
+		Description: "Account email address and an app password, for CalDAV clients",
+	}
+
+	todo := doc.SchemaOf(models.Todo{})
+	errorBody := doc.SchemaOf(ErrorResponse{})
+	etag := map[string]*openapi.Header{
+		"ETag": {Description: "Current version of the todo", Schema: &openapi.Schema{Type: "string"}},
+	}
+	ifMatch := openapi.Parameter{
+		Name: "If-Match", In: "header",
+		Description: "Only apply the change if the todo still has this ETag",
+		Schema:      &openapi.Schema{Type: "string"},
+	}
+	authenticated := []map[string][]string{{"cookieAuth": {}}, {"bearerAuth": {}}}
+	undoToken := map[string]*openapi.Header{
+		"Undo-Token": {Description: "Token for POST /undo/{token} reverting the change", Schema: &openapi.Schema{Type: "string"}},
+	}
+
+	// Pages
+	for _, page := range []struct{ path, summary string }{
+		{"/", "Home page"},
+		{"/login", "Login page"},
+		{"/register", "Registration page"},
+	} {
+		doc.AddOperation(http.MethodGet, page.path, &openapi.Operation{
+			Summary: page.summary, OperationID: pageOperationID(page.path), Tags: []string{"pages"},
+			Responses: htmlResponse("HTML page"),
+		})
+	}
+	doc.AddOperation(http.MethodGet, "/dashboard", &openapi.Operation{
+		Summary: "Dashboard with the user's todos", OperationID: "dashboardPage", Tags: []string{"pages"},
+		Responses: htmlResponse("HTML page"), Security: authenticated,
+	})
+	doc.AddOperation(http.MethodGet, "/trash", &openapi.Operation{
+		Summary: "Trash page with deleted todos", OperationID: "trashPage", Tags: []string{"pages"},
+		Responses: htmlResponse("HTML page"), Security: authenticated,
+	})
+	doc.AddOperation(http.MethodGet, "/docs", &openapi.Operation{
+		Summary: "Interactive API documentation", OperationID: "apiDocs", Tags: []string{"docs"},
+		Responses: htmlResponse("HTML page"),
+	})
+	doc.AddOperation(http.MethodGet, "/openapi.json", &openapi.Operation{
+		Summary: "This OpenAPI document", OperationID: "openAPISpec", Tags: []string{"docs"},
+		Responses: jsonResponse(http.StatusOK, "OpenAPI 3.1 document", &openapi.Schema{Type: "object"}),
+	})
+
+	// Authentication
+	doc.AddOperation(http.MethodGet, "/auth/github", &openapi.Operation{
+		Summary: "Start GitHub OAuth login", OperationID: "githubAuth", Tags: []string{"auth"},
+		Responses: redirectResponse("Redirect to GitHub"),
+	})
+	doc.AddOperation(http.MethodGet, "/auth/github/callback", &openapi.Operation{
+		Summary: "Finish GitHub OAuth login", OperationID: "githubCallback", Tags: []string{"auth"},
+		Parameters: []openapi.Parameter{
+			{Name: "code", In: "query", Required: true, Schema: &openapi.Schema{Type: "string"}},
+			{Name: "state", In: "query", Required: true, Schema: &openapi.Schema{Type: "string"}},
+		},
+		Responses: redirectResponse("Redirect to the dashboard with the auth_token cookie set"),
+	})
+	doc.AddOperation(http.MethodPost, "/auth/login", &openapi.Operation{
+		Summary: "Log in with email and password", OperationID: "login", Tags: []string{"auth"},
+		RequestBody: formBody(doc.SchemaOf(LoginRequest{})),
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "Logged in; the auth_token cookie is set and HX-Redirect points to the dashboard, or an HTML login form with an error is returned"},
+		},
+	})
+	doc.AddOperation(http.MethodPost, "/auth/token", &openapi.Operation{
+		Summary: "Issue a session token for API clients", OperationID: "createToken", Tags: []string{"auth"},
+		RequestBody: jsonBody(doc.SchemaOf(LoginRequest{})),
+		Responses: withErrors(jsonResponse(http.StatusOK, "The new session", doc.SchemaOf(auth.Session{})), errorBody,
+			http.StatusBadRequest, http.StatusUnauthorized),
+	})
+	doc.AddOperation(http.MethodPost, "/auth/register", &openapi.Operation{
+		Summary: "Register with email and password", OperationID: "register", Tags: []string{"auth"},
+		Description: "Returns an HTML fragment, or the new user when JSON is accepted.",
+		RequestBody: formBody(doc.SchemaOf(RegisterRequest{})),
+		Responses: withError(withJSON(htmlResponse("HTML fragment reporting the outcome"), http.StatusCreated, "The new user", doc.SchemaOf(auth.User{}), nil),
+			http.StatusBadRequest, errorBody),
+	})
+	doc.AddOperation(http.MethodPost, "/auth/logout", &openapi.Operation{
+		Summary: "Log out", OperationID: "logout", Tags: []string{"auth"},
+		Description: "Revokes the session. Bearer token clients get 204 No Content.",
+		Responses: map[string]*openapi.Response{
+			"204": {Description: "The bearer token was revoked"},
+			"302": {Description: "Redirect to the login page with the auth_token cookie cleared"},
+		},
+	})
+
+	// Todos
+	doc.AddOperation(http.MethodGet, "/todos", &openapi.Operation{
+		Summary: "List the user's todos", OperationID: "listTodos", Tags: []string{"todos"},
+		Description: "Returns the HTML todo list for htmx requests.",
+		Responses: withError(jsonResponse(http.StatusOK, "Todos outside the trash", &openapi.Schema{Type: "array", Items: todo}),
+			http.StatusInternalServerError, errorBody),
+		Security: authenticated,
+	})
+	doc.AddOperation(http.MethodPost, "/todos", &openapi.Operation{
+		Summary: "Create a todo", OperationID: "createTodo", Tags: []string{"todos"},
+		Parameters: []openapi.Parameter{{
+			Name: "Idempotency-Key", In: "header",
+			Description: "Replays the original response when the same request is retried; a retry arriving while the original is still running gets 409",
+			Schema:      &openapi.Schema{Type: "string"},
+		}},
+		Description: "Returns the HTML todo list, or the new todo when JSON is accepted. " +
+			"With quick_add the due date, tags, priority, project and recurrence are read from the title.",
+		RequestBody: formBody(doc.SchemaOf(CreateTodoRequest{})),
+		Responses: withErrors(withJSON(htmlResponse("HTML todo list including the new todo"), http.StatusCreated, "The new todo", todo, etag), errorBody,
+			http.StatusBadRequest, http.StatusConflict, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity),
+		Security: authenticated,
+	})
+	doc.AddOperation(http.MethodPost, "/todos/preview", &openapi.Operation{
+		Summary: "Preview the todo quick-add text creates", OperationID: "previewTodo", Tags: []string{"todos"},
+		Description: "Reads the due date, tags, priority, project and recurrence from the title without saving anything. " +
+			"Returns an HTML preview, or the unsaved todo when JSON is accepted.",
+		RequestBody: formBody(doc.SchemaOf(QuickAddPreviewRequest{})),
+		Responses: withError(withJSON(htmlResponse("HTML preview of the todo"), http.StatusOK, "The unsaved todo", todo, nil),
+			http.StatusBadRequest, errorBody),
+		Security: authenticated,
+	})
+	doc.AddOperation(http.MethodPost, "/todos/bulk", &openapi.Operation{
+		Summary: "Apply one action to several todos", OperationID: "bulkUpdateTodos", Tags: []string{"todos"},
+		RequestBody: jsonBody(doc.SchemaOf(BulkRequest{})),
+		Responses: withError(jsonResponse(http.StatusOK, "Result per todo; htmx requests get the HTML todo list", doc.SchemaOf(BulkResponse{})),
+			http.StatusBadRequest, errorBody),
+		Security: authenticated,
+	})
+	doc.AddOperation(http.MethodGet, "/todos/{id}", &openapi.Operation{
+		Summary: "Get a todo", OperationID: "getTodo", Tags: []string{"todos"},
+		Responses: withError(jsonResponseWithHeaders(http.StatusOK, "The todo", todo, etag),
+			http.StatusNotFound, errorBody),
+		Security: authenticated,
+	})
+	doc.AddOperation(http.MethodPut, "/todos/{id}", &openapi.Operation{
+		Summary: "Replace a todo's title and description", OperationID: "updateTodo", Tags: []string{"todos"},
+		Parameters:  []openapi.Parameter{ifMatch},
+		RequestBody: jsonBody(doc.SchemaOf(UpdateTodoRequest{})),
+		Responses: withErrors(jsonResponseWithHeaders(http.StatusOK, "The updated todo", todo, etag), errorBody,
+			http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed),
+		Security: authenticated,
+	})
+	doc.AddOperation(http.MethodPatch, "/todos/{id}", &openapi.Operation{
+		Summary: "Partially update a todo", OperationID: "patchTodo", Tags: []string{"todos"},
+		Description: "Accepts a JSON Merge Patch (RFC 7396). Omitted members are left unchanged.",
+		Parameters:  []openapi.Parameter{ifMatch},
+		RequestBody: &openapi.RequestBody{Required: true, Content: map[string]*openapi.MediaType{
+			"application/merge-patch+json": {Schema: doc.SchemaOf(models.TodoPatch{})},
+		}},
+		Responses: withErrors(jsonResponseWithHeaders(http.StatusOK, "The updated todo", todo, etag), errorBody,
+			http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed,
+			http.StatusUnsupportedMediaType, http.StatusUnprocessableEntity),
+		Security: authenticated,
+	})
+	doc.AddOperation(http.MethodPut, "/todos/{id}/complete", &openapi.Operation{
+		Summary: "Mark a todo as completed", OperationID: "completeTodo", Tags: []string{"todos"},
+		Parameters: []openapi.Parameter{ifMatch},
+		Responses:  todoItemResponses(withJSON(htmlResponse(todoItemDescription), http.StatusOK, "The updated todo", todo, mergeHeaders(etag, undoToken)), errorBody),
+		Security:   authenticated,
+	})
+	doc.AddOperation(http.MethodPut, "/todos/{id}/incomplete", &openapi.Operation{
+		Summary: "Mark a todo as incomplete", OperationID: "reopenTodo", Tags: []string{"todos"},
+		Parameters: []openapi.Parameter{ifMatch},
+		Responses:  todoItemResponses(withJSON(htmlResponse(todoItemDescription), http.StatusOK, "The updated todo", todo, mergeHeaders(etag, undoToken)), errorBody),
+		Security:   authenticated,
+	})
+	doc.AddOperation(http.MethodDelete, "/todos/{id}", &openapi.Operation{
+		Summary: "Move a todo to the trash", OperationID: "deleteTodo", Tags: []string{"todos"},
+		Parameters: []openapi.Parameter{ifMatch},
+		Responses: todoItemResponses(map[string]*openapi.Response{
+			"200": {Description: "HTML todo list and an undo toast", Content: map[string]*openapi.MediaType{
+				"text/html": {Schema: &openapi.Schema{Type: "string"}},
+			}},
+			"204": {Description: "The todo was moved to the trash (JSON clients)", Headers: undoToken},
+		}, errorBody),
+		Security: authenticated,
+	})
+	doc.AddOperation(http.MethodGet, "/todos/{id}/history", &openapi.Operation{
+		Summary: "Show a todo's change history", OperationID: "getTodoHistory", Tags: []string{"activity"},
+		Responses: htmlResponse("HTML history panel"),
+		Security:  authenticated,
+	})
+	doc.AddOperation(http.MethodPost, "/todos/{id}/restore", &openapi.Operation{
+		Summary: "Restore a todo from the trash", OperationID: "restoreTodo", Tags: []string{"trash"},
+		Responses: htmlResponse("HTML trash list"),
+		Security:  authenticated,
+	})
+	doc.AddOperation(http.MethodDelete, "/trash", &openapi.Operation{
+		Summary: "Permanently delete all trashed todos", OperationID: "emptyTrash", Tags: []string{"trash"},
+		Responses: htmlResponse("HTML trash list"),
+		Security:  authenticated,
+	})
+	doc.AddOperation(http.MethodPost, "/undo/{token}", &openapi.Operation{
+		Summary: "Undo a recent delete or status change", OperationID: "undo", Tags: []string{"todos"},
+		Description: "Returns the HTML todo list, or the restored todo when JSON is accepted.",
+		Responses: withErrors(withJSON(htmlResponse("HTML todo list with the toast replaced"), http.StatusOK, "The restored todo", todo, etag), errorBody,
+			http.StatusBadRequest, http.StatusNotFound, http.StatusGone),
+		Security: authenticated,
+	})
+
+	// Activity
+	doc.AddOperation(http.MethodGet, "/activity", &openapi.Operation{
+		Summary: "Page through the user's activity feed", OperationID: "getActivityFeed", Tags: []string{"activity"},
+		Parameters: []openapi.Parameter{
+			{Name: "limit", In: "query", Schema: &openapi.Schema{Type: "integer"}},
+			{Name: "offset", In: "query", Schema: &openapi.Schema{Type: "integer"}},
+		},
+		Responses: withErrors(jsonResponse(http.StatusOK, "A page of activity, newest first", doc.SchemaOf(services.ActivityPage{})), errorBody,
+			http.StatusBadRequest, http.StatusInternalServerError),
+		Security: authenticated,
+	})
+
+	// Live updates
+	doc.AddOperation(http.MethodGet, "/events", &openapi.Operation{
+		Summary: "Stream live changes to the user's todos", OperationID: "streamEvents", Tags: []string{"todos"},
+		Description: "Server-sent events carrying rendered TodoItem fragments: todo-created, todo-updated-{id} and " +
+			"todo-deleted-{id}. A heartbeat comment keeps idle streams open. Clients reconnecting with Last-Event-ID " +
+			"receive the events they missed, or todo-reset when the list has to be reloaded.",
+		Parameters: []openapi.Parameter{{
+			Name: "Last-Event-ID", In: "header",
+			Description: "ID of the last event received before reconnecting",
+			Schema:      &openapi.Schema{Type: "string"},
+		}},
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "Event stream", Content: map[string]*openapi.MediaType{
+				"text/event-stream": {Schema: &openapi.Schema{Type: "string"}},
+			}},
+		},
+		Security: authenticated,
+	})
+
+	// Webhooks
+	webhook := doc.SchemaOf(models.Webhook{})
+	delivery := doc.SchemaOf(models.WebhookDelivery{})
+	doc.AddOperation(http.MethodGet, "/webhooks", &openapi.Operation{
+		Summary: "List the user's webhooks", OperationID: "listWebhooks", Tags: []string{"webhooks"},
+		Description: "Returns the webhooks page for browsers.",
+		Responses: withError(withJSON(htmlResponse("HTML page"), http.StatusOK, "The user's webhooks", &openapi.Schema{Type: "array", Items: webhook}, nil),
+			http.StatusInternalServerError, errorBody),
+		Security: authenticated,
+	})
+	doc.AddOperation(http.MethodPost, "/webhooks", &openapi.Operation{
+		Summary: "Register a webhook", OperationID: "createWebhook", Tags: []string{"webhooks"},
+		Description: "Deliveries are POSTed as JSON signed with the webhook secret: the " + services.WebhookSignatureHeader +
+			" header holds sha256= and the hex HMAC-SHA256 of the " + services.WebhookTimestampHeader +
+			" header, a dot and the raw body. Returns the HTML webhook list, or the new webhook when JSON is accepted.",
+		RequestBody: formBody(doc.SchemaOf(CreateWebhookRequest{})),
+		Responses: withErrors(withJSON(htmlResponse("HTML webhook list"), http.StatusCreated, "The new webhook including its secret", webhook, nil), errorBody,
+			http.StatusBadRequest, http.StatusInternalServerError),
+		Security: authenticated,
+	})
+	doc.AddOperation(http.MethodDelete, "/webhooks/{id}", &openapi.Operation{
+		Summary: "Delete a webhook and its deliveries", OperationID: "deleteWebhook", Tags: []string{"webhooks"},
+		Responses: withError(map[string]*openapi.Response{
+			"200": {Description: "HTML webhook list", Content: map[string]*openapi.MediaType{
+				"text/html": {Schema: &openapi.Schema{Type: "string"}},
+			}},
+			"204": {Description: "The webhook was deleted (JSON clients)"},
+		}, http.StatusNotFound, errorBody),
+		Security: authenticated,
+	})
+	doc.AddOperation(http.MethodGet, "/webhooks/{id}/deliveries", &openapi.Operation{
+		Summary: "Show a webhook's recent deliveries", OperationID: "listWebhookDeliveries", Tags: []string{"webhooks"},
+		Responses: withError(withJSON(htmlResponse("HTML delivery log"), http.StatusOK, "Recent deliveries, newest first", &openapi.Schema{Type: "array", Items: delivery}, nil),
+			http.StatusNotFound, errorBody),
+		Security: authenticated,
+	})
+	doc.AddOperation(http.MethodPost, "/webhooks/{id}/test", &openapi.Operation{
+		Summary: "Send a test event to a webhook", OperationID: "testWebhook", Tags: []string{"webhooks"},
+		Description: "Delivers a webhook.test event right away. A failed test is retried like any other delivery.",
+		Responses: withError(withJSON(htmlResponse("HTML delivery log"), http.StatusOK, "The test delivery", delivery, nil),
+			http.StatusNotFound, errorBody),
+		Security: authenticated,
+	})
+
+	// Quick capture
+	captureURL := doc.SchemaOf(CaptureURL{})
+	doc.AddOperation(http.MethodGet, "/capture", &openapi.Operation{
+		Summary: "Show the user's capture URL", OperationID: "getCaptureURL", Tags: []string{"capture"},
+		Description: "Creates the capture URL on first use. Returns the capture page for browsers.",
+		Responses: withError(withJSON(htmlResponse("HTML page"), http.StatusOK, "The capture URL", captureURL, nil),
+			http.StatusInternalServerError, errorBody),
+		Security: authenticated,
+	})
+	doc.AddOperation(http.MethodPost, "/capture", &openapi.Operation{
+		Summary: "Rotate the user's capture URL", OperationID: "rotateCaptureURL", Tags: []string{"capture"},
+		Description: "The previous capture URL stops working. Returns the HTML capture settings, or the new URL when JSON is accepted.",
+		Responses: withError(withJSON(htmlResponse("HTML capture settings"), http.StatusOK, "The new capture URL", captureURL, nil),
+			http.StatusInternalServerError, errorBody),
+		Security: authenticated,
+	})
+	captureRequest := doc.SchemaOf(CaptureRequest{})
+	doc.AddOperation(http.MethodPost, "/capture/{secret}", &openapi.Operation{
+		Summary: "Create a todo through a capture URL", OperationID: "capture", Tags: []string{"capture"},
+		Description: "Accepts plain text, whose first line is the title, or a JSON or form body with a title and " +
+			"description. Without a title, an email-style subject and body are parsed: reply markers, quoted replies " +
+			"and signatures are dropped. Requests are rate limited per capture URL.",
+		RequestBody: &openapi.RequestBody{Required: true, Content: map[string]*openapi.MediaType{
+			"text/plain":                        {Schema: &openapi.Schema{Type: "string"}},
+			"application/json":                  {Schema: captureRequest},
+			"application/x-www-form-urlencoded": {Schema: captureRequest},
+		}},
+		Responses: withErrors(jsonResponse(http.StatusCreated, "The new todo", todo), errorBody,
+			http.StatusBadRequest, http.StatusNotFound, http.StatusRequestEntityTooLarge, http.StatusTooManyRequests),
+	})
+
+	// Calendar feeds
+	calendarURL := doc.SchemaOf(CalendarURL{})
+	calendarFile := map[string]*openapi.MediaType{
+		"text/calendar": {Schema: &openapi.Schema{Type: "string"}},
+	}
+	doc.AddOperation(http.MethodGet, "/calendar", &openapi.Operation{
+		Summary: "Show the user's calendar feed URL", OperationID: "getCalendarURL", Tags: []string{"calendar"},
+		Description: "Creates the calendar feed on first use. Returns the calendar page for browsers.",
+		Responses: withError(withJSON(htmlResponse("HTML page"), http.StatusOK, "The calendar feed URL", calendarURL, nil),
+			http.StatusInternalServerError, errorBody),
+		Security: authenticated,
+	})
+	doc.AddOperation(http.MethodPost, "/calendar", &openapi.Operation{
+		Summary: "Rotate the user's calendar feed URL", OperationID: "rotateCalendarURL", Tags: []string{"calendar"},
+		Description: "Subscriptions to the previous feed URL stop working. Returns the HTML calendar settings, or the new URL when JSON is accepted.",
+		Responses: withError(withJSON(htmlResponse("HTML calendar settings"), http.StatusOK, "The new calendar feed URL", calendarURL, nil),
+			http.StatusInternalServerError, errorBody),
+		Security: authenticated,
+	})
+	doc.AddOperation(http.MethodGet, "/calendar/{feed}", &openapi.Operation{
+		Summary: "Subscribe to a calendar feed", OperationID: "calendarFeed", Tags: []string{"calendar"},
+		Description: "Serves the todos of the feed's owner as an iCalendar feed; the feed may end in .ics. " +
+			"Todos with a due date are events, the others tasks. Pass tasks=1 to get every todo as a task.",
+		Parameters: []openapi.Parameter{
+			{Name: "tasks", In: "query", Description: "Set to 1 to get every todo as a task", Schema: &openapi.Schema{Type: "string"}},
+		},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": {Description: "iCalendar feed", Content: calendarFile},
+		}, errorBody, http.StatusNotFound),
+	})
+	doc.AddOperation(http.MethodGet, "/todos.ics", &openapi.Operation{
+		Summary: "Download all todos as an iCalendar file", OperationID: "downloadCalendar", Tags: []string{"calendar"},
+		Description: "Every todo is a task, due when the todo is due.",
+		Responses: withError(map[string]*openapi.Response{
+			"200": {Description: "iCalendar file", Content: calendarFile},
+		}, http.StatusInternalServerError, errorBody),
+		Security: authenticated,
+	})
+
+	// App passwords
+	appPassword := doc.SchemaOf(models.AppPassword{})
+	doc.AddOperation(http.MethodGet, "/app-passwords", &openapi.Operation{
+		Summary: "List the user's app passwords", OperationID: "listAppPasswords", Tags: []string{"caldav"},
+		Description: "Returns the sync page, with the CalDAV server URL, for browsers.",
+		Responses: withError(withJSON(htmlResponse("HTML page"), http.StatusOK, "App passwords, oldest first", &openapi.Schema{Type: "array", Items: appPassword}, nil),
+			http.StatusInternalServerError, errorBody),
+		Security: authenticated,
+	})
+	createAppPassword := doc.SchemaOf(CreateAppPasswordRequest{})
+	doc.AddOperation(http.MethodPost, "/app-passwords", &openapi.Operation{
+		Summary: "Create an app password", OperationID: "createAppPassword", Tags: []string{"caldav"},
+		Description: "The password is only returned once. Returns the HTML app password list showing it, or the new app password when JSON is accepted.",
+		RequestBody: &openapi.RequestBody{Required: true, Content: map[string]*openapi.MediaType{
+			"application/json":                  {Schema: createAppPassword},
+			"application/x-www-form-urlencoded": {Schema: createAppPassword},
+		}},
+		Responses: withErrors(withJSON(htmlResponse("HTML app password list"), http.StatusCreated, "The new app password and its password", doc.SchemaOf(CreatedAppPassword{}), nil),
+			errorBody, http.StatusBadRequest, http.StatusInternalServerError),
+		Security: authenticated,
+	})
+	doc.AddOperation(http.MethodDelete, "/app-passwords/{id}", &openapi.Operation{
+		Summary: "Revoke an app password", OperationID: "deleteAppPassword", Tags: []string{"caldav"},
+		Responses: withError(map[string]*openapi.Response{
+			"200": {Description: "HTML app password list", Content: map[string]*openapi.MediaType{
+				"text/html": {Schema: &openapi.Schema{Type: "string"}},
+			}},
+			"204": {Description: "The app password was revoked (JSON clients)"},
+		}, http.StatusNotFound, errorBody),
+		Security: authenticated,
+	})
+
+	// Imports
+	importFormat := &openapi.Schema{Type: "string", Description: "Format of the export"}
+	for _, format := range todoimport.Formats {
+		importFormat.Enum = append(importFormat.Enum, string(format))
+	}
+	importForm := &openapi.Schema{
+		Type: "object",
+		Properties: map[string]*openapi.Schema{
+			"file":      {Type: "string", ContentEncoding: "binary", Description: "The export to import"},
+			"format":    importFormat,
+			"dry_run":   {Type: "string", Description: "Set to 1 to preview the import without creating todos"},
+			"project":   {Type: "string", Description: "Project for todos without one"},
+			"time_zone": {Type: "string", Description: "IANA time zone of times given without one, UTC by default"},
+		},
+		Required: []string{"file", "format"},
+	}
+	var importParameters []openapi.Parameter
+	for name, schema := range importForm.Properties {
+		if name != "file" {
+			importParameters = append(importParameters, openapi.Parameter{Name: name, In: "query", Description: schema.Description, Schema: schema})
+		}
+	}
+	for _, field := range todoimport.Fields {
+		column := &openapi.Schema{Type: "string", Description: "Header of the CSV column holding the " + field}
+		importForm.Properties["column_"+field] = column
+		importParameters = append(importParameters, openapi.Parameter{Name: "column_" + field, In: "query", Description: column.Description, Schema: column})
+	}
+	sort.Slice(importParameters, func(i, j int) bool { return importParameters[i].Name < importParameters[j].Name })
+	importResult := doc.SchemaOf(services.ImportResult{})
+	doc.AddOperation(http.MethodGet, "/import", &openapi.Operation{
+		Summary: "Show the import page", OperationID: "getImport", Tags: []string{"import"},
+		Description: "Returns the supported formats and the fields CSV columns can be mapped to when JSON is accepted.",
+		Responses:   withJSON(htmlResponse("HTML page"), http.StatusOK, "What can be imported", doc.SchemaOf(ImportFormats{}), nil),
+		Security:    authenticated,
+	})
+	doc.AddOperation(http.MethodPost, "/import", &openapi.Operation{
+		Summary: "Import todos from another app", OperationID: "importTodos", Tags: []string{"import"},
+		Description: "Reads a todo.txt file, a CSV file, a Todoist CSV export or JSON backup, or a Microsoft To Do JSON export. " +
+			"Upload the file in a multipart form, or send it as the request body with the other parameters in the query. " +
+			"Rows that cannot be read are skipped and reported; the other todos are created together, or not at all. " +
+			"With dry_run=1 nothing is created. Returns the HTML preview, or the result when JSON is accepted.",
+		Parameters: importParameters,
+		RequestBody: &openapi.RequestBody{Required: true, Content: map[string]*openapi.MediaType{
+			"multipart/form-data":      {Schema: importForm},
+			"application/octet-stream": {Schema: &openapi.Schema{Type: "string", ContentEncoding: "binary"}},
+		}},
+		Responses: withErrors(withJSON(withJSON(htmlResponse("HTML import preview"), http.StatusOK, "The preview of a dry run", importResult, nil),
+			http.StatusCreated, "The imported todos", importResult, nil),
+			errorBody, http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusInternalServerError),
+		Security: authenticated,
+	})
+
+	// Exports
+	exportFormat := &openapi.Schema{Type: "string", Description: "Format of the export, json by default"}
+	exportFiles := make(map[string]*openapi.MediaType)
+	for _, format := range services.ExportFormats {
+		exportFormat.Enum = append(exportFormat.Enum, string(format))
+		exportFiles[strings.SplitN(format.ContentType(), ";", 2)[0]] = &openapi.MediaType{Schema: &openapi.Schema{Type: "string"}}
+	}
+	doc.AddOperation(http.MethodGet, "/export", &openapi.Operation{
+		Summary: "Export all todos", OperationID: "exportTodos", Tags: []string{"import"},
+		Description: "Downloads every todo of the user. The JSON export holds every field and the history of each todo, trashed todos included, " +
+			"and can be imported again. CSV exports have a row for every todo. Markdown and todo.txt exports leave out the trash.",
+		Parameters: []openapi.Parameter{{Name: "format", In: "query", Description: exportFormat.Description, Schema: exportFormat}},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": {Description: "The export, as an attachment", Content: exportFiles},
+		}, errorBody, http.StatusBadRequest, http.StatusInternalServerError),
+		Security: authenticated,
+	})
+
+	// Account
+	accountDeletion := doc.SchemaOf(models.AccountDeletion{})
+	doc.AddOperation(http.MethodGet, "/account", &openapi.Operation{
+		Summary: "Get the user's account", OperationID: "getAccount", Tags: []string{"account"},
+		Description: "Returns the account page for browsers, or the profile with any pending deletion when JSON is accepted.",
+		Responses: withError(withJSON(htmlResponse("HTML page"), http.StatusOK, "The profile of the user", doc.SchemaOf(services.AccountProfile{}), nil),
+			http.StatusInternalServerError, errorBody),
+		Security: authenticated,
+	})
+	deleteAccount := doc.SchemaOf(DeleteAccountRequest{})
+	doc.AddOperation(http.MethodPost, "/account/deletion", &openapi.Operation{
+		Summary: "Delete the user's account", OperationID: "deleteAccount", Tags: []string{"account"},
+		Description: "Users with a password confirm with it; users who log in with GitHub must have logged in within the last " +
+			strconv.Itoa(int(auth.ReauthenticationWindow.Minutes())) + " minutes. The account and all its data are deleted at the end " +
+			"of the configured grace period, or at once if there is none, which also ends the session.",
+		RequestBody: &openapi.RequestBody{Content: map[string]*openapi.MediaType{
+			"application/json":                  {Schema: deleteAccount},
+			"application/x-www-form-urlencoded": {Schema: deleteAccount},
+		}},
+		Responses: withErrors(withJSON(map[string]*openapi.Response{
+			"200": {Description: "HTML deletion section, or a redirect home once the account is gone", Content: map[string]*openapi.MediaType{
+				"text/html": {Schema: &openapi.Schema{Type: "string"}},
+			}},
+			"204": {Description: "The account was deleted (JSON clients)"},
+		}, http.StatusAccepted, "The scheduled deletion", accountDeletion, nil),
+			errorBody, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError),
+		Security: authenticated,
+	})
+	doc.AddOperation(http.MethodDelete, "/account/deletion", &openapi.Operation{
+		Summary: "Cancel the deletion of the user's account", OperationID: "cancelAccountDeletion", Tags: []string{"account"},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": {Description: "HTML deletion section", Content: map[string]*openapi.MediaType{
+				"text/html": {Schema: &openapi.Schema{Type: "string"}},
+			}},
+			"204": {Description: "The account is kept (JSON clients)"},
+		}, errorBody, http.StatusNotFound, http.StatusInternalServerError),
+		Security: authenticated,
+	})
+	doc.AddOperation(http.MethodGet, "/account/data", &openapi.Operation{
+		Summary: "Download all data of the user", OperationID: "downloadAccountData", Tags: []string{"account"},
+		Description: "A zip archive of JSON files: profile.json, todos.json (the JSON export), activity.json, webhooks.json and app_passwords.json.",
+		Responses: withError(map[string]*openapi.Response{
+			"200": {Description: "The archive, as an attachment", Content: map[string]*openapi.MediaType{
+				"application/zip": {Schema: &openapi.Schema{Type: "string", Format: "binary"}},
+			}},
+		}, http.StatusInternalServerError, errorBody),
+		Security: authenticated,
+	})
+
+	// CalDAV
+	basicAuth := []map[string][]string{{"basicAuth": {}}}
+	multistatus := func() map[string]*openapi.Response {
+		return map[string]*openapi.Response{
+			"207": {Description: "WebDAV multistatus", Content: map[string]*openapi.MediaType{
+				"application/xml": {Schema: &openapi.Schema{Type: "string"}},
+			}},
+		}
+	}
+	davXML := &openapi.RequestBody{Content: map[string]*openapi.MediaType{
+		"application/xml": {Schema: &openapi.Schema{Type: "string"}},
+	}}
+	depth := openapi.Parameter{
+		Name: "Depth", In: "header",
+		Description: "0 for the resource alone, 1 to include its members",
+		Schema:      &openapi.Schema{Type: "string"},
+	}
+	for method, operationID := range map[string]string{http.MethodGet: "calDAVWellKnown", "PROPFIND": "calDAVWellKnownPropfind"} {
+		doc.AddOperation(method, "/.well-known/caldav", &openapi.Operation{
+			Summary: "Discover the CalDAV server", OperationID: operationID, Tags: []string{"caldav"},
+			Responses: map[string]*openapi.Response{
+				"301": {Description: "Redirect to the calendar home /dav/"},
+			},
+		})
+	}
+	for _, path := range []string{"/dav/", "/dav/todos/", "/dav/todos/{resource}"} {
+		doc.AddOperation(http.MethodOptions, path, &openapi.Operation{
+			Summary: "Advertise CalDAV support", OperationID: "calDAVOptions" + calDAVOperationSuffix(path), Tags: []string{"caldav"},
+			Responses: map[string]*openapi.Response{
+				"200": {Description: "DAV and Allow headers listing the supported features and methods"},
+			},
+		})
+	}
+	doc.AddOperation("PROPFIND", "/dav/", &openapi.Operation{
+		Summary: "Describe the user's principal and calendar home", OperationID: "calDAVPropfindHome", Tags: []string{"caldav"},
+		Description: "With Depth: 1 the todo calendar /dav/todos/ is described as well.",
+		Parameters:  []openapi.Parameter{depth}, RequestBody: davXML,
+		Responses: withError(multistatus(), http.StatusBadRequest, errorBody), Security: basicAuth,
+	})
+	doc.AddOperation("PROPFIND", "/dav/todos/", &openapi.Operation{
+		Summary: "Describe the todo calendar", OperationID: "calDAVPropfindTodos", Tags: []string{"caldav"},
+		Description: "With Depth: 1 every todo is described as well. The getctag property changes with every change of a todo.",
+		Parameters:  []openapi.Parameter{depth}, RequestBody: davXML,
+		Responses: withError(multistatus(), http.StatusBadRequest, errorBody), Security: basicAuth,
+	})
+	unsupportedReport := multistatus()
+	unsupportedReport["403"] = &openapi.Response{Description: "The report is not supported"}
+	doc.AddOperation("REPORT", "/dav/todos/", &openapi.Operation{
+		Summary: "Query the todo calendar", OperationID: "calDAVReport", Tags: []string{"caldav"},
+		Description: "Supports calendar-query, filtering on components and on properties being defined, and calendar-multiget.",
+		RequestBody: &openapi.RequestBody{Required: true, Content: davXML.Content},
+		Responses:   withErrors(unsupportedReport, errorBody, http.StatusBadRequest, http.StatusRequestEntityTooLarge),
+		Security:    basicAuth,
+	})
+	resourceDescription := "Todos are stored at /dav/todos/{id}.ics."
+	missingTodo := multistatus()
+	missingTodo["404"] = &openapi.Response{Description: "No such todo"}
+	doc.AddOperation("PROPFIND", "/dav/todos/{resource}", &openapi.Operation{
+		Summary: "Describe a todo", OperationID: "calDAVPropfindTodo", Tags: []string{"caldav"},
+		Description: resourceDescription, RequestBody: davXML,
+		Responses: withError(missingTodo, http.StatusBadRequest, errorBody), Security: basicAuth,
+	})
+	doc.AddOperation(http.MethodGet, "/dav/todos/{resource}", &openapi.Operation{
+		Summary: "Get a todo as an iCalendar task", OperationID: "calDAVGetTodo", Tags: []string{"caldav"},
+		Description: resourceDescription,
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "Calendar holding the todo as a VTODO", Content: calendarFile, Headers: etag},
+			"404": {Description: "No such todo"},
+		},
+		Security: basicAuth,
+	})
+	doc.AddOperation(http.MethodPut, "/dav/todos/{resource}", &openapi.Operation{
+		Summary: "Create or replace a todo from an iCalendar task", OperationID: "calDAVPutTodo", Tags: []string{"caldav"},
+		Description: resourceDescription + " The resource name must be a UUID. The project of an existing todo is kept. " +
+			"If-Match and If-None-Match: * guard against overwriting changes.",
+		Parameters: []openapi.Parameter{ifMatch, {
+			Name: "If-None-Match", In: "header",
+			Description: "Set to * to only create a new todo",
+			Schema:      &openapi.Schema{Type: "string"},
+		}},
+		RequestBody: &openapi.RequestBody{Required: true, Content: calendarFile},
+		Responses: withErrors(map[string]*openapi.Response{
+			"201": {Description: "The todo was created", Headers: etag},
+			"204": {Description: "The todo was replaced", Headers: etag},
+			"403": {Description: "The body is no calendar holding a valid task, or the todo belongs to someone else"},
+			"412": {Description: "The todo changed since the client read it"},
+		}, errorBody, http.StatusConflict),
+		Security: basicAuth,
+	})
+	doc.AddOperation(http.MethodDelete, "/dav/todos/{resource}", &openapi.Operation{
+		Summary: "Move a todo to the trash", OperationID: "calDAVDeleteTodo", Tags: []string{"caldav"},
+		Description: resourceDescription,
+		Parameters:  []openapi.Parameter{ifMatch},
+		Responses: map[string]*openapi.Response{
+			"204": {Description: "The todo was moved to the trash"},
+			"404": {Description: "No such todo"},
+			"412": {Description: "The todo changed since the client read it"},
+		},
+		Security: basicAuth,
+	})
+
+	// Authenticated routes reject JSON clients without a valid token
+	for _, item := range doc.Paths {
+		for _, op := range *item {
+			if op.Security != nil {
+				withError(op.Responses, http.StatusUnauthorized, errorBody)
+			}
+		}
+	}
+
+	return doc
+}
+
+// pageOperationID names the operation serving a public page
+func pageOperationID(path string) string {
+	switch path {
+	case "/":
+		return "homePage"
+	case "/login":
+		return "loginPage"
+	default:
+		return "registerPage"
+	}
+}
+
+// calDAVOperationSuffix names the CalDAV resource a path serves
+func calDAVOperationSuffix(path string) string {
+	switch path {
+	case "/dav/":
+		return "Home"
+	case "/dav/todos/":
+		return "Todos"
+	default:
+		return "Todo"
+	}
+}
+
+// htmlResponse describes a successful HTML response
+func htmlResponse(description string) map[string]*openapi.Response {
+	return map[string]*openapi.Response{
+		"200": {Description: description, Content: map[string]*openapi.MediaType{
+			"text/html": {Schema: &openapi.Schema{Type: "string"}},
+		}},
+	}
+}
+
+// redirectResponse describes a redirect
+func redirectResponse(description string) map[string]*openapi.Response {
+	return map[string]*openapi.Response{
+		"302": {Description: description},
+	}
+}
+
+// todoItemDescription describes the HTML response of todo item actions
+const todoItemDescription = "HTML fragment with the changed todo and an undo toast, or the todo as JSON when JSON is accepted"
+
+// todoItemResponses adds the error responses of todo item actions
+func todoItemResponses(responses map[string]*openapi.Response, errorBody *openapi.Schema) map[string]*openapi.Response {
+	withErrors(responses, errorBody, http.StatusBadRequest, http.StatusNotFound)
+	responses["412"] = &openapi.Response{
+		Description: "The todo changed since it was rendered; the current todo is returned as HTML",
+		Content: map[string]*openapi.MediaType{
+			"text/html":        {Schema: &openapi.Schema{Type: "string"}},
+			"application/json": {Schema: errorBody},
+		},
+	}
+	return responses
+}
+
+// withJSON adds a JSON alternative to a response, merging it into an existing
+// response with the same status
+func withJSON(responses map[string]*openapi.Response, status int, description string, schema *openapi.Schema, headers map[string]*openapi.Header) map[string]*openapi.Response {
+	response, exists := responses[statusKey(status)]
+	if !exists {
+		responses[statusKey(status)] = &openapi.Response{Description: description, Headers: headers, Content: map[string]*openapi.MediaType{
+			"application/json": {Schema: schema},
+		}}
+		return responses
+	}
+
+	response.Content["application/json"] = &openapi.MediaType{Schema: schema}
+	if len(headers) > 0 {
+		response.Headers = mergeHeaders(response.Headers, headers)
+	}
+	return responses
+}
+
+// mergeHeaders combines header descriptions
+func mergeHeaders(sets ...map[string]*openapi.Header) map[string]*openapi.Header {
+	merged := map[string]*openapi.Header{}
+	for _, set := range sets {
+		for name, header := range set {
+			merged[name] = header
+		}
+	}
+	return merged
+}
+
+// jsonResponse describes a JSON response
+func jsonResponse(status int, description string, schema *openapi.Schema) map[string]*openapi.Response {
+	return jsonResponseWithHeaders(status, description, schema, nil)
+}
+
+// jsonResponseWithHeaders describes a JSON response with headers
+func jsonResponseWithHeaders(status int, description string, schema *openapi.Schema, headers map[string]*openapi.Header) map[string]*openapi.Response {
+	return map[string]*openapi.Response{
+		statusKey(status): {Description: description, Headers: headers, Content: map[string]*openapi.MediaType{
+			"application/json": {Schema: schema},
+		}},
+	}
+}
+
+// withError adds a JSON error response
+func withError(responses map[string]*openapi.Response, status int, schema *openapi.Schema) map[string]*openapi.Response {
+	responses[statusKey(status)] = &openapi.Response{
+		Description: http.StatusText(status),
+		Content: map[string]*openapi.MediaType{
+			"application/json": {Schema: schema},
+		},
+	}
+	return responses
+}
+
+// withErrors adds several JSON error responses
+func withErrors(responses map[string]*openapi.Response, schema *openapi.Schema, statuses ...int) map[string]*openapi.Response {
+	for _, status := range statuses {
+		withError(responses, status, schema)
+	}
+	return responses
+}
+
+// jsonBody describes a required JSON request body
+func jsonBody(schema *openapi.Schema) *openapi.RequestBody {
+	return &openapi.RequestBody{Required: true, Content: map[string]*openapi.MediaType{
+		"application/json": {Schema: schema},
+	}}
+}
+
+// formBody describes a required request body sent as a form or as JSON
+func formBody(schema *openapi.Schema) *openapi.RequestBody {
+	return &openapi.RequestBody{Required: true, Content: map[string]*openapi.MediaType{
+		"application/x-www-form-urlencoded": {Schema: schema},
+		"application/json":                  {Schema: schema},
+	}}
+}
+
+// statusKey formats a status code as a responses map key
+func statusKey(status int) string {
+	return strconv.Itoa(status)
+}
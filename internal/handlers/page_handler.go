@@ -12,13 +12,15 @@ import (
 // PageHandler handles HTTP requests for HTML pages
 type PageHandler struct {
 	todoService *services.TodoService
+	undoService *services.UndoService
 	authService *auth.AuthService
 }
 
 // NewPageHandler creates a new PageHandler
-func NewPageHandler(todoService *services.TodoService, authService *auth.AuthService) *PageHandler {
+func NewPageHandler(todoService *services.TodoService, undoService *services.UndoService, authService *auth.AuthService) *PageHandler {
 	return &PageHandler{
 		todoService: todoService,
+		undoService: undoService,
 		authService: authService,
 	}
 }
@@ -52,6 +54,32 @@ func (h *PageHandler) Dashboard(c echo.Context) error {
 		})
 	}
 
+	// Offer to undo the last destructive action if its window is still open
+	undo, err := h.undoService.GetPendingUndo(c.Request().Context(), userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
 	// Render the dashboard template with the todos and user email
-	return templates.Dashboard(todos, user.Email).Render(c.Request().Context(), c.Response().Writer)
+	return templates.Dashboard(todos, user.Email, undo).Render(c.Request().Context(), c.Response().Writer)
+}
+
+// Trash handles GET /trash
+func (h *PageHandler) Trash(c echo.Context) error {
+	// Get user from context
+	userID := c.Get("user_id").(string)
+	user := c.Get("user").(*auth.User)
+
+	// Get trashed todos for the user
+	todos, err := h.todoService.GetTrashedTodos(c.Request().Context(), userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	// Render the trash page with the trashed todos and user email
+	return templates.Trash(todos, user.Email).Render(c.Request().Context(), c.Response().Writer)
 }
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"github.com/labstack/echo/v4"
+	appmiddleware "github.com/starbops/gottodo/internal/middleware"
+	"github.com/starbops/gottodo/internal/services"
+)
+
+// Routes holds everything the routes of the application are served by
+type Routes struct {
+	Todo        *TodoHandler
+	Page        *PageHandler
+	Auth        *AuthHandler
+	Activity    *ActivityHandler
+	Docs        *DocsHandler
+	Events      *EventsHandler
+	Webhooks    *WebhookHandler
+	Capture     *CaptureHandler
+	Calendar    *CalendarHandler
+	CalDAV      *CalDAVHandler
+	AppPassword *AppPasswordHandler
+	Import      *ImportHandler
+	Export      *ExportHandler
+	Account     *AccountHandler
+	Idempotency *services.IdempotencyService
+}
+
+// RegisterRoutes registers all routes of the application. Every route added
+// here must also be described by OpenAPISpec.
+func RegisterRoutes(e *echo.Echo, h *Routes) {
+	// Auth middleware
+	authMiddleware := h.Auth.AuthMiddleware
+
+	// Replays retried create requests carrying an Idempotency-Key header
+	idempotencyMiddleware := appmiddleware.IdempotencyMiddleware(h.Idempotency)
+
+	// Public routes
+	e.GET("/", h.Page.Home)
+	e.GET("/login", h.Page.Login)
+	e.GET("/register", h.Page.Register)
+
+	// API documentation
+	e.GET("/openapi.json", h.Docs.OpenAPI)
+	e.GET("/docs", h.Docs.Docs)
+
+	// Auth routes
+	e.GET("/auth/github", h.Auth.GitHubAuth)
+	e.GET("/auth/github/callback", h.Auth.GitHubCallback)
+	e.POST("/auth/login", h.Auth.Login)
+	e.POST("/auth/token", h.Auth.Token)
+	e.POST("/auth/register", h.Auth.Register)
+	e.POST("/auth/logout", h.Auth.Logout)
+
+	// Capture URLs authenticate with the secret in the path
+	e.POST("/capture/:secret", h.Capture.Capture, h.Capture.RateLimit)
+
+	// Calendar feeds authenticate with the secret in the path, as calendar
+	// apps cannot log in
+	e.GET("/calendar/:feed", h.Calendar.Feed)
+
+	// CalDAV clients log in with HTTP Basic auth and an app password
+	basicAuth := h.CalDAV.BasicAuth
+	e.GET("/.well-known/caldav", h.CalDAV.WellKnown)
+	e.Add("PROPFIND", "/.well-known/caldav", h.CalDAV.WellKnown)
+	davGroup := e.Group("/dav")
+	davGroup.OPTIONS("/", h.CalDAV.Options)
+	davGroup.OPTIONS("/todos/", h.CalDAV.Options)
+	davGroup.OPTIONS("/todos/:resource", h.CalDAV.Options)
+	davGroup.Add("PROPFIND", "/", h.CalDAV.PropfindHome, basicAuth)
+	davGroup.Add("PROPFIND", "/todos/", h.CalDAV.PropfindTodos, basicAuth)
+	davGroup.Add("REPORT", "/todos/", h.CalDAV.Report, basicAuth)
+	davGroup.Add("PROPFIND", "/todos/:resource", h.CalDAV.PropfindTodo, basicAuth)
+	davGroup.GET("/todos/:resource", h.CalDAV.GetTodo, basicAuth)
+	davGroup.PUT("/todos/:resource", h.CalDAV.PutTodo, basicAuth)
+	davGroup.DELETE("/todos/:resource", h.CalDAV.DeleteTodo, basicAuth)
+
+	// Protected routes
+	e.GET("/dashboard", h.Page.Dashboard, authMiddleware)
+	e.GET("/trash", h.Page.Trash, authMiddleware)
+	e.DELETE("/trash", h.Todo.EmptyTrash, authMiddleware)
+	e.POST("/undo/:token", h.Todo.Undo, authMiddleware)
+	e.GET("/todos.ics", h.Calendar.Download, authMiddleware)
+
+	// Todo API routes
+	todoGroup := e.Group("/todos", authMiddleware)
+	todoGroup.GET("", h.Todo.GetAllTodos)
+	todoGroup.GET("/:id", h.Todo.GetTodo)
+	todoGroup.POST("", h.Todo.CreateTodo, idempotencyMiddleware)
+	todoGroup.POST("/bulk", h.Todo.BulkUpdate)
+	todoGroup.POST("/preview", h.Todo.PreviewTodo)
+	todoGroup.PUT("/:id", h.Todo.UpdateTodo)
+	todoGroup.PATCH("/:id", h.Todo.PatchTodo)
+	todoGroup.PUT("/:id/complete", h.Todo.UpdateTodoStatus)
+	todoGroup.PUT("/:id/incomplete", h.Todo.UpdateTodoStatus)
+	todoGroup.DELETE("/:id", h.Todo.DeleteTodo)
+	todoGroup.GET("/:id/history", h.Todo.GetTodoHistory)
+	todoGroup.POST("/:id/restore", h.Todo.RestoreTodo)
+
+	// Activity feed
+	e.GET("/activity", h.Activity.GetActivityFeed, authMiddleware)
+
+	// Live updates
+	e.GET("/events", h.Events.Stream, authMiddleware)
+
+	// Webhooks
+	webhookGroup := e.Group("/webhooks", authMiddleware)
+	webhookGroup.GET("", h.Webhooks.GetWebhooks)
+	webhookGroup.POST("", h.Webhooks.CreateWebhook)
+	webhookGroup.DELETE("/:id", h.Webhooks.DeleteWebhook)
+	webhookGroup.GET("/:id/deliveries", h.Webhooks.GetDeliveries)
+	webhookGroup.POST("/:id/test", h.Webhooks.SendTestEvent)
+
+	// Capture URL management
+	e.GET("/capture", h.Capture.GetCaptureURL, authMiddleware)
+	e.POST("/capture", h.Capture.RotateCaptureURL, authMiddleware)
+
+	// Calendar feed management
+	e.GET("/calendar", h.Calendar.GetCalendarURL, authMiddleware)
+	e.POST("/calendar", h.Calendar.RotateCalendarURL, authMiddleware)
+
+	// App passwords for sync clients
+	appPasswordGroup := e.Group("/app-passwords", authMiddleware)
+	appPasswordGroup.GET("", h.AppPassword.GetAppPasswords)
+	appPasswordGroup.POST("", h.AppPassword.CreateAppPassword)
+	appPasswordGroup.DELETE("/:id", h.AppPassword.DeleteAppPassword)
+
+	// Imports from other todo apps
+	e.GET("/import", h.Import.GetImport, authMiddleware)
+	e.POST("/import", h.Import.Import, authMiddleware)
+
+	// Exports of all todos
+	e.GET("/export", h.Export.Export, authMiddleware)
+
+	// Account deletion and data portability
+	accountGroup := e.Group("/account", authMiddleware)
+	accountGroup.GET("", h.Account.GetAccount)
+	accountGroup.POST("/deletion", h.Account.RequestDeletion)
+	accountGroup.DELETE("/deletion", h.Account.CancelDeletion)
+	accountGroup.GET("/data", h.Account.DownloadData)
+}
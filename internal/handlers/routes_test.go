@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestRoutesAreDescribedByOpenAPISpec(t *testing.T) {
+	// The handlers are never invoked, so zero values are enough to register the routes
+	e := echo.New()
+	RegisterRoutes(e, &Routes{})
+
+	spec := OpenAPISpec()
+	routes := e.Routes()
+	if len(routes) == 0 {
+		t.Fatal("Expected routes to be registered")
+	}
+
+	for _, route := range routes {
+		// Echo registers these for groups with middleware
+		if route.Method == echo.RouteNotFound {
+			continue
+		}
+		if !spec.HasOperation(route.Method, route.Path) {
+			t.Errorf("Route %s %s is missing from the OpenAPI document", route.Method, route.Path)
+		}
+	}
+}
@@ -1,28 +1,36 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
 	"github.com/starbops/gottodo/internal/services"
+	"github.com/starbops/gottodo/pkg/quickadd"
 	"github.com/starbops/gottodo/ui/templates"
 )
 
 // TodoHandler handles HTTP requests for todos
 type TodoHandler struct {
 	todoService *services.TodoService
+	undoService *services.UndoService
 }
 
 // NewTodoHandler creates a new TodoHandler
-func NewTodoHandler(todoService *services.TodoService) *TodoHandler {
+func NewTodoHandler(todoService *services.TodoService, undoService *services.UndoService) *TodoHandler {
 	return &TodoHandler{
 		todoService: todoService,
+		undoService: undoService,
 	}
 }
 
-// GetAllTodos handles GET /todos
+// GetAllTodos handles GET /todos, rendering the todo list for htmx requests
 func (h *TodoHandler) GetAllTodos(c echo.Context) error {
 	userID := c.Get("user_id").(string)
 	todos, err := h.todoService.GetUserTodos(c.Request().Context(), userID)
@@ -31,6 +39,10 @@ func (h *TodoHandler) GetAllTodos(c echo.Context) error {
 			"error": err.Error(),
 		})
 	}
+
+	if c.Request().Header.Get("HX-Request") == "true" {
+		return templates.TodoListComponent(todos).Render(c.Request().Context(), c.Response().Writer)
+	}
 	return c.JSON(http.StatusOK, todos)
 }
 
@@ -46,13 +58,34 @@ func (h *TodoHandler) GetTodo(c echo.Context) error {
 		})
 	}
 
+	setTodoETag(c, todo)
 	return c.JSON(http.StatusOK, todo)
 }
 
 // CreateTodoRequest represents the request body for creating a todo
 type CreateTodoRequest struct {
-	Title       string `json:"title" form:"title"`
-	Description string `json:"description" form:"description"`
+	Title       string          `json:"title" form:"title"`
+	Description string          `json:"description" form:"description"`
+	DueAt       *time.Time      `json:"due_at,omitempty"`
+	Priority    models.Priority `json:"priority,omitempty" form:"priority"`
+	Project     string          `json:"project,omitempty" form:"project"`
+	Tags        []string        `json:"tags,omitempty" form:"tags"`
+	Recurrence  string          `json:"recurrence,omitempty" form:"recurrence"`
+
+	// QuickAdd reads the due date, tags, priority, project and recurrence
+	// from the title, as in "Pay invoice tomorrow 5pm #finance !high".
+	// Fields given explicitly take precedence.
+	QuickAdd bool `json:"quick_add,omitempty" form:"quick_add"`
+
+	// TimeZone is the IANA time zone quick-add dates are relative to,
+	// defaulting to the server's
+	TimeZone string `json:"time_zone,omitempty" form:"time_zone"`
+}
+
+// QuickAddPreviewRequest represents the text typed into the todo form
+type QuickAddPreviewRequest struct {
+	Title    string `json:"title" form:"title"`
+	TimeZone string `json:"time_zone,omitempty" form:"time_zone"`
 }
 
 // UpdateTodoRequest represents the request body for updating a todo
@@ -66,28 +99,61 @@ func (h *TodoHandler) CreateTodo(c echo.Context) error {
 	// Get user ID from context
 	userID := c.Get("user_id").(string)
 
-	// Parse form data
-	title := c.FormValue("title")
-	description := c.FormValue("description")
-
-	// Validate input
-	if title == "" {
-		return templates.TodoListWithError("Title is required", nil).Render(c.Request().Context(), c.Response().Writer)
+	// Parse form or JSON data
+	var req CreateTodoRequest
+	if err := c.Bind(&req); err != nil {
+		if wantsJSON(c) {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body",
+			})
+		}
+		return templates.TodoListWithError("Invalid form data", nil).Render(c.Request().Context(), c.Response().Writer)
 	}
 
 	// Create todo
 	todo := &models.Todo{
-		Title:       title,
-		Description: description,
+		Title:       req.Title,
+		Description: req.Description,
 		UserID:      userID,
 		Completed:   false,
 	}
+	if req.QuickAdd {
+		todo = quickAddTodo(req.Title, referenceTime(req.TimeZone))
+		todo.Description = req.Description
+		todo.UserID = userID
+	}
+	applyPlanningFields(todo, &req)
+
+	// Validate input
+	if todo.Title == "" {
+		if wantsJSON(c) {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Title is required",
+			})
+		}
+		return templates.TodoListWithError("Title is required", nil).Render(c.Request().Context(), c.Response().Writer)
+	}
 
 	err := h.todoService.CreateTodo(c.Request().Context(), todo)
 	if err != nil {
+		status := http.StatusInternalServerError
+		if isInvalidTodo(err) {
+			status = http.StatusBadRequest
+		}
+		if wantsJSON(c) {
+			return c.JSON(status, map[string]string{
+				"error": err.Error(),
+			})
+		}
 		return templates.TodoListWithError(fmt.Sprintf("Failed to create todo: %v", err), nil).Render(c.Request().Context(), c.Response().Writer)
 	}
 
+	// API clients get the new todo
+	if wantsJSON(c) {
+		setTodoETag(c, todo)
+		return c.JSON(http.StatusCreated, todo)
+	}
+
 	// Get updated list of todos
 	todos, err := h.todoService.GetUserTodos(c.Request().Context(), userID)
 	if err != nil {
@@ -98,42 +164,183 @@ func (h *TodoHandler) CreateTodo(c echo.Context) error {
 	return templates.TodoListComponent(todos).Render(c.Request().Context(), c.Response().Writer)
 }
 
+// PreviewTodo handles POST /todos/preview, showing what quick-add reads
+// from the text typed into the todo form
+func (h *TodoHandler) PreviewTodo(c echo.Context) error {
+	var req QuickAddPreviewRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	todo := quickAddTodo(req.Title, referenceTime(req.TimeZone))
+	if wantsJSON(c) {
+		return c.JSON(http.StatusOK, todo)
+	}
+	return templates.QuickAddPreview(todo).Render(c.Request().Context(), c.Response().Writer)
+}
+
+// quickAddTodo builds an unsaved todo from quick-add text
+func quickAddTodo(text string, now time.Time) *models.Todo {
+	parsed := quickadd.Parse(text, now)
+	return &models.Todo{
+		Title:      parsed.Title,
+		DueAt:      parsed.Due,
+		Priority:   models.Priority(parsed.Priority),
+		Project:    parsed.Project,
+		Tags:       parsed.Tags,
+		Recurrence: parsed.Recurrence,
+	}
+}
+
+// applyPlanningFields copies the planning fields given in a create request
+// onto the todo
+func applyPlanningFields(todo *models.Todo, req *CreateTodoRequest) {
+	if req.DueAt != nil {
+		todo.DueAt = req.DueAt
+	}
+	if req.Priority != models.PriorityNone {
+		todo.Priority = req.Priority
+	}
+	if req.Project != "" {
+		todo.Project = req.Project
+	}
+	if len(req.Tags) > 0 {
+		todo.Tags = req.Tags
+	}
+	if req.Recurrence != "" {
+		todo.Recurrence = req.Recurrence
+	}
+}
+
+// isInvalidTodo reports whether the todo service refused a todo because of
+// the values of its fields
+func isInvalidTodo(err error) bool {
+	return errors.Is(err, services.ErrEmptyTitle) || errors.Is(err, services.ErrInvalidPriority) ||
+		errors.Is(err, services.ErrInvalidTags) || errors.Is(err, services.ErrInvalidRecurrence)
+}
+
+// referenceTime is the current time in the named time zone, falling back to
+// the server's time zone for empty or unknown names
+func referenceTime(timeZone string) time.Time {
+	now := time.Now()
+	if timeZone == "" {
+		return now
+	}
+	loc, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return now
+	}
+	return now.In(loc)
+}
+
 // UpdateTodo handles PUT /todos/:id
 func (h *TodoHandler) UpdateTodo(c echo.Context) error {
 	todoID := c.Param("id")
 
-	var req struct {
-		Title       string `json:"title"`
-		Description string `json:"description"`
-	}
-
+	var req UpdateTodoRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
 			"error": "Invalid request body",
 		})
 	}
 
-	// Get user ID and check ownership
+	// Get user ID from context
 	userID := c.Get("user_id").(string)
 
-	// First check if the todo exists and belongs to this user
-	_, err := h.todoService.GetTodo(c.Request().Context(), todoID, userID)
+	// An If-Match header pins the update to the version the client last saw
+	expectedVersion := ifMatchVersion(c)
+	todo, err := h.todoService.UpdateTodo(c.Request().Context(), todoID, userID, req.Title, req.Description, expectedVersion)
+	if errors.Is(err, repositories.ErrConflict) {
+		status := http.StatusConflict
+		if hasIfMatch(c) {
+			status = http.StatusPreconditionFailed
+		}
+		return c.JSON(status, map[string]string{
+			"error": err.Error(),
+		})
+	}
 	if err != nil {
 		return c.JSON(http.StatusNotFound, map[string]string{
 			"error": err.Error(),
 		})
 	}
 
-	todo, err := h.todoService.UpdateTodo(c.Request().Context(), todoID, req.Title, req.Description)
+	setTodoETag(c, todo)
+	return c.JSON(http.StatusOK, todo)
+}
+
+// PatchTodo handles PATCH /todos/:id with a JSON Merge Patch (RFC 7396) body
+func (h *TodoHandler) PatchTodo(c echo.Context) error {
+	todoID := c.Param("id")
+
+	mediaType, _, _ := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	if mediaType != "application/merge-patch+json" {
+		return c.JSON(http.StatusUnsupportedMediaType, map[string]string{
+			"error": "Content-Type must be application/merge-patch+json",
+		})
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	patch, err := models.ParseTodoMergePatch(body)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	// Get user ID from context
+	userID := c.Get("user_id").(string)
+
+	todo, _, err := h.todoService.PatchTodo(c.Request().Context(), todoID, userID, patch, ifMatchVersion(c))
+	if errors.Is(err, repositories.ErrConflict) {
+		status := http.StatusConflict
+		if hasIfMatch(c) {
+			status = http.StatusPreconditionFailed
+		}
+		return c.JSON(status, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if isInvalidTodo(err) {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
 			"error": err.Error(),
 		})
 	}
 
+	setTodoETag(c, todo)
 	return c.JSON(http.StatusOK, todo)
 }
 
+// rejectStaleTodo answers a change refused because the todo no longer
+// matches the If-Match header with 412 Precondition Failed, rendering the
+// current todo so the page can show the latest state
+func (h *TodoHandler) rejectStaleTodo(c echo.Context, todoID, userID string) error {
+	todo, err := h.todoService.GetTodo(c.Request().Context(), todoID, userID)
+	if err == nil {
+		setTodoETag(c, todo)
+	}
+	if err != nil || wantsJSON(c) {
+		return c.JSON(http.StatusPreconditionFailed, map[string]string{
+			"error": repositories.ErrConflict.Error(),
+		})
+	}
+	c.Response().WriteHeader(http.StatusPreconditionFailed)
+	return templates.TodoItem(todo).Render(c.Request().Context(), c.Response().Writer)
+}
+
 // UpdateTodoStatus handles PUT /todos/:id/complete and /todos/:id/incomplete
 func (h *TodoHandler) UpdateTodoStatus(c echo.Context) error {
 	// Get user ID from context
@@ -151,9 +358,17 @@ func (h *TodoHandler) UpdateTodoStatus(c echo.Context) error {
 		completed = false
 	}
 
-	// Update todo
-	err := h.todoService.UpdateTodoStatus(c.Request().Context(), todoID, userID, completed)
+	// Update todo, unless it changed since the client rendered it
+	undo, err := h.undoService.UpdateTodoStatus(c.Request().Context(), todoID, userID, completed, ifMatchVersion(c))
+	if errors.Is(err, repositories.ErrConflict) && hasIfMatch(c) {
+		return h.rejectStaleTodo(c, todoID, userID)
+	}
 	if err != nil {
+		if wantsJSON(c) {
+			return c.JSON(todoErrorStatus(err), map[string]string{
+				"error": err.Error(),
+			})
+		}
 		return c.String(http.StatusBadRequest, fmt.Sprintf("Failed to update todo: %v", err))
 	}
 
@@ -163,8 +378,19 @@ func (h *TodoHandler) UpdateTodoStatus(c echo.Context) error {
 		return c.String(http.StatusInternalServerError, fmt.Sprintf("Failed to get updated todo: %v", err))
 	}
 
-	// Return updated todo HTML
-	return templates.TodoItem(todo).Render(c.Request().Context(), c.Response().Writer)
+	// API clients get the updated todo and the token to undo the change
+	if wantsJSON(c) {
+		setTodoETag(c, todo)
+		setUndoToken(c, undo)
+		return c.JSON(http.StatusOK, todo)
+	}
+
+	// Return updated todo HTML with an undo toast
+	setTodoETag(c, todo)
+	if err := templates.TodoItem(todo).Render(c.Request().Context(), c.Response().Writer); err != nil {
+		return err
+	}
+	return templates.UndoToast(undo).Render(c.Request().Context(), c.Response().Writer)
 }
 
 // DeleteTodo handles DELETE /todos/:id
@@ -175,18 +401,207 @@ func (h *TodoHandler) DeleteTodo(c echo.Context) error {
 	// Get todo ID from URL
 	todoID := c.Param("id")
 
-	// Delete todo
-	err := h.todoService.DeleteTodo(c.Request().Context(), todoID, userID)
+	// Delete todo, unless it changed since the client rendered it
+	undo, err := h.undoService.DeleteTodo(c.Request().Context(), todoID, userID, ifMatchVersion(c))
+	if errors.Is(err, repositories.ErrConflict) && hasIfMatch(c) {
+		return h.rejectStaleTodo(c, todoID, userID)
+	}
 	if err != nil {
+		if wantsJSON(c) {
+			return c.JSON(todoErrorStatus(err), map[string]string{
+				"error": err.Error(),
+			})
+		}
 		return c.String(http.StatusBadRequest, fmt.Sprintf("Failed to delete todo: %v", err))
 	}
 
+	// API clients only need the token to undo the deletion
+	if wantsJSON(c) {
+		setUndoToken(c, undo)
+		return c.NoContent(http.StatusNoContent)
+	}
+
 	// Get all todos for the user to refresh the list
 	todos, err := h.todoService.GetUserTodos(c.Request().Context(), userID)
 	if err != nil {
 		return c.String(http.StatusInternalServerError, fmt.Sprintf("Failed to get todos: %v", err))
 	}
 
-	// Return the updated todo list
-	return templates.TodoListComponent(todos).Render(c.Request().Context(), c.Response().Writer)
+	// Return the updated todo list with an undo toast
+	if err := templates.TodoListComponent(todos).Render(c.Request().Context(), c.Response().Writer); err != nil {
+		return err
+	}
+	return templates.UndoToast(undo).Render(c.Request().Context(), c.Response().Writer)
+}
+
+// BulkRequest represents the request body for changing several todos at once
+type BulkRequest struct {
+	IDs    []string `json:"ids" form:"ids"`
+	Action string   `json:"action" form:"action"`
+
+	// Value is the project of move_to_project or the tag of add_tag and remove_tag
+	Value string `json:"value,omitempty" form:"value"`
+}
+
+// BulkItemResponse reports the outcome of a bulk action for a single todo
+type BulkItemResponse struct {
+	ID    string       `json:"id"`
+	OK    bool         `json:"ok"`
+	Error string       `json:"error,omitempty"`
+	Todo  *models.Todo `json:"todo,omitempty"`
+}
+
+// BulkResponse is the body of a successful bulk action
+type BulkResponse struct {
+	Results []BulkItemResponse `json:"results"`
+}
+
+// BulkUpdate handles POST /todos/bulk
+func (h *TodoHandler) BulkUpdate(c echo.Context) error {
+	// Get user ID from context
+	userID := c.Get("user_id").(string)
+
+	var req BulkRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	results, err := h.todoService.BulkUpdate(c.Request().Context(), userID, req.IDs, services.BulkAction(req.Action), req.Value)
+	if err != nil {
+		if c.Request().Header.Get("HX-Request") == "true" {
+			// Keep the list as it is and only report the problem
+			c.Response().Header().Set("HX-Reswap", "none")
+			return templates.BulkResultToast(0, nil, err.Error()).Render(c.Request().Context(), c.Response().Writer)
+		}
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	items := make([]BulkItemResponse, len(results))
+	var failures []templates.BulkFailure
+	for i, result := range results {
+		items[i] = BulkItemResponse{ID: result.TodoID, OK: result.Err == nil, Todo: result.Todo}
+		if result.Err != nil {
+			items[i].Error = result.Err.Error()
+			failures = append(failures, templates.BulkFailure{ID: result.TodoID, Error: result.Err.Error()})
+		}
+	}
+
+	// The dashboard gets the refreshed list and a toast listing the todos
+	// that could not be changed
+	if c.Request().Header.Get("HX-Request") == "true" {
+		todos, err := h.todoService.GetUserTodos(c.Request().Context(), userID)
+		if err != nil {
+			return c.String(http.StatusInternalServerError, fmt.Sprintf("Failed to get todos: %v", err))
+		}
+		titles := make(map[string]string, len(todos))
+		for _, todo := range todos {
+			titles[todo.ID] = todo.Title
+		}
+		for i := range failures {
+			failures[i].Title = titles[failures[i].ID]
+		}
+
+		if err := templates.TodoListComponent(todos).Render(c.Request().Context(), c.Response().Writer); err != nil {
+			return err
+		}
+		return templates.BulkResultToast(len(results)-len(failures), failures, "").Render(c.Request().Context(), c.Response().Writer)
+	}
+
+	return c.JSON(http.StatusOK, BulkResponse{
+		Results: items,
+	})
+}
+
+// Undo handles POST /undo/:token
+func (h *TodoHandler) Undo(c echo.Context) error {
+	// Get user ID from context
+	userID := c.Get("user_id").(string)
+
+	// Revert the action captured by the token
+	restored, undoErr := h.undoService.Undo(c.Request().Context(), c.Param("token"), userID)
+
+	// API clients get the restored todo
+	if wantsJSON(c) {
+		if undoErr != nil {
+			return c.JSON(undoErrorStatus(undoErr), map[string]string{
+				"error": undoErr.Error(),
+			})
+		}
+		setTodoETag(c, restored)
+		return c.JSON(http.StatusOK, restored)
+	}
+
+	// Get all todos for the user to refresh the list
+	todos, err := h.todoService.GetUserTodos(c.Request().Context(), userID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("Failed to get todos: %v", err))
+	}
+
+	// Return the updated todo list, replacing the toast
+	if err := templates.TodoListComponent(todos).Render(c.Request().Context(), c.Response().Writer); err != nil {
+		return err
+	}
+	if undoErr != nil {
+		return templates.UndoFailedToast(undoErr.Error()).Render(c.Request().Context(), c.Response().Writer)
+	}
+	return templates.UndoToast(nil).Render(c.Request().Context(), c.Response().Writer)
+}
+
+// GetTodoHistory handles GET /todos/:id/history
+func (h *TodoHandler) GetTodoHistory(c echo.Context) error {
+	// Get user ID from context
+	userID := c.Get("user_id").(string)
+
+	// Get todo ID from URL
+	todoID := c.Param("id")
+
+	history, err := h.todoService.GetTodoHistory(c.Request().Context(), todoID, userID)
+	if err != nil {
+		return c.String(http.StatusNotFound, fmt.Sprintf("Failed to get todo history: %v", err))
+	}
+
+	// Return the history panel HTML
+	return templates.TodoHistory(history).Render(c.Request().Context(), c.Response().Writer)
+}
+
+// RestoreTodo handles POST /todos/:id/restore
+func (h *TodoHandler) RestoreTodo(c echo.Context) error {
+	// Get user ID from context
+	userID := c.Get("user_id").(string)
+
+	// Get todo ID from URL
+	todoID := c.Param("id")
+
+	// Restore todo
+	err := h.todoService.RestoreTodo(c.Request().Context(), todoID, userID)
+	if err != nil {
+		return c.String(http.StatusBadRequest, fmt.Sprintf("Failed to restore todo: %v", err))
+	}
+
+	// Get the remaining trashed todos to refresh the list
+	todos, err := h.todoService.GetTrashedTodos(c.Request().Context(), userID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("Failed to get trash: %v", err))
+	}
+
+	// Return the updated trash list
+	return templates.TrashList(todos).Render(c.Request().Context(), c.Response().Writer)
+}
+
+// EmptyTrash handles DELETE /trash
+func (h *TodoHandler) EmptyTrash(c echo.Context) error {
+	// Get user ID from context
+	userID := c.Get("user_id").(string)
+
+	// Permanently remove all trashed todos
+	if _, err := h.todoService.EmptyTrash(c.Request().Context(), userID); err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("Failed to empty trash: %v", err))
+	}
+
+	// Return the now empty trash list
+	return templates.TrashList(nil).Render(c.Request().Context(), c.Response().Writer)
 }
@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
+	"github.com/starbops/gottodo/internal/services"
+)
+
+// newTodoTestServer serves the todo creation, status, deletion and bulk
+// routes for user1
+func newTodoTestServer(t *testing.T) (*echo.Echo, *services.TodoService) {
+	t.Helper()
+
+	todoService := services.NewTodoService(repositories.NewMemoryTodoRepository())
+	undoService := services.NewUndoService(todoService, repositories.NewMemoryUndoRepository(), 0)
+	handler := NewTodoHandler(todoService, undoService)
+
+	e := echo.New()
+	g := e.Group("/todos", func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("user_id", "user1")
+			return next(c)
+		}
+	})
+	g.POST("", handler.CreateTodo)
+	g.POST("/preview", handler.PreviewTodo)
+	g.POST("/bulk", handler.BulkUpdate)
+	g.PUT("/:id/complete", handler.UpdateTodoStatus)
+	g.DELETE("/:id", handler.DeleteTodo)
+	return e, todoService
+}
+
+func TestTodoHandler_CreateTodo_QuickAdd(t *testing.T) {
+	e, todoService := newTodoTestServer(t)
+
+	// Explicit fields take precedence over the ones read from the title
+	rec := serveWebhookRequest(e, http.MethodPost, "/todos", echo.MIMEApplicationJSON,
+		`{"title":"Pay invoice tomorrow #finance !high +work every month","quick_add":true,"priority":"low","time_zone":"Asia/Taipei"}`, true)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var todo models.Todo
+	if err := json.Unmarshal(rec.Body.Bytes(), &todo); err != nil {
+		t.Fatalf("Failed to decode todo: %v", err)
+	}
+	if todo.Title != "Pay invoice" || todo.Project != "work" || todo.Recurrence != "FREQ=MONTHLY" {
+		t.Errorf("Expected the parsed title, project and recurrence, got %+v", todo)
+	}
+	if todo.Priority != models.PriorityLow {
+		t.Errorf("Expected the explicit priority, got %q", todo.Priority)
+	}
+	if len(todo.Tags) != 1 || todo.Tags[0] != "finance" {
+		t.Errorf("Expected the finance tag, got %v", todo.Tags)
+	}
+	if !todo.DueAllDay() {
+		t.Errorf("Expected a whole-day due date, got %v", todo.DueAt)
+	}
+
+	// Without quick-add the title is taken as it is
+	rec = serveWebhookRequest(e, http.MethodPost, "/todos", echo.MIMEApplicationJSON,
+		`{"title":"Read #1 tomorrow","due_at":"2025-03-14T09:00:00Z","tags":["books"]}`, true)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	todo = models.Todo{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &todo); err != nil {
+		t.Fatalf("Failed to decode todo: %v", err)
+	}
+	want := time.Date(2025, time.March, 14, 9, 0, 0, 0, time.UTC)
+	if todo.Title != "Read #1 tomorrow" || todo.DueAt == nil || !todo.DueAt.Equal(want) || len(todo.Tags) != 1 {
+		t.Errorf("Expected the todo as sent, got %+v", todo)
+	}
+
+	rec = serveWebhookRequest(e, http.MethodPost, "/todos", echo.MIMEApplicationJSON,
+		`{"title":"Call bank","priority":"urgent"}`, true)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown priority, got %d", rec.Code)
+	}
+	rec = serveWebhookRequest(e, http.MethodPost, "/todos", echo.MIMEApplicationJSON,
+		`{"title":"Call bank","recurrence":"FREQ=DAILY\r\nATTACH:x"}`, true)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a recurrence that is not an RRULE, got %d", rec.Code)
+	}
+	rec = serveWebhookRequest(e, http.MethodPost, "/todos", echo.MIMEApplicationJSON,
+		`{"title":"Call bank","tags":["money\nlaundering"]}`, true)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a tag with a line break, got %d", rec.Code)
+	}
+
+	// Text made of details alone has no title
+	rec = serveWebhookRequest(e, http.MethodPost, "/todos", echo.MIMEApplicationJSON,
+		`{"title":"tomorrow #finance","quick_add":true}`, true)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without a title, got %d", rec.Code)
+	}
+
+	todos, err := todoService.GetUserTodos(context.Background(), "user1")
+	if err != nil || len(todos) != 2 {
+		t.Errorf("Expected two todos, got %d (%v)", len(todos), err)
+	}
+}
+
+func TestTodoHandler_CreateTodo_QuickAddForm(t *testing.T) {
+	e, _ := newTodoTestServer(t)
+
+	form := url.Values{
+		"title":       {"Standup every weekday at 9:30am #team !!"},
+		"description": {"Daily sync"},
+		"quick_add":   {"true"},
+		"time_zone":   {"Europe/Berlin"},
+	}
+	rec := serveWebhookRequest(e, http.MethodPost, "/todos", echo.MIMEApplicationForm, form.Encode(), false)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{"Standup", "Daily sync", "Repeats every weekday", "medium priority", "#team", "data-local"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %q in the todo list, got %s", want, body)
+		}
+	}
+}
+
+func TestTodoHandler_PreviewTodo(t *testing.T) {
+	e, todoService := newTodoTestServer(t)
+
+	form := url.Values{"title": {"Pay invoice tomorrow 5pm #finance !high every month"}}
+	rec := serveWebhookRequest(e, http.MethodPost, "/todos/preview", echo.MIMEApplicationForm, form.Encode(), false)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{"Creates", "Pay invoice", "Due", "Repeats every month", "high priority", "#finance"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %q in the preview, got %s", want, body)
+		}
+	}
+
+	form.Set("title", "tomorrow #finance")
+	rec = serveWebhookRequest(e, http.MethodPost, "/todos/preview", echo.MIMEApplicationForm, form.Encode(), false)
+	if !strings.Contains(rec.Body.String(), "Add a title") {
+		t.Errorf("Expected a hint about the missing title, got %s", rec.Body.String())
+	}
+
+	rec = serveWebhookRequest(e, http.MethodPost, "/todos/preview", echo.MIMEApplicationJSON,
+		`{"title":"Water plants every 3 days +garden","time_zone":"America/New_York"}`, true)
+	var todo models.Todo
+	if err := json.Unmarshal(rec.Body.Bytes(), &todo); err != nil {
+		t.Fatalf("Failed to decode preview: %v", err)
+	}
+	if todo.Title != "Water plants" || todo.Project != "garden" || todo.Recurrence != "FREQ=DAILY;INTERVAL=3" || todo.ID != "" {
+		t.Errorf("Expected an unsaved todo with the parsed details, got %+v", todo)
+	}
+
+	// Previews are never saved
+	todos, _ := todoService.GetUserTodos(context.Background(), "user1")
+	if len(todos) != 0 {
+		t.Errorf("Expected no todos, got %d", len(todos))
+	}
+}
+
+func TestReferenceTime(t *testing.T) {
+	if loc := referenceTime("Asia/Tokyo").Location().String(); loc != "Asia/Tokyo" {
+		t.Errorf("Expected the requested time zone, got %s", loc)
+	}
+	if loc := referenceTime("Not/AZone").Location(); loc != time.Local {
+		t.Errorf("Expected the server's time zone for unknown names, got %s", loc)
+	}
+}
+
+func TestTodoHandler_BulkUpdate_Dashboard(t *testing.T) {
+	e, todoService := newTodoTestServer(t)
+	ctx := context.Background()
+
+	todo := models.NewTodo("user1", "Buy milk", "")
+	if err := todoService.CreateTodo(ctx, todo); err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+	missing := "00000000-0000-0000-0000-000000000000"
+
+	// serveBulk submits the bulk action bar of the dashboard
+	serveBulk := func(form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/todos/bulk", strings.NewReader(form.Encode()))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+		req.Header.Set("HX-Request", "true")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serveBulk(url.Values{"ids": {todo.ID, missing}, "action": {"add_tag"}, "value": {"groceries"}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// The list shows the new tag and the toast names the todo that failed
+	body := rec.Body.String()
+	for _, want := range []string{"#groceries", "1 updated", "1 could not be changed", missing + ": todo not found"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %q in the response, got %s", want, body)
+		}
+	}
+
+	rec = serveBulk(url.Values{"ids": {todo.ID}, "action": {"move_to_project"}, "value": {"+home"}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	stored, err := todoService.GetTodo(ctx, todo.ID, "user1")
+	if err != nil {
+		t.Fatalf("Failed to get todo: %v", err)
+	}
+	if stored.Project != "home" || len(stored.Tags) != 1 {
+		t.Errorf("Expected the todo in project home with its tag, got %+v", stored)
+	}
+
+	// A tag action without a tag leaves the list as it is
+	rec = serveBulk(url.Values{"ids": {todo.ID}, "action": {"remove_tag"}})
+	if rec.Header().Get("HX-Reswap") != "none" || !strings.Contains(rec.Body.String(), "tag cannot be empty") {
+		t.Errorf("Expected only an error toast, got %s", rec.Body.String())
+	}
+}
+
+func TestTodoHandler_IfMatch(t *testing.T) {
+	e, todoService := newTodoTestServer(t)
+	ctx := context.Background()
+
+	todo := models.NewTodo("user1", "Buy milk", "")
+	if err := todoService.CreateTodo(ctx, todo); err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+
+	// serveIfMatch sends a request with the If-Match header
+	serveIfMatch := func(method, path, ifMatch string, jsonClient bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("If-Match", ifMatch)
+		if jsonClient {
+			req.Header.Set(echo.HeaderAccept, echo.MIMEApplicationJSON)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	completePath, todoPath := "/todos/"+todo.ID+"/complete", "/todos/"+todo.ID
+
+	// Stale, weak and malformed entity tags never match
+	for _, ifMatch := range []string{`"2"`, `W/"1"`, `1`} {
+		if rec := serveIfMatch(http.MethodPut, completePath, ifMatch, true); rec.Code != http.StatusPreconditionFailed {
+			t.Errorf("Expected 412 completing with If-Match %s, got %d", ifMatch, rec.Code)
+		}
+		if rec := serveIfMatch(http.MethodDelete, todoPath, ifMatch, true); rec.Code != http.StatusPreconditionFailed {
+			t.Errorf("Expected 412 deleting with If-Match %s, got %d", ifMatch, rec.Code)
+		}
+	}
+
+	// The dashboard gets the current todo along with the 412
+	rec := serveIfMatch(http.MethodPut, completePath, `"2"`, false)
+	if rec.Code != http.StatusPreconditionFailed || rec.Header().Get("ETag") != `"1"` || !strings.Contains(rec.Body.String(), "Buy milk") {
+		t.Errorf("Expected the current todo with 412, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	stored, err := todoService.GetTodo(ctx, todo.ID, "user1")
+	if err != nil {
+		t.Fatalf("Failed to get todo: %v", err)
+	}
+	if stored.Completed || stored.Version != 1 {
+		t.Fatalf("Expected the todo to be unchanged, got %+v", stored)
+	}
+
+	// The current version goes through
+	rec = serveIfMatch(http.MethodPut, completePath, `"1"`, true)
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") != `"2"` {
+		t.Fatalf("Expected 200 with the new ETag, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := serveIfMatch(http.MethodDelete, todoPath, `"1"`, true); rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expected 412 deleting the completed todo's old version, got %d", rec.Code)
+	}
+	if rec := serveIfMatch(http.MethodDelete, todoPath, `"2"`, true); rec.Code != http.StatusNoContent {
+		t.Errorf("Expected 204, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
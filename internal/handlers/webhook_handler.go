@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
+	"github.com/starbops/gottodo/internal/services"
+	"github.com/starbops/gottodo/pkg/auth"
+	"github.com/starbops/gottodo/ui/templates"
+)
+
+// WebhookHandler handles HTTP requests for webhooks and their deliveries
+type WebhookHandler struct {
+	webhookService *services.WebhookService
+}
+
+// NewWebhookHandler creates a new WebhookHandler
+func NewWebhookHandler(webhookService *services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// CreateWebhookRequest represents the request body for registering a webhook
+type CreateWebhookRequest struct {
+	URL    string                `json:"url" form:"url"`
+	Events []models.WebhookEvent `json:"events" form:"events"`
+}
+
+// webhookErrorStatus maps errors of webhook actions to HTTP status codes
+func webhookErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repositories.ErrWebhookNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidWebhookURL), errors.Is(err, services.ErrInvalidWebhookEvent),
+		errors.Is(err, services.ErrForbiddenWebhookAddress):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GetWebhooks handles GET /webhooks, rendering the webhooks page for browsers
+func (h *WebhookHandler) GetWebhooks(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+
+	webhooks, err := h.webhookService.GetUserWebhooks(c.Request().Context(), userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	if wantsJSON(c) {
+		if webhooks == nil {
+			webhooks = []*models.Webhook{}
+		}
+		return c.JSON(http.StatusOK, webhooks)
+	}
+
+	user := c.Get("user").(*auth.User)
+	return templates.Webhooks(webhooks, user.Email).Render(c.Request().Context(), c.Response().Writer)
+}
+
+// CreateWebhook handles POST /webhooks
+func (h *WebhookHandler) CreateWebhook(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+
+	var req CreateWebhookRequest
+	if err := c.Bind(&req); err != nil {
+		if wantsJSON(c) {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body",
+			})
+		}
+		return h.renderList(c, userID, "Invalid form data")
+	}
+
+	webhook, err := h.webhookService.CreateWebhook(c.Request().Context(), userID, req.URL, req.Events)
+	if err != nil {
+		if wantsJSON(c) {
+			return c.JSON(webhookErrorStatus(err), map[string]string{
+				"error": err.Error(),
+			})
+		}
+		return h.renderList(c, userID, fmt.Sprintf("Failed to add webhook: %v", err))
+	}
+
+	// API clients get the new webhook, including its secret
+	if wantsJSON(c) {
+		return c.JSON(http.StatusCreated, webhook)
+	}
+	return h.renderList(c, userID, "")
+}
+
+// DeleteWebhook handles DELETE /webhooks/:id
+func (h *WebhookHandler) DeleteWebhook(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+
+	if err := h.webhookService.DeleteWebhook(c.Request().Context(), c.Param("id"), userID); err != nil {
+		if wantsJSON(c) {
+			return c.JSON(webhookErrorStatus(err), map[string]string{
+				"error": err.Error(),
+			})
+		}
+		return h.renderList(c, userID, fmt.Sprintf("Failed to delete webhook: %v", err))
+	}
+
+	if wantsJSON(c) {
+		return c.NoContent(http.StatusNoContent)
+	}
+	return h.renderList(c, userID, "")
+}
+
+// GetDeliveries handles GET /webhooks/:id/deliveries
+func (h *WebhookHandler) GetDeliveries(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+
+	deliveries, err := h.webhookService.GetDeliveries(c.Request().Context(), c.Param("id"), userID)
+	if err != nil {
+		if wantsJSON(c) {
+			return c.JSON(webhookErrorStatus(err), map[string]string{
+				"error": err.Error(),
+			})
+		}
+		return c.String(webhookErrorStatus(err), fmt.Sprintf("Failed to get deliveries: %v", err))
+	}
+
+	if wantsJSON(c) {
+		if deliveries == nil {
+			deliveries = []*models.WebhookDelivery{}
+		}
+		return c.JSON(http.StatusOK, deliveries)
+	}
+	return templates.DeliveryLog(deliveries).Render(c.Request().Context(), c.Response().Writer)
+}
+
+// SendTestEvent handles POST /webhooks/:id/test
+func (h *WebhookHandler) SendTestEvent(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+	webhookID := c.Param("id")
+
+	delivery, err := h.webhookService.SendTestEvent(c.Request().Context(), webhookID, userID)
+	if err != nil {
+		if wantsJSON(c) {
+			return c.JSON(webhookErrorStatus(err), map[string]string{
+				"error": err.Error(),
+			})
+		}
+		return c.String(webhookErrorStatus(err), fmt.Sprintf("Failed to send test event: %v", err))
+	}
+
+	// The outcome of the attempt is part of the delivery
+	if wantsJSON(c) {
+		return c.JSON(http.StatusOK, delivery)
+	}
+
+	// Show the test among the recent deliveries
+	return h.GetDeliveries(c)
+}
+
+// renderList renders the webhook list, optionally with an error message
+func (h *WebhookHandler) renderList(c echo.Context, userID, errorMessage string) error {
+	webhooks, err := h.webhookService.GetUserWebhooks(c.Request().Context(), userID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("Failed to get webhooks: %v", err))
+	}
+	return templates.WebhookList(webhooks, errorMessage).Render(c.Request().Context(), c.Response().Writer)
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
+	"github.com/starbops/gottodo/internal/services"
+)
+
+// newWebhookTestServer serves the webhook routes for user1
+func newWebhookTestServer(t *testing.T) *echo.Echo {
+	t.Helper()
+
+	// The test receivers listen on loopback
+	handler := NewWebhookHandler(services.NewWebhookService(repositories.NewMemoryWebhookRepository(), services.WithWebhookLoopback()))
+	e := echo.New()
+	g := e.Group("/webhooks", func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("user_id", "user1")
+			return next(c)
+		}
+	})
+	g.GET("", handler.GetWebhooks)
+	g.POST("", handler.CreateWebhook)
+	g.DELETE("/:id", handler.DeleteWebhook)
+	g.GET("/:id/deliveries", handler.GetDeliveries)
+	g.POST("/:id/test", handler.SendTestEvent)
+	return e
+}
+
+// serveWebhookRequest sends a request to the webhook routes
+func serveWebhookRequest(e *echo.Echo, method, path, contentType, body string, jsonClient bool) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set(echo.HeaderContentType, contentType)
+	}
+	if jsonClient {
+		req.Header.Set(echo.HeaderAccept, echo.MIMEApplicationJSON)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestWebhookHandler_JSON(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(receiver.Close)
+	e := newWebhookTestServer(t)
+
+	// Invalid registrations are rejected
+	rec := serveWebhookRequest(e, http.MethodPost, "/webhooks", echo.MIMEApplicationJSON,
+		`{"url":"not a url","events":["todo.created"]}`, true)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for an invalid URL, got %d", rec.Code)
+	}
+
+	rec = serveWebhookRequest(e, http.MethodPost, "/webhooks", echo.MIMEApplicationJSON,
+		`{"url":"`+receiver.URL+`","events":["todo.created","todo.deleted"]}`, true)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var webhook models.Webhook
+	if err := json.Unmarshal(rec.Body.Bytes(), &webhook); err != nil {
+		t.Fatalf("Failed to decode webhook: %v", err)
+	}
+	if webhook.Secret == "" || len(webhook.Events) != 2 {
+		t.Errorf("Expected the webhook with its secret and events, got %+v", webhook)
+	}
+
+	rec = serveWebhookRequest(e, http.MethodPost, "/webhooks/"+webhook.ID+"/test", "", "", true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 for a test event, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var delivery models.WebhookDelivery
+	if err := json.Unmarshal(rec.Body.Bytes(), &delivery); err != nil {
+		t.Fatalf("Failed to decode delivery: %v", err)
+	}
+	if delivery.Status != models.DeliverySucceeded || delivery.ResponseStatus != http.StatusAccepted {
+		t.Errorf("Expected a successful delivery, got %s %d", delivery.Status, delivery.ResponseStatus)
+	}
+
+	rec = serveWebhookRequest(e, http.MethodGet, "/webhooks/"+webhook.ID+"/deliveries", "", "", true)
+	var deliveries []models.WebhookDelivery
+	if err := json.Unmarshal(rec.Body.Bytes(), &deliveries); err != nil || len(deliveries) != 1 {
+		t.Fatalf("Expected one delivery in the log, got %s (%v)", rec.Body.String(), err)
+	}
+
+	rec = serveWebhookRequest(e, http.MethodDelete, "/webhooks/"+webhook.ID, "", "", true)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d", rec.Code)
+	}
+	rec = serveWebhookRequest(e, http.MethodGet, "/webhooks/"+webhook.ID+"/deliveries", "", "", true)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a deleted webhook, got %d", rec.Code)
+	}
+
+	rec = serveWebhookRequest(e, http.MethodGet, "/webhooks", "", "", true)
+	if body := strings.TrimSpace(rec.Body.String()); body != "[]" {
+		t.Errorf("Expected an empty list, got %s", body)
+	}
+}
+
+func TestWebhookHandler_HTML(t *testing.T) {
+	e := newWebhookTestServer(t)
+
+	form := url.Values{"url": {"https://example.com/hook"}, "events": {"todo.completed"}}
+	rec := serveWebhookRequest(e, http.MethodPost, "/webhooks", echo.MIMEApplicationForm, form.Encode(), false)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, `id="webhook-list"`) || !strings.Contains(body, "https://example.com/hook") {
+		t.Errorf("Expected the webhook list with the new webhook, got %s", body)
+	}
+	if !strings.Contains(body, "Send Test Event") {
+		t.Error("Expected a button for sending a test event")
+	}
+
+	// Validation errors are shown above the form
+	form.Set("events", "todo.renamed")
+	rec = serveWebhookRequest(e, http.MethodPost, "/webhooks", echo.MIMEApplicationForm, form.Encode(), false)
+	if !strings.Contains(rec.Body.String(), "Failed to add webhook") {
+		t.Errorf("Expected the error in the list, got %s", rec.Body.String())
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/labstack/echo/v4"
+	"github.com/starbops/gottodo/internal/repositories"
 	"github.com/starbops/gottodo/pkg/auth"
 )
 
@@ -35,9 +36,11 @@ func AuthMiddleware(authService *auth.AuthService) echo.MiddlewareFunc {
 				})
 			}
 
-			// Set user in context
+			// Set user in context, and restrict the request's database work to
+			// the user's todos
 			c.Set("user", user)
 			c.Set("user_id", user.ID)
+			c.SetRequest(c.Request().WithContext(repositories.WithUserID(c.Request().Context(), user.ID)))
 
 			return next(c)
 		}
@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/starbops/gottodo/internal/services"
+)
+
+const (
+	// maxIdempotencyKeyLength bounds the size of stored idempotency keys
+	maxIdempotencyKeyLength = 255
+
+	// maxIdempotentBodySize bounds the size of request bodies read for hashing
+	maxIdempotentBodySize = 1 << 20
+)
+
+// IdempotencyMiddleware creates middleware that replays the stored response
+// when a request is retried with the same Idempotency-Key header. It must run
+// after authentication because keys are scoped to the user.
+func IdempotencyMiddleware(idempotencyService *services.IdempotencyService) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := c.Request().Header.Get("Idempotency-Key")
+			if key == "" {
+				return next(c)
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				return c.JSON(http.StatusBadRequest, map[string]string{
+					"error": "Idempotency-Key is too long",
+				})
+			}
+
+			userID, _ := c.Get("user_id").(string)
+			ctx := c.Request().Context()
+
+			// Read the body for hashing and hand an untouched copy to the handler
+			body, err := io.ReadAll(http.MaxBytesReader(c.Response(), c.Request().Body, maxIdempotentBodySize))
+			if err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{
+						"error": "Request body is too large",
+					})
+				}
+				return c.JSON(http.StatusBadRequest, map[string]string{
+					"error": "Invalid request body",
+				})
+			}
+			c.Request().Body = io.NopCloser(bytes.NewReader(body))
+			requestHash := services.HashRequest(c.Request().Method, c.Request().URL.Path, body)
+
+			// Retries arriving while the original is still running wait for it
+			// on this server; Reserve turns them away on other servers
+			unlock := idempotencyService.Lock(userID, key)
+			defer unlock()
+
+			record, err := idempotencyService.Reserve(ctx, userID, key, requestHash)
+			if errors.Is(err, services.ErrIdempotencyKeyReused) {
+				return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+					"error": err.Error(),
+				})
+			}
+			if errors.Is(err, services.ErrIdempotencyKeyInUse) {
+				c.Response().Header().Set("Retry-After", "1")
+				return c.JSON(http.StatusConflict, map[string]string{
+					"error": err.Error(),
+				})
+			}
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{
+					"error": err.Error(),
+				})
+			}
+			if record != nil {
+				c.Response().Header().Set("Idempotent-Replayed", "true")
+				return c.Blob(record.StatusCode, record.ContentType, record.Body)
+			}
+
+			// Give the key up again if the request failed, also if the handler
+			// panics. A request that went through keeps it even if its
+			// response cannot be stored, so a retry is not executed twice.
+			done := false
+			defer func() {
+				if done {
+					return
+				}
+				if err := idempotencyService.Release(context.WithoutCancel(ctx), userID, key); err != nil {
+					log.Printf("Failed to release idempotency key %q: %v", key, err)
+				}
+			}()
+
+			// Capture the response while it is written to the client
+			recorder := &responseRecorder{ResponseWriter: c.Response().Writer}
+			c.Response().Writer = recorder
+			if err := next(c); err != nil {
+				return err
+			}
+
+			// Server errors are worth retrying, so they are not remembered
+			status := c.Response().Status
+			if status >= http.StatusInternalServerError {
+				return nil
+			}
+			done = true
+
+			contentType := c.Response().Header().Get(echo.HeaderContentType)
+			if contentType == "" {
+				contentType = http.DetectContentType(recorder.body.Bytes())
+			}
+			if err := idempotencyService.Save(ctx, userID, key, requestHash, status, contentType, recorder.body.Bytes()); err != nil {
+				log.Printf("Failed to store response for idempotency key %q: %v", key, err)
+			}
+			return nil
+		}
+	}
+}
+
+// responseRecorder copies everything written to the response into a buffer
+type responseRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+// Write sends the bytes to the client and keeps a copy
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// AccountDeletion is a user's request to delete their account, which is
+// carried out once the grace period ends unless the user cancels it
+type AccountDeletion struct {
+	UserID      string    `json:"user_id"`
+	RequestedAt time.Time `json:"requested_at"`
+	DeleteAt    time.Time `json:"delete_at"`
+}
+
+// NewAccountDeletion creates a new AccountDeletion carried out after grace
+func NewAccountDeletion(userID string, grace time.Duration) *AccountDeletion {
+	now := time.Now()
+	return &AccountDeletion{
+		UserID:      userID,
+		RequestedAt: now,
+		DeleteAt:    now.Add(grace),
+	}
+}
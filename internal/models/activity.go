@@ -0,0 +1,126 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ActivityAction identifies the kind of change recorded in the activity log
+type ActivityAction string
+
+const (
+	// ActivityCreated is recorded when a todo is created
+	ActivityCreated ActivityAction = "created"
+
+	// ActivityUpdated is recorded when a todo's details are edited
+	ActivityUpdated ActivityAction = "updated"
+
+	// ActivityCompleted is recorded when a todo is marked as completed
+	ActivityCompleted ActivityAction = "completed"
+
+	// ActivityReopened is recorded when a completed todo is marked as incomplete
+	ActivityReopened ActivityAction = "reopened"
+
+	// ActivityDeleted is recorded when a todo is moved to the trash
+	ActivityDeleted ActivityAction = "deleted"
+
+	// ActivityRestored is recorded when a todo is restored from the trash
+	ActivityRestored ActivityAction = "restored"
+
+	// ActivityPurged is recorded when a todo is permanently removed from the trash
+	ActivityPurged ActivityAction = "purged"
+)
+
+// FieldChange records the value of a single todo field before and after a change
+type FieldChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// Activity is an append-only record of a change made to a todo
+type Activity struct {
+	ID        string         `json:"id"`
+	ActorID   string         `json:"actor_id"`
+	TodoID    string         `json:"todo_id"`
+	Action    ActivityAction `json:"action"`
+	Changes   []FieldChange  `json:"changes"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// NewActivity creates a new Activity entry
+func NewActivity(actorID, todoID string, action ActivityAction, changes []FieldChange) *Activity {
+	if changes == nil {
+		changes = []FieldChange{}
+	}
+
+	return &Activity{
+		ID:        uuid.New().String(),
+		ActorID:   actorID,
+		TodoID:    todoID,
+		Action:    action,
+		Changes:   changes,
+		CreatedAt: time.Now(),
+	}
+}
+
+// DiffTodos returns the fields that differ between two snapshots of a todo.
+// A nil before describes a creation and a nil after describes a deletion.
+func DiffTodos(before, after *Todo) []FieldChange {
+	var empty Todo
+	if before == nil {
+		before = &empty
+	}
+	if after == nil {
+		after = &empty
+	}
+
+	var changes []FieldChange
+	if before.Title != after.Title {
+		changes = append(changes, FieldChange{Field: "title", Before: before.Title, After: after.Title})
+	}
+	if before.Description != after.Description {
+		changes = append(changes, FieldChange{Field: "description", Before: before.Description, After: after.Description})
+	}
+	if before.Completed != after.Completed {
+		changes = append(changes, FieldChange{Field: "completed", Before: before.Completed, After: after.Completed})
+	}
+	if !equalTimes(before.DeletedAt, after.DeletedAt) {
+		changes = append(changes, FieldChange{Field: "deleted_at", Before: formatTime(before.DeletedAt), After: formatTime(after.DeletedAt)})
+	}
+	if !equalTimes(before.DueAt, after.DueAt) {
+		changes = append(changes, FieldChange{Field: "due_at", Before: formatTime(before.DueAt), After: formatTime(after.DueAt)})
+	}
+	if before.Priority != after.Priority {
+		changes = append(changes, FieldChange{Field: "priority", Before: string(before.Priority), After: string(after.Priority)})
+	}
+	if before.Project != after.Project {
+		changes = append(changes, FieldChange{Field: "project", Before: before.Project, After: after.Project})
+	}
+	if strings.Join(before.Tags, ",") != strings.Join(after.Tags, ",") {
+		changes = append(changes, FieldChange{Field: "tags", Before: before.Tags, After: after.Tags})
+	}
+	if before.Recurrence != after.Recurrence {
+		changes = append(changes, FieldChange{Field: "recurrence", Before: before.Recurrence, After: after.Recurrence})
+	}
+
+	return changes
+}
+
+// equalTimes reports whether two optional timestamps describe the same instant
+func equalTimes(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// formatTime renders an optional timestamp for the activity log
+func formatTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC().Format(time.RFC3339)
+}
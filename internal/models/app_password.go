@@ -0,0 +1,43 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AppPassword lets an app that cannot log in interactively, such as a
+// CalDAV client, act for a user with HTTP Basic auth. Only a hash of the
+// password is stored.
+type AppPassword struct {
+	ID     string `json:"id"`
+	UserID string `json:"user_id"`
+
+	// Name tells the user which app the password was created for
+	Name      string    `json:"name"`
+	Hash      string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewAppPassword creates a new AppPassword for a user, returning it together
+// with the password, which cannot be recovered afterwards
+func NewAppPassword(userID, name string) (*AppPassword, string) {
+	password := newSecret("app_")
+	return &AppPassword{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Name:      name,
+		Hash:      HashAppPassword(password),
+		CreatedAt: time.Now(),
+	}, password
+}
+
+// HashAppPassword hashes an app password for storage and lookup. App
+// passwords are long random strings, so a fast hash is enough and keeps the
+// many requests of sync clients cheap.
+func HashAppPassword(password string) string {
+	sum := sha256.Sum256([]byte(password))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// CalendarFeed is the secret part of a user's iCalendar feed URL, which
+// calendar apps subscribe to without further authentication
+type CalendarFeed struct {
+	UserID    string    `json:"user_id"`
+	Secret    string    `json:"secret"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewCalendarFeed creates a new random CalendarFeed for a user
+func NewCalendarFeed(userID string) *CalendarFeed {
+	return &CalendarFeed{
+		UserID:    userID,
+		Secret:    newSecret("cal_"),
+		CreatedAt: time.Now(),
+	}
+}
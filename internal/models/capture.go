@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// CaptureSecret is the secret part of a user's capture URL, which creates
+// todos for the user without further authentication
+type CaptureSecret struct {
+	UserID    string    `json:"user_id"`
+	Secret    string    `json:"secret"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewCaptureSecret creates a new random CaptureSecret for a user
+func NewCaptureSecret(userID string) *CaptureSecret {
+	return &CaptureSecret{
+		UserID:    userID,
+		Secret:    newSecret("cap_"),
+		CreatedAt: time.Now(),
+	}
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// IdempotencyRecord stores the response to a request sent with an
+// Idempotency-Key header so retries of that request can be answered with the
+// original response instead of being executed again
+type IdempotencyRecord struct {
+	Key         string    `json:"key"`
+	UserID      string    `json:"user_id"`
+	RequestHash string    `json:"request_hash"`
+	StatusCode  int       `json:"status_code"`
+	ContentType string    `json:"content_type"`
+	Body        []byte    `json:"body"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// NewIdempotencyRecord creates a new IdempotencyRecord kept for the given time to live
+func NewIdempotencyRecord(userID, key, requestHash string, statusCode int, contentType string, body []byte, ttl time.Duration) *IdempotencyRecord {
+	now := time.Now()
+
+	return &IdempotencyRecord{
+		Key:         key,
+		UserID:      userID,
+		RequestHash: requestHash,
+		StatusCode:  statusCode,
+		ContentType: contentType,
+		Body:        body,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(ttl),
+	}
+}
+
+// NewPendingIdempotencyRecord creates a record reserving a key for a request
+// whose response is not known yet, kept for the given time to live
+func NewPendingIdempotencyRecord(userID, key, requestHash string, ttl time.Duration) *IdempotencyRecord {
+	return NewIdempotencyRecord(userID, key, requestHash, 0, "", []byte{}, ttl)
+}
+
+// IsPending reports whether the request of the record is still running
+func (r *IdempotencyRecord) IsPending() bool {
+	return r.StatusCode == 0
+}
+
+// IsExpired reports whether the record has outlived its time to live
+func (r *IdempotencyRecord) IsExpired() bool {
+	return time.Now().After(r.ExpiresAt)
+}
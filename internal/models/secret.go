@@ -0,0 +1,18 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/google/uuid"
+)
+
+// newSecret generates a random secret with the given prefix
+func newSecret(prefix string) string {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		// Fall back to a random UUID if the system random source fails
+		return prefix + uuid.New().String()
+	}
+	return prefix + hex.EncodeToString(b)
+}
@@ -1,20 +1,124 @@
 package models
 
 import (
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 )
 
+// Priority ranks how urgent a todo is
+type Priority string
+
+const (
+	// PriorityNone is the priority of todos nobody ranked
+	PriorityNone   Priority = ""
+	PriorityLow    Priority = "low"
+	PriorityMedium Priority = "medium"
+	PriorityHigh   Priority = "high"
+)
+
+// IsValid reports whether the priority is one of the known priorities
+func (p Priority) IsValid() bool {
+	switch p {
+	case PriorityNone, PriorityLow, PriorityMedium, PriorityHigh:
+		return true
+	}
+	return false
+}
+
+const (
+	// MaxTags is how many tags a todo can have
+	MaxTags = 20
+
+	// MaxTagLength caps the length of a tag, in characters
+	MaxTagLength = 50
+
+	// MaxRecurrenceLength caps the length of a recurrence, in characters
+	MaxRecurrenceLength = 200
+)
+
+// IsValidTag reports whether tag can label a todo: it is not blank, at most
+// MaxTagLength characters long and has no control characters such as line
+// breaks
+func IsValidTag(tag string) bool {
+	if strings.TrimSpace(tag) == "" || utf8.RuneCountInString(tag) > MaxTagLength {
+		return false
+	}
+	return strings.IndexFunc(tag, unicode.IsControl) < 0
+}
+
+// recurrenceParts are the parts of an RRULE value, see RFC 5545 section 3.3.10
+var recurrenceParts = map[string]bool{
+	"FREQ": true, "UNTIL": true, "COUNT": true, "INTERVAL": true,
+	"BYSECOND": true, "BYMINUTE": true, "BYHOUR": true, "BYDAY": true,
+	"BYMONTHDAY": true, "BYYEARDAY": true, "BYWEEKNO": true, "BYMONTH": true,
+	"BYSETPOS": true, "WKST": true,
+}
+
+// recurrenceFrequencies are the values of the FREQ part of an RRULE
+var recurrenceFrequencies = map[string]bool{
+	"SECONDLY": true, "MINUTELY": true, "HOURLY": true, "DAILY": true,
+	"WEEKLY": true, "MONTHLY": true, "YEARLY": true,
+}
+
+// IsValidRecurrence reports whether rule is an iCalendar RRULE value such as
+// "FREQ=WEEKLY;BYDAY=MO": at most MaxRecurrenceLength characters of known
+// parts, each at most once, including FREQ, with values made of letters,
+// digits, commas and signs. The empty rule of todos that do not repeat is
+// valid too.
+func IsValidRecurrence(rule string) bool {
+	if rule == "" {
+		return true
+	}
+	if len(rule) > MaxRecurrenceLength {
+		return false
+	}
+
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(rule, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		name = strings.ToUpper(name)
+		if !ok || !recurrenceParts[name] || seen[name] || value == "" {
+			return false
+		}
+		seen[name] = true
+
+		for _, r := range value {
+			if !(r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == ',' || r == '+' || r == '-') {
+				return false
+			}
+		}
+		if name == "FREQ" && !recurrenceFrequencies[strings.ToUpper(value)] {
+			return false
+		}
+	}
+	return seen["FREQ"]
+}
+
 // Todo represents a todo item
 type Todo struct {
-	ID          string    `json:"id"`
-	UserID      string    `json:"user_id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Completed   bool      `json:"completed"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          string     `json:"id"`
+	UserID      string     `json:"user_id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Completed   bool       `json:"completed"`
+	Version     int        `json:"version"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+
+	// DueAt is when the todo is due. Todos due on a day rather than at a
+	// time of day are due at midnight UTC of that day, see DueAllDay.
+	DueAt    *time.Time `json:"due_at,omitempty"`
+	Priority Priority   `json:"priority,omitempty"`
+	Project  string     `json:"project,omitempty"`
+	Tags     []string   `json:"tags,omitempty"`
+
+	// Recurrence is an iCalendar RRULE value such as "FREQ=WEEKLY;BYDAY=MO"
+	Recurrence string `json:"recurrence,omitempty"`
 }
 
 // NewTodo creates a new Todo item
@@ -30,6 +134,7 @@ func NewTodo(userID, title, description string) *Todo {
 		Title:       title,
 		Description: description,
 		Completed:   false,
+		Version:     1,
 		CreatedAt:   now,
 		UpdatedAt:   now,
 	}
@@ -54,6 +159,33 @@ func (t *Todo) Update(title, description string) {
 	t.UpdatedAt = time.Now()
 }
 
+// MoveToTrash marks the todo as deleted without removing it
+func (t *Todo) MoveToTrash() {
+	now := time.Now()
+	t.DeletedAt = &now
+}
+
+// Restore takes the todo back out of the trash
+func (t *Todo) Restore() {
+	t.DeletedAt = nil
+	t.UpdatedAt = time.Now()
+}
+
+// DueAllDay reports whether the todo is due on a whole day rather than at a
+// time of day
+func (t *Todo) DueAllDay() bool {
+	if t.DueAt == nil {
+		return false
+	}
+	due := t.DueAt.UTC()
+	return due.Hour() == 0 && due.Minute() == 0 && due.Second() == 0 && due.Nanosecond() == 0
+}
+
+// IsTrashed reports whether the todo has been moved to the trash
+func (t *Todo) IsTrashed() bool {
+	return t.DeletedAt != nil
+}
+
 // IsValidUUID checks if a string is a valid UUID
 func IsValidUUID(id string) bool {
 	_, err := uuid.Parse(id)
@@ -0,0 +1,157 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Todo fields that can be changed after creation. The names match the JSON
+// keys of Todo and the columns of the todos table.
+const (
+	FieldTitle       = "title"
+	FieldDescription = "description"
+	FieldCompleted   = "completed"
+	FieldDueAt       = "due_at"
+	FieldPriority    = "priority"
+	FieldProject     = "project"
+	FieldTags        = "tags"
+	FieldRecurrence  = "recurrence"
+)
+
+// TodoPatch is a partial update of a todo. A nil field is left unchanged.
+type TodoPatch struct {
+	Title       *string `json:"title,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Completed   *bool   `json:"completed,omitempty"`
+
+	// DueAt points to the new due date, or to nil to remove it
+	DueAt      **time.Time `json:"due_at,omitempty"`
+	Priority   *Priority   `json:"priority,omitempty"`
+	Project    *string     `json:"project,omitempty"`
+	Tags       *[]string   `json:"tags,omitempty"`
+	Recurrence *string     `json:"recurrence,omitempty"`
+}
+
+// ParseTodoMergePatch decodes a JSON Merge Patch (RFC 7396) document for a
+// todo. A null member removes the value, which is only allowed for fields
+// with an empty state; members naming read-only or unknown fields are rejected.
+// Tags are not merged: a tags member replaces all of the todo's tags.
+func ParseTodoMergePatch(data []byte) (*TodoPatch, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil, errors.New("merge patch must be a JSON object")
+	}
+
+	patch := &TodoPatch{}
+	for name, raw := range members {
+		isNull := strings.TrimSpace(string(raw)) == "null"
+
+		switch name {
+		case FieldTitle:
+			if isNull {
+				return nil, errors.New("title cannot be removed")
+			}
+			var title string
+			if err := json.Unmarshal(raw, &title); err != nil {
+				return nil, errors.New("title must be a string")
+			}
+			patch.Title = &title
+		case FieldDescription:
+			var description string
+			if !isNull {
+				if err := json.Unmarshal(raw, &description); err != nil {
+					return nil, errors.New("description must be a string")
+				}
+			}
+			patch.Description = &description
+		case FieldCompleted:
+			var completed bool
+			if !isNull {
+				if err := json.Unmarshal(raw, &completed); err != nil {
+					return nil, errors.New("completed must be a boolean")
+				}
+			}
+			patch.Completed = &completed
+		case FieldDueAt:
+			var dueAt *time.Time
+			if !isNull {
+				dueAt = new(time.Time)
+				if err := json.Unmarshal(raw, dueAt); err != nil {
+					return nil, errors.New("due_at must be an RFC 3339 date-time")
+				}
+			}
+			patch.DueAt = &dueAt
+		case FieldPriority:
+			var priority Priority
+			if !isNull {
+				if err := json.Unmarshal(raw, &priority); err != nil {
+					return nil, errors.New("priority must be a string")
+				}
+			}
+			patch.Priority = &priority
+		case FieldProject:
+			var project string
+			if !isNull {
+				if err := json.Unmarshal(raw, &project); err != nil {
+					return nil, errors.New("project must be a string")
+				}
+			}
+			patch.Project = &project
+		case FieldTags:
+			var tags []string
+			if !isNull {
+				if err := json.Unmarshal(raw, &tags); err != nil {
+					return nil, errors.New("tags must be an array of strings")
+				}
+			}
+			patch.Tags = &tags
+		case FieldRecurrence:
+			var recurrence string
+			if !isNull {
+				if err := json.Unmarshal(raw, &recurrence); err != nil {
+					return nil, errors.New("recurrence must be a string")
+				}
+			}
+			patch.Recurrence = &recurrence
+		default:
+			return nil, fmt.Errorf("field %q cannot be patched", name)
+		}
+	}
+
+	return patch, nil
+}
+
+// Apply copies the patched fields onto the todo
+func (p *TodoPatch) Apply(todo *Todo) {
+	if p.Title != nil {
+		todo.Title = *p.Title
+	}
+	if p.Description != nil {
+		todo.Description = *p.Description
+	}
+	if p.Completed != nil {
+		todo.Completed = *p.Completed
+	}
+	if p.DueAt != nil {
+		todo.DueAt = *p.DueAt
+	}
+	if p.Priority != nil {
+		todo.Priority = *p.Priority
+	}
+	if p.Project != nil {
+		todo.Project = *p.Project
+	}
+	if p.Tags != nil {
+		todo.Tags = nil
+		if len(*p.Tags) > 0 {
+			todo.Tags = slices.Clone(*p.Tags)
+		}
+	}
+	if p.Recurrence != nil {
+		todo.Recurrence = *p.Recurrence
+	}
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UndoToken captures the state of a todo before a destructive action so the
+// action can be reverted for a short time afterwards
+type UndoToken struct {
+	Token     string         `json:"token"`
+	UserID    string         `json:"user_id"`
+	TodoID    string         `json:"todo_id"`
+	Action    ActivityAction `json:"action"`
+	Snapshot  Todo           `json:"snapshot"`
+	CreatedAt time.Time      `json:"created_at"`
+	ExpiresAt time.Time      `json:"expires_at"`
+}
+
+// NewUndoToken creates a new UndoToken for an action performed on the given todo
+func NewUndoToken(userID string, action ActivityAction, snapshot *Todo, window time.Duration) *UndoToken {
+	now := time.Now()
+
+	return &UndoToken{
+		Token:     uuid.New().String(),
+		UserID:    userID,
+		TodoID:    snapshot.ID,
+		Action:    action,
+		Snapshot:  *snapshot,
+		CreatedAt: now,
+		ExpiresAt: now.Add(window),
+	}
+}
+
+// IsExpired reports whether the undo window has passed
+func (u *UndoToken) IsExpired() bool {
+	return time.Now().After(u.ExpiresAt)
+}
+
+// Remaining returns how much of the undo window is left
+func (u *UndoToken) Remaining() time.Duration {
+	remaining := time.Until(u.ExpiresAt)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
@@ -0,0 +1,128 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookEvent identifies the kind of event a webhook is notified about
+type WebhookEvent string
+
+const (
+	// WebhookTodoCreated is sent when a todo is created
+	WebhookTodoCreated WebhookEvent = "todo.created"
+
+	// WebhookTodoCompleted is sent when a todo is marked as completed
+	WebhookTodoCompleted WebhookEvent = "todo.completed"
+
+	// WebhookTodoDeleted is sent when a todo is moved to the trash
+	WebhookTodoDeleted WebhookEvent = "todo.deleted"
+
+	// WebhookTest is sent on request to check that an endpoint is reachable
+	WebhookTest WebhookEvent = "webhook.test"
+)
+
+// WebhookEvents lists the events a webhook can subscribe to
+var WebhookEvents = []WebhookEvent{WebhookTodoCreated, WebhookTodoCompleted, WebhookTodoDeleted}
+
+// IsValid reports whether webhooks can subscribe to the event
+func (e WebhookEvent) IsValid() bool {
+	for _, event := range WebhookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhook is an endpoint notified about events of a user's todos
+type Webhook struct {
+	ID     string `json:"id"`
+	UserID string `json:"user_id"`
+	URL    string `json:"url"`
+
+	// Secret signs the payloads sent to the endpoint
+	Secret    string         `json:"secret"`
+	Events    []WebhookEvent `json:"events"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// NewWebhook creates a new Webhook with a random secret
+func NewWebhook(userID, url string, events []WebhookEvent) *Webhook {
+	return &Webhook{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		URL:       url,
+		Secret:    newSecret("whsec_"),
+		Events:    events,
+		CreatedAt: time.Now(),
+	}
+}
+
+// Subscribes reports whether the webhook is notified about the event. Every
+// webhook receives test events.
+func (w *Webhook) Subscribes(event WebhookEvent) bool {
+	if event == WebhookTest {
+		return true
+	}
+	for _, subscribed := range w.Events {
+		if subscribed == event {
+			return true
+		}
+	}
+	return false
+}
+
+// DeliveryStatus is the state of a webhook delivery
+type DeliveryStatus string
+
+const (
+	// DeliveryPending deliveries are waiting for their next attempt
+	DeliveryPending DeliveryStatus = "pending"
+
+	// DeliverySucceeded deliveries were accepted by the endpoint
+	DeliverySucceeded DeliveryStatus = "succeeded"
+
+	// DeliveryDead deliveries failed too often and are no longer retried
+	DeliveryDead DeliveryStatus = "dead"
+)
+
+// WebhookDelivery is a single event sent, or still to be sent, to a webhook
+type WebhookDelivery struct {
+	ID        string       `json:"id"`
+	WebhookID string       `json:"webhook_id"`
+	UserID    string       `json:"user_id"`
+	Event     WebhookEvent `json:"event"`
+
+	// Payload is the JSON body sent to the endpoint
+	Payload       json.RawMessage `json:"payload"`
+	Status        DeliveryStatus  `json:"status"`
+	Attempts      int             `json:"attempts"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+
+	// ResponseStatus is the HTTP status of the last attempt, 0 if the
+	// endpoint could not be reached
+	ResponseStatus int       `json:"response_status"`
+	LastError      string    `json:"last_error"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// NewWebhookDelivery creates a new pending WebhookDelivery due immediately
+func NewWebhookDelivery(webhook *Webhook, event WebhookEvent, payload []byte) *WebhookDelivery {
+	now := time.Now()
+
+	return &WebhookDelivery{
+		ID:            uuid.New().String(),
+		WebhookID:     webhook.ID,
+		UserID:        webhook.UserID,
+		Event:         event,
+		Payload:       payload,
+		Status:        DeliveryPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+}
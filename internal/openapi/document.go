@@ -0,0 +1,166 @@
+// Package openapi builds OpenAPI 3.1 documents, deriving schemas from Go types
+package openapi
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Version is the OpenAPI version of the generated documents
+const Version = "3.1.0"
+
+// Document is the root of an OpenAPI document
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+// Info describes the API
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem maps lower-case HTTP methods to the operations of a path. Methods
+// OpenAPI has no field for, such as the WebDAV PROPFIND, are kept as
+// extensions like x-propfind.
+type PathItem map[string]*Operation
+
+// Operation describes a single API operation on a path
+type Operation struct {
+	Summary     string                `json:"summary"`
+	Description string                `json:"description,omitempty"`
+	OperationID string                `json:"operationId"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+// Parameter describes a path, query or header parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody describes the accepted request payloads by media type
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response describes a response by status code
+type Response struct {
+	Description string                `json:"description"`
+	Headers     map[string]*Header    `json:"headers,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// Header describes a response header
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// MediaType holds the schema of a payload
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds the reusable parts of a document
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme describes how requests are authenticated
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	Name        string `json:"name,omitempty"`
+	In          string `json:"in,omitempty"`
+	Scheme      string `json:"scheme,omitempty"`
+}
+
+// NewDocument creates an empty document
+func NewDocument(info Info) *Document {
+	return &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   map[string]*PathItem{},
+		Components: Components{
+			Schemas:         map[string]*Schema{},
+			SecuritySchemes: map[string]*SecurityScheme{},
+		},
+	}
+}
+
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// AddOperation registers an operation, declaring any path parameters the
+// operation does not describe itself
+func (d *Document) AddOperation(method, path string, op *Operation) {
+	for _, match := range pathParam.FindAllStringSubmatch(path, -1) {
+		if !op.hasParameter(match[1], "path") {
+			op.Parameters = append(op.Parameters, Parameter{
+				Name: match[1], In: "path", Required: true, Schema: &Schema{Type: "string"},
+			})
+		}
+	}
+
+	item, exists := d.Paths[path]
+	if !exists {
+		item = &PathItem{}
+		d.Paths[path] = item
+	}
+	(*item)[methodKey(method)] = op
+}
+
+// HasOperation reports whether the document describes the method on the path.
+// Echo style paths such as /todos/:id are accepted as well.
+func (d *Document) HasOperation(method, path string) bool {
+	item, exists := d.Paths[ToOpenAPIPath(path)]
+	if !exists {
+		return false
+	}
+	_, exists = (*item)[methodKey(method)]
+	return exists
+}
+
+// methodKey returns the path item field describing an HTTP method
+func methodKey(method string) string {
+	switch key := strings.ToLower(method); key {
+	case "get", "put", "post", "delete", "options", "head", "patch", "trace":
+		return key
+	default:
+		return "x-" + key
+	}
+}
+
+// ToOpenAPIPath converts an Echo route path like /todos/:id to /todos/{id}
+func ToOpenAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// hasParameter reports whether the operation declares the given parameter
+func (op *Operation) hasParameter(name, in string) bool {
+	for _, param := range op.Parameters {
+		if param.Name == name && param.In == in {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Schema is a JSON Schema (draft 2020-12) as used by OpenAPI 3.1
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 interface{}        `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	ContentEncoding      string             `json:"contentEncoding,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+)
+
+// SchemaOf returns the schema for a Go value, registering every named struct
+// it reaches as a component and referring to it by $ref
+func (d *Document) SchemaOf(v interface{}) *Schema {
+	return d.schemaFor(reflect.TypeOf(v))
+}
+
+// schemaFor derives a schema from a Go type using its encoding/json tags
+func (d *Document) schemaFor(t reflect.Type) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawMessageType:
+		// Embedded JSON may have any shape
+		return &Schema{}
+	case t.Kind() == reflect.Ptr:
+		return d.nullable(d.schemaFor(t.Elem()))
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		return &Schema{Type: "string", ContentEncoding: "base64"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		// Slices of pointers are described by their elements
+		elem := t.Elem()
+		if elem.Kind() == reflect.Ptr {
+			elem = elem.Elem()
+		}
+		return &Schema{Type: "array", Items: d.schemaFor(elem)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return d.structSchema(t)
+		}
+		return d.component(t)
+	default:
+		// interface{} and friends accept any JSON value
+		return &Schema{}
+	}
+}
+
+// component registers a named struct once and returns a reference to it
+func (d *Document) component(t reflect.Type) *Schema {
+	name := t.Name()
+	if _, exists := d.Components.Schemas[name]; !exists {
+		// Reserve the name first so recursive types terminate
+		d.Components.Schemas[name] = &Schema{}
+		*d.Components.Schemas[name] = *d.structSchema(t)
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// structSchema describes the JSON object encoding/json produces for a struct
+func (d *Document) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, omitempty := field.Name, false
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			parts := strings.Split(tag, ",")
+			if parts[0] == "-" {
+				continue
+			}
+			if parts[0] != "" {
+				name = parts[0]
+			}
+			for _, option := range parts[1:] {
+				omitempty = omitempty || option == "omitempty"
+			}
+		}
+
+		fieldSchema := d.schemaFor(field.Type)
+		if omitempty && field.Type.Kind() == reflect.Ptr {
+			// Omitted rather than null, so describe the value itself
+			fieldSchema = d.schemaFor(field.Type.Elem())
+		}
+		schema.Properties[name] = fieldSchema
+		if !omitempty {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
+
+// nullable widens a schema to also accept null
+func (d *Document) nullable(schema *Schema) *Schema {
+	if typeName, ok := schema.Type.(string); ok {
+		schema.Type = []string{typeName, "null"}
+		return schema
+	}
+	return &Schema{OneOf: []*Schema{schema, {Type: "null"}}}
+}
@@ -0,0 +1,93 @@
+package openapi
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+type sampleItem struct {
+	ID        string          `json:"id"`
+	Done      bool            `json:"done"`
+	Count     int             `json:"count"`
+	Tags      []string        `json:"tags"`
+	DeletedAt *time.Time      `json:"deleted_at,omitempty"`
+	Next      *int            `json:"next"`
+	Child     *sampleItem     `json:"child,omitempty"`
+	Extra     interface{}     `json:"extra"`
+	Raw       json.RawMessage `json:"raw"`
+	Secret    string          `json:"-"`
+	hidden    string
+}
+
+func TestSchemaOf_Struct(t *testing.T) {
+	doc := NewDocument(Info{Title: "Test", Version: "1"})
+
+	ref := doc.SchemaOf(sampleItem{})
+	if ref.Ref != "#/components/schemas/sampleItem" {
+		t.Fatalf("Expected a reference to sampleItem, got %q", ref.Ref)
+	}
+
+	schema := doc.Components.Schemas["sampleItem"]
+	if schema == nil {
+		t.Fatal("Expected sampleItem to be registered as a component")
+	}
+
+	expectType := func(name string, want interface{}) {
+		t.Helper()
+		property, ok := schema.Properties[name]
+		if !ok {
+			t.Fatalf("Expected property %q", name)
+		}
+		got, _ := json.Marshal(property.Type)
+		wanted, _ := json.Marshal(want)
+		if string(got) != string(wanted) {
+			t.Errorf("Property %q: expected type %s, got %s", name, wanted, got)
+		}
+	}
+	expectType("id", "string")
+	expectType("done", "boolean")
+	expectType("count", "integer")
+	expectType("tags", "array")
+	expectType("deleted_at", "string")
+	expectType("next", []string{"integer", "null"})
+	expectType("raw", nil)
+
+	if schema.Properties["deleted_at"].Format != "date-time" {
+		t.Errorf("Expected deleted_at to be a date-time, got %q", schema.Properties["deleted_at"].Format)
+	}
+	if schema.Properties["child"].Ref != "#/components/schemas/sampleItem" {
+		t.Errorf("Expected child to refer back to sampleItem, got %+v", schema.Properties["child"])
+	}
+	for _, name := range []string{"Secret", "-", "hidden"} {
+		if _, ok := schema.Properties[name]; ok {
+			t.Errorf("Expected %q to be skipped", name)
+		}
+	}
+
+	// Fields tagged omitempty are optional
+	required := map[string]bool{}
+	for _, name := range schema.Required {
+		required[name] = true
+	}
+	if required["deleted_at"] || required["child"] || !required["id"] || !required["next"] {
+		t.Errorf("Unexpected required fields: %v", schema.Required)
+	}
+}
+
+func TestAddOperation_PathParameters(t *testing.T) {
+	doc := NewDocument(Info{Title: "Test", Version: "1"})
+	doc.AddOperation("GET", "/todos/{id}", &Operation{Summary: "Get", OperationID: "get"})
+
+	op := (*doc.Paths["/todos/{id}"])["get"]
+	if len(op.Parameters) != 1 || op.Parameters[0].Name != "id" || op.Parameters[0].In != "path" || !op.Parameters[0].Required {
+		t.Errorf("Expected a required id path parameter, got %+v", op.Parameters)
+	}
+
+	if !doc.HasOperation("GET", "/todos/:id") {
+		t.Error("Expected Echo style paths to match")
+	}
+	if doc.HasOperation("DELETE", "/todos/:id") {
+		t.Error("Expected undescribed methods not to match")
+	}
+}
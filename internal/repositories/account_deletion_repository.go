@@ -0,0 +1,26 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/starbops/gottodo/internal/models"
+)
+
+// AccountDeletionRepository defines the interface for storing the pending
+// account deletions of the users
+type AccountDeletionRepository interface {
+	// SaveAccountDeletion stores a user's account deletion, replacing any
+	// previous one
+	SaveAccountDeletion(ctx context.Context, deletion *models.AccountDeletion) error
+
+	// GetAccountDeletion retrieves the pending account deletion of a user
+	GetAccountDeletion(ctx context.Context, userID string) (*models.AccountDeletion, error)
+
+	// DeleteAccountDeletion removes the pending account deletion of a user
+	DeleteAccountDeletion(ctx context.Context, userID string) error
+
+	// GetDueAccountDeletions retrieves the account deletions due at now,
+	// oldest first
+	GetDueAccountDeletions(ctx context.Context, now time.Time) ([]*models.AccountDeletion, error)
+}
@@ -0,0 +1,23 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/starbops/gottodo/internal/models"
+)
+
+// ActivityRepository defines the interface for the append-only todo activity log
+type ActivityRepository interface {
+	// AppendActivity appends an entry to the activity log
+	AppendActivity(ctx context.Context, activity *models.Activity) error
+
+	// GetTodoActivity retrieves the history of a specific todo, newest first
+	GetTodoActivity(ctx context.Context, todoID string) ([]*models.Activity, error)
+
+	// GetUserActivity retrieves a page of the activity performed by a user, newest first
+	GetUserActivity(ctx context.Context, userID string, limit, offset int) ([]*models.Activity, error)
+
+	// DeleteUserActivity removes the activity performed by a user. It is the
+	// only way entries ever leave the log, used when accounts are deleted.
+	DeleteUserActivity(ctx context.Context, userID string) error
+}
@@ -0,0 +1,29 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/starbops/gottodo/internal/models"
+)
+
+// AppPasswordRepository defines the interface for storing the app passwords
+// of the users
+type AppPasswordRepository interface {
+	// CreateAppPassword stores a new app password
+	CreateAppPassword(ctx context.Context, password *models.AppPassword) error
+
+	// GetAppPassword retrieves an app password by ID
+	GetAppPassword(ctx context.Context, id string) (*models.AppPassword, error)
+
+	// GetAppPasswordByHash looks up an app password by the hash of the password
+	GetAppPasswordByHash(ctx context.Context, hash string) (*models.AppPassword, error)
+
+	// GetUserAppPasswords retrieves the app passwords of a user, oldest first
+	GetUserAppPasswords(ctx context.Context, userID string) ([]*models.AppPassword, error)
+
+	// DeleteAppPassword removes an app password
+	DeleteAppPassword(ctx context.Context, id string) error
+
+	// DeleteUserAppPasswords removes all app passwords of a user
+	DeleteUserAppPasswords(ctx context.Context, userID string) error
+}
@@ -0,0 +1,23 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/starbops/gottodo/internal/models"
+)
+
+// CalendarFeedRepository defines the interface for storing the secrets of the
+// users' calendar feed URLs
+type CalendarFeedRepository interface {
+	// GetUserCalendarFeed retrieves the current calendar feed of a user
+	GetUserCalendarFeed(ctx context.Context, userID string) (*models.CalendarFeed, error)
+
+	// GetCalendarFeed looks up a feed secret to find the user it belongs to
+	GetCalendarFeed(ctx context.Context, secret string) (*models.CalendarFeed, error)
+
+	// SaveCalendarFeed stores a user's calendar feed, replacing the previous one
+	SaveCalendarFeed(ctx context.Context, feed *models.CalendarFeed) error
+
+	// DeleteUserCalendarFeed removes the calendar feed of a user, if any
+	DeleteUserCalendarFeed(ctx context.Context, userID string) error
+}
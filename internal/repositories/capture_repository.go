@@ -0,0 +1,24 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/starbops/gottodo/internal/models"
+)
+
+// CaptureRepository defines the interface for storing the secrets of the
+// users' capture URLs
+type CaptureRepository interface {
+	// GetUserCaptureSecret retrieves the current capture secret of a user
+	GetUserCaptureSecret(ctx context.Context, userID string) (*models.CaptureSecret, error)
+
+	// GetCaptureSecret looks up a capture secret to find the user it belongs to
+	GetCaptureSecret(ctx context.Context, secret string) (*models.CaptureSecret, error)
+
+	// SaveCaptureSecret stores a user's capture secret, replacing the
+	// previous one
+	SaveCaptureSecret(ctx context.Context, secret *models.CaptureSecret) error
+
+	// DeleteUserCaptureSecret removes the capture secret of a user, if any
+	DeleteUserCaptureSecret(ctx context.Context, userID string) error
+}
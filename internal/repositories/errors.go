@@ -4,5 +4,15 @@ import "errors"
 
 // Common repository errors
 var (
-	ErrTodoNotFound = errors.New("todo not found")
+	ErrTodoNotFound              = errors.New("todo not found")
+	ErrTodoExists                = errors.New("todo already exists")
+	ErrConflict                  = errors.New("todo was modified concurrently")
+	ErrUndoTokenNotFound         = errors.New("undo token not found")
+	ErrIdempotencyRecordNotFound = errors.New("idempotency record not found")
+	ErrWebhookNotFound           = errors.New("webhook not found")
+	ErrDeliveryNotFound          = errors.New("webhook delivery not found")
+	ErrCaptureSecretNotFound     = errors.New("capture secret not found")
+	ErrCalendarFeedNotFound      = errors.New("calendar feed not found")
+	ErrAppPasswordNotFound       = errors.New("app password not found")
+	ErrAccountDeletionNotFound   = errors.New("account deletion not found")
 )
@@ -1,6 +1,8 @@
 package repositories
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
 	"log"
 
@@ -8,24 +10,109 @@ import (
 	"github.com/starbops/gottodo/pkg/database"
 )
 
-// NewTodoRepository creates a TodoRepository based on the provided configuration
-func NewTodoRepository(cfg *config.Config) (TodoRepository, error) {
+// Repositories groups the repositories used by the application
+type Repositories struct {
+	Todos        TodoRepository
+	Activity     ActivityRepository
+	Undo         UndoRepository
+	Idempotency  IdempotencyRepository
+	Webhooks     WebhookRepository
+	Capture      CaptureRepository
+	Calendar     CalendarFeedRepository
+	AppPasswords AppPasswordRepository
+	Deletions    AccountDeletionRepository
+
+	// UnitOfWork runs writes across the repositories atomically
+	UnitOfWork UnitOfWork
+
+	// System holds the repositories of background jobs and of the lookups
+	// of capture and calendar feed secrets, which work across users. On
+	// Postgres they connect as a role that bypasses row-level security;
+	// otherwise they are these repositories themselves.
+	System *Repositories
+}
+
+// NewRepositories creates all repositories based on the provided configuration,
+// sharing a single database connection between them
+func NewRepositories(cfg *config.Config) (*Repositories, error) {
 	switch cfg.Repository.Type {
 	case config.MemoryRepository:
-		log.Println("Using in-memory todo repository")
-		return NewMemoryTodoRepository(), nil
-
+		log.Println("Using in-memory repositories")
+		return NewMemoryRepositories(), nil
 	case config.SupabaseRepository:
-		log.Println("Using Supabase todo repository")
+		log.Println("Using Supabase repositories")
 		// Connect to Supabase
 		db, err := database.ConnectToSupabase(cfg)
 		if err != nil {
 			return nil, fmt.Errorf("failed to connect to Supabase: %w", err)
 		}
+		repos := newSupabaseRepositories(db)
 
-		return NewSupabaseTodoRepository(db), nil
+		systemDB := db
+		if cfg.Database.SystemDBURL != "" {
+			systemDB, err = database.Connect(cfg.GetSystemDBURL())
+			if err != nil {
+				return nil, fmt.Errorf("failed to connect as the system role: %w", err)
+			}
+			repos.System = newSupabaseRepositories(systemDB)
+		}
 
+		// Without the bypass, the jobs only see the data of no one
+		bypasses, err := database.BypassesRowLevelSecurity(context.Background(), systemDB)
+		if err != nil {
+			return nil, err
+		}
+		if !bypasses {
+			log.Println("Warning: the database role of the background jobs does not bypass row-level security, so they will not purge expired trash nor find capture and calendar feed URLs; set system_db_url to a role with BYPASSRLS")
+		}
+		return repos, nil
 	default:
 		return nil, fmt.Errorf("unsupported repository type: %s", cfg.Repository.Type)
 	}
 }
+
+// newSupabaseRepositories creates the Supabase repositories on a database
+// connection; they are their own system repositories
+func newSupabaseRepositories(db *sql.DB) *Repositories {
+	repos := &Repositories{
+		UnitOfWork:   NewSupabaseUnitOfWork(db),
+		Todos:        NewSupabaseTodoRepository(db),
+		Activity:     NewSupabaseActivityRepository(db),
+		Undo:         NewSupabaseUndoRepository(db),
+		Idempotency:  NewSupabaseIdempotencyRepository(db),
+		Webhooks:     NewSupabaseWebhookRepository(db),
+		Capture:      NewSupabaseCaptureRepository(db),
+		Calendar:     NewSupabaseCalendarFeedRepository(db),
+		AppPasswords: NewSupabaseAppPasswordRepository(db),
+		Deletions:    NewSupabaseAccountDeletionRepository(db),
+	}
+	repos.System = repos
+	return repos
+}
+
+// NewMemoryRepositories creates in-memory implementations of all repositories
+func NewMemoryRepositories() *Repositories {
+	repos := &Repositories{
+		Todos:        NewMemoryTodoRepository(),
+		Activity:     NewMemoryActivityRepository(),
+		Undo:         NewMemoryUndoRepository(),
+		Idempotency:  NewMemoryIdempotencyRepository(),
+		Webhooks:     NewMemoryWebhookRepository(),
+		Capture:      NewMemoryCaptureRepository(),
+		Calendar:     NewMemoryCalendarFeedRepository(),
+		AppPasswords: NewMemoryAppPasswordRepository(),
+		Deletions:    NewMemoryAccountDeletionRepository(),
+	}
+	repos.UnitOfWork = NewMemoryUnitOfWork(repos)
+	repos.System = repos
+	return repos
+}
+
+// NewTodoRepository creates a TodoRepository based on the provided configuration
+func NewTodoRepository(cfg *config.Config) (TodoRepository, error) {
+	repos, err := NewRepositories(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return repos.Todos, nil
+}
@@ -35,6 +35,40 @@ func TestNewTodoRepository_UnsupportedType(t *testing.T) {
 	}
 }
 
+func TestNewRepositories_Memory(t *testing.T) {
+	cfg := config.DefaultConfig()
+
+	repos, err := NewRepositories(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create memory repositories: %v", err)
+	}
+
+	if _, ok := repos.Todos.(*MemoryTodoRepository); !ok {
+		t.Errorf("Expected *MemoryTodoRepository, got %T", repos.Todos)
+	}
+	if _, ok := repos.Activity.(*MemoryActivityRepository); !ok {
+		t.Errorf("Expected *MemoryActivityRepository, got %T", repos.Activity)
+	}
+	if _, ok := repos.Undo.(*MemoryUndoRepository); !ok {
+		t.Errorf("Expected *MemoryUndoRepository, got %T", repos.Undo)
+	}
+	if _, ok := repos.Idempotency.(*MemoryIdempotencyRepository); !ok {
+		t.Errorf("Expected *MemoryIdempotencyRepository, got %T", repos.Idempotency)
+	}
+	if _, ok := repos.Webhooks.(*MemoryWebhookRepository); !ok {
+		t.Errorf("Expected *MemoryWebhookRepository, got %T", repos.Webhooks)
+	}
+	if _, ok := repos.Capture.(*MemoryCaptureRepository); !ok {
+		t.Errorf("Expected *MemoryCaptureRepository, got %T", repos.Capture)
+	}
+	if _, ok := repos.Calendar.(*MemoryCalendarFeedRepository); !ok {
+		t.Errorf("Expected *MemoryCalendarFeedRepository, got %T", repos.Calendar)
+	}
+	if _, ok := repos.AppPasswords.(*MemoryAppPasswordRepository); !ok {
+		t.Errorf("Expected *MemoryAppPasswordRepository, got %T", repos.AppPasswords)
+	}
+}
+
 // Note: We're not testing the Supabase repository creation since it requires
 // actual database connection details. This would be better tested in an
 // integration test environment with a test database.
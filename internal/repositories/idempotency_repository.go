@@ -0,0 +1,30 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/starbops/gottodo/internal/models"
+)
+
+// IdempotencyRepository defines the interface for storing responses to
+// requests sent with an Idempotency-Key header
+type IdempotencyRepository interface {
+	// GetIdempotencyRecord retrieves the unexpired record of a user's key
+	GetIdempotencyRecord(ctx context.Context, userID, key string) (*models.IdempotencyRecord, error)
+
+	// ReserveIdempotencyRecord stores a pending record unless the key is
+	// already taken, discarding expired records first. It reports whether the
+	// key was reserved, atomically, so only one of concurrent requests wins.
+	ReserveIdempotencyRecord(ctx context.Context, record *models.IdempotencyRecord) (bool, error)
+
+	// SaveIdempotencyRecord stores a record unless the key is already taken
+	// by another request or by a response, and discards expired records
+	SaveIdempotencyRecord(ctx context.Context, record *models.IdempotencyRecord) error
+
+	// DeletePendingIdempotencyRecord releases a key whose request has no
+	// response worth remembering
+	DeletePendingIdempotencyRecord(ctx context.Context, userID, key string) error
+
+	// DeleteUserIdempotencyRecords removes all records of a user
+	DeleteUserIdempotencyRecords(ctx context.Context, userID string) error
+}
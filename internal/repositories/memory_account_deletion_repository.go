@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/starbops/gottodo/internal/models"
+)
+
+// MemoryAccountDeletionRepository is an in-memory implementation of AccountDeletionRepository
+type MemoryAccountDeletionRepository struct {
+	// deletions holds the pending account deletion of each user
+	deletions map[string]*models.AccountDeletion
+	mutex     memoryLock
+
+	// tx is the unit of work the repository is a view for, if any
+	tx *memoryTx
+}
+
+// NewMemoryAccountDeletionRepository creates a new MemoryAccountDeletionRepository
+func NewMemoryAccountDeletionRepository() AccountDeletionRepository {
+	return &MemoryAccountDeletionRepository{
+		deletions: make(map[string]*models.AccountDeletion),
+		mutex:     &sync.RWMutex{},
+	}
+}
+
+// SaveAccountDeletion stores a user's account deletion, replacing any previous one
+func (r *MemoryAccountDeletionRepository) SaveAccountDeletion(ctx context.Context, deletion *models.AccountDeletion) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored := *deletion
+	recordEntry(r.tx, r.deletions, deletion.UserID, copyStruct)
+	r.deletions[deletion.UserID] = &stored
+	return nil
+}
+
+// GetAccountDeletion retrieves the pending account deletion of a user
+func (r *MemoryAccountDeletionRepository) GetAccountDeletion(ctx context.Context, userID string) (*models.AccountDeletion, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	stored, exists := r.deletions[userID]
+	if !exists {
+		return nil, ErrAccountDeletionNotFound
+	}
+
+	result := *stored
+	return &result, nil
+}
+
+// DeleteAccountDeletion removes the pending account deletion of a user
+func (r *MemoryAccountDeletionRepository) DeleteAccountDeletion(ctx context.Context, userID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.deletions[userID]; !exists {
+		return ErrAccountDeletionNotFound
+	}
+	recordEntry(r.tx, r.deletions, userID, copyStruct)
+	delete(r.deletions, userID)
+	return nil
+}
+
+// GetDueAccountDeletions retrieves the account deletions due at now, oldest first
+func (r *MemoryAccountDeletionRepository) GetDueAccountDeletions(ctx context.Context, now time.Time) ([]*models.AccountDeletion, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var due []*models.AccountDeletion
+	for _, stored := range r.deletions {
+		if !stored.DeleteAt.After(now) {
+			result := *stored
+			due = append(due, &result)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].DeleteAt.Before(due[j].DeleteAt)
+	})
+	return due, nil
+}
@@ -0,0 +1,43 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryAccountDeletionRepository(t *testing.T) {
+	repo := NewMemoryAccountDeletionRepository()
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+	_, err := repo.GetAccountDeletion(ctx, userID)
+	assert.Equal(t, ErrAccountDeletionNotFound, err)
+
+	later := models.NewAccountDeletion(userID, time.Hour)
+	assert.NoError(t, repo.SaveAccountDeletion(ctx, later))
+	stored, err := repo.GetAccountDeletion(ctx, userID)
+	assert.NoError(t, err)
+	assert.True(t, later.DeleteAt.Equal(stored.DeleteAt))
+
+	// Only deletions whose time has come are due, oldest first
+	first := models.NewAccountDeletion(uuid.New().String(), -2*time.Hour)
+	second := models.NewAccountDeletion(uuid.New().String(), -time.Hour)
+	assert.NoError(t, repo.SaveAccountDeletion(ctx, second))
+	assert.NoError(t, repo.SaveAccountDeletion(ctx, first))
+
+	due, err := repo.GetDueAccountDeletions(ctx, time.Now())
+	assert.NoError(t, err)
+	assert.Len(t, due, 2)
+	assert.Equal(t, first.UserID, due[0].UserID)
+	assert.Equal(t, second.UserID, due[1].UserID)
+
+	assert.NoError(t, repo.DeleteAccountDeletion(ctx, userID))
+	assert.Equal(t, ErrAccountDeletionNotFound, repo.DeleteAccountDeletion(ctx, userID))
+	_, err = repo.GetAccountDeletion(ctx, userID)
+	assert.Equal(t, ErrAccountDeletionNotFound, err)
+}
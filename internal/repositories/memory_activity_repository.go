@@ -0,0 +1,114 @@
+package repositories
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/starbops/gottodo/internal/models"
+)
+
+// MemoryActivityRepository is an in-memory implementation of ActivityRepository
+type MemoryActivityRepository struct {
+	log   *activityLog
+	mutex memoryLock
+
+	// tx is the unit of work the repository is a view for, if any
+	tx *memoryTx
+}
+
+// activityLog holds the entries of the activity log, oldest first, shared
+// with the views of units of work
+type activityLog struct {
+	entries []*models.Activity
+}
+
+// NewMemoryActivityRepository creates a new MemoryActivityRepository
+func NewMemoryActivityRepository() ActivityRepository {
+	return &MemoryActivityRepository{
+		log:   &activityLog{},
+		mutex: &sync.RWMutex{},
+	}
+}
+
+// AppendActivity appends an entry to the activity log
+func (r *MemoryActivityRepository) AppendActivity(ctx context.Context, activity *models.Activity) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	// Ensure the entry has an ID
+	if activity.ID == "" {
+		activity.ID = generateID()
+	}
+
+	log := r.log
+	appended := len(log.entries)
+	r.tx.record(func() {
+		clear(log.entries[appended:])
+		log.entries = log.entries[:appended]
+	})
+
+	entry := *activity
+	log.entries = append(log.entries, &entry)
+	return nil
+}
+
+// GetTodoActivity retrieves the history of a specific todo, newest first
+func (r *MemoryActivityRepository) GetTodoActivity(ctx context.Context, todoID string) ([]*models.Activity, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var history []*models.Activity
+	for i := len(r.log.entries) - 1; i >= 0; i-- {
+		if r.log.entries[i].TodoID == todoID {
+			entry := *r.log.entries[i]
+			history = append(history, &entry)
+		}
+	}
+
+	return history, nil
+}
+
+// GetUserActivity retrieves a page of the activity performed by a user, newest first
+func (r *MemoryActivityRepository) GetUserActivity(ctx context.Context, userID string, limit, offset int) ([]*models.Activity, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var feed []*models.Activity
+	skipped := 0
+	for i := len(r.log.entries) - 1; i >= 0 && len(feed) < limit; i-- {
+		if r.log.entries[i].ActorID != userID {
+			continue
+		}
+		if skipped < offset {
+			skipped++
+			continue
+		}
+		entry := *r.log.entries[i]
+		feed = append(feed, &entry)
+	}
+
+	return feed, nil
+}
+
+// DeleteUserActivity removes the activity performed by a user
+func (r *MemoryActivityRepository) DeleteUserActivity(ctx context.Context, userID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	log := r.log
+	if r.tx != nil {
+		old := slices.Clone(log.entries)
+		r.tx.record(func() { log.entries = old })
+	}
+
+	kept := log.entries[:0]
+	for _, entry := range log.entries {
+		if entry.ActorID != userID {
+			kept = append(kept, entry)
+		}
+	}
+	clear(log.entries[len(kept):])
+	log.entries = kept
+	return nil
+}
@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryActivityRepository_GetTodoActivity(t *testing.T) {
+	repo := NewMemoryActivityRepository()
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+	todoID := uuid.New().String()
+	otherTodoID := uuid.New().String()
+
+	// Append entries for two todos
+	err := repo.AppendActivity(ctx, models.NewActivity(userID, todoID, models.ActivityCreated, nil))
+	assert.NoError(t, err)
+
+	err = repo.AppendActivity(ctx, models.NewActivity(userID, otherTodoID, models.ActivityCreated, nil))
+	assert.NoError(t, err)
+
+	err = repo.AppendActivity(ctx, models.NewActivity(userID, todoID, models.ActivityCompleted, nil))
+	assert.NoError(t, err)
+
+	// History only contains the requested todo, newest first
+	history, err := repo.GetTodoActivity(ctx, todoID)
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+	assert.Equal(t, models.ActivityCompleted, history[0].Action)
+	assert.Equal(t, models.ActivityCreated, history[1].Action)
+
+	// Unknown todos have no history
+	history, err = repo.GetTodoActivity(ctx, "non-existent-id")
+	assert.NoError(t, err)
+	assert.Len(t, history, 0)
+}
+
+func TestMemoryActivityRepository_GetUserActivity(t *testing.T) {
+	repo := NewMemoryActivityRepository()
+	ctx := context.Background()
+
+	userID1 := uuid.New().String()
+	userID2 := uuid.New().String()
+
+	// Append five entries for user1 and one for user2
+	var todoIDs []string
+	for i := 0; i < 5; i++ {
+		todoID := uuid.New().String()
+		todoIDs = append(todoIDs, todoID)
+		err := repo.AppendActivity(ctx, models.NewActivity(userID1, todoID, models.ActivityCreated, nil))
+		assert.NoError(t, err)
+	}
+	err := repo.AppendActivity(ctx, models.NewActivity(userID2, uuid.New().String(), models.ActivityCreated, nil))
+	assert.NoError(t, err)
+
+	// First page
+	feed, err := repo.GetUserActivity(ctx, userID1, 2, 0)
+	assert.NoError(t, err)
+	assert.Len(t, feed, 2)
+	assert.Equal(t, todoIDs[4], feed[0].TodoID)
+	assert.Equal(t, todoIDs[3], feed[1].TodoID)
+
+	// Last, partial page
+	feed, err = repo.GetUserActivity(ctx, userID1, 2, 4)
+	assert.NoError(t, err)
+	assert.Len(t, feed, 1)
+	assert.Equal(t, todoIDs[0], feed[0].TodoID)
+
+	// Other users' activity is not included
+	feed, err = repo.GetUserActivity(ctx, userID2, 10, 0)
+	assert.NoError(t, err)
+	assert.Len(t, feed, 1)
+}
+
+func TestMemoryActivityRepository_AppendOnly(t *testing.T) {
+	repo := NewMemoryActivityRepository()
+	ctx := context.Background()
+
+	activity := models.NewActivity(uuid.New().String(), uuid.New().String(), models.ActivityCreated, nil)
+	err := repo.AppendActivity(ctx, activity)
+	assert.NoError(t, err)
+
+	// Mutating the caller's copy or a returned entry must not rewrite history
+	activity.Action = models.ActivityDeleted
+	history, err := repo.GetTodoActivity(ctx, activity.TodoID)
+	assert.NoError(t, err)
+	history[0].Action = models.ActivityUpdated
+
+	history, err = repo.GetTodoActivity(ctx, activity.TodoID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ActivityCreated, history[0].Action)
+}
+
+func TestMemoryActivityRepository_DeleteUserActivity(t *testing.T) {
+	repo := NewMemoryActivityRepository()
+	ctx := context.Background()
+
+	userID, otherID := uuid.New().String(), uuid.New().String()
+	todoID := uuid.New().String()
+	for _, actorID := range []string{userID, otherID, userID} {
+		assert.NoError(t, repo.AppendActivity(ctx, models.NewActivity(actorID, todoID, models.ActivityUpdated, nil)))
+	}
+
+	assert.NoError(t, repo.DeleteUserActivity(ctx, userID))
+
+	entries, err := repo.GetTodoActivity(ctx, todoID)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, otherID, entries[0].ActorID)
+}
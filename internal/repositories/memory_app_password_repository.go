@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/starbops/gottodo/internal/models"
+)
+
+// MemoryAppPasswordRepository is an in-memory implementation of AppPasswordRepository
+type MemoryAppPasswordRepository struct {
+	passwords map[string]*models.AppPassword
+	mutex     memoryLock
+
+	// tx is the unit of work the repository is a view for, if any
+	tx *memoryTx
+}
+
+// NewMemoryAppPasswordRepository creates a new MemoryAppPasswordRepository
+func NewMemoryAppPasswordRepository() AppPasswordRepository {
+	return &MemoryAppPasswordRepository{
+		passwords: make(map[string]*models.AppPassword),
+		mutex:     &sync.RWMutex{},
+	}
+}
+
+// CreateAppPassword stores a new app password
+func (r *MemoryAppPasswordRepository) CreateAppPassword(ctx context.Context, password *models.AppPassword) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored := *password
+	recordEntry(r.tx, r.passwords, password.ID, copyStruct)
+	r.passwords[password.ID] = &stored
+	return nil
+}
+
+// GetAppPassword retrieves an app password by ID
+func (r *MemoryAppPasswordRepository) GetAppPassword(ctx context.Context, id string) (*models.AppPassword, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	stored, exists := r.passwords[id]
+	if !exists {
+		return nil, ErrAppPasswordNotFound
+	}
+
+	result := *stored
+	return &result, nil
+}
+
+// GetAppPasswordByHash looks up an app password by the hash of the password
+func (r *MemoryAppPasswordRepository) GetAppPasswordByHash(ctx context.Context, hash string) (*models.AppPassword, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, stored := range r.passwords {
+		if stored.Hash == hash {
+			result := *stored
+			return &result, nil
+		}
+	}
+	return nil, ErrAppPasswordNotFound
+}
+
+// GetUserAppPasswords retrieves the app passwords of a user, oldest first
+func (r *MemoryAppPasswordRepository) GetUserAppPasswords(ctx context.Context, userID string) ([]*models.AppPassword, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var passwords []*models.AppPassword
+	for _, stored := range r.passwords {
+		if stored.UserID == userID {
+			result := *stored
+			passwords = append(passwords, &result)
+		}
+	}
+
+	sort.SliceStable(passwords, func(i, j int) bool {
+		return passwords[i].CreatedAt.Before(passwords[j].CreatedAt)
+	})
+	return passwords, nil
+}
+
+// DeleteAppPassword removes an app password
+func (r *MemoryAppPasswordRepository) DeleteAppPassword(ctx context.Context, id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.passwords[id]; !exists {
+		return ErrAppPasswordNotFound
+	}
+	recordEntry(r.tx, r.passwords, id, copyStruct)
+	delete(r.passwords, id)
+	return nil
+}
+
+// DeleteUserAppPasswords removes all app passwords of a user
+func (r *MemoryAppPasswordRepository) DeleteUserAppPasswords(ctx context.Context, userID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for id, password := range r.passwords {
+		if password.UserID == userID {
+			recordEntry(r.tx, r.passwords, id, copyStruct)
+			delete(r.passwords, id)
+		}
+	}
+	return nil
+}
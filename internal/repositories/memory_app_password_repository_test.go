@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryAppPasswordRepository(t *testing.T) {
+	repo := NewMemoryAppPasswordRepository()
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+	first, password := models.NewAppPassword(userID, "Phone")
+	second, _ := models.NewAppPassword(userID, "Laptop")
+	second.CreatedAt = first.CreatedAt.Add(time.Second)
+	other, _ := models.NewAppPassword(uuid.New().String(), "Tablet")
+	for _, p := range []*models.AppPassword{second, first, other} {
+		assert.NoError(t, repo.CreateAppPassword(ctx, p))
+	}
+
+	stored, err := repo.GetAppPasswordByHash(ctx, models.HashAppPassword(password))
+	assert.NoError(t, err)
+	assert.Equal(t, first.ID, stored.ID)
+
+	_, err = repo.GetAppPasswordByHash(ctx, models.HashAppPassword("app_wrong"))
+	assert.Equal(t, ErrAppPasswordNotFound, err)
+
+	passwords, err := repo.GetUserAppPasswords(ctx, userID)
+	assert.NoError(t, err)
+	if assert.Len(t, passwords, 2) {
+		assert.Equal(t, "Phone", passwords[0].Name)
+		assert.Equal(t, "Laptop", passwords[1].Name)
+	}
+
+	assert.NoError(t, repo.DeleteAppPassword(ctx, first.ID))
+	_, err = repo.GetAppPassword(ctx, first.ID)
+	assert.Equal(t, ErrAppPasswordNotFound, err)
+	assert.Equal(t, ErrAppPasswordNotFound, repo.DeleteAppPassword(ctx, first.ID))
+}
+
+func TestMemoryAppPasswordRepository_DeleteUserAppPasswords(t *testing.T) {
+	repo := NewMemoryAppPasswordRepository()
+	ctx := context.Background()
+
+	userID, otherID := uuid.New().String(), uuid.New().String()
+	for _, id := range []string{userID, userID, otherID} {
+		appPassword, _ := models.NewAppPassword(id, "Phone")
+		assert.NoError(t, repo.CreateAppPassword(ctx, appPassword))
+	}
+
+	assert.NoError(t, repo.DeleteUserAppPasswords(ctx, userID))
+
+	passwords, err := repo.GetUserAppPasswords(ctx, userID)
+	assert.NoError(t, err)
+	assert.Empty(t, passwords)
+	passwords, err = repo.GetUserAppPasswords(ctx, otherID)
+	assert.NoError(t, err)
+	assert.Len(t, passwords, 1)
+}
@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+
+	"github.com/starbops/gottodo/internal/models"
+)
+
+// MemoryCalendarFeedRepository is an in-memory implementation of CalendarFeedRepository
+type MemoryCalendarFeedRepository struct {
+	// feeds holds the current calendar feed of each user
+	feeds map[string]*models.CalendarFeed
+	mutex memoryLock
+
+	// tx is the unit of work the repository is a view for, if any
+	tx *memoryTx
+}
+
+// NewMemoryCalendarFeedRepository creates a new MemoryCalendarFeedRepository
+func NewMemoryCalendarFeedRepository() CalendarFeedRepository {
+	return &MemoryCalendarFeedRepository{
+		feeds: make(map[string]*models.CalendarFeed),
+		mutex: &sync.RWMutex{},
+	}
+}
+
+// GetUserCalendarFeed retrieves the current calendar feed of a user
+func (r *MemoryCalendarFeedRepository) GetUserCalendarFeed(ctx context.Context, userID string) (*models.CalendarFeed, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	stored, exists := r.feeds[userID]
+	if !exists {
+		return nil, ErrCalendarFeedNotFound
+	}
+
+	result := *stored
+	return &result, nil
+}
+
+// GetCalendarFeed looks up a feed secret to find the user it belongs to
+func (r *MemoryCalendarFeedRepository) GetCalendarFeed(ctx context.Context, secret string) (*models.CalendarFeed, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, stored := range r.feeds {
+		if stored.Secret == secret {
+			result := *stored
+			return &result, nil
+		}
+	}
+	return nil, ErrCalendarFeedNotFound
+}
+
+// SaveCalendarFeed stores a user's calendar feed, replacing the previous one
+func (r *MemoryCalendarFeedRepository) SaveCalendarFeed(ctx context.Context, feed *models.CalendarFeed) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored := *feed
+	recordEntry(r.tx, r.feeds, feed.UserID, copyStruct)
+	r.feeds[feed.UserID] = &stored
+	return nil
+}
+
+// DeleteUserCalendarFeed removes the calendar feed of a user, if any
+func (r *MemoryCalendarFeedRepository) DeleteUserCalendarFeed(ctx context.Context, userID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	recordEntry(r.tx, r.feeds, userID, copyStruct)
+	delete(r.feeds, userID)
+	return nil
+}
@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryCalendarFeedRepository_SaveAndGet(t *testing.T) {
+	repo := NewMemoryCalendarFeedRepository()
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+	_, err := repo.GetUserCalendarFeed(ctx, userID)
+	assert.Equal(t, ErrCalendarFeedNotFound, err)
+
+	first := models.NewCalendarFeed(userID)
+	assert.NoError(t, repo.SaveCalendarFeed(ctx, first))
+
+	stored, err := repo.GetCalendarFeed(ctx, first.Secret)
+	assert.NoError(t, err)
+	assert.Equal(t, userID, stored.UserID)
+
+	// Saving another secret replaces the previous one
+	second := models.NewCalendarFeed(userID)
+	assert.NoError(t, repo.SaveCalendarFeed(ctx, second))
+
+	stored, err = repo.GetUserCalendarFeed(ctx, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, second.Secret, stored.Secret)
+
+	_, err = repo.GetCalendarFeed(ctx, first.Secret)
+	assert.Equal(t, ErrCalendarFeedNotFound, err)
+}
+
+func TestMemoryCalendarFeedRepository_DeleteUserCalendarFeed(t *testing.T) {
+	repo := NewMemoryCalendarFeedRepository()
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+	feed := models.NewCalendarFeed(userID)
+	assert.NoError(t, repo.SaveCalendarFeed(ctx, feed))
+
+	assert.NoError(t, repo.DeleteUserCalendarFeed(ctx, userID))
+
+	_, err := repo.GetCalendarFeed(ctx, feed.Secret)
+	assert.Equal(t, ErrCalendarFeedNotFound, err)
+
+	// Users without a feed are left as they are
+	assert.NoError(t, repo.DeleteUserCalendarFeed(ctx, userID))
+}
@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+
+	"github.com/starbops/gottodo/internal/models"
+)
+
+// MemoryCaptureRepository is an in-memory implementation of CaptureRepository
+type MemoryCaptureRepository struct {
+	// secrets holds the current capture secret of each user
+	secrets map[string]*models.CaptureSecret
+	mutex   memoryLock
+
+	// tx is the unit of work the repository is a view for, if any
+	tx *memoryTx
+}
+
+// NewMemoryCaptureRepository creates a new MemoryCaptureRepository
+func NewMemoryCaptureRepository() CaptureRepository {
+	return &MemoryCaptureRepository{
+		secrets: make(map[string]*models.CaptureSecret),
+		mutex:   &sync.RWMutex{},
+	}
+}
+
+// GetUserCaptureSecret retrieves the current capture secret of a user
+func (r *MemoryCaptureRepository) GetUserCaptureSecret(ctx context.Context, userID string) (*models.CaptureSecret, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	stored, exists := r.secrets[userID]
+	if !exists {
+		return nil, ErrCaptureSecretNotFound
+	}
+
+	result := *stored
+	return &result, nil
+}
+
+// GetCaptureSecret looks up a capture secret to find the user it belongs to
+func (r *MemoryCaptureRepository) GetCaptureSecret(ctx context.Context, secret string) (*models.CaptureSecret, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, stored := range r.secrets {
+		if stored.Secret == secret {
+			result := *stored
+			return &result, nil
+		}
+	}
+	return nil, ErrCaptureSecretNotFound
+}
+
+// SaveCaptureSecret stores a user's capture secret, replacing the previous one
+func (r *MemoryCaptureRepository) SaveCaptureSecret(ctx context.Context, secret *models.CaptureSecret) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored := *secret
+	recordEntry(r.tx, r.secrets, secret.UserID, copyStruct)
+	r.secrets[secret.UserID] = &stored
+	return nil
+}
+
+// DeleteUserCaptureSecret removes the capture secret of a user, if any
+func (r *MemoryCaptureRepository) DeleteUserCaptureSecret(ctx context.Context, userID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	recordEntry(r.tx, r.secrets, userID, copyStruct)
+	delete(r.secrets, userID)
+	return nil
+}
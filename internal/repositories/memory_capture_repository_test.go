@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryCaptureRepository_SaveAndGet(t *testing.T) {
+	repo := NewMemoryCaptureRepository()
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+	_, err := repo.GetUserCaptureSecret(ctx, userID)
+	assert.Equal(t, ErrCaptureSecretNotFound, err)
+
+	first := models.NewCaptureSecret(userID)
+	assert.NoError(t, repo.SaveCaptureSecret(ctx, first))
+
+	stored, err := repo.GetCaptureSecret(ctx, first.Secret)
+	assert.NoError(t, err)
+	assert.Equal(t, userID, stored.UserID)
+
+	// Saving another secret replaces the previous one
+	second := models.NewCaptureSecret(userID)
+	assert.NoError(t, repo.SaveCaptureSecret(ctx, second))
+
+	stored, err = repo.GetUserCaptureSecret(ctx, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, second.Secret, stored.Secret)
+
+	_, err = repo.GetCaptureSecret(ctx, first.Secret)
+	assert.Equal(t, ErrCaptureSecretNotFound, err)
+}
+
+func TestMemoryCaptureRepository_DeleteUserCaptureSecret(t *testing.T) {
+	repo := NewMemoryCaptureRepository()
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+	secret := models.NewCaptureSecret(userID)
+	assert.NoError(t, repo.SaveCaptureSecret(ctx, secret))
+
+	assert.NoError(t, repo.DeleteUserCaptureSecret(ctx, userID))
+
+	_, err := repo.GetCaptureSecret(ctx, secret.Secret)
+	assert.Equal(t, ErrCaptureSecretNotFound, err)
+
+	// Users without a secret are left as they are
+	assert.NoError(t, repo.DeleteUserCaptureSecret(ctx, userID))
+}
@@ -0,0 +1,120 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/starbops/gottodo/internal/models"
+)
+
+// MemoryIdempotencyRepository is an in-memory implementation of IdempotencyRepository
+type MemoryIdempotencyRepository struct {
+	records map[string]*models.IdempotencyRecord
+	mutex   memoryLock
+
+	// tx is the unit of work the repository is a view for, if any
+	tx *memoryTx
+}
+
+// NewMemoryIdempotencyRepository creates a new MemoryIdempotencyRepository
+func NewMemoryIdempotencyRepository() IdempotencyRepository {
+	return &MemoryIdempotencyRepository{
+		records: make(map[string]*models.IdempotencyRecord),
+		mutex:   &sync.RWMutex{},
+	}
+}
+
+// idempotencyRecordKey scopes an idempotency key to its user
+func idempotencyRecordKey(userID, key string) string {
+	return userID + "\x00" + key
+}
+
+// GetIdempotencyRecord retrieves the unexpired record of a user's key
+func (r *MemoryIdempotencyRepository) GetIdempotencyRecord(ctx context.Context, userID, key string) (*models.IdempotencyRecord, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored, exists := r.records[idempotencyRecordKey(userID, key)]
+	if !exists || stored.IsExpired() {
+		return nil, ErrIdempotencyRecordNotFound
+	}
+
+	result := *stored
+	return &result, nil
+}
+
+// deleteExpired discards expired records; the caller must hold the lock
+func (r *MemoryIdempotencyRepository) deleteExpired() {
+	now := time.Now()
+	for key, existing := range r.records {
+		if now.After(existing.ExpiresAt) {
+			recordEntry(r.tx, r.records, key, copyStruct)
+			delete(r.records, key)
+		}
+	}
+}
+
+// ReserveIdempotencyRecord stores a pending record unless the key is already taken
+func (r *MemoryIdempotencyRepository) ReserveIdempotencyRecord(ctx context.Context, record *models.IdempotencyRecord) (bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.deleteExpired()
+
+	key := idempotencyRecordKey(record.UserID, record.Key)
+	if _, exists := r.records[key]; exists {
+		return false, nil
+	}
+
+	stored := *record
+	recordEntry(r.tx, r.records, key, copyStruct)
+	r.records[key] = &stored
+	return true, nil
+}
+
+// SaveIdempotencyRecord stores a record unless the key is already taken by
+// another request or by a response
+func (r *MemoryIdempotencyRepository) SaveIdempotencyRecord(ctx context.Context, record *models.IdempotencyRecord) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.deleteExpired()
+
+	key := idempotencyRecordKey(record.UserID, record.Key)
+	if existing, exists := r.records[key]; exists && (!existing.IsPending() || existing.RequestHash != record.RequestHash) {
+		return nil
+	}
+
+	stored := *record
+	recordEntry(r.tx, r.records, key, copyStruct)
+	r.records[key] = &stored
+	return nil
+}
+
+// DeletePendingIdempotencyRecord releases a key whose request has no response worth remembering
+func (r *MemoryIdempotencyRepository) DeletePendingIdempotencyRecord(ctx context.Context, userID, key string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	recordKey := idempotencyRecordKey(userID, key)
+	if existing, exists := r.records[recordKey]; exists && existing.IsPending() {
+		recordEntry(r.tx, r.records, recordKey, copyStruct)
+		delete(r.records, recordKey)
+	}
+	return nil
+}
+
+// DeleteUserIdempotencyRecords removes all records of a user
+func (r *MemoryIdempotencyRepository) DeleteUserIdempotencyRecords(ctx context.Context, userID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for key, record := range r.records {
+		if record.UserID == userID {
+			recordEntry(r.tx, r.records, key, copyStruct)
+			delete(r.records, key)
+		}
+	}
+	return nil
+}
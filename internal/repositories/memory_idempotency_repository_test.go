@@ -0,0 +1,128 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryIdempotencyRepository_SaveAndGet(t *testing.T) {
+	repo := NewMemoryIdempotencyRepository()
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+	record := models.NewIdempotencyRecord(userID, "key-1", "hash", 201, "application/json", []byte(`{"id":"1"}`), time.Hour)
+
+	err := repo.SaveIdempotencyRecord(ctx, record)
+	assert.NoError(t, err)
+
+	stored, err := repo.GetIdempotencyRecord(ctx, userID, "key-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "hash", stored.RequestHash)
+	assert.Equal(t, 201, stored.StatusCode)
+	assert.Equal(t, []byte(`{"id":"1"}`), stored.Body)
+
+	// The first record stored for a key is kept
+	other := models.NewIdempotencyRecord(userID, "key-1", "other-hash", 200, "text/plain", []byte("late"), time.Hour)
+	err = repo.SaveIdempotencyRecord(ctx, other)
+	assert.NoError(t, err)
+
+	stored, err = repo.GetIdempotencyRecord(ctx, userID, "key-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "hash", stored.RequestHash)
+
+	// Keys are scoped to their user
+	_, err = repo.GetIdempotencyRecord(ctx, uuid.New().String(), "key-1")
+	assert.Equal(t, ErrIdempotencyRecordNotFound, err)
+}
+
+func TestMemoryIdempotencyRepository_Expired(t *testing.T) {
+	repo := NewMemoryIdempotencyRepository()
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+	record := models.NewIdempotencyRecord(userID, "key-1", "hash", 201, "application/json", nil, -time.Second)
+
+	err := repo.SaveIdempotencyRecord(ctx, record)
+	assert.NoError(t, err)
+
+	_, err = repo.GetIdempotencyRecord(ctx, userID, "key-1")
+	assert.Equal(t, ErrIdempotencyRecordNotFound, err)
+
+	// An expired key can be used again
+	fresh := models.NewIdempotencyRecord(userID, "key-1", "new-hash", 201, "application/json", nil, time.Hour)
+	err = repo.SaveIdempotencyRecord(ctx, fresh)
+	assert.NoError(t, err)
+
+	stored, err := repo.GetIdempotencyRecord(ctx, userID, "key-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "new-hash", stored.RequestHash)
+}
+
+func TestMemoryIdempotencyRepository_DeleteUserIdempotencyRecords(t *testing.T) {
+	repo := NewMemoryIdempotencyRepository()
+	ctx := context.Background()
+
+	userID, otherID := uuid.New().String(), uuid.New().String()
+	for _, id := range []string{userID, otherID} {
+		record := models.NewIdempotencyRecord(id, "key", "hash", 201, "application/json", []byte(`{}`), time.Hour)
+		assert.NoError(t, repo.SaveIdempotencyRecord(ctx, record))
+	}
+
+	assert.NoError(t, repo.DeleteUserIdempotencyRecords(ctx, userID))
+
+	_, err := repo.GetIdempotencyRecord(ctx, userID, "key")
+	assert.Equal(t, ErrIdempotencyRecordNotFound, err)
+	_, err = repo.GetIdempotencyRecord(ctx, otherID, "key")
+	assert.NoError(t, err)
+}
+
+func TestMemoryIdempotencyRepository_Reserve(t *testing.T) {
+	repo := NewMemoryIdempotencyRepository()
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+	pending := models.NewPendingIdempotencyRecord(userID, "key-1", "hash", time.Hour)
+
+	// Only the first reservation of a key wins
+	reserved, err := repo.ReserveIdempotencyRecord(ctx, pending)
+	assert.NoError(t, err)
+	assert.True(t, reserved)
+	reserved, err = repo.ReserveIdempotencyRecord(ctx, models.NewPendingIdempotencyRecord(userID, "key-1", "hash", time.Hour))
+	assert.NoError(t, err)
+	assert.False(t, reserved)
+
+	stored, err := repo.GetIdempotencyRecord(ctx, userID, "key-1")
+	assert.NoError(t, err)
+	assert.True(t, stored.IsPending())
+
+	// Another request cannot store its response under the reserved key
+	other := models.NewIdempotencyRecord(userID, "key-1", "other-hash", 200, "text/plain", []byte("late"), time.Hour)
+	assert.NoError(t, repo.SaveIdempotencyRecord(ctx, other))
+	stored, err = repo.GetIdempotencyRecord(ctx, userID, "key-1")
+	assert.NoError(t, err)
+	assert.True(t, stored.IsPending())
+
+	// The request holding the key stores its response in place of the reservation
+	record := models.NewIdempotencyRecord(userID, "key-1", "hash", 201, "application/json", []byte(`{}`), time.Hour)
+	assert.NoError(t, repo.SaveIdempotencyRecord(ctx, record))
+	stored, err = repo.GetIdempotencyRecord(ctx, userID, "key-1")
+	assert.NoError(t, err)
+	assert.Equal(t, 201, stored.StatusCode)
+
+	// Stored responses are not released
+	assert.NoError(t, repo.DeletePendingIdempotencyRecord(ctx, userID, "key-1"))
+	_, err = repo.GetIdempotencyRecord(ctx, userID, "key-1")
+	assert.NoError(t, err)
+
+	// Reservations are
+	_, err = repo.ReserveIdempotencyRecord(ctx, models.NewPendingIdempotencyRecord(userID, "key-2", "hash", time.Hour))
+	assert.NoError(t, err)
+	assert.NoError(t, repo.DeletePendingIdempotencyRecord(ctx, userID, "key-2"))
+	_, err = repo.GetIdempotencyRecord(ctx, userID, "key-2")
+	assert.Equal(t, ErrIdempotencyRecordNotFound, err)
+}
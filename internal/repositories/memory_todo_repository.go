@@ -2,23 +2,32 @@ package repositories
 
 import (
 	"context"
+	"fmt"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/starbops/gottodo/internal/models"
 )
 
-// MemoryTodoRepository is an in-memory implementation of TodoRepository
+// MemoryTodoRepository is an in-memory implementation of TodoRepository.
+// Todos are copied on the way in and out so callers can never modify stored
+// state without going through UpdateTodo and its version check.
 type MemoryTodoRepository struct {
 	todos map[string]*models.Todo
-	mutex sync.RWMutex
+	mutex memoryLock
+
+	// tx is the unit of work the repository is a view for, if any
+	tx *memoryTx
 }
 
 // NewMemoryTodoRepository creates a new MemoryTodoRepository
 func NewMemoryTodoRepository() TodoRepository {
 	return &MemoryTodoRepository{
 		todos: make(map[string]*models.Todo),
+		mutex: &sync.RWMutex{},
 	}
 }
 
@@ -34,14 +43,46 @@ func (r *MemoryTodoRepository) GetUserTodos(ctx context.Context, userID string)
 
 	var userTodos []*models.Todo
 	for _, todo := range r.todos {
-		if todo.UserID == userID {
-			userTodos = append(userTodos, todo)
+		if todo.UserID == userID && !todo.IsTrashed() {
+			userTodos = append(userTodos, copyTodo(todo))
 		}
 	}
 
+	sortOldestFirst(userTodos)
 	return userTodos, nil
 }
 
+// sortOldestFirst sorts todos by creation, those created at the same time by ID
+func sortOldestFirst(todos []*models.Todo) {
+	sort.Slice(todos, func(i, j int) bool {
+		if !todos[i].CreatedAt.Equal(todos[j].CreatedAt) {
+			return todos[i].CreatedAt.Before(todos[j].CreatedAt)
+		}
+		return todos[i].ID < todos[j].ID
+	})
+}
+
+// StreamUserTodos calls fn with each todo of a user, oldest first. The todos
+// are copied before fn is called, so fn may use the repository.
+func (r *MemoryTodoRepository) StreamUserTodos(ctx context.Context, userID string, fn func(todo *models.Todo) error) error {
+	r.mutex.RLock()
+	var userTodos []*models.Todo
+	for _, todo := range r.todos {
+		if todo.UserID == userID {
+			userTodos = append(userTodos, copyTodo(todo))
+		}
+	}
+	r.mutex.RUnlock()
+
+	sortOldestFirst(userTodos)
+	for _, todo := range userTodos {
+		if err := fn(todo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // GetTodo retrieves a specific todo by ID
 func (r *MemoryTodoRepository) GetTodo(ctx context.Context, todoID string) (*models.Todo, error) {
 	r.mutex.RLock()
@@ -52,7 +93,7 @@ func (r *MemoryTodoRepository) GetTodo(ctx context.Context, todoID string) (*mod
 		return nil, ErrTodoNotFound
 	}
 
-	return todo, nil
+	return copyTodo(todo), nil
 }
 
 // CreateTodo creates a new todo
@@ -64,35 +105,281 @@ func (r *MemoryTodoRepository) CreateTodo(ctx context.Context, todo *models.Todo
 	if todo.ID == "" {
 		todo.ID = generateID()
 	}
+	if _, exists := r.todos[todo.ID]; exists {
+		return ErrTodoExists
+	}
+
+	setCreatedTimestamps(todo)
 
-	r.todos[todo.ID] = todo
+	// Every todo starts at the first version
+	todo.Version = 1
+
+	recordEntry(r.tx, r.todos, todo.ID, copyTodo)
+	r.todos[todo.ID] = copyTodo(todo)
 	return nil
 }
 
-// UpdateTodo updates an existing todo
+// CreateTodos creates several todos, storing none of them if any already exists
+func (r *MemoryTodoRepository) CreateTodos(ctx context.Context, todos []*models.Todo) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	ids := make(map[string]bool, len(todos))
+	for _, todo := range todos {
+		if todo.ID == "" {
+			todo.ID = generateID()
+		}
+		if _, exists := r.todos[todo.ID]; exists || ids[todo.ID] {
+			return ErrTodoExists
+		}
+		ids[todo.ID] = true
+	}
+
+	for _, todo := range todos {
+		setCreatedTimestamps(todo)
+		todo.Version = 1
+		recordEntry(r.tx, r.todos, todo.ID, copyTodo)
+		r.todos[todo.ID] = copyTodo(todo)
+	}
+	return nil
+}
+
+// setCreatedTimestamps sets the timestamps a new todo is missing, equal as in
+// models.NewTodo
+func setCreatedTimestamps(todo *models.Todo) {
+	if todo.CreatedAt.IsZero() {
+		todo.CreatedAt = time.Now()
+	}
+	if todo.UpdatedAt.IsZero() {
+		todo.UpdatedAt = todo.CreatedAt
+	}
+}
+
+// UpdateTodo updates an existing todo if its version matches the stored one
 func (r *MemoryTodoRepository) UpdateTodo(ctx context.Context, todo *models.Todo) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	_, exists := r.todos[todo.ID]
+	stored, exists := r.todos[todo.ID]
 	if !exists {
 		return ErrTodoNotFound
 	}
 
-	r.todos[todo.ID] = todo
+	if stored.Version != todo.Version {
+		return ErrConflict
+	}
+
+	todo.Version++
+	recordEntry(r.tx, r.todos, todo.ID, copyTodo)
+	r.todos[todo.ID] = copyTodo(todo)
 	return nil
 }
 
-// DeleteTodo deletes a todo by ID
-func (r *MemoryTodoRepository) DeleteTodo(ctx context.Context, todoID string) error {
+// PatchTodo writes only the named fields of an existing todo
+func (r *MemoryTodoRepository) PatchTodo(ctx context.Context, todo *models.Todo, fields []string) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	_, exists := r.todos[todoID]
+	stored, exists := r.todos[todo.ID]
 	if !exists {
 		return ErrTodoNotFound
 	}
 
-	delete(r.todos, todoID)
+	if stored.Version != todo.Version {
+		return ErrConflict
+	}
+
+	patched := copyTodo(stored)
+	for _, field := range fields {
+		switch field {
+		case models.FieldTitle:
+			patched.Title = todo.Title
+		case models.FieldDescription:
+			patched.Description = todo.Description
+		case models.FieldCompleted:
+			patched.Completed = todo.Completed
+		case models.FieldDueAt:
+			patched.DueAt = todo.DueAt
+		case models.FieldPriority:
+			patched.Priority = todo.Priority
+		case models.FieldProject:
+			patched.Project = todo.Project
+		case models.FieldTags:
+			patched.Tags = todo.Tags
+		case models.FieldRecurrence:
+			patched.Recurrence = todo.Recurrence
+		default:
+			return fmt.Errorf("unknown todo field %q", field)
+		}
+	}
+	if todo.UpdatedAt.IsZero() {
+		todo.UpdatedAt = time.Now()
+	}
+	patched.UpdatedAt = todo.UpdatedAt
+	patched.Version++
+
+	// Copy again so the stored todo shares no due date or tags with the caller
+	recordEntry(r.tx, r.todos, todo.ID, copyTodo)
+	r.todos[todo.ID] = copyTodo(patched)
+	todo.Version = patched.Version
+	return nil
+}
+
+// UpdateTodos applies update to several todos under a single lock
+func (r *MemoryTodoRepository) UpdateTodos(ctx context.Context, todoIDs []string, update BulkUpdateFunc) ([]BulkResult, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	// Stage all changes first so the batch is stored as a whole
+	results := make([]BulkResult, len(todoIDs))
+	staged := make(map[string]*models.Todo)
+	for i, todoID := range todoIDs {
+		results[i].TodoID = todoID
+
+		stored, exists := r.todos[todoID]
+		if !exists {
+			results[i].Err = ErrTodoNotFound
+			continue
+		}
+		if current, ok := staged[todoID]; ok {
+			stored = current
+		}
+
+		todo := copyTodo(stored)
+		if err := update(todo); err != nil {
+			results[i].Err = err
+			continue
+		}
+		todo.UpdatedAt = time.Now()
+		todo.Version++
+
+		results[i].Before = copyTodo(stored)
+		results[i].Todo = copyTodo(todo)
+		staged[todoID] = todo
+	}
+
+	for todoID, todo := range staged {
+		recordEntry(r.tx, r.todos, todoID, copyTodo)
+		r.todos[todoID] = todo
+	}
+	return results, nil
+}
+
+// DeleteTodo moves a todo to the trash by ID unless its version changed
+func (r *MemoryTodoRepository) DeleteTodo(ctx context.Context, todoID string, version int) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	todo, exists := r.todos[todoID]
+	if !exists || todo.IsTrashed() {
+		return ErrTodoNotFound
+	}
+	if todo.Version != version {
+		return ErrConflict
+	}
+
+	recordEntry(r.tx, r.todos, todoID, copyTodo)
+	todo.MoveToTrash()
+	todo.Version++
+	return nil
+}
+
+// GetTrashedTodos retrieves the todos a user has moved to the trash
+func (r *MemoryTodoRepository) GetTrashedTodos(ctx context.Context, userID string) ([]*models.Todo, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var trashed []*models.Todo
+	for _, todo := range r.todos {
+		if todo.UserID == userID && todo.IsTrashed() {
+			trashed = append(trashed, copyTodo(todo))
+		}
+	}
+
+	// Most recently trashed first
+	sort.Slice(trashed, func(i, j int) bool {
+		if !trashed[i].DeletedAt.Equal(*trashed[j].DeletedAt) {
+			return trashed[i].DeletedAt.After(*trashed[j].DeletedAt)
+		}
+		return trashed[i].ID < trashed[j].ID
+	})
+	return trashed, nil
+}
+
+// RestoreTodo takes a todo back out of the trash
+func (r *MemoryTodoRepository) RestoreTodo(ctx context.Context, todoID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	todo, exists := r.todos[todoID]
+	if !exists || !todo.IsTrashed() {
+		return ErrTodoNotFound
+	}
+
+	recordEntry(r.tx, r.todos, todoID, copyTodo)
+	todo.Restore()
+	todo.Version++
+	return nil
+}
+
+// EmptyTrash permanently removes all trashed todos of a user and returns them
+func (r *MemoryTodoRepository) EmptyTrash(ctx context.Context, userID string) ([]*models.Todo, error) {
+	return r.purge(func(todo *models.Todo) bool {
+		return todo.UserID == userID
+	}), nil
+}
+
+// PurgeTrash permanently removes todos trashed before the cutoff and returns them
+func (r *MemoryTodoRepository) PurgeTrash(ctx context.Context, deletedBefore time.Time) ([]*models.Todo, error) {
+	return r.purge(func(todo *models.Todo) bool {
+		return todo.DeletedAt.Before(deletedBefore)
+	}), nil
+}
+
+// purge permanently removes the trashed todos matching the predicate
+func (r *MemoryTodoRepository) purge(match func(todo *models.Todo) bool) []*models.Todo {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var purged []*models.Todo
+	for id, todo := range r.todos {
+		if todo.IsTrashed() && match(todo) {
+			recordEntry(r.tx, r.todos, id, copyTodo)
+			purged = append(purged, todo)
+			delete(r.todos, id)
+		}
+	}
+
+	return purged
+}
+
+// copyTodo returns a copy of a todo that shares no state with the original
+func copyTodo(todo *models.Todo) *models.Todo {
+	c := *todo
+	if todo.DeletedAt != nil {
+		deletedAt := *todo.DeletedAt
+		c.DeletedAt = &deletedAt
+	}
+	if todo.DueAt != nil {
+		dueAt := *todo.DueAt
+		c.DueAt = &dueAt
+	}
+	if todo.Tags != nil {
+		c.Tags = append([]string(nil), todo.Tags...)
+	}
+	return &c
+}
+
+// DeleteUserTodos permanently removes all todos of a user, trashed ones included
+func (r *MemoryTodoRepository) DeleteUserTodos(ctx context.Context, userID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for id, todo := range r.todos {
+		if todo.UserID == userID {
+			recordEntry(r.tx, r.todos, id, copyTodo)
+			delete(r.todos, id)
+		}
+	}
 	return nil
 }
@@ -2,7 +2,9 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/starbops/gottodo/internal/models"
@@ -68,6 +70,37 @@ func TestMemoryTodoRepository_GetUserTodos(t *testing.T) {
 	assert.Len(t, todos, 0)
 }
 
+func TestMemoryTodoRepository_StreamUserTodos(t *testing.T) {
+	repo := NewMemoryTodoRepository()
+	ctx := context.Background()
+
+	start := time.Now()
+	todos := []*models.Todo{
+		{Title: "Second", UserID: "user1", CreatedAt: start.Add(time.Minute)},
+		{Title: "First", UserID: "user1", CreatedAt: start},
+		{Title: "Other", UserID: "user2", CreatedAt: start},
+	}
+	for _, todo := range todos {
+		assert.NoError(t, repo.CreateTodo(ctx, todo))
+	}
+	assert.NoError(t, repo.DeleteTodo(ctx, todos[0].ID, todos[0].Version))
+
+	// The user's todos, trashed ones included, come oldest first
+	var titles []string
+	err := repo.StreamUserTodos(ctx, "user1", func(todo *models.Todo) error {
+		titles = append(titles, todo.Title)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"First", "Second"}, titles)
+
+	stop := errors.New("stop")
+	err = repo.StreamUserTodos(ctx, "user1", func(todo *models.Todo) error {
+		return stop
+	})
+	assert.Equal(t, stop, err)
+}
+
 func TestMemoryTodoRepository_GetTodo(t *testing.T) {
 	repo := NewMemoryTodoRepository()
 	ctx := context.Background()
@@ -137,6 +170,47 @@ func TestMemoryTodoRepository_CreateTodo(t *testing.T) {
 	fetchedTodo, err = repo.GetTodo(ctx, todo2.ID)
 	assert.NoError(t, err)
 	assert.Equal(t, todo2.ID, fetchedTodo.ID)
+
+	// IDs cannot be reused
+	err = repo.CreateTodo(ctx, &models.Todo{ID: todo1.ID, Title: "Duplicate", UserID: userID})
+	assert.Equal(t, ErrTodoExists, err)
+
+	fetchedTodo, err = repo.GetTodo(ctx, todo1.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Test Todo with ID", fetchedTodo.Title)
+}
+
+func TestMemoryTodoRepository_CreateTodos(t *testing.T) {
+	repo := NewMemoryTodoRepository()
+	ctx := context.Background()
+	userID := "user1"
+
+	existing := &models.Todo{Title: "Existing", UserID: userID}
+	assert.NoError(t, repo.CreateTodo(ctx, existing))
+
+	// A batch holding a duplicate stores none of its todos
+	fresh := &models.Todo{Title: "Fresh", UserID: userID}
+	err := repo.CreateTodos(ctx, []*models.Todo{fresh, {ID: existing.ID, Title: "Duplicate", UserID: userID}})
+	assert.Equal(t, ErrTodoExists, err)
+
+	err = repo.CreateTodos(ctx, []*models.Todo{{ID: "same", UserID: userID}, {ID: "same", UserID: userID}})
+	assert.Equal(t, ErrTodoExists, err)
+
+	todos, err := repo.GetUserTodos(ctx, userID)
+	assert.NoError(t, err)
+	assert.Len(t, todos, 1)
+
+	// Otherwise all of them are stored at the first version
+	batch := []*models.Todo{{Title: "First", UserID: userID}, {Title: "Second", UserID: userID}}
+	assert.NoError(t, repo.CreateTodos(ctx, batch))
+	for _, todo := range batch {
+		assert.NotEmpty(t, todo.ID)
+		assert.Equal(t, 1, todo.Version)
+
+		stored, err := repo.GetTodo(ctx, todo.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, todo.Title, stored.Title)
+	}
 }
 
 func TestMemoryTodoRepository_UpdateTodo(t *testing.T) {
@@ -203,20 +277,171 @@ func TestMemoryTodoRepository_DeleteTodo(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Delete the todo
-	err = repo.DeleteTodo(ctx, todo.ID)
+	err = repo.DeleteTodo(ctx, todo.ID, todo.Version)
 	assert.NoError(t, err)
 
-	// Verify the todo is deleted
-	_, err = repo.GetTodo(ctx, todo.ID)
-	assert.Error(t, err)
+	// Verify the todo is in the trash and hidden from the user's list
+	trashedTodo, err := repo.GetTodo(ctx, todo.ID)
+	assert.NoError(t, err)
+	assert.True(t, trashedTodo.IsTrashed())
+
+	todos, err := repo.GetUserTodos(ctx, userID)
+	assert.NoError(t, err)
+	assert.Len(t, todos, 0)
+
+	// Test deleting an already trashed todo
+	err = repo.DeleteTodo(ctx, todo.ID, todo.Version)
 	assert.Equal(t, ErrTodoNotFound, err)
 
 	// Test deleting non-existent todo
-	err = repo.DeleteTodo(ctx, "non-existent-id")
+	err = repo.DeleteTodo(ctx, "non-existent-id", 1)
 	assert.Error(t, err)
 	assert.Equal(t, ErrTodoNotFound, err)
 }
 
+func TestMemoryTodoRepository_RestoreTodo(t *testing.T) {
+	repo := NewMemoryTodoRepository()
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+	todo := &models.Todo{
+		ID:     uuid.New().String(),
+		Title:  "Test Todo",
+		UserID: userID,
+	}
+
+	err := repo.CreateTodo(ctx, todo)
+	assert.NoError(t, err)
+
+	// Restoring a todo that is not in the trash fails
+	err = repo.RestoreTodo(ctx, todo.ID)
+	assert.Equal(t, ErrTodoNotFound, err)
+
+	err = repo.DeleteTodo(ctx, todo.ID, todo.Version)
+	assert.NoError(t, err)
+
+	trashed, err := repo.GetTrashedTodos(ctx, userID)
+	assert.NoError(t, err)
+	assert.Len(t, trashed, 1)
+
+	// Restore the todo
+	err = repo.RestoreTodo(ctx, todo.ID)
+	assert.NoError(t, err)
+
+	todos, err := repo.GetUserTodos(ctx, userID)
+	assert.NoError(t, err)
+	assert.Len(t, todos, 1)
+	assert.False(t, todos[0].IsTrashed())
+
+	trashed, err = repo.GetTrashedTodos(ctx, userID)
+	assert.NoError(t, err)
+	assert.Len(t, trashed, 0)
+}
+
+func TestMemoryTodoRepository_EmptyTrash(t *testing.T) {
+	repo := NewMemoryTodoRepository()
+	ctx := context.Background()
+
+	userID1 := uuid.New().String()
+	userID2 := uuid.New().String()
+
+	kept := &models.Todo{ID: uuid.New().String(), Title: "Kept", UserID: userID1}
+	trashed := &models.Todo{ID: uuid.New().String(), Title: "Trashed", UserID: userID1}
+	otherUser := &models.Todo{ID: uuid.New().String(), Title: "Other user", UserID: userID2}
+
+	for _, todo := range []*models.Todo{kept, trashed, otherUser} {
+		assert.NoError(t, repo.CreateTodo(ctx, todo))
+	}
+	assert.NoError(t, repo.DeleteTodo(ctx, trashed.ID, trashed.Version))
+	assert.NoError(t, repo.DeleteTodo(ctx, otherUser.ID, otherUser.Version))
+
+	// Only user1's trashed todo is removed
+	purged, err := repo.EmptyTrash(ctx, userID1)
+	assert.NoError(t, err)
+	assert.Len(t, purged, 1)
+	assert.Equal(t, trashed.ID, purged[0].ID)
+
+	_, err = repo.GetTodo(ctx, trashed.ID)
+	assert.Equal(t, ErrTodoNotFound, err)
+
+	_, err = repo.GetTodo(ctx, kept.ID)
+	assert.NoError(t, err)
+
+	_, err = repo.GetTodo(ctx, otherUser.ID)
+	assert.NoError(t, err)
+}
+
+func TestMemoryTodoRepository_PurgeTrash(t *testing.T) {
+	repo := NewMemoryTodoRepository()
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+	old := &models.Todo{ID: uuid.New().String(), Title: "Old", UserID: userID}
+	recent := &models.Todo{ID: uuid.New().String(), Title: "Recent", UserID: userID}
+
+	assert.NoError(t, repo.CreateTodo(ctx, old))
+	assert.NoError(t, repo.CreateTodo(ctx, recent))
+	assert.NoError(t, repo.DeleteTodo(ctx, old.ID, old.Version))
+	assert.NoError(t, repo.DeleteTodo(ctx, recent.ID, recent.Version))
+
+	// Backdate the first deletion
+	longAgo := time.Now().Add(-48 * time.Hour)
+	repo.(*MemoryTodoRepository).todos[old.ID].DeletedAt = &longAgo
+
+	purged, err := repo.PurgeTrash(ctx, time.Now().Add(-24*time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, purged, 1)
+	assert.Equal(t, old.ID, purged[0].ID)
+
+	trashed, err := repo.GetTrashedTodos(ctx, userID)
+	assert.NoError(t, err)
+	assert.Len(t, trashed, 1)
+	assert.Equal(t, recent.ID, trashed[0].ID)
+}
+
+func TestMemoryTodoRepository_UpdateTodo_Version(t *testing.T) {
+	repo := NewMemoryTodoRepository()
+	ctx := context.Background()
+
+	todo := &models.Todo{
+		ID:     uuid.New().String(),
+		Title:  "Original Title",
+		UserID: uuid.New().String(),
+	}
+
+	err := repo.CreateTodo(ctx, todo)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, todo.Version)
+
+	// Two tabs load the same todo
+	tab1, err := repo.GetTodo(ctx, todo.ID)
+	assert.NoError(t, err)
+	tab2, err := repo.GetTodo(ctx, todo.ID)
+	assert.NoError(t, err)
+
+	// The first save wins and bumps the version
+	tab1.Title = "Edited in tab 1"
+	err = repo.UpdateTodo(ctx, tab1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, tab1.Version)
+
+	// The second save is based on a stale version
+	tab2.Title = "Edited in tab 2"
+	err = repo.UpdateTodo(ctx, tab2)
+	assert.Equal(t, ErrConflict, err)
+
+	stored, err := repo.GetTodo(ctx, todo.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Edited in tab 1", stored.Title)
+	assert.Equal(t, 2, stored.Version)
+
+	// Returned todos are copies of the stored state
+	stored.Title = "Modified without saving"
+	stored, err = repo.GetTodo(ctx, todo.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Edited in tab 1", stored.Title)
+}
+
 func TestMemoryTodoRepository_Concurrency(t *testing.T) {
 	repo := NewMemoryTodoRepository()
 	ctx := context.Background()
@@ -258,3 +483,132 @@ func TestMemoryTodoRepository_Concurrency(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, todoID, fetchedTodo.ID)
 }
+
+func TestMemoryTodoRepository_PatchTodo(t *testing.T) {
+	repo := NewMemoryTodoRepository()
+	ctx := context.Background()
+
+	todo := &models.Todo{
+		ID:          uuid.New().String(),
+		Title:       "Original Title",
+		Description: "Original Description",
+		UserID:      uuid.New().String(),
+	}
+
+	err := repo.CreateTodo(ctx, todo)
+	assert.NoError(t, err)
+
+	// Only the named fields are written
+	patched := &models.Todo{
+		ID:          todo.ID,
+		Title:       "Ignored Title",
+		Description: "Patched Description",
+		Version:     1,
+	}
+	err = repo.PatchTodo(ctx, patched, []string{models.FieldDescription})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, patched.Version)
+
+	stored, err := repo.GetTodo(ctx, todo.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Original Title", stored.Title)
+	assert.Equal(t, "Patched Description", stored.Description)
+	assert.Equal(t, 2, stored.Version)
+
+	// A stale version is rejected
+	patched.Version = 1
+	err = repo.PatchTodo(ctx, patched, []string{models.FieldTitle})
+	assert.Equal(t, ErrConflict, err)
+
+	// Unknown fields are rejected without touching the todo
+	patched.Version = 2
+	err = repo.PatchTodo(ctx, patched, []string{models.FieldTitle, "user_id"})
+	assert.Error(t, err)
+
+	stored, err = repo.GetTodo(ctx, todo.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Original Title", stored.Title)
+	assert.Equal(t, 2, stored.Version)
+
+	// Planning fields are written without sharing memory with the caller
+	due := time.Date(2025, time.March, 14, 0, 0, 0, 0, time.UTC)
+	patched.DueAt = &due
+	patched.Priority = models.PriorityHigh
+	patched.Tags = []string{"home"}
+	err = repo.PatchTodo(ctx, patched, []string{models.FieldDueAt, models.FieldPriority, models.FieldTags})
+	assert.NoError(t, err)
+	patched.Tags[0] = "changed"
+
+	stored, err = repo.GetTodo(ctx, todo.ID)
+	assert.NoError(t, err)
+	assert.True(t, due.Equal(*stored.DueAt))
+	assert.Equal(t, models.PriorityHigh, stored.Priority)
+	assert.Equal(t, []string{"home"}, stored.Tags)
+	assert.Equal(t, 3, stored.Version)
+}
+
+func TestMemoryTodoRepository_UpdateTodos(t *testing.T) {
+	repo := NewMemoryTodoRepository()
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+	first := &models.Todo{ID: uuid.New().String(), Title: "First", UserID: userID}
+	second := &models.Todo{ID: uuid.New().String(), Title: "Second", UserID: userID}
+	assert.NoError(t, repo.CreateTodo(ctx, first))
+	assert.NoError(t, repo.CreateTodo(ctx, second))
+
+	skip := errors.New("skip")
+	missingID := uuid.New().String()
+
+	// Apply an update that refuses the second todo
+	results, err := repo.UpdateTodos(ctx, []string{first.ID, second.ID, missingID}, func(todo *models.Todo) error {
+		if todo.ID == second.ID {
+			return skip
+		}
+		todo.Completed = true
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, results, 3)
+
+	// Results are reported per todo in request order
+	assert.Equal(t, first.ID, results[0].TodoID)
+	assert.NoError(t, results[0].Err)
+	assert.False(t, results[0].Before.Completed)
+	assert.True(t, results[0].Todo.Completed)
+	assert.Equal(t, 2, results[0].Todo.Version)
+	assert.Equal(t, skip, results[1].Err)
+	assert.Nil(t, results[1].Todo)
+	assert.Equal(t, ErrTodoNotFound, results[2].Err)
+
+	stored, err := repo.GetTodo(ctx, first.ID)
+	assert.NoError(t, err)
+	assert.True(t, stored.Completed)
+
+	stored, err = repo.GetTodo(ctx, second.ID)
+	assert.NoError(t, err)
+	assert.False(t, stored.Completed)
+	assert.Equal(t, 1, stored.Version)
+}
+
+func TestMemoryTodoRepository_DeleteUserTodos(t *testing.T) {
+	repo := NewMemoryTodoRepository()
+	ctx := context.Background()
+
+	userID, otherID := uuid.New().String(), uuid.New().String()
+	open := models.NewTodo(userID, "Open", "")
+	trashed := models.NewTodo(userID, "Trashed", "")
+	other := models.NewTodo(otherID, "Other", "")
+	assert.NoError(t, repo.CreateTodos(ctx, []*models.Todo{open, trashed, other}))
+	assert.NoError(t, repo.DeleteTodo(ctx, trashed.ID, trashed.Version))
+
+	assert.NoError(t, repo.DeleteUserTodos(ctx, userID))
+
+	// Trashed todos go too
+	for _, id := range []string{open.ID, trashed.ID} {
+		_, err := repo.GetTodo(ctx, id)
+		assert.Equal(t, ErrTodoNotFound, err)
+	}
+	_, err := repo.GetTodo(ctx, other.ID)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/starbops/gottodo/internal/models"
+)
+
+// MemoryUndoRepository is an in-memory implementation of UndoRepository
+type MemoryUndoRepository struct {
+	tokens map[string]*models.UndoToken
+	mutex  memoryLock
+
+	// tx is the unit of work the repository is a view for, if any
+	tx *memoryTx
+}
+
+// NewMemoryUndoRepository creates a new MemoryUndoRepository
+func NewMemoryUndoRepository() UndoRepository {
+	return &MemoryUndoRepository{
+		tokens: make(map[string]*models.UndoToken),
+		mutex:  &sync.RWMutex{},
+	}
+}
+
+// SaveUndoToken stores a new undo token and discards expired ones
+func (r *MemoryUndoRepository) SaveUndoToken(ctx context.Context, token *models.UndoToken) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	for key, existing := range r.tokens {
+		if now.After(existing.ExpiresAt) {
+			recordEntry(r.tx, r.tokens, key, copyStruct)
+			delete(r.tokens, key)
+		}
+	}
+
+	stored := *token
+	recordEntry(r.tx, r.tokens, token.Token, copyStruct)
+	r.tokens[token.Token] = &stored
+	return nil
+}
+
+// GetUndoToken retrieves an undo token without using it up
+func (r *MemoryUndoRepository) GetUndoToken(ctx context.Context, token string) (*models.UndoToken, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	stored, exists := r.tokens[token]
+	if !exists {
+		return nil, ErrUndoTokenNotFound
+	}
+
+	result := *stored
+	return &result, nil
+}
+
+// ConsumeUndoToken removes an unexpired undo token of the user and returns
+// it, so it can only be used once
+func (r *MemoryUndoRepository) ConsumeUndoToken(ctx context.Context, token string, userID string) (*models.UndoToken, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored, exists := r.tokens[token]
+	if !exists || stored.UserID != userID || stored.IsExpired() {
+		return nil, ErrUndoTokenNotFound
+	}
+
+	recordEntry(r.tx, r.tokens, token, copyStruct)
+	delete(r.tokens, token)
+	return stored, nil
+}
+
+// GetLatestUndoToken retrieves the most recent unexpired undo token of a user
+func (r *MemoryUndoRepository) GetLatestUndoToken(ctx context.Context, userID string) (*models.UndoToken, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var latest *models.UndoToken
+	for _, token := range r.tokens {
+		if token.UserID != userID || token.IsExpired() {
+			continue
+		}
+		if latest == nil || token.CreatedAt.After(latest.CreatedAt) {
+			latest = token
+		}
+	}
+
+	if latest == nil {
+		return nil, ErrUndoTokenNotFound
+	}
+
+	result := *latest
+	return &result, nil
+}
+
+// DeleteUserUndoTokens removes all undo tokens of a user
+func (r *MemoryUndoRepository) DeleteUserUndoTokens(ctx context.Context, userID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for key, token := range r.tokens {
+		if token.UserID == userID {
+			recordEntry(r.tx, r.tokens, key, copyStruct)
+			delete(r.tokens, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryUndoRepository_ConsumeUndoToken(t *testing.T) {
+	repo := NewMemoryUndoRepository()
+	ctx := context.Background()
+
+	todo := models.NewTodo(uuid.New().String(), "Test Todo", "Description")
+	token := models.NewUndoToken(todo.UserID, models.ActivityDeleted, todo, time.Minute)
+
+	err := repo.SaveUndoToken(ctx, token)
+	assert.NoError(t, err)
+
+	// Only the owner can consume the token
+	_, err = repo.ConsumeUndoToken(ctx, token.Token, uuid.New().String())
+	assert.Equal(t, ErrUndoTokenNotFound, err)
+
+	// Reading it leaves it in place
+	stored, err := repo.GetUndoToken(ctx, token.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, todo.UserID, stored.UserID)
+
+	// The token can be consumed once
+	consumed, err := repo.ConsumeUndoToken(ctx, token.Token, todo.UserID)
+	assert.NoError(t, err)
+	assert.Equal(t, todo.ID, consumed.TodoID)
+	assert.Equal(t, todo.Title, consumed.Snapshot.Title)
+
+	_, err = repo.ConsumeUndoToken(ctx, token.Token, todo.UserID)
+	assert.Equal(t, ErrUndoTokenNotFound, err)
+	_, err = repo.GetUndoToken(ctx, token.Token)
+	assert.Equal(t, ErrUndoTokenNotFound, err)
+
+	// Expired tokens cannot be consumed
+	expired := models.NewUndoToken(todo.UserID, models.ActivityDeleted, todo, -time.Minute)
+	assert.NoError(t, repo.SaveUndoToken(ctx, expired))
+	_, err = repo.ConsumeUndoToken(ctx, expired.Token, todo.UserID)
+	assert.Equal(t, ErrUndoTokenNotFound, err)
+}
+
+func TestMemoryUndoRepository_GetLatestUndoToken(t *testing.T) {
+	repo := NewMemoryUndoRepository()
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+
+	// No pending undo yet
+	_, err := repo.GetLatestUndoToken(ctx, userID)
+	assert.Equal(t, ErrUndoTokenNotFound, err)
+
+	first := models.NewUndoToken(userID, models.ActivityCompleted, models.NewTodo(userID, "First", ""), time.Minute)
+	second := models.NewUndoToken(userID, models.ActivityDeleted, models.NewTodo(userID, "Second", ""), time.Minute)
+	second.CreatedAt = first.CreatedAt.Add(time.Second)
+	expired := models.NewUndoToken(userID, models.ActivityDeleted, models.NewTodo(userID, "Expired", ""), -time.Minute)
+	expired.CreatedAt = first.CreatedAt.Add(time.Minute)
+
+	assert.NoError(t, repo.SaveUndoToken(ctx, first))
+	assert.NoError(t, repo.SaveUndoToken(ctx, second))
+	assert.NoError(t, repo.SaveUndoToken(ctx, expired))
+
+	// The newest unexpired token wins
+	latest, err := repo.GetLatestUndoToken(ctx, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, second.Token, latest.Token)
+
+	// Other users see nothing
+	_, err = repo.GetLatestUndoToken(ctx, uuid.New().String())
+	assert.Equal(t, ErrUndoTokenNotFound, err)
+}
+
+func TestMemoryUndoRepository_SaveDiscardsExpired(t *testing.T) {
+	repo := NewMemoryUndoRepository()
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+	expired := models.NewUndoToken(userID, models.ActivityDeleted, models.NewTodo(userID, "Expired", ""), -time.Minute)
+	assert.NoError(t, repo.SaveUndoToken(ctx, expired))
+
+	fresh := models.NewUndoToken(userID, models.ActivityDeleted, models.NewTodo(userID, "Fresh", ""), time.Minute)
+	assert.NoError(t, repo.SaveUndoToken(ctx, fresh))
+
+	_, err := repo.GetUndoToken(ctx, expired.Token)
+	assert.Equal(t, ErrUndoTokenNotFound, err)
+}
+
+func TestMemoryUndoRepository_DeleteUserUndoTokens(t *testing.T) {
+	repo := NewMemoryUndoRepository()
+	ctx := context.Background()
+
+	userID, otherID := uuid.New().String(), uuid.New().String()
+	todo := models.NewTodo(userID, "Call Ann", "")
+	assert.NoError(t, repo.SaveUndoToken(ctx, models.NewUndoToken(userID, models.ActivityDeleted, todo, time.Minute)))
+	assert.NoError(t, repo.SaveUndoToken(ctx, models.NewUndoToken(otherID, models.ActivityDeleted, todo, time.Minute)))
+
+	assert.NoError(t, repo.DeleteUserUndoTokens(ctx, userID))
+
+	_, err := repo.GetLatestUndoToken(ctx, userID)
+	assert.Equal(t, ErrUndoTokenNotFound, err)
+	_, err = repo.GetLatestUndoToken(ctx, otherID)
+	assert.NoError(t, err)
+}
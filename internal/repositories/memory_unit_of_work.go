@@ -0,0 +1,225 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryUnitOfWork runs units of work on the in-memory repositories. A unit
+// of work locks a repository when it first uses it and holds the lock until
+// it ends, so other callers of that repository wait for it. Its writes go
+// straight to the repositories, logging how to revert them, and are reverted
+// if it fails.
+type MemoryUnitOfWork struct {
+	repos *Repositories
+}
+
+// NewMemoryUnitOfWork creates a new MemoryUnitOfWork over the in-memory
+// repositories of repos; any others take part without rollback
+func NewMemoryUnitOfWork(repos *Repositories) UnitOfWork {
+	return &MemoryUnitOfWork{repos: repos}
+}
+
+// memoryLock is the lock of an in-memory repository: a sync.RWMutex, or a
+// memoryTxLock within a unit of work
+type memoryLock interface {
+	Lock()
+	Unlock()
+	RLock()
+	RUnlock()
+}
+
+// memoryTxRepository is an in-memory repository that can take part in a unit of work
+type memoryTxRepository interface {
+	// txLock returns the lock guarding the contents of the repository
+	txLock() memoryLock
+
+	// withTx returns a view of the repository that shares its contents,
+	// guards them with lock and logs its writes to tx
+	withTx(tx *memoryTx, lock memoryLock) memoryTxRepository
+}
+
+// memoryTx is a running unit of work on the in-memory repositories
+type memoryTx struct {
+	// mutex keeps the views of the unit of work from being used concurrently
+	mutex sync.Mutex
+
+	// locks are those of the joined repositories, in the order they are
+	// taken, of which the first held are held
+	locks []memoryLock
+	held  int
+
+	// undo reverts the writes of the unit of work, in reverse order
+	undo []func()
+}
+
+// joinMemoryTx returns a view of repo for the unit of work, or repo itself if
+// it is not an in-memory repository
+func joinMemoryTx[R any](tx *memoryTx, repo R) R {
+	txRepo, ok := any(repo).(memoryTxRepository)
+	if !ok {
+		return repo
+	}
+	lock := memoryTxLock{tx: tx, rank: len(tx.locks)}
+	tx.locks = append(tx.locks, txRepo.txLock())
+	return txRepo.withTx(tx, lock).(R)
+}
+
+// acquire takes the locks up to and including the one of the given rank.
+// Taking them in order keeps concurrent units of work from deadlocking.
+func (tx *memoryTx) acquire(rank int) {
+	for tx.held <= rank {
+		tx.locks[tx.held].Lock()
+		tx.held++
+	}
+}
+
+// record logs how to revert a write; it does nothing outside of a unit of work
+func (tx *memoryTx) record(revert func()) {
+	if tx != nil {
+		tx.undo = append(tx.undo, revert)
+	}
+}
+
+// recordEntry logs how to restore the entry of key in m as it is now
+func recordEntry[T any](tx *memoryTx, m map[string]*T, key string, copyValue func(value *T) *T) {
+	if tx == nil {
+		return
+	}
+	old, existed := m[key]
+	if existed {
+		old = copyValue(old)
+	}
+	tx.record(func() {
+		if existed {
+			m[key] = old
+		} else {
+			delete(m, key)
+		}
+	})
+}
+
+// end reverts the writes unless commit is set and releases the locks
+func (tx *memoryTx) end(commit bool) {
+	if !commit {
+		for i := len(tx.undo) - 1; i >= 0; i-- {
+			tx.undo[i]()
+		}
+	}
+	for i := tx.held - 1; i >= 0; i-- {
+		tx.locks[i].Unlock()
+	}
+}
+
+// memoryTxLock guards a repository within a unit of work. The unit of work
+// takes the lock of the repository on first use and keeps it until it ends.
+type memoryTxLock struct {
+	tx   *memoryTx
+	rank int
+}
+
+func (l memoryTxLock) Lock() {
+	l.tx.mutex.Lock()
+	l.tx.acquire(l.rank)
+}
+
+func (l memoryTxLock) Unlock() {
+	l.tx.mutex.Unlock()
+}
+
+func (l memoryTxLock) RLock() {
+	l.Lock()
+}
+
+func (l memoryTxLock) RUnlock() {
+	l.Unlock()
+}
+
+// WithTx calls fn with views of the repositories and reverts its writes
+// unless fn succeeds. The repositories that are used most come first in the
+// locking order, so most units of work lock only a few of them.
+func (u *MemoryUnitOfWork) WithTx(ctx context.Context, fn func(repos *Repositories) error) error {
+	tx := &memoryTx{}
+	committed := false
+	// Also reverts the writes if fn panics
+	defer func() { tx.end(committed) }()
+
+	repos := &Repositories{
+		Todos:        joinMemoryTx(tx, u.repos.Todos),
+		Activity:     joinMemoryTx(tx, u.repos.Activity),
+		Undo:         joinMemoryTx(tx, u.repos.Undo),
+		Webhooks:     joinMemoryTx(tx, u.repos.Webhooks),
+		Idempotency:  joinMemoryTx(tx, u.repos.Idempotency),
+		Deletions:    joinMemoryTx(tx, u.repos.Deletions),
+		Capture:      joinMemoryTx(tx, u.repos.Capture),
+		Calendar:     joinMemoryTx(tx, u.repos.Calendar),
+		AppPasswords: joinMemoryTx(tx, u.repos.AppPasswords),
+	}
+	repos.UnitOfWork = joinedUnitOfWork{repos: repos}
+	if err := fn(repos); err != nil {
+		return err
+	}
+
+	committed = true
+	return nil
+}
+
+// copyStruct returns a shallow copy of a value
+func copyStruct[T any](value *T) *T {
+	copied := *value
+	return &copied
+}
+
+func (r *MemoryTodoRepository) txLock() memoryLock { return r.mutex }
+
+func (r *MemoryTodoRepository) withTx(tx *memoryTx, lock memoryLock) memoryTxRepository {
+	return &MemoryTodoRepository{todos: r.todos, mutex: lock, tx: tx}
+}
+
+func (r *MemoryActivityRepository) txLock() memoryLock { return r.mutex }
+
+func (r *MemoryActivityRepository) withTx(tx *memoryTx, lock memoryLock) memoryTxRepository {
+	return &MemoryActivityRepository{log: r.log, mutex: lock, tx: tx}
+}
+
+func (r *MemoryUndoRepository) txLock() memoryLock { return r.mutex }
+
+func (r *MemoryUndoRepository) withTx(tx *memoryTx, lock memoryLock) memoryTxRepository {
+	return &MemoryUndoRepository{tokens: r.tokens, mutex: lock, tx: tx}
+}
+
+func (r *MemoryIdempotencyRepository) txLock() memoryLock { return r.mutex }
+
+func (r *MemoryIdempotencyRepository) withTx(tx *memoryTx, lock memoryLock) memoryTxRepository {
+	return &MemoryIdempotencyRepository{records: r.records, mutex: lock, tx: tx}
+}
+
+func (r *MemoryWebhookRepository) txLock() memoryLock { return r.mutex }
+
+func (r *MemoryWebhookRepository) withTx(tx *memoryTx, lock memoryLock) memoryTxRepository {
+	return &MemoryWebhookRepository{webhooks: r.webhooks, deliveries: r.deliveries, mutex: lock, tx: tx}
+}
+
+func (r *MemoryCaptureRepository) txLock() memoryLock { return r.mutex }
+
+func (r *MemoryCaptureRepository) withTx(tx *memoryTx, lock memoryLock) memoryTxRepository {
+	return &MemoryCaptureRepository{secrets: r.secrets, mutex: lock, tx: tx}
+}
+
+func (r *MemoryCalendarFeedRepository) txLock() memoryLock { return r.mutex }
+
+func (r *MemoryCalendarFeedRepository) withTx(tx *memoryTx, lock memoryLock) memoryTxRepository {
+	return &MemoryCalendarFeedRepository{feeds: r.feeds, mutex: lock, tx: tx}
+}
+
+func (r *MemoryAppPasswordRepository) txLock() memoryLock { return r.mutex }
+
+func (r *MemoryAppPasswordRepository) withTx(tx *memoryTx, lock memoryLock) memoryTxRepository {
+	return &MemoryAppPasswordRepository{passwords: r.passwords, mutex: lock, tx: tx}
+}
+
+func (r *MemoryAccountDeletionRepository) txLock() memoryLock { return r.mutex }
+
+func (r *MemoryAccountDeletionRepository) withTx(tx *memoryTx, lock memoryLock) memoryTxRepository {
+	return &MemoryAccountDeletionRepository{deletions: r.deletions, mutex: lock, tx: tx}
+}
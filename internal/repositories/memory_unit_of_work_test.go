@@ -0,0 +1,210 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// seedUnitOfWork gives a user a todo and a capture secret in repos
+func seedUnitOfWork(t *testing.T, repos *Repositories, userID string) *models.Todo {
+	t.Helper()
+	ctx := context.Background()
+
+	todo := &models.Todo{UserID: userID, Title: "Call Ann"}
+	assert.NoError(t, repos.Todos.CreateTodo(ctx, todo))
+	assert.NoError(t, repos.Capture.SaveCaptureSecret(ctx, models.NewCaptureSecret(userID)))
+	return todo
+}
+
+func TestMemoryUnitOfWork_Commit(t *testing.T) {
+	repos := NewMemoryRepositories()
+	ctx := context.Background()
+	userID := uuid.New().String()
+	todo := seedUnitOfWork(t, repos, userID)
+
+	err := repos.UnitOfWork.WithTx(ctx, func(tx *Repositories) error {
+		if err := tx.Todos.DeleteTodo(ctx, todo.ID, todo.Version); err != nil {
+			return err
+		}
+		if err := tx.Activity.AppendActivity(ctx, models.NewActivity(userID, todo.ID, models.ActivityDeleted, nil)); err != nil {
+			return err
+		}
+
+		// A nested unit of work joins the running one
+		return tx.UnitOfWork.WithTx(ctx, func(nested *Repositories) error {
+			return nested.Capture.DeleteUserCaptureSecret(ctx, userID)
+		})
+	})
+	assert.NoError(t, err)
+
+	// Every write took effect
+	stored, err := repos.Todos.GetTodo(ctx, todo.ID)
+	assert.NoError(t, err)
+	assert.True(t, stored.IsTrashed())
+	history, err := repos.Activity.GetTodoActivity(ctx, todo.ID)
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+	_, err = repos.Capture.GetUserCaptureSecret(ctx, userID)
+	assert.Equal(t, ErrCaptureSecretNotFound, err)
+}
+
+func TestMemoryUnitOfWork_Rollback(t *testing.T) {
+	repos := NewMemoryRepositories()
+	ctx := context.Background()
+	userID := uuid.New().String()
+	todo := seedUnitOfWork(t, repos, userID)
+
+	failure := errors.New("failed")
+	err := repos.UnitOfWork.WithTx(ctx, func(tx *Repositories) error {
+		stored, err := tx.Todos.GetTodo(ctx, todo.ID)
+		if err != nil {
+			return err
+		}
+		stored.Title = "Call Bob"
+		if err := tx.Todos.UpdateTodo(ctx, stored); err != nil {
+			return err
+		}
+		if err := tx.Todos.CreateTodo(ctx, &models.Todo{UserID: userID, Title: "Write Ann"}); err != nil {
+			return err
+		}
+		if err := tx.Activity.AppendActivity(ctx, models.NewActivity(userID, todo.ID, models.ActivityUpdated, nil)); err != nil {
+			return err
+		}
+		if err := tx.Capture.DeleteUserCaptureSecret(ctx, userID); err != nil {
+			return err
+		}
+
+		// The writes are visible within the unit of work
+		todos, err := tx.Todos.GetUserTodos(ctx, userID)
+		assert.NoError(t, err)
+		assert.Len(t, todos, 2)
+		return failure
+	})
+	assert.Equal(t, failure, err)
+
+	// None of the writes took effect
+	assertUnitOfWorkRolledBack(t, repos, userID, todo)
+}
+
+func TestMemoryUnitOfWork_RollbackOnPanic(t *testing.T) {
+	repos := NewMemoryRepositories()
+	ctx := context.Background()
+	userID := uuid.New().String()
+	todo := seedUnitOfWork(t, repos, userID)
+
+	assert.PanicsWithValue(t, "boom", func() {
+		repos.UnitOfWork.WithTx(ctx, func(tx *Repositories) error {
+			stored, _ := tx.Todos.GetTodo(ctx, todo.ID)
+			stored.Title = "Call Bob"
+			tx.Todos.UpdateTodo(ctx, stored)
+			tx.Todos.CreateTodo(ctx, &models.Todo{UserID: userID, Title: "Write Ann"})
+			tx.Activity.AppendActivity(ctx, models.NewActivity(userID, todo.ID, models.ActivityUpdated, nil))
+			tx.Capture.DeleteUserCaptureSecret(ctx, userID)
+			panic("boom")
+		})
+	})
+
+	// None of the writes took effect, and the repositories are usable again
+	assertUnitOfWorkRolledBack(t, repos, userID, todo)
+}
+
+// assertUnitOfWorkRolledBack checks that the repositories hold only what
+// seedUnitOfWork put there
+func assertUnitOfWorkRolledBack(t *testing.T, repos *Repositories, userID string, todo *models.Todo) {
+	t.Helper()
+	ctx := context.Background()
+
+	todos, err := repos.Todos.GetUserTodos(ctx, userID)
+	assert.NoError(t, err)
+	if assert.Len(t, todos, 1) {
+		assert.Equal(t, todo.ID, todos[0].ID)
+		assert.Equal(t, "Call Ann", todos[0].Title)
+		assert.Equal(t, todo.Version, todos[0].Version)
+	}
+	history, err := repos.Activity.GetTodoActivity(ctx, todo.ID)
+	assert.NoError(t, err)
+	assert.Len(t, history, 0)
+	_, err = repos.Capture.GetUserCaptureSecret(ctx, userID)
+	assert.NoError(t, err)
+}
+
+func TestMemoryUnitOfWork_Isolation(t *testing.T) {
+	repos := NewMemoryRepositories()
+	ctx := context.Background()
+	userID := uuid.New().String()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- repos.UnitOfWork.WithTx(ctx, func(tx *Repositories) error {
+			if _, err := tx.Todos.GetUserTodos(ctx, userID); err != nil {
+				return err
+			}
+			close(started)
+			<-release
+			return tx.Todos.CreateTodo(ctx, &models.Todo{UserID: userID, Title: "Call Ann"})
+		})
+	}()
+	<-started
+
+	// Other callers of the todos wait for the unit of work to end
+	read := make(chan []*models.Todo)
+	go func() {
+		todos, _ := repos.Todos.GetUserTodos(ctx, userID)
+		read <- todos
+	}()
+	select {
+	case <-read:
+		t.Fatal("Expected the read to wait for the unit of work")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	assert.NoError(t, <-done)
+	assert.Len(t, <-read, 1)
+}
+
+func TestMemoryUnitOfWork_LocksOnlyUsedRepositories(t *testing.T) {
+	repos := NewMemoryRepositories()
+	ctx := context.Background()
+	userID := uuid.New().String()
+	seedUnitOfWork(t, repos, userID)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- repos.UnitOfWork.WithTx(ctx, func(tx *Repositories) error {
+			if _, err := tx.Todos.GetUserTodos(ctx, userID); err != nil {
+				return err
+			}
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+
+	// The unit of work has not used the capture secrets, so they stay available
+	read := make(chan error)
+	go func() {
+		_, err := repos.Capture.GetUserCaptureSecret(ctx, userID)
+		read <- err
+	}()
+	select {
+	case err := <-read:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Expected the read not to wait for the unit of work")
+	}
+
+	close(release)
+	assert.NoError(t, <-done)
+}
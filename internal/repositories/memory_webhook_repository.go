@@ -0,0 +1,213 @@
+package repositories
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/starbops/gottodo/internal/models"
+)
+
+// MemoryWebhookRepository is an in-memory implementation of WebhookRepository
+type MemoryWebhookRepository struct {
+	webhooks   map[string]*models.Webhook
+	deliveries map[string]*models.WebhookDelivery
+	mutex      memoryLock
+
+	// tx is the unit of work the repository is a view for, if any
+	tx *memoryTx
+}
+
+// NewMemoryWebhookRepository creates a new MemoryWebhookRepository
+func NewMemoryWebhookRepository() WebhookRepository {
+	return &MemoryWebhookRepository{
+		webhooks:   make(map[string]*models.Webhook),
+		deliveries: make(map[string]*models.WebhookDelivery),
+		mutex:      &sync.RWMutex{},
+	}
+}
+
+// copyWebhook returns a copy of a webhook that shares no memory with it
+func copyWebhook(webhook *models.Webhook) *models.Webhook {
+	result := *webhook
+	result.Events = append([]models.WebhookEvent(nil), webhook.Events...)
+	return &result
+}
+
+// copyDelivery returns a copy of a delivery that shares no memory with it
+func copyDelivery(delivery *models.WebhookDelivery) *models.WebhookDelivery {
+	result := *delivery
+	result.Payload = append([]byte(nil), delivery.Payload...)
+	return &result
+}
+
+// CreateWebhook stores a new webhook
+func (r *MemoryWebhookRepository) CreateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	recordEntry(r.tx, r.webhooks, webhook.ID, copyWebhook)
+	r.webhooks[webhook.ID] = copyWebhook(webhook)
+	return nil
+}
+
+// GetWebhook retrieves a webhook by ID
+func (r *MemoryWebhookRepository) GetWebhook(ctx context.Context, id string) (*models.Webhook, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	webhook, exists := r.webhooks[id]
+	if !exists {
+		return nil, ErrWebhookNotFound
+	}
+	return copyWebhook(webhook), nil
+}
+
+// GetUserWebhooks retrieves the webhooks of a user, oldest first
+func (r *MemoryWebhookRepository) GetUserWebhooks(ctx context.Context, userID string) ([]*models.Webhook, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var webhooks []*models.Webhook
+	for _, webhook := range r.webhooks {
+		if webhook.UserID == userID {
+			webhooks = append(webhooks, copyWebhook(webhook))
+		}
+	}
+
+	sort.SliceStable(webhooks, func(i, j int) bool {
+		return webhooks[i].CreatedAt.Before(webhooks[j].CreatedAt)
+	})
+	return webhooks, nil
+}
+
+// DeleteWebhook removes a webhook together with its deliveries
+func (r *MemoryWebhookRepository) DeleteWebhook(ctx context.Context, id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.webhooks[id]; !exists {
+		return ErrWebhookNotFound
+	}
+	recordEntry(r.tx, r.webhooks, id, copyWebhook)
+	delete(r.webhooks, id)
+
+	for deliveryID, delivery := range r.deliveries {
+		if delivery.WebhookID == id {
+			recordEntry(r.tx, r.deliveries, deliveryID, copyDelivery)
+			delete(r.deliveries, deliveryID)
+		}
+	}
+	return nil
+}
+
+// CreateDelivery stores a new delivery
+func (r *MemoryWebhookRepository) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.webhooks[delivery.WebhookID]; !exists {
+		return ErrWebhookNotFound
+	}
+	recordEntry(r.tx, r.deliveries, delivery.ID, copyDelivery)
+	r.deliveries[delivery.ID] = copyDelivery(delivery)
+	return nil
+}
+
+// UpdateDelivery stores the outcome of a delivery attempt
+func (r *MemoryWebhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.deliveries[delivery.ID]; !exists {
+		return ErrDeliveryNotFound
+	}
+	recordEntry(r.tx, r.deliveries, delivery.ID, copyDelivery)
+	r.deliveries[delivery.ID] = copyDelivery(delivery)
+	return nil
+}
+
+// GetDelivery retrieves a delivery by ID
+func (r *MemoryWebhookRepository) GetDelivery(ctx context.Context, id string) (*models.WebhookDelivery, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	delivery, exists := r.deliveries[id]
+	if !exists {
+		return nil, ErrDeliveryNotFound
+	}
+	return copyDelivery(delivery), nil
+}
+
+// GetWebhookDeliveries retrieves the most recent deliveries of a webhook,
+// newest first
+func (r *MemoryWebhookRepository) GetWebhookDeliveries(ctx context.Context, webhookID string, limit int) ([]*models.WebhookDelivery, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var deliveries []*models.WebhookDelivery
+	for _, delivery := range r.deliveries {
+		if delivery.WebhookID == webhookID {
+			deliveries = append(deliveries, copyDelivery(delivery))
+		}
+	}
+
+	sort.SliceStable(deliveries, func(i, j int) bool {
+		return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt)
+	})
+	if limit > 0 && len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+// ClaimDueDeliveries retrieves up to limit pending deliveries due at now,
+// postponing them by lease
+func (r *MemoryWebhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var due []*models.WebhookDelivery
+	for _, delivery := range r.deliveries {
+		if delivery.Status == models.DeliveryPending && !delivery.NextAttemptAt.After(now) {
+			due = append(due, delivery)
+		}
+	}
+
+	// Attempt the longest overdue deliveries first
+	sort.SliceStable(due, func(i, j int) bool {
+		return due[i].NextAttemptAt.Before(due[j].NextAttemptAt)
+	})
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
+	}
+
+	claimed := make([]*models.WebhookDelivery, 0, len(due))
+	for _, delivery := range due {
+		claimed = append(claimed, copyDelivery(delivery))
+		recordEntry(r.tx, r.deliveries, delivery.ID, copyDelivery)
+		delivery.NextAttemptAt = now.Add(lease)
+	}
+	return claimed, nil
+}
+
+// DeleteUserWebhooks removes all webhooks of a user together with their deliveries
+func (r *MemoryWebhookRepository) DeleteUserWebhooks(ctx context.Context, userID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for id, webhook := range r.webhooks {
+		if webhook.UserID == userID {
+			recordEntry(r.tx, r.webhooks, id, copyWebhook)
+			delete(r.webhooks, id)
+		}
+	}
+	for id, delivery := range r.deliveries {
+		if delivery.UserID == userID {
+			recordEntry(r.tx, r.deliveries, id, copyDelivery)
+			delete(r.deliveries, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryWebhookRepository_Webhooks(t *testing.T) {
+	repo := NewMemoryWebhookRepository()
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+	first := models.NewWebhook(userID, "https://example.com/first", []models.WebhookEvent{models.WebhookTodoCreated})
+	second := models.NewWebhook(userID, "https://example.com/second", models.WebhookEvents)
+	second.CreatedAt = first.CreatedAt.Add(time.Second)
+	other := models.NewWebhook(uuid.New().String(), "https://example.com/other", models.WebhookEvents)
+
+	for _, webhook := range []*models.Webhook{second, first, other} {
+		assert.NoError(t, repo.CreateWebhook(ctx, webhook))
+	}
+
+	// Stored webhooks are copies
+	first.Events[0] = models.WebhookTodoDeleted
+	stored, err := repo.GetWebhook(ctx, first.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.WebhookEvent{models.WebhookTodoCreated}, stored.Events)
+
+	webhooks, err := repo.GetUserWebhooks(ctx, userID)
+	assert.NoError(t, err)
+	if assert.Len(t, webhooks, 2) {
+		assert.Equal(t, first.ID, webhooks[0].ID)
+		assert.Equal(t, second.ID, webhooks[1].ID)
+	}
+
+	// Deleting a webhook removes its deliveries
+	delivery := models.NewWebhookDelivery(first, models.WebhookTest, []byte(`{}`))
+	assert.NoError(t, repo.CreateDelivery(ctx, delivery))
+	assert.NoError(t, repo.DeleteWebhook(ctx, first.ID))
+
+	_, err = repo.GetWebhook(ctx, first.ID)
+	assert.Equal(t, ErrWebhookNotFound, err)
+	_, err = repo.GetDelivery(ctx, delivery.ID)
+	assert.Equal(t, ErrDeliveryNotFound, err)
+	assert.Equal(t, ErrWebhookNotFound, repo.DeleteWebhook(ctx, first.ID))
+}
+
+func TestMemoryWebhookRepository_Deliveries(t *testing.T) {
+	repo := NewMemoryWebhookRepository()
+	ctx := context.Background()
+
+	webhook := models.NewWebhook(uuid.New().String(), "https://example.com/hook", models.WebhookEvents)
+	assert.NoError(t, repo.CreateWebhook(ctx, webhook))
+
+	now := time.Now()
+	overdue := models.NewWebhookDelivery(webhook, models.WebhookTodoCreated, []byte(`{"n":1}`))
+	overdue.NextAttemptAt = now.Add(-time.Minute)
+	due := models.NewWebhookDelivery(webhook, models.WebhookTodoCompleted, []byte(`{"n":2}`))
+	due.NextAttemptAt = now
+	due.CreatedAt = overdue.CreatedAt.Add(time.Second)
+	later := models.NewWebhookDelivery(webhook, models.WebhookTodoDeleted, []byte(`{"n":3}`))
+	later.NextAttemptAt = now.Add(time.Minute)
+	later.CreatedAt = overdue.CreatedAt.Add(2 * time.Second)
+
+	for _, delivery := range []*models.WebhookDelivery{overdue, due, later} {
+		assert.NoError(t, repo.CreateDelivery(ctx, delivery))
+	}
+
+	// Deliveries need an existing webhook
+	orphan := models.NewWebhookDelivery(models.NewWebhook(webhook.UserID, "https://example.com", nil), models.WebhookTest, nil)
+	assert.Equal(t, ErrWebhookNotFound, repo.CreateDelivery(ctx, orphan))
+
+	// Due deliveries are claimed in order and not handed out twice
+	claimed, err := repo.ClaimDueDeliveries(ctx, now, time.Minute, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, claimed, 2) {
+		assert.Equal(t, overdue.ID, claimed[0].ID)
+		assert.Equal(t, due.ID, claimed[1].ID)
+	}
+	claimed, err = repo.ClaimDueDeliveries(ctx, now, time.Minute, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, claimed)
+
+	// Finished deliveries are never claimed again
+	overdue.Status = models.DeliverySucceeded
+	overdue.Attempts = 1
+	assert.NoError(t, repo.UpdateDelivery(ctx, overdue))
+	claimed, err = repo.ClaimDueDeliveries(ctx, now.Add(2*time.Minute), time.Minute, 10)
+	assert.NoError(t, err)
+	assert.Len(t, claimed, 2)
+
+	stored, err := repo.GetDelivery(ctx, overdue.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.DeliverySucceeded, stored.Status)
+	assert.Equal(t, 1, stored.Attempts)
+
+	// The delivery log lists the newest deliveries first
+	log, err := repo.GetWebhookDeliveries(ctx, webhook.ID, 2)
+	assert.NoError(t, err)
+	if assert.Len(t, log, 2) {
+		assert.Equal(t, later.ID, log[0].ID)
+		assert.Equal(t, due.ID, log[1].ID)
+	}
+
+	assert.Equal(t, ErrDeliveryNotFound, repo.UpdateDelivery(ctx, orphan))
+}
+
+func TestMemoryWebhookRepository_DeleteUserWebhooks(t *testing.T) {
+	repo := NewMemoryWebhookRepository()
+	ctx := context.Background()
+
+	userID, otherID := uuid.New().String(), uuid.New().String()
+	webhook := models.NewWebhook(userID, "https://example.com/hook", models.WebhookEvents)
+	other := models.NewWebhook(otherID, "https://example.com/hook", models.WebhookEvents)
+	delivery := models.NewWebhookDelivery(webhook, models.WebhookTodoCreated, []byte(`{}`))
+	assert.NoError(t, repo.CreateWebhook(ctx, webhook))
+	assert.NoError(t, repo.CreateWebhook(ctx, other))
+	assert.NoError(t, repo.CreateDelivery(ctx, delivery))
+
+	assert.NoError(t, repo.DeleteUserWebhooks(ctx, userID))
+
+	_, err := repo.GetWebhook(ctx, webhook.ID)
+	assert.Equal(t, ErrWebhookNotFound, err)
+	_, err = repo.GetDelivery(ctx, delivery.ID)
+	assert.Equal(t, ErrDeliveryNotFound, err)
+	_, err = repo.GetWebhook(ctx, other.ID)
+	assert.NoError(t, err)
+}
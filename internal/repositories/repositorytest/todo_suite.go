@@ -0,0 +1,774 @@
+// Package repositorytest holds conformance suites that every implementation
+// of a repository interface has to pass, so that the implementations cannot
+// drift apart in the details their callers rely on.
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
+)
+
+// TodoRepositoryFactory returns the TodoRepository a subtest runs against.
+// Every subtest works with users of its own, so the factory may hand out
+// repositories sharing a database; only PurgeTrash reaches beyond them.
+type TodoRepositoryFactory func(t *testing.T) repositories.TodoRepository
+
+// concurrentWriters is how many goroutines the concurrency subtests start
+const concurrentWriters = 8
+
+// testContext returns the context of the suite's calls. The suite works
+// across users, so on Postgres it needs a role that bypasses row-level
+// security, like the background jobs.
+func testContext() context.Context {
+	return context.Background()
+}
+
+// errStop is returned by callbacks to check that an error is passed through
+var errStop = errors.New("stop")
+
+// RunTodoRepositorySuite checks that the repositories returned by factory
+// fulfil the whole TodoRepository contract
+func RunTodoRepositorySuite(t *testing.T, factory TodoRepositoryFactory) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, repo repositories.TodoRepository)
+	}{
+		{"CreateAndGet", testCreateAndGet},
+		{"CreateGeneratesIDAndTimestamps", testCreateGeneratesIDAndTimestamps},
+		{"CreateDuplicate", testCreateDuplicate},
+		{"CreateTodos", testCreateTodos},
+		{"CreateTodosIsAtomic", testCreateTodosIsAtomic},
+		{"NotFound", testNotFound},
+		{"UserIsolation", testUserIsolation},
+		{"GetUserTodosExcludesTrash", testGetUserTodosExcludesTrash},
+		{"GetUserTodosOrder", testGetUserTodosOrder},
+		{"StreamUserTodosOrder", testStreamUserTodosOrder},
+		{"StreamUserTodosStops", testStreamUserTodosStops},
+		{"UpdateTodo", testUpdateTodo},
+		{"UpdateTodoConflict", testUpdateTodoConflict},
+		{"PatchTodo", testPatchTodo},
+		{"PatchTodoConflict", testPatchTodoConflict},
+		{"PatchTodoUnknownField", testPatchTodoUnknownField},
+		{"UpdateTodos", testUpdateTodos},
+		{"TrashAndRestore", testTrashAndRestore},
+		{"GetTrashedTodosOrder", testGetTrashedTodosOrder},
+		{"EmptyTrash", testEmptyTrash},
+		{"PurgeTrash", testPurgeTrash},
+		{"DeleteUserTodos", testDeleteUserTodos},
+		{"ReturnsCopies", testReturnsCopies},
+		{"ConcurrentCreate", testConcurrentCreate},
+		{"ConcurrentUpdateSameVersion", testConcurrentUpdateSameVersion},
+		{"ConcurrentWritersLoseNoUpdate", testConcurrentWritersLoseNoUpdate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, factory(t))
+		})
+	}
+}
+
+// newTodo returns a todo with every field set. Times are truncated to the
+// microseconds Postgres keeps.
+func newTodo(userID, title string) *models.Todo {
+	now := time.Now().Truncate(time.Microsecond)
+	due := now.Add(48 * time.Hour)
+	return &models.Todo{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		Title:       title,
+		Description: "Description of " + title,
+		Completed:   false,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		DueAt:       &due,
+		Priority:    models.PriorityHigh,
+		Project:     "Suite",
+		Tags:        []string{"alpha", "beta"},
+		Recurrence:  "FREQ=WEEKLY;BYDAY=MO",
+	}
+}
+
+// createTodos stores todos one by one
+func createTodos(t *testing.T, repo repositories.TodoRepository, todos ...*models.Todo) {
+	t.Helper()
+	for _, todo := range todos {
+		require.NoError(t, repo.CreateTodo(testContext(), todo))
+	}
+}
+
+// getTodo reads a todo that has to exist
+func getTodo(t *testing.T, repo repositories.TodoRepository, todoID string) *models.Todo {
+	t.Helper()
+	todo, err := repo.GetTodo(testContext(), todoID)
+	require.NoError(t, err)
+	return todo
+}
+
+// assertSameTodo checks that two todos hold the same values, comparing
+// times as instants
+func assertSameTodo(t *testing.T, want, got *models.Todo) {
+	t.Helper()
+	assert.Equal(t, want.ID, got.ID)
+	assert.Equal(t, want.UserID, got.UserID)
+	assert.Equal(t, want.Title, got.Title)
+	assert.Equal(t, want.Description, got.Description)
+	assert.Equal(t, want.Completed, got.Completed)
+	assert.Equal(t, want.Version, got.Version)
+	assert.True(t, want.CreatedAt.Equal(got.CreatedAt), "created_at: want %v, got %v", want.CreatedAt, got.CreatedAt)
+	assert.True(t, want.UpdatedAt.Equal(got.UpdatedAt), "updated_at: want %v, got %v", want.UpdatedAt, got.UpdatedAt)
+	assertSameTime(t, "deleted_at", want.DeletedAt, got.DeletedAt)
+	assertSameTime(t, "due_at", want.DueAt, got.DueAt)
+	assert.Equal(t, want.Priority, got.Priority)
+	assert.Equal(t, want.Project, got.Project)
+	assert.Equal(t, want.Tags, got.Tags)
+	assert.Equal(t, want.Recurrence, got.Recurrence)
+}
+
+// assertSameTime checks that two optional times are both unset or equal
+func assertSameTime(t *testing.T, name string, want, got *time.Time) {
+	t.Helper()
+	if want == nil || got == nil {
+		assert.True(t, want == nil && got == nil, "%s: want %v, got %v", name, want, got)
+		return
+	}
+	assert.True(t, want.Equal(*got), "%s: want %v, got %v", name, *want, *got)
+}
+
+// todoIDs returns the IDs of todos in order
+func todoIDs(todos []*models.Todo) []string {
+	ids := make([]string, len(todos))
+	for i, todo := range todos {
+		ids[i] = todo.ID
+	}
+	return ids
+}
+
+// sortedIDs returns the IDs of todos sorted, for results in no particular order
+func sortedIDs(todos []*models.Todo) []string {
+	ids := todoIDs(todos)
+	sort.Strings(ids)
+	return ids
+}
+
+// sorted returns ids sorted
+func sorted(ids ...string) []string {
+	ids = append([]string(nil), ids...)
+	sort.Strings(ids)
+	return ids
+}
+
+func testCreateAndGet(t *testing.T, repo repositories.TodoRepository) {
+	todo := newTodo(uuid.New().String(), "Round trip")
+	todo.Version = 7
+
+	createTodos(t, repo, todo)
+	assert.Equal(t, 1, todo.Version, "a new todo starts at version 1")
+
+	assertSameTodo(t, todo, getTodo(t, repo, todo.ID))
+
+	// Optional fields stay unset
+	bare := &models.Todo{
+		ID:     uuid.New().String(),
+		UserID: todo.UserID,
+		Title:  "Bare",
+	}
+	createTodos(t, repo, bare)
+	got := getTodo(t, repo, bare.ID)
+	assert.Empty(t, got.Description)
+	assert.Nil(t, got.DeletedAt)
+	assert.Nil(t, got.DueAt)
+	assert.Empty(t, got.Priority)
+	assert.Empty(t, got.Project)
+	assert.Nil(t, got.Tags)
+	assert.Empty(t, got.Recurrence)
+}
+
+func testCreateGeneratesIDAndTimestamps(t *testing.T, repo repositories.TodoRepository) {
+	todo := &models.Todo{UserID: uuid.New().String(), Title: "Generated"}
+
+	before := time.Now()
+	createTodos(t, repo, todo)
+
+	assert.True(t, models.IsValidUUID(todo.ID), "generated ID %q is not a UUID", todo.ID)
+	assert.WithinDuration(t, before, todo.CreatedAt, time.Minute)
+	assert.True(t, todo.UpdatedAt.Equal(todo.CreatedAt), "a new todo is updated when it is created")
+
+	got := getTodo(t, repo, todo.ID)
+	assert.WithinDuration(t, todo.CreatedAt, got.CreatedAt, time.Microsecond)
+	assert.WithinDuration(t, todo.UpdatedAt, got.UpdatedAt, time.Microsecond)
+}
+
+func testCreateDuplicate(t *testing.T, repo repositories.TodoRepository) {
+	todo := newTodo(uuid.New().String(), "Original")
+	createTodos(t, repo, todo)
+
+	duplicate := newTodo(uuid.New().String(), "Duplicate")
+	duplicate.ID = todo.ID
+	assert.ErrorIs(t, repo.CreateTodo(testContext(), duplicate), repositories.ErrTodoExists)
+
+	assert.Equal(t, "Original", getTodo(t, repo, todo.ID).Title)
+}
+
+func testCreateTodos(t *testing.T, repo repositories.TodoRepository) {
+	userID := uuid.New().String()
+	todos := []*models.Todo{newTodo(userID, "First"), newTodo(userID, "Second"), {UserID: userID, Title: "Third"}}
+	todos[1].Version = 3
+
+	require.NoError(t, repo.CreateTodos(testContext(), todos))
+
+	for _, todo := range todos {
+		assert.Equal(t, 1, todo.Version)
+		assert.NotEmpty(t, todo.ID)
+		assertSameTodo(t, todo, getTodo(t, repo, todo.ID))
+	}
+}
+
+func testCreateTodosIsAtomic(t *testing.T, repo repositories.TodoRepository) {
+	userID := uuid.New().String()
+	existing := newTodo(userID, "Existing")
+	createTodos(t, repo, existing)
+
+	clash := newTodo(userID, "Clash")
+	clash.ID = existing.ID
+	fresh := newTodo(userID, "Fresh")
+	err := repo.CreateTodos(testContext(), []*models.Todo{fresh, clash})
+	assert.ErrorIs(t, err, repositories.ErrTodoExists)
+
+	_, err = repo.GetTodo(testContext(), fresh.ID)
+	assert.ErrorIs(t, err, repositories.ErrTodoNotFound, "no todo of a failed batch is stored")
+	assert.Equal(t, "Existing", getTodo(t, repo, existing.ID).Title)
+}
+
+func testNotFound(t *testing.T, repo repositories.TodoRepository) {
+	ctx := testContext()
+	missing := newTodo(uuid.New().String(), "Missing")
+	missing.Version = 1
+
+	_, err := repo.GetTodo(ctx, missing.ID)
+	assert.ErrorIs(t, err, repositories.ErrTodoNotFound, "GetTodo")
+	assert.ErrorIs(t, repo.UpdateTodo(ctx, missing), repositories.ErrTodoNotFound, "UpdateTodo")
+	assert.ErrorIs(t, repo.PatchTodo(ctx, missing, []string{models.FieldTitle}), repositories.ErrTodoNotFound, "PatchTodo")
+	assert.ErrorIs(t, repo.DeleteTodo(ctx, missing.ID, missing.Version), repositories.ErrTodoNotFound, "DeleteTodo")
+	assert.ErrorIs(t, repo.RestoreTodo(ctx, missing.ID), repositories.ErrTodoNotFound, "RestoreTodo")
+
+	// A user without todos has none, which is no error
+	todos, err := repo.GetUserTodos(ctx, missing.UserID)
+	require.NoError(t, err)
+	assert.Empty(t, todos)
+	trashed, err := repo.GetTrashedTodos(ctx, missing.UserID)
+	require.NoError(t, err)
+	assert.Empty(t, trashed)
+	assert.NoError(t, repo.DeleteUserTodos(ctx, missing.UserID))
+}
+
+func testUserIsolation(t *testing.T, repo repositories.TodoRepository) {
+	ctx := testContext()
+	alice, bob := uuid.New().String(), uuid.New().String()
+	aliceTodo, aliceTrashed := newTodo(alice, "Alice's"), newTodo(alice, "Alice's trashed")
+	bobTodo, bobTrashed := newTodo(bob, "Bob's"), newTodo(bob, "Bob's trashed")
+	createTodos(t, repo, aliceTodo, aliceTrashed, bobTodo, bobTrashed)
+	require.NoError(t, repo.DeleteTodo(ctx, aliceTrashed.ID, aliceTrashed.Version))
+	require.NoError(t, repo.DeleteTodo(ctx, bobTrashed.ID, bobTrashed.Version))
+
+	todos, err := repo.GetUserTodos(ctx, alice)
+	require.NoError(t, err)
+	assert.Equal(t, []string{aliceTodo.ID}, todoIDs(todos))
+
+	var streamed []*models.Todo
+	require.NoError(t, repo.StreamUserTodos(ctx, alice, func(todo *models.Todo) error {
+		streamed = append(streamed, todo)
+		return nil
+	}))
+	assert.Equal(t, sorted(aliceTodo.ID, aliceTrashed.ID), sortedIDs(streamed))
+
+	trashed, err := repo.GetTrashedTodos(ctx, alice)
+	require.NoError(t, err)
+	assert.Equal(t, []string{aliceTrashed.ID}, todoIDs(trashed))
+
+	emptied, err := repo.EmptyTrash(ctx, alice)
+	require.NoError(t, err)
+	assert.Equal(t, []string{aliceTrashed.ID}, todoIDs(emptied))
+
+	require.NoError(t, repo.DeleteUserTodos(ctx, alice))
+
+	// Bob's todos are untouched by everything done to Alice's
+	assertSameTodo(t, bobTodo, getTodo(t, repo, bobTodo.ID))
+	trashed, err = repo.GetTrashedTodos(ctx, bob)
+	require.NoError(t, err)
+	assert.Equal(t, []string{bobTrashed.ID}, todoIDs(trashed))
+}
+
+func testGetUserTodosExcludesTrash(t *testing.T, repo repositories.TodoRepository) {
+	ctx := testContext()
+	userID := uuid.New().String()
+	kept, trashed := newTodo(userID, "Kept"), newTodo(userID, "Trashed")
+	createTodos(t, repo, kept, trashed)
+	require.NoError(t, repo.DeleteTodo(ctx, trashed.ID, trashed.Version))
+
+	todos, err := repo.GetUserTodos(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{kept.ID}, todoIDs(todos))
+
+	// The trashed todo is still readable by ID
+	assert.NotNil(t, getTodo(t, repo, trashed.ID).DeletedAt)
+}
+
+func testGetUserTodosOrder(t *testing.T, repo repositories.TodoRepository) {
+	userID := uuid.New().String()
+	want := createOrderedTodos(t, repo, userID)
+
+	todos, err := repo.GetUserTodos(testContext(), userID)
+	require.NoError(t, err)
+	assert.Equal(t, want[1:], todoIDs(todos), "oldest first, trashed todos excluded")
+}
+
+func testStreamUserTodosOrder(t *testing.T, repo repositories.TodoRepository) {
+	userID := uuid.New().String()
+	want := createOrderedTodos(t, repo, userID)
+
+	var got []string
+	require.NoError(t, repo.StreamUserTodos(testContext(), userID, func(todo *models.Todo) error {
+		got = append(got, todo.ID)
+		return nil
+	}))
+	assert.Equal(t, want, got, "oldest first, trashed todos included")
+}
+
+// createOrderedTodos creates todos of a user at different times, some at the
+// same time, and trashes the oldest. It returns their IDs oldest first.
+func createOrderedTodos(t *testing.T, repo repositories.TodoRepository, userID string) []string {
+	t.Helper()
+	base := time.Now().Truncate(time.Microsecond)
+
+	newest, oldest, middle := newTodo(userID, "Newest"), newTodo(userID, "Oldest"), newTodo(userID, "Middle")
+	newest.CreatedAt = base
+	oldest.CreatedAt = base.Add(-2 * time.Hour)
+	middle.CreatedAt = base.Add(-time.Hour)
+
+	// Todos created at the same time are ordered by ID
+	tieA, tieB := newTodo(userID, "Tie"), newTodo(userID, "Tie")
+	tieA.CreatedAt, tieB.CreatedAt = middle.CreatedAt, middle.CreatedAt
+	createTodos(t, repo, newest, oldest, middle, tieA, tieB)
+	require.NoError(t, repo.DeleteTodo(testContext(), oldest.ID, oldest.Version))
+
+	ties := sorted(middle.ID, tieA.ID, tieB.ID)
+	return append(append([]string{oldest.ID}, ties...), newest.ID)
+}
+
+func testStreamUserTodosStops(t *testing.T, repo repositories.TodoRepository) {
+	userID := uuid.New().String()
+	createTodos(t, repo, newTodo(userID, "One"), newTodo(userID, "Two"), newTodo(userID, "Three"))
+
+	calls := 0
+	err := repo.StreamUserTodos(testContext(), userID, func(todo *models.Todo) error {
+		calls++
+		// The repository may be used while streaming
+		if _, err := repo.GetTodo(testContext(), todo.ID); err != nil {
+			return err
+		}
+		return errStop
+	})
+	assert.ErrorIs(t, err, errStop)
+	assert.Equal(t, 1, calls)
+}
+
+func testUpdateTodo(t *testing.T, repo repositories.TodoRepository) {
+	todo := newTodo(uuid.New().String(), "Before")
+	createTodos(t, repo, todo)
+
+	due := todo.DueAt.Add(24 * time.Hour)
+	todo.Title = "After"
+	todo.Description = ""
+	todo.Completed = true
+	todo.DueAt = &due
+	todo.Priority = models.PriorityLow
+	todo.Project = "Other"
+	todo.Tags = nil
+	todo.Recurrence = ""
+	todo.UpdatedAt = todo.UpdatedAt.Add(time.Minute)
+	require.NoError(t, repo.UpdateTodo(testContext(), todo))
+	assert.Equal(t, 2, todo.Version)
+
+	assertSameTodo(t, todo, getTodo(t, repo, todo.ID))
+
+	// Clearing the due date is an update too
+	todo.DueAt = nil
+	require.NoError(t, repo.UpdateTodo(testContext(), todo))
+	assert.Equal(t, 3, todo.Version)
+	assertSameTodo(t, todo, getTodo(t, repo, todo.ID))
+}
+
+func testUpdateTodoConflict(t *testing.T, repo repositories.TodoRepository) {
+	todo := newTodo(uuid.New().String(), "Original")
+	createTodos(t, repo, todo)
+
+	first, second := getTodo(t, repo, todo.ID), getTodo(t, repo, todo.ID)
+	first.Title = "First writer"
+	require.NoError(t, repo.UpdateTodo(testContext(), first))
+
+	second.Title = "Second writer"
+	assert.ErrorIs(t, repo.UpdateTodo(testContext(), second), repositories.ErrConflict)
+	assert.Equal(t, 1, second.Version, "a failed update leaves the version alone")
+
+	stored := getTodo(t, repo, todo.ID)
+	assert.Equal(t, "First writer", stored.Title)
+	assert.Equal(t, 2, stored.Version)
+}
+
+func testPatchTodo(t *testing.T, repo repositories.TodoRepository) {
+	todo := newTodo(uuid.New().String(), "Before")
+	createTodos(t, repo, todo)
+
+	patch := getTodo(t, repo, todo.ID)
+	patch.Title = "After"
+	patch.Tags = []string{"gamma"}
+	patch.Description = "Not written"
+	patch.Completed = true
+	patch.UpdatedAt = todo.UpdatedAt.Add(time.Minute)
+	require.NoError(t, repo.PatchTodo(testContext(), patch, []string{models.FieldTitle, models.FieldTags}))
+	assert.Equal(t, 2, patch.Version)
+
+	want := *todo
+	want.Title = "After"
+	want.Tags = []string{"gamma"}
+	want.UpdatedAt = patch.UpdatedAt
+	want.Version = 2
+	assertSameTodo(t, &want, getTodo(t, repo, todo.ID))
+}
+
+func testPatchTodoConflict(t *testing.T, repo repositories.TodoRepository) {
+	todo := newTodo(uuid.New().String(), "Original")
+	createTodos(t, repo, todo)
+
+	fresh := getTodo(t, repo, todo.ID)
+	fresh.Completed = true
+	require.NoError(t, repo.UpdateTodo(testContext(), fresh))
+
+	todo.Title = "Stale"
+	assert.ErrorIs(t, repo.PatchTodo(testContext(), todo, []string{models.FieldTitle}), repositories.ErrConflict)
+	assert.Equal(t, "Original", getTodo(t, repo, todo.ID).Title)
+}
+
+func testPatchTodoUnknownField(t *testing.T, repo repositories.TodoRepository) {
+	todo := newTodo(uuid.New().String(), "Original")
+	createTodos(t, repo, todo)
+
+	todo.Title = "Changed"
+	err := repo.PatchTodo(testContext(), todo, []string{models.FieldTitle, "user_id"})
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, repositories.ErrConflict)
+
+	stored := getTodo(t, repo, todo.ID)
+	assert.Equal(t, "Original", stored.Title)
+	assert.Equal(t, 1, stored.Version)
+}
+
+func testUpdateTodos(t *testing.T, repo repositories.TodoRepository) {
+	userID := uuid.New().String()
+	completed, skipped, trashed := newTodo(userID, "Complete me"), newTodo(userID, "Skip me"), newTodo(userID, "Trash me")
+	createTodos(t, repo, completed, skipped, trashed)
+	missingID := uuid.New().String()
+
+	results, err := repo.UpdateTodos(testContext(), []string{completed.ID, missingID, skipped.ID, trashed.ID}, func(todo *models.Todo) error {
+		switch todo.ID {
+		case completed.ID:
+			todo.Completed = true
+		case skipped.ID:
+			return errStop
+		case trashed.ID:
+			todo.MoveToTrash()
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+
+	// Results follow the order of the IDs
+	assert.Equal(t, []string{completed.ID, missingID, skipped.ID, trashed.ID},
+		[]string{results[0].TodoID, results[1].TodoID, results[2].TodoID, results[3].TodoID})
+
+	assert.NoError(t, results[0].Err)
+	require.NotNil(t, results[0].Before)
+	require.NotNil(t, results[0].Todo)
+	assertSameTodo(t, completed, results[0].Before)
+	assert.True(t, results[0].Todo.Completed)
+	assert.Equal(t, 2, results[0].Todo.Version)
+	assert.True(t, results[0].Todo.UpdatedAt.After(completed.UpdatedAt), "a bulk update stamps updated_at")
+	assertSameTodo(t, results[0].Todo, getTodo(t, repo, completed.ID))
+
+	assert.ErrorIs(t, results[1].Err, repositories.ErrTodoNotFound)
+	assert.Nil(t, results[1].Todo)
+
+	assert.ErrorIs(t, results[2].Err, errStop)
+	assert.Nil(t, results[2].Todo)
+	assertSameTodo(t, skipped, getTodo(t, repo, skipped.ID))
+
+	assert.NoError(t, results[3].Err)
+	stored := getTodo(t, repo, trashed.ID)
+	assert.NotNil(t, stored.DeletedAt)
+	assert.Equal(t, 2, stored.Version)
+
+	todos, err := repo.GetUserTodos(testContext(), userID)
+	require.NoError(t, err)
+	assert.Equal(t, sorted(completed.ID, skipped.ID), sortedIDs(todos))
+}
+
+func testTrashAndRestore(t *testing.T, repo repositories.TodoRepository) {
+	ctx := testContext()
+	todo := newTodo(uuid.New().String(), "Trash me")
+	createTodos(t, repo, todo)
+
+	assert.ErrorIs(t, repo.DeleteTodo(ctx, todo.ID, todo.Version+1), repositories.ErrConflict, "a stale version cannot trash a todo")
+	assert.Nil(t, getTodo(t, repo, todo.ID).DeletedAt)
+
+	before := time.Now().Add(-time.Second)
+	require.NoError(t, repo.DeleteTodo(ctx, todo.ID, todo.Version))
+	stored := getTodo(t, repo, todo.ID)
+	require.NotNil(t, stored.DeletedAt)
+	assert.True(t, stored.DeletedAt.After(before))
+	assert.Equal(t, 2, stored.Version, "trashing a todo is a new version")
+
+	assert.ErrorIs(t, repo.DeleteTodo(ctx, todo.ID, todo.Version), repositories.ErrTodoNotFound, "a trashed todo cannot be trashed again")
+
+	require.NoError(t, repo.RestoreTodo(ctx, todo.ID))
+	stored = getTodo(t, repo, todo.ID)
+	assert.Nil(t, stored.DeletedAt)
+	assert.Equal(t, 3, stored.Version, "restoring a todo is a new version")
+	assert.True(t, stored.UpdatedAt.After(todo.UpdatedAt), "restoring a todo stamps updated_at")
+
+	assert.ErrorIs(t, repo.RestoreTodo(ctx, todo.ID), repositories.ErrTodoNotFound, "a todo outside the trash cannot be restored")
+}
+
+func testGetTrashedTodosOrder(t *testing.T, repo repositories.TodoRepository) {
+	userID := uuid.New().String()
+	first, second, third := newTodo(userID, "First"), newTodo(userID, "Second"), newTodo(userID, "Third")
+	createTodos(t, repo, first, second, third)
+
+	base := time.Now().Add(-time.Hour).Truncate(time.Microsecond)
+	deletedAt := map[string]time.Time{
+		first.ID:  base,
+		second.ID: base.Add(2 * time.Minute),
+		third.ID:  base.Add(time.Minute),
+	}
+	_, err := repo.UpdateTodos(testContext(), []string{first.ID, second.ID, third.ID}, func(todo *models.Todo) error {
+		at := deletedAt[todo.ID]
+		todo.DeletedAt = &at
+		return nil
+	})
+	require.NoError(t, err)
+
+	trashed, err := repo.GetTrashedTodos(testContext(), userID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{second.ID, third.ID, first.ID}, todoIDs(trashed), "most recently trashed first")
+}
+
+func testEmptyTrash(t *testing.T, repo repositories.TodoRepository) {
+	ctx := testContext()
+	userID := uuid.New().String()
+	kept, trashedA, trashedB := newTodo(userID, "Kept"), newTodo(userID, "Trashed A"), newTodo(userID, "Trashed B")
+	createTodos(t, repo, kept, trashedA, trashedB)
+	require.NoError(t, repo.DeleteTodo(ctx, trashedA.ID, trashedA.Version))
+	require.NoError(t, repo.DeleteTodo(ctx, trashedB.ID, trashedB.Version))
+
+	emptied, err := repo.EmptyTrash(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, sorted(trashedA.ID, trashedB.ID), sortedIDs(emptied))
+	for _, todo := range emptied {
+		assert.NotNil(t, todo.DeletedAt, "emptied todos are returned as they were stored")
+		assert.Equal(t, "Description of "+todo.Title, todo.Description)
+	}
+
+	_, err = repo.GetTodo(ctx, trashedA.ID)
+	assert.ErrorIs(t, err, repositories.ErrTodoNotFound)
+	assertSameTodo(t, kept, getTodo(t, repo, kept.ID))
+
+	emptied, err = repo.EmptyTrash(ctx, userID)
+	require.NoError(t, err)
+	assert.Empty(t, emptied)
+}
+
+func testPurgeTrash(t *testing.T, repo repositories.TodoRepository) {
+	ctx := testContext()
+	userID := uuid.New().String()
+	old, recent, kept := newTodo(userID, "Old"), newTodo(userID, "Recent"), newTodo(userID, "Kept")
+	createTodos(t, repo, old, recent, kept)
+
+	cutoff := time.Now().Add(-24 * time.Hour).Truncate(time.Microsecond)
+	deletedAt := map[string]time.Time{
+		old.ID:    cutoff.Add(-time.Microsecond),
+		recent.ID: cutoff,
+	}
+	_, err := repo.UpdateTodos(ctx, []string{old.ID, recent.ID}, func(todo *models.Todo) error {
+		at := deletedAt[todo.ID]
+		todo.DeletedAt = &at
+		return nil
+	})
+	require.NoError(t, err)
+
+	purged, err := repo.PurgeTrash(ctx, cutoff)
+	require.NoError(t, err)
+
+	// Other todos in a shared database may be purged as well
+	var ours []*models.Todo
+	for _, todo := range purged {
+		if todo.UserID == userID {
+			ours = append(ours, todo)
+		}
+	}
+	assert.Equal(t, []string{old.ID}, todoIDs(ours), "only todos trashed before the cutoff are purged")
+
+	_, err = repo.GetTodo(ctx, old.ID)
+	assert.ErrorIs(t, err, repositories.ErrTodoNotFound)
+	assert.NotNil(t, getTodo(t, repo, recent.ID).DeletedAt)
+	assert.Nil(t, getTodo(t, repo, kept.ID).DeletedAt)
+}
+
+func testDeleteUserTodos(t *testing.T, repo repositories.TodoRepository) {
+	ctx := testContext()
+	userID := uuid.New().String()
+	todo, trashed := newTodo(userID, "Todo"), newTodo(userID, "Trashed")
+	createTodos(t, repo, todo, trashed)
+	require.NoError(t, repo.DeleteTodo(ctx, trashed.ID, trashed.Version))
+
+	require.NoError(t, repo.DeleteUserTodos(ctx, userID))
+
+	for _, id := range []string{todo.ID, trashed.ID} {
+		_, err := repo.GetTodo(ctx, id)
+		assert.ErrorIs(t, err, repositories.ErrTodoNotFound)
+	}
+}
+
+func testReturnsCopies(t *testing.T, repo repositories.TodoRepository) {
+	ctx := testContext()
+	todo := newTodo(uuid.New().String(), "Original")
+	want := *todo
+	want.Tags = append([]string(nil), todo.Tags...)
+	due := *todo.DueAt
+	want.DueAt = &due
+	createTodos(t, repo, todo)
+	want.Version = 1
+
+	// Changing the created todo does not change the stored one
+	todo.Title = "Changed"
+	todo.Tags[0] = "changed"
+	*todo.DueAt = todo.DueAt.Add(time.Hour)
+	assertSameTodo(t, &want, getTodo(t, repo, todo.ID))
+
+	// Neither does changing a todo that was read
+	got := getTodo(t, repo, todo.ID)
+	got.Title = "Changed"
+	got.Tags[0] = "changed"
+	*got.DueAt = got.DueAt.Add(time.Hour)
+	assertSameTodo(t, &want, getTodo(t, repo, todo.ID))
+
+	todos, err := repo.GetUserTodos(ctx, todo.UserID)
+	require.NoError(t, err)
+	require.Len(t, todos, 1)
+	todos[0].Tags[1] = "changed"
+	assertSameTodo(t, &want, getTodo(t, repo, todo.ID))
+}
+
+func testConcurrentCreate(t *testing.T, repo repositories.TodoRepository) {
+	id := uuid.New().String()
+	userID := uuid.New().String()
+
+	errs := runConcurrently(func(i int) error {
+		todo := newTodo(userID, "Writer")
+		todo.ID = id
+		return repo.CreateTodo(testContext(), todo)
+	})
+
+	created := 0
+	for _, err := range errs {
+		if err == nil {
+			created++
+			continue
+		}
+		assert.ErrorIs(t, err, repositories.ErrTodoExists)
+	}
+	assert.Equal(t, 1, created, "exactly one writer creates the todo")
+	assert.Equal(t, 1, getTodo(t, repo, id).Version)
+}
+
+func testConcurrentUpdateSameVersion(t *testing.T, repo repositories.TodoRepository) {
+	todo := newTodo(uuid.New().String(), "Original")
+	createTodos(t, repo, todo)
+
+	errs := runConcurrently(func(i int) error {
+		update := *todo
+		update.Title = "Writer"
+		return repo.UpdateTodo(testContext(), &update)
+	})
+
+	updated := 0
+	for _, err := range errs {
+		if err == nil {
+			updated++
+			continue
+		}
+		assert.ErrorIs(t, err, repositories.ErrConflict)
+	}
+	assert.Equal(t, 1, updated, "exactly one writer wins a version")
+	assert.Equal(t, 2, getTodo(t, repo, todo.ID).Version)
+}
+
+func testConcurrentWritersLoseNoUpdate(t *testing.T, repo repositories.TodoRepository) {
+	todo := newTodo(uuid.New().String(), "Counter")
+	todo.Tags = nil
+	createTodos(t, repo, todo)
+
+	// Every writer adds a tag, reading the todo again after each conflict
+	// until its write lands on the latest version
+	errs := runConcurrently(func(i int) error {
+		tag := string(rune('a' + i))
+		for {
+			current, err := repo.GetTodo(testContext(), todo.ID)
+			if err != nil {
+				return err
+			}
+			current.Tags = append(current.Tags, tag)
+			err = repo.UpdateTodo(testContext(), current)
+			if !errors.Is(err, repositories.ErrConflict) {
+				return err
+			}
+		}
+	})
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+
+	stored := getTodo(t, repo, todo.ID)
+	assert.Equal(t, 1+concurrentWriters, stored.Version)
+	assert.Len(t, stored.Tags, concurrentWriters)
+}
+
+// runConcurrently calls fn from concurrentWriters goroutines started at once
+// and returns their errors
+func runConcurrently(fn func(i int) error) []error {
+	errs := make([]error, concurrentWriters)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < concurrentWriters; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			errs[i] = fn(i)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+	return errs
+}
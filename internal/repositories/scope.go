@@ -0,0 +1,28 @@
+package repositories
+
+import "context"
+
+// scopeKey is the context key of the Scope of a request
+type scopeKey struct{}
+
+// Scope tells a repository whose data the caller may see. The Postgres
+// repositories hand it to the database, whose row-level security policies
+// restrict every statement to the data of the scope's user. Background jobs
+// that work across users do not widen the scope; they connect as a role
+// that bypasses the policies instead (see Repositories.System).
+type Scope struct {
+	// UserID is the authenticated user, or empty for no user
+	UserID string
+}
+
+// WithUserID returns a context scoped to the data of the authenticated user
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, scopeKey{}, Scope{UserID: userID})
+}
+
+// ScopeFromContext returns the scope of a context. Without one, the database
+// shows no user's data.
+func ScopeFromContext(ctx context.Context) Scope {
+	scope, _ := ctx.Value(scopeKey{}).(Scope)
+	return scope
+}
@@ -0,0 +1,492 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/starbops/gottodo/internal/models"
+)
+
+// sqliteTodoSchema creates the todos table of a SQLite database. Times are
+// stored as Unix nanoseconds so that they sort and compare exactly, and tags
+// as a JSON array.
+const sqliteTodoSchema = `CREATE TABLE IF NOT EXISTS todos (
+	id TEXT PRIMARY KEY,
+	title TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	user_id TEXT NOT NULL,
+	completed INTEGER NOT NULL DEFAULT 0,
+	version INTEGER NOT NULL DEFAULT 1,
+	created_at INTEGER NOT NULL,
+	updated_at INTEGER NOT NULL,
+	deleted_at INTEGER,
+	due_at INTEGER,
+	priority TEXT NOT NULL DEFAULT '',
+	project TEXT NOT NULL DEFAULT '',
+	tags TEXT NOT NULL DEFAULT '[]',
+	recurrence TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS idx_todos_user_id ON todos(user_id);
+CREATE INDEX IF NOT EXISTS idx_todos_deleted_at ON todos(deleted_at) WHERE deleted_at IS NOT NULL;`
+
+// SQLiteTodoRepository is a SQLite implementation of TodoRepository that
+// runs the conformance suite against a real SQL database without a server.
+// It only exists in tests, so the server does not need cgo.
+type SQLiteTodoRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteTodoRepository creates a new SQLiteTodoRepository, creating the
+// todos table if it does not exist yet
+func NewSQLiteTodoRepository(ctx context.Context, db *sql.DB) (TodoRepository, error) {
+	if _, err := db.ExecContext(ctx, sqliteTodoSchema); err != nil {
+		return nil, fmt.Errorf("failed to create todos table: %w", err)
+	}
+	return &SQLiteTodoRepository{
+		db: db,
+	}, nil
+}
+
+// GetUserTodos retrieves all todos for a specific user
+func (r *SQLiteTodoRepository) GetUserTodos(ctx context.Context, userID string) ([]*models.Todo, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+todoColumns+` FROM todos WHERE user_id = ? AND deleted_at IS NULL ORDER BY created_at, id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query todos: %w", err)
+	}
+	defer rows.Close()
+
+	return scanSQLiteTodoRows(rows)
+}
+
+// StreamUserTodos calls fn with each todo of a user as it is read from the
+// database, oldest first
+func (r *SQLiteTodoRepository) StreamUserTodos(ctx context.Context, userID string, fn func(todo *models.Todo) error) error {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+todoColumns+` FROM todos WHERE user_id = ? ORDER BY created_at, id`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to query todos: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		todo, err := scanSQLiteTodo(rows)
+		if err != nil {
+			return fmt.Errorf("failed to scan todo row: %w", err)
+		}
+		if err := fn(todo); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error during rows iteration: %w", err)
+	}
+	return nil
+}
+
+// GetTodo retrieves a specific todo by ID
+func (r *SQLiteTodoRepository) GetTodo(ctx context.Context, todoID string) (*models.Todo, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+todoColumns+` FROM todos WHERE id = ?`, todoID)
+
+	todo, err := scanSQLiteTodo(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTodoNotFound
+		}
+		return nil, fmt.Errorf("failed to scan todo: %w", err)
+	}
+
+	return todo, nil
+}
+
+// CreateTodo creates a new todo
+func (r *SQLiteTodoRepository) CreateTodo(ctx context.Context, todo *models.Todo) error {
+	return insertSQLiteTodo(ctx, r.db, todo)
+}
+
+// CreateTodos creates several todos within a single transaction
+func (r *SQLiteTodoRepository) CreateTodos(ctx context.Context, todos []*models.Todo) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, todo := range todos {
+		if err := insertSQLiteTodo(ctx, tx, todo); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// insertSQLiteTodo inserts a new todo at version 1
+func insertSQLiteTodo(ctx context.Context, db execer, todo *models.Todo) error {
+	query := `INSERT INTO todos (id, title, description, user_id, completed, version, created_at, updated_at, deleted_at, due_at, priority, project, tags, recurrence)
+              VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	// Generate UUID if not provided
+	if todo.ID == "" {
+		todo.ID = uuid.New().String()
+	}
+
+	// Ensure timestamps are set, equal for a new todo as in models.NewTodo
+	if todo.CreatedAt.IsZero() {
+		todo.CreatedAt = time.Now()
+	}
+	if todo.UpdatedAt.IsZero() {
+		todo.UpdatedAt = todo.CreatedAt
+	}
+
+	// Every todo starts at the first version
+	todo.Version = 1
+
+	tags, err := tagsJSON(todo.Tags)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, query,
+		todo.ID, todo.Title, todo.Description, todo.UserID, todo.Completed, todo.Version,
+		todo.CreatedAt.UnixNano(), todo.UpdatedAt.UnixNano(), unixNanos(todo.DeletedAt), unixNanos(todo.DueAt),
+		todo.Priority, todo.Project, tags, todo.Recurrence)
+	if err != nil {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey {
+			return ErrTodoExists
+		}
+		return fmt.Errorf("failed to insert todo: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateTodo updates an existing todo if its version matches the stored one
+func (r *SQLiteTodoRepository) UpdateTodo(ctx context.Context, todo *models.Todo) error {
+	query := `UPDATE todos SET title = ?, description = ?, completed = ?, due_at = ?, priority = ?, project = ?, tags = ?, recurrence = ?, updated_at = ?, version = version + 1 WHERE id = ? AND version = ?`
+
+	// Ensure updated_at is set
+	if todo.UpdatedAt.IsZero() {
+		todo.UpdatedAt = time.Now()
+	}
+
+	tags, err := tagsJSON(todo.Tags)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.ExecContext(ctx, query,
+		todo.Title, todo.Description, todo.Completed, unixNanos(todo.DueAt), todo.Priority, todo.Project,
+		tags, todo.Recurrence, todo.UpdatedAt.UnixNano(), todo.ID, todo.Version)
+	if err != nil {
+		return fmt.Errorf("failed to update todo: %w", err)
+	}
+
+	if err := r.checkVersionedWrite(ctx, result, `SELECT EXISTS (SELECT 1 FROM todos WHERE id = ?)`, todo.ID); err != nil {
+		return err
+	}
+
+	todo.Version++
+	return nil
+}
+
+// PatchTodo writes only the named columns of an existing todo
+func (r *SQLiteTodoRepository) PatchTodo(ctx context.Context, todo *models.Todo, fields []string) error {
+	// Ensure updated_at is set
+	if todo.UpdatedAt.IsZero() {
+		todo.UpdatedAt = time.Now()
+	}
+
+	// Build the SET clause from a fixed column list, never from input
+	var assignments []string
+	var args []interface{}
+	for _, field := range fields {
+		var value interface{}
+		switch field {
+		case models.FieldTitle:
+			value = todo.Title
+		case models.FieldDescription:
+			value = todo.Description
+		case models.FieldCompleted:
+			value = todo.Completed
+		case models.FieldDueAt:
+			value = unixNanos(todo.DueAt)
+		case models.FieldPriority:
+			value = string(todo.Priority)
+		case models.FieldProject:
+			value = todo.Project
+		case models.FieldTags:
+			tags, err := tagsJSON(todo.Tags)
+			if err != nil {
+				return err
+			}
+			value = tags
+		case models.FieldRecurrence:
+			value = todo.Recurrence
+		default:
+			return fmt.Errorf("unknown todo field %q", field)
+		}
+		args = append(args, value)
+		assignments = append(assignments, field+" = ?")
+	}
+	args = append(args, todo.UpdatedAt.UnixNano(), todo.ID, todo.Version)
+	assignments = append(assignments, "updated_at = ?")
+
+	query := `UPDATE todos SET ` + strings.Join(assignments, ", ") + `, version = version + 1 WHERE id = ? AND version = ?`
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to patch todo: %w", err)
+	}
+
+	if err := r.checkVersionedWrite(ctx, result, `SELECT EXISTS (SELECT 1 FROM todos WHERE id = ?)`, todo.ID); err != nil {
+		return err
+	}
+
+	todo.Version++
+	return nil
+}
+
+// checkVersionedWrite tells a missing todo apart from a stale version when
+// an update guarded by the version changed no row, using existsQuery to
+// look for the todo
+func (r *SQLiteTodoRepository) checkVersionedWrite(ctx context.Context, result sql.Result, existsQuery string, todoID string) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected > 0 {
+		return nil
+	}
+
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, existsQuery, todoID).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check todo existence: %w", err)
+	}
+	if exists {
+		return ErrConflict
+	}
+	return ErrTodoNotFound
+}
+
+// UpdateTodos applies update to several todos within a single transaction
+func (r *SQLiteTodoRepository) UpdateTodos(ctx context.Context, todoIDs []string, update BulkUpdateFunc) ([]BulkResult, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	current := make(map[string]*models.Todo, len(todoIDs))
+	if len(todoIDs) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(todoIDs)), ", ")
+		args := make([]interface{}, len(todoIDs))
+		for i, todoID := range todoIDs {
+			args[i] = todoID
+		}
+		rows, err := tx.QueryContext(ctx, `SELECT `+todoColumns+` FROM todos WHERE id IN (`+placeholders+`)`, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read todos: %w", err)
+		}
+		stored, err := scanSQLiteTodoRows(rows)
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, todo := range stored {
+			current[todo.ID] = todo
+		}
+	}
+
+	query := `UPDATE todos SET title = ?, description = ?, completed = ?, deleted_at = ?, due_at = ?, priority = ?, project = ?, tags = ?, recurrence = ?, updated_at = ?, version = version + 1 WHERE id = ?`
+
+	results := make([]BulkResult, len(todoIDs))
+	for i, todoID := range todoIDs {
+		results[i].TodoID = todoID
+
+		stored, exists := current[todoID]
+		if !exists {
+			results[i].Err = ErrTodoNotFound
+			continue
+		}
+
+		todo := copyTodo(stored)
+		if err := update(todo); err != nil {
+			results[i].Err = err
+			continue
+		}
+		todo.UpdatedAt = time.Now()
+
+		tags, err := tagsJSON(todo.Tags)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.ExecContext(ctx, query,
+			todo.Title, todo.Description, todo.Completed, unixNanos(todo.DeletedAt), unixNanos(todo.DueAt), todo.Priority, todo.Project,
+			tags, todo.Recurrence, todo.UpdatedAt.UnixNano(), todo.ID); err != nil {
+			return nil, fmt.Errorf("failed to update todo %s: %w", todo.ID, err)
+		}
+		todo.Version++
+
+		results[i].Before = copyTodo(stored)
+		results[i].Todo = copyTodo(todo)
+		current[todoID] = todo
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return results, nil
+}
+
+// DeleteTodo moves a todo to the trash by ID unless its version changed
+func (r *SQLiteTodoRepository) DeleteTodo(ctx context.Context, todoID string, version int) error {
+	query := `UPDATE todos SET deleted_at = ?, version = version + 1 WHERE id = ? AND version = ? AND deleted_at IS NULL`
+
+	result, err := r.db.ExecContext(ctx, query, time.Now().UnixNano(), todoID, version)
+	if err != nil {
+		return fmt.Errorf("failed to delete todo: %w", err)
+	}
+	return r.checkVersionedWrite(ctx, result, `SELECT EXISTS (SELECT 1 FROM todos WHERE id = ? AND deleted_at IS NULL)`, todoID)
+}
+
+// GetTrashedTodos retrieves the todos a user has moved to the trash
+func (r *SQLiteTodoRepository) GetTrashedTodos(ctx context.Context, userID string) ([]*models.Todo, error) {
+	query := `SELECT ` + todoColumns + ` FROM todos WHERE user_id = ? AND deleted_at IS NOT NULL ORDER BY deleted_at DESC, id`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trashed todos: %w", err)
+	}
+	defer rows.Close()
+
+	return scanSQLiteTodoRows(rows)
+}
+
+// RestoreTodo takes a todo back out of the trash
+func (r *SQLiteTodoRepository) RestoreTodo(ctx context.Context, todoID string) error {
+	query := `UPDATE todos SET deleted_at = NULL, updated_at = ?, version = version + 1 WHERE id = ? AND deleted_at IS NOT NULL`
+
+	result, err := r.db.ExecContext(ctx, query, time.Now().UnixNano(), todoID)
+	if err != nil {
+		return fmt.Errorf("failed to restore todo: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrTodoNotFound
+	}
+
+	return nil
+}
+
+// EmptyTrash permanently removes all trashed todos of a user and returns them
+func (r *SQLiteTodoRepository) EmptyTrash(ctx context.Context, userID string) ([]*models.Todo, error) {
+	rows, err := r.db.QueryContext(ctx, `DELETE FROM todos WHERE user_id = ? AND deleted_at IS NOT NULL RETURNING `+todoColumns, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to empty trash: %w", err)
+	}
+	defer rows.Close()
+
+	return scanSQLiteTodoRows(rows)
+}
+
+// PurgeTrash permanently removes todos trashed before the cutoff and returns them
+func (r *SQLiteTodoRepository) PurgeTrash(ctx context.Context, deletedBefore time.Time) ([]*models.Todo, error) {
+	rows, err := r.db.QueryContext(ctx, `DELETE FROM todos WHERE deleted_at IS NOT NULL AND deleted_at < ? RETURNING `+todoColumns, deletedBefore.UnixNano())
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge trash: %w", err)
+	}
+	defer rows.Close()
+
+	return scanSQLiteTodoRows(rows)
+}
+
+// DeleteUserTodos permanently removes all todos of a user, trashed ones included
+func (r *SQLiteTodoRepository) DeleteUserTodos(ctx context.Context, userID string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM todos WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("failed to delete todos: %w", err)
+	}
+	return nil
+}
+
+// unixNanos returns an optional time as Unix nanoseconds, or nil for NULL
+func unixNanos(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UnixNano()
+}
+
+// tagsJSON returns the tags of a todo as a JSON array, never null
+func tagsJSON(tags []string) (string, error) {
+	if tags == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(tags)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode tags: %w", err)
+	}
+	return string(data), nil
+}
+
+// scanSQLiteTodoRows reads todos from a result set
+func scanSQLiteTodoRows(rows *sql.Rows) ([]*models.Todo, error) {
+	var todos []*models.Todo
+	for rows.Next() {
+		todo, err := scanSQLiteTodo(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan todo row: %w", err)
+		}
+		todos = append(todos, todo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+
+	return todos, nil
+}
+
+// scanSQLiteTodo reads a todo selected with todoColumns
+func scanSQLiteTodo(row interface{ Scan(dest ...any) error }) (*models.Todo, error) {
+	var todo models.Todo
+	var createdAt, updatedAt int64
+	var deletedAt, dueAt sql.NullInt64
+	var tags string
+	if err := row.Scan(&todo.ID, &todo.Title, &todo.Description, &todo.UserID, &todo.Completed, &todo.Version,
+		&createdAt, &updatedAt, &deletedAt, &dueAt, &todo.Priority, &todo.Project, &tags, &todo.Recurrence); err != nil {
+		return nil, err
+	}
+	todo.CreatedAt = time.Unix(0, createdAt).UTC()
+	todo.UpdatedAt = time.Unix(0, updatedAt).UTC()
+	if deletedAt.Valid {
+		t := time.Unix(0, deletedAt.Int64).UTC()
+		todo.DeletedAt = &t
+	}
+	if dueAt.Valid {
+		t := time.Unix(0, dueAt.Int64).UTC()
+		todo.DueAt = &t
+	}
+	if err := json.Unmarshal([]byte(tags), &todo.Tags); err != nil {
+		return nil, fmt.Errorf("failed to decode tags: %w", err)
+	}
+	if len(todo.Tags) == 0 {
+		todo.Tags = nil
+	}
+	return &todo, nil
+}
@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/starbops/gottodo/internal/models"
+)
+
+// SupabaseAccountDeletionRepository is a PostgreSQL implementation of AccountDeletionRepository using Supabase
+type SupabaseAccountDeletionRepository struct {
+	scopedDB
+}
+
+// NewSupabaseAccountDeletionRepository creates a new SupabaseAccountDeletionRepository
+func NewSupabaseAccountDeletionRepository(db *sql.DB) AccountDeletionRepository {
+	return &SupabaseAccountDeletionRepository{
+		scopedDB: scopedDB{db: db},
+	}
+}
+
+// SaveAccountDeletion stores a user's account deletion, replacing any previous one
+func (r *SupabaseAccountDeletionRepository) SaveAccountDeletion(ctx context.Context, deletion *models.AccountDeletion) error {
+	query := `INSERT INTO account_deletions (user_id, requested_at, delete_at) VALUES ($1, $2, $3) ON CONFLICT (user_id) DO UPDATE SET requested_at = EXCLUDED.requested_at, delete_at = EXCLUDED.delete_at`
+	return r.inScope(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, query, deletion.UserID, deletion.RequestedAt, deletion.DeleteAt); err != nil {
+			return fmt.Errorf("failed to save account deletion: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetAccountDeletion retrieves the pending account deletion of a user
+func (r *SupabaseAccountDeletionRepository) GetAccountDeletion(ctx context.Context, userID string) (*models.AccountDeletion, error) {
+	query := `SELECT user_id, requested_at, delete_at FROM account_deletions WHERE user_id = $1`
+
+	var deletion models.AccountDeletion
+	err := r.inScope(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, query, userID).Scan(&deletion.UserID, &deletion.RequestedAt, &deletion.DeleteAt)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrAccountDeletionNotFound
+			}
+			return fmt.Errorf("failed to get account deletion: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &deletion, nil
+}
+
+// DeleteAccountDeletion removes the pending account deletion of a user
+func (r *SupabaseAccountDeletionRepository) DeleteAccountDeletion(ctx context.Context, userID string) error {
+	return r.inScope(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `DELETE FROM account_deletions WHERE user_id = $1`, userID)
+		if err != nil {
+			return fmt.Errorf("failed to delete account deletion: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return ErrAccountDeletionNotFound
+		}
+		return nil
+	})
+}
+
+// GetDueAccountDeletions retrieves the account deletions due at now, oldest first
+func (r *SupabaseAccountDeletionRepository) GetDueAccountDeletions(ctx context.Context, now time.Time) ([]*models.AccountDeletion, error) {
+	query := `SELECT user_id, requested_at, delete_at FROM account_deletions WHERE delete_at <= $1 ORDER BY delete_at`
+
+	var deletions []*models.AccountDeletion
+	err := r.inScope(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, query, now)
+		if err != nil {
+			return fmt.Errorf("failed to get due account deletions: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var deletion models.AccountDeletion
+			if err := rows.Scan(&deletion.UserID, &deletion.RequestedAt, &deletion.DeleteAt); err != nil {
+				return fmt.Errorf("failed to scan account deletion: %w", err)
+			}
+			deletions = append(deletions, &deletion)
+		}
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error iterating account deletion rows: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deletions, nil
+}
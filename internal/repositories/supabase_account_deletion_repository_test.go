@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSupabaseAccountDeletionRepository_SaveAccountDeletion(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseAccountDeletionRepository(mockDB)
+	ctx := context.Background()
+
+	deletion := models.NewAccountDeletion(uuid.New().String(), time.Hour)
+
+	expectScope(mock, Scope{})
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO account_deletions (user_id, requested_at, delete_at) VALUES ($1, $2, $3) ON CONFLICT (user_id) DO UPDATE SET requested_at = EXCLUDED.requested_at, delete_at = EXCLUDED.delete_at`)).
+		WithArgs(deletion.UserID, deletion.RequestedAt, deletion.DeleteAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	// Execute the function being tested
+	err := repo.SaveAccountDeletion(ctx, deletion)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseAccountDeletionRepository_GetAccountDeletion(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseAccountDeletionRepository(mockDB)
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+	now := time.Now()
+
+	rows := sqlmock.NewRows([]string{"user_id", "requested_at", "delete_at"}).AddRow(userID, now, now.Add(time.Hour))
+	query := regexp.QuoteMeta(`SELECT user_id, requested_at, delete_at FROM account_deletions WHERE user_id = $1`)
+	expectScope(mock, Scope{})
+	mock.ExpectQuery(query).WithArgs(userID).WillReturnRows(rows)
+	mock.ExpectCommit()
+	expectScope(mock, Scope{})
+	mock.ExpectQuery(query).WithArgs(userID).WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	// Execute the function being tested
+	deletion, err := repo.GetAccountDeletion(ctx, userID)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Equal(t, now.Add(time.Hour), deletion.DeleteAt)
+
+	_, err = repo.GetAccountDeletion(ctx, userID)
+	assert.Equal(t, ErrAccountDeletionNotFound, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseAccountDeletionRepository_DeleteAccountDeletion(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseAccountDeletionRepository(mockDB)
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+	query := regexp.QuoteMeta(`DELETE FROM account_deletions WHERE user_id = $1`)
+	expectScope(mock, Scope{})
+	mock.ExpectExec(query).WithArgs(userID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectScope(mock, Scope{})
+	mock.ExpectExec(query).WithArgs(userID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	// Execute the function being tested
+	assert.NoError(t, repo.DeleteAccountDeletion(ctx, userID))
+	assert.Equal(t, ErrAccountDeletionNotFound, repo.DeleteAccountDeletion(ctx, userID))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseAccountDeletionRepository_GetDueAccountDeletions(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseAccountDeletionRepository(mockDB)
+	ctx := context.Background()
+
+	now := time.Now()
+	first, second := uuid.New().String(), uuid.New().String()
+	rows := sqlmock.NewRows([]string{"user_id", "requested_at", "delete_at"}).
+		AddRow(first, now.Add(-48*time.Hour), now.Add(-2*time.Hour)).
+		AddRow(second, now.Add(-24*time.Hour), now.Add(-time.Hour))
+	expectScope(mock, Scope{})
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT user_id, requested_at, delete_at FROM account_deletions WHERE delete_at <= $1 ORDER BY delete_at`)).
+		WithArgs(now).
+		WillReturnRows(rows)
+	mock.ExpectCommit()
+
+	// Execute the function being tested
+	deletions, err := repo.GetDueAccountDeletions(ctx, now)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Len(t, deletions, 2)
+	assert.Equal(t, first, deletions[0].UserID)
+	assert.Equal(t, second, deletions[1].UserID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/starbops/gottodo/internal/models"
+)
+
+// SupabaseActivityRepository is a PostgreSQL implementation of ActivityRepository using Supabase
+type SupabaseActivityRepository struct {
+	scopedDB
+}
+
+// NewSupabaseActivityRepository creates a new SupabaseActivityRepository
+func NewSupabaseActivityRepository(db *sql.DB) ActivityRepository {
+	return &SupabaseActivityRepository{
+		scopedDB: scopedDB{db: db},
+	}
+}
+
+// AppendActivity appends an entry to the activity log
+func (r *SupabaseActivityRepository) AppendActivity(ctx context.Context, activity *models.Activity) error {
+	query := `INSERT INTO activity_log (id, actor_id, todo_id, action, changes, created_at) VALUES ($1, $2, $3, $4, $5, $6)`
+
+	// Generate UUID if not provided
+	if activity.ID == "" {
+		activity.ID = uuid.New().String()
+	}
+
+	changes, err := json.Marshal(activity.Changes)
+	if err != nil {
+		return fmt.Errorf("failed to encode activity changes: %w", err)
+	}
+
+	return r.inScope(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, query,
+			activity.ID, activity.ActorID, activity.TodoID, string(activity.Action), changes, activity.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert activity: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetTodoActivity retrieves the history of a specific todo, newest first
+func (r *SupabaseActivityRepository) GetTodoActivity(ctx context.Context, todoID string) ([]*models.Activity, error) {
+	query := `SELECT id, actor_id, todo_id, action, changes, created_at FROM activity_log WHERE todo_id = $1 ORDER BY created_at DESC`
+
+	return r.queryActivities(ctx, query, todoID)
+}
+
+// GetUserActivity retrieves a page of the activity performed by a user, newest first
+func (r *SupabaseActivityRepository) GetUserActivity(ctx context.Context, userID string, limit, offset int) ([]*models.Activity, error) {
+	query := `SELECT id, actor_id, todo_id, action, changes, created_at FROM activity_log WHERE actor_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3`
+
+	return r.queryActivities(ctx, query, userID, limit, offset)
+}
+
+// queryActivities runs a query selecting activity entries
+func (r *SupabaseActivityRepository) queryActivities(ctx context.Context, query string, args ...any) ([]*models.Activity, error) {
+	var entries []*models.Activity
+	err := r.inScope(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("failed to query activity: %w", err)
+		}
+		defer rows.Close()
+
+		entries, err = scanActivities(rows)
+		return err
+	})
+	return entries, err
+}
+
+// scanActivities reads activity entries from a result set
+func scanActivities(rows *sql.Rows) ([]*models.Activity, error) {
+	var entries []*models.Activity
+	for rows.Next() {
+		var activity models.Activity
+		var action string
+		var changes []byte
+		if err := rows.Scan(&activity.ID, &activity.ActorID, &activity.TodoID, &action, &changes, &activity.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan activity row: %w", err)
+		}
+		activity.Action = models.ActivityAction(action)
+		if err := json.Unmarshal(changes, &activity.Changes); err != nil {
+			return nil, fmt.Errorf("failed to decode activity changes: %w", err)
+		}
+		entries = append(entries, &activity)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+
+	return entries, nil
+}
+
+// DeleteUserActivity removes the activity performed by a user
+func (r *SupabaseActivityRepository) DeleteUserActivity(ctx context.Context, userID string) error {
+	return r.inScope(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM activity_log WHERE actor_id = $1`, userID); err != nil {
+			return fmt.Errorf("failed to delete activity: %w", err)
+		}
+		return nil
+	})
+}
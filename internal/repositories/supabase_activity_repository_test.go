@@ -0,0 +1,118 @@
+package repositories
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSupabaseActivityRepository_AppendActivity(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseActivityRepository(mockDB)
+	ctx := context.Background()
+
+	activity := models.NewActivity(uuid.New().String(), uuid.New().String(), models.ActivityUpdated, []models.FieldChange{
+		{Field: "title", Before: "Old", After: "New"},
+	})
+
+	expectScope(mock, Scope{})
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO activity_log (id, actor_id, todo_id, action, changes, created_at) VALUES ($1, $2, $3, $4, $5, $6)`)).
+		WithArgs(activity.ID, activity.ActorID, activity.TodoID, "updated", []byte(`[{"field":"title","before":"Old","after":"New"}]`), activity.CreatedAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	// Execute the function being tested
+	err := repo.AppendActivity(ctx, activity)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseActivityRepository_GetTodoActivity(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseActivityRepository(mockDB)
+	ctx := context.Background()
+
+	todoID := uuid.New().String()
+	actorID := uuid.New().String()
+	now := time.Now()
+
+	rows := sqlmock.NewRows([]string{"id", "actor_id", "todo_id", "action", "changes", "created_at"}).
+		AddRow(uuid.New().String(), actorID, todoID, "completed", []byte(`[{"field":"completed","before":false,"after":true}]`), now).
+		AddRow(uuid.New().String(), actorID, todoID, "created", []byte(`[]`), now.Add(-time.Hour))
+
+	expectScope(mock, Scope{})
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, actor_id, todo_id, action, changes, created_at FROM activity_log WHERE todo_id = $1 ORDER BY created_at DESC`)).
+		WithArgs(todoID).
+		WillReturnRows(rows)
+	mock.ExpectCommit()
+
+	// Execute the function being tested
+	history, err := repo.GetTodoActivity(ctx, todoID)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+	assert.Equal(t, models.ActivityCompleted, history[0].Action)
+	assert.Equal(t, []models.FieldChange{{Field: "completed", Before: false, After: true}}, history[0].Changes)
+	assert.Equal(t, models.ActivityCreated, history[1].Action)
+	assert.Empty(t, history[1].Changes)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseActivityRepository_GetUserActivity(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseActivityRepository(mockDB)
+	ctx := context.Background()
+
+	actorID := uuid.New().String()
+
+	rows := sqlmock.NewRows([]string{"id", "actor_id", "todo_id", "action", "changes", "created_at"}).
+		AddRow(uuid.New().String(), actorID, uuid.New().String(), "deleted", []byte(`[]`), time.Now())
+
+	expectScope(mock, Scope{})
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, actor_id, todo_id, action, changes, created_at FROM activity_log WHERE actor_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3`)).
+		WithArgs(actorID, 10, 20).
+		WillReturnRows(rows)
+	mock.ExpectCommit()
+
+	// Execute the function being tested
+	feed, err := repo.GetUserActivity(ctx, actorID, 10, 20)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Len(t, feed, 1)
+	assert.Equal(t, models.ActivityDeleted, feed[0].Action)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseActivityRepository_DeleteUserActivity(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseActivityRepository(mockDB)
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+	expectScope(mock, Scope{})
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM activity_log WHERE actor_id = $1`)).
+		WithArgs(userID).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	// Execute the function being tested
+	err := repo.DeleteUserActivity(ctx, userID)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,134 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/starbops/gottodo/internal/models"
+)
+
+const appPasswordColumns = `id, user_id, name, password_hash, created_at`
+
+// SupabaseAppPasswordRepository is a PostgreSQL implementation of AppPasswordRepository using Supabase
+type SupabaseAppPasswordRepository struct {
+	scopedDB
+}
+
+// NewSupabaseAppPasswordRepository creates a new SupabaseAppPasswordRepository
+func NewSupabaseAppPasswordRepository(db *sql.DB) AppPasswordRepository {
+	return &SupabaseAppPasswordRepository{
+		scopedDB: scopedDB{db: db},
+	}
+}
+
+// scanAppPassword scans a row selected with appPasswordColumns
+func scanAppPassword(row interface{ Scan(...interface{}) error }) (*models.AppPassword, error) {
+	var password models.AppPassword
+	if err := row.Scan(&password.ID, &password.UserID, &password.Name, &password.Hash, &password.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &password, nil
+}
+
+// CreateAppPassword stores a new app password
+func (r *SupabaseAppPasswordRepository) CreateAppPassword(ctx context.Context, password *models.AppPassword) error {
+	query := `INSERT INTO app_passwords (` + appPasswordColumns + `) VALUES ($1, $2, $3, $4, $5)`
+	return r.inScope(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, query,
+			password.ID, password.UserID, password.Name, password.Hash, password.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert app password: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetAppPassword retrieves an app password by ID
+func (r *SupabaseAppPasswordRepository) GetAppPassword(ctx context.Context, id string) (*models.AppPassword, error) {
+	query := `SELECT ` + appPasswordColumns + ` FROM app_passwords WHERE id = $1`
+	return r.get(ctx, query, id)
+}
+
+// GetAppPasswordByHash looks up an app password by the hash of the password
+func (r *SupabaseAppPasswordRepository) GetAppPasswordByHash(ctx context.Context, hash string) (*models.AppPassword, error) {
+	query := `SELECT ` + appPasswordColumns + ` FROM app_passwords WHERE password_hash = $1`
+	return r.get(ctx, query, hash)
+}
+
+// get runs a query selecting a single app password
+func (r *SupabaseAppPasswordRepository) get(ctx context.Context, query string, arg string) (*models.AppPassword, error) {
+	var password *models.AppPassword
+	err := r.inScope(ctx, func(tx *sql.Tx) error {
+		var err error
+		password, err = scanAppPassword(tx.QueryRowContext(ctx, query, arg))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrAppPasswordNotFound
+			}
+			return fmt.Errorf("failed to scan app password: %w", err)
+		}
+		return nil
+	})
+	return password, err
+}
+
+// GetUserAppPasswords retrieves the app passwords of a user, oldest first
+func (r *SupabaseAppPasswordRepository) GetUserAppPasswords(ctx context.Context, userID string) ([]*models.AppPassword, error) {
+	query := `SELECT ` + appPasswordColumns + ` FROM app_passwords WHERE user_id = $1 ORDER BY created_at ASC`
+
+	var passwords []*models.AppPassword
+	err := r.inScope(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, query, userID)
+		if err != nil {
+			return fmt.Errorf("failed to query app passwords: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			password, err := scanAppPassword(rows)
+			if err != nil {
+				return fmt.Errorf("failed to scan app password: %w", err)
+			}
+			passwords = append(passwords, password)
+		}
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error iterating app password rows: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return passwords, nil
+}
+
+// DeleteAppPassword removes an app password
+func (r *SupabaseAppPasswordRepository) DeleteAppPassword(ctx context.Context, id string) error {
+	return r.inScope(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `DELETE FROM app_passwords WHERE id = $1`, id)
+		if err != nil {
+			return fmt.Errorf("failed to delete app password: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return ErrAppPasswordNotFound
+		}
+		return nil
+	})
+}
+
+// DeleteUserAppPasswords removes all app passwords of a user
+func (r *SupabaseAppPasswordRepository) DeleteUserAppPasswords(ctx context.Context, userID string) error {
+	return r.inScope(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM app_passwords WHERE user_id = $1`, userID); err != nil {
+			return fmt.Errorf("failed to delete app passwords: %w", err)
+		}
+		return nil
+	})
+}
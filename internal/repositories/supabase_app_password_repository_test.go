@@ -0,0 +1,143 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSupabaseAppPasswordRepository_CreateAppPassword(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseAppPasswordRepository(mockDB)
+	ctx := context.Background()
+
+	password, _ := models.NewAppPassword(uuid.New().String(), "Phone")
+
+	expectScope(mock, Scope{})
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO app_passwords (id, user_id, name, password_hash, created_at) VALUES ($1, $2, $3, $4, $5)`)).
+		WithArgs(password.ID, password.UserID, "Phone", password.Hash, password.CreatedAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	// Execute the function being tested
+	err := repo.CreateAppPassword(ctx, password)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseAppPasswordRepository_GetAppPasswordByHash(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseAppPasswordRepository(mockDB)
+	ctx := context.Background()
+
+	id := uuid.New().String()
+	userID := uuid.New().String()
+	now := time.Now()
+
+	rows := sqlmock.NewRows([]string{"id", "user_id", "name", "password_hash", "created_at"}).
+		AddRow(id, userID, "Phone", "abc123", now)
+	query := regexp.QuoteMeta(`SELECT id, user_id, name, password_hash, created_at FROM app_passwords WHERE password_hash = $1`)
+	expectScope(mock, Scope{})
+	mock.ExpectQuery(query).WithArgs("abc123").WillReturnRows(rows)
+	mock.ExpectCommit()
+	expectScope(mock, Scope{})
+	mock.ExpectQuery(query).WithArgs("unknown").WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	// Execute the function being tested
+	password, err := repo.GetAppPasswordByHash(ctx, "abc123")
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Equal(t, id, password.ID)
+	assert.Equal(t, userID, password.UserID)
+	assert.Equal(t, "Phone", password.Name)
+
+	_, err = repo.GetAppPasswordByHash(ctx, "unknown")
+	assert.Equal(t, ErrAppPasswordNotFound, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseAppPasswordRepository_GetUserAppPasswords(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseAppPasswordRepository(mockDB)
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+	now := time.Now()
+
+	rows := sqlmock.NewRows([]string{"id", "user_id", "name", "password_hash", "created_at"}).
+		AddRow(uuid.New().String(), userID, "Phone", "abc", now).
+		AddRow(uuid.New().String(), userID, "Laptop", "def", now.Add(time.Minute))
+	expectScope(mock, Scope{})
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, user_id, name, password_hash, created_at FROM app_passwords WHERE user_id = $1 ORDER BY created_at ASC`)).
+		WithArgs(userID).
+		WillReturnRows(rows)
+	mock.ExpectCommit()
+
+	// Execute the function being tested
+	passwords, err := repo.GetUserAppPasswords(ctx, userID)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Len(t, passwords, 2)
+	assert.Equal(t, "Laptop", passwords[1].Name)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseAppPasswordRepository_DeleteAppPassword(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseAppPasswordRepository(mockDB)
+	ctx := context.Background()
+
+	id := uuid.New().String()
+	query := regexp.QuoteMeta(`DELETE FROM app_passwords WHERE id = $1`)
+	expectScope(mock, Scope{})
+	mock.ExpectExec(query).WithArgs(id).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectScope(mock, Scope{})
+	mock.ExpectExec(query).WithArgs(id).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	// Execute the function being tested
+	err := repo.DeleteAppPassword(ctx, id)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Equal(t, ErrAppPasswordNotFound, repo.DeleteAppPassword(ctx, id))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseAppPasswordRepository_DeleteUserAppPasswords(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseAppPasswordRepository(mockDB)
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+	expectScope(mock, Scope{})
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM app_passwords WHERE user_id = $1`)).
+		WithArgs(userID).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	// Execute the function being tested
+	err := repo.DeleteUserAppPasswords(ctx, userID)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
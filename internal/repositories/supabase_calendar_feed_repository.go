@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/starbops/gottodo/internal/models"
+)
+
+// SupabaseCalendarFeedRepository is a PostgreSQL implementation of CalendarFeedRepository using Supabase
+type SupabaseCalendarFeedRepository struct {
+	scopedDB
+}
+
+// NewSupabaseCalendarFeedRepository creates a new SupabaseCalendarFeedRepository
+func NewSupabaseCalendarFeedRepository(db *sql.DB) CalendarFeedRepository {
+	return &SupabaseCalendarFeedRepository{
+		scopedDB: scopedDB{db: db},
+	}
+}
+
+// GetUserCalendarFeed retrieves the current calendar feed of a user
+func (r *SupabaseCalendarFeedRepository) GetUserCalendarFeed(ctx context.Context, userID string) (*models.CalendarFeed, error) {
+	query := `SELECT user_id, secret, created_at FROM calendar_feeds WHERE user_id = $1`
+	return r.get(ctx, query, userID)
+}
+
+// GetCalendarFeed looks up a feed secret to find the user it belongs to
+func (r *SupabaseCalendarFeedRepository) GetCalendarFeed(ctx context.Context, secret string) (*models.CalendarFeed, error) {
+	query := `SELECT user_id, secret, created_at FROM calendar_feeds WHERE secret = $1`
+	return r.get(ctx, query, secret)
+}
+
+// SaveCalendarFeed stores a user's calendar feed, replacing the previous one
+func (r *SupabaseCalendarFeedRepository) SaveCalendarFeed(ctx context.Context, feed *models.CalendarFeed) error {
+	query := `INSERT INTO calendar_feeds (user_id, secret, created_at) VALUES ($1, $2, $3) ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, created_at = EXCLUDED.created_at`
+	return r.inScope(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, query, feed.UserID, feed.Secret, feed.CreatedAt); err != nil {
+			return fmt.Errorf("failed to save calendar feed: %w", err)
+		}
+		return nil
+	})
+}
+
+// get runs a query selecting a single calendar feed
+func (r *SupabaseCalendarFeedRepository) get(ctx context.Context, query string, arg string) (*models.CalendarFeed, error) {
+	var feed models.CalendarFeed
+	err := r.inScope(ctx, func(tx *sql.Tx) error {
+		if err := tx.QueryRowContext(ctx, query, arg).Scan(&feed.UserID, &feed.Secret, &feed.CreatedAt); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrCalendarFeedNotFound
+			}
+			return fmt.Errorf("failed to scan calendar feed: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &feed, nil
+}
+
+// DeleteUserCalendarFeed removes the calendar feed of a user, if any
+func (r *SupabaseCalendarFeedRepository) DeleteUserCalendarFeed(ctx context.Context, userID string) error {
+	return r.inScope(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM calendar_feeds WHERE user_id = $1`, userID); err != nil {
+			return fmt.Errorf("failed to delete calendar feed: %w", err)
+		}
+		return nil
+	})
+}
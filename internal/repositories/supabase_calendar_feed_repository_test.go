@@ -0,0 +1,87 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSupabaseCalendarFeedRepository_SaveCalendarFeed(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseCalendarFeedRepository(mockDB)
+	ctx := context.Background()
+
+	secret := models.NewCalendarFeed(uuid.New().String())
+
+	expectScope(mock, Scope{})
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO calendar_feeds (user_id, secret, created_at) VALUES ($1, $2, $3) ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, created_at = EXCLUDED.created_at`)).
+		WithArgs(secret.UserID, secret.Secret, secret.CreatedAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	// Execute the function being tested
+	err := repo.SaveCalendarFeed(ctx, secret)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseCalendarFeedRepository_GetCalendarFeed(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseCalendarFeedRepository(mockDB)
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+	now := time.Now()
+
+	rows := sqlmock.NewRows([]string{"user_id", "secret", "created_at"}).AddRow(userID, "cal_test", now)
+	query := regexp.QuoteMeta(`SELECT user_id, secret, created_at FROM calendar_feeds WHERE secret = $1`)
+	expectScope(mock, Scope{})
+	mock.ExpectQuery(query).WithArgs("cal_test").WillReturnRows(rows)
+	mock.ExpectCommit()
+	expectScope(mock, Scope{})
+	mock.ExpectQuery(query).WithArgs("cal_unknown").WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	// Execute the function being tested
+	secret, err := repo.GetCalendarFeed(ctx, "cal_test")
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Equal(t, userID, secret.UserID)
+
+	_, err = repo.GetCalendarFeed(ctx, "cal_unknown")
+	assert.Equal(t, ErrCalendarFeedNotFound, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseCalendarFeedRepository_DeleteUserCalendarFeed(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseCalendarFeedRepository(mockDB)
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+	expectScope(mock, Scope{})
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM calendar_feeds WHERE user_id = $1`)).
+		WithArgs(userID).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	// Execute the function being tested
+	err := repo.DeleteUserCalendarFeed(ctx, userID)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
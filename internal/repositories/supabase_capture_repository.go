@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/starbops/gottodo/internal/models"
+)
+
+// SupabaseCaptureRepository is a PostgreSQL implementation of CaptureRepository using Supabase
+type SupabaseCaptureRepository struct {
+	scopedDB
+}
+
+// NewSupabaseCaptureRepository creates a new SupabaseCaptureRepository
+func NewSupabaseCaptureRepository(db *sql.DB) CaptureRepository {
+	return &SupabaseCaptureRepository{
+		scopedDB: scopedDB{db: db},
+	}
+}
+
+// GetUserCaptureSecret retrieves the current capture secret of a user
+func (r *SupabaseCaptureRepository) GetUserCaptureSecret(ctx context.Context, userID string) (*models.CaptureSecret, error) {
+	query := `SELECT user_id, secret, created_at FROM capture_secrets WHERE user_id = $1`
+	return r.get(ctx, query, userID)
+}
+
+// GetCaptureSecret looks up a capture secret to find the user it belongs to
+func (r *SupabaseCaptureRepository) GetCaptureSecret(ctx context.Context, secret string) (*models.CaptureSecret, error) {
+	query := `SELECT user_id, secret, created_at FROM capture_secrets WHERE secret = $1`
+	return r.get(ctx, query, secret)
+}
+
+// SaveCaptureSecret stores a user's capture secret, replacing the previous one
+func (r *SupabaseCaptureRepository) SaveCaptureSecret(ctx context.Context, secret *models.CaptureSecret) error {
+	query := `INSERT INTO capture_secrets (user_id, secret, created_at) VALUES ($1, $2, $3) ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, created_at = EXCLUDED.created_at`
+	return r.inScope(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, query, secret.UserID, secret.Secret, secret.CreatedAt); err != nil {
+			return fmt.Errorf("failed to save capture secret: %w", err)
+		}
+		return nil
+	})
+}
+
+// get runs a query selecting a single capture secret
+func (r *SupabaseCaptureRepository) get(ctx context.Context, query string, arg string) (*models.CaptureSecret, error) {
+	var secret models.CaptureSecret
+	err := r.inScope(ctx, func(tx *sql.Tx) error {
+		if err := tx.QueryRowContext(ctx, query, arg).Scan(&secret.UserID, &secret.Secret, &secret.CreatedAt); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrCaptureSecretNotFound
+			}
+			return fmt.Errorf("failed to scan capture secret: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &secret, nil
+}
+
+// DeleteUserCaptureSecret removes the capture secret of a user, if any
+func (r *SupabaseCaptureRepository) DeleteUserCaptureSecret(ctx context.Context, userID string) error {
+	return r.inScope(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM capture_secrets WHERE user_id = $1`, userID); err != nil {
+			return fmt.Errorf("failed to delete capture secret: %w", err)
+		}
+		return nil
+	})
+}
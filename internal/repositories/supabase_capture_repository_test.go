@@ -0,0 +1,87 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSupabaseCaptureRepository_SaveCaptureSecret(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseCaptureRepository(mockDB)
+	ctx := context.Background()
+
+	secret := models.NewCaptureSecret(uuid.New().String())
+
+	expectScope(mock, Scope{})
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO capture_secrets (user_id, secret, created_at) VALUES ($1, $2, $3) ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, created_at = EXCLUDED.created_at`)).
+		WithArgs(secret.UserID, secret.Secret, secret.CreatedAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	// Execute the function being tested
+	err := repo.SaveCaptureSecret(ctx, secret)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseCaptureRepository_GetCaptureSecret(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseCaptureRepository(mockDB)
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+	now := time.Now()
+
+	rows := sqlmock.NewRows([]string{"user_id", "secret", "created_at"}).AddRow(userID, "cap_test", now)
+	query := regexp.QuoteMeta(`SELECT user_id, secret, created_at FROM capture_secrets WHERE secret = $1`)
+	expectScope(mock, Scope{})
+	mock.ExpectQuery(query).WithArgs("cap_test").WillReturnRows(rows)
+	mock.ExpectCommit()
+	expectScope(mock, Scope{})
+	mock.ExpectQuery(query).WithArgs("cap_unknown").WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	// Execute the function being tested
+	secret, err := repo.GetCaptureSecret(ctx, "cap_test")
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Equal(t, userID, secret.UserID)
+
+	_, err = repo.GetCaptureSecret(ctx, "cap_unknown")
+	assert.Equal(t, ErrCaptureSecretNotFound, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseCaptureRepository_DeleteUserCaptureSecret(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseCaptureRepository(mockDB)
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+	expectScope(mock, Scope{})
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM capture_secrets WHERE user_id = $1`)).
+		WithArgs(userID).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	// Execute the function being tested
+	err := repo.DeleteUserCaptureSecret(ctx, userID)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
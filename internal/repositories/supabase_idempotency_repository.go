@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/starbops/gottodo/internal/models"
+)
+
+// SupabaseIdempotencyRepository is a PostgreSQL implementation of IdempotencyRepository using Supabase
+type SupabaseIdempotencyRepository struct {
+	scopedDB
+}
+
+// NewSupabaseIdempotencyRepository creates a new SupabaseIdempotencyRepository
+func NewSupabaseIdempotencyRepository(db *sql.DB) IdempotencyRepository {
+	return &SupabaseIdempotencyRepository{
+		scopedDB: scopedDB{db: db},
+	}
+}
+
+// GetIdempotencyRecord retrieves the unexpired record of a user's key
+func (r *SupabaseIdempotencyRepository) GetIdempotencyRecord(ctx context.Context, userID, key string) (*models.IdempotencyRecord, error) {
+	query := `SELECT key, user_id, request_hash, status_code, content_type, body, created_at, expires_at FROM idempotency_keys WHERE user_id = $1 AND key = $2 AND expires_at > $3`
+
+	var record models.IdempotencyRecord
+	err := r.inScope(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, query, userID, key, time.Now()).Scan(
+			&record.Key, &record.UserID, &record.RequestHash, &record.StatusCode,
+			&record.ContentType, &record.Body, &record.CreatedAt, &record.ExpiresAt)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrIdempotencyRecordNotFound
+			}
+			return fmt.Errorf("failed to scan idempotency record: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+// deleteExpiredIdempotencyRecords discards expired records
+func deleteExpiredIdempotencyRecords(ctx context.Context, tx *sql.Tx) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at < $1`, time.Now()); err != nil {
+		return fmt.Errorf("failed to delete expired idempotency records: %w", err)
+	}
+	return nil
+}
+
+// ReserveIdempotencyRecord stores a pending record unless the key is already taken
+func (r *SupabaseIdempotencyRepository) ReserveIdempotencyRecord(ctx context.Context, record *models.IdempotencyRecord) (bool, error) {
+	// The primary key decides between concurrent requests, even across servers
+	query := `INSERT INTO idempotency_keys (key, user_id, request_hash, status_code, content_type, body, created_at, expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) ON CONFLICT (user_id, key) DO NOTHING`
+
+	reserved := false
+	err := r.inScope(ctx, func(tx *sql.Tx) error {
+		if err := deleteExpiredIdempotencyRecords(ctx, tx); err != nil {
+			return err
+		}
+
+		result, err := tx.ExecContext(ctx, query,
+			record.Key, record.UserID, record.RequestHash, record.StatusCode,
+			record.ContentType, record.Body, record.CreatedAt, record.ExpiresAt)
+		if err != nil {
+			return fmt.Errorf("failed to reserve idempotency key: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		reserved = rowsAffected == 1
+		return nil
+	})
+	return reserved, err
+}
+
+// SaveIdempotencyRecord stores a record unless the key is already taken by
+// another request or by a response
+func (r *SupabaseIdempotencyRepository) SaveIdempotencyRecord(ctx context.Context, record *models.IdempotencyRecord) error {
+	// Only the pending reservation of the same request is replaced
+	query := `INSERT INTO idempotency_keys (key, user_id, request_hash, status_code, content_type, body, created_at, expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) ` +
+		`ON CONFLICT (user_id, key) DO UPDATE SET status_code = EXCLUDED.status_code, content_type = EXCLUDED.content_type, body = EXCLUDED.body, created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at ` +
+		`WHERE idempotency_keys.status_code = 0 AND idempotency_keys.request_hash = EXCLUDED.request_hash`
+	return r.inScope(ctx, func(tx *sql.Tx) error {
+		if err := deleteExpiredIdempotencyRecords(ctx, tx); err != nil {
+			return err
+		}
+
+		_, err := tx.ExecContext(ctx, query,
+			record.Key, record.UserID, record.RequestHash, record.StatusCode,
+			record.ContentType, record.Body, record.CreatedAt, record.ExpiresAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert idempotency record: %w", err)
+		}
+		return nil
+	})
+}
+
+// DeletePendingIdempotencyRecord releases a key whose request has no response worth remembering
+func (r *SupabaseIdempotencyRepository) DeletePendingIdempotencyRecord(ctx context.Context, userID, key string) error {
+	return r.inScope(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2 AND status_code = 0`, userID, key); err != nil {
+			return fmt.Errorf("failed to release idempotency key: %w", err)
+		}
+		return nil
+	})
+}
+
+// DeleteUserIdempotencyRecords removes all records of a user
+func (r *SupabaseIdempotencyRepository) DeleteUserIdempotencyRecords(ctx context.Context, userID string) error {
+	return r.inScope(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE user_id = $1`, userID); err != nil {
+			return fmt.Errorf("failed to delete idempotency records: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,150 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSupabaseIdempotencyRepository_SaveIdempotencyRecord(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseIdempotencyRepository(mockDB)
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+	record := models.NewIdempotencyRecord(userID, "key-1", "hash", 201, "application/json", []byte(`{}`), time.Hour)
+
+	expectScope(mock, Scope{})
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM idempotency_keys WHERE expires_at < $1`)).
+		WithArgs(sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO idempotency_keys (key, user_id, request_hash, status_code, content_type, body, created_at, expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) `+
+		`ON CONFLICT (user_id, key) DO UPDATE SET status_code = EXCLUDED.status_code, content_type = EXCLUDED.content_type, body = EXCLUDED.body, created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at `+
+		`WHERE idempotency_keys.status_code = 0 AND idempotency_keys.request_hash = EXCLUDED.request_hash`)).
+		WithArgs("key-1", userID, "hash", 201, "application/json", []byte(`{}`), record.CreatedAt, record.ExpiresAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	// Execute the function being tested
+	err := repo.SaveIdempotencyRecord(ctx, record)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseIdempotencyRepository_ReserveIdempotencyRecord(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseIdempotencyRepository(mockDB)
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+	record := models.NewPendingIdempotencyRecord(userID, "key-1", "hash", time.Hour)
+
+	insert := regexp.QuoteMeta(`INSERT INTO idempotency_keys (key, user_id, request_hash, status_code, content_type, body, created_at, expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) ON CONFLICT (user_id, key) DO NOTHING`)
+	for _, rows := range []int64{1, 0} {
+		expectScope(mock, Scope{})
+		mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM idempotency_keys WHERE expires_at < $1`)).
+			WithArgs(sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(insert).
+			WithArgs("key-1", userID, "hash", 0, "", []byte{}, record.CreatedAt, record.ExpiresAt).
+			WillReturnResult(sqlmock.NewResult(0, rows))
+		mock.ExpectCommit()
+	}
+
+	// Execute the function being tested
+	first, err := repo.ReserveIdempotencyRecord(ctx, record)
+	assert.NoError(t, err)
+	second, err := repo.ReserveIdempotencyRecord(ctx, record)
+	assert.NoError(t, err)
+
+	// Assertions
+	assert.True(t, first)
+	assert.False(t, second)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseIdempotencyRepository_DeletePendingIdempotencyRecord(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseIdempotencyRepository(mockDB)
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+	expectScope(mock, Scope{})
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2 AND status_code = 0`)).
+		WithArgs(userID, "key-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// Execute the function being tested
+	err := repo.DeletePendingIdempotencyRecord(ctx, userID, "key-1")
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseIdempotencyRepository_GetIdempotencyRecord(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseIdempotencyRepository(mockDB)
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+	now := time.Now()
+
+	rows := sqlmock.NewRows([]string{"key", "user_id", "request_hash", "status_code", "content_type", "body", "created_at", "expires_at"}).
+		AddRow("key-1", userID, "hash", 201, "application/json", []byte(`{}`), now, now.Add(time.Hour))
+
+	query := regexp.QuoteMeta(`SELECT key, user_id, request_hash, status_code, content_type, body, created_at, expires_at FROM idempotency_keys WHERE user_id = $1 AND key = $2 AND expires_at > $3`)
+	expectScope(mock, Scope{})
+	mock.ExpectQuery(query).WithArgs(userID, "key-1", sqlmock.AnyArg()).WillReturnRows(rows)
+	mock.ExpectCommit()
+	expectScope(mock, Scope{})
+	mock.ExpectQuery(query).WithArgs(userID, "key-2", sqlmock.AnyArg()).WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	// Execute the function being tested
+	record, err := repo.GetIdempotencyRecord(ctx, userID, "key-1")
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Equal(t, "hash", record.RequestHash)
+	assert.Equal(t, 201, record.StatusCode)
+
+	_, err = repo.GetIdempotencyRecord(ctx, userID, "key-2")
+	assert.Equal(t, ErrIdempotencyRecordNotFound, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseIdempotencyRepository_DeleteUserIdempotencyRecords(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseIdempotencyRepository(mockDB)
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+	expectScope(mock, Scope{})
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM idempotency_keys WHERE user_id = $1`)).
+		WithArgs(userID).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	// Execute the function being tested
+	err := repo.DeleteUserIdempotencyRecords(ctx, userID)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -3,31 +3,43 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/starbops/gottodo/internal/models"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
+// TodoChangesChannel is the Postgres notification channel the todos table
+// announces its changes on (see migrations/007_notify_todo_changes.up.sql)
+const TodoChangesChannel = "todo_events"
+
+// uniqueViolation is the Postgres error code of a duplicate key
+const uniqueViolation = "23505"
+
+// todoColumns are the columns of a todo in the order scanTodo reads them
+const todoColumns = `id, title, description, user_id, completed, version, created_at, updated_at, deleted_at, due_at, priority, project, tags, recurrence`
+
 // SupabaseTodoRepository is a PostgreSQL implementation of TodoRepository using Supabase
 type SupabaseTodoRepository struct {
-	db *sql.DB
+	scopedDB
 }
 
 // NewSupabaseTodoRepository creates a new SupabaseTodoRepository
 func NewSupabaseTodoRepository(db *sql.DB) TodoRepository {
 	return &SupabaseTodoRepository{
-		db: db,
+		scopedDB: scopedDB{db: db},
 	}
 }
 
 // GetUserTodos retrieves all todos for a specific user
 func (r *SupabaseTodoRepository) GetUserTodos(ctx context.Context, userID string) ([]*models.Todo, error) {
-	query := `SELECT id, title, description, user_id, completed FROM todos WHERE user_id = $1`
+	query := `SELECT ` + todoColumns + ` FROM todos WHERE user_id = $1 AND deleted_at IS NULL ORDER BY created_at, id`
 
 	// Parse userID into UUID
 	uid, err := uuid.Parse(userID)
@@ -35,343 +47,549 @@ func (r *SupabaseTodoRepository) GetUserTodos(ctx context.Context, userID string
 		return nil, fmt.Errorf("invalid user ID format: %w", err)
 	}
 
-	rows, err := r.db.QueryContext(ctx, query, uid)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query todos: %w", err)
-	}
-	defer rows.Close()
-
 	var todos []*models.Todo
-	for rows.Next() {
-		var todo models.Todo
-		if err := rows.Scan(&todo.ID, &todo.Title, &todo.Description, &todo.UserID, &todo.Completed); err != nil {
-			return nil, fmt.Errorf("failed to scan todo row: %w", err)
+	err = r.inScope(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, query, uid)
+		if err != nil {
+			return fmt.Errorf("failed to query todos: %w", err)
 		}
-		todos = append(todos, &todo)
-	}
+		defer rows.Close()
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error during rows iteration: %w", err)
+		todos, err = scanTodoRows(rows)
+		return err
+	})
+	return todos, err
+}
+
+// StreamUserTodos calls fn with each todo of a user as it is read from the
+// database, oldest first
+func (r *SupabaseTodoRepository) StreamUserTodos(ctx context.Context, userID string, fn func(todo *models.Todo) error) error {
+	query := `SELECT ` + todoColumns + ` FROM todos WHERE user_id = $1 ORDER BY created_at, id`
+
+	// Parse userID into UUID
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return fmt.Errorf("invalid user ID format: %w", err)
 	}
 
-	return todos, nil
+	return r.inScope(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, query, uid)
+		if err != nil {
+			return fmt.Errorf("failed to query todos: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			todo, err := scanTodo(rows)
+			if err != nil {
+				return fmt.Errorf("failed to scan todo row: %w", err)
+			}
+			if err := fn(todo); err != nil {
+				return err
+			}
+		}
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error during rows iteration: %w", err)
+		}
+		return nil
+	})
 }
 
 // GetTodo retrieves a specific todo by ID
 func (r *SupabaseTodoRepository) GetTodo(ctx context.Context, todoID string) (*models.Todo, error) {
-	query := `SELECT id, title, description, user_id, completed FROM todos WHERE id = $1`
-
-	row := r.db.QueryRowContext(ctx, query, todoID)
+	query := `SELECT ` + todoColumns + ` FROM todos WHERE id = $1`
 
-	var todo models.Todo
-	if err := row.Scan(&todo.ID, &todo.Title, &todo.Description, &todo.UserID, &todo.Completed); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, ErrTodoNotFound
+	var todo *models.Todo
+	err := r.inScope(ctx, func(tx *sql.Tx) error {
+		var err error
+		todo, err = scanTodo(tx.QueryRowContext(ctx, query, todoID))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrTodoNotFound
+			}
+			return fmt.Errorf("failed to scan todo: %w", err)
 		}
-		return nil, fmt.Errorf("failed to scan todo: %w", err)
-	}
+		return nil
+	})
+	return todo, err
+}
 
-	return &todo, nil
+// execer runs statements on a database or within a transaction
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
 }
 
 // CreateTodo creates a new todo
 func (r *SupabaseTodoRepository) CreateTodo(ctx context.Context, todo *models.Todo) error {
-	query := `INSERT INTO todos (id, title, description, user_id, completed, created_at, updated_at) 
-              VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	return r.inScope(ctx, func(tx *sql.Tx) error {
+		return insertTodo(ctx, tx, todo)
+	})
+}
+
+// CreateTodos creates several todos within a single transaction
+func (r *SupabaseTodoRepository) CreateTodos(ctx context.Context, todos []*models.Todo) error {
+	return r.inScope(ctx, func(tx *sql.Tx) error {
+		for _, todo := range todos {
+			if err := insertTodo(ctx, tx, todo); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// insertTodo inserts a new todo at version 1
+func insertTodo(ctx context.Context, db execer, todo *models.Todo) error {
+	query := `INSERT INTO todos (id, title, description, user_id, completed, version, created_at, updated_at, due_at, priority, project, tags, recurrence) 
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
 
 	// Generate UUID if not provided
 	if todo.ID == "" {
 		todo.ID = uuid.New().String()
 	}
 
-	// Ensure timestamps are set
-	now := time.Now()
+	// Ensure timestamps are set, equal for a new todo as in models.NewTodo
 	if todo.CreatedAt.IsZero() {
-		todo.CreatedAt = now
+		todo.CreatedAt = time.Now()
 	}
 	if todo.UpdatedAt.IsZero() {
-		todo.UpdatedAt = now
+		todo.UpdatedAt = todo.CreatedAt
 	}
 
+	// Every todo starts at the first version
+	todo.Version = 1
+
 	// Parse userID into UUID
 	uid, err := uuid.Parse(todo.UserID)
 	if err != nil {
 		return fmt.Errorf("invalid user ID format: %w", err)
 	}
 
-	_, err = r.db.ExecContext(ctx, query,
-		todo.ID, todo.Title, todo.Description, uid, todo.Completed,
-		todo.CreatedAt, todo.UpdatedAt)
+	_, err = db.ExecContext(ctx, query,
+		todo.ID, todo.Title, todo.Description, uid, todo.Completed, todo.Version,
+		todo.CreatedAt, todo.UpdatedAt, todo.DueAt, todo.Priority, todo.Project,
+		tagsArray(todo.Tags), todo.Recurrence)
 	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return ErrTodoExists
+		}
 		return fmt.Errorf("failed to insert todo: %w", err)
 	}
 
 	return nil
 }
 
-// UpdateTodo updates an existing todo
+// UpdateTodo updates an existing todo if its version matches the stored one
 func (r *SupabaseTodoRepository) UpdateTodo(ctx context.Context, todo *models.Todo) error {
-	query := `UPDATE todos SET title = $1, description = $2, completed = $3, updated_at = $4 WHERE id = $5`
+	query := `UPDATE todos SET title = $1, description = $2, completed = $3, due_at = $4, priority = $5, project = $6, tags = $7, recurrence = $8, updated_at = $9, version = version + 1 WHERE id = $10 AND version = $11`
 
 	// Ensure updated_at is set
 	if todo.UpdatedAt.IsZero() {
 		todo.UpdatedAt = time.Now()
 	}
 
-	result, err := r.db.ExecContext(ctx, query,
-		todo.Title, todo.Description, todo.Completed, todo.UpdatedAt, todo.ID)
+	err := r.inScope(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, query,
+			todo.Title, todo.Description, todo.Completed, todo.DueAt, todo.Priority, todo.Project,
+			tagsArray(todo.Tags), todo.Recurrence, todo.UpdatedAt, todo.ID, todo.Version)
+		if err != nil {
+			return fmt.Errorf("failed to update todo: %w", err)
+		}
+		return checkVersionedWrite(ctx, tx, result, todoExistsQuery, todo.ID)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to update todo: %w", err)
+		return err
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+	todo.Version++
+	return nil
+}
+
+// PatchTodo writes only the named columns of an existing todo
+func (r *SupabaseTodoRepository) PatchTodo(ctx context.Context, todo *models.Todo, fields []string) error {
+	// Ensure updated_at is set
+	if todo.UpdatedAt.IsZero() {
+		todo.UpdatedAt = time.Now()
 	}
 
-	if rowsAffected == 0 {
-		return ErrTodoNotFound
+	// Build the SET clause from a fixed column list, never from input
+	var assignments []string
+	var args []interface{}
+	for _, field := range fields {
+		var value interface{}
+		switch field {
+		case models.FieldTitle:
+			value = todo.Title
+		case models.FieldDescription:
+			value = todo.Description
+		case models.FieldCompleted:
+			value = todo.Completed
+		case models.FieldDueAt:
+			value = todo.DueAt
+		case models.FieldPriority:
+			value = string(todo.Priority)
+		case models.FieldProject:
+			value = todo.Project
+		case models.FieldTags:
+			value = tagsArray(todo.Tags)
+		case models.FieldRecurrence:
+			value = todo.Recurrence
+		default:
+			return fmt.Errorf("unknown todo field %q", field)
+		}
+		args = append(args, value)
+		assignments = append(assignments, fmt.Sprintf("%s = $%d", field, len(args)))
 	}
+	args = append(args, todo.UpdatedAt)
+	assignments = append(assignments, fmt.Sprintf("updated_at = $%d", len(args)))
+
+	query := fmt.Sprintf(`UPDATE todos SET %s, version = version + 1 WHERE id = $%d AND version = $%d`,
+		strings.Join(assignments, ", "), len(args)+1, len(args)+2)
+	args = append(args, todo.ID, todo.Version)
 
+	err := r.inScope(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("failed to patch todo: %w", err)
+		}
+		return checkVersionedWrite(ctx, tx, result, todoExistsQuery, todo.ID)
+	})
+	if err != nil {
+		return err
+	}
+
+	todo.Version++
 	return nil
 }
 
-// DeleteTodo deletes a todo by ID
-func (r *SupabaseTodoRepository) DeleteTodo(ctx context.Context, todoID string) error {
-	query := `DELETE FROM todos WHERE id = $1`
+const (
+	// todoExistsQuery asks whether a todo exists
+	todoExistsQuery = `SELECT EXISTS (SELECT 1 FROM todos WHERE id = $1)`
 
-	result, err := r.db.ExecContext(ctx, query, todoID)
-	if err != nil {
-		return fmt.Errorf("failed to delete todo: %w", err)
-	}
+	// liveTodoExistsQuery asks whether a todo exists outside of the trash
+	liveTodoExistsQuery = `SELECT EXISTS (SELECT 1 FROM todos WHERE id = $1 AND deleted_at IS NULL)`
+)
 
+// checkVersionedWrite tells a missing todo apart from a stale version when
+// an update guarded by the version changed no row, using existsQuery to
+// look for the todo
+func checkVersionedWrite(ctx context.Context, tx *sql.Tx, result sql.Result, existsQuery string, todoID string) error {
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return fmt.Errorf("failed to get rows affected: %w", err)
 	}
-
-	if rowsAffected == 0 {
-		return ErrTodoNotFound
+	if rowsAffected > 0 {
+		return nil
 	}
 
-	return nil
+	var exists bool
+	if err := tx.QueryRowContext(ctx, existsQuery, todoID).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check todo existence: %w", err)
+	}
+	if exists {
+		return ErrConflict
+	}
+	return ErrTodoNotFound
 }
 
-// Create stores a new todo in Supabase
-func (r *SupabaseTodoRepository) Create(ctx context.Context, todo *models.Todo) error {
-	// Debug: Log the todo object values
-	fmt.Printf("Creating todo: ID=%s, UserID=%s, Title='%s', Description='%s', Completed=%v\n",
-		todo.ID, todo.UserID, todo.Title, todo.Description, todo.Completed)
+// UpdateTodos applies update to several todos within a single transaction
+func (r *SupabaseTodoRepository) UpdateTodos(ctx context.Context, todoIDs []string, update BulkUpdateFunc) ([]BulkResult, error) {
+	var results []BulkResult
+	err := r.inScope(ctx, func(tx *sql.Tx) error {
+		// Lock the affected rows for the rest of the transaction
+		rows, err := tx.QueryContext(ctx, `SELECT `+todoColumns+` FROM todos WHERE id = ANY($1) FOR UPDATE`, pq.Array(todoIDs))
+		if err != nil {
+			return fmt.Errorf("failed to lock todos: %w", err)
+		}
+		locked, err := scanTodoRows(rows)
+		rows.Close()
+		if err != nil {
+			return err
+		}
 
-	// Validate inputs
-	if todo.Title == "" {
-		return fmt.Errorf("todo title cannot be empty")
-	}
+		current := make(map[string]*models.Todo, len(locked))
+		for _, todo := range locked {
+			current[todo.ID] = todo
+		}
 
-	// Parse user ID as UUID
-	userID, err := uuid.Parse(todo.UserID)
+		query := `UPDATE todos SET title = $1, description = $2, completed = $3, deleted_at = $4, due_at = $5, priority = $6, project = $7, tags = $8, recurrence = $9, updated_at = $10, version = version + 1 WHERE id = $11`
+
+		results = make([]BulkResult, len(todoIDs))
+		for i, todoID := range todoIDs {
+			results[i].TodoID = todoID
+
+			stored, exists := current[todoID]
+			if !exists {
+				results[i].Err = ErrTodoNotFound
+				continue
+			}
+
+			todo := *stored
+			if err := update(&todo); err != nil {
+				results[i].Err = err
+				continue
+			}
+			todo.UpdatedAt = time.Now()
+
+			if _, err := tx.ExecContext(ctx, query,
+				todo.Title, todo.Description, todo.Completed, todo.DeletedAt, todo.DueAt, todo.Priority, todo.Project,
+				tagsArray(todo.Tags), todo.Recurrence, todo.UpdatedAt, todo.ID); err != nil {
+				return fmt.Errorf("failed to update todo %s: %w", todo.ID, err)
+			}
+			todo.Version++
+
+			before := *stored
+			results[i].Before = &before
+			results[i].Todo = &todo
+			current[todoID] = &todo
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("invalid user ID: %w", err)
+		return nil, err
 	}
+	return results, nil
+}
 
-	// Parse todo ID as UUID
-	todoID, err := uuid.Parse(todo.ID)
-	if err != nil {
-		return fmt.Errorf("invalid todo ID: %w", err)
-	}
-
-	// Debug log the query parameters
-	fmt.Printf("SQL Query params: todoID=%v, userID=%v, title='%s', description='%s'\n",
-		todoID, userID, todo.Title, todo.Description)
-
-	query := `
-		INSERT INTO todos (id, user_id, title, description, completed, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		RETURNING id
-	`
-
-	// Use plain SQL query to debug
-	var returnedID uuid.UUID
-	err = r.db.QueryRowContext(
-		ctx,
-		query,
-		todoID,           // $1: todo ID (UUID)
-		userID,           // $2: user ID (UUID)
-		todo.Title,       // $3: title (string)
-		todo.Description, // $4: description (string)
-		todo.Completed,   // $5: completed (bool)
-		todo.CreatedAt,   // $6: created_at (time)
-		todo.UpdatedAt,   // $7: updated_at (time)
-	).Scan(&returnedID)
+// DeleteTodo moves a todo to the trash by ID unless its version changed
+func (r *SupabaseTodoRepository) DeleteTodo(ctx context.Context, todoID string, version int) error {
+	query := `UPDATE todos SET deleted_at = $1, version = version + 1 WHERE id = $2 AND version = $3 AND deleted_at IS NULL`
 
-	if err != nil {
-		return fmt.Errorf("failed to create todo: %w", err)
-	}
+	return r.inScope(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, query, time.Now(), todoID, version)
+		if err != nil {
+			return fmt.Errorf("failed to delete todo: %w", err)
+		}
+		return checkVersionedWrite(ctx, tx, result, liveTodoExistsQuery, todoID)
+	})
+}
 
-	// Debug: Log the result
-	fmt.Printf("Todo created successfully with ID: %s\n", returnedID)
+// GetTrashedTodos retrieves the todos a user has moved to the trash
+func (r *SupabaseTodoRepository) GetTrashedTodos(ctx context.Context, userID string) ([]*models.Todo, error) {
+	query := `SELECT ` + todoColumns + ` FROM todos WHERE user_id = $1 AND deleted_at IS NOT NULL ORDER BY deleted_at DESC, id`
 
-	// Double-check by fetching the new todo
-	newTodo, err := r.GetTodo(ctx, returnedID.String())
+	// Parse userID into UUID
+	uid, err := uuid.Parse(userID)
 	if err != nil {
-		fmt.Printf("Warning: Todo created but couldn't be retrieved: %v\n", err)
-	} else {
-		fmt.Printf("Retrieved new todo: Title='%s', Description='%s'\n",
-			newTodo.Title, newTodo.Description)
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
 	}
 
-	return nil
+	var todos []*models.Todo
+	err = r.inScope(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, query, uid)
+		if err != nil {
+			return fmt.Errorf("failed to query trashed todos: %w", err)
+		}
+		defer rows.Close()
+
+		todos, err = scanTodoRows(rows)
+		return err
+	})
+	return todos, err
 }
 
-// GetByID retrieves a todo by ID from Supabase
-func (r *SupabaseTodoRepository) GetByID(ctx context.Context, id string) (*models.Todo, error) {
-	// Parse todo ID as UUID
-	todoID, err := uuid.Parse(id)
-	if err != nil {
-		return nil, fmt.Errorf("invalid todo ID: %w", err)
-	}
-
-	query := `
-		SELECT id, user_id, title, description, completed, created_at, updated_at
-		FROM todos
-		WHERE id = $1
-	`
-	row := r.db.QueryRowContext(ctx, query, todoID)
-
-	var dbTodoID, dbUserID uuid.UUID
-	todo := &models.Todo{}
-	err = row.Scan(
-		&dbTodoID,
-		&dbUserID,
-		&todo.Title,
-		&todo.Description,
-		&todo.Completed,
-		&todo.CreatedAt,
-		&todo.UpdatedAt,
-	)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, ErrTodoNotFound
+// RestoreTodo takes a todo back out of the trash
+func (r *SupabaseTodoRepository) RestoreTodo(ctx context.Context, todoID string) error {
+	query := `UPDATE todos SET deleted_at = NULL, updated_at = $1, version = version + 1 WHERE id = $2 AND deleted_at IS NOT NULL`
+
+	return r.inScope(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, query, time.Now(), todoID)
+		if err != nil {
+			return fmt.Errorf("failed to restore todo: %w", err)
 		}
-		return nil, fmt.Errorf("failed to get todo: %w", err)
+		return checkRowsAffected(result)
+	})
+}
+
+// checkRowsAffected reports ErrTodoNotFound when a statement changed no row
+func checkRowsAffected(result sql.Result) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
 	}
 
-	// Convert UUIDs to strings for the model
-	todo.ID = dbTodoID.String()
-	todo.UserID = dbUserID.String()
+	if rowsAffected == 0 {
+		return ErrTodoNotFound
+	}
 
-	return todo, nil
+	return nil
 }
 
-// GetByUserID retrieves all todos for a user from Supabase
-func (r *SupabaseTodoRepository) GetByUserID(ctx context.Context, userID string) ([]*models.Todo, error) {
-	// Parse user ID as UUID
-	parsedUserID, err := uuid.Parse(userID)
-	if err != nil {
-		return nil, fmt.Errorf("invalid user ID: %w", err)
-	}
+// EmptyTrash permanently removes all trashed todos of a user and returns them
+func (r *SupabaseTodoRepository) EmptyTrash(ctx context.Context, userID string) ([]*models.Todo, error) {
+	query := `DELETE FROM todos WHERE user_id = $1 AND deleted_at IS NOT NULL RETURNING ` + todoColumns
 
-	query := `
-		SELECT id, user_id, title, description, completed, created_at, updated_at
-		FROM todos
-		WHERE user_id = $1
-		ORDER BY created_at DESC
-	`
-	rows, err := r.db.QueryContext(ctx, query, parsedUserID)
+	// Parse userID into UUID
+	uid, err := uuid.Parse(userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get todos: %w", err)
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
 	}
-	defer rows.Close()
 
 	var todos []*models.Todo
-	for rows.Next() {
-		var dbTodoID, dbUserID uuid.UUID
-		todo := &models.Todo{}
-		err := rows.Scan(
-			&dbTodoID,
-			&dbUserID,
-			&todo.Title,
-			&todo.Description,
-			&todo.Completed,
-			&todo.CreatedAt,
-			&todo.UpdatedAt,
-		)
+	err = r.inScope(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, query, uid)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan todo: %w", err)
+			return fmt.Errorf("failed to empty trash: %w", err)
 		}
+		defer rows.Close()
 
-		// Convert UUIDs to strings for the model
-		todo.ID = dbTodoID.String()
-		todo.UserID = dbUserID.String()
+		todos, err = scanTodoRows(rows)
+		return err
+	})
+	return todos, err
+}
 
-		todos = append(todos, todo)
-	}
+// PurgeTrash permanently removes todos trashed before the cutoff and returns them
+func (r *SupabaseTodoRepository) PurgeTrash(ctx context.Context, deletedBefore time.Time) ([]*models.Todo, error) {
+	query := `DELETE FROM todos WHERE deleted_at IS NOT NULL AND deleted_at < $1 RETURNING ` + todoColumns
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating todos: %w", err)
-	}
+	var todos []*models.Todo
+	err := r.inScope(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, query, deletedBefore)
+		if err != nil {
+			return fmt.Errorf("failed to purge trash: %w", err)
+		}
+		defer rows.Close()
 
-	return todos, nil
+		todos, err = scanTodoRows(rows)
+		return err
+	})
+	return todos, err
 }
 
-// Update updates a todo in Supabase
-func (r *SupabaseTodoRepository) Update(ctx context.Context, todo *models.Todo) error {
-	// Parse todo ID as UUID
-	todoID, err := uuid.Parse(todo.ID)
-	if err != nil {
-		return fmt.Errorf("invalid todo ID: %w", err)
-	}
-
-	query := `
-		UPDATE todos
-		SET title = $1, description = $2, completed = $3, updated_at = $4
-		WHERE id = $5
-	`
-	res, err := r.db.ExecContext(
-		ctx,
-		query,
-		todo.Title,
-		todo.Description,
-		todo.Completed,
-		time.Now(),
-		todoID,
-	)
+// DeleteUserTodos permanently removes all todos of a user, trashed ones included
+func (r *SupabaseTodoRepository) DeleteUserTodos(ctx context.Context, userID string) error {
+	uid, err := uuid.Parse(userID)
 	if err != nil {
-		return fmt.Errorf("failed to update todo: %w", err)
+		return fmt.Errorf("invalid user ID format: %w", err)
 	}
 
-	rowsAffected, err := res.RowsAffected()
+	return r.inScope(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM todos WHERE user_id = $1`, uid); err != nil {
+			return fmt.Errorf("failed to delete todos: %w", err)
+		}
+		return nil
+	})
+}
+
+// scopedDB is the connection of a Postgres repository, whose tables have
+// row-level security policies scoped by the session (see
+// migrations/014_scope_todos_by_session.up.sql and
+// migrations/016_scope_user_tables_by_session.up.sql)
+type scopedDB struct {
+	db *sql.DB
+
+	// tx is the transaction of the unit of work the repository belongs to, if any
+	tx *sql.Tx
+}
+
+// inScope runs fn in a transaction that hands the scope of ctx to the
+// row-level security policies, committing it if fn succeeds.
+//
+// Every call sets the scope anew in a transaction of its own rather than once
+// per request. That is enough: the policies are checked statement by
+// statement against the scope, which is the same for every call of a request
+// as it comes from the request's context, and the scope is local to the
+// transaction, so it never leaks to the next user of a pooled connection.
+// What separate transactions do not give is atomicity across calls; writes
+// that must succeed or fail together go through a UnitOfWork, which runs
+// them on one transaction scoped once.
+func (r scopedDB) inScope(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	// Within a unit of work, join its transaction, whose scope is already set
+	if r.tx != nil {
+		return fn(r.tx)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	if rowsAffected == 0 {
-		return ErrTodoNotFound
+	defer tx.Rollback()
+
+	if err := setScope(ctx, tx); err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		return err
 	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
 	return nil
 }
 
-// Delete deletes a todo from Supabase
-func (r *SupabaseTodoRepository) Delete(ctx context.Context, id string) error {
-	// Parse todo ID as UUID
-	todoID, err := uuid.Parse(id)
-	if err != nil {
-		return fmt.Errorf("invalid todo ID: %w", err)
+// setScope sets the session variables the row-level security policies read.
+// They are local to the transaction, so they never outlive it on a pooled
+// connection. request.jwt.claims is what Supabase's auth.uid() reads.
+func setScope(ctx context.Context, tx *sql.Tx) error {
+	scope := ScopeFromContext(ctx)
+
+	claims := ""
+	if scope.UserID != "" {
+		data, err := json.Marshal(map[string]string{"sub": scope.UserID, "role": "authenticated"})
+		if err != nil {
+			return fmt.Errorf("failed to encode claims: %w", err)
+		}
+		claims = string(data)
 	}
 
-	query := `DELETE FROM todos WHERE id = $1`
-	res, err := r.db.ExecContext(ctx, query, todoID)
+	_, err := tx.ExecContext(ctx, `SELECT set_config('app.user_id', $1, true), set_config('request.jwt.claims', $2, true)`,
+		scope.UserID, claims)
 	if err != nil {
-		return fmt.Errorf("failed to delete todo: %w", err)
+		return fmt.Errorf("failed to set the request scope: %w", err)
 	}
+	return nil
+}
 
-	rowsAffected, err := res.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+// tagsArray returns the tags of a todo as a Postgres array, since the tags
+// column holds an empty array rather than NULL for todos without tags
+func tagsArray(tags []string) pq.StringArray {
+	if tags == nil {
+		return pq.StringArray{}
 	}
-	if rowsAffected == 0 {
-		return ErrTodoNotFound
+	return pq.StringArray(tags)
+}
+
+// scanTodoRows reads todos from a result set
+func scanTodoRows(rows *sql.Rows) ([]*models.Todo, error) {
+	var todos []*models.Todo
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan todo row: %w", err)
+		}
+		todos = append(todos, todo)
 	}
 
-	return nil
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+
+	return todos, nil
+}
+
+// scanTodo reads a todo selected with todoColumns
+func scanTodo(row interface{ Scan(dest ...any) error }) (*models.Todo, error) {
+	var todo models.Todo
+	var description sql.NullString
+	var deletedAt, dueAt sql.NullTime
+	var tags pq.StringArray
+	if err := row.Scan(&todo.ID, &todo.Title, &description, &todo.UserID, &todo.Completed, &todo.Version,
+		&todo.CreatedAt, &todo.UpdatedAt, &deletedAt, &dueAt, &todo.Priority, &todo.Project, &tags, &todo.Recurrence); err != nil {
+		return nil, err
+	}
+	// The description column is nullable
+	todo.Description = description.String
+	if deletedAt.Valid {
+		todo.DeletedAt = &deletedAt.Time
+	}
+	if dueAt.Valid {
+		todo.DueAt = &dueAt.Time
+	}
+	if len(tags) > 0 {
+		todo.Tags = tags
+	}
+	return &todo, nil
 }
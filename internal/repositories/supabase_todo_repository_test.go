@@ -3,16 +3,21 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"regexp"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/starbops/gottodo/internal/models"
 	"github.com/stretchr/testify/assert"
 )
 
+// todoTimestamp is when the todos of mocked rows were created and updated
+var todoTimestamp = time.Date(2025, time.March, 1, 9, 0, 0, 0, time.UTC)
+
 func setupMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -31,6 +36,20 @@ func parseUUID(t *testing.T, uuidStr string) uuid.UUID {
 	return id
 }
 
+// expectScope expects the transaction every repository call runs in and the
+// session variables the row-level security policies read for the scope
+func expectScope(mock sqlmock.Sqlmock, scope Scope) {
+	claims := ""
+	if scope.UserID != "" {
+		claims = `{"role":"authenticated","sub":"` + scope.UserID + `"}`
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT set_config('app.user_id', $1, true), set_config('request.jwt.claims', $2, true)`)).
+		WithArgs(scope.UserID, claims).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
 func TestSupabaseTodoRepository_CreateTodo(t *testing.T) {
 	// Setup
 	mockDB, mock := setupMockDB(t)
@@ -43,6 +62,7 @@ func TestSupabaseTodoRepository_CreateTodo(t *testing.T) {
 
 	// Create a todo with timestamps
 	now := time.Now()
+	dueAt := now.Add(24 * time.Hour)
 	todo := &models.Todo{
 		ID:          todoID,
 		UserID:      userID,
@@ -51,15 +71,23 @@ func TestSupabaseTodoRepository_CreateTodo(t *testing.T) {
 		Completed:   false,
 		CreatedAt:   now,
 		UpdatedAt:   now,
+		DueAt:       &dueAt,
+		Priority:    models.PriorityHigh,
+		Project:     "work",
+		Tags:        []string{"finance", "q1"},
+		Recurrence:  "FREQ=MONTHLY",
 	}
 
 	// Parse userID into UUID for matching in SQL mock
 	userUUID := parseUUID(t, userID)
 
 	// Set expected query and response - using specific timestamps
-	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO todos (id, title, description, user_id, completed, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`)).
-		WithArgs(todoID, "Test Todo", "This is a test todo", userUUID, false, todo.CreatedAt, todo.UpdatedAt).
+	expectScope(mock, Scope{})
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO todos (id, title, description, user_id, completed, version, created_at, updated_at, due_at, priority, project, tags, recurrence) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`)).
+		WithArgs(todoID, "Test Todo", "This is a test todo", userUUID, false, 1, todo.CreatedAt, todo.UpdatedAt,
+			dueAt, "high", "work", "{\"finance\",\"q1\"}", "FREQ=MONTHLY").
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
 	// Execute the function being tested
 	err := repo.CreateTodo(ctx, todo)
@@ -81,13 +109,17 @@ func TestSupabaseTodoRepository_GetTodo(t *testing.T) {
 	todoID := uuid.New().String()
 	userID := uuid.New().String()
 
+	dueAt := time.Date(2025, time.March, 13, 17, 0, 0, 0, time.UTC)
+
 	// Set expected query and response
-	rows := sqlmock.NewRows([]string{"id", "title", "description", "user_id", "completed"}).
-		AddRow(todoID, "Test Todo", "This is a test todo", userID, false)
+	rows := sqlmock.NewRows([]string{"id", "title", "description", "user_id", "completed", "version", "created_at", "updated_at", "deleted_at", "due_at", "priority", "project", "tags", "recurrence"}).
+		AddRow(todoID, "Test Todo", "This is a test todo", userID, false, 1, todoTimestamp, todoTimestamp, nil, dueAt, "high", "work", "{finance,q1}", "FREQ=MONTHLY")
 
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, title, description, user_id, completed FROM todos WHERE id = $1`)).
+	expectScope(mock, Scope{})
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, title, description, user_id, completed, version, created_at, updated_at, deleted_at, due_at, priority, project, tags, recurrence FROM todos WHERE id = $1`)).
 		WithArgs(todoID).
 		WillReturnRows(rows)
+	mock.ExpectCommit()
 
 	// Execute the function being tested
 	todo, err := repo.GetTodo(ctx, todoID)
@@ -99,6 +131,43 @@ func TestSupabaseTodoRepository_GetTodo(t *testing.T) {
 	assert.Equal(t, "Test Todo", todo.Title)
 	assert.Equal(t, "This is a test todo", todo.Description)
 	assert.False(t, todo.Completed)
+	assert.Equal(t, &dueAt, todo.DueAt)
+	assert.Equal(t, models.PriorityHigh, todo.Priority)
+	assert.Equal(t, "work", todo.Project)
+	assert.Equal(t, []string{"finance", "q1"}, todo.Tags)
+	assert.Equal(t, "FREQ=MONTHLY", todo.Recurrence)
+	assert.Equal(t, todoTimestamp, todo.CreatedAt)
+	assert.Equal(t, todoTimestamp, todo.UpdatedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseTodoRepository_GetTodo_NullDescription(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseTodoRepository(mockDB)
+	ctx := context.Background()
+
+	todoID := uuid.New().String()
+	updatedAt := todoTimestamp.Add(time.Hour)
+
+	// Rows without a description and with a later update read back as stored
+	rows := sqlmock.NewRows([]string{"id", "title", "description", "user_id", "completed", "version", "created_at", "updated_at", "deleted_at", "due_at", "priority", "project", "tags", "recurrence"}).
+		AddRow(todoID, "Test Todo", nil, uuid.New().String(), true, 2, todoTimestamp, updatedAt, nil, nil, "", "", nil, "")
+	expectScope(mock, Scope{})
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, title, description, user_id, completed, version, created_at, updated_at, deleted_at, due_at, priority, project, tags, recurrence FROM todos WHERE id = $1`)).
+		WithArgs(todoID).
+		WillReturnRows(rows)
+	mock.ExpectCommit()
+
+	// Execute the function being tested
+	todo, err := repo.GetTodo(ctx, todoID)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Equal(t, "", todo.Description)
+	assert.Equal(t, todoTimestamp, todo.CreatedAt)
+	assert.Equal(t, updatedAt, todo.UpdatedAt)
+	assert.Nil(t, todo.Tags)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
@@ -112,9 +181,11 @@ func TestSupabaseTodoRepository_GetTodo_NotFound(t *testing.T) {
 	todoID := uuid.New().String()
 
 	// Set expected query and response for a todo that doesn't exist
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, title, description, user_id, completed FROM todos WHERE id = $1`)).
+	expectScope(mock, Scope{})
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, title, description, user_id, completed, version, created_at, updated_at, deleted_at, due_at, priority, project, tags, recurrence FROM todos WHERE id = $1`)).
 		WithArgs(todoID).
 		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
 
 	// Execute the function being tested
 	_, err := repo.GetTodo(ctx, todoID)
@@ -140,13 +211,15 @@ func TestSupabaseTodoRepository_GetUserTodos(t *testing.T) {
 	userUUID := parseUUID(t, userID)
 
 	// Set expected query and response
-	rows := sqlmock.NewRows([]string{"id", "title", "description", "user_id", "completed"}).
-		AddRow(todoID1, "Todo 1", "Description 1", userID, false).
-		AddRow(todoID2, "Todo 2", "Description 2", userID, true)
+	rows := sqlmock.NewRows([]string{"id", "title", "description", "user_id", "completed", "version", "created_at", "updated_at", "deleted_at", "due_at", "priority", "project", "tags", "recurrence"}).
+		AddRow(todoID1, "Todo 1", "Description 1", userID, false, 1, todoTimestamp, todoTimestamp, nil, nil, "", "", "{}", "").
+		AddRow(todoID2, "Todo 2", "Description 2", userID, true, 1, todoTimestamp, todoTimestamp, nil, nil, "", "", "{}", "")
 
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, title, description, user_id, completed FROM todos WHERE user_id = $1`)).
+	expectScope(mock, Scope{})
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, title, description, user_id, completed, version, created_at, updated_at, deleted_at, due_at, priority, project, tags, recurrence FROM todos WHERE user_id = $1 AND deleted_at IS NULL ORDER BY created_at, id`)).
 		WithArgs(userUUID).
 		WillReturnRows(rows)
+	mock.ExpectCommit()
 
 	// Execute the function being tested
 	todos, err := repo.GetUserTodos(ctx, userID)
@@ -161,6 +234,71 @@ func TestSupabaseTodoRepository_GetUserTodos(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestSupabaseTodoRepository_StreamUserTodos(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseTodoRepository(mockDB)
+	ctx := context.Background()
+
+	// Create valid UUIDs for testing
+	userID := uuid.New().String()
+	todoID1 := uuid.New().String()
+	todoID2 := uuid.New().String()
+	deletedAt := time.Now()
+
+	// Trashed todos are streamed too
+	rows := sqlmock.NewRows([]string{"id", "title", "description", "user_id", "completed", "version", "created_at", "updated_at", "deleted_at", "due_at", "priority", "project", "tags", "recurrence"}).
+		AddRow(todoID1, "Todo 1", "", userID, false, 1, todoTimestamp, todoTimestamp, nil, nil, "high", "", "{home}", "").
+		AddRow(todoID2, "Todo 2", "", userID, true, 2, todoTimestamp, todoTimestamp, deletedAt, nil, "", "", "{}", "")
+	expectScope(mock, Scope{})
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, title, description, user_id, completed, version, created_at, updated_at, deleted_at, due_at, priority, project, tags, recurrence FROM todos WHERE user_id = $1 ORDER BY created_at, id`)).
+		WithArgs(parseUUID(t, userID)).
+		WillReturnRows(rows)
+	mock.ExpectCommit()
+
+	// Execute the function being tested
+	var todos []*models.Todo
+	err := repo.StreamUserTodos(ctx, userID, func(todo *models.Todo) error {
+		todos = append(todos, todo)
+		return nil
+	})
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Len(t, todos, 2)
+	assert.Equal(t, []string{"home"}, todos[0].Tags)
+	assert.True(t, todos[1].IsTrashed())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseTodoRepository_StreamUserTodos_Stop(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseTodoRepository(mockDB)
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+	rows := sqlmock.NewRows([]string{"id", "title", "description", "user_id", "completed", "version", "created_at", "updated_at", "deleted_at", "due_at", "priority", "project", "tags", "recurrence"}).
+		AddRow(uuid.New().String(), "Todo 1", "", userID, false, 1, todoTimestamp, todoTimestamp, nil, nil, "", "", "{}", "").
+		AddRow(uuid.New().String(), "Todo 2", "", userID, false, 1, todoTimestamp, todoTimestamp, nil, nil, "", "", "{}", "")
+	expectScope(mock, Scope{})
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id`)).WillReturnRows(rows)
+	mock.ExpectRollback()
+
+	// An error from the callback stops the iteration
+	stop := errors.New("stop")
+	calls := 0
+	err := repo.StreamUserTodos(ctx, userID, func(todo *models.Todo) error {
+		calls++
+		return stop
+	})
+
+	// Assertions
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, calls)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestSupabaseTodoRepository_UpdateTodo(t *testing.T) {
 	// Setup
 	mockDB, mock := setupMockDB(t)
@@ -177,19 +315,23 @@ func TestSupabaseTodoRepository_UpdateTodo(t *testing.T) {
 		Title:       "Updated Todo",
 		Description: "This is an updated test todo",
 		Completed:   true,
+		Version:     2,
 		UpdatedAt:   now,
 	}
 
 	// Set expected query and response with updated_at
-	mock.ExpectExec(regexp.QuoteMeta(`UPDATE todos SET title = $1, description = $2, completed = $3, updated_at = $4 WHERE id = $5`)).
-		WithArgs("Updated Todo", "This is an updated test todo", true, now, todoID).
+	expectScope(mock, Scope{})
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE todos SET title = $1, description = $2, completed = $3, due_at = $4, priority = $5, project = $6, tags = $7, recurrence = $8, updated_at = $9, version = version + 1 WHERE id = $10 AND version = $11`)).
+		WithArgs("Updated Todo", "This is an updated test todo", true, nil, "", "", "{}", "", now, todoID, 2).
 		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
 
 	// Execute the function being tested
 	err := repo.UpdateTodo(ctx, todo)
 
 	// Assertions
 	assert.NoError(t, err)
+	assert.Equal(t, 3, todo.Version, "Version should be incremented")
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
@@ -209,13 +351,19 @@ func TestSupabaseTodoRepository_UpdateTodo_NotFound(t *testing.T) {
 		Title:       "Updated Todo",
 		Description: "This is an updated test todo",
 		Completed:   true,
+		Version:     1,
 		UpdatedAt:   now,
 	}
 
 	// Set expected query and response (no rows affected)
-	mock.ExpectExec(regexp.QuoteMeta(`UPDATE todos SET title = $1, description = $2, completed = $3, updated_at = $4 WHERE id = $5`)).
-		WithArgs("Updated Todo", "This is an updated test todo", true, now, todoID).
+	expectScope(mock, Scope{})
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE todos SET title = $1, description = $2, completed = $3, due_at = $4, priority = $5, project = $6, tags = $7, recurrence = $8, updated_at = $9, version = version + 1 WHERE id = $10 AND version = $11`)).
+		WithArgs("Updated Todo", "This is an updated test todo", true, nil, "", "", "{}", "", now, todoID, 1).
 		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS (SELECT 1 FROM todos WHERE id = $1)`)).
+		WithArgs(todoID).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectRollback()
 
 	// Execute the function being tested
 	err := repo.UpdateTodo(ctx, todo)
@@ -226,6 +374,291 @@ func TestSupabaseTodoRepository_UpdateTodo_NotFound(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestSupabaseTodoRepository_UpdateTodo_Conflict(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseTodoRepository(mockDB)
+	ctx := context.Background()
+
+	// Create valid UUIDs for testing
+	todoID := uuid.New().String()
+	now := time.Now()
+	todo := &models.Todo{
+		ID:          todoID,
+		UserID:      uuid.New().String(),
+		Title:       "Stale Todo",
+		Description: "Edited from an old tab",
+		Version:     1,
+		UpdatedAt:   now,
+	}
+
+	// The row exists but has moved on to a newer version
+	expectScope(mock, Scope{})
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE todos SET title = $1, description = $2, completed = $3, due_at = $4, priority = $5, project = $6, tags = $7, recurrence = $8, updated_at = $9, version = version + 1 WHERE id = $10 AND version = $11`)).
+		WithArgs("Stale Todo", "Edited from an old tab", false, nil, "", "", "{}", "", now, todoID, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS (SELECT 1 FROM todos WHERE id = $1)`)).
+		WithArgs(todoID).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectRollback()
+
+	// Execute the function being tested
+	err := repo.UpdateTodo(ctx, todo)
+
+	// Assertions
+	assert.Equal(t, ErrConflict, err)
+	assert.Equal(t, 1, todo.Version, "Version should be unchanged on conflict")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseTodoRepository_PatchTodo(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseTodoRepository(mockDB)
+	ctx := context.Background()
+
+	// Create valid UUIDs for testing
+	todoID := uuid.New().String()
+	now := time.Now()
+	todo := &models.Todo{
+		ID:          todoID,
+		UserID:      uuid.New().String(),
+		Title:       "Unchanged Title",
+		Description: "Patched Description",
+		Completed:   true,
+		Version:     2,
+		UpdatedAt:   now,
+	}
+
+	// Only the patched columns are written
+	expectScope(mock, Scope{})
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE todos SET description = $1, completed = $2, updated_at = $3, version = version + 1 WHERE id = $4 AND version = $5`)).
+		WithArgs("Patched Description", true, now, todoID, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// Execute the function being tested
+	err := repo.PatchTodo(ctx, todo, []string{models.FieldDescription, models.FieldCompleted})
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Equal(t, 3, todo.Version)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseTodoRepository_PatchTodo_PlanningFields(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseTodoRepository(mockDB)
+	ctx := context.Background()
+
+	todoID := uuid.New().String()
+	now := time.Now()
+	due := now.Add(24 * time.Hour)
+	todo := &models.Todo{
+		ID:         todoID,
+		Version:    4,
+		UpdatedAt:  now,
+		DueAt:      &due,
+		Priority:   models.PriorityLow,
+		Project:    "home",
+		Tags:       []string{"garden"},
+		Recurrence: "FREQ=WEEKLY",
+	}
+
+	expectScope(mock, Scope{})
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE todos SET due_at = $1, priority = $2, project = $3, tags = $4, recurrence = $5, updated_at = $6, version = version + 1 WHERE id = $7 AND version = $8`)).
+		WithArgs(due, "low", "home", "{\"garden\"}", "FREQ=WEEKLY", now, todoID, 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// Execute the function being tested
+	err := repo.PatchTodo(ctx, todo, []string{models.FieldDueAt, models.FieldPriority, models.FieldProject, models.FieldTags, models.FieldRecurrence})
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Equal(t, 5, todo.Version)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseTodoRepository_CreateTodo_Duplicate(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseTodoRepository(mockDB)
+	ctx := context.Background()
+
+	todo := &models.Todo{ID: uuid.New().String(), UserID: uuid.New().String(), Title: "Duplicate"}
+
+	expectScope(mock, Scope{})
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO todos`)).
+		WillReturnError(&pq.Error{Code: uniqueViolation})
+	mock.ExpectRollback()
+
+	// Execute the function being tested
+	err := repo.CreateTodo(ctx, todo)
+
+	// Assertions
+	assert.Equal(t, ErrTodoExists, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseTodoRepository_CreateTodos(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseTodoRepository(mockDB)
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+	todos := []*models.Todo{
+		{UserID: userID, Title: "First"},
+		{UserID: userID, Title: "Second", Tags: []string{"home"}},
+	}
+
+	// Both todos are inserted within one transaction
+	expectScope(mock, Scope{})
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO todos`)).
+		WithArgs(sqlmock.AnyArg(), "First", "", sqlmock.AnyArg(), false, 1, sqlmock.AnyArg(), sqlmock.AnyArg(), nil, "", "", sqlmock.AnyArg(), "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO todos`)).
+		WithArgs(sqlmock.AnyArg(), "Second", "", sqlmock.AnyArg(), false, 1, sqlmock.AnyArg(), sqlmock.AnyArg(), nil, "", "", sqlmock.AnyArg(), "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// Execute the function being tested
+	err := repo.CreateTodos(ctx, todos)
+
+	// Assertions
+	assert.NoError(t, err)
+	for _, todo := range todos {
+		assert.NotEmpty(t, todo.ID)
+		assert.Equal(t, 1, todo.Version)
+		assert.False(t, todo.CreatedAt.IsZero())
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseTodoRepository_CreateTodos_Rollback(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseTodoRepository(mockDB)
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+	todos := []*models.Todo{
+		{UserID: userID, Title: "First"},
+		{ID: uuid.New().String(), UserID: userID, Title: "Duplicate"},
+	}
+
+	// A duplicate second todo rolls back the first
+	expectScope(mock, Scope{})
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO todos`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO todos`)).
+		WillReturnError(&pq.Error{Code: uniqueViolation})
+	mock.ExpectRollback()
+
+	// Execute the function being tested
+	err := repo.CreateTodos(ctx, todos)
+
+	// Assertions
+	assert.Equal(t, ErrTodoExists, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseTodoRepository_PatchTodo_UnknownField(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseTodoRepository(mockDB)
+	ctx := context.Background()
+
+	todo := &models.Todo{ID: uuid.New().String(), Version: 1}
+
+	// Execute the function being tested
+	err := repo.PatchTodo(ctx, todo, []string{"user_id"})
+
+	// Assertions
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseTodoRepository_UpdateTodos(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseTodoRepository(mockDB)
+	ctx := context.Background()
+
+	// Create valid UUIDs for testing
+	userID := uuid.New().String()
+	todoID := uuid.New().String()
+	missingID := uuid.New().String()
+
+	// Both todos are locked within one transaction, the missing one is reported
+	expectScope(mock, Scope{})
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, title, description, user_id, completed, version, created_at, updated_at, deleted_at, due_at, priority, project, tags, recurrence FROM todos WHERE id = ANY($1) FOR UPDATE`)).
+		WithArgs(pq.Array([]string{todoID, missingID})).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "description", "user_id", "completed", "version", "created_at", "updated_at", "deleted_at", "due_at", "priority", "project", "tags", "recurrence"}).
+			AddRow(todoID, "Bulk Todo", "Description", userID, false, 3, todoTimestamp, todoTimestamp, nil, nil, "", "", "{}", ""))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE todos SET title = $1, description = $2, completed = $3, deleted_at = $4, due_at = $5, priority = $6, project = $7, tags = $8, recurrence = $9, updated_at = $10, version = version + 1 WHERE id = $11`)).
+		WithArgs("Bulk Todo", "Description", true, nil, nil, "", "", "{}", "", sqlmock.AnyArg(), todoID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// Execute the function being tested
+	results, err := repo.UpdateTodos(ctx, []string{todoID, missingID}, func(todo *models.Todo) error {
+		todo.Completed = true
+		return nil
+	})
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.NoError(t, results[0].Err)
+	assert.False(t, results[0].Before.Completed)
+	assert.True(t, results[0].Todo.Completed)
+	assert.Equal(t, 4, results[0].Todo.Version)
+	assert.Equal(t, missingID, results[1].TodoID)
+	assert.Equal(t, ErrTodoNotFound, results[1].Err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseTodoRepository_UpdateTodos_Rollback(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseTodoRepository(mockDB)
+	ctx := context.Background()
+
+	// Create valid UUIDs for testing
+	userID := uuid.New().String()
+	firstID := uuid.New().String()
+	secondID := uuid.New().String()
+
+	// A database error on the second todo rolls back the first
+	expectScope(mock, Scope{})
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, title, description, user_id, completed, version, created_at, updated_at, deleted_at, due_at, priority, project, tags, recurrence FROM todos WHERE id = ANY($1) FOR UPDATE`)).
+		WithArgs(pq.Array([]string{firstID, secondID})).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "description", "user_id", "completed", "version", "created_at", "updated_at", "deleted_at", "due_at", "priority", "project", "tags", "recurrence"}).
+			AddRow(firstID, "First", "", userID, false, 1, todoTimestamp, todoTimestamp, nil, nil, "", "", "{}", "").
+			AddRow(secondID, "Second", "", userID, false, 1, todoTimestamp, todoTimestamp, nil, nil, "", "", "{}", ""))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE todos SET`)).
+		WithArgs("First", "", true, nil, nil, "", "", "{}", "", sqlmock.AnyArg(), firstID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE todos SET`)).
+		WithArgs("Second", "", true, nil, nil, "", "", "{}", "", sqlmock.AnyArg(), secondID).
+		WillReturnError(sql.ErrConnDone)
+	mock.ExpectRollback()
+
+	// Execute the function being tested
+	results, err := repo.UpdateTodos(ctx, []string{firstID, secondID}, func(todo *models.Todo) error {
+		todo.Completed = true
+		return nil
+	})
+
+	// Assertions
+	assert.Error(t, err)
+	assert.Nil(t, results)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestSupabaseTodoRepository_DeleteTodo(t *testing.T) {
 	// Setup
 	mockDB, mock := setupMockDB(t)
@@ -236,12 +669,14 @@ func TestSupabaseTodoRepository_DeleteTodo(t *testing.T) {
 	todoID := uuid.New().String()
 
 	// Set expected query and response
-	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM todos WHERE id = $1`)).
-		WithArgs(todoID).
+	expectScope(mock, Scope{})
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE todos SET deleted_at = $1, version = version + 1 WHERE id = $2 AND version = $3 AND deleted_at IS NULL`)).
+		WithArgs(sqlmock.AnyArg(), todoID, 3).
 		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
 
 	// Execute the function being tested
-	err := repo.DeleteTodo(ctx, todoID)
+	err := repo.DeleteTodo(ctx, todoID, 3)
 
 	// Assertions
 	assert.NoError(t, err)
@@ -257,13 +692,18 @@ func TestSupabaseTodoRepository_DeleteTodo_NotFound(t *testing.T) {
 	// Create a valid UUID for testing
 	todoID := uuid.New().String()
 
-	// Set expected query and response (no rows affected)
-	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM todos WHERE id = $1`)).
-		WithArgs(todoID).
+	// Set expected query and response (no rows affected, no todo outside the trash)
+	expectScope(mock, Scope{})
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE todos SET deleted_at = $1, version = version + 1 WHERE id = $2 AND version = $3 AND deleted_at IS NULL`)).
+		WithArgs(sqlmock.AnyArg(), todoID, 3).
 		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS (SELECT 1 FROM todos WHERE id = $1 AND deleted_at IS NULL)`)).
+		WithArgs(todoID).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectRollback()
 
 	// Execute the function being tested
-	err := repo.DeleteTodo(ctx, todoID)
+	err := repo.DeleteTodo(ctx, todoID, 3)
 
 	// Assertions
 	assert.Error(t, err)
@@ -271,6 +711,33 @@ func TestSupabaseTodoRepository_DeleteTodo_NotFound(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestSupabaseTodoRepository_DeleteTodo_VersionConflict(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseTodoRepository(mockDB)
+	ctx := context.Background()
+
+	// Create a valid UUID for testing
+	todoID := uuid.New().String()
+
+	// Set expected query and response (no rows affected, but the todo is still outside the trash)
+	expectScope(mock, Scope{})
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE todos SET deleted_at = $1, version = version + 1 WHERE id = $2 AND version = $3 AND deleted_at IS NULL`)).
+		WithArgs(sqlmock.AnyArg(), todoID, 3).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS (SELECT 1 FROM todos WHERE id = $1 AND deleted_at IS NULL)`)).
+		WithArgs(todoID).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectRollback()
+
+	// Execute the function being tested
+	err := repo.DeleteTodo(ctx, todoID, 3)
+
+	// Assertions
+	assert.Equal(t, ErrConflict, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestSupabaseTodoRepository_CreateTodo_SetsTimestamps(t *testing.T) {
 	// Setup
 	mockDB, mock := setupMockDB(t)
@@ -291,9 +758,13 @@ func TestSupabaseTodoRepository_CreateTodo_SetsTimestamps(t *testing.T) {
 		// CreatedAt and UpdatedAt are zero values
 	}
 
-	// Set expected query without checking arguments in detail
-	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO todos (id, title, description, user_id, completed, created_at, updated_at) VALUES`)).
+	// Todos without tags are stored with an empty array rather than NULL
+	expectScope(mock, Scope{})
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO todos (id, title, description, user_id, completed, version, created_at, updated_at, due_at, priority, project, tags, recurrence) VALUES`)).
+		WithArgs(todoID, "Test Todo", "This is a test todo", parseUUID(t, userID), false, 1, sqlmock.AnyArg(), sqlmock.AnyArg(),
+			nil, "", "", "{}", "").
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
 	// Execute the function being tested
 	err := repo.CreateTodo(ctx, todo)
@@ -302,5 +773,202 @@ func TestSupabaseTodoRepository_CreateTodo_SetsTimestamps(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 	assert.False(t, todo.CreatedAt.IsZero(), "CreatedAt should be automatically set")
-	assert.False(t, todo.UpdatedAt.IsZero(), "UpdatedAt should be automatically set")
+	assert.Equal(t, todo.CreatedAt, todo.UpdatedAt, "UpdatedAt should equal CreatedAt for a new todo")
+}
+
+func TestSupabaseTodoRepository_GetTrashedTodos(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseTodoRepository(mockDB)
+	ctx := context.Background()
+
+	// Create valid UUIDs for testing
+	userID := uuid.New().String()
+	todoID := uuid.New().String()
+	deletedAt := time.Now()
+
+	// Parse UUIDs for matching in SQL mock
+	userUUID := parseUUID(t, userID)
+
+	rows := sqlmock.NewRows([]string{"id", "title", "description", "user_id", "completed", "version", "created_at", "updated_at", "deleted_at", "due_at", "priority", "project", "tags", "recurrence"}).
+		AddRow(todoID, "Trashed Todo", "Description", userID, false, 1, todoTimestamp, todoTimestamp, deletedAt, nil, "", "", "{}", "")
+
+	expectScope(mock, Scope{})
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, title, description, user_id, completed, version, created_at, updated_at, deleted_at, due_at, priority, project, tags, recurrence FROM todos WHERE user_id = $1 AND deleted_at IS NOT NULL ORDER BY deleted_at DESC, id`)).
+		WithArgs(userUUID).
+		WillReturnRows(rows)
+	mock.ExpectCommit()
+
+	// Execute the function being tested
+	todos, err := repo.GetTrashedTodos(ctx, userID)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Len(t, todos, 1)
+	assert.True(t, todos[0].IsTrashed())
+	assert.Equal(t, deletedAt, *todos[0].DeletedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseTodoRepository_RestoreTodo(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseTodoRepository(mockDB)
+	ctx := context.Background()
+
+	todoID := uuid.New().String()
+
+	expectScope(mock, Scope{})
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE todos SET deleted_at = NULL, updated_at = $1, version = version + 1 WHERE id = $2 AND deleted_at IS NOT NULL`)).
+		WithArgs(sqlmock.AnyArg(), todoID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// Restoring a todo that is not in the trash reports not found
+	expectScope(mock, Scope{})
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE todos SET deleted_at = NULL, updated_at = $1, version = version + 1 WHERE id = $2 AND deleted_at IS NOT NULL`)).
+		WithArgs(sqlmock.AnyArg(), todoID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	// Assertions
+	assert.NoError(t, repo.RestoreTodo(ctx, todoID))
+	assert.Equal(t, ErrTodoNotFound, repo.RestoreTodo(ctx, todoID))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseTodoRepository_EmptyTrash(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseTodoRepository(mockDB)
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+	userUUID := parseUUID(t, userID)
+
+	rows := sqlmock.NewRows([]string{"id", "title", "description", "user_id", "completed", "version", "created_at", "updated_at", "deleted_at", "due_at", "priority", "project", "tags", "recurrence"}).
+		AddRow(uuid.New().String(), "Todo 1", "Description 1", userID, false, 1, todoTimestamp, todoTimestamp, time.Now(), nil, "", "", "{}", "").
+		AddRow(uuid.New().String(), "Todo 2", "Description 2", userID, true, 1, todoTimestamp, todoTimestamp, time.Now(), nil, "", "", "{}", "")
+
+	expectScope(mock, Scope{})
+	mock.ExpectQuery(regexp.QuoteMeta(`DELETE FROM todos WHERE user_id = $1 AND deleted_at IS NOT NULL RETURNING id, title, description, user_id, completed, version, created_at, updated_at, deleted_at, due_at, priority, project, tags, recurrence`)).
+		WithArgs(userUUID).
+		WillReturnRows(rows)
+	mock.ExpectCommit()
+
+	// Execute the function being tested
+	purged, err := repo.EmptyTrash(ctx, userID)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Len(t, purged, 2)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseTodoRepository_PurgeTrash(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseTodoRepository(mockDB)
+	ctx := context.Background()
+
+	cutoff := time.Now().Add(-30 * 24 * time.Hour)
+
+	rows := sqlmock.NewRows([]string{"id", "title", "description", "user_id", "completed", "version", "created_at", "updated_at", "deleted_at", "due_at", "priority", "project", "tags", "recurrence"}).
+		AddRow(uuid.New().String(), "Old Todo", "Description", uuid.New().String(), false, 1, todoTimestamp, todoTimestamp, cutoff.Add(-time.Hour), nil, "", "", "{}", "")
+
+	expectScope(mock, Scope{})
+	mock.ExpectQuery(regexp.QuoteMeta(`DELETE FROM todos WHERE deleted_at IS NOT NULL AND deleted_at < $1 RETURNING id, title, description, user_id, completed, version, created_at, updated_at, deleted_at, due_at, priority, project, tags, recurrence`)).
+		WithArgs(cutoff).
+		WillReturnRows(rows)
+	mock.ExpectCommit()
+
+	// Execute the function being tested
+	purged, err := repo.PurgeTrash(ctx, cutoff)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Len(t, purged, 1)
+	assert.Equal(t, "Old Todo", purged[0].Title)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseTodoRepository_DeleteUserTodos(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseTodoRepository(mockDB)
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+	expectScope(mock, Scope{})
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM todos WHERE user_id = $1`)).
+		WithArgs(parseUUID(t, userID)).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	// Execute the function being tested
+	err := repo.DeleteUserTodos(ctx, userID)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// Malformed user IDs never reach the database
+	assert.Error(t, repo.DeleteUserTodos(ctx, "not-a-uuid"))
+}
+
+func TestSupabaseTodoRepository_Scope(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseTodoRepository(mockDB)
+
+	userID := uuid.New().String()
+	todoID := uuid.New().String()
+	cutoff := time.Now()
+	columns := []string{"id", "title", "description", "user_id", "completed", "version", "created_at", "updated_at", "deleted_at", "due_at", "priority", "project", "tags", "recurrence"}
+
+	// A user's request runs as that user
+	expectScope(mock, Scope{UserID: userID})
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id`)).
+		WithArgs(todoID).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(todoID, "Todo", "", userID, false, 1, todoTimestamp, todoTimestamp, nil, nil, "", "", "{}", ""))
+	mock.ExpectCommit()
+
+	// Background work has no user and relies on the role of its connection
+	expectScope(mock, Scope{})
+	mock.ExpectQuery(regexp.QuoteMeta(`DELETE FROM todos`)).
+		WithArgs(cutoff).
+		WillReturnRows(sqlmock.NewRows(columns))
+	mock.ExpectCommit()
+
+	// Execute the functions being tested
+	todo, err := repo.GetTodo(WithUserID(context.Background(), userID), todoID)
+	assert.NoError(t, err)
+	assert.Equal(t, todoID, todo.ID)
+
+	_, err = repo.PurgeTrash(context.Background(), cutoff)
+	assert.NoError(t, err)
+
+	// Assertions
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseTodoRepository_Scope_Error(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseTodoRepository(mockDB)
+
+	// Without its scope no statement runs
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT set_config`)).
+		WillReturnError(errors.New("permission denied"))
+	mock.ExpectRollback()
+
+	// Execute the function being tested
+	_, err := repo.GetTodo(context.Background(), uuid.New().String())
+
+	// Assertions
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrTodoNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
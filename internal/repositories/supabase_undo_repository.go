@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/starbops/gottodo/internal/models"
+)
+
+// SupabaseUndoRepository is a PostgreSQL implementation of UndoRepository using Supabase
+type SupabaseUndoRepository struct {
+	scopedDB
+}
+
+// NewSupabaseUndoRepository creates a new SupabaseUndoRepository
+func NewSupabaseUndoRepository(db *sql.DB) UndoRepository {
+	return &SupabaseUndoRepository{
+		scopedDB: scopedDB{db: db},
+	}
+}
+
+// SaveUndoToken stores a new undo token and discards expired ones
+func (r *SupabaseUndoRepository) SaveUndoToken(ctx context.Context, token *models.UndoToken) error {
+	snapshot, err := json.Marshal(token.Snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to encode undo snapshot: %w", err)
+	}
+
+	query := `INSERT INTO undo_tokens (token, user_id, todo_id, action, snapshot, created_at, expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	return r.inScope(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM undo_tokens WHERE expires_at < $1`, time.Now()); err != nil {
+			return fmt.Errorf("failed to delete expired undo tokens: %w", err)
+		}
+
+		_, err := tx.ExecContext(ctx, query,
+			token.Token, token.UserID, token.TodoID, string(token.Action), snapshot, token.CreatedAt, token.ExpiresAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert undo token: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetUndoToken retrieves an undo token without using it up
+func (r *SupabaseUndoRepository) GetUndoToken(ctx context.Context, token string) (*models.UndoToken, error) {
+	query := `SELECT token, user_id, todo_id, action, snapshot, created_at, expires_at FROM undo_tokens WHERE token = $1`
+
+	return r.get(ctx, query, token)
+}
+
+// ConsumeUndoToken removes an unexpired undo token of the user and returns
+// it, so it can only be used once
+func (r *SupabaseUndoRepository) ConsumeUndoToken(ctx context.Context, token string, userID string) (*models.UndoToken, error) {
+	query := `DELETE FROM undo_tokens WHERE token = $1 AND user_id = $2 AND expires_at > now() RETURNING token, user_id, todo_id, action, snapshot, created_at, expires_at`
+
+	return r.get(ctx, query, token, userID)
+}
+
+// GetLatestUndoToken retrieves the most recent unexpired undo token of a user
+func (r *SupabaseUndoRepository) GetLatestUndoToken(ctx context.Context, userID string) (*models.UndoToken, error) {
+	query := `SELECT token, user_id, todo_id, action, snapshot, created_at, expires_at FROM undo_tokens WHERE user_id = $1 AND expires_at > $2 ORDER BY created_at DESC LIMIT 1`
+
+	return r.get(ctx, query, userID, time.Now())
+}
+
+// get runs a query selecting a single undo token
+func (r *SupabaseUndoRepository) get(ctx context.Context, query string, args ...any) (*models.UndoToken, error) {
+	var token *models.UndoToken
+	err := r.inScope(ctx, func(tx *sql.Tx) error {
+		var err error
+		token, err = scanUndoToken(tx.QueryRowContext(ctx, query, args...))
+		return err
+	})
+	return token, err
+}
+
+// scanUndoToken reads a single undo token from a query result
+func scanUndoToken(row *sql.Row) (*models.UndoToken, error) {
+	var token models.UndoToken
+	var action string
+	var snapshot []byte
+	err := row.Scan(&token.Token, &token.UserID, &token.TodoID, &action, &snapshot, &token.CreatedAt, &token.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUndoTokenNotFound
+		}
+		return nil, fmt.Errorf("failed to scan undo token: %w", err)
+	}
+
+	token.Action = models.ActivityAction(action)
+	if err := json.Unmarshal(snapshot, &token.Snapshot); err != nil {
+		return nil, fmt.Errorf("failed to decode undo snapshot: %w", err)
+	}
+
+	return &token, nil
+}
+
+// DeleteUserUndoTokens removes all undo tokens of a user
+func (r *SupabaseUndoRepository) DeleteUserUndoTokens(ctx context.Context, userID string) error {
+	return r.inScope(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM undo_tokens WHERE user_id = $1`, userID); err != nil {
+			return fmt.Errorf("failed to delete undo tokens: %w", err)
+		}
+		return nil
+	})
+}
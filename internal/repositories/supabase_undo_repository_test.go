@@ -0,0 +1,128 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSupabaseUndoRepository_SaveUndoToken(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseUndoRepository(mockDB)
+	ctx := context.Background()
+
+	todo := models.NewTodo(uuid.New().String(), "Test Todo", "Description")
+	token := models.NewUndoToken(todo.UserID, models.ActivityDeleted, todo, time.Minute)
+
+	expectScope(mock, Scope{})
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM undo_tokens WHERE expires_at < $1`)).
+		WithArgs(sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO undo_tokens (token, user_id, todo_id, action, snapshot, created_at, expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`)).
+		WithArgs(token.Token, token.UserID, todo.ID, "deleted", sqlmock.AnyArg(), token.CreatedAt, token.ExpiresAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	// Execute the function being tested
+	err := repo.SaveUndoToken(ctx, token)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseUndoRepository_ConsumeUndoToken(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseUndoRepository(mockDB)
+	ctx := context.Background()
+
+	token := uuid.New().String()
+	userID := uuid.New().String()
+	todoID := uuid.New().String()
+	now := time.Now()
+
+	rows := sqlmock.NewRows([]string{"token", "user_id", "todo_id", "action", "snapshot", "created_at", "expires_at"}).
+		AddRow(token, userID, todoID, "completed", []byte(`{"id":"`+todoID+`","title":"Test Todo","completed":false}`), now, now.Add(time.Minute))
+
+	query := regexp.QuoteMeta(`DELETE FROM undo_tokens WHERE token = $1 AND user_id = $2 AND expires_at > now() RETURNING token, user_id, todo_id, action, snapshot, created_at, expires_at`)
+	expectScope(mock, Scope{})
+	mock.ExpectQuery(query).WithArgs(token, userID).WillReturnRows(rows)
+	mock.ExpectCommit()
+	expectScope(mock, Scope{})
+	mock.ExpectQuery(query).WithArgs(token, userID).WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	// Execute the function being tested
+	consumed, err := repo.ConsumeUndoToken(ctx, token, userID)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Equal(t, models.ActivityCompleted, consumed.Action)
+	assert.Equal(t, "Test Todo", consumed.Snapshot.Title)
+	assert.False(t, consumed.Snapshot.Completed)
+
+	// A consumed token is gone
+	_, err = repo.ConsumeUndoToken(ctx, token, userID)
+	assert.Equal(t, ErrUndoTokenNotFound, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseUndoRepository_GetUndoToken(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseUndoRepository(mockDB)
+	ctx := context.Background()
+
+	token := uuid.New().String()
+	userID := uuid.New().String()
+	todoID := uuid.New().String()
+	now := time.Now()
+
+	rows := sqlmock.NewRows([]string{"token", "user_id", "todo_id", "action", "snapshot", "created_at", "expires_at"}).
+		AddRow(token, userID, todoID, "deleted", []byte(`{"id":"`+todoID+`","title":"Test Todo"}`), now, now.Add(-time.Minute))
+
+	expectScope(mock, Scope{})
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT token, user_id, todo_id, action, snapshot, created_at, expires_at FROM undo_tokens WHERE token = $1`)).
+		WithArgs(token).
+		WillReturnRows(rows)
+	mock.ExpectCommit()
+
+	// Execute the function being tested
+	stored, err := repo.GetUndoToken(ctx, token)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Equal(t, userID, stored.UserID)
+	assert.True(t, stored.IsExpired())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseUndoRepository_DeleteUserUndoTokens(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseUndoRepository(mockDB)
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+	expectScope(mock, Scope{})
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM undo_tokens WHERE user_id = $1`)).
+		WithArgs(userID).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	// Execute the function being tested
+	err := repo.DeleteUserUndoTokens(ctx, userID)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// SupabaseUnitOfWork runs units of work in a Postgres transaction
+type SupabaseUnitOfWork struct {
+	db *sql.DB
+}
+
+// NewSupabaseUnitOfWork creates a new SupabaseUnitOfWork
+func NewSupabaseUnitOfWork(db *sql.DB) UnitOfWork {
+	return &SupabaseUnitOfWork{db: db}
+}
+
+// WithTx calls fn with repositories that run on a single transaction, scoped
+// like the repositories' own transactions, and commits it if fn succeeds.
+// A database error aborts the transaction, so fn should return it rather than
+// carry on. While the transaction is open, the callback of StreamUserTodos
+// must not use the repositories, since the connection is busy with the rows.
+func (u *SupabaseUnitOfWork) WithTx(ctx context.Context, fn func(repos *Repositories) error) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	// Also rolls back if fn panics; a no-op once committed
+	defer tx.Rollback()
+
+	if err := setScope(ctx, tx); err != nil {
+		return err
+	}
+
+	// The repositories share the transaction, whose scope is set
+	scoped := scopedDB{db: u.db, tx: tx}
+	repos := &Repositories{
+		Todos:        &SupabaseTodoRepository{scopedDB: scoped},
+		Activity:     &SupabaseActivityRepository{scopedDB: scoped},
+		Undo:         &SupabaseUndoRepository{scopedDB: scoped},
+		Idempotency:  &SupabaseIdempotencyRepository{scopedDB: scoped},
+		Webhooks:     &SupabaseWebhookRepository{scopedDB: scoped},
+		Capture:      &SupabaseCaptureRepository{scopedDB: scoped},
+		Calendar:     &SupabaseCalendarFeedRepository{scopedDB: scoped},
+		AppPasswords: &SupabaseAppPasswordRepository{scopedDB: scoped},
+		Deletions:    &SupabaseAccountDeletionRepository{scopedDB: scoped},
+	}
+	repos.UnitOfWork = joinedUnitOfWork{repos: repos}
+	if err := fn(repos); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSupabaseUnitOfWork_Commit(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	unitOfWork := NewSupabaseUnitOfWork(mockDB)
+	userID := uuid.New().String()
+	ctx := WithUserID(context.Background(), userID)
+
+	todoID := uuid.New().String()
+	activity := models.NewActivity(userID, todoID, models.ActivityDeleted, nil)
+
+	// Every statement runs in the one transaction, scoped once
+	expectScope(mock, Scope{UserID: userID})
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE todos SET deleted_at = $1, version = version + 1 WHERE id = $2 AND version = $3 AND deleted_at IS NULL`)).
+		WithArgs(sqlmock.AnyArg(), todoID, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO activity_log (id, actor_id, todo_id, action, changes, created_at) VALUES ($1, $2, $3, $4, $5, $6)`)).
+		WithArgs(activity.ID, userID, todoID, "deleted", []byte(`[]`), activity.CreatedAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM account_deletions WHERE user_id = $1`)).
+		WithArgs(userID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// Execute the function being tested
+	err := unitOfWork.WithTx(ctx, func(repos *Repositories) error {
+		if err := repos.Todos.DeleteTodo(ctx, todoID, 1); err != nil {
+			return err
+		}
+		if err := repos.Activity.AppendActivity(ctx, activity); err != nil {
+			return err
+		}
+
+		// A nested unit of work joins the running one
+		return repos.UnitOfWork.WithTx(ctx, func(nested *Repositories) error {
+			return nested.Deletions.DeleteAccountDeletion(ctx, userID)
+		})
+	})
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseUnitOfWork_Rollback(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	unitOfWork := NewSupabaseUnitOfWork(mockDB)
+	ctx := context.Background()
+
+	todoID := uuid.New().String()
+	activity := models.NewActivity(uuid.New().String(), todoID, models.ActivityDeleted, nil)
+
+	// The todo is trashed, but the failed activity entry undoes it
+	expectScope(mock, Scope{})
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE todos SET deleted_at = $1, version = version + 1 WHERE id = $2 AND version = $3 AND deleted_at IS NULL`)).
+		WithArgs(sqlmock.AnyArg(), todoID, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO activity_log`)).
+		WillReturnError(errors.New("database error"))
+	mock.ExpectRollback()
+
+	// Execute the function being tested
+	err := unitOfWork.WithTx(ctx, func(repos *Repositories) error {
+		if err := repos.Todos.DeleteTodo(ctx, todoID, 1); err != nil {
+			return err
+		}
+		return repos.Activity.AppendActivity(ctx, activity)
+	})
+
+	// Assertions
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseUnitOfWork_RollbackOnPanic(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	unitOfWork := NewSupabaseUnitOfWork(mockDB)
+	ctx := context.Background()
+
+	expectScope(mock, Scope{})
+	mock.ExpectRollback()
+
+	// Execute the function being tested
+	assert.PanicsWithValue(t, "boom", func() {
+		unitOfWork.WithTx(ctx, func(repos *Repositories) error {
+			panic("boom")
+		})
+	})
+
+	// Assertions
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseUnitOfWork_BeginError(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	unitOfWork := NewSupabaseUnitOfWork(mockDB)
+	ctx := context.Background()
+
+	mock.ExpectBegin().WillReturnError(errors.New("connection refused"))
+
+	// Execute the function being tested
+	called := false
+	err := unitOfWork.WithTx(ctx, func(repos *Repositories) error {
+		called = true
+		return nil
+	})
+
+	// Assertions
+	assert.Error(t, err)
+	assert.False(t, called)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
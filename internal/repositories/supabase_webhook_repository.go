@@ -0,0 +1,274 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/starbops/gottodo/internal/models"
+)
+
+const (
+	webhookColumns  = `id, user_id, url, secret, events, created_at`
+	deliveryColumns = `id, webhook_id, user_id, event, payload, status, attempts, next_attempt_at, response_status, last_error, created_at, updated_at`
+)
+
+// SupabaseWebhookRepository is a PostgreSQL implementation of WebhookRepository using Supabase
+type SupabaseWebhookRepository struct {
+	scopedDB
+}
+
+// NewSupabaseWebhookRepository creates a new SupabaseWebhookRepository
+func NewSupabaseWebhookRepository(db *sql.DB) WebhookRepository {
+	return &SupabaseWebhookRepository{
+		scopedDB: scopedDB{db: db},
+	}
+}
+
+// scanWebhook scans a row selected with webhookColumns
+func scanWebhook(row interface{ Scan(...interface{}) error }) (*models.Webhook, error) {
+	var webhook models.Webhook
+	var events []string
+	if err := row.Scan(&webhook.ID, &webhook.UserID, &webhook.URL, &webhook.Secret, pq.Array(&events), &webhook.CreatedAt); err != nil {
+		return nil, err
+	}
+	for _, event := range events {
+		webhook.Events = append(webhook.Events, models.WebhookEvent(event))
+	}
+	return &webhook, nil
+}
+
+// scanDelivery scans a row selected with deliveryColumns
+func scanDelivery(row interface{ Scan(...interface{}) error }) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	var payload []byte
+	err := row.Scan(&delivery.ID, &delivery.WebhookID, &delivery.UserID, &delivery.Event, &payload,
+		&delivery.Status, &delivery.Attempts, &delivery.NextAttemptAt, &delivery.ResponseStatus,
+		&delivery.LastError, &delivery.CreatedAt, &delivery.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	delivery.Payload = payload
+	return &delivery, nil
+}
+
+// scanDeliveries scans every row selected with deliveryColumns
+func scanDeliveries(rows *sql.Rows) ([]*models.WebhookDelivery, error) {
+	defer rows.Close()
+
+	var deliveries []*models.WebhookDelivery
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook delivery rows: %w", err)
+	}
+	return deliveries, nil
+}
+
+// CreateWebhook stores a new webhook
+func (r *SupabaseWebhookRepository) CreateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	events := make([]string, 0, len(webhook.Events))
+	for _, event := range webhook.Events {
+		events = append(events, string(event))
+	}
+
+	query := `INSERT INTO webhooks (` + webhookColumns + `) VALUES ($1, $2, $3, $4, $5, $6)`
+	return r.inScope(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, query,
+			webhook.ID, webhook.UserID, webhook.URL, webhook.Secret, pq.Array(events), webhook.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert webhook: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetWebhook retrieves a webhook by ID
+func (r *SupabaseWebhookRepository) GetWebhook(ctx context.Context, id string) (*models.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = $1`
+
+	var webhook *models.Webhook
+	err := r.inScope(ctx, func(tx *sql.Tx) error {
+		var err error
+		webhook, err = scanWebhook(tx.QueryRowContext(ctx, query, id))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrWebhookNotFound
+			}
+			return fmt.Errorf("failed to scan webhook: %w", err)
+		}
+		return nil
+	})
+	return webhook, err
+}
+
+// GetUserWebhooks retrieves the webhooks of a user, oldest first
+func (r *SupabaseWebhookRepository) GetUserWebhooks(ctx context.Context, userID string) ([]*models.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE user_id = $1 ORDER BY created_at ASC`
+
+	var webhooks []*models.Webhook
+	err := r.inScope(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, query, userID)
+		if err != nil {
+			return fmt.Errorf("failed to query webhooks: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			webhook, err := scanWebhook(rows)
+			if err != nil {
+				return fmt.Errorf("failed to scan webhook: %w", err)
+			}
+			webhooks = append(webhooks, webhook)
+		}
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error iterating webhook rows: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// DeleteWebhook removes a webhook; its deliveries are removed by the
+// foreign key cascade
+func (r *SupabaseWebhookRepository) DeleteWebhook(ctx context.Context, id string) error {
+	return r.inScope(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `DELETE FROM webhooks WHERE id = $1`, id)
+		if err != nil {
+			return fmt.Errorf("failed to delete webhook: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return ErrWebhookNotFound
+		}
+		return nil
+	})
+}
+
+// CreateDelivery stores a new delivery
+func (r *SupabaseWebhookRepository) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	query := `INSERT INTO webhook_deliveries (` + deliveryColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+	return r.inScope(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, query,
+			delivery.ID, delivery.WebhookID, delivery.UserID, delivery.Event, []byte(delivery.Payload),
+			delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.ResponseStatus,
+			delivery.LastError, delivery.CreatedAt, delivery.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert webhook delivery: %w", err)
+		}
+		return nil
+	})
+}
+
+// UpdateDelivery stores the outcome of a delivery attempt
+func (r *SupabaseWebhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	query := `UPDATE webhook_deliveries SET status = $1, attempts = $2, next_attempt_at = $3, response_status = $4, last_error = $5, updated_at = $6 WHERE id = $7`
+	return r.inScope(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, query,
+			delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.ResponseStatus,
+			delivery.LastError, delivery.UpdatedAt, delivery.ID)
+		if err != nil {
+			return fmt.Errorf("failed to update webhook delivery: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return ErrDeliveryNotFound
+		}
+		return nil
+	})
+}
+
+// GetDelivery retrieves a delivery by ID
+func (r *SupabaseWebhookRepository) GetDelivery(ctx context.Context, id string) (*models.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE id = $1`
+
+	var delivery *models.WebhookDelivery
+	err := r.inScope(ctx, func(tx *sql.Tx) error {
+		var err error
+		delivery, err = scanDelivery(tx.QueryRowContext(ctx, query, id))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrDeliveryNotFound
+			}
+			return fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		return nil
+	})
+	return delivery, err
+}
+
+// GetWebhookDeliveries retrieves the most recent deliveries of a webhook,
+// newest first
+func (r *SupabaseWebhookRepository) GetWebhookDeliveries(ctx context.Context, webhookID string, limit int) ([]*models.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE webhook_id = $1 ORDER BY created_at DESC LIMIT $2`
+
+	var deliveries []*models.WebhookDelivery
+	err := r.inScope(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, query, webhookID, limit)
+		if err != nil {
+			return fmt.Errorf("failed to query webhook deliveries: %w", err)
+		}
+		deliveries, err = scanDeliveries(rows)
+		return err
+	})
+	return deliveries, err
+}
+
+// ClaimDueDeliveries retrieves up to limit pending deliveries due at now,
+// postponing them by lease. Rows locked by a concurrent claim are skipped so
+// several instances can run workers side by side.
+func (r *SupabaseWebhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error) {
+	query := `UPDATE webhook_deliveries SET next_attempt_at = $1 WHERE id IN (` +
+		`SELECT id FROM webhook_deliveries WHERE status = $2 AND next_attempt_at <= $3 ` +
+		`ORDER BY next_attempt_at LIMIT $4 FOR UPDATE SKIP LOCKED) ` +
+		`RETURNING ` + deliveryColumns
+
+	var deliveries []*models.WebhookDelivery
+	err := r.inScope(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, query, now.Add(lease), models.DeliveryPending, now, limit)
+		if err != nil {
+			return fmt.Errorf("failed to claim webhook deliveries: %w", err)
+		}
+		deliveries, err = scanDeliveries(rows)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// RETURNING reports the postponed time; callers expect the due time
+	for _, delivery := range deliveries {
+		delivery.NextAttemptAt = now
+	}
+	return deliveries, nil
+}
+
+// DeleteUserWebhooks removes all webhooks of a user together with their
+// deliveries, which the foreign key cascades to
+func (r *SupabaseWebhookRepository) DeleteUserWebhooks(ctx context.Context, userID string) error {
+	return r.inScope(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM webhooks WHERE user_id = $1`, userID); err != nil {
+			return fmt.Errorf("failed to delete webhooks: %w", err)
+		}
+		return nil
+	})
+}
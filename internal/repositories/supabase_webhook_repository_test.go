@@ -0,0 +1,175 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSupabaseWebhookRepository_CreateWebhook(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseWebhookRepository(mockDB)
+	ctx := context.Background()
+
+	webhook := models.NewWebhook(uuid.New().String(), "https://example.com/hook", models.WebhookEvents)
+
+	expectScope(mock, Scope{})
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO webhooks (id, user_id, url, secret, events, created_at) VALUES ($1, $2, $3, $4, $5, $6)`)).
+		WithArgs(webhook.ID, webhook.UserID, webhook.URL, webhook.Secret,
+			pq.Array([]string{"todo.created", "todo.completed", "todo.deleted"}), webhook.CreatedAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	// Execute the function being tested
+	err := repo.CreateWebhook(ctx, webhook)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseWebhookRepository_GetWebhook(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseWebhookRepository(mockDB)
+	ctx := context.Background()
+
+	webhookID := uuid.New().String()
+	userID := uuid.New().String()
+	now := time.Now()
+
+	rows := sqlmock.NewRows([]string{"id", "user_id", "url", "secret", "events", "created_at"}).
+		AddRow(webhookID, userID, "https://example.com/hook", "whsec_test", "{todo.created,todo.deleted}", now)
+
+	query := regexp.QuoteMeta(`SELECT id, user_id, url, secret, events, created_at FROM webhooks WHERE id = $1`)
+	expectScope(mock, Scope{})
+	mock.ExpectQuery(query).WithArgs(webhookID).WillReturnRows(rows)
+	mock.ExpectCommit()
+	expectScope(mock, Scope{})
+	mock.ExpectQuery(query).WithArgs("missing").WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	// Execute the function being tested
+	webhook, err := repo.GetWebhook(ctx, webhookID)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Equal(t, userID, webhook.UserID)
+	assert.Equal(t, []models.WebhookEvent{models.WebhookTodoCreated, models.WebhookTodoDeleted}, webhook.Events)
+
+	_, err = repo.GetWebhook(ctx, "missing")
+	assert.Equal(t, ErrWebhookNotFound, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseWebhookRepository_DeleteWebhook(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseWebhookRepository(mockDB)
+	ctx := context.Background()
+
+	query := regexp.QuoteMeta(`DELETE FROM webhooks WHERE id = $1`)
+	expectScope(mock, Scope{})
+	mock.ExpectExec(query).WithArgs("hook-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectScope(mock, Scope{})
+	mock.ExpectExec(query).WithArgs("missing").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	// Execute the function being tested
+	assert.NoError(t, repo.DeleteWebhook(ctx, "hook-1"))
+	assert.Equal(t, ErrWebhookNotFound, repo.DeleteWebhook(ctx, "missing"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseWebhookRepository_UpdateDelivery(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseWebhookRepository(mockDB)
+	ctx := context.Background()
+
+	webhook := models.NewWebhook(uuid.New().String(), "https://example.com/hook", models.WebhookEvents)
+	delivery := models.NewWebhookDelivery(webhook, models.WebhookTodoCreated, []byte(`{}`))
+	delivery.Status = models.DeliveryDead
+	delivery.Attempts = 3
+	delivery.ResponseStatus = 500
+	delivery.LastError = "unexpected status 500"
+
+	expectScope(mock, Scope{})
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE webhook_deliveries SET status = $1, attempts = $2, next_attempt_at = $3, response_status = $4, last_error = $5, updated_at = $6 WHERE id = $7`)).
+		WithArgs(models.DeliveryDead, 3, delivery.NextAttemptAt, 500, "unexpected status 500", delivery.UpdatedAt, delivery.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// Execute the function being tested
+	err := repo.UpdateDelivery(ctx, delivery)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseWebhookRepository_ClaimDueDeliveries(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseWebhookRepository(mockDB)
+	ctx := context.Background()
+
+	now := time.Now()
+	deliveryID := uuid.New().String()
+	webhookID := uuid.New().String()
+	userID := uuid.New().String()
+
+	rows := sqlmock.NewRows([]string{"id", "webhook_id", "user_id", "event", "payload", "status", "attempts", "next_attempt_at", "response_status", "last_error", "created_at", "updated_at"}).
+		AddRow(deliveryID, webhookID, userID, "todo.created", []byte(`{"event":"todo.created"}`), "pending", 1, now.Add(time.Minute), 502, "unexpected status 502", now, now)
+
+	expectScope(mock, Scope{})
+	mock.ExpectQuery(regexp.QuoteMeta(`UPDATE webhook_deliveries SET next_attempt_at = $1 WHERE id IN (SELECT id FROM webhook_deliveries WHERE status = $2 AND next_attempt_at <= $3 ORDER BY next_attempt_at LIMIT $4 FOR UPDATE SKIP LOCKED) RETURNING id, webhook_id, user_id, event, payload, status, attempts, next_attempt_at, response_status, last_error, created_at, updated_at`)).
+		WithArgs(now.Add(time.Minute), models.DeliveryPending, now, 10).
+		WillReturnRows(rows)
+	mock.ExpectCommit()
+
+	// Execute the function being tested
+	deliveries, err := repo.ClaimDueDeliveries(ctx, now, time.Minute, 10)
+
+	// Assertions
+	assert.NoError(t, err)
+	if assert.Len(t, deliveries, 1) {
+		assert.Equal(t, deliveryID, deliveries[0].ID)
+		assert.Equal(t, models.WebhookTodoCreated, deliveries[0].Event)
+		assert.JSONEq(t, `{"event":"todo.created"}`, string(deliveries[0].Payload))
+		assert.Equal(t, 1, deliveries[0].Attempts)
+		assert.Equal(t, now, deliveries[0].NextAttemptAt)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSupabaseWebhookRepository_DeleteUserWebhooks(t *testing.T) {
+	// Setup
+	mockDB, mock := setupMockDB(t)
+	repo := NewSupabaseWebhookRepository(mockDB)
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+	expectScope(mock, Scope{})
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM webhooks WHERE user_id = $1`)).
+		WithArgs(userID).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	// Execute the function being tested
+	err := repo.DeleteUserWebhooks(ctx, userID)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
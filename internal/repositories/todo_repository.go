@@ -2,24 +2,82 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"github.com/starbops/gottodo/internal/models"
 )
 
+// BulkResult reports the outcome of a bulk update for a single todo
+type BulkResult struct {
+	TodoID string
+
+	// Before and Todo hold the todo before and after the update
+	Before *models.Todo
+	Todo   *models.Todo
+
+	// Err is set when the todo was left unchanged
+	Err error
+}
+
+// BulkUpdateFunc changes a single todo as part of a bulk update. Returning an
+// error skips that todo without affecting the others.
+type BulkUpdateFunc func(todo *models.Todo) error
+
 // TodoRepository defines the interface for todo data access
 type TodoRepository interface {
-	// GetUserTodos retrieves all todos for a specific user
+	// GetUserTodos retrieves all todos for a specific user, excluding trashed
+	// ones, oldest first
 	GetUserTodos(ctx context.Context, userID string) ([]*models.Todo, error)
 
-	// GetTodo retrieves a specific todo by ID
+	// StreamUserTodos calls fn with each todo of a user, trashed ones
+	// included, oldest first, without loading them all at once. An error
+	// returned by fn stops the iteration and is returned.
+	StreamUserTodos(ctx context.Context, userID string, fn func(todo *models.Todo) error) error
+
+	// GetTodo retrieves a specific todo by ID, including trashed ones
 	GetTodo(ctx context.Context, todoID string) (*models.Todo, error)
 
-	// CreateTodo creates a new todo
+	// CreateTodo creates a new todo at version 1, generating its ID and
+	// timestamps if they are unset. It returns ErrTodoExists for a taken ID.
 	CreateTodo(ctx context.Context, todo *models.Todo) error
 
-	// UpdateTodo updates an existing todo
+	// CreateTodos creates several todos at version 1 atomically: either all
+	// of them are stored or, if any fails, none is
+	CreateTodos(ctx context.Context, todos []*models.Todo) error
+
+	// UpdateTodo updates an existing todo and increments its version. It
+	// returns ErrConflict if todo.Version no longer matches the stored version.
 	UpdateTodo(ctx context.Context, todo *models.Todo) error
 
-	// DeleteTodo deletes a todo by ID
-	DeleteTodo(ctx context.Context, todoID string) error
+	// PatchTodo writes only the named fields of the todo and increments its
+	// version. Like UpdateTodo it returns ErrConflict on a stale version.
+	PatchTodo(ctx context.Context, todo *models.Todo, fields []string) error
+
+	// UpdateTodos applies update to each of the given todos and writes all
+	// successful changes atomically, incrementing their versions. Missing todos
+	// and errors returned by update are reported per todo in the results, in
+	// the order of todoIDs; any other error aborts the whole batch.
+	UpdateTodos(ctx context.Context, todoIDs []string, update BulkUpdateFunc) ([]BulkResult, error)
+
+	// DeleteTodo moves a todo to the trash by ID and increments its version.
+	// Like UpdateTodo it returns ErrConflict if version no longer matches the
+	// stored version; todos already in the trash are not found.
+	DeleteTodo(ctx context.Context, todoID string, version int) error
+
+	// GetTrashedTodos retrieves the todos a user has moved to the trash, most
+	// recently trashed first
+	GetTrashedTodos(ctx context.Context, userID string) ([]*models.Todo, error)
+
+	// RestoreTodo takes a todo back out of the trash
+	RestoreTodo(ctx context.Context, todoID string) error
+
+	// EmptyTrash permanently removes all trashed todos of a user and returns them
+	EmptyTrash(ctx context.Context, userID string) ([]*models.Todo, error)
+
+	// PurgeTrash permanently removes todos trashed before the cutoff and returns them
+	PurgeTrash(ctx context.Context, deletedBefore time.Time) ([]*models.Todo, error)
+
+	// DeleteUserTodos permanently removes all todos of a user, trashed ones
+	// included
+	DeleteUserTodos(ctx context.Context, userID string) error
 }
@@ -0,0 +1,399 @@
+package repositories_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
+	"github.com/starbops/gottodo/internal/repositories/repositorytest"
+	"github.com/starbops/gottodo/migrations"
+	"github.com/starbops/gottodo/pkg/database"
+)
+
+// postgresDSNEnv names the environment variable with the connection string
+// of a Postgres database to run the suite against. The migrations are applied
+// to it and the suite leaves its todos behind, so use a scratch database. The
+// suite works across users like the background jobs, so connect as a role
+// that bypasses row-level security, such as the superuser.
+const postgresDSNEnv = "GOTTODO_TEST_POSTGRES_DSN"
+
+// rlsTestRole is the role the row-level security test switches to when the
+// role of the test database bypasses the policies
+const rlsTestRole = "gottodo_rls_test"
+
+func TestMemoryTodoRepository_Suite(t *testing.T) {
+	repositorytest.RunTodoRepositorySuite(t, func(t *testing.T) repositories.TodoRepository {
+		return repositories.NewMemoryTodoRepository()
+	})
+}
+
+func TestSQLiteTodoRepository_Suite(t *testing.T) {
+	repositorytest.RunTodoRepositorySuite(t, func(t *testing.T) repositories.TodoRepository {
+		// Concurrent writers wait for each other instead of failing as busy
+		dsn := "file:" + filepath.Join(t.TempDir(), "todos.db") + "?_journal_mode=WAL&_busy_timeout=10000&_txlock=immediate"
+		db, err := sql.Open("sqlite3", dsn)
+		if err != nil {
+			t.Fatalf("failed to open SQLite database: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		repo, err := repositories.NewSQLiteTodoRepository(context.Background(), db)
+		if err != nil {
+			t.Fatalf("failed to create repository: %v", err)
+		}
+		return repo
+	})
+}
+
+func TestSupabaseTodoRepository_Suite(t *testing.T) {
+	db := openPostgres(t)
+	if !bypassesRowLevelSecurity(t, db) {
+		t.Skip("the role of the connection does not bypass row-level security")
+	}
+
+	// Every subtest uses users of its own, so they can share the database
+	repo := repositories.NewSupabaseTodoRepository(db)
+	repositorytest.RunTodoRepositorySuite(t, func(t *testing.T) repositories.TodoRepository {
+		return repo
+	})
+}
+
+func TestSupabaseTodoRepository_RowLevelSecurity(t *testing.T) {
+	systemDB := openPostgres(t)
+	db := systemDB
+	if bypassesRowLevelSecurity(t, systemDB) {
+		db = openRestricted(t, systemDB)
+	} else {
+		systemDB = nil
+	}
+
+	repo := repositories.NewSupabaseTodoRepository(db)
+	alice, bob := uuid.New().String(), uuid.New().String()
+	aliceCtx := repositories.WithUserID(context.Background(), alice)
+	bobCtx := repositories.WithUserID(context.Background(), bob)
+
+	todo := &models.Todo{UserID: alice, Title: "Alice's"}
+	if err := repo.CreateTodo(aliceCtx, todo); err != nil {
+		t.Fatalf("failed to create todo: %v", err)
+	}
+	if _, err := repo.GetTodo(aliceCtx, todo.ID); err != nil {
+		t.Errorf("Expected the owner to read the todo, got %v", err)
+	}
+
+	// Another user neither sees nor changes the todo, even when asking by ID
+	if _, err := repo.GetTodo(bobCtx, todo.ID); !errors.Is(err, repositories.ErrTodoNotFound) {
+		t.Errorf("Expected ErrTodoNotFound for another user, got %v", err)
+	}
+	stolen := *todo
+	stolen.Title = "Bob's now"
+	if err := repo.UpdateTodo(bobCtx, &stolen); !errors.Is(err, repositories.ErrTodoNotFound) {
+		t.Errorf("Expected ErrTodoNotFound updating another user's todo, got %v", err)
+	}
+	if err := repo.DeleteTodo(bobCtx, todo.ID, todo.Version); !errors.Is(err, repositories.ErrTodoNotFound) {
+		t.Errorf("Expected ErrTodoNotFound trashing another user's todo, got %v", err)
+	}
+	if err := repo.CreateTodo(bobCtx, &models.Todo{UserID: alice, Title: "Planted"}); err == nil {
+		t.Error("Expected an error creating a todo for another user")
+	}
+
+	// Without a scope nothing is visible, and no session variable lets a
+	// transaction past the policy
+	if _, err := repo.GetTodo(context.Background(), todo.ID); !errors.Is(err, repositories.ErrTodoNotFound) {
+		t.Errorf("Expected ErrTodoNotFound without a scope, got %v", err)
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+	var visible int
+	if _, err := tx.Exec(`SELECT set_config('app.bypass_rls', 'on', true)`); err != nil {
+		t.Fatalf("failed to set app.bypass_rls: %v", err)
+	}
+	if err := tx.QueryRow(`SELECT count(*) FROM todos WHERE id = $1`, todo.ID).Scan(&visible); err != nil || visible != 0 {
+		t.Errorf("Expected app.bypass_rls to have no effect, got %d todos (%v)", visible, err)
+	}
+	tx.Rollback()
+
+	// The role of the background jobs sees every user's todos
+	if systemDB != nil {
+		if _, err := repositories.NewSupabaseTodoRepository(systemDB).GetTodo(context.Background(), todo.ID); err != nil {
+			t.Errorf("Expected the system role to read the todo, got %v", err)
+		}
+	}
+
+	stored, err := repo.GetTodo(aliceCtx, todo.ID)
+	if err != nil {
+		t.Fatalf("failed to read todo: %v", err)
+	}
+	if stored.Title != "Alice's" || stored.IsTrashed() {
+		t.Errorf("Expected the todo to be unchanged, got %+v", stored)
+	}
+}
+
+func TestSupabaseRepositories_RowLevelSecurity(t *testing.T) {
+	systemDB := openPostgres(t)
+	db := systemDB
+	if bypassesRowLevelSecurity(t, systemDB) {
+		db = openRestricted(t, systemDB)
+	} else {
+		systemDB = nil
+	}
+
+	alice, bob := uuid.New().String(), uuid.New().String()
+	aliceCtx := repositories.WithUserID(context.Background(), alice)
+	bobCtx := repositories.WithUserID(context.Background(), bob)
+
+	// Every table of the users' data hides the rows of other users
+	capture := repositories.NewSupabaseCaptureRepository(db)
+	secret := models.NewCaptureSecret(alice)
+	if err := capture.SaveCaptureSecret(aliceCtx, secret); err != nil {
+		t.Fatalf("failed to save capture secret: %v", err)
+	}
+	if _, err := capture.GetCaptureSecret(bobCtx, secret.Secret); !errors.Is(err, repositories.ErrCaptureSecretNotFound) {
+		t.Errorf("Expected ErrCaptureSecretNotFound for another user, got %v", err)
+	}
+	if _, err := capture.GetCaptureSecret(context.Background(), secret.Secret); !errors.Is(err, repositories.ErrCaptureSecretNotFound) {
+		t.Errorf("Expected ErrCaptureSecretNotFound without a scope, got %v", err)
+	}
+	if err := capture.SaveCaptureSecret(bobCtx, models.NewCaptureSecret(alice)); err == nil {
+		t.Error("Expected an error replacing another user's capture secret")
+	}
+
+	calendar := repositories.NewSupabaseCalendarFeedRepository(db)
+	feed := models.NewCalendarFeed(alice)
+	if err := calendar.SaveCalendarFeed(aliceCtx, feed); err != nil {
+		t.Fatalf("failed to save calendar feed: %v", err)
+	}
+	if _, err := calendar.GetCalendarFeed(bobCtx, feed.Secret); !errors.Is(err, repositories.ErrCalendarFeedNotFound) {
+		t.Errorf("Expected ErrCalendarFeedNotFound for another user, got %v", err)
+	}
+
+	appPasswords := repositories.NewSupabaseAppPasswordRepository(db)
+	appPassword, password := models.NewAppPassword(alice, "Phone")
+	if err := appPasswords.CreateAppPassword(aliceCtx, appPassword); err != nil {
+		t.Fatalf("failed to create app password: %v", err)
+	}
+	if _, err := appPasswords.GetAppPasswordByHash(bobCtx, models.HashAppPassword(password)); !errors.Is(err, repositories.ErrAppPasswordNotFound) {
+		t.Errorf("Expected ErrAppPasswordNotFound for another user, got %v", err)
+	}
+	if err := appPasswords.DeleteAppPassword(bobCtx, appPassword.ID); !errors.Is(err, repositories.ErrAppPasswordNotFound) {
+		t.Errorf("Expected ErrAppPasswordNotFound deleting another user's app password, got %v", err)
+	}
+
+	webhooks := repositories.NewSupabaseWebhookRepository(db)
+	webhook := models.NewWebhook(alice, "https://example.com/hook", models.WebhookEvents)
+	delivery := models.NewWebhookDelivery(webhook, models.WebhookEvents[0], []byte(`{}`))
+	if err := webhooks.CreateWebhook(aliceCtx, webhook); err != nil {
+		t.Fatalf("failed to create webhook: %v", err)
+	}
+	if err := webhooks.CreateDelivery(aliceCtx, delivery); err != nil {
+		t.Fatalf("failed to create webhook delivery: %v", err)
+	}
+	if _, err := webhooks.GetWebhook(bobCtx, webhook.ID); !errors.Is(err, repositories.ErrWebhookNotFound) {
+		t.Errorf("Expected ErrWebhookNotFound for another user, got %v", err)
+	}
+	if _, err := webhooks.GetDelivery(bobCtx, delivery.ID); !errors.Is(err, repositories.ErrDeliveryNotFound) {
+		t.Errorf("Expected ErrDeliveryNotFound for another user, got %v", err)
+	}
+	if err := webhooks.DeleteWebhook(bobCtx, webhook.ID); !errors.Is(err, repositories.ErrWebhookNotFound) {
+		t.Errorf("Expected ErrWebhookNotFound deleting another user's webhook, got %v", err)
+	}
+
+	activity := repositories.NewSupabaseActivityRepository(db)
+	todoID := uuid.New().String()
+	if err := activity.AppendActivity(aliceCtx, models.NewActivity(alice, todoID, models.ActivityCreated, nil)); err != nil {
+		t.Fatalf("failed to append activity: %v", err)
+	}
+	if history, err := activity.GetTodoActivity(bobCtx, todoID); err != nil || len(history) != 0 {
+		t.Errorf("Expected no activity for another user, got %v (%v)", history, err)
+	}
+	if err := activity.AppendActivity(bobCtx, models.NewActivity(alice, todoID, models.ActivityDeleted, nil)); err == nil {
+		t.Error("Expected an error appending activity of another user")
+	}
+
+	undo := repositories.NewSupabaseUndoRepository(db)
+	token := models.NewUndoToken(alice, models.ActivityDeleted, models.NewTodo(alice, "Call Ann", ""), time.Minute)
+	if err := undo.SaveUndoToken(aliceCtx, token); err != nil {
+		t.Fatalf("failed to save undo token: %v", err)
+	}
+	if _, err := undo.GetLatestUndoToken(bobCtx, alice); !errors.Is(err, repositories.ErrUndoTokenNotFound) {
+		t.Errorf("Expected ErrUndoTokenNotFound for another user, got %v", err)
+	}
+	if _, err := undo.GetUndoToken(bobCtx, token.Token); !errors.Is(err, repositories.ErrUndoTokenNotFound) {
+		t.Errorf("Expected ErrUndoTokenNotFound for another user, got %v", err)
+	}
+
+	idempotency := repositories.NewSupabaseIdempotencyRepository(db)
+	record := models.NewPendingIdempotencyRecord(alice, "key-1", "hash", time.Hour)
+	if _, err := idempotency.ReserveIdempotencyRecord(aliceCtx, record); err != nil {
+		t.Fatalf("failed to reserve idempotency key: %v", err)
+	}
+	if _, err := idempotency.GetIdempotencyRecord(bobCtx, alice, "key-1"); !errors.Is(err, repositories.ErrIdempotencyRecordNotFound) {
+		t.Errorf("Expected ErrIdempotencyRecordNotFound for another user, got %v", err)
+	}
+
+	deletions := repositories.NewSupabaseAccountDeletionRepository(db)
+	if err := deletions.SaveAccountDeletion(aliceCtx, models.NewAccountDeletion(alice, 0)); err != nil {
+		t.Fatalf("failed to save account deletion: %v", err)
+	}
+	if _, err := deletions.GetAccountDeletion(bobCtx, alice); !errors.Is(err, repositories.ErrAccountDeletionNotFound) {
+		t.Errorf("Expected ErrAccountDeletionNotFound for another user, got %v", err)
+	}
+	if due, err := deletions.GetDueAccountDeletions(context.Background(), time.Now()); err != nil || len(due) != 0 {
+		t.Errorf("Expected no account deletions without a scope, got %v (%v)", due, err)
+	}
+
+	// The owner still has everything, and the role of the background jobs
+	// and secret lookups sees it too
+	if _, err := capture.GetCaptureSecret(aliceCtx, secret.Secret); err != nil {
+		t.Errorf("Expected the owner to read the capture secret, got %v", err)
+	}
+	if _, err := undo.ConsumeUndoToken(aliceCtx, token.Token, alice); err != nil {
+		t.Errorf("Expected the owner to consume the undo token, got %v", err)
+	}
+	if _, err := webhooks.GetDelivery(aliceCtx, delivery.ID); err != nil {
+		t.Errorf("Expected the owner to read the webhook delivery, got %v", err)
+	}
+	if systemDB != nil {
+		if _, err := repositories.NewSupabaseCaptureRepository(systemDB).GetCaptureSecret(context.Background(), secret.Secret); err != nil {
+			t.Errorf("Expected the system role to read the capture secret, got %v", err)
+		}
+		if _, err := repositories.NewSupabaseCalendarFeedRepository(systemDB).GetCalendarFeed(context.Background(), feed.Secret); err != nil {
+			t.Errorf("Expected the system role to read the calendar feed, got %v", err)
+		}
+	}
+}
+
+func TestSupabaseUnitOfWork_Postgres(t *testing.T) {
+	db := openPostgres(t)
+
+	userID := uuid.New().String()
+	ctx := repositories.WithUserID(context.Background(), userID)
+	unitOfWork := repositories.NewSupabaseUnitOfWork(db)
+	todos := repositories.NewSupabaseTodoRepository(db)
+
+	// A failing unit of work leaves nothing behind
+	failure := errors.New("failed")
+	todo := &models.Todo{UserID: userID, Title: "Call Ann"}
+	err := unitOfWork.WithTx(ctx, func(repos *repositories.Repositories) error {
+		if err := repos.Todos.CreateTodo(ctx, todo); err != nil {
+			return err
+		}
+		if err := repos.Activity.AppendActivity(ctx, models.NewActivity(userID, todo.ID, models.ActivityCreated, nil)); err != nil {
+			return err
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("Expected the unit of work to fail, got %v", err)
+	}
+	if _, err := todos.GetTodo(ctx, todo.ID); !errors.Is(err, repositories.ErrTodoNotFound) {
+		t.Errorf("Expected the todo to be rolled back, got %v", err)
+	}
+	history, err := repositories.NewSupabaseActivityRepository(db).GetTodoActivity(ctx, todo.ID)
+	if err != nil || len(history) != 0 {
+		t.Errorf("Expected the activity to be rolled back, got %v (%v)", history, err)
+	}
+
+	// A successful one keeps everything
+	err = unitOfWork.WithTx(ctx, func(repos *repositories.Repositories) error {
+		return repos.Todos.CreateTodo(ctx, todo)
+	})
+	if err != nil {
+		t.Fatalf("Failed to run unit of work: %v", err)
+	}
+	if _, err := todos.GetTodo(ctx, todo.ID); err != nil {
+		t.Errorf("Expected the todo to be committed, got %v", err)
+	}
+}
+
+// bypassesRowLevelSecurity reports whether the role of db skips the policies
+func bypassesRowLevelSecurity(t *testing.T, db *sql.DB) bool {
+	t.Helper()
+	bypasses, err := database.BypassesRowLevelSecurity(context.Background(), db)
+	if err != nil {
+		t.Fatalf("failed to read the role: %v", err)
+	}
+	return bypasses
+}
+
+// openRestricted returns connections to the database of db that run as
+// rlsTestRole, a role subject to row-level security like the server's own
+func openRestricted(t *testing.T, db *sql.DB) *sql.DB {
+	t.Helper()
+
+	_, err := db.Exec(`DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = '` + rlsTestRole + `') THEN
+        CREATE ROLE ` + rlsTestRole + ` NOLOGIN NOSUPERUSER NOBYPASSRLS;
+    END IF;
+END
+$$;
+GRANT ` + rlsTestRole + ` TO CURRENT_USER;
+GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public TO ` + rlsTestRole)
+	if err != nil {
+		t.Fatalf("failed to create the restricted role: %v", err)
+	}
+
+	connector, err := pq.NewConnector(os.Getenv(postgresDSNEnv))
+	if err != nil {
+		t.Fatalf("failed to open Postgres database: %v", err)
+	}
+	restricted := sql.OpenDB(roleConnector{Connector: connector, role: rlsTestRole})
+	t.Cleanup(func() { restricted.Close() })
+	return restricted
+}
+
+// roleConnector switches every connection it opens to a role
+type roleConnector struct {
+	driver.Connector
+	role string
+}
+
+func (c roleConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.(driver.ExecerContext).ExecContext(ctx, "SET ROLE "+pq.QuoteIdentifier(c.role), nil); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// openPostgres opens and migrates the database named by postgresDSNEnv, or
+// skips the test if it is not set
+func openPostgres(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv(postgresDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", postgresDSNEnv)
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("failed to open Postgres database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := database.NewMigrator(db, migrations.FS)
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	return db
+}
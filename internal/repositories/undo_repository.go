@@ -0,0 +1,26 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/starbops/gottodo/internal/models"
+)
+
+// UndoRepository defines the interface for storing undo tokens
+type UndoRepository interface {
+	// SaveUndoToken stores a new undo token and discards expired ones
+	SaveUndoToken(ctx context.Context, token *models.UndoToken) error
+
+	// GetUndoToken retrieves an undo token without using it up
+	GetUndoToken(ctx context.Context, token string) (*models.UndoToken, error)
+
+	// ConsumeUndoToken removes an unexpired undo token of the user and
+	// returns it, so it can only be used once
+	ConsumeUndoToken(ctx context.Context, token string, userID string) (*models.UndoToken, error)
+
+	// GetLatestUndoToken retrieves the most recent unexpired undo token of a user
+	GetLatestUndoToken(ctx context.Context, userID string) (*models.UndoToken, error)
+
+	// DeleteUserUndoTokens removes all undo tokens of a user
+	DeleteUserUndoTokens(ctx context.Context, userID string) error
+}
@@ -0,0 +1,25 @@
+package repositories
+
+import "context"
+
+// UnitOfWork runs multi-step writes atomically across repositories
+type UnitOfWork interface {
+	// WithTx calls fn with repositories whose writes all take effect if fn
+	// returns nil and none do if it returns an error or panics. fn must only
+	// use the repositories it is given: the ones it was created from may wait
+	// for the unit of work to end. Calling WithTx on the given repositories'
+	// UnitOfWork joins the running unit of work.
+	WithTx(ctx context.Context, fn func(repos *Repositories) error) error
+}
+
+// joinedUnitOfWork is the UnitOfWork of repositories that are already part of
+// a unit of work, so nested units of work share it
+type joinedUnitOfWork struct {
+	repos *Repositories
+}
+
+// WithTx calls fn with the repositories of the running unit of work, which
+// decides whether the writes take effect
+func (u joinedUnitOfWork) WithTx(ctx context.Context, fn func(repos *Repositories) error) error {
+	return fn(u.repos)
+}
@@ -0,0 +1,46 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/starbops/gottodo/internal/models"
+)
+
+// WebhookRepository defines the interface for storing webhooks and the
+// deliveries of their events
+type WebhookRepository interface {
+	// CreateWebhook stores a new webhook
+	CreateWebhook(ctx context.Context, webhook *models.Webhook) error
+
+	// GetWebhook retrieves a webhook by ID
+	GetWebhook(ctx context.Context, id string) (*models.Webhook, error)
+
+	// GetUserWebhooks retrieves the webhooks of a user, oldest first
+	GetUserWebhooks(ctx context.Context, userID string) ([]*models.Webhook, error)
+
+	// DeleteWebhook removes a webhook together with its deliveries
+	DeleteWebhook(ctx context.Context, id string) error
+
+	// CreateDelivery stores a new delivery
+	CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+
+	// UpdateDelivery stores the outcome of a delivery attempt
+	UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+
+	// GetDelivery retrieves a delivery by ID
+	GetDelivery(ctx context.Context, id string) (*models.WebhookDelivery, error)
+
+	// GetWebhookDeliveries retrieves the most recent deliveries of a webhook,
+	// newest first
+	GetWebhookDeliveries(ctx context.Context, webhookID string, limit int) ([]*models.WebhookDelivery, error)
+
+	// ClaimDueDeliveries retrieves up to limit pending deliveries due at now,
+	// postponing them by lease so that no other worker claims them while
+	// they are being attempted
+	ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error)
+
+	// DeleteUserWebhooks removes all webhooks of a user together with their
+	// deliveries
+	DeleteUserWebhooks(ctx context.Context, userID string) error
+}
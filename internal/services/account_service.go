@@ -0,0 +1,326 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
+)
+
+// ErrNoAccountDeletion is returned when a user has no pending account deletion
+var ErrNoAccountDeletion = errors.New("no account deletion is pending")
+
+// archivePageSize is how many activity entries the data archive reads at once
+const archivePageSize = 200
+
+// UserDeleter removes users together with their sessions and identities, as
+// auth.AuthService does
+type UserDeleter interface {
+	DeleteUser(ctx context.Context, userID string) error
+}
+
+// AccountProfile is the account information of a user in their data archive
+type AccountProfile struct {
+	ID          string    `json:"id"`
+	Email       string    `json:"email"`
+	CreatedAt   time.Time `json:"created_at"`
+	HasPassword bool      `json:"has_password"`
+
+	// Deletion is the pending deletion of the account, if any
+	Deletion *models.AccountDeletion `json:"deletion,omitempty"`
+}
+
+// AccountService deletes accounts together with all their data, after a
+// grace period in which the user can change their mind, and packs the data
+// of an account into an archive
+type AccountService struct {
+	repos       *repositories.Repositories
+	users       UserDeleter
+	todoService *TodoService
+	grace       time.Duration
+}
+
+// NewAccountService creates a new AccountService deleting accounts grace
+// after the user asks for it, or at once if grace is 0
+func NewAccountService(repos *repositories.Repositories, users UserDeleter, todoService *TodoService, grace time.Duration) *AccountService {
+	return &AccountService{
+		repos:       repos,
+		users:       users,
+		todoService: todoService,
+		grace:       grace,
+	}
+}
+
+// GracePeriod returns how long after the user asks for it an account is deleted
+func (s *AccountService) GracePeriod() time.Duration {
+	return s.grace
+}
+
+// RequestDeletion schedules the deletion of a user's account at the end of
+// the grace period, or deletes it at once if there is none. The returned
+// deletion is due now in the latter case.
+func (s *AccountService) RequestDeletion(ctx context.Context, userID string) (*models.AccountDeletion, error) {
+	if userID == "" {
+		return nil, errors.New("user ID cannot be empty")
+	}
+
+	deletion := models.NewAccountDeletion(userID, s.grace)
+	if s.grace <= 0 {
+		return deletion, s.DeleteAccount(ctx, userID)
+	}
+	if err := s.repos.Deletions.SaveAccountDeletion(ctx, deletion); err != nil {
+		return nil, err
+	}
+	return deletion, nil
+}
+
+// GetDeletion retrieves the pending deletion of a user's account, or nil if
+// there is none
+func (s *AccountService) GetDeletion(ctx context.Context, userID string) (*models.AccountDeletion, error) {
+	deletion, err := s.repos.Deletions.GetAccountDeletion(ctx, userID)
+	if errors.Is(err, repositories.ErrAccountDeletionNotFound) {
+		return nil, nil
+	}
+	return deletion, err
+}
+
+// CancelDeletion keeps a user's account whose deletion is pending
+func (s *AccountService) CancelDeletion(ctx context.Context, userID string) error {
+	err := s.repos.Deletions.DeleteAccountDeletion(ctx, userID)
+	if errors.Is(err, repositories.ErrAccountDeletionNotFound) {
+		return ErrNoAccountDeletion
+	}
+	return err
+}
+
+// DeleteAccount permanently deletes a user's account and all its data. The
+// deletion is recorded first and removed last, so the account purger
+// completes a deletion that fails halfway. The ways into the account go
+// next, so that nothing new is added while the rest is removed. Every step
+// can be repeated.
+func (s *AccountService) DeleteAccount(ctx context.Context, userID string) error {
+	if userID == "" {
+		return errors.New("user ID cannot be empty")
+	}
+
+	_, err := s.repos.Deletions.GetAccountDeletion(ctx, userID)
+	if errors.Is(err, repositories.ErrAccountDeletionNotFound) {
+		err = s.repos.Deletions.SaveAccountDeletion(ctx, models.NewAccountDeletion(userID, 0))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record deletion of user %s: %w", userID, err)
+	}
+
+	if err := s.users.DeleteUser(ctx, userID); err != nil {
+		return fmt.Errorf("failed to delete user %s: %w", userID, err)
+	}
+
+	// The data goes in a single unit of work, so a failed deletion leaves it whole
+	return s.withTx(ctx, func(repos *repositories.Repositories) error {
+		steps := []struct {
+			name   string
+			delete func(ctx context.Context, userID string) error
+		}{
+			{"capture secret", repos.Capture.DeleteUserCaptureSecret},
+			{"calendar feed", repos.Calendar.DeleteUserCalendarFeed},
+			{"app passwords", repos.AppPasswords.DeleteUserAppPasswords},
+			{"webhooks", repos.Webhooks.DeleteUserWebhooks},
+			{"todos", repos.Todos.DeleteUserTodos},
+			{"activity", repos.Activity.DeleteUserActivity},
+			{"undo tokens", repos.Undo.DeleteUserUndoTokens},
+			{"idempotency records", repos.Idempotency.DeleteUserIdempotencyRecords},
+		}
+		for _, step := range steps {
+			if err := step.delete(ctx, userID); err != nil {
+				return fmt.Errorf("failed to delete %s of user %s: %w", step.name, userID, err)
+			}
+		}
+
+		err := repos.Deletions.DeleteAccountDeletion(ctx, userID)
+		if err != nil && !errors.Is(err, repositories.ErrAccountDeletionNotFound) {
+			return err
+		}
+		return nil
+	})
+}
+
+// withTx runs fn in a unit of work of the repositories, or directly on them
+// if they have none
+func (s *AccountService) withTx(ctx context.Context, fn func(repos *repositories.Repositories) error) error {
+	if s.repos.UnitOfWork == nil {
+		return fn(s.repos)
+	}
+	return s.repos.UnitOfWork.WithTx(ctx, fn)
+}
+
+// PurgeDeletedAccounts deletes the accounts whose grace period has ended
+func (s *AccountService) PurgeDeletedAccounts(ctx context.Context) (int, error) {
+	due, err := s.repos.Deletions.GetDueAccountDeletions(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	var errs []error
+	count := 0
+	for _, deletion := range due {
+		// Each account is deleted with the rights of its owner
+		if err := s.DeleteAccount(repositories.WithUserID(ctx, deletion.UserID), deletion.UserID); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		count++
+	}
+	return count, errors.Join(errs...)
+}
+
+// RunAccountPurger deletes due accounts every interval until the context is
+// cancelled
+func (s *AccountService) RunAccountPurger(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		count, err := s.PurgeDeletedAccounts(ctx)
+		if err != nil {
+			log.Printf("Failed to delete accounts: %v", err)
+		}
+		if count > 0 {
+			log.Printf("Deleted %d accounts", count)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// WriteArchive writes a zip archive of JSON files holding all data of a
+// user to w: the profile, the todos with their history as exported by
+// TodoService.Export, the activity, the webhooks and the app passwords.
+// Like the hashes of the app passwords, the webhook secrets are left out.
+func (s *AccountService) WriteArchive(ctx context.Context, profile AccountProfile, w io.Writer) error {
+	userID := profile.ID
+	if userID == "" {
+		return errors.New("user ID cannot be empty")
+	}
+
+	deletion, err := s.GetDeletion(ctx, userID)
+	if err != nil {
+		return err
+	}
+	profile.Deletion = deletion
+
+	archive := zip.NewWriter(w)
+	files := []struct {
+		name  string
+		write func(w io.Writer) error
+	}{
+		{"profile.json", func(w io.Writer) error {
+			return writeArchiveJSON(w, profile)
+		}},
+		{"todos.json", func(w io.Writer) error {
+			return s.todoService.Export(ctx, userID, ExportJSON, w)
+		}},
+		{"activity.json", func(w io.Writer) error {
+			return s.writeActivity(ctx, userID, w)
+		}},
+		{"webhooks.json", func(w io.Writer) error {
+			webhooks, err := s.repos.Webhooks.GetUserWebhooks(ctx, userID)
+			if err != nil {
+				return err
+			}
+			archived := make([]archivedWebhook, len(webhooks))
+			for i, webhook := range webhooks {
+				archived[i] = archivedWebhook{ID: webhook.ID, URL: webhook.URL, Events: webhook.Events, CreatedAt: webhook.CreatedAt}
+			}
+			return writeArchiveJSON(w, archived)
+		}},
+		{"app_passwords.json", func(w io.Writer) error {
+			passwords, err := s.repos.AppPasswords.GetUserAppPasswords(ctx, userID)
+			if err != nil {
+				return err
+			}
+			return writeArchiveJSON(w, nonNil(passwords))
+		}},
+	}
+	for _, file := range files {
+		f, err := archive.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: time.Now()})
+		if err != nil {
+			return err
+		}
+		if err := file.write(f); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file.name, err)
+		}
+	}
+	return archive.Close()
+}
+
+// archivedWebhook is a webhook as written to the archive, without the secret
+// that signs its payloads
+type archivedWebhook struct {
+	ID        string                `json:"id"`
+	URL       string                `json:"url"`
+	Events    []models.WebhookEvent `json:"events"`
+	CreatedAt time.Time             `json:"created_at"`
+}
+
+// writeActivity writes all activity of a user as a JSON array, newest first,
+// one page at a time
+func (s *AccountService) writeActivity(ctx context.Context, userID string, w io.Writer) error {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+	count := 0
+	for offset := 0; ; offset += archivePageSize {
+		entries, err := s.repos.Activity.GetUserActivity(ctx, userID, archivePageSize, offset)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			data, err := json.Marshal(entry)
+			if err != nil {
+				return err
+			}
+			separator := ",\n"
+			if count == 0 {
+				separator = "\n"
+			}
+			count++
+			if _, err := io.WriteString(w, separator); err != nil {
+				return err
+			}
+			if _, err := w.Write(data); err != nil {
+				return err
+			}
+		}
+		if len(entries) < archivePageSize {
+			break
+		}
+	}
+	_, err := io.WriteString(w, "\n]\n")
+	return err
+}
+
+// writeArchiveJSON writes an indented JSON file of the archive
+func writeArchiveJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// nonNil turns a nil slice into an empty one, so that it is written as []
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
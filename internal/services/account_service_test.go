@@ -0,0 +1,320 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
+)
+
+// fakeUserDeleter records the users it deletes, failing while err is set
+type fakeUserDeleter struct {
+	deleted []string
+	err     error
+}
+
+func (d *fakeUserDeleter) DeleteUser(ctx context.Context, userID string) error {
+	if d.err != nil {
+		return d.err
+	}
+	d.deleted = append(d.deleted, userID)
+	return nil
+}
+
+// seedAccount gives a user data in every repository
+func seedAccount(t *testing.T, repos *repositories.Repositories, todoService *TodoService, userID string) {
+	t.Helper()
+	ctx := context.Background()
+
+	todo := models.NewTodo(userID, "Call Ann", "")
+	if err := todoService.CreateTodo(ctx, todo); err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+	appPassword, _ := models.NewAppPassword(userID, "Phone")
+	webhook := models.NewWebhook(userID, "https://example.com/hook", []models.WebhookEvent{models.WebhookTodoCreated})
+	for _, err := range []error{
+		repos.Capture.SaveCaptureSecret(ctx, models.NewCaptureSecret(userID)),
+		repos.Calendar.SaveCalendarFeed(ctx, models.NewCalendarFeed(userID)),
+		repos.AppPasswords.CreateAppPassword(ctx, appPassword),
+		repos.Webhooks.CreateWebhook(ctx, webhook),
+		repos.Webhooks.CreateDelivery(ctx, models.NewWebhookDelivery(webhook, models.WebhookTodoCreated, []byte(`{}`))),
+		repos.Undo.SaveUndoToken(ctx, models.NewUndoToken(userID, models.ActivityDeleted, todo, time.Hour)),
+		repos.Idempotency.SaveIdempotencyRecord(ctx, models.NewIdempotencyRecord(userID, "key", "hash", 201, "application/json", nil, time.Hour)),
+	} {
+		if err != nil {
+			t.Fatalf("Failed to seed account: %v", err)
+		}
+	}
+}
+
+// assertAccountData checks whether a user still has data in the repositories
+func assertAccountData(t *testing.T, repos *repositories.Repositories, userID string, want bool) {
+	t.Helper()
+	ctx := context.Background()
+
+	found := map[string]bool{}
+	count := 0
+	repos.Todos.StreamUserTodos(ctx, userID, func(*models.Todo) error { count++; return nil })
+	found["todos"] = count > 0
+	activity, _ := repos.Activity.GetUserActivity(ctx, userID, 10, 0)
+	found["activity"] = len(activity) > 0
+	_, err := repos.Capture.GetUserCaptureSecret(ctx, userID)
+	found["capture secret"] = err == nil
+	_, err = repos.Calendar.GetUserCalendarFeed(ctx, userID)
+	found["calendar feed"] = err == nil
+	passwords, _ := repos.AppPasswords.GetUserAppPasswords(ctx, userID)
+	found["app passwords"] = len(passwords) > 0
+	webhooks, _ := repos.Webhooks.GetUserWebhooks(ctx, userID)
+	found["webhooks"] = len(webhooks) > 0
+	_, err = repos.Undo.GetLatestUndoToken(ctx, userID)
+	found["undo tokens"] = err == nil
+	_, err = repos.Idempotency.GetIdempotencyRecord(ctx, userID, "key")
+	found["idempotency records"] = err == nil
+
+	for name, got := range found {
+		if got != want {
+			t.Errorf("Expected %s of user %s to exist: %v, got %v", name, userID, want, got)
+		}
+	}
+}
+
+// newAccountTestService returns an AccountService over in-memory repositories
+func newAccountTestService(grace time.Duration) (*AccountService, *repositories.Repositories, *TodoService, *fakeUserDeleter) {
+	repos := repositories.NewMemoryRepositories()
+	todoService := NewTodoService(repos.Todos, WithActivityRepository(repos.Activity))
+	users := &fakeUserDeleter{}
+	return NewAccountService(repos, users, todoService, grace), repos, todoService, users
+}
+
+func TestAccountService_GracePeriod(t *testing.T) {
+	service, repos, todoService, users := newAccountTestService(time.Hour)
+	ctx := context.Background()
+	user1, user2 := uuid.New().String(), uuid.New().String()
+	seedAccount(t, repos, todoService, user1)
+	seedAccount(t, repos, todoService, user2)
+
+	deletion, err := service.RequestDeletion(ctx, user1)
+	if err != nil {
+		t.Fatalf("Failed to request deletion: %v", err)
+	}
+	if deletion.DeleteAt.Sub(deletion.RequestedAt) != time.Hour {
+		t.Errorf("Expected the deletion at the end of the grace period, got %+v", deletion)
+	}
+
+	// Nothing is deleted during the grace period, which can be cancelled
+	if count, err := service.PurgeDeletedAccounts(ctx); err != nil || count != 0 {
+		t.Errorf("Expected no account to be due, got %d (%v)", count, err)
+	}
+	if pending, _ := service.GetDeletion(ctx, user1); pending == nil {
+		t.Errorf("Expected the deletion to be pending")
+	}
+	if err := service.CancelDeletion(ctx, user1); err != nil {
+		t.Fatalf("Failed to cancel deletion: %v", err)
+	}
+	if err := service.CancelDeletion(ctx, user1); err != ErrNoAccountDeletion {
+		t.Errorf("Expected ErrNoAccountDeletion, got %v", err)
+	}
+	if pending, _ := service.GetDeletion(ctx, user1); pending != nil {
+		t.Errorf("Expected no pending deletion, got %+v", pending)
+	}
+
+	// Once the grace period ends all data of the user goes
+	if _, err := service.RequestDeletion(ctx, user1); err != nil {
+		t.Fatalf("Failed to request deletion: %v", err)
+	}
+	repos.Deletions.SaveAccountDeletion(ctx, &models.AccountDeletion{UserID: user1, RequestedAt: time.Now().Add(-2 * time.Hour), DeleteAt: time.Now().Add(-time.Hour)})
+	if count, err := service.PurgeDeletedAccounts(ctx); err != nil || count != 1 {
+		t.Fatalf("Expected the account to be deleted, got %d (%v)", count, err)
+	}
+	assertAccountData(t, repos, user1, false)
+	assertAccountData(t, repos, user2, true)
+	if len(users.deleted) != 1 || users.deleted[0] != user1 {
+		t.Errorf("Expected user1 to be deleted, got %v", users.deleted)
+	}
+	if pending, _ := service.GetDeletion(ctx, user1); pending != nil {
+		t.Errorf("Expected the deletion to be done, got %+v", pending)
+	}
+}
+
+func TestAccountService_ImmediateDeletion(t *testing.T) {
+	service, repos, todoService, _ := newAccountTestService(0)
+	ctx := context.Background()
+	userID := uuid.New().String()
+	seedAccount(t, repos, todoService, userID)
+
+	deletion, err := service.RequestDeletion(ctx, userID)
+	if err != nil {
+		t.Fatalf("Failed to delete account: %v", err)
+	}
+	if deletion.DeleteAt.After(time.Now()) {
+		t.Errorf("Expected the account to be deleted at once, got %+v", deletion)
+	}
+	assertAccountData(t, repos, userID, false)
+}
+
+func TestAccountService_RetriesFailedDeletion(t *testing.T) {
+	service, repos, todoService, users := newAccountTestService(time.Hour)
+	ctx := context.Background()
+	userID := uuid.New().String()
+	seedAccount(t, repos, todoService, userID)
+	repos.Deletions.SaveAccountDeletion(ctx, &models.AccountDeletion{UserID: userID, DeleteAt: time.Now().Add(-time.Minute)})
+
+	users.err = errors.New("unavailable")
+	if count, err := service.PurgeDeletedAccounts(ctx); err == nil || count != 0 {
+		t.Fatalf("Expected the deletion to fail, got %d (%v)", count, err)
+	}
+	if pending, _ := service.GetDeletion(ctx, userID); pending == nil {
+		t.Fatalf("Expected the failed deletion to stay pending")
+	}
+
+	users.err = nil
+	if count, err := service.PurgeDeletedAccounts(ctx); err != nil || count != 1 {
+		t.Fatalf("Expected the deletion to be retried, got %d (%v)", count, err)
+	}
+	assertAccountData(t, repos, userID, false)
+}
+
+func TestAccountService_ImmediateDeletionCompletedByPurger(t *testing.T) {
+	service, repos, todoService, users := newAccountTestService(0)
+	ctx := context.Background()
+	userID := uuid.New().String()
+	seedAccount(t, repos, todoService, userID)
+
+	// The user is gone when the data fails to go, so the deletion is left
+	// for the purger instead of being lost
+	unitOfWork := repos.UnitOfWork
+	repos.UnitOfWork = failingIdempotencyUnitOfWork{unitOfWork}
+	if _, err := service.RequestDeletion(ctx, userID); err == nil {
+		t.Fatal("Expected the deletion to fail")
+	}
+	if len(users.deleted) != 1 {
+		t.Fatalf("Expected the user to be deleted, got %v", users.deleted)
+	}
+	if pending, _ := service.GetDeletion(ctx, userID); pending == nil || pending.DeleteAt.After(time.Now()) {
+		t.Fatalf("Expected a due deletion to be recorded, got %+v", pending)
+	}
+
+	repos.UnitOfWork = unitOfWork
+	if count, err := service.PurgeDeletedAccounts(ctx); err != nil || count != 1 {
+		t.Fatalf("Expected the purger to complete the deletion, got %d (%v)", count, err)
+	}
+	assertAccountData(t, repos, userID, false)
+	if pending, _ := service.GetDeletion(ctx, userID); pending != nil {
+		t.Errorf("Expected the deletion to be done, got %+v", pending)
+	}
+}
+
+// failingIdempotencyRepository fails to delete idempotency records
+type failingIdempotencyRepository struct {
+	repositories.IdempotencyRepository
+}
+
+func (r failingIdempotencyRepository) DeleteUserIdempotencyRecords(ctx context.Context, userID string) error {
+	return errors.New("unavailable")
+}
+
+// failingIdempotencyUnitOfWork runs units of work whose idempotency records
+// cannot be deleted
+type failingIdempotencyUnitOfWork struct {
+	repositories.UnitOfWork
+}
+
+func (u failingIdempotencyUnitOfWork) WithTx(ctx context.Context, fn func(repos *repositories.Repositories) error) error {
+	return u.UnitOfWork.WithTx(ctx, func(repos *repositories.Repositories) error {
+		failing := *repos
+		failing.Idempotency = failingIdempotencyRepository{repos.Idempotency}
+		return fn(&failing)
+	})
+}
+
+func TestAccountService_FailedDeletionKeepsData(t *testing.T) {
+	service, repos, todoService, _ := newAccountTestService(time.Hour)
+	ctx := context.Background()
+	userID := uuid.New().String()
+	seedAccount(t, repos, todoService, userID)
+	repos.Deletions.SaveAccountDeletion(ctx, &models.AccountDeletion{UserID: userID, DeleteAt: time.Now().Add(-time.Minute)})
+
+	// The last step fails, so the steps before it are undone
+	unitOfWork := repos.UnitOfWork
+	repos.UnitOfWork = failingIdempotencyUnitOfWork{unitOfWork}
+	if count, err := service.PurgeDeletedAccounts(ctx); err == nil || count != 0 {
+		t.Fatalf("Expected the deletion to fail, got %d (%v)", count, err)
+	}
+	assertAccountData(t, repos, userID, true)
+	if pending, _ := service.GetDeletion(ctx, userID); pending == nil {
+		t.Fatalf("Expected the failed deletion to stay pending")
+	}
+
+	repos.UnitOfWork = unitOfWork
+	if count, err := service.PurgeDeletedAccounts(ctx); err != nil || count != 1 {
+		t.Fatalf("Expected the deletion to be retried, got %d (%v)", count, err)
+	}
+	assertAccountData(t, repos, userID, false)
+}
+
+func TestAccountService_WriteArchive(t *testing.T) {
+	service, repos, todoService, _ := newAccountTestService(time.Hour)
+	ctx := context.Background()
+	userID := uuid.New().String()
+	seedAccount(t, repos, todoService, userID)
+	for i := 0; i < archivePageSize; i++ {
+		repos.Activity.AppendActivity(ctx, models.NewActivity(userID, uuid.New().String(), models.ActivityUpdated, nil))
+	}
+	if _, err := service.RequestDeletion(ctx, userID); err != nil {
+		t.Fatalf("Failed to request deletion: %v", err)
+	}
+
+	var buf bytes.Buffer
+	profile := AccountProfile{ID: userID, Email: "ann@example.com", CreatedAt: time.Now(), HasPassword: true}
+	if err := service.WriteArchive(ctx, profile, &buf); err != nil {
+		t.Fatalf("Failed to write archive: %v", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Failed to read archive: %v", err)
+	}
+	files := map[string][]byte{}
+	for _, f := range archive.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", f.Name, err)
+		}
+		files[f.Name], _ = io.ReadAll(r)
+		r.Close()
+	}
+
+	var gotProfile AccountProfile
+	if err := json.Unmarshal(files["profile.json"], &gotProfile); err != nil || gotProfile.Email != "ann@example.com" || gotProfile.Deletion == nil {
+		t.Errorf("Expected the profile with the pending deletion, got %s (%v)", files["profile.json"], err)
+	}
+	var todos struct {
+		Todos []ExportedTodo `json:"todos"`
+	}
+	if err := json.Unmarshal(files["todos.json"], &todos); err != nil || len(todos.Todos) != 1 || len(todos.Todos[0].History) != 1 {
+		t.Errorf("Expected the todo with its history, got %s (%v)", files["todos.json"], err)
+	}
+	var activity []*models.Activity
+	if err := json.Unmarshal(files["activity.json"], &activity); err != nil || len(activity) != archivePageSize+1 {
+		t.Errorf("Expected all activity across pages, got %d entries (%v)", len(activity), err)
+	}
+	var webhooks []map[string]any
+	if err := json.Unmarshal(files["webhooks.json"], &webhooks); err != nil || len(webhooks) != 1 || webhooks[0]["url"] == nil {
+		t.Errorf("Expected the webhook, got %s (%v)", files["webhooks.json"], err)
+	}
+	if bytes.Contains(files["webhooks.json"], []byte("secret")) {
+		t.Errorf("Expected the webhook secret to be left out, got %s", files["webhooks.json"])
+	}
+	var passwords []map[string]any
+	if err := json.Unmarshal(files["app_passwords.json"], &passwords); err != nil || len(passwords) != 1 || passwords[0]["name"] != "Phone" {
+		t.Errorf("Expected the app password, got %s (%v)", files["app_passwords.json"], err)
+	}
+}
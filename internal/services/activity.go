@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
+)
+
+const (
+	// DefaultActivityPageSize is the number of feed entries returned when no limit is given
+	DefaultActivityPageSize = 20
+
+	// MaxActivityPageSize is the largest page of feed entries a caller may request
+	MaxActivityPageSize = 100
+)
+
+// ActivityPage is a single page of a user's activity feed
+type ActivityPage struct {
+	Items      []*models.Activity `json:"items"`
+	Limit      int                `json:"limit"`
+	Offset     int                `json:"offset"`
+	NextOffset *int               `json:"next_offset"`
+}
+
+// GetTodoHistory retrieves the change history of a todo owned by the user
+func (s *TodoService) GetTodoHistory(ctx context.Context, todoID string, userID string) ([]*models.Activity, error) {
+	// Verify ownership first
+	if _, err := s.GetTodo(ctx, todoID, userID); err != nil {
+		return nil, err
+	}
+
+	if s.activityRepo == nil {
+		return []*models.Activity{}, nil
+	}
+	return s.activityRepo.GetTodoActivity(ctx, todoID)
+}
+
+// GetActivityFeed retrieves a page of the changes made by a user, newest first
+func (s *TodoService) GetActivityFeed(ctx context.Context, userID string, limit, offset int) (*ActivityPage, error) {
+	if limit <= 0 {
+		limit = DefaultActivityPageSize
+	}
+	if limit > MaxActivityPageSize {
+		limit = MaxActivityPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	page := &ActivityPage{
+		Items:  []*models.Activity{},
+		Limit:  limit,
+		Offset: offset,
+	}
+	if s.activityRepo == nil {
+		return page, nil
+	}
+
+	// Fetch one extra entry to find out whether another page exists
+	entries, err := s.activityRepo.GetUserActivity(ctx, userID, limit+1, offset)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) > limit {
+		entries = entries[:limit]
+		next := offset + limit
+		page.NextOffset = &next
+	}
+	if entries != nil {
+		page.Items = entries
+	}
+
+	return page, nil
+}
+
+// todoChange is a stored change to a todo, to be recorded in the activity log
+type todoChange struct {
+	actorID string
+	todoID  string
+	action  models.ActivityAction
+	before  *models.Todo
+	after   *models.Todo
+}
+
+// write runs fn with the todo repository to write to and records the changes
+// it returns. With a unit of work, the changes and their activity entries are
+// stored atomically, and announced once they are.
+func (s *TodoService) write(ctx context.Context, fn func(todos repositories.TodoRepository) ([]todoChange, error)) error {
+	return s.writeRepos(ctx, func(repos *repositories.Repositories) ([]todoChange, error) {
+		return fn(repos.Todos)
+	})
+}
+
+// writeRepos is write for changes that also touch other repositories, which
+// fn finds in repos when they take part in the unit of work. Without a unit
+// of work, repos only holds the todo and activity repositories.
+func (s *TodoService) writeRepos(ctx context.Context, fn func(repos *repositories.Repositories) ([]todoChange, error)) error {
+	if s.unitOfWork == nil {
+		changes, err := fn(&repositories.Repositories{Todos: s.todoRepo, Activity: s.activityRepo})
+		if err != nil {
+			return err
+		}
+		for _, change := range changes {
+			s.recordActivity(ctx, change.actorID, change.todoID, change.action, change.before, change.after)
+		}
+		return nil
+	}
+
+	var changes []todoChange
+	err := s.unitOfWork.WithTx(ctx, func(repos *repositories.Repositories) error {
+		var err error
+		changes, err = fn(repos)
+		if err != nil || s.activityRepo == nil {
+			return err
+		}
+
+		for _, change := range changes {
+			activity := models.NewActivity(change.actorID, change.todoID, change.action, models.DiffTodos(change.before, change.after))
+			if err := repos.Activity.AppendActivity(ctx, activity); err != nil {
+				return fmt.Errorf("failed to record %s activity for todo %s: %w", change.action, change.todoID, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		s.publishEvent(change.todoID, change.action, change.before, change.after)
+		s.notifyWebhooks(ctx, change.action, change.before, change.after)
+	}
+	return nil
+}
+
+// recordActivity appends a change to the activity log and announces it on
+// the owner's event stream and webhooks. The change itself has already been
+// persisted, so a failure here is logged rather than returned.
+func (s *TodoService) recordActivity(ctx context.Context, actorID, todoID string, action models.ActivityAction, before, after *models.Todo) {
+	s.publishEvent(todoID, action, before, after)
+	s.notifyWebhooks(ctx, action, before, after)
+
+	if s.activityRepo == nil {
+		return
+	}
+
+	activity := models.NewActivity(actorID, todoID, action, models.DiffTodos(before, after))
+	if err := s.activityRepo.AppendActivity(ctx, activity); err != nil {
+		log.Printf("Failed to record %s activity for todo %s: %v", action, todoID, err)
+	}
+}
+
+// publishEvent announces a change on the event stream of the todo's owner
+func (s *TodoService) publishEvent(todoID string, action models.ActivityAction, before, after *models.Todo) {
+	owner := after
+	if owner == nil {
+		owner = before
+	}
+	if s.events == nil || owner == nil {
+		return
+	}
+
+	event := &TodoEvent{
+		Type:   todoEventType(action),
+		UserID: owner.UserID,
+		TodoID: todoID,
+	}
+
+	// Deleted todos are announced by ID only; others carry a copy so
+	// subscribers never observe later changes to the caller's todo
+	if event.Type != TodoEventDeleted && after != nil {
+		todo := *after
+		event.Todo = &todo
+	}
+
+	s.events.Publish(event)
+}
+
+// notifyWebhooks enqueues the change for the webhooks of the todo's owner
+func (s *TodoService) notifyWebhooks(ctx context.Context, action models.ActivityAction, before, after *models.Todo) {
+	todo := after
+	if todo == nil {
+		todo = before
+	}
+	if s.webhooks == nil || todo == nil {
+		return
+	}
+
+	event, ok := webhookEvent(action)
+	if !ok {
+		return
+	}
+	s.webhooks.Enqueue(ctx, todo.UserID, event, todo)
+}
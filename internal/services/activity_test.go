@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
+)
+
+func TestTodoService_RecordsActivity(t *testing.T) {
+	activityRepo := repositories.NewMemoryActivityRepository()
+	service := NewTodoService(NewMockTodoRepository(), WithActivityRepository(activityRepo))
+	ctx := context.Background()
+
+	// Create, edit, complete and delete a todo
+	todo := &models.Todo{
+		UserID:      "user1",
+		Title:       "Original",
+		Description: "Description",
+	}
+	if err := service.CreateTodo(ctx, todo); err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+	if _, err := service.UpdateTodo(ctx, todo.ID, "user1", "Renamed", "Description", 0); err != nil {
+		t.Fatalf("Failed to update todo: %v", err)
+	}
+	if err := service.UpdateTodoStatus(ctx, todo.ID, "user1", true, 0); err != nil {
+		t.Fatalf("Failed to update todo status: %v", err)
+	}
+
+	// Check the history while the todo still exists
+	history, err := service.GetTodoHistory(ctx, todo.ID, "user1")
+	if err != nil {
+		t.Fatalf("Failed to get todo history: %v", err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("Expected 3 history entries, got %d", len(history))
+	}
+
+	completed := history[0]
+	if completed.Action != models.ActivityCompleted || completed.ActorID != "user1" {
+		t.Errorf("Expected completed entry by user1, got %s by %s", completed.Action, completed.ActorID)
+	}
+
+	updated := history[1]
+	if len(updated.Changes) != 1 || updated.Changes[0].Field != "title" {
+		t.Fatalf("Expected a single title change, got %+v", updated.Changes)
+	}
+	if updated.Changes[0].Before != "Original" || updated.Changes[0].After != "Renamed" {
+		t.Errorf("Expected title change Original -> Renamed, got %v -> %v", updated.Changes[0].Before, updated.Changes[0].After)
+	}
+
+	if err := service.DeleteTodo(ctx, todo.ID, "user1", 0); err != nil {
+		t.Fatalf("Failed to delete todo: %v", err)
+	}
+
+	// The feed still shows the deletion after the todo is gone
+	page, err := service.GetActivityFeed(ctx, "user1", 0, 0)
+	if err != nil {
+		t.Fatalf("Failed to get activity feed: %v", err)
+	}
+	if len(page.Items) != 4 {
+		t.Fatalf("Expected 4 feed entries, got %d", len(page.Items))
+	}
+	if page.Items[0].Action != models.ActivityDeleted {
+		t.Errorf("Expected newest entry to be a deletion, got %s", page.Items[0].Action)
+	}
+	if page.NextOffset != nil {
+		t.Errorf("Expected no next page, got offset %d", *page.NextOffset)
+	}
+}
+
+func TestTodoService_GetActivityFeed_Pagination(t *testing.T) {
+	activityRepo := repositories.NewMemoryActivityRepository()
+	service := NewTodoService(NewMockTodoRepository(), WithActivityRepository(activityRepo))
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		if err := service.CreateTodo(ctx, &models.Todo{UserID: "user1", Title: "Todo"}); err != nil {
+			t.Fatalf("Failed to create todo: %v", err)
+		}
+	}
+
+	page, err := service.GetActivityFeed(ctx, "user1", 2, 0)
+	if err != nil {
+		t.Fatalf("Failed to get activity feed: %v", err)
+	}
+	if len(page.Items) != 2 || page.NextOffset == nil || *page.NextOffset != 2 {
+		t.Fatalf("Expected 2 items with next offset 2, got %d items", len(page.Items))
+	}
+
+	page, err = service.GetActivityFeed(ctx, "user1", 2, 4)
+	if err != nil {
+		t.Fatalf("Failed to get activity feed: %v", err)
+	}
+	if len(page.Items) != 1 || page.NextOffset != nil {
+		t.Errorf("Expected a final page with 1 item, got %d items", len(page.Items))
+	}
+}
+
+func TestTodoService_GetTodoHistory_Ownership(t *testing.T) {
+	service := NewTodoService(NewMockTodoRepository(), WithActivityRepository(repositories.NewMemoryActivityRepository()))
+	ctx := context.Background()
+
+	todo := &models.Todo{UserID: "user1", Title: "Private"}
+	if err := service.CreateTodo(ctx, todo); err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+
+	if _, err := service.GetTodoHistory(ctx, todo.ID, "user2"); err == nil {
+		t.Errorf("Expected an error when reading another user's todo history")
+	}
+}
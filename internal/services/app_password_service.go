@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
+)
+
+// maxAppPasswordNameLength bounds the names given to app passwords
+const maxAppPasswordNameLength = 100
+
+// ErrInvalidAppPassword is returned when an app password does not belong to
+// the user it is presented for
+var ErrInvalidAppPassword = errors.New("invalid app password")
+
+// ErrEmptyAppPasswordName is returned when an app password is created without a name
+var ErrEmptyAppPasswordName = errors.New("app password name cannot be empty")
+
+// AppPasswordService manages the app passwords that sync clients
+// authenticate with
+type AppPasswordService struct {
+	repo repositories.AppPasswordRepository
+}
+
+// NewAppPasswordService creates a new AppPasswordService
+func NewAppPasswordService(repo repositories.AppPasswordRepository) *AppPasswordService {
+	return &AppPasswordService{
+		repo: repo,
+	}
+}
+
+// CreateAppPassword creates an app password for a user, returning it
+// together with the password, which is not shown again
+func (s *AppPasswordService) CreateAppPassword(ctx context.Context, userID, name string) (*models.AppPassword, string, error) {
+	if userID == "" {
+		return nil, "", errors.New("user ID cannot be empty")
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", ErrEmptyAppPasswordName
+	}
+	if len([]rune(name)) > maxAppPasswordNameLength {
+		name = string([]rune(name)[:maxAppPasswordNameLength])
+	}
+
+	appPassword, password := models.NewAppPassword(userID, name)
+	if err := s.repo.CreateAppPassword(ctx, appPassword); err != nil {
+		return nil, "", err
+	}
+	return appPassword, password, nil
+}
+
+// GetAppPasswords retrieves the app passwords of a user, oldest first
+func (s *AppPasswordService) GetAppPasswords(ctx context.Context, userID string) ([]*models.AppPassword, error) {
+	return s.repo.GetUserAppPasswords(ctx, userID)
+}
+
+// DeleteAppPassword revokes an app password of the user
+func (s *AppPasswordService) DeleteAppPassword(ctx context.Context, id, userID string) error {
+	appPassword, err := s.repo.GetAppPassword(ctx, id)
+	if err != nil {
+		return err
+	}
+	if appPassword.UserID != userID {
+		return repositories.ErrAppPasswordNotFound
+	}
+	return s.repo.DeleteAppPassword(ctx, id)
+}
+
+// Authenticate checks that a password is an app password of the user
+func (s *AppPasswordService) Authenticate(ctx context.Context, userID, password string) error {
+	// Only the app passwords of the user may match
+	ctx = repositories.WithUserID(ctx, userID)
+
+	appPassword, err := s.repo.GetAppPasswordByHash(ctx, models.HashAppPassword(password))
+	if errors.Is(err, repositories.ErrAppPasswordNotFound) {
+		return ErrInvalidAppPassword
+	}
+	if err != nil {
+		return err
+	}
+	if appPassword.UserID != userID {
+		return ErrInvalidAppPassword
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/starbops/gottodo/internal/repositories"
+)
+
+func TestAppPasswordService(t *testing.T) {
+	service := NewAppPasswordService(repositories.NewMemoryAppPasswordRepository())
+	ctx := context.Background()
+
+	if _, _, err := service.CreateAppPassword(ctx, "user1", "  "); err != ErrEmptyAppPasswordName {
+		t.Errorf("Expected ErrEmptyAppPasswordName, got %v", err)
+	}
+
+	appPassword, password, err := service.CreateAppPassword(ctx, "user1", " Phone ")
+	if err != nil {
+		t.Fatalf("Failed to create app password: %v", err)
+	}
+	if appPassword.Name != "Phone" || !strings.HasPrefix(password, "app_") || strings.Contains(appPassword.Hash, password) {
+		t.Errorf("Expected a named app password storing only a hash, got %+v", appPassword)
+	}
+
+	if err := service.Authenticate(ctx, "user1", password); err != nil {
+		t.Errorf("Expected the password to authenticate user1, got %v", err)
+	}
+	if err := service.Authenticate(ctx, "user2", password); err != ErrInvalidAppPassword {
+		t.Errorf("Expected ErrInvalidAppPassword for another user, got %v", err)
+	}
+	if err := service.Authenticate(ctx, "user1", "app_guess"); err != ErrInvalidAppPassword {
+		t.Errorf("Expected ErrInvalidAppPassword for a wrong password, got %v", err)
+	}
+
+	// Only the owner can revoke a password
+	if err := service.DeleteAppPassword(ctx, appPassword.ID, "user2"); err != repositories.ErrAppPasswordNotFound {
+		t.Errorf("Expected ErrAppPasswordNotFound for another user, got %v", err)
+	}
+	if err := service.DeleteAppPassword(ctx, appPassword.ID, "user1"); err != nil {
+		t.Fatalf("Failed to delete app password: %v", err)
+	}
+	if err := service.Authenticate(ctx, "user1", password); err != ErrInvalidAppPassword {
+		t.Errorf("Expected a revoked password to fail, got %v", err)
+	}
+	passwords, _ := service.GetAppPasswords(ctx, "user1")
+	if len(passwords) != 0 {
+		t.Errorf("Expected no app passwords, got %d", len(passwords))
+	}
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
+)
+
+// MaxBulkTodos is the largest number of todos a single bulk action may touch
+const MaxBulkTodos = 100
+
+// BulkAction identifies the change applied to every todo of a bulk request
+type BulkAction string
+
+const (
+	// BulkComplete marks the todos as completed
+	BulkComplete BulkAction = "complete"
+
+	// BulkIncomplete marks the todos as incomplete
+	BulkIncomplete BulkAction = "incomplete"
+
+	// BulkDelete moves the todos to the trash
+	BulkDelete BulkAction = "delete"
+
+	// BulkMoveToProject files the todos under the project given as the
+	// value, or under none if it is empty
+	BulkMoveToProject BulkAction = "move_to_project"
+
+	// BulkAddTag adds the tag given as the value to the todos
+	BulkAddTag BulkAction = "add_tag"
+
+	// BulkRemoveTag removes the tag given as the value from the todos
+	BulkRemoveTag BulkAction = "remove_tag"
+)
+
+// ErrUnsupportedBulkAction is returned for bulk actions the service does not know
+var ErrUnsupportedBulkAction = errors.New("unsupported bulk action")
+
+// ErrEmptyBulkTag is returned for tag actions without a tag
+var ErrEmptyBulkTag = errors.New("tag cannot be empty")
+
+// BulkUpdate applies one action to several todos owned by the user at once.
+// value is the argument of the actions that take one, such as the project of
+// BulkMoveToProject. All changes are stored atomically; todos that cannot be
+// changed are reported in the per-todo results instead of failing the whole
+// request.
+func (s *TodoService) BulkUpdate(ctx context.Context, userID string, todoIDs []string, action BulkAction, value string) ([]repositories.BulkResult, error) {
+	if userID == "" {
+		return nil, errors.New("user ID cannot be empty")
+	}
+
+	var apply func(todo *models.Todo)
+	var activity models.ActivityAction
+	switch action {
+	case BulkComplete:
+		apply, activity = (*models.Todo).MarkComplete, models.ActivityCompleted
+	case BulkIncomplete:
+		apply, activity = (*models.Todo).MarkIncomplete, models.ActivityReopened
+	case BulkDelete:
+		apply, activity = (*models.Todo).MoveToTrash, models.ActivityDeleted
+	case BulkMoveToProject:
+		project := strings.TrimPrefix(strings.TrimSpace(value), "+")
+		apply, activity = func(todo *models.Todo) { todo.Project = project }, models.ActivityUpdated
+	case BulkAddTag, BulkRemoveTag:
+		tag := strings.TrimPrefix(strings.TrimSpace(value), "#")
+		if tag == "" {
+			return nil, ErrEmptyBulkTag
+		}
+		apply, activity = func(todo *models.Todo) {
+			// The tags are cloned rather than changed in place, as they may
+			// be shared with the stored todo
+			has := slices.Contains(todo.Tags, tag)
+			switch {
+			case action == BulkAddTag && !has:
+				todo.Tags = append(slices.Clone(todo.Tags), tag)
+			case action == BulkRemoveTag && has:
+				todo.Tags = slices.DeleteFunc(slices.Clone(todo.Tags), func(t string) bool { return t == tag })
+			}
+		}, models.ActivityUpdated
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedBulkAction, action)
+	}
+
+	ids := uniqueIDs(todoIDs)
+	if len(ids) == 0 {
+		return nil, errors.New("no todos selected")
+	}
+	if len(ids) > MaxBulkTodos {
+		return nil, fmt.Errorf("cannot change more than %d todos at once", MaxBulkTodos)
+	}
+
+	// Malformed IDs can never match a todo, so keep them out of the query
+	var valid []string
+	for _, id := range ids {
+		if models.IsValidUUID(id) {
+			valid = append(valid, id)
+		}
+	}
+
+	ordered := make([]repositories.BulkResult, len(ids))
+	err := s.write(ctx, func(todos repositories.TodoRepository) ([]todoChange, error) {
+		results := make(map[string]repositories.BulkResult, len(ids))
+		if len(valid) > 0 {
+			updated, err := todos.UpdateTodos(ctx, valid, func(todo *models.Todo) error {
+				// Foreign and trashed todos look the same as missing ones
+				if todo.UserID != userID || todo.IsTrashed() {
+					return repositories.ErrTodoNotFound
+				}
+				apply(todo)
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+			for _, result := range updated {
+				results[result.TodoID] = result
+			}
+		}
+
+		var changes []todoChange
+		for i, id := range ids {
+			result, ok := results[id]
+			if !ok {
+				result = repositories.BulkResult{TodoID: id, Err: repositories.ErrTodoNotFound}
+			}
+			// Todos that already were as asked, say already tagged, have no
+			// changes to record
+			if result.Err == nil && (activity != models.ActivityUpdated || len(models.DiffTodos(result.Before, result.Todo)) > 0) {
+				changes = append(changes, todoChange{userID, id, activity, result.Before, result.Todo})
+			}
+			ordered[i] = result
+		}
+		return changes, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ordered, nil
+}
+
+// uniqueIDs drops empty and repeated IDs while keeping their order
+func uniqueIDs(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	var unique []string
+	for _, id := range ids {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		unique = append(unique, id)
+	}
+	return unique
+}
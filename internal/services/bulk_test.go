@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
+)
+
+func TestTodoService_BulkUpdate(t *testing.T) {
+	activityRepo := repositories.NewMemoryActivityRepository()
+	service := NewTodoService(NewMockTodoRepository(), WithActivityRepository(activityRepo))
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+	otherID := uuid.New().String()
+
+	first := &models.Todo{UserID: userID, Title: "First"}
+	second := &models.Todo{UserID: userID, Title: "Second"}
+	foreign := &models.Todo{UserID: otherID, Title: "Foreign"}
+	for _, todo := range []*models.Todo{first, second, foreign} {
+		if err := service.CreateTodo(ctx, todo); err != nil {
+			t.Fatalf("Failed to create todo: %v", err)
+		}
+	}
+
+	// Complete our todos, a foreign one, a duplicate and a malformed ID
+	ids := []string{first.ID, foreign.ID, second.ID, first.ID, "not-a-uuid"}
+	results, err := service.BulkUpdate(ctx, userID, ids, BulkComplete, "")
+	if err != nil {
+		t.Fatalf("Failed to bulk update todos: %v", err)
+	}
+
+	// Results are per todo, in request order, without duplicates
+	if len(results) != 4 {
+		t.Fatalf("Expected 4 results, got %d", len(results))
+	}
+	for i, want := range []struct {
+		id string
+		ok bool
+	}{{first.ID, true}, {foreign.ID, false}, {second.ID, true}, {"not-a-uuid", false}} {
+		if results[i].TodoID != want.id {
+			t.Errorf("Result %d: expected todo %s, got %s", i, want.id, results[i].TodoID)
+		}
+		if (results[i].Err == nil) != want.ok {
+			t.Errorf("Result %d: unexpected error %v", i, results[i].Err)
+		}
+	}
+
+	for _, todo := range []*models.Todo{first, second} {
+		stored, err := service.GetTodo(ctx, todo.ID, userID)
+		if err != nil {
+			t.Fatalf("Failed to get todo: %v", err)
+		}
+		if !stored.Completed {
+			t.Errorf("Expected todo %s to be completed", todo.Title)
+		}
+	}
+
+	stored, err := service.GetTodo(ctx, foreign.ID, otherID)
+	if err != nil {
+		t.Fatalf("Failed to get todo: %v", err)
+	}
+	if stored.Completed {
+		t.Error("Expected foreign todo to be left unchanged")
+	}
+
+	// Every changed todo gets its own activity entry
+	history, err := service.GetTodoHistory(ctx, first.ID, userID)
+	if err != nil {
+		t.Fatalf("Failed to get history: %v", err)
+	}
+	if len(history) != 2 || history[0].Action != models.ActivityCompleted {
+		t.Errorf("Expected completed activity on top of history, got %v", history)
+	}
+
+	// Deleting in bulk moves the todos to the trash
+	if _, err := service.BulkUpdate(ctx, userID, []string{first.ID, second.ID}, BulkDelete, ""); err != nil {
+		t.Fatalf("Failed to bulk delete todos: %v", err)
+	}
+	trashed, err := service.GetTrashedTodos(ctx, userID)
+	if err != nil {
+		t.Fatalf("Failed to get trash: %v", err)
+	}
+	if len(trashed) != 2 {
+		t.Errorf("Expected 2 trashed todos, got %d", len(trashed))
+	}
+}
+
+func TestTodoService_BulkUpdate_ProjectAndTags(t *testing.T) {
+	activityRepo := repositories.NewMemoryActivityRepository()
+	service := NewTodoService(NewMockTodoRepository(), WithActivityRepository(activityRepo))
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+	otherID := uuid.New().String()
+
+	first := &models.Todo{UserID: userID, Title: "First", Project: "home", Tags: []string{"errand"}}
+	second := &models.Todo{UserID: userID, Title: "Second"}
+	foreign := &models.Todo{UserID: otherID, Title: "Foreign", Project: "home"}
+	for _, todo := range []*models.Todo{first, second, foreign} {
+		if err := service.CreateTodo(ctx, todo); err != nil {
+			t.Fatalf("Failed to create todo: %v", err)
+		}
+	}
+	ids := []string{first.ID, second.ID, foreign.ID}
+
+	// assertResults checks that our todos were changed and the foreign one was not
+	assertResults := func(results []repositories.BulkResult, err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("Failed to bulk update todos: %v", err)
+		}
+		if len(results) != 3 || results[0].Err != nil || results[1].Err != nil || results[2].Err == nil {
+			t.Fatalf("Expected our todos to change and the foreign one to fail, got %+v", results)
+		}
+	}
+	// stored returns our todos as stored
+	stored := func() (*models.Todo, *models.Todo) {
+		t.Helper()
+		a, err := service.GetTodo(ctx, first.ID, userID)
+		if err != nil {
+			t.Fatalf("Failed to get todo: %v", err)
+		}
+		b, err := service.GetTodo(ctx, second.ID, userID)
+		if err != nil {
+			t.Fatalf("Failed to get todo: %v", err)
+		}
+		return a, b
+	}
+
+	// Moving to a project accepts the +project syntax of quick add
+	assertResults(service.BulkUpdate(ctx, userID, ids, BulkMoveToProject, " +work "))
+	if a, b := stored(); a.Project != "work" || b.Project != "work" {
+		t.Errorf("Expected both todos in project work, got %q and %q", a.Project, b.Project)
+	}
+
+	// Adding a tag keeps the existing ones and does not duplicate it
+	assertResults(service.BulkUpdate(ctx, userID, ids, BulkAddTag, "#errand"))
+	if a, b := stored(); !reflect.DeepEqual(a.Tags, []string{"errand"}) || !reflect.DeepEqual(b.Tags, []string{"errand"}) {
+		t.Errorf("Expected both todos tagged errand once, got %v and %v", a.Tags, b.Tags)
+	}
+	assertResults(service.BulkUpdate(ctx, userID, ids, BulkAddTag, "urgent"))
+	if a, _ := stored(); !reflect.DeepEqual(a.Tags, []string{"errand", "urgent"}) {
+		t.Errorf("Expected tags errand and urgent, got %v", a.Tags)
+	}
+
+	// Removing a tag leaves the others
+	assertResults(service.BulkUpdate(ctx, userID, ids, BulkRemoveTag, "errand"))
+	if a, b := stored(); !reflect.DeepEqual(a.Tags, []string{"urgent"}) || !reflect.DeepEqual(b.Tags, []string{"urgent"}) {
+		t.Errorf("Expected both todos tagged urgent only, got %v and %v", a.Tags, b.Tags)
+	}
+
+	// An empty project clears it
+	assertResults(service.BulkUpdate(ctx, userID, ids, BulkMoveToProject, ""))
+	if a, b := stored(); a.Project != "" || b.Project != "" {
+		t.Errorf("Expected no project, got %q and %q", a.Project, b.Project)
+	}
+
+	// The foreign todo was never touched
+	other, err := service.GetTodo(ctx, foreign.ID, otherID)
+	if err != nil {
+		t.Fatalf("Failed to get todo: %v", err)
+	}
+	if other.Project != "home" || len(other.Tags) != 0 {
+		t.Errorf("Expected foreign todo to be left unchanged, got %+v", other)
+	}
+
+	// Only actual changes are recorded: the first todo already had the
+	// errand tag when it was added
+	history, err := service.GetTodoHistory(ctx, first.ID, userID)
+	if err != nil {
+		t.Fatalf("Failed to get history: %v", err)
+	}
+	if len(history) != 5 {
+		t.Errorf("Expected 5 activity entries, got %d", len(history))
+	}
+	history, err = service.GetTodoHistory(ctx, second.ID, userID)
+	if err != nil {
+		t.Fatalf("Failed to get history: %v", err)
+	}
+	if len(history) != 6 {
+		t.Errorf("Expected 6 activity entries, got %d", len(history))
+	}
+}
+
+func TestTodoService_BulkUpdate_Invalid(t *testing.T) {
+	service := NewTodoService(NewMockTodoRepository())
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+
+	if _, err := service.BulkUpdate(ctx, userID, []string{uuid.New().String()}, "archive", ""); !errors.Is(err, ErrUnsupportedBulkAction) {
+		t.Errorf("Expected ErrUnsupportedBulkAction, got %v", err)
+	}
+	for _, action := range []BulkAction{BulkAddTag, BulkRemoveTag} {
+		if _, err := service.BulkUpdate(ctx, userID, []string{uuid.New().String()}, action, " # "); !errors.Is(err, ErrEmptyBulkTag) {
+			t.Errorf("Expected ErrEmptyBulkTag for %s, got %v", action, err)
+		}
+	}
+	if _, err := service.BulkUpdate(ctx, userID, nil, BulkComplete, ""); err == nil {
+		t.Error("Expected error when no todos are selected")
+	}
+
+	tooMany := make([]string, MaxBulkTodos+1)
+	for i := range tooMany {
+		tooMany[i] = uuid.New().String()
+	}
+	if _, err := service.BulkUpdate(ctx, userID, tooMany, BulkComplete, ""); err == nil {
+		t.Error("Expected error when selecting too many todos")
+	}
+}
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
+	"github.com/starbops/gottodo/pkg/ical"
+)
+
+// CalendarProdID identifies gottodo in the calendars it produces
+const CalendarProdID = "-//gottodo//gottodo//EN"
+
+// CalendarName is the name calendar apps show for the todo list
+const CalendarName = "Todos"
+
+// ErrNoCalendarTask is returned when a calendar holds no VTODO to read a todo from
+var ErrNoCalendarTask = errors.New("calendar holds no VTODO")
+
+// CalendarService serves the todos of the users as iCalendar feeds under
+// secret URLs
+type CalendarService struct {
+	repo        repositories.CalendarFeedRepository
+	owners      repositories.CalendarFeedRepository
+	todoService *TodoService
+}
+
+// NewCalendarService creates a new CalendarService. Feed requests are not
+// scoped to a user until their secret is looked up, so owners looks secrets
+// up across users, like the system repository does.
+func NewCalendarService(repo, owners repositories.CalendarFeedRepository, todoService *TodoService) *CalendarService {
+	return &CalendarService{
+		repo:        repo,
+		owners:      owners,
+		todoService: todoService,
+	}
+}
+
+// GetCalendarFeed retrieves the calendar feed of a user, creating one on
+// first use
+func (s *CalendarService) GetCalendarFeed(ctx context.Context, userID string) (*models.CalendarFeed, error) {
+	feed, err := s.repo.GetUserCalendarFeed(ctx, userID)
+	if errors.Is(err, repositories.ErrCalendarFeedNotFound) {
+		return s.RotateCalendarFeed(ctx, userID)
+	}
+	return feed, err
+}
+
+// RotateCalendarFeed replaces the calendar feed secret of a user, so
+// subscriptions to the previous feed URL stop working
+func (s *CalendarService) RotateCalendarFeed(ctx context.Context, userID string) (*models.CalendarFeed, error) {
+	if userID == "" {
+		return nil, errors.New("user ID cannot be empty")
+	}
+
+	feed := models.NewCalendarFeed(userID)
+	if err := s.repo.SaveCalendarFeed(ctx, feed); err != nil {
+		return nil, err
+	}
+	return feed, nil
+}
+
+// FeedTodos retrieves the todos of the owner of a feed secret. Unknown
+// secrets are reported as repositories.ErrCalendarFeedNotFound.
+func (s *CalendarService) FeedTodos(ctx context.Context, secret string) ([]*models.Todo, error) {
+	feed, err := s.owners.GetCalendarFeed(ctx, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	// The secret authenticates its owner, whose todos the request may read
+	ctx = repositories.WithUserID(ctx, feed.UserID)
+	return s.todoService.GetUserTodos(ctx, feed.UserID)
+}
+
+// TodoCalendar builds a calendar of todos. With dueAsEvents, todos that have
+// a due date become events so they show up in calendar views; all the other
+// todos are tasks.
+func TodoCalendar(todos []*models.Todo, dueAsEvents bool) *ical.Calendar {
+	cal := &ical.Calendar{ProdID: CalendarProdID, Name: CalendarName}
+	for _, todo := range todos {
+		if dueAsEvents && todo.DueAt != nil {
+			cal.Components = append(cal.Components, CalendarEvent(todo))
+		} else {
+			cal.Components = append(cal.Components, CalendarTask(todo))
+		}
+	}
+	return cal
+}
+
+// CalendarTask builds the VTODO of a todo
+func CalendarTask(todo *models.Todo) *ical.Component {
+	task := ical.NewComponent("VTODO")
+	addTodoProperties(task, todo)
+
+	if todo.DueAt != nil {
+		if todo.DueAllDay() {
+			task.AddDate("DUE", todo.DueAt.UTC())
+		} else {
+			task.AddDateTime("DUE", *todo.DueAt)
+		}
+	}
+	// Repeating tasks need a start for their recurrences to count from: the
+	// due date, so that each recurrence falls due on its own day, or else
+	// the creation of the todo
+	if task.Get("RRULE") != nil {
+		switch {
+		case todo.DueAllDay():
+			task.AddDate("DTSTART", todo.DueAt.UTC())
+		case todo.DueAt != nil:
+			task.AddDateTime("DTSTART", *todo.DueAt)
+		default:
+			task.AddDateTime("DTSTART", todo.CreatedAt)
+		}
+	}
+	if todo.Completed {
+		task.Add("STATUS", "COMPLETED").AddDateTime("COMPLETED", todo.UpdatedAt)
+	} else {
+		task.Add("STATUS", "NEEDS-ACTION")
+	}
+	return task
+}
+
+// CalendarEvent builds the VEVENT of a todo with a due date, taking place when
+// the todo is due
+func CalendarEvent(todo *models.Todo) *ical.Component {
+	event := ical.NewComponent("VEVENT")
+	addTodoProperties(event, todo)
+
+	due := todo.DueAt.UTC()
+	if todo.DueAllDay() {
+		event.AddDate("DTSTART", due).AddDate("DTEND", due.AddDate(0, 0, 1))
+	} else {
+		event.AddDateTime("DTSTART", due)
+	}
+	// Due dates should not block time in the calendar
+	event.Add("STATUS", "CONFIRMED").Add("TRANSP", "TRANSPARENT")
+	return event
+}
+
+// addTodoProperties adds the properties that tasks and events of a todo share
+func addTodoProperties(c *ical.Component, todo *models.Todo) {
+	c.AddText("UID", todo.ID).
+		AddDateTime("DTSTAMP", todo.UpdatedAt).
+		AddDateTime("CREATED", todo.CreatedAt).
+		AddDateTime("LAST-MODIFIED", todo.UpdatedAt).
+		AddText("SUMMARY", todo.Title)
+
+	if todo.Description != "" {
+		c.AddText("DESCRIPTION", todo.Description)
+	}
+	if priority := calendarPriority(todo.Priority); priority != "" {
+		c.Add("PRIORITY", priority)
+	}
+	if len(todo.Tags) > 0 {
+		c.AddTextList("CATEGORIES", todo.Tags)
+	}
+	// The value is written as is, so rules stored before recurrences were
+	// validated must not break out of the property
+	if todo.Recurrence != "" && models.IsValidRecurrence(todo.Recurrence) {
+		c.Add("RRULE", todo.Recurrence)
+	}
+}
+
+// ParseCalendarTask reads a todo from the VTODO of a calendar, the reverse of
+// CalendarTask. The ID, owner, project and timestamps of the todo are left to
+// the caller.
+func ParseCalendarTask(cal *ical.Calendar) (*models.Todo, error) {
+	var task *ical.Component
+	for _, component := range cal.Components {
+		if component.Name == "VTODO" {
+			task = component
+			break
+		}
+	}
+	if task == nil {
+		return nil, ErrNoCalendarTask
+	}
+
+	todo := &models.Todo{}
+	if summary := task.Get("SUMMARY"); summary != nil {
+		todo.Title = strings.TrimSpace(summary.Text())
+	}
+	if description := task.Get("DESCRIPTION"); description != nil {
+		todo.Description = description.Text()
+	}
+
+	status := task.Get("STATUS")
+	todo.Completed = task.Get("COMPLETED") != nil || (status != nil && strings.EqualFold(status.Value, "COMPLETED"))
+
+	if due := task.Get("DUE"); due != nil {
+		dueAt, _, err := due.Time()
+		if err != nil {
+			return nil, fmt.Errorf("invalid DUE: %w", err)
+		}
+		dueAt = dueAt.UTC()
+		todo.DueAt = &dueAt
+	}
+
+	if priority := task.Get("PRIORITY"); priority != nil {
+		value, err := strconv.Atoi(strings.TrimSpace(priority.Value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid PRIORITY: %w", err)
+		}
+		todo.Priority = todoPriority(value)
+	}
+
+	seen := make(map[string]bool)
+	for _, categories := range task.GetAll("CATEGORIES") {
+		for _, tag := range categories.TextList() {
+			tag = strings.TrimSpace(tag)
+			if tag != "" && !seen[tag] {
+				seen[tag] = true
+				todo.Tags = append(todo.Tags, tag)
+			}
+		}
+	}
+
+	if rrule := task.Get("RRULE"); rrule != nil {
+		todo.Recurrence = rrule.Value
+	}
+	return todo, nil
+}
+
+// todoPriority maps an iCalendar priority onto the todo priorities, splitting
+// the scale the way RFC 5545 suggests for three levels
+func todoPriority(priority int) models.Priority {
+	switch {
+	case priority >= 1 && priority <= 4:
+		return models.PriorityHigh
+	case priority == 5:
+		return models.PriorityMedium
+	case priority >= 6 && priority <= 9:
+		return models.PriorityLow
+	default:
+		return models.PriorityNone
+	}
+}
+
+// calendarPriority maps a todo priority onto the 1 (highest) to 9 (lowest)
+// scale of iCalendar
+func calendarPriority(priority models.Priority) string {
+	switch priority {
+	case models.PriorityHigh:
+		return "1"
+	case models.PriorityMedium:
+		return "5"
+	case models.PriorityLow:
+		return "9"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
+	"github.com/starbops/gottodo/pkg/ical"
+)
+
+func TestCalendarService_Feed(t *testing.T) {
+	todoService := NewTodoService(NewMockTodoRepository())
+	feedRepo := repositories.NewMemoryCalendarFeedRepository()
+	service := NewCalendarService(feedRepo, feedRepo, todoService)
+	ctx := context.Background()
+
+	// A feed is created on first use and kept afterwards
+	feed, err := service.GetCalendarFeed(ctx, "user1")
+	if err != nil {
+		t.Fatalf("Failed to get calendar feed: %v", err)
+	}
+	again, err := service.GetCalendarFeed(ctx, "user1")
+	if err != nil || again.Secret != feed.Secret {
+		t.Fatalf("Expected the same feed, got %v (%v)", again, err)
+	}
+
+	if err := todoService.CreateTodo(ctx, &models.Todo{Title: "Mine", UserID: "user1"}); err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+	if err := todoService.CreateTodo(ctx, &models.Todo{Title: "Theirs", UserID: "user2"}); err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+
+	todos, err := service.FeedTodos(ctx, feed.Secret)
+	if err != nil {
+		t.Fatalf("Failed to get feed todos: %v", err)
+	}
+	if len(todos) != 1 || todos[0].Title != "Mine" {
+		t.Errorf("Expected only the todo of user1, got %v", todos)
+	}
+
+	// Rotating the secret disables the previous feed URL
+	rotated, err := service.RotateCalendarFeed(ctx, "user1")
+	if err != nil {
+		t.Fatalf("Failed to rotate calendar feed: %v", err)
+	}
+	if rotated.Secret == feed.Secret {
+		t.Fatal("Expected a new secret")
+	}
+	if _, err := service.FeedTodos(ctx, feed.Secret); err != repositories.ErrCalendarFeedNotFound {
+		t.Errorf("Expected ErrCalendarFeedNotFound for the old secret, got %v", err)
+	}
+}
+
+func TestTodoCalendar(t *testing.T) {
+	created := time.Date(2025, time.March, 10, 8, 0, 0, 0, time.UTC)
+	updated := time.Date(2025, time.March, 11, 9, 30, 0, 0, time.UTC)
+	day := time.Date(2025, time.March, 14, 0, 0, 0, 0, time.UTC)
+	meeting := time.Date(2025, time.March, 15, 17, 0, 0, 0, time.FixedZone("CET", 3600))
+
+	todos := []*models.Todo{
+		{
+			ID: "a", Title: "Pay rent, twice", Description: "Bank; online", CreatedAt: created, UpdatedAt: updated,
+			DueAt: &day, Priority: models.PriorityHigh, Tags: []string{"home", "money"}, Recurrence: "FREQ=MONTHLY",
+		},
+		{ID: "b", Title: "Meet Ann", CreatedAt: created, UpdatedAt: updated, DueAt: &meeting, Completed: true},
+		{ID: "c", Title: "Someday", CreatedAt: created, UpdatedAt: updated, Priority: models.PriorityLow},
+		{ID: "d", Title: "Water plants", CreatedAt: created, UpdatedAt: updated, Recurrence: "FREQ=DAILY"},
+		{ID: "e", Title: "Stored before validation", CreatedAt: created, UpdatedAt: updated, Recurrence: "FREQ=DAILY\r\nATTACH:x"},
+	}
+
+	tests := []struct {
+		name        string
+		dueAsEvents bool
+		want        []string
+	}{
+		{
+			name: "tasks",
+			want: []string{
+				"BEGIN:VTODO\r\nUID:a\r\nDTSTAMP:20250311T093000Z\r\nCREATED:20250310T080000Z\r\nLAST-MODIFIED:20250311T093000Z\r\n" +
+					"SUMMARY:Pay rent\\, twice\r\nDESCRIPTION:Bank\\; online\r\nPRIORITY:1\r\nCATEGORIES:home,money\r\n" +
+					"RRULE:FREQ=MONTHLY\r\nDUE;VALUE=DATE:20250314\r\nDTSTART;VALUE=DATE:20250314\r\nSTATUS:NEEDS-ACTION\r\nEND:VTODO\r\n",
+				"UID:b\r\n",
+				"DUE:20250315T160000Z\r\nSTATUS:COMPLETED\r\nCOMPLETED:20250311T093000Z\r\n",
+				"SUMMARY:Someday\r\nPRIORITY:9\r\nSTATUS:NEEDS-ACTION\r\n",
+				"SUMMARY:Water plants\r\nRRULE:FREQ=DAILY\r\nDTSTART:20250310T080000Z\r\nSTATUS:NEEDS-ACTION\r\n",
+				"SUMMARY:Stored before validation\r\nSTATUS:NEEDS-ACTION\r\n",
+			},
+		},
+		{
+			name:        "events",
+			dueAsEvents: true,
+			want: []string{
+				"BEGIN:VEVENT\r\nUID:a\r\n",
+				"DTSTART;VALUE=DATE:20250314\r\nDTEND;VALUE=DATE:20250315\r\nSTATUS:CONFIRMED\r\nTRANSP:TRANSPARENT\r\nEND:VEVENT\r\n",
+				"DTSTART:20250315T160000Z\r\n",
+				"BEGIN:VTODO\r\nUID:c\r\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := ical.NewEncoder(&buf).Encode(TodoCalendar(todos, tt.dueAsEvents)); err != nil {
+				t.Fatalf("Failed to encode calendar: %v", err)
+			}
+			out := buf.String()
+			if !strings.Contains(out, "PRODID:"+CalendarProdID+"\r\n") {
+				t.Errorf("Expected the gottodo PRODID, got %s", out)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("Expected %q in the calendar, got %s", want, out)
+				}
+			}
+		})
+	}
+}
+
+func TestParseCalendarTask(t *testing.T) {
+	due := time.Date(2025, time.March, 14, 0, 0, 0, 0, time.UTC)
+	original := &models.Todo{
+		ID: "a", Title: "Pay rent, twice", Description: "Bank;\nonline", Completed: true,
+		DueAt: &due, Priority: models.PriorityMedium, Tags: []string{"home", "money"}, Recurrence: "FREQ=MONTHLY",
+	}
+
+	// A todo survives the way through a calendar
+	var buf bytes.Buffer
+	if err := ical.NewEncoder(&buf).Encode(TodoCalendar([]*models.Todo{original}, false)); err != nil {
+		t.Fatalf("Failed to encode calendar: %v", err)
+	}
+	cal, err := ical.NewDecoder(&buf).Decode()
+	if err != nil {
+		t.Fatalf("Failed to decode calendar: %v", err)
+	}
+	todo, err := ParseCalendarTask(cal)
+	if err != nil {
+		t.Fatalf("Failed to parse task: %v", err)
+	}
+	if todo.Title != original.Title || todo.Description != original.Description || !todo.Completed ||
+		todo.DueAt == nil || !todo.DueAt.Equal(due) || !todo.DueAllDay() || todo.Priority != models.PriorityMedium ||
+		strings.Join(todo.Tags, ",") != "home,money" || todo.Recurrence != "FREQ=MONTHLY" {
+		t.Errorf("Expected the original todo back, got %+v", todo)
+	}
+
+	tests := []struct {
+		name    string
+		task    string
+		check   func(*models.Todo) bool
+		wantErr bool
+	}{
+		{
+			name: "time zone and priority",
+			task: "SUMMARY:Call\r\nDUE;TZID=Europe/Berlin:20250315T170000\r\nPRIORITY:2\r\nSTATUS:NEEDS-ACTION\r\n",
+			check: func(todo *models.Todo) bool {
+				return todo.DueAt.Equal(time.Date(2025, time.March, 15, 16, 0, 0, 0, time.UTC)) && todo.Priority == models.PriorityHigh && !todo.Completed
+			},
+		},
+		{
+			name: "repeated categories",
+			task: "SUMMARY:Tidy\r\nCATEGORIES:home,work\r\nCATEGORIES:home\r\nPRIORITY:0\r\n",
+			check: func(todo *models.Todo) bool {
+				return strings.Join(todo.Tags, ",") == "home,work" && todo.Priority == models.PriorityNone
+			},
+		},
+		{
+			name:  "completed timestamp",
+			task:  "SUMMARY:Done\r\nCOMPLETED:20250301T100000Z\r\n",
+			check: func(todo *models.Todo) bool { return todo.Completed && todo.DueAt == nil },
+		},
+		{name: "invalid due", task: "SUMMARY:X\r\nDUE:soon\r\n", wantErr: true},
+		{name: "invalid priority", task: "SUMMARY:X\r\nPRIORITY:high\r\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\n" + tt.task + "END:VTODO\r\nEND:VCALENDAR\r\n"
+			cal, err := ical.NewDecoder(strings.NewReader(input)).Decode()
+			if err != nil {
+				t.Fatalf("Failed to decode calendar: %v", err)
+			}
+			todo, err := ParseCalendarTask(cal)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected an error")
+				}
+				return
+			}
+			if err != nil || !tt.check(todo) {
+				t.Errorf("Unexpected todo %+v (%v)", todo, err)
+			}
+		})
+	}
+
+	if _, err := ParseCalendarTask(&ical.Calendar{Components: []*ical.Component{ical.NewComponent("VEVENT")}}); err != ErrNoCalendarTask {
+		t.Errorf("Expected ErrNoCalendarTask, got %v", err)
+	}
+}
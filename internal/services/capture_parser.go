@@ -0,0 +1,113 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxCaptureTitleLength caps the title of captured todos, in characters
+const MaxCaptureTitleLength = 200
+
+// CaptureMessage is the title and description of a todo to capture
+type CaptureMessage struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+var (
+	// replyPrefix matches reply and forward markers such as "Re:", "Fwd:",
+	// "AW:" or "Re[2]:" in front of a subject
+	replyPrefix = regexp.MustCompile(`(?i)^\s*(re|fw|fwd|aw|wg|sv|vs)(\[\d+\])?\s*:\s*`)
+
+	// quoteHeader matches the line introducing a quoted reply
+	quoteHeader = regexp.MustCompile(`^On .+ wrote:$`)
+
+	// whitespaceRun matches runs of whitespace inside a line
+	whitespaceRun = regexp.MustCompile(`\s+`)
+)
+
+// ParseCaptureMessage maps a subject and body, as received from an email
+// gateway, to the title and description of a todo. Reply and forward markers
+// are stripped from the subject, and quoted replies and the signature from
+// the body. Without a subject the first line of the body becomes the title.
+func ParseCaptureMessage(subject, body string) CaptureMessage {
+	title := subject
+	for {
+		stripped := replyPrefix.ReplaceAllString(title, "")
+		if stripped == title {
+			break
+		}
+		title = stripped
+	}
+	title = collapseWhitespace(title)
+
+	lines := captureBodyLines(body)
+	if title == "" {
+		for len(lines) > 0 && title == "" {
+			title = collapseWhitespace(lines[0])
+			lines = lines[1:]
+		}
+	}
+
+	return CaptureMessage{
+		Title:       truncateTitle(title),
+		Description: joinParagraphs(lines),
+	}
+}
+
+// captureBodyLines returns the lines of a body without quoted replies and
+// the signature
+func captureBodyLines(body string) []string {
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	body = strings.ReplaceAll(body, "\r", "\n")
+
+	var lines []string
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimRight(line, " \t")
+		// The signature delimiter ends the message
+		if line == "--" || line == "-- " {
+			break
+		}
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, ">") || quoteHeader.MatchString(trimmed) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// joinParagraphs joins lines, trimming blank lines at both ends and keeping
+// at most one blank line between paragraphs
+func joinParagraphs(lines []string) string {
+	var kept []string
+	blank := false
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			blank = len(kept) > 0
+			continue
+		}
+		if blank {
+			kept = append(kept, "")
+			blank = false
+		}
+		kept = append(kept, line)
+	}
+	return strings.Join(kept, "\n")
+}
+
+// collapseWhitespace trims a line and replaces inner whitespace runs with a
+// single space
+func collapseWhitespace(s string) string {
+	return whitespaceRun.ReplaceAllString(strings.TrimSpace(s), " ")
+}
+
+// truncateTitle shortens overly long titles to MaxCaptureTitleLength characters
+func truncateTitle(title string) string {
+	if utf8.RuneCountInString(title) <= MaxCaptureTitleLength {
+		return title
+	}
+	runes := []rune(title)
+	return strings.TrimSpace(string(runes[:MaxCaptureTitleLength-1])) + "…"
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCaptureMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		subject string
+		body    string
+		want    CaptureMessage
+	}{
+		{
+			name:    "subject and body",
+			subject: "Renew passport",
+			body:    "Appointment at the town hall.\n",
+			want:    CaptureMessage{Title: "Renew passport", Description: "Appointment at the town hall."},
+		},
+		{
+			name:    "reply and forward markers",
+			subject: "RE: Fwd: re[2]:  Quarterly   report",
+			want:    CaptureMessage{Title: "Quarterly report"},
+		},
+		{
+			name:    "markers inside the subject are kept",
+			subject: "Draft the Re: line of the letter",
+			want:    CaptureMessage{Title: "Draft the Re: line of the letter"},
+		},
+		{
+			name: "first body line without a subject",
+			body: "\n\n  Buy milk  \nTwo litres\r\nSemi-skimmed\r\n",
+			want: CaptureMessage{Title: "Buy milk", Description: "Two litres\nSemi-skimmed"},
+		},
+		{
+			name:    "quoted reply is dropped",
+			subject: "Re: Team lunch",
+			body:    "Book a table for six.\n\nOn Mon, 3 Mar 2025 at 10:00, Alex <alex@example.com> wrote:\n> Can someone book?\n>> Friday works\n",
+			want:    CaptureMessage{Title: "Team lunch", Description: "Book a table for six."},
+		},
+		{
+			name:    "signature is dropped",
+			subject: "Call the plumber",
+			body:    "Kitchen sink leaks.\n\n-- \nSent from my phone\n",
+			want:    CaptureMessage{Title: "Call the plumber", Description: "Kitchen sink leaks."},
+		},
+		{
+			name:    "blank lines between paragraphs are collapsed",
+			subject: "Notes",
+			body:    "First\n\n\n\nSecond\n   \nThird",
+			want:    CaptureMessage{Title: "Notes", Description: "First\n\nSecond\n\nThird"},
+		},
+		{
+			name:    "indentation is kept",
+			subject: "Packing list",
+			body:    "Clothes:\n  - socks\n  - shirts",
+			want:    CaptureMessage{Title: "Packing list", Description: "Clothes:\n  - socks\n  - shirts"},
+		},
+		{
+			name:    "subject made only of markers",
+			subject: "Fwd:",
+			body:    "Forwarded task\nDetails",
+			want:    CaptureMessage{Title: "Forwarded task", Description: "Details"},
+		},
+		{
+			name: "empty message",
+			body: "\n> quoted only\n",
+			want: CaptureMessage{},
+		},
+		{
+			name:    "long titles are truncated",
+			subject: strings.Repeat("a", MaxCaptureTitleLength+10),
+			want:    CaptureMessage{Title: strings.Repeat("a", MaxCaptureTitleLength-1) + "…"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseCaptureMessage(tt.subject, tt.body)
+			if got != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
+)
+
+// CaptureService creates todos from messages sent to the users' secret
+// capture URLs
+type CaptureService struct {
+	repo        repositories.CaptureRepository
+	owners      repositories.CaptureRepository
+	todoService *TodoService
+}
+
+// NewCaptureService creates a new CaptureService. Captures are not scoped to
+// a user until their secret is looked up, so owners looks secrets up across
+// users, like the system repository does.
+func NewCaptureService(repo, owners repositories.CaptureRepository, todoService *TodoService) *CaptureService {
+	return &CaptureService{
+		repo:        repo,
+		owners:      owners,
+		todoService: todoService,
+	}
+}
+
+// GetCaptureSecret retrieves the capture secret of a user, creating one on
+// first use
+func (s *CaptureService) GetCaptureSecret(ctx context.Context, userID string) (*models.CaptureSecret, error) {
+	secret, err := s.repo.GetUserCaptureSecret(ctx, userID)
+	if errors.Is(err, repositories.ErrCaptureSecretNotFound) {
+		return s.RotateCaptureSecret(ctx, userID)
+	}
+	return secret, err
+}
+
+// RotateCaptureSecret replaces the capture secret of a user, so the previous
+// capture URL stops working
+func (s *CaptureService) RotateCaptureSecret(ctx context.Context, userID string) (*models.CaptureSecret, error) {
+	if userID == "" {
+		return nil, errors.New("user ID cannot be empty")
+	}
+
+	secret := models.NewCaptureSecret(userID)
+	if err := s.repo.SaveCaptureSecret(ctx, secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// Capture creates a todo for the owner of the capture secret. Unknown
+// secrets are reported as repositories.ErrCaptureSecretNotFound.
+func (s *CaptureService) Capture(ctx context.Context, secret string, message CaptureMessage) (*models.Todo, error) {
+	owner, err := s.owners.GetCaptureSecret(ctx, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	if message.Title == "" {
+		return nil, ErrEmptyTitle
+	}
+
+	// The secret authenticates its owner, whose todos the request may write
+	ctx = repositories.WithUserID(ctx, owner.UserID)
+
+	todo := &models.Todo{
+		Title:       truncateTitle(message.Title),
+		Description: message.Description,
+		UserID:      owner.UserID,
+	}
+	if err := s.todoService.CreateTodo(ctx, todo); err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
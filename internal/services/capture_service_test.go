@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
+)
+
+func TestCaptureService_Capture(t *testing.T) {
+	todoService := NewTodoService(NewMockTodoRepository())
+	captureRepo := repositories.NewMemoryCaptureRepository()
+	service := NewCaptureService(captureRepo, captureRepo, todoService)
+	ctx := context.Background()
+
+	// A secret is created on first use and kept afterwards
+	secret, err := service.GetCaptureSecret(ctx, "user1")
+	if err != nil {
+		t.Fatalf("Failed to get capture secret: %v", err)
+	}
+	again, err := service.GetCaptureSecret(ctx, "user1")
+	if err != nil || again.Secret != secret.Secret {
+		t.Fatalf("Expected the same secret, got %v (%v)", again, err)
+	}
+
+	todo, err := service.Capture(ctx, secret.Secret, CaptureMessage{Title: "Captured", Description: "From mail"})
+	if err != nil {
+		t.Fatalf("Failed to capture todo: %v", err)
+	}
+	if todo.UserID != "user1" || todo.Title != "Captured" || todo.Description != "From mail" {
+		t.Errorf("Expected the captured todo for user1, got %+v", todo)
+	}
+	todos, _ := todoService.GetUserTodos(ctx, "user1")
+	if len(todos) != 1 {
+		t.Errorf("Expected the todo to be stored, got %d todos", len(todos))
+	}
+
+	if _, err := service.Capture(ctx, secret.Secret, CaptureMessage{}); err != ErrEmptyTitle {
+		t.Errorf("Expected ErrEmptyTitle, got %v", err)
+	}
+
+	// Rotating the secret disables the previous capture URL
+	rotated, err := service.RotateCaptureSecret(ctx, "user1")
+	if err != nil {
+		t.Fatalf("Failed to rotate capture secret: %v", err)
+	}
+	if rotated.Secret == secret.Secret {
+		t.Fatal("Expected a new secret")
+	}
+	if _, err := service.Capture(ctx, secret.Secret, CaptureMessage{Title: "Late"}); err != repositories.ErrCaptureSecretNotFound {
+		t.Errorf("Expected ErrCaptureSecretNotFound for the old secret, got %v", err)
+	}
+	if _, err := service.Capture(ctx, rotated.Secret, CaptureMessage{Title: "Fresh"}); err != nil {
+		t.Errorf("Failed to capture with the new secret: %v", err)
+	}
+}
+
+// scopeRecordingRepository records the scope of the contexts its todos are
+// created with
+type scopeRecordingRepository struct {
+	repositories.TodoRepository
+	scopes []repositories.Scope
+}
+
+func (r *scopeRecordingRepository) CreateTodo(ctx context.Context, todo *models.Todo) error {
+	r.scopes = append(r.scopes, repositories.ScopeFromContext(ctx))
+	return r.TodoRepository.CreateTodo(ctx, todo)
+}
+
+func TestCaptureService_Capture_OwnerScope(t *testing.T) {
+	repo := &scopeRecordingRepository{TodoRepository: NewMockTodoRepository()}
+	captureRepo := repositories.NewMemoryCaptureRepository()
+	service := NewCaptureService(captureRepo, captureRepo, NewTodoService(repo))
+	ctx := context.Background()
+
+	secret, err := service.GetCaptureSecret(ctx, "user1")
+	if err != nil {
+		t.Fatalf("Failed to get capture secret: %v", err)
+	}
+
+	// The unauthenticated request writes with the rights of the secret's owner
+	if _, err := service.Capture(ctx, secret.Secret, CaptureMessage{Title: "Captured"}); err != nil {
+		t.Fatalf("Failed to capture todo: %v", err)
+	}
+	if len(repo.scopes) != 1 || repo.scopes[0].UserID != "user1" {
+		t.Errorf("Expected the todo to be created as user1, got %+v", repo.scopes)
+	}
+}
+
+func TestCaptureService_Capture_LooksUpOwners(t *testing.T) {
+	// The repository of the unscoped request sees no user's secrets, like
+	// row-level security hides them; only owners does
+	owners := repositories.NewMemoryCaptureRepository()
+	service := NewCaptureService(repositories.NewMemoryCaptureRepository(), owners, NewTodoService(NewMockTodoRepository()))
+	ctx := context.Background()
+
+	secret := models.NewCaptureSecret("user1")
+	if err := owners.SaveCaptureSecret(ctx, secret); err != nil {
+		t.Fatalf("Failed to save capture secret: %v", err)
+	}
+
+	todo, err := service.Capture(ctx, secret.Secret, CaptureMessage{Title: "Captured"})
+	if err != nil {
+		t.Fatalf("Failed to capture todo: %v", err)
+	}
+	if todo.UserID != "user1" {
+		t.Errorf("Expected the todo of user1, got %s", todo.UserID)
+	}
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+
+	"github.com/starbops/gottodo/internal/repositories"
+)
+
+// TodoChange is a todo change announced through the database by this or
+// another instance of the application
+type TodoChange struct {
+	Type   TodoEventType `json:"type"`
+	TodoID string        `json:"todo_id"`
+	UserID string        `json:"user_id"`
+}
+
+// EventRelay publishes todo changes announced through the database to the
+// local event hub, so the live updates of several instances stay in sync.
+// Services sharing the hub must not publish their changes themselves.
+type EventRelay struct {
+	todoRepo repositories.TodoRepository
+	hub      *EventHub
+}
+
+// NewEventRelay creates a new EventRelay
+func NewEventRelay(todoRepo repositories.TodoRepository, hub *EventHub) *EventRelay {
+	return &EventRelay{
+		todoRepo: todoRepo,
+		hub:      hub,
+	}
+}
+
+// Relay publishes the change described by a notification payload. Created
+// and updated todos are loaded so subscribers receive their current state.
+func (r *EventRelay) Relay(ctx context.Context, payload string) {
+	var change TodoChange
+	if err := json.Unmarshal([]byte(payload), &change); err != nil {
+		log.Printf("Ignoring malformed todo change %q: %v", payload, err)
+		return
+	}
+
+	event := &TodoEvent{
+		Type:   change.Type,
+		UserID: change.UserID,
+		TodoID: change.TodoID,
+	}
+
+	switch change.Type {
+	case TodoEventDeleted:
+	case TodoEventCreated, TodoEventUpdated:
+		todo, err := r.todoRepo.GetTodo(repositories.WithUserID(ctx, change.UserID), change.TodoID)
+		if errors.Is(err, repositories.ErrTodoNotFound) {
+			// Deleted in the meantime; its own notification follows
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to load todo %s for %s event: %v", change.TodoID, change.Type, err)
+			return
+		}
+		if todo.IsTrashed() {
+			return
+		}
+		event.Todo = todo
+	default:
+		log.Printf("Ignoring todo change of unknown type %q", change.Type)
+		return
+	}
+
+	r.hub.Publish(event)
+}
+
+// Reset makes every subscriber reload its state after changes may have been
+// missed, such as while the notification connection was down
+func (r *EventRelay) Reset() {
+	r.hub.Reset()
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/starbops/gottodo/internal/models"
+)
+
+func TestEventRelay_Relay(t *testing.T) {
+	repo := NewMockTodoRepository()
+	hub := NewEventHub()
+	relay := NewEventRelay(repo, hub)
+	sub := hub.Subscribe("user1")
+	defer sub.Close()
+	ctx := context.Background()
+
+	todo := &models.Todo{UserID: "user1", Title: "Shared"}
+	if err := repo.CreateTodo(ctx, todo); err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+
+	// Created and updated todos are loaded from the repository
+	relay.Relay(ctx, `{"type": "created", "todo_id": "`+todo.ID+`", "user_id": "user1"}`)
+	event := receive(t, sub)
+	if event.Type != TodoEventCreated || event.Todo == nil || event.Todo.Title != "Shared" {
+		t.Errorf("Expected the created todo, got %+v", event)
+	}
+
+	relay.Relay(ctx, `{"type": "deleted", "todo_id": "`+todo.ID+`", "user_id": "user1"}`)
+	if event := receive(t, sub); event.Type != TodoEventDeleted || event.Todo != nil {
+		t.Errorf("Expected a deleted event without todo, got %+v", event)
+	}
+
+	// Changes to missing todos and malformed payloads are skipped
+	relay.Relay(ctx, `{"type": "updated", "todo_id": "missing", "user_id": "user1"}`)
+	relay.Relay(ctx, `{"type": "renamed", "todo_id": "`+todo.ID+`", "user_id": "user1"}`)
+	relay.Relay(ctx, `not json`)
+	select {
+	case event := <-sub.Events():
+		t.Errorf("Expected no event, got %+v", event)
+	default:
+	}
+
+	relay.Reset()
+	if event := receive(t, sub); event.Type != TodoEventReset {
+		t.Errorf("Expected a reset event, got %+v", event)
+	}
+}
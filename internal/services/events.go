@@ -0,0 +1,337 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/starbops/gottodo/internal/models"
+)
+
+const (
+	// DefaultEventBufferSize is how many events may queue up for a subscriber
+	DefaultEventBufferSize = 64
+
+	// DefaultEventReplaySize is how many recent events are kept per user
+	DefaultEventReplaySize = 256
+
+	// DefaultEventReplayTTL is how long recent events are kept
+	DefaultEventReplayTTL = 10 * time.Minute
+)
+
+// TodoEventType identifies the kind of change a TodoEvent announces
+type TodoEventType string
+
+const (
+	// TodoEventCreated is published when a todo appears in the user's list
+	TodoEventCreated TodoEventType = "created"
+
+	// TodoEventUpdated is published when a todo in the list changes
+	TodoEventUpdated TodoEventType = "updated"
+
+	// TodoEventDeleted is published when a todo leaves the user's list
+	TodoEventDeleted TodoEventType = "deleted"
+
+	// TodoEventReset tells every subscriber to reload its state because
+	// events may have been lost
+	TodoEventReset TodoEventType = "reset"
+)
+
+// TodoEvent announces a change to one of a user's todos
+type TodoEvent struct {
+	// ID orders the events of a hub, starting at 1
+	ID        uint64        `json:"id"`
+	Type      TodoEventType `json:"type"`
+	UserID    string        `json:"user_id"`
+	TodoID    string        `json:"todo_id"`
+	Todo      *models.Todo  `json:"todo"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// todoEventType maps an activity action to the event announcing it
+func todoEventType(action models.ActivityAction) TodoEventType {
+	switch action {
+	case models.ActivityCreated, models.ActivityRestored:
+		// Restored todos reappear in the list
+		return TodoEventCreated
+	case models.ActivityDeleted, models.ActivityPurged:
+		return TodoEventDeleted
+	default:
+		return TodoEventUpdated
+	}
+}
+
+// EventHub fans out todo events to the subscribers of each user. Every
+// subscriber has a bounded buffer; a subscriber that falls behind is dropped
+// and expected to reconnect, catching up from the recent events the hub
+// keeps per user. Users without subscribers are forgotten once their recent
+// events have expired.
+type EventHub struct {
+	mu         sync.Mutex
+	instance   string
+	lastID     uint64
+	bufferSize int
+	replaySize int
+	replayTTL  time.Duration
+	users      map[string]*userEvents
+
+	// floor is the ID of the last reset; older events cannot be replayed
+	floor uint64
+
+	// expired is the ID of the newest event dropped for its age
+	expired uint64
+
+	// swept is when expired events and idle users were last cleaned up
+	swept time.Time
+}
+
+// userEvents holds the subscribers and recent events of a single user
+type userEvents struct {
+	subscribers map[*EventSubscription]struct{}
+	recent      []*TodoEvent
+
+	// evicted is the ID of the newest event dropped from recent
+	evicted uint64
+}
+
+// EventHubOption configures an EventHub
+type EventHubOption func(*EventHub)
+
+// WithEventBufferSize sets how many events may queue up for a subscriber
+func WithEventBufferSize(size int) EventHubOption {
+	return func(h *EventHub) {
+		if size > 0 {
+			h.bufferSize = size
+		}
+	}
+}
+
+// WithEventReplaySize sets how many recent events are kept per user
+func WithEventReplaySize(size int) EventHubOption {
+	return func(h *EventHub) {
+		if size > 0 {
+			h.replaySize = size
+		}
+	}
+}
+
+// WithEventReplayTTL sets how long recent events are kept
+func WithEventReplayTTL(ttl time.Duration) EventHubOption {
+	return func(h *EventHub) {
+		if ttl > 0 {
+			h.replayTTL = ttl
+		}
+	}
+}
+
+// NewEventHub creates a new EventHub
+func NewEventHub(opts ...EventHubOption) *EventHub {
+	h := &EventHub{
+		instance:   uuid.New().String()[:8],
+		bufferSize: DefaultEventBufferSize,
+		replaySize: DefaultEventReplaySize,
+		replayTTL:  DefaultEventReplayTTL,
+		users:      make(map[string]*userEvents),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Instance identifies the hub. Event IDs are only meaningful to the hub that
+// issued them, so clients reconnecting to another instance or after a
+// restart have to reload their state.
+func (h *EventHub) Instance() string {
+	return h.instance
+}
+
+// Publish assigns the event an ID and delivers it to the user's subscribers
+func (h *EventHub) Publish(event *TodoEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	h.sweep(now)
+
+	h.lastID++
+	event.ID = h.lastID
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = now
+	}
+
+	user := h.user(event.UserID)
+	if len(user.recent) == h.replaySize {
+		user.evicted = user.recent[0].ID
+		user.recent = append(user.recent[:0], user.recent[1:]...)
+	}
+	user.recent = append(user.recent, event)
+
+	for sub := range user.subscribers {
+		select {
+		case sub.events <- event:
+		default:
+			// Never block publishers on a slow subscriber
+			h.remove(sub)
+		}
+	}
+}
+
+// Subscribe registers a subscriber for the user's events. Callers must Close
+// the subscription when done.
+func (h *EventHub) Subscribe(userID string) *EventSubscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sub := &EventSubscription{
+		hub:    h,
+		userID: userID,
+		events: make(chan *TodoEvent, h.bufferSize),
+	}
+	h.user(userID).subscribers[sub] = struct{}{}
+	return sub
+}
+
+// Since returns the user's events published after the given event ID. It
+// reports false when some of those events are no longer kept, or the ID was
+// never issued by this hub, so the caller has to reload the full state.
+func (h *EventHub) Since(userID string, lastEventID uint64) ([]*TodoEvent, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if lastEventID > h.lastID || lastEventID < h.floor {
+		return nil, false
+	}
+
+	user, exists := h.users[userID]
+	if !exists {
+		// A forgotten user may have had events since expired
+		return nil, lastEventID >= h.expired
+	}
+	h.expire(user, time.Now())
+	if lastEventID < user.evicted {
+		return nil, false
+	}
+
+	var events []*TodoEvent
+	for _, event := range user.recent {
+		if event.ID > lastEventID {
+			events = append(events, event)
+		}
+	}
+	return events, true
+}
+
+// Reset discards the recent events and sends every subscriber a reset event.
+// It is used when events may have been lost, such as after the connection
+// delivering them from other instances dropped.
+func (h *EventHub) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastID++
+	h.floor = h.lastID
+	event := &TodoEvent{ID: h.lastID, Type: TodoEventReset, CreatedAt: time.Now()}
+
+	for _, user := range h.users {
+		user.recent = nil
+		user.evicted = 0
+		for sub := range user.subscribers {
+			select {
+			case sub.events <- event:
+			default:
+				h.remove(sub)
+			}
+		}
+	}
+}
+
+// LastID returns the ID of the most recently published event
+func (h *EventHub) LastID() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.lastID
+}
+
+// user returns the state of a user, creating it if needed. The caller must
+// hold the lock.
+func (h *EventHub) user(userID string) *userEvents {
+	user, exists := h.users[userID]
+	if !exists {
+		user = &userEvents{subscribers: make(map[*EventSubscription]struct{})}
+		h.users[userID] = user
+	}
+	return user
+}
+
+// expire drops the user's events older than the replay TTL. The caller must
+// hold the lock.
+func (h *EventHub) expire(user *userEvents, now time.Time) {
+	cutoff := now.Add(-h.replayTTL)
+	n := 0
+	for n < len(user.recent) && user.recent[n].CreatedAt.Before(cutoff) {
+		n++
+	}
+	if n == 0 {
+		return
+	}
+
+	user.evicted = user.recent[n-1].ID
+	h.expired = max(h.expired, user.evicted)
+	user.recent = append(user.recent[:0], user.recent[n:]...)
+}
+
+// sweep expires the events of every user and forgets the users left without
+// subscribers or events, at most once per replay TTL. The caller must hold
+// the lock.
+func (h *EventHub) sweep(now time.Time) {
+	if now.Sub(h.swept) < h.replayTTL {
+		return
+	}
+	h.swept = now
+
+	for userID, user := range h.users {
+		h.expire(user, now)
+		if len(user.subscribers) == 0 && len(user.recent) == 0 {
+			delete(h.users, userID)
+		}
+	}
+}
+
+// remove unregisters a subscriber and closes its channel, forgetting the user
+// if nothing is left to replay. The caller must hold the lock.
+func (h *EventHub) remove(sub *EventSubscription) {
+	user, exists := h.users[sub.userID]
+	if !exists {
+		return
+	}
+	if _, subscribed := user.subscribers[sub]; !subscribed {
+		return
+	}
+	delete(user.subscribers, sub)
+	close(sub.events)
+
+	if len(user.subscribers) == 0 && len(user.recent) == 0 {
+		delete(h.users, sub.userID)
+	}
+}
+
+// EventSubscription receives the events of a single user
+type EventSubscription struct {
+	hub    *EventHub
+	userID string
+	events chan *TodoEvent
+}
+
+// Events returns the channel the user's events are delivered on. It is closed
+// when the subscription is closed or dropped for falling behind.
+func (s *EventSubscription) Events() <-chan *TodoEvent {
+	return s.events
+}
+
+// Close unregisters the subscription
+func (s *EventSubscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.remove(s)
+}
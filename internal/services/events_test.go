@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/starbops/gottodo/internal/models"
+)
+
+// receive returns the next event of a subscription without blocking
+func receive(t *testing.T, sub *EventSubscription) *TodoEvent {
+	t.Helper()
+	select {
+	case event, ok := <-sub.Events():
+		if !ok {
+			t.Fatal("Expected an event, but the subscription was closed")
+		}
+		return event
+	default:
+		t.Fatal("Expected an event, but none was delivered")
+		return nil
+	}
+}
+
+func TestEventHub_DeliversToUserSubscribers(t *testing.T) {
+	hub := NewEventHub()
+	sub := hub.Subscribe("user1")
+	defer sub.Close()
+	other := hub.Subscribe("user2")
+	defer other.Close()
+
+	hub.Publish(&TodoEvent{Type: TodoEventCreated, UserID: "user1", TodoID: "todo1"})
+
+	event := receive(t, sub)
+	if event.ID != 1 || event.TodoID != "todo1" {
+		t.Errorf("Expected event 1 for todo1, got event %d for %s", event.ID, event.TodoID)
+	}
+	if event.CreatedAt.IsZero() {
+		t.Error("Expected the event to be timestamped")
+	}
+
+	select {
+	case event := <-other.Events():
+		t.Errorf("Expected no event for another user, got %+v", event)
+	default:
+	}
+}
+
+func TestEventHub_DropsSlowSubscribers(t *testing.T) {
+	hub := NewEventHub(WithEventBufferSize(2))
+	sub := hub.Subscribe("user1")
+
+	for i := 0; i < 3; i++ {
+		hub.Publish(&TodoEvent{Type: TodoEventUpdated, UserID: "user1", TodoID: "todo1"})
+	}
+
+	// The buffered events are still delivered before the channel closes
+	receive(t, sub)
+	receive(t, sub)
+	if _, ok := <-sub.Events(); ok {
+		t.Fatal("Expected the subscription to be closed after overflowing")
+	}
+
+	// Closing a dropped subscription is a no-op
+	sub.Close()
+
+	// The dropped subscriber can catch up after reconnecting
+	events, ok := hub.Since("user1", 2)
+	if !ok || len(events) != 1 || events[0].ID != 3 {
+		t.Errorf("Expected to catch up with event 3, got %v (complete: %v)", events, ok)
+	}
+}
+
+func TestEventHub_Since(t *testing.T) {
+	hub := NewEventHub(WithEventReplaySize(2))
+	for i := 0; i < 3; i++ {
+		hub.Publish(&TodoEvent{Type: TodoEventUpdated, UserID: "user1", TodoID: "todo1"})
+	}
+	hub.Publish(&TodoEvent{Type: TodoEventUpdated, UserID: "user2", TodoID: "todo2"})
+
+	tests := []struct {
+		name        string
+		userID      string
+		lastEventID uint64
+		wantIDs     []uint64
+		wantOK      bool
+	}{
+		{name: "up to date", userID: "user1", lastEventID: 3, wantOK: true},
+		{name: "recent events", userID: "user1", lastEventID: 1, wantIDs: []uint64{2, 3}, wantOK: true},
+		{name: "other users' events are skipped", userID: "user2", lastEventID: 1, wantIDs: []uint64{4}, wantOK: true},
+		{name: "evicted events", userID: "user1", lastEventID: 0, wantOK: false},
+		{name: "unknown event ID", userID: "user1", lastEventID: 99, wantOK: false},
+		{name: "user without events", userID: "user3", lastEventID: 2, wantOK: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, ok := hub.Since(tt.userID, tt.lastEventID)
+			if ok != tt.wantOK {
+				t.Fatalf("Expected complete to be %v, got %v", tt.wantOK, ok)
+			}
+			if len(events) != len(tt.wantIDs) {
+				t.Fatalf("Expected %d events, got %d", len(tt.wantIDs), len(events))
+			}
+			for i, event := range events {
+				if event.ID != tt.wantIDs[i] {
+					t.Errorf("Expected event %d, got %d", tt.wantIDs[i], event.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestTodoService_PublishesEvents(t *testing.T) {
+	hub := NewEventHub()
+	service := NewTodoService(NewMockTodoRepository(), WithEventHub(hub))
+	sub := hub.Subscribe("user1")
+	defer sub.Close()
+	ctx := context.Background()
+
+	todo := &models.Todo{UserID: "user1", Title: "Original"}
+	if err := service.CreateTodo(ctx, todo); err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+	if err := service.UpdateTodoStatus(ctx, todo.ID, "user1", true, 0); err != nil {
+		t.Fatalf("Failed to update todo status: %v", err)
+	}
+	if err := service.DeleteTodo(ctx, todo.ID, "user1", 0); err != nil {
+		t.Fatalf("Failed to delete todo: %v", err)
+	}
+	if err := service.RestoreTodo(ctx, todo.ID, "user1"); err != nil {
+		t.Fatalf("Failed to restore todo: %v", err)
+	}
+
+	expected := []TodoEventType{TodoEventCreated, TodoEventUpdated, TodoEventDeleted, TodoEventCreated}
+	for _, want := range expected {
+		event := receive(t, sub)
+		if event.Type != want || event.TodoID != todo.ID {
+			t.Errorf("Expected %s event for %s, got %s event for %s", want, todo.ID, event.Type, event.TodoID)
+		}
+		if want == TodoEventDeleted && event.Todo != nil {
+			t.Error("Expected deleted events to carry no todo")
+		}
+		if want != TodoEventDeleted && (event.Todo == nil || event.Todo == todo) {
+			t.Error("Expected events to carry a copy of the todo")
+		}
+	}
+
+	// The event snapshot reflects the todo at the time of the change
+	if err := service.UpdateTodoStatus(ctx, todo.ID, "user1", false, 0); err != nil {
+		t.Fatalf("Failed to update todo status: %v", err)
+	}
+	if event := receive(t, sub); event.Todo.Completed {
+		t.Error("Expected the reopened todo in the event")
+	}
+}
+
+func TestEventHub_Reset(t *testing.T) {
+	hub := NewEventHub()
+	sub := hub.Subscribe("user1")
+	defer sub.Close()
+
+	hub.Publish(&TodoEvent{Type: TodoEventCreated, UserID: "user1", TodoID: "todo1"})
+	receive(t, sub)
+
+	hub.Reset()
+	if event := receive(t, sub); event.Type != TodoEventReset || event.ID != 2 {
+		t.Errorf("Expected reset event 2, got %s event %d", event.Type, event.ID)
+	}
+
+	// Events before the reset can no longer be replayed
+	if _, ok := hub.Since("user1", 1); ok {
+		t.Error("Expected events before the reset to be unavailable")
+	}
+	if events, ok := hub.Since("user1", 2); !ok || len(events) != 0 {
+		t.Errorf("Expected to be up to date after the reset, got %v (complete: %v)", events, ok)
+	}
+}
+
+func TestEventHub_ForgetsIdleUsers(t *testing.T) {
+	hub := NewEventHub(WithEventReplayTTL(time.Minute))
+
+	// A user whose last subscriber leaves with nothing to replay is forgotten
+	sub := hub.Subscribe("user1")
+	sub.Close()
+	if len(hub.users) != 0 {
+		t.Fatalf("Expected the idle user to be forgotten, got %d users", len(hub.users))
+	}
+
+	// Users keep their recent events after their subscribers leave
+	sub = hub.Subscribe("user1")
+	hub.Publish(&TodoEvent{Type: TodoEventCreated, UserID: "user1", TodoID: "todo1", CreatedAt: time.Now().Add(-2 * time.Minute)})
+	hub.Publish(&TodoEvent{Type: TodoEventCreated, UserID: "user2", TodoID: "todo2"})
+	sub.Close()
+	if len(hub.users) != 2 {
+		t.Fatalf("Expected both users to be kept for replay, got %d users", len(hub.users))
+	}
+
+	// and are forgotten once those have expired
+	hub.swept = time.Time{}
+	hub.Publish(&TodoEvent{Type: TodoEventCreated, UserID: "user3", TodoID: "todo3"})
+	if _, exists := hub.users["user1"]; exists {
+		t.Error("Expected user1 to be forgotten after its events expired")
+	}
+	if _, exists := hub.users["user2"]; !exists {
+		t.Error("Expected user2 to be kept while its event is recent")
+	}
+
+	// A forgotten user catching up from before the expired events has to reload
+	if _, ok := hub.Since("user1", 0); ok {
+		t.Error("Expected expired events to be unavailable")
+	}
+	if events, ok := hub.Since("user1", 1); !ok || len(events) != 0 {
+		t.Errorf("Expected to be up to date after the expired event, got %v (complete: %v)", events, ok)
+	}
+}
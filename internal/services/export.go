@@ -0,0 +1,375 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/pkg/todoimport"
+)
+
+// ExportFormat is a format the todos of a user can be exported in
+type ExportFormat string
+
+const (
+	// ExportJSON is a lossless export of every todo with its history, which
+	// can be imported again
+	ExportJSON ExportFormat = "json"
+
+	// ExportCSV has a row for every todo, with a column for each field
+	ExportCSV ExportFormat = "csv"
+
+	// ExportMarkdown is a checklist of the todos not in the trash
+	ExportMarkdown ExportFormat = "markdown"
+
+	// ExportTodoTxt is a todo.txt file of the todos not in the trash
+	ExportTodoTxt ExportFormat = "todo.txt"
+)
+
+// ExportFormats lists the supported export formats
+var ExportFormats = []ExportFormat{ExportJSON, ExportCSV, ExportMarkdown, ExportTodoTxt}
+
+// ErrUnknownExportFormat is returned for formats not in ExportFormats
+var ErrUnknownExportFormat = errors.New("unknown export format")
+
+// ContentType returns the media type of exports in the format
+func (f ExportFormat) ContentType() string {
+	switch f {
+	case ExportJSON:
+		return "application/json; charset=utf-8"
+	case ExportCSV:
+		return "text/csv; charset=utf-8"
+	case ExportMarkdown:
+		return "text/markdown; charset=utf-8"
+	default:
+		return "text/plain; charset=utf-8"
+	}
+}
+
+// FileName returns the name exports in the format are saved under
+func (f ExportFormat) FileName() string {
+	switch f {
+	case ExportJSON:
+		return "todos.json"
+	case ExportCSV:
+		return "todos.csv"
+	case ExportMarkdown:
+		return "todos.md"
+	default:
+		return "todo.txt"
+	}
+}
+
+// exportVersion is the version of the JSON export layout
+const exportVersion = 1
+
+// ExportedTodo is a todo of a JSON export together with its history
+type ExportedTodo struct {
+	*models.Todo
+
+	// History is the activity of the todo, newest first
+	History []*models.Activity `json:"history,omitempty"`
+}
+
+// todoEncoder writes the todos of an export one at a time
+type todoEncoder interface {
+	begin() error
+	encode(todo *models.Todo, history []*models.Activity) error
+	end() error
+}
+
+// Export writes all todos of a user, trashed ones included where the format
+// can tell them apart, to w. The todos are read and written one at a time,
+// so exports of any size take little memory.
+func (s *TodoService) Export(ctx context.Context, userID string, format ExportFormat, w io.Writer) error {
+	if userID == "" {
+		return errors.New("user ID cannot be empty")
+	}
+
+	var enc todoEncoder
+	switch format {
+	case ExportJSON:
+		enc = &jsonTodoEncoder{w: w, exportedAt: time.Now().UTC()}
+	case ExportCSV:
+		enc = &csvTodoEncoder{w: csv.NewWriter(w)}
+	case ExportMarkdown:
+		enc = &markdownTodoEncoder{w: bufio.NewWriter(w)}
+	case ExportTodoTxt:
+		enc = &todoTxtEncoder{w: bufio.NewWriter(w)}
+	default:
+		return ErrUnknownExportFormat
+	}
+
+	if err := enc.begin(); err != nil {
+		return err
+	}
+	err := s.todoRepo.StreamUserTodos(ctx, userID, func(todo *models.Todo) error {
+		var history []*models.Activity
+		if format == ExportJSON && s.activityRepo != nil {
+			var err error
+			if history, err = s.activityRepo.GetTodoActivity(ctx, todo.ID); err != nil {
+				return fmt.Errorf("failed to get history of todo %s: %w", todo.ID, err)
+			}
+		}
+		return enc.encode(todo, history)
+	})
+	if err != nil {
+		return err
+	}
+	return enc.end()
+}
+
+// jsonTodoEncoder writes an object holding the todos as an array, one todo
+// per line
+type jsonTodoEncoder struct {
+	w          io.Writer
+	exportedAt time.Time
+	count      int
+}
+
+func (e *jsonTodoEncoder) begin() error {
+	_, err := fmt.Fprintf(e.w, `{"version":%d,"exported_at":%q,"todos":[`, exportVersion, e.exportedAt.Format(time.RFC3339))
+	return err
+}
+
+func (e *jsonTodoEncoder) encode(todo *models.Todo, history []*models.Activity) error {
+	data, err := json.Marshal(ExportedTodo{Todo: todo, History: history})
+	if err != nil {
+		return err
+	}
+	separator := ",\n"
+	if e.count == 0 {
+		separator = "\n"
+	}
+	e.count++
+	if _, err := io.WriteString(e.w, separator); err != nil {
+		return err
+	}
+	_, err = e.w.Write(data)
+	return err
+}
+
+func (e *jsonTodoEncoder) end() error {
+	_, err := io.WriteString(e.w, "\n]}\n")
+	return err
+}
+
+// csvHeader are the columns of CSV exports, named so that CSV imports find
+// them
+var csvHeader = []string{"id", "title", "description", "completed", "due_at", "priority", "project", "tags", "recurrence", "version", "created_at", "updated_at", "deleted_at"}
+
+// csvTodoEncoder writes a row for every todo, escaping the text users enter
+// so that spreadsheet apps do not run it as formulas
+type csvTodoEncoder struct {
+	w *csv.Writer
+}
+
+func (e *csvTodoEncoder) begin() error {
+	return e.w.Write(csvHeader)
+}
+
+func (e *csvTodoEncoder) encode(todo *models.Todo, _ []*models.Activity) error {
+	return e.w.Write([]string{
+		todo.ID,
+		todoimport.EscapeCSVField(todo.Title),
+		todoimport.EscapeCSVField(todo.Description),
+		strconv.FormatBool(todo.Completed),
+		formatDue(todo),
+		string(todo.Priority),
+		todoimport.EscapeCSVField(todo.Project),
+		todoimport.EscapeCSVField(strings.Join(todo.Tags, ", ")),
+		todo.Recurrence,
+		strconv.Itoa(todo.Version),
+		formatExportTime(&todo.CreatedAt),
+		formatExportTime(&todo.UpdatedAt),
+		formatExportTime(todo.DeletedAt),
+	})
+}
+
+func (e *csvTodoEncoder) end() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// formatExportTime formats a time for exports, or "" if it is not set
+func formatExportTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// formatDue formats the due date of a todo for exports, leaving out the time
+// of todos due on a whole day
+func formatDue(todo *models.Todo) string {
+	if todo.DueAllDay() {
+		return todo.DueAt.UTC().Format("2006-01-02")
+	}
+	return formatExportTime(todo.DueAt)
+}
+
+// markdownTodoEncoder writes a task list item for every todo not in the
+// trash, with its details and description nested below it
+type markdownTodoEncoder struct {
+	w *bufio.Writer
+}
+
+func (e *markdownTodoEncoder) begin() error {
+	_, err := e.w.WriteString("# Todos\n\n")
+	return err
+}
+
+func (e *markdownTodoEncoder) encode(todo *models.Todo, _ []*models.Activity) error {
+	if todo.IsTrashed() {
+		return nil
+	}
+
+	check := " "
+	if todo.Completed {
+		check = "x"
+	}
+	fmt.Fprintf(e.w, "- [%s] %s\n", check, singleLine(todo.Title))
+	if todo.DueAt != nil {
+		fmt.Fprintf(e.w, "  - Due: %s\n", formatDue(todo))
+	}
+	if todo.Priority != models.PriorityNone {
+		fmt.Fprintf(e.w, "  - Priority: %s\n", todo.Priority)
+	}
+	if todo.Project != "" {
+		fmt.Fprintf(e.w, "  - Project: %s\n", singleLine(todo.Project))
+	}
+	if len(todo.Tags) > 0 {
+		fmt.Fprintf(e.w, "  - Tags: #%s\n", strings.Join(todo.Tags, ", #"))
+	}
+	if todo.Recurrence != "" && models.IsValidRecurrence(todo.Recurrence) {
+		fmt.Fprintf(e.w, "  - Repeats: `%s`\n", todo.Recurrence)
+	}
+	if description := strings.TrimSpace(todo.Description); description != "" {
+		e.w.WriteString("\n")
+		for _, line := range strings.Split(description, "\n") {
+			fmt.Fprintf(e.w, "  %s\n", strings.TrimRight(line, "\r"))
+		}
+		e.w.WriteString("\n")
+	}
+	return nil
+}
+
+func (e *markdownTodoEncoder) end() error {
+	return e.w.Flush()
+}
+
+// todoTxtEncoder writes a todo.txt line for every todo not in the trash.
+// Descriptions and recurrences todo.txt cannot express are left out.
+type todoTxtEncoder struct {
+	w *bufio.Writer
+}
+
+func (e *todoTxtEncoder) begin() error {
+	return nil
+}
+
+func (e *todoTxtEncoder) encode(todo *models.Todo, _ []*models.Activity) error {
+	if todo.IsTrashed() {
+		return nil
+	}
+
+	var words []string
+	if todo.Completed {
+		// The completion date is not kept; the last change comes closest
+		words = append(words, "x", todoTxtDate(todo.UpdatedAt, todo.CreatedAt))
+	} else if letter := todoTxtPriorities[todo.Priority]; letter != "" {
+		words = append(words, "("+letter+")")
+	}
+	if !todo.CreatedAt.IsZero() {
+		words = append(words, todo.CreatedAt.UTC().Format("2006-01-02"))
+	}
+	words = append(words, singleLine(todo.Title))
+	if todo.Project != "" {
+		words = append(words, "+"+todoTxtWord(todo.Project))
+	}
+	for _, tag := range todo.Tags {
+		words = append(words, "@"+todoTxtWord(tag))
+	}
+	if todo.DueAt != nil {
+		words = append(words, "due:"+todo.DueAt.UTC().Format("2006-01-02"))
+	}
+	if todo.Completed && todoTxtPriorities[todo.Priority] != "" {
+		words = append(words, "pri:"+todoTxtPriorities[todo.Priority])
+	}
+	if rec := todoTxtRecurrence(todo.Recurrence); rec != "" {
+		words = append(words, "rec:"+rec)
+	}
+
+	_, err := e.w.WriteString(strings.Join(words, " ") + "\n")
+	return err
+}
+
+func (e *todoTxtEncoder) end() error {
+	return e.w.Flush()
+}
+
+// todoTxtPriorities are the todo.txt priorities of todos
+var todoTxtPriorities = map[models.Priority]string{
+	models.PriorityHigh:   "A",
+	models.PriorityMedium: "B",
+	models.PriorityLow:    "C",
+}
+
+// todoTxtDate formats the first of the given times that is set as a date
+func todoTxtDate(times ...time.Time) string {
+	for _, t := range times {
+		if !t.IsZero() {
+			return t.UTC().Format("2006-01-02")
+		}
+	}
+	return time.Now().UTC().Format("2006-01-02")
+}
+
+// todoTxtWord turns a project or tag into a single word
+func todoTxtWord(text string) string {
+	return strings.Join(strings.Fields(text), "_")
+}
+
+// todoTxtRecurrence converts the simple RRULEs todo.txt can express, such as
+// FREQ=WEEKLY;INTERVAL=2, to the rec: extension
+func todoTxtRecurrence(rule string) string {
+	if !models.IsValidRecurrence(rule) {
+		return ""
+	}
+	parts := make(map[string]string)
+	for _, part := range strings.Split(rule, ";") {
+		if name, value, ok := strings.Cut(part, "="); ok {
+			parts[name] = value
+		}
+	}
+	interval := parts["INTERVAL"]
+	delete(parts, "INTERVAL")
+
+	if len(parts) == 2 && parts["FREQ"] == "WEEKLY" && parts["BYDAY"] == "MO,TU,WE,TH,FR" && interval == "" {
+		return "b"
+	}
+	if len(parts) != 1 {
+		return ""
+	}
+	unit := map[string]string{"DAILY": "d", "WEEKLY": "w", "MONTHLY": "m", "YEARLY": "y"}[parts["FREQ"]]
+	if unit == "" {
+		return ""
+	}
+	if interval == "" {
+		interval = "1"
+	}
+	return interval + unit
+}
+
+// singleLine joins the lines of a text with spaces
+func singleLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
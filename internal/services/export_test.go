@@ -0,0 +1,203 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
+	"github.com/starbops/gottodo/pkg/todoimport"
+)
+
+// newExportTestService returns a service holding an open, a completed and a
+// trashed todo of user1
+func newExportTestService(t *testing.T) *TodoService {
+	t.Helper()
+
+	service := NewTodoService(repositories.NewMemoryTodoRepository(), WithActivityRepository(repositories.NewMemoryActivityRepository()))
+	ctx := context.Background()
+	created := time.Date(2025, time.February, 20, 8, 0, 0, 0, time.UTC)
+	due := time.Date(2025, time.March, 14, 0, 0, 0, 0, time.UTC)
+
+	todos := []*models.Todo{
+		{
+			UserID: "user1", Title: "Call Ann", Description: "About the trip\nand the car",
+			DueAt: &due, Priority: models.PriorityHigh, Project: "Family", Tags: []string{"phone", "red category"},
+			Recurrence: "FREQ=WEEKLY;INTERVAL=2", CreatedAt: created,
+		},
+		{UserID: "user1", Title: "Water plants", CreatedAt: created.Add(time.Hour)},
+		{UserID: "user1", Title: "Old", CreatedAt: created.Add(2 * time.Hour)},
+		{UserID: "user2", Title: "Not mine", CreatedAt: created},
+	}
+	for _, todo := range todos {
+		if err := service.CreateTodo(ctx, todo); err != nil {
+			t.Fatalf("Failed to create todo: %v", err)
+		}
+	}
+	if err := service.UpdateTodoStatus(ctx, todos[1].ID, "user1", true, 0); err != nil {
+		t.Fatalf("Failed to complete todo: %v", err)
+	}
+	if err := service.DeleteTodo(ctx, todos[2].ID, "user1", 0); err != nil {
+		t.Fatalf("Failed to delete todo: %v", err)
+	}
+	return service
+}
+
+// export exports the todos of user1
+func export(t *testing.T, service *TodoService, format ExportFormat) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := service.Export(context.Background(), "user1", format, &buf); err != nil {
+		t.Fatalf("Failed to export %s: %v", format, err)
+	}
+	return buf.String()
+}
+
+func TestTodoService_Export_JSON(t *testing.T) {
+	service := newExportTestService(t)
+	data := export(t, service, ExportJSON)
+
+	var exported struct {
+		Version int            `json:"version"`
+		Todos   []ExportedTodo `json:"todos"`
+	}
+	if err := json.Unmarshal([]byte(data), &exported); err != nil {
+		t.Fatalf("Failed to decode export: %v\n%s", err, data)
+	}
+	if exported.Version != 1 || len(exported.Todos) != 3 {
+		t.Fatalf("Expected the 3 todos of user1, got %+v", exported)
+	}
+	first := exported.Todos[0]
+	if first.Title != "Call Ann" || first.Recurrence != "FREQ=WEEKLY;INTERVAL=2" || len(first.Tags) != 2 || len(first.History) != 1 {
+		t.Errorf("Expected every field and the history of the first todo, got %+v", first)
+	}
+	if trashed := exported.Todos[2]; !trashed.IsTrashed() || len(trashed.History) != 2 {
+		t.Errorf("Expected the trashed todo with its history, got %+v", trashed)
+	}
+
+	// JSON exports can be imported again
+	importService := NewImportService(NewTodoService(repositories.NewMemoryTodoRepository()))
+	result, err := importService.Import(context.Background(), "user3", todoimport.FormatJSON, strings.NewReader(data), ImportOptions{})
+	if err != nil {
+		t.Fatalf("Failed to import export: %v", err)
+	}
+	if result.Imported != 2 || result.Invalid != 1 {
+		t.Fatalf("Expected the todos outside the trash to be imported, got %+v", result)
+	}
+	imported := result.Rows[0].Todo
+	if imported.Title != first.Title || imported.Description != first.Description || !imported.DueAt.Equal(*first.DueAt) ||
+		imported.Priority != first.Priority || imported.Project != first.Project || imported.Recurrence != first.Recurrence ||
+		!imported.CreatedAt.Equal(first.CreatedAt) || strings.Join(imported.Tags, ",") != strings.Join(first.Tags, ",") {
+		t.Errorf("Expected the imported todo to match the exported one, got %+v", imported)
+	}
+}
+
+func TestTodoService_Export_CSV(t *testing.T) {
+	service := newExportTestService(t)
+	records, err := csv.NewReader(strings.NewReader(export(t, service, ExportCSV))).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to read CSV export: %v", err)
+	}
+	if len(records) != 4 || strings.Join(records[0], ",") != strings.Join(csvHeader, ",") {
+		t.Fatalf("Expected a header and 3 rows, got %v", records)
+	}
+	if row := records[1]; row[1] != "Call Ann" || row[2] != "About the trip\nand the car" || row[4] != "2025-03-14" ||
+		row[5] != "high" || row[7] != "phone, red category" || row[10] != "2025-02-20T08:00:00Z" {
+		t.Errorf("Expected the fields of the first todo, got %v", row)
+	}
+	if row := records[2]; row[3] != "true" || row[4] != "" {
+		t.Errorf("Expected the completed todo without a due date, got %v", row)
+	}
+	if row := records[3]; row[12] == "" {
+		t.Errorf("Expected the trashed todo to carry its deletion time, got %v", row)
+	}
+
+	// CSV exports can be imported again
+	items, err := todoimport.Parse(todoimport.FormatCSV, strings.NewReader(export(t, service, ExportCSV)), todoimport.Options{})
+	if err != nil || len(items) != 3 || items[0].Err != nil || items[0].Due == nil || items[0].Priority != todoimport.PriorityHigh || !items[1].Completed {
+		t.Errorf("Expected the CSV export to be read back, got %+v (%v)", items, err)
+	}
+}
+
+func TestTodoService_Export_CSVFormulas(t *testing.T) {
+	service := NewTodoService(repositories.NewMemoryTodoRepository())
+	ctx := context.Background()
+
+	todo := &models.Todo{UserID: "user1", Title: "=HYPERLINK(\"https://example.com\")", Description: "+1 555 0100", Project: "@home", Tags: []string{"-urgent"}}
+	if err := service.CreateTodo(ctx, todo); err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+
+	// Text that spreadsheet apps would run as a formula is escaped
+	data := export(t, service, ExportCSV)
+	records, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to read CSV export: %v", err)
+	}
+	if row := records[1]; row[1] != "'"+todo.Title || row[2] != "'+1 555 0100" || row[6] != "'@home" || row[7] != "'-urgent" {
+		t.Errorf("Expected the formulas to be escaped, got %v", row)
+	}
+
+	// and comes back unchanged when imported
+	items, err := todoimport.Parse(todoimport.FormatCSV, strings.NewReader(data), todoimport.Options{})
+	if err != nil || len(items) != 1 {
+		t.Fatalf("Expected the CSV export to be read back, got %+v (%v)", items, err)
+	}
+	if item := items[0]; item.Title != todo.Title || item.Description != todo.Description || item.Project != todo.Project || strings.Join(item.Tags, ",") != "-urgent" {
+		t.Errorf("Expected the original text, got %+v", item)
+	}
+}
+
+func TestTodoService_Export_Markdown(t *testing.T) {
+	service := newExportTestService(t)
+
+	want := "# Todos\n\n" +
+		"- [ ] Call Ann\n" +
+		"  - Due: 2025-03-14\n" +
+		"  - Priority: high\n" +
+		"  - Project: Family\n" +
+		"  - Tags: #phone, #red category\n" +
+		"  - Repeats: `FREQ=WEEKLY;INTERVAL=2`\n" +
+		"\n" +
+		"  About the trip\n" +
+		"  and the car\n" +
+		"\n" +
+		"- [x] Water plants\n"
+	if got := export(t, service, ExportMarkdown); got != want {
+		t.Errorf("Unexpected Markdown export:\n%s", got)
+	}
+}
+
+func TestTodoService_Export_TodoTxt(t *testing.T) {
+	service := newExportTestService(t)
+	data := export(t, service, ExportTodoTxt)
+
+	lines := strings.Split(strings.TrimSpace(data), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected a line per todo outside the trash, got %q", data)
+	}
+	if want := "(A) 2025-02-20 Call Ann +Family @phone @red_category due:2025-03-14 rec:2w"; lines[0] != want {
+		t.Errorf("Expected %q, got %q", want, lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "x ") || !strings.HasSuffix(lines[1], " 2025-02-20 Water plants") {
+		t.Errorf("Expected the completed todo, got %q", lines[1])
+	}
+
+	// todo.txt exports can be imported again
+	items, err := todoimport.Parse(todoimport.FormatTodoTxt, strings.NewReader(data), todoimport.Options{})
+	if err != nil || len(items) != 2 || items[0].Title != "Call Ann" || items[0].Recurrence != "FREQ=WEEKLY;INTERVAL=2" || !items[1].Completed {
+		t.Errorf("Expected the todo.txt export to be read back, got %+v (%v)", items, err)
+	}
+}
+
+func TestTodoService_Export_UnknownFormat(t *testing.T) {
+	service := newExportTestService(t)
+	if err := service.Export(context.Background(), "user1", "pdf", &bytes.Buffer{}); err != ErrUnknownExportFormat {
+		t.Errorf("Expected ErrUnknownExportFormat, got %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
+)
+
+// DefaultIdempotencyTTL is how long responses are kept for replay by default
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// idempotencyReservationTTL bounds how long a key stays reserved for a request
+// that never gets its response stored, say because its server went down
+const idempotencyReservationTTL = 5 * time.Minute
+
+// ErrIdempotencyKeyReused is returned when an idempotency key is sent again
+// with a request that differs from the one it was first used for
+var ErrIdempotencyKeyReused = errors.New("idempotency key was already used for a different request")
+
+// ErrIdempotencyKeyInUse is returned when an idempotency key is sent again
+// while the request it was first used for is still running elsewhere
+var ErrIdempotencyKeyInUse = errors.New("a request with this idempotency key is still in progress")
+
+// IdempotencyService remembers responses to requests sent with an
+// Idempotency-Key header so retried requests are not executed twice
+type IdempotencyService struct {
+	repo repositories.IdempotencyRepository
+	ttl  time.Duration
+
+	mutex sync.Mutex
+	locks map[string]*keyLock
+}
+
+// keyLock serializes the requests sharing one idempotency key
+type keyLock struct {
+	sync.Mutex
+	waiters int
+}
+
+// NewIdempotencyService creates a new IdempotencyService
+func NewIdempotencyService(repo repositories.IdempotencyRepository, ttl time.Duration) *IdempotencyService {
+	if ttl <= 0 {
+		ttl = DefaultIdempotencyTTL
+	}
+
+	return &IdempotencyService{
+		repo:  repo,
+		ttl:   ttl,
+		locks: make(map[string]*keyLock),
+	}
+}
+
+// HashRequest fingerprints the parts of a request that must match on replay
+func HashRequest(method, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method))
+	hash.Write([]byte{0})
+	hash.Write([]byte(path))
+	hash.Write([]byte{0})
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Lock waits until no other request with the same key is in flight on this
+// server and returns the function releasing the key again. Requests on other
+// servers are kept apart by Reserve instead.
+func (s *IdempotencyService) Lock(userID, key string) func() {
+	name := userID + "\x00" + key
+
+	s.mutex.Lock()
+	lock, exists := s.locks[name]
+	if !exists {
+		lock = &keyLock{}
+		s.locks[name] = lock
+	}
+	lock.waiters++
+	s.mutex.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+
+		s.mutex.Lock()
+		lock.waiters--
+		if lock.waiters == 0 {
+			delete(s.locks, name)
+		}
+		s.mutex.Unlock()
+	}
+}
+
+// Reserve claims a key for a request. It returns the stored response if the
+// request was answered before, or nil if the caller now holds the key and
+// must run the request and then Save or Release it. It returns
+// ErrIdempotencyKeyReused if the key belongs to another request and
+// ErrIdempotencyKeyInUse if the request is still running.
+func (s *IdempotencyService) Reserve(ctx context.Context, userID, key, requestHash string) (*models.IdempotencyRecord, error) {
+	pending := models.NewPendingIdempotencyRecord(userID, key, requestHash, idempotencyReservationTTL)
+	reserved, err := s.repo.ReserveIdempotencyRecord(ctx, pending)
+	if err != nil {
+		return nil, err
+	}
+	if reserved {
+		return nil, nil
+	}
+
+	record, err := s.repo.GetIdempotencyRecord(ctx, userID, key)
+	if errors.Is(err, repositories.ErrIdempotencyRecordNotFound) {
+		// The holder released the key in between, so the retry is due
+		return nil, ErrIdempotencyKeyInUse
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if record.RequestHash != requestHash {
+		return nil, ErrIdempotencyKeyReused
+	}
+	if record.IsPending() {
+		return nil, ErrIdempotencyKeyInUse
+	}
+	return record, nil
+}
+
+// Release gives up a key reserved with Reserve without storing a response, so
+// the request can be retried
+func (s *IdempotencyService) Release(ctx context.Context, userID, key string) error {
+	return s.repo.DeletePendingIdempotencyRecord(ctx, userID, key)
+}
+
+// Save remembers the response to a request reserved with Reserve for the
+// configured time to live
+func (s *IdempotencyService) Save(ctx context.Context, userID, key, requestHash string, statusCode int, contentType string, body []byte) error {
+	record := models.NewIdempotencyRecord(userID, key, requestHash, statusCode, contentType, body, s.ttl)
+	return s.repo.SaveIdempotencyRecord(ctx, record)
+}
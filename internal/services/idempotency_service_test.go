@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/starbops/gottodo/internal/repositories"
+)
+
+func TestIdempotencyService_ReserveAndSave(t *testing.T) {
+	service := NewIdempotencyService(repositories.NewMemoryIdempotencyRepository(), time.Hour)
+	ctx := context.Background()
+
+	hash := HashRequest("POST", "/todos", []byte("title=Milk"))
+
+	// A new key is reserved for the request
+	record, err := service.Reserve(ctx, "user1", "key-1", hash)
+	if err != nil {
+		t.Fatalf("Failed to reserve key: %v", err)
+	}
+	if record != nil {
+		t.Fatalf("Expected no record for a new key, got %v", record)
+	}
+
+	// The same request cannot run again while the first is in progress
+	if _, err := service.Reserve(ctx, "user1", "key-1", hash); !errors.Is(err, ErrIdempotencyKeyInUse) {
+		t.Errorf("Expected ErrIdempotencyKeyInUse, got %v", err)
+	}
+
+	if err := service.Save(ctx, "user1", "key-1", hash, 201, "text/html", []byte("<div></div>")); err != nil {
+		t.Fatalf("Failed to save response: %v", err)
+	}
+
+	// The same request gets the original response
+	record, err = service.Reserve(ctx, "user1", "key-1", hash)
+	if err != nil {
+		t.Fatalf("Failed to reserve key: %v", err)
+	}
+	if record == nil || record.StatusCode != 201 || string(record.Body) != "<div></div>" {
+		t.Errorf("Expected the original response, got %v", record)
+	}
+
+	// A different payload with the same key is rejected
+	other := HashRequest("POST", "/todos", []byte("title=Eggs"))
+	if _, err := service.Reserve(ctx, "user1", "key-1", other); !errors.Is(err, ErrIdempotencyKeyReused) {
+		t.Errorf("Expected ErrIdempotencyKeyReused, got %v", err)
+	}
+
+	// Other users can use the same key independently
+	record, err = service.Reserve(ctx, "user2", "key-1", other)
+	if err != nil || record != nil {
+		t.Errorf("Expected no record for another user, got %v, %v", record, err)
+	}
+}
+
+func TestIdempotencyService_Release(t *testing.T) {
+	service := NewIdempotencyService(repositories.NewMemoryIdempotencyRepository(), time.Hour)
+	ctx := context.Background()
+
+	hash := HashRequest("POST", "/todos", []byte("title=Milk"))
+	if _, err := service.Reserve(ctx, "user1", "key-1", hash); err != nil {
+		t.Fatalf("Failed to reserve key: %v", err)
+	}
+
+	// A released key can be reserved again, so a failed request can be retried
+	if err := service.Release(ctx, "user1", "key-1"); err != nil {
+		t.Fatalf("Failed to release key: %v", err)
+	}
+	if record, err := service.Reserve(ctx, "user1", "key-1", hash); err != nil || record != nil {
+		t.Errorf("Expected to reserve the released key, got %v, %v", record, err)
+	}
+
+	// Releasing does not discard a stored response
+	if err := service.Save(ctx, "user1", "key-1", hash, 201, "text/html", []byte("<div></div>")); err != nil {
+		t.Fatalf("Failed to save response: %v", err)
+	}
+	if err := service.Release(ctx, "user1", "key-1"); err != nil {
+		t.Fatalf("Failed to release key: %v", err)
+	}
+	if record, err := service.Reserve(ctx, "user1", "key-1", hash); err != nil || record == nil {
+		t.Errorf("Expected the stored response, got %v, %v", record, err)
+	}
+}
+
+func TestIdempotencyService_ReserveAcrossServers(t *testing.T) {
+	// Each service stands for a server, sharing the repository but not the locks
+	repo := repositories.NewMemoryIdempotencyRepository()
+	servers := []*IdempotencyService{
+		NewIdempotencyService(repo, time.Hour),
+		NewIdempotencyService(repo, time.Hour),
+	}
+	ctx := context.Background()
+	hash := HashRequest("POST", "/todos", []byte("title=Milk"))
+
+	// Of concurrent requests with one key exactly one gets to run
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	reserved, inUse := 0, 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(service *IdempotencyService) {
+			defer wg.Done()
+			record, err := service.Reserve(ctx, "user1", "key-1", hash)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			switch {
+			case err == nil && record == nil:
+				reserved++
+			case errors.Is(err, ErrIdempotencyKeyInUse):
+				inUse++
+			default:
+				t.Errorf("Unexpected result %v, %v", record, err)
+			}
+		}(servers[i%len(servers)])
+	}
+	wg.Wait()
+
+	if reserved != 1 || inUse != 9 {
+		t.Errorf("Expected one reservation and 9 requests turned away, got %d and %d", reserved, inUse)
+	}
+}
+
+func TestIdempotencyService_Lock(t *testing.T) {
+	service := NewIdempotencyService(repositories.NewMemoryIdempotencyRepository(), time.Hour)
+
+	// Concurrent holders of one key run one after another
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	active, maxActive := 0, 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock := service.Lock("user1", "key-1")
+			defer unlock()
+
+			mutex.Lock()
+			active++
+			if active > maxActive {
+				maxActive = active
+			}
+			mutex.Unlock()
+
+			time.Sleep(time.Millisecond)
+
+			mutex.Lock()
+			active--
+			mutex.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if maxActive != 1 {
+		t.Errorf("Expected one request at a time, got %d", maxActive)
+	}
+	if len(service.locks) != 0 {
+		t.Errorf("Expected released locks to be cleaned up, got %d", len(service.locks))
+	}
+}
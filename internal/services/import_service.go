@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/pkg/todoimport"
+)
+
+// ErrInvalidImport is returned for files that cannot be read as an export of
+// the given format at all
+var ErrInvalidImport = errors.New("invalid import file")
+
+// ImportOptions adjust how an export is imported
+type ImportOptions struct {
+	// DryRun reads the export and reports what would be imported without
+	// creating any todos
+	DryRun bool
+
+	// Columns maps todo fields to CSV headers, see todoimport.Options
+	Columns map[string]string
+
+	// Project files the imported todos that have no project of their own
+	Project string
+
+	// Location is the time zone of times given without one
+	Location *time.Location
+}
+
+// ImportRow is the outcome for a single todo of an export
+type ImportRow struct {
+	// Row is where the todo is in the file, see todoimport.Item
+	Row int `json:"row"`
+
+	// Todo is the todo created from the row, or that would be created in a
+	// dry run. It is nil for rows with an error.
+	Todo *models.Todo `json:"todo,omitempty"`
+
+	// Error explains why the row is skipped
+	Error string `json:"error,omitempty"`
+}
+
+// ImportResult reports what an import did, or would do in a dry run
+type ImportResult struct {
+	Format todoimport.Format `json:"format"`
+	DryRun bool              `json:"dry_run"`
+	Rows   []ImportRow       `json:"rows"`
+
+	// Valid and Invalid count the rows that can and cannot be imported
+	Valid   int `json:"valid"`
+	Invalid int `json:"invalid"`
+
+	// Imported counts the todos created, which is zero for dry runs
+	Imported int `json:"imported"`
+}
+
+// ImportService imports todos exported from other todo apps
+type ImportService struct {
+	todoService *TodoService
+}
+
+// NewImportService creates a new ImportService
+func NewImportService(todoService *TodoService) *ImportService {
+	return &ImportService{
+		todoService: todoService,
+	}
+}
+
+// Import reads an export and creates its todos for the user. Rows that
+// cannot be read are skipped and reported; the others are created together,
+// so an import either creates all of its valid todos or none of them.
+func (s *ImportService) Import(ctx context.Context, userID string, format todoimport.Format, r io.Reader, opts ImportOptions) (*ImportResult, error) {
+	if userID == "" {
+		return nil, errors.New("user ID cannot be empty")
+	}
+
+	items, err := todoimport.Parse(format, r, todoimport.Options{
+		Columns:  opts.Columns,
+		Location: opts.Location,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidImport, err)
+	}
+
+	result := &ImportResult{
+		Format: format,
+		DryRun: opts.DryRun,
+		Rows:   make([]ImportRow, 0, len(items)),
+	}
+	var todos []*models.Todo
+	now := time.Now()
+	for _, item := range items {
+		row := ImportRow{Row: item.Row}
+		err := item.Err
+		if err == nil {
+			// Rows the todo service would refuse are reported like unreadable ones
+			row.Todo = importedTodo(userID, item, opts.Project, now)
+			err = validateTodo(row.Todo)
+		}
+		if err != nil {
+			row.Todo = nil
+			row.Error = err.Error()
+			result.Invalid++
+		} else {
+			todos = append(todos, row.Todo)
+			result.Valid++
+		}
+		result.Rows = append(result.Rows, row)
+	}
+
+	if opts.DryRun || len(todos) == 0 {
+		return result, nil
+	}
+	if err := s.todoService.CreateTodos(ctx, todos); err != nil {
+		return nil, err
+	}
+	result.Imported = len(todos)
+	return result, nil
+}
+
+// importedTodo builds the todo of an item read from an export
+func importedTodo(userID string, item todoimport.Item, project string, now time.Time) *models.Todo {
+	todo := models.NewTodo(userID, truncateTitle(item.Title), item.Description)
+	todo.Completed = item.Completed
+	todo.DueAt = item.Due
+	todo.Priority = models.Priority(item.Priority)
+	todo.Project = item.Project
+	todo.Tags = item.Tags
+	todo.Recurrence = item.Recurrence
+	if todo.Project == "" {
+		todo.Project = project
+	}
+	if item.CreatedAt != nil && item.CreatedAt.Before(now) {
+		todo.CreatedAt = *item.CreatedAt
+	}
+	return todo
+}
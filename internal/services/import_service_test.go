@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
+	"github.com/starbops/gottodo/pkg/todoimport"
+)
+
+// failingCreateRepository refuses to create todos in bulk
+type failingCreateRepository struct {
+	repositories.TodoRepository
+}
+
+// CreateTodos always fails
+func (r failingCreateRepository) CreateTodos(ctx context.Context, todos []*models.Todo) error {
+	return errors.New("database unavailable")
+}
+
+// importFile is a todo.txt export with two valid tasks and an invalid one
+const importFile = `(A) 2025-02-20 Call Ann +Family @phone due:2025-03-14
+Broken due:soon
+x Water plants
+`
+
+func TestImportService_Import(t *testing.T) {
+	activityRepo := repositories.NewMemoryActivityRepository()
+	todoService := NewTodoService(repositories.NewMemoryTodoRepository(), WithActivityRepository(activityRepo))
+	service := NewImportService(todoService)
+	ctx := context.Background()
+
+	// A dry run reports every row without creating anything
+	preview, err := service.Import(ctx, "user1", todoimport.FormatTodoTxt, strings.NewReader(importFile), ImportOptions{DryRun: true, Project: "Imported"})
+	if err != nil {
+		t.Fatalf("Failed to preview import: %v", err)
+	}
+	if preview.Valid != 2 || preview.Invalid != 1 || preview.Imported != 0 || len(preview.Rows) != 3 {
+		t.Fatalf("Expected 2 valid and 1 invalid rows, got %+v", preview)
+	}
+	if preview.Rows[1].Row != 2 || preview.Rows[1].Todo != nil || !strings.Contains(preview.Rows[1].Error, "soon") {
+		t.Errorf("Expected the error of line 2, got %+v", preview.Rows[1])
+	}
+	if todos, _ := todoService.GetUserTodos(ctx, "user1"); len(todos) != 0 {
+		t.Fatalf("Expected a dry run to create no todos, got %d", len(todos))
+	}
+
+	// Otherwise the valid rows are created
+	result, err := service.Import(ctx, "user1", todoimport.FormatTodoTxt, strings.NewReader(importFile), ImportOptions{Project: "Imported"})
+	if err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+	if result.Imported != 2 {
+		t.Fatalf("Expected 2 imported todos, got %+v", result)
+	}
+	call := result.Rows[0].Todo
+	if call.UserID != "user1" || call.Title != "Call Ann" || call.Priority != models.PriorityHigh || call.Project != "Family" {
+		t.Errorf("Expected the todo of line 1, got %+v", call)
+	}
+	if want := time.Date(2025, time.February, 20, 0, 0, 0, 0, time.UTC); !call.CreatedAt.Equal(want) {
+		t.Errorf("Expected the creation date of the export, got %v", call.CreatedAt)
+	}
+	watered := result.Rows[2].Todo
+	if !watered.Completed || watered.Project != "Imported" {
+		t.Errorf("Expected a completed todo in the default project, got %+v", watered)
+	}
+
+	todos, _ := todoService.GetUserTodos(ctx, "user1")
+	if len(todos) != 2 {
+		t.Errorf("Expected 2 stored todos, got %d", len(todos))
+	}
+	page, _ := todoService.GetActivityFeed(ctx, "user1", 0, 0)
+	if len(page.Items) != 2 || page.Items[0].Action != models.ActivityCreated {
+		t.Errorf("Expected the imported todos in the activity feed, got %+v", page.Items)
+	}
+}
+
+func TestImportService_Import_InvalidTodos(t *testing.T) {
+	todoService := NewTodoService(repositories.NewMemoryTodoRepository())
+	service := NewImportService(todoService)
+	ctx := context.Background()
+
+	// Rows the todo service would refuse are reported, the others imported
+	file := `[{"title": "Call Ann", "recurrence": "FREQ=WEEKLY"}, {"title": "Pay rent", "recurrence": "FREQ=MONTHLY\r\nATTACH:x"}]`
+	result, err := service.Import(ctx, "user1", todoimport.FormatJSON, strings.NewReader(file), ImportOptions{})
+	if err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+	if result.Imported != 1 || result.Invalid != 1 {
+		t.Fatalf("Expected 1 imported and 1 invalid row, got %+v", result)
+	}
+	if row := result.Rows[1]; row.Todo != nil || row.Error != ErrInvalidRecurrence.Error() {
+		t.Errorf("Expected the recurrence of row 2 to be refused, got %+v", row)
+	}
+	if todos, _ := todoService.GetUserTodos(ctx, "user1"); len(todos) != 1 || todos[0].Title != "Call Ann" {
+		t.Errorf("Expected only the valid todo to be stored, got %+v", todos)
+	}
+}
+
+func TestImportService_Import_Errors(t *testing.T) {
+	todoRepo := repositories.NewMemoryTodoRepository()
+	service := NewImportService(NewTodoService(failingCreateRepository{todoRepo}))
+	ctx := context.Background()
+
+	_, err := service.Import(ctx, "user1", todoimport.FormatCSV, strings.NewReader("Due\n2025-03-14\n"), ImportOptions{})
+	if !errors.Is(err, ErrInvalidImport) {
+		t.Errorf("Expected ErrInvalidImport for a CSV file without titles, got %v", err)
+	}
+	_, err = service.Import(ctx, "user1", "evernote", strings.NewReader(""), ImportOptions{})
+	if !errors.Is(err, ErrInvalidImport) || !errors.Is(err, todoimport.ErrUnknownFormat) {
+		t.Errorf("Expected ErrInvalidImport for an unknown format, got %v", err)
+	}
+
+	// A failure to store the todos fails the whole import
+	if _, err := service.Import(ctx, "user1", todoimport.FormatTodoTxt, strings.NewReader(importFile), ImportOptions{}); err == nil {
+		t.Error("Expected the import to fail")
+	}
+	if todos, _ := todoRepo.GetUserTodos(ctx, "user1"); len(todos) != 0 {
+		t.Errorf("Expected no todos after a failed import, got %d", len(todos))
+	}
+}
@@ -3,21 +3,100 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/starbops/gottodo/internal/models"
 	"github.com/starbops/gottodo/internal/repositories"
 )
 
+// ErrEmptyTitle is returned when a change would leave a todo without a title
+var ErrEmptyTitle = errors.New("title cannot be empty")
+
+// ErrInvalidPriority is returned for priorities other than low, medium and high
+var ErrInvalidPriority = errors.New("priority must be low, medium or high")
+
+// ErrInvalidRecurrence is returned for recurrences that are not iCalendar
+// RRULE values
+var ErrInvalidRecurrence = errors.New("recurrence must be an iCalendar RRULE such as FREQ=WEEKLY;BYDAY=MO")
+
+// ErrInvalidTags is returned for too many tags or tags that are blank, too
+// long or contain control characters
+var ErrInvalidTags = fmt.Errorf("a todo can have up to %d tags of 1 to %d characters, without line breaks", models.MaxTags, models.MaxTagLength)
+
+// validateTodo checks the fields of a todo that is about to be stored
+func validateTodo(todo *models.Todo) error {
+	if todo.Title == "" {
+		return ErrEmptyTitle
+	}
+	if !todo.Priority.IsValid() {
+		return ErrInvalidPriority
+	}
+	if len(todo.Tags) > models.MaxTags {
+		return ErrInvalidTags
+	}
+	for _, tag := range todo.Tags {
+		if !models.IsValidTag(tag) {
+			return ErrInvalidTags
+		}
+	}
+	if !models.IsValidRecurrence(todo.Recurrence) {
+		return ErrInvalidRecurrence
+	}
+	return nil
+}
+
 // TodoService handles business logic for todo operations
 type TodoService struct {
-	todoRepo repositories.TodoRepository
+	todoRepo     repositories.TodoRepository
+	activityRepo repositories.ActivityRepository
+	unitOfWork   repositories.UnitOfWork
+	events       *EventHub
+	webhooks     *WebhookService
+}
+
+// TodoServiceOption configures optional TodoService dependencies
+type TodoServiceOption func(*TodoService)
+
+// WithActivityRepository records every todo change in the given activity log
+func WithActivityRepository(activityRepo repositories.ActivityRepository) TodoServiceOption {
+	return func(s *TodoService) {
+		s.activityRepo = activityRepo
+	}
+}
+
+// WithUnitOfWork stores every todo change atomically with its activity entry,
+// using the repositories of the unit of work, which must include the todo and
+// activity repositories of the service
+func WithUnitOfWork(unitOfWork repositories.UnitOfWork) TodoServiceOption {
+	return func(s *TodoService) {
+		s.unitOfWork = unitOfWork
+	}
+}
+
+// WithEventHub publishes every todo change to the owner's live event stream
+func WithEventHub(events *EventHub) TodoServiceOption {
+	return func(s *TodoService) {
+		s.events = events
+	}
+}
+
+// WithWebhookService sends todo changes to the owner's webhooks
+func WithWebhookService(webhooks *WebhookService) TodoServiceOption {
+	return func(s *TodoService) {
+		s.webhooks = webhooks
+	}
 }
 
 // NewTodoService creates a new TodoService
-func NewTodoService(todoRepo repositories.TodoRepository) *TodoService {
-	return &TodoService{
+func NewTodoService(todoRepo repositories.TodoRepository, opts ...TodoServiceOption) *TodoService {
+	s := &TodoService{
 		todoRepo: todoRepo,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // GetUserTodos retrieves all todos belonging to a user
@@ -30,11 +109,16 @@ func (s *TodoService) GetUserTodos(ctx context.Context, userID string) ([]*model
 
 // GetTodo retrieves a specific todo
 func (s *TodoService) GetTodo(ctx context.Context, todoID string, userID string) (*models.Todo, error) {
+	return getTodo(ctx, s.todoRepo, todoID, userID)
+}
+
+// getTodo retrieves a todo of the user from todos
+func getTodo(ctx context.Context, todos repositories.TodoRepository, todoID string, userID string) (*models.Todo, error) {
 	if todoID == "" {
 		return nil, errors.New("todo ID cannot be empty")
 	}
 
-	todo, err := s.todoRepo.GetTodo(ctx, todoID)
+	todo, err := todos.GetTodo(ctx, todoID)
 	if err != nil {
 		return nil, err
 	}
@@ -44,69 +128,243 @@ func (s *TodoService) GetTodo(ctx context.Context, todoID string, userID string)
 		return nil, errors.New("you don't have permission to access this todo")
 	}
 
+	// Trashed todos are only reachable through the trash
+	if todo.IsTrashed() {
+		return nil, repositories.ErrTodoNotFound
+	}
+
 	return todo, nil
 }
 
 // CreateTodo creates a new todo for a user
 func (s *TodoService) CreateTodo(ctx context.Context, todo *models.Todo) error {
-	if todo.Title == "" {
-		return errors.New("title cannot be empty")
+	if err := validateTodo(todo); err != nil {
+		return err
 	}
 
-	return s.todoRepo.CreateTodo(ctx, todo)
+	return s.write(ctx, func(todos repositories.TodoRepository) ([]todoChange, error) {
+		if err := todos.CreateTodo(ctx, todo); err != nil {
+			return nil, err
+		}
+		return []todoChange{{todo.UserID, todo.ID, models.ActivityCreated, nil, todo}}, nil
+	})
 }
 
-// UpdateTodo updates an existing todo
-func (s *TodoService) UpdateTodo(ctx context.Context, todoID string, title string, description string) (*models.Todo, error) {
-	// Get the current todo
-	todo, err := s.todoRepo.GetTodo(ctx, todoID)
-	if err != nil {
-		return nil, err
+// CreateTodos creates several todos at once. Either all of them are created
+// or, if any is invalid or cannot be stored, none is.
+func (s *TodoService) CreateTodos(ctx context.Context, todos []*models.Todo) error {
+	for _, todo := range todos {
+		if err := validateTodo(todo); err != nil {
+			return err
+		}
 	}
 
-	// Update fields
-	todo.Title = title
-	todo.Description = description
+	return s.write(ctx, func(repo repositories.TodoRepository) ([]todoChange, error) {
+		if err := repo.CreateTodos(ctx, todos); err != nil {
+			return nil, err
+		}
 
-	// Save changes
-	err = s.todoRepo.UpdateTodo(ctx, todo)
+		changes := make([]todoChange, len(todos))
+		for i, todo := range todos {
+			changes[i] = todoChange{todo.UserID, todo.ID, models.ActivityCreated, nil, todo}
+		}
+		return changes, nil
+	})
+}
+
+// UpdateTodo updates an existing todo. A non-zero expectedVersion must match
+// the stored version, otherwise repositories.ErrConflict is returned.
+func (s *TodoService) UpdateTodo(ctx context.Context, todoID string, userID string, title string, description string, expectedVersion int) (*models.Todo, error) {
+	var todo *models.Todo
+	err := s.write(ctx, func(todos repositories.TodoRepository) ([]todoChange, error) {
+		// Get the current todo and verify ownership
+		var err error
+		todo, err = getTodo(ctx, todos, todoID, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		if expectedVersion != 0 && todo.Version != expectedVersion {
+			return nil, repositories.ErrConflict
+		}
+		before := *todo
+
+		// Update fields
+		todo.Title = title
+		todo.Description = description
+		todo.UpdatedAt = time.Now()
+
+		// Save changes
+		if err := todos.UpdateTodo(ctx, todo); err != nil {
+			return nil, err
+		}
+		return []todoChange{{userID, todo.ID, models.ActivityUpdated, &before, todo}}, nil
+	})
 	if err != nil {
 		return nil, err
 	}
-
 	return todo, nil
 }
 
-// DeleteTodo deletes a todo
-func (s *TodoService) DeleteTodo(ctx context.Context, todoID string, userID string) error {
-	// Verify ownership first
-	todo, err := s.todoRepo.GetTodo(ctx, todoID)
+// PatchTodo applies a partial update to a todo and persists only the fields
+// whose values actually changed. It returns the updated todo together with
+// the set of changed fields, which is empty when the patch was a no-op.
+// A non-zero expectedVersion must match the stored version, otherwise
+// repositories.ErrConflict is returned.
+func (s *TodoService) PatchTodo(ctx context.Context, todoID string, userID string, patch *models.TodoPatch, expectedVersion int) (*models.Todo, []models.FieldChange, error) {
+	return s.changeTodo(ctx, todoID, userID, expectedVersion, patch.Apply)
+}
+
+// ReplaceTodo replaces everything about a todo that can be changed after
+// creation with the values of replacement, for clients that send whole
+// todos rather than patches. Like PatchTodo, it persists only the fields
+// that changed and returns them.
+func (s *TodoService) ReplaceTodo(ctx context.Context, todoID string, userID string, replacement *models.Todo, expectedVersion int) (*models.Todo, []models.FieldChange, error) {
+	return s.changeTodo(ctx, todoID, userID, expectedVersion, func(todo *models.Todo) {
+		todo.Title = replacement.Title
+		todo.Description = replacement.Description
+		todo.Completed = replacement.Completed
+		todo.DueAt = replacement.DueAt
+		todo.Priority = replacement.Priority
+		todo.Project = replacement.Project
+		todo.Tags = replacement.Tags
+		todo.Recurrence = replacement.Recurrence
+	})
+}
+
+// changeTodo applies change to a copy of a todo and persists the fields
+// whose values actually changed
+func (s *TodoService) changeTodo(ctx context.Context, todoID string, userID string, expectedVersion int, change func(*models.Todo)) (*models.Todo, []models.FieldChange, error) {
+	var result *models.Todo
+	var changes []models.FieldChange
+	err := s.write(ctx, func(todos repositories.TodoRepository) ([]todoChange, error) {
+		// Get the current todo and verify ownership
+		todo, err := getTodo(ctx, todos, todoID, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		if expectedVersion != 0 && todo.Version != expectedVersion {
+			return nil, repositories.ErrConflict
+		}
+		before := *todo
+
+		// Validate the changed copy before writing anything
+		patched := *todo
+		change(&patched)
+		if err := validateTodo(&patched); err != nil {
+			return nil, err
+		}
+
+		changes = models.DiffTodos(&before, &patched)
+		if len(changes) == 0 {
+			result = todo
+			return nil, nil
+		}
+
+		fields := make([]string, len(changes))
+		for i, change := range changes {
+			fields[i] = change.Field
+		}
+
+		patched.UpdatedAt = time.Now()
+		if err := todos.PatchTodo(ctx, &patched, fields); err != nil {
+			return nil, err
+		}
+		result = &patched
+		return []todoChange{{userID, patched.ID, patchAction(changes, &patched), &before, &patched}}, nil
+	})
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
+	return result, changes, nil
+}
 
-	if todo.UserID != userID {
-		return errors.New("you don't have permission to delete this todo")
+// patchAction picks the activity action describing a set of changes
+func patchAction(changes []models.FieldChange, todo *models.Todo) models.ActivityAction {
+	if len(changes) == 1 && changes[0].Field == models.FieldCompleted {
+		if todo.Completed {
+			return models.ActivityCompleted
+		}
+		return models.ActivityReopened
 	}
+	return models.ActivityUpdated
+}
+
+// DeleteTodo moves a todo to the trash. A non-zero expectedVersion must
+// match the stored version, otherwise repositories.ErrConflict is returned.
+func (s *TodoService) DeleteTodo(ctx context.Context, todoID string, userID string, expectedVersion int) error {
+	return s.write(ctx, func(todos repositories.TodoRepository) ([]todoChange, error) {
+		// Verify ownership first
+		todo, err := todos.GetTodo(ctx, todoID)
+		if err != nil {
+			return nil, err
+		}
+
+		if todo.UserID != userID {
+			return nil, errors.New("you don't have permission to delete this todo")
+		}
+
+		if todo.IsTrashed() {
+			return nil, repositories.ErrTodoNotFound
+		}
+		if expectedVersion != 0 && todo.Version != expectedVersion {
+			return nil, repositories.ErrConflict
+		}
+		before := *todo
 
-	return s.todoRepo.DeleteTodo(ctx, todoID)
+		if err := todos.DeleteTodo(ctx, todoID, todo.Version); err != nil {
+			return nil, err
+		}
+		return s.trashChanges(ctx, todos, userID, todoID, models.ActivityDeleted, &before), nil
+	})
 }
 
-// UpdateTodoStatus updates the completed status of a todo
-func (s *TodoService) UpdateTodoStatus(ctx context.Context, todoID string, userID string, completed bool) error {
+// UpdateTodoStatus updates the completed status of a todo. A non-zero
+// expectedVersion must match the stored version, otherwise
+// repositories.ErrConflict is returned.
+func (s *TodoService) UpdateTodoStatus(ctx context.Context, todoID string, userID string, completed bool, expectedVersion int) error {
+	return s.write(ctx, func(todos repositories.TodoRepository) ([]todoChange, error) {
+		return updateTodoStatus(ctx, todos, todoID, userID, completed, expectedVersion)
+	})
+}
+
+// updateTodoStatus updates the completed status of a todo of the user in
+// todos and returns the change
+func updateTodoStatus(ctx context.Context, todos repositories.TodoRepository, todoID string, userID string, completed bool, expectedVersion int) ([]todoChange, error) {
 	// Verify ownership first
-	todo, err := s.todoRepo.GetTodo(ctx, todoID)
+	todo, err := todos.GetTodo(ctx, todoID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if todo.UserID != userID {
-		return errors.New("you don't have permission to update this todo")
+		return nil, errors.New("you don't have permission to update this todo")
 	}
 
+	if todo.IsTrashed() {
+		return nil, repositories.ErrTodoNotFound
+	}
+	if expectedVersion != 0 && todo.Version != expectedVersion {
+		return nil, repositories.ErrConflict
+	}
+	before := *todo
+
 	// Update status
-	todo.Completed = completed
+	if completed {
+		todo.MarkComplete()
+	} else {
+		todo.MarkIncomplete()
+	}
 
 	// Save changes
-	return s.todoRepo.UpdateTodo(ctx, todo)
+	if err := todos.UpdateTodo(ctx, todo); err != nil {
+		return nil, err
+	}
+
+	action := models.ActivityReopened
+	if completed {
+		action = models.ActivityCompleted
+	}
+	return []todoChange{{userID, todoID, action, &before, todo}}, nil
 }
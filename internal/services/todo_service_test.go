@@ -2,7 +2,11 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/starbops/gottodo/internal/models"
@@ -11,7 +15,8 @@ import (
 
 // MockTodoRepository is a mock implementation of the TodoRepository interface
 type MockTodoRepository struct {
-	todos map[string]*models.Todo
+	todos         map[string]*models.Todo
+	patchedFields []string
 }
 
 // NewMockTodoRepository creates a new MockTodoRepository
@@ -25,13 +30,25 @@ func NewMockTodoRepository() repositories.TodoRepository {
 func (r *MockTodoRepository) GetUserTodos(ctx context.Context, userID string) ([]*models.Todo, error) {
 	var todos []*models.Todo
 	for _, todo := range r.todos {
-		if todo.UserID == userID {
+		if todo.UserID == userID && !todo.IsTrashed() {
 			todos = append(todos, todo)
 		}
 	}
 	return todos, nil
 }
 
+// StreamUserTodos implements the StreamUserTodos method of the TodoRepository interface
+func (r *MockTodoRepository) StreamUserTodos(ctx context.Context, userID string, fn func(todo *models.Todo) error) error {
+	for _, todo := range r.todos {
+		if todo.UserID == userID {
+			if err := fn(todo); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // GetTodo implements the GetTodo method of the TodoRepository interface
 func (r *MockTodoRepository) GetTodo(ctx context.Context, todoID string) (*models.Todo, error) {
 	todo, ok := r.todos[todoID]
@@ -46,25 +63,130 @@ func (r *MockTodoRepository) CreateTodo(ctx context.Context, todo *models.Todo)
 	if todo.ID == "" {
 		todo.ID = uuid.New().String()
 	}
+	todo.Version = 1
 	r.todos[todo.ID] = todo
 	return nil
 }
 
+// CreateTodos implements the CreateTodos method of the TodoRepository interface
+func (r *MockTodoRepository) CreateTodos(ctx context.Context, todos []*models.Todo) error {
+	for _, todo := range todos {
+		if err := r.CreateTodo(ctx, todo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // UpdateTodo implements the UpdateTodo method of the TodoRepository interface
 func (r *MockTodoRepository) UpdateTodo(ctx context.Context, todo *models.Todo) error {
 	if _, ok := r.todos[todo.ID]; !ok {
 		return repositories.ErrTodoNotFound
 	}
+	todo.Version++
 	r.todos[todo.ID] = todo
 	return nil
 }
 
+// PatchTodo implements the PatchTodo method of the TodoRepository interface
+func (r *MockTodoRepository) PatchTodo(ctx context.Context, todo *models.Todo, fields []string) error {
+	r.patchedFields = fields
+	return r.UpdateTodo(ctx, todo)
+}
+
+// UpdateTodos implements the UpdateTodos method of the TodoRepository interface
+func (r *MockTodoRepository) UpdateTodos(ctx context.Context, todoIDs []string, update repositories.BulkUpdateFunc) ([]repositories.BulkResult, error) {
+	results := make([]repositories.BulkResult, len(todoIDs))
+	for i, todoID := range todoIDs {
+		results[i].TodoID = todoID
+
+		stored, ok := r.todos[todoID]
+		if !ok {
+			results[i].Err = repositories.ErrTodoNotFound
+			continue
+		}
+
+		before := *stored
+		todo := *stored
+		if err := update(&todo); err != nil {
+			results[i].Err = err
+			continue
+		}
+		todo.Version++
+
+		r.todos[todoID] = &todo
+		results[i].Before = &before
+		results[i].Todo = &todo
+	}
+	return results, nil
+}
+
 // DeleteTodo implements the DeleteTodo method of the TodoRepository interface
-func (r *MockTodoRepository) DeleteTodo(ctx context.Context, todoID string) error {
-	if _, ok := r.todos[todoID]; !ok {
+func (r *MockTodoRepository) DeleteTodo(ctx context.Context, todoID string, version int) error {
+	todo, ok := r.todos[todoID]
+	if !ok || todo.IsTrashed() {
+		return repositories.ErrTodoNotFound
+	}
+	if todo.Version != version {
+		return repositories.ErrConflict
+	}
+	todo.MoveToTrash()
+	todo.Version++
+	return nil
+}
+
+// GetTrashedTodos implements the GetTrashedTodos method of the TodoRepository interface
+func (r *MockTodoRepository) GetTrashedTodos(ctx context.Context, userID string) ([]*models.Todo, error) {
+	var todos []*models.Todo
+	for _, todo := range r.todos {
+		if todo.UserID == userID && todo.IsTrashed() {
+			todos = append(todos, todo)
+		}
+	}
+	return todos, nil
+}
+
+// RestoreTodo implements the RestoreTodo method of the TodoRepository interface
+func (r *MockTodoRepository) RestoreTodo(ctx context.Context, todoID string) error {
+	todo, ok := r.todos[todoID]
+	if !ok || !todo.IsTrashed() {
 		return repositories.ErrTodoNotFound
 	}
-	delete(r.todos, todoID)
+	todo.Restore()
+	return nil
+}
+
+// EmptyTrash implements the EmptyTrash method of the TodoRepository interface
+func (r *MockTodoRepository) EmptyTrash(ctx context.Context, userID string) ([]*models.Todo, error) {
+	var purged []*models.Todo
+	for id, todo := range r.todos {
+		if todo.UserID == userID && todo.IsTrashed() {
+			purged = append(purged, todo)
+			delete(r.todos, id)
+		}
+	}
+	return purged, nil
+}
+
+// PurgeTrash implements the PurgeTrash method of the TodoRepository interface
+func (r *MockTodoRepository) PurgeTrash(ctx context.Context, deletedBefore time.Time) ([]*models.Todo, error) {
+	var purged []*models.Todo
+	for id, todo := range r.todos {
+		if todo.IsTrashed() && todo.DeletedAt.Before(deletedBefore) {
+			purged = append(purged, todo)
+			delete(r.todos, id)
+		}
+	}
+	return purged, nil
+}
+
+// DeleteUserTodos implements the DeleteUserTodos method of the TodoRepository interface
+func (r *MockTodoRepository) DeleteUserTodos(ctx context.Context, userID string) error {
+	for id, todo := range r.todos {
+		if todo.UserID == userID {
+			delete(r.todos, id)
+		}
+	}
 	return nil
 }
 
@@ -105,6 +227,77 @@ func TestCreateTodo(t *testing.T) {
 	}
 }
 
+func TestCreateTodo_InvalidPriority(t *testing.T) {
+	service := NewTodoService(NewMockTodoRepository())
+
+	err := service.CreateTodo(context.Background(), &models.Todo{
+		UserID:   "user123",
+		Title:    "Test Todo",
+		Priority: "urgent",
+	})
+	if !errors.Is(err, ErrInvalidPriority) {
+		t.Fatalf("Expected ErrInvalidPriority, got %v", err)
+	}
+}
+
+func TestCreateTodo_Recurrence(t *testing.T) {
+	service := NewTodoService(NewMockTodoRepository())
+
+	for _, rule := range []string{"", "FREQ=DAILY", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TU", "FREQ=MONTHLY;BYDAY=-1FR", "freq=yearly;until=20301231T000000Z"} {
+		todo := &models.Todo{UserID: "user123", Title: "Test Todo", Recurrence: rule}
+		if err := service.CreateTodo(context.Background(), todo); err != nil {
+			t.Errorf("Expected %q to be accepted, got %v", rule, err)
+		}
+	}
+
+	for _, rule := range []string{
+		"WEEKLY",
+		"BYDAY=MO",
+		"FREQ=FORTNIGHTLY",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;COUNT=",
+		"FREQ=DAILY;X-NAME=1",
+		"FREQ=DAILY;",
+		"FREQ=DAILY\r\nATTACH:https://example.com",
+		"FREQ=DAILY;UNTIL=20301231T000000Z\n",
+		"FREQ=DAILY;INTERVAL=2 3",
+		"FREQ=DAILY;BYDAY=MO\x00",
+		"FREQ=WEEKLY;BYDAY=" + strings.Repeat("MO,", models.MaxRecurrenceLength/3) + "MO",
+	} {
+		todo := &models.Todo{UserID: "user123", Title: "Test Todo", Recurrence: rule}
+		if err := service.CreateTodo(context.Background(), todo); !errors.Is(err, ErrInvalidRecurrence) {
+			t.Errorf("Expected ErrInvalidRecurrence for %q, got %v", rule, err)
+		}
+	}
+}
+
+func TestCreateTodo_Tags(t *testing.T) {
+	service := NewTodoService(NewMockTodoRepository())
+
+	tooMany := make([]string, models.MaxTags+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("tag%d", i)
+	}
+	for name, tags := range map[string][]string{
+		"too many":   tooMany,
+		"empty":      {"home", ""},
+		"blank":      {"  "},
+		"too long":   {strings.Repeat("x", models.MaxTagLength+1)},
+		"line break": {"home\nwork"},
+		"control":    {"home\x1b[31m"},
+	} {
+		todo := &models.Todo{UserID: "user123", Title: "Test Todo", Tags: tags}
+		if err := service.CreateTodo(context.Background(), todo); !errors.Is(err, ErrInvalidTags) {
+			t.Errorf("Expected ErrInvalidTags for %s tags, got %v", name, err)
+		}
+	}
+
+	todo := &models.Todo{UserID: "user123", Title: "Test Todo", Tags: []string{"home", "Wochenende", strings.Repeat("x", models.MaxTagLength)}}
+	if err := service.CreateTodo(context.Background(), todo); err != nil {
+		t.Errorf("Expected the tags to be accepted, got %v", err)
+	}
+}
+
 func TestGetUserTodos(t *testing.T) {
 	// Create a mock repository
 	repo := NewMockTodoRepository()
@@ -183,7 +376,7 @@ func TestUpdateTodoStatus(t *testing.T) {
 	}
 
 	// Update the todo status to completed
-	err = service.UpdateTodoStatus(context.Background(), todo.ID, "user1", true)
+	err = service.UpdateTodoStatus(context.Background(), todo.ID, "user1", true, 0)
 	if err != nil {
 		t.Fatalf("Failed to update todo status: %v", err)
 	}
@@ -199,3 +392,384 @@ func TestUpdateTodoStatus(t *testing.T) {
 		t.Errorf("Expected todo to be completed, but it was not")
 	}
 }
+
+func TestUpdateTodo_Timestamps(t *testing.T) {
+	service := NewTodoService(repositories.NewMemoryTodoRepository())
+	ctx := context.Background()
+
+	created := time.Now().Add(-time.Hour)
+	todo := &models.Todo{UserID: uuid.New().String(), Title: "Test Todo", CreatedAt: created, UpdatedAt: created}
+	if err := service.CreateTodo(ctx, todo); err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+
+	// Edits and status changes move UpdatedAt but keep CreatedAt
+	updated, err := service.UpdateTodo(ctx, todo.ID, todo.UserID, "Edited", "", 0)
+	if err != nil {
+		t.Fatalf("Failed to update todo: %v", err)
+	}
+	if !updated.CreatedAt.Equal(created) || !updated.UpdatedAt.After(created) {
+		t.Errorf("Expected only UpdatedAt to move on an edit, got %v and %v", updated.CreatedAt, updated.UpdatedAt)
+	}
+
+	edited := updated.UpdatedAt
+	if err := service.UpdateTodoStatus(ctx, todo.ID, todo.UserID, true, 0); err != nil {
+		t.Fatalf("Failed to update todo status: %v", err)
+	}
+	completed, _ := service.GetTodo(ctx, todo.ID, todo.UserID)
+	if !completed.CreatedAt.Equal(created) || completed.UpdatedAt.Before(edited) {
+		t.Errorf("Expected only UpdatedAt to move on completion, got %v and %v", completed.CreatedAt, completed.UpdatedAt)
+	}
+}
+
+func TestUpdateTodo_VersionMismatch(t *testing.T) {
+	// Use the memory repository so each caller works on its own copy
+	service := NewTodoService(repositories.NewMemoryTodoRepository())
+
+	// Create a todo
+	todo := &models.Todo{
+		UserID: uuid.New().String(),
+		Title:  "Test Todo",
+	}
+
+	err := service.CreateTodo(context.Background(), todo)
+	if err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+
+	// Update based on the current version
+	updated, err := service.UpdateTodo(context.Background(), todo.ID, todo.UserID, "First edit", "", 1)
+	if err != nil {
+		t.Fatalf("Failed to update todo: %v", err)
+	}
+	if updated.Version != 2 {
+		t.Errorf("Expected version 2 after update, got %d", updated.Version)
+	}
+
+	// A second update based on the old version must not overwrite the first
+	_, err = service.UpdateTodo(context.Background(), todo.ID, todo.UserID, "Stale edit", "", 1)
+	if err != repositories.ErrConflict {
+		t.Errorf("Expected ErrConflict, got %v", err)
+	}
+
+	current, err := service.GetTodo(context.Background(), todo.ID, todo.UserID)
+	if err != nil {
+		t.Fatalf("Failed to get todo: %v", err)
+	}
+	if current.Title != "First edit" {
+		t.Errorf("Expected title to be 'First edit', got '%s'", current.Title)
+	}
+}
+
+func TestPatchTodo(t *testing.T) {
+	// Create a mock repository
+	repo := NewMockTodoRepository()
+	service := NewTodoService(repo)
+
+	// Create a todo
+	todo := &models.Todo{
+		UserID:      uuid.New().String(),
+		Title:       "Test Todo",
+		Description: "Keep me",
+	}
+
+	err := service.CreateTodo(context.Background(), todo)
+	if err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+
+	// Patch only the title and completed status
+	patch, err := models.ParseTodoMergePatch([]byte(`{"title": "Patched", "completed": true}`))
+	if err != nil {
+		t.Fatalf("Failed to parse patch: %v", err)
+	}
+
+	updated, changes, err := service.PatchTodo(context.Background(), todo.ID, todo.UserID, patch, 0)
+	if err != nil {
+		t.Fatalf("Failed to patch todo: %v", err)
+	}
+
+	if updated.Title != "Patched" {
+		t.Errorf("Expected title to be 'Patched', got '%s'", updated.Title)
+	}
+	if updated.Description != "Keep me" {
+		t.Errorf("Expected description to be unchanged, got '%s'", updated.Description)
+	}
+	if !updated.Completed {
+		t.Error("Expected todo to be completed")
+	}
+
+	// Only changed fields are reported and persisted
+	if len(changes) != 2 || changes[0].Field != models.FieldTitle || changes[1].Field != models.FieldCompleted {
+		t.Errorf("Expected title and completed changes, got %v", changes)
+	}
+	fields := repo.(*MockTodoRepository).patchedFields
+	if len(fields) != 2 || fields[0] != models.FieldTitle || fields[1] != models.FieldCompleted {
+		t.Errorf("Expected title and completed to be persisted, got %v", fields)
+	}
+}
+
+func TestPatchTodo_NoChanges(t *testing.T) {
+	// Create a mock repository
+	repo := NewMockTodoRepository()
+	service := NewTodoService(repo)
+
+	// Create a todo
+	todo := &models.Todo{
+		UserID: uuid.New().String(),
+		Title:  "Test Todo",
+	}
+
+	err := service.CreateTodo(context.Background(), todo)
+	if err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+
+	// Patching a field to its current value does not write anything
+	patch, err := models.ParseTodoMergePatch([]byte(`{"title": "Test Todo", "description": null}`))
+	if err != nil {
+		t.Fatalf("Failed to parse patch: %v", err)
+	}
+
+	updated, changes, err := service.PatchTodo(context.Background(), todo.ID, todo.UserID, patch, 0)
+	if err != nil {
+		t.Fatalf("Failed to patch todo: %v", err)
+	}
+
+	if len(changes) != 0 {
+		t.Errorf("Expected no changes, got %v", changes)
+	}
+	if updated.Version != 1 {
+		t.Errorf("Expected version to stay at 1, got %d", updated.Version)
+	}
+	if repo.(*MockTodoRepository).patchedFields != nil {
+		t.Error("Expected repository not to be called")
+	}
+}
+
+func TestPatchTodo_PlanningFields(t *testing.T) {
+	// Create a mock repository
+	repo := NewMockTodoRepository()
+	service := NewTodoService(repo)
+
+	// Create a todo
+	due := time.Date(2025, time.March, 14, 9, 0, 0, 0, time.UTC)
+	todo := &models.Todo{
+		UserID:     uuid.New().String(),
+		Title:      "Test Todo",
+		DueAt:      &due,
+		Priority:   models.PriorityLow,
+		Project:    "home",
+		Tags:       []string{"errand", "weekly"},
+		Recurrence: "FREQ=WEEKLY",
+	}
+
+	err := service.CreateTodo(context.Background(), todo)
+	if err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+
+	// The due date and priority change, tags are replaced as a whole, and
+	// the project stays as it is
+	patch, err := models.ParseTodoMergePatch([]byte(`{"due_at": "2025-03-15T10:30:00Z", "priority": "high", "tags": ["errand"], "project": "home"}`))
+	if err != nil {
+		t.Fatalf("Failed to parse patch: %v", err)
+	}
+
+	updated, _, err := service.PatchTodo(context.Background(), todo.ID, todo.UserID, patch, 0)
+	if err != nil {
+		t.Fatalf("Failed to patch todo: %v", err)
+	}
+	want := time.Date(2025, time.March, 15, 10, 30, 0, 0, time.UTC)
+	if updated.DueAt == nil || !updated.DueAt.Equal(want) || updated.Priority != models.PriorityHigh {
+		t.Errorf("Expected the new due date and priority, got %v and %q", updated.DueAt, updated.Priority)
+	}
+	if len(updated.Tags) != 1 || updated.Tags[0] != "errand" || updated.Project != "home" {
+		t.Errorf("Expected the errand tag in project home, got %v in %q", updated.Tags, updated.Project)
+	}
+	fields := repo.(*MockTodoRepository).patchedFields
+	if len(fields) != 3 || fields[0] != models.FieldDueAt || fields[1] != models.FieldPriority || fields[2] != models.FieldTags {
+		t.Errorf("Expected due_at, priority and tags to be persisted, got %v", fields)
+	}
+
+	// null clears each of them
+	patch, err = models.ParseTodoMergePatch([]byte(`{"due_at": null, "priority": null, "project": null, "tags": null, "recurrence": null}`))
+	if err != nil {
+		t.Fatalf("Failed to parse patch: %v", err)
+	}
+
+	updated, changes, err := service.PatchTodo(context.Background(), todo.ID, todo.UserID, patch, 0)
+	if err != nil {
+		t.Fatalf("Failed to patch todo: %v", err)
+	}
+	if updated.DueAt != nil || updated.Priority != models.PriorityNone || updated.Project != "" || updated.Tags != nil || updated.Recurrence != "" {
+		t.Errorf("Expected the planning fields to be cleared, got %+v", updated)
+	}
+	if len(changes) != 5 {
+		t.Errorf("Expected 5 changes, got %v", changes)
+	}
+
+	// The patch's tags are not shared with the todo
+	tags := []string{"errand"}
+	if _, _, err := service.PatchTodo(context.Background(), todo.ID, todo.UserID, &models.TodoPatch{Tags: &tags}, 0); err != nil {
+		t.Fatalf("Failed to patch todo: %v", err)
+	}
+	tags[0] = "changed"
+	stored, err := service.GetTodo(context.Background(), todo.ID, todo.UserID)
+	if err != nil {
+		t.Fatalf("Failed to get todo: %v", err)
+	}
+	if len(stored.Tags) != 1 || stored.Tags[0] != "errand" {
+		t.Errorf("Expected the errand tag, got %v", stored.Tags)
+	}
+}
+
+func TestReplaceTodo(t *testing.T) {
+	// Create a mock repository
+	repo := NewMockTodoRepository()
+	service := NewTodoService(repo)
+
+	// Create a todo
+	todo := &models.Todo{
+		UserID:      uuid.New().String(),
+		Title:       "Test Todo",
+		Description: "Replaced away",
+		Priority:    models.PriorityLow,
+		Tags:        []string{"home"},
+	}
+
+	err := service.CreateTodo(context.Background(), todo)
+	if err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+
+	// Every editable field is taken from the replacement
+	due := time.Date(2025, time.March, 14, 0, 0, 0, 0, time.UTC)
+	replacement := &models.Todo{
+		Title:      "Test Todo",
+		Completed:  true,
+		DueAt:      &due,
+		Priority:   models.PriorityLow,
+		Tags:       []string{"home"},
+		Recurrence: "FREQ=WEEKLY",
+	}
+
+	updated, changes, err := service.ReplaceTodo(context.Background(), todo.ID, todo.UserID, replacement, 1)
+	if err != nil {
+		t.Fatalf("Failed to replace todo: %v", err)
+	}
+	if updated.Description != "" || !updated.Completed || updated.DueAt == nil || updated.Recurrence != "FREQ=WEEKLY" {
+		t.Errorf("Expected the replacement's fields, got %+v", updated)
+	}
+
+	fields := repo.(*MockTodoRepository).patchedFields
+	want := []string{models.FieldDescription, models.FieldCompleted, models.FieldDueAt, models.FieldRecurrence}
+	if len(changes) != len(want) || len(fields) != len(want) {
+		t.Fatalf("Expected %v to change, got %v", want, fields)
+	}
+	for i, field := range want {
+		if fields[i] != field {
+			t.Errorf("Expected %v to be persisted, got %v", want, fields)
+		}
+	}
+
+	// Stale versions and invalid replacements are rejected
+	if _, _, err := service.ReplaceTodo(context.Background(), todo.ID, todo.UserID, replacement, 1); err != repositories.ErrConflict {
+		t.Errorf("Expected ErrConflict for a stale version, got %v", err)
+	}
+	replacement.Priority = "urgent"
+	if _, _, err := service.ReplaceTodo(context.Background(), todo.ID, todo.UserID, replacement, 0); err != ErrInvalidPriority {
+		t.Errorf("Expected ErrInvalidPriority, got %v", err)
+	}
+	if _, _, err := service.ReplaceTodo(context.Background(), todo.ID, uuid.New().String(), &models.Todo{Title: "Mine now"}, 0); err == nil {
+		t.Error("Expected another user's replacement to be rejected")
+	}
+}
+
+func TestPatchTodo_Invalid(t *testing.T) {
+	// Create a mock repository
+	repo := NewMockTodoRepository()
+	service := NewTodoService(repo)
+
+	// Create a todo
+	todo := &models.Todo{
+		UserID: uuid.New().String(),
+		Title:  "Test Todo",
+	}
+
+	err := service.CreateTodo(context.Background(), todo)
+	if err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+
+	// Malformed patches are rejected while parsing
+	for _, doc := range []string{
+		`[]`,
+		`{"title": null}`,
+		`{"title": 42}`,
+		`{"completed": "yes"}`,
+		`{"user_id": "someone-else"}`,
+		`{"due_at": "tomorrow"}`,
+		`{"priority": 3}`,
+		`{"project": ["home"]}`,
+		`{"tags": "errand"}`,
+		`{"tags": [1, 2]}`,
+		`{"recurrence": true}`,
+	} {
+		if _, err := models.ParseTodoMergePatch([]byte(doc)); err == nil {
+			t.Errorf("Expected error parsing %s", doc)
+		}
+	}
+
+	// An empty title is rejected after applying the patch
+	patch, err := models.ParseTodoMergePatch([]byte(`{"title": ""}`))
+	if err != nil {
+		t.Fatalf("Failed to parse patch: %v", err)
+	}
+
+	_, _, err = service.PatchTodo(context.Background(), todo.ID, todo.UserID, patch, 0)
+	if err == nil {
+		t.Error("Expected error when patching title to empty")
+	}
+
+	// So is an unknown priority, as when creating a todo
+	patch, err = models.ParseTodoMergePatch([]byte(`{"priority": "urgent"}`))
+	if err != nil {
+		t.Fatalf("Failed to parse patch: %v", err)
+	}
+
+	_, _, err = service.PatchTodo(context.Background(), todo.ID, todo.UserID, patch, 0)
+	if err != ErrInvalidPriority {
+		t.Errorf("Expected ErrInvalidPriority, got %v", err)
+	}
+
+	// And blank tags
+	patch, err = models.ParseTodoMergePatch([]byte(`{"tags": ["home", " "]}`))
+	if err != nil {
+		t.Fatalf("Failed to parse patch: %v", err)
+	}
+
+	_, _, err = service.PatchTodo(context.Background(), todo.ID, todo.UserID, patch, 0)
+	if err != ErrInvalidTags {
+		t.Errorf("Expected ErrInvalidTags, got %v", err)
+	}
+
+	// And a recurrence that is not an RRULE
+	patch, err = models.ParseTodoMergePatch([]byte(`{"recurrence": "FREQ=DAILY\r\nATTACH:x"}`))
+	if err != nil {
+		t.Fatalf("Failed to parse patch: %v", err)
+	}
+
+	_, _, err = service.PatchTodo(context.Background(), todo.ID, todo.UserID, patch, 0)
+	if err != ErrInvalidRecurrence {
+		t.Errorf("Expected ErrInvalidRecurrence, got %v", err)
+	}
+
+	stored, err := service.GetTodo(context.Background(), todo.ID, todo.UserID)
+	if err != nil {
+		t.Fatalf("Failed to get todo: %v", err)
+	}
+	if stored.Title != "Test Todo" || stored.Priority != models.PriorityNone || len(stored.Tags) != 0 || stored.Recurrence != "" {
+		t.Errorf("Expected todo to be unchanged, got %+v", stored)
+	}
+}
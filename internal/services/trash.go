@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
+)
+
+// GetTrashedTodos retrieves the todos a user has moved to the trash
+func (s *TodoService) GetTrashedTodos(ctx context.Context, userID string) ([]*models.Todo, error) {
+	if userID == "" {
+		return nil, errors.New("user ID cannot be empty")
+	}
+	return s.todoRepo.GetTrashedTodos(ctx, userID)
+}
+
+// RestoreTodo takes a todo owned by the user back out of the trash
+func (s *TodoService) RestoreTodo(ctx context.Context, todoID string, userID string) error {
+	return s.write(ctx, func(todos repositories.TodoRepository) ([]todoChange, error) {
+		return s.restoreTodo(ctx, todos, todoID, userID)
+	})
+}
+
+// restoreTodo takes a todo of the user out of the trash in todos and
+// returns the changes
+func (s *TodoService) restoreTodo(ctx context.Context, todos repositories.TodoRepository, todoID string, userID string) ([]todoChange, error) {
+	// Verify ownership first
+	todo, err := todos.GetTodo(ctx, todoID)
+	if err != nil {
+		return nil, err
+	}
+
+	if todo.UserID != userID {
+		return nil, errors.New("you don't have permission to restore this todo")
+	}
+
+	if !todo.IsTrashed() {
+		return nil, repositories.ErrTodoNotFound
+	}
+	before := *todo
+
+	if err := todos.RestoreTodo(ctx, todoID); err != nil {
+		return nil, err
+	}
+	return s.trashChanges(ctx, todos, userID, todoID, models.ActivityRestored, &before), nil
+}
+
+// EmptyTrash permanently removes every todo in the user's trash
+func (s *TodoService) EmptyTrash(ctx context.Context, userID string) (int, error) {
+	if userID == "" {
+		return 0, errors.New("user ID cannot be empty")
+	}
+
+	count := 0
+	err := s.write(ctx, func(todos repositories.TodoRepository) ([]todoChange, error) {
+		purged, err := todos.EmptyTrash(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		count = len(purged)
+		return purgeChanges(purged, userID), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// PurgeTrash permanently removes todos that have been in the trash longer
+// than the retention period. The purge spans the trash of every user, so on
+// Postgres the service must run on the system repositories.
+func (s *TodoService) PurgeTrash(ctx context.Context, retention time.Duration) (int, error) {
+	count := 0
+	err := s.write(ctx, func(todos repositories.TodoRepository) ([]todoChange, error) {
+		purged, err := todos.PurgeTrash(ctx, time.Now().Add(-retention))
+		if err != nil {
+			return nil, err
+		}
+
+		count = len(purged)
+		return purgeChanges(purged, ""), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// RunTrashPurger purges expired trash every interval until the context is cancelled
+func (s *TodoService) RunTrashPurger(ctx context.Context, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		count, err := s.PurgeTrash(ctx, retention)
+		if err != nil {
+			log.Printf("Failed to purge trash: %v", err)
+		} else if count > 0 {
+			log.Printf("Purged %d todos from the trash", count)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// trashChanges describes a move into or out of the trash, diffing against the
+// stored todo so the logged timestamp matches the repository
+func (s *TodoService) trashChanges(ctx context.Context, todos repositories.TodoRepository, actorID, todoID string, action models.ActivityAction, before *models.Todo) []todoChange {
+	if s.activityRepo == nil && s.events == nil && s.webhooks == nil {
+		return nil
+	}
+
+	after, err := todos.GetTodo(ctx, todoID)
+	if err != nil {
+		log.Printf("Failed to load todo %s for %s activity: %v", todoID, action, err)
+		return nil
+	}
+	return []todoChange{{actorID, todoID, action, before, after}}
+}
+
+// purgeChanges describes the permanent removal of todos, performed by actorID
+// or, if it is empty, by each todo's owner
+func purgeChanges(purged []*models.Todo, actorID string) []todoChange {
+	changes := make([]todoChange, len(purged))
+	for i, todo := range purged {
+		actor := actorID
+		if actor == "" {
+			actor = todo.UserID
+		}
+		changes[i] = todoChange{actor, todo.ID, models.ActivityPurged, todo, nil}
+	}
+	return changes
+}
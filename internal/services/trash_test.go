@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
+)
+
+func TestTodoService_DeleteAndRestore(t *testing.T) {
+	service := NewTodoService(NewMockTodoRepository())
+	ctx := context.Background()
+
+	todo := &models.Todo{UserID: "user1", Title: "Test Todo"}
+	if err := service.CreateTodo(ctx, todo); err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+
+	if err := service.DeleteTodo(ctx, todo.ID, "user1", 0); err != nil {
+		t.Fatalf("Failed to delete todo: %v", err)
+	}
+
+	// The todo disappears from the normal views
+	todos, err := service.GetUserTodos(ctx, "user1")
+	if err != nil {
+		t.Fatalf("Failed to get user todos: %v", err)
+	}
+	if len(todos) != 0 {
+		t.Errorf("Expected no todos after deletion, got %d", len(todos))
+	}
+	if _, err := service.GetTodo(ctx, todo.ID, "user1"); err != repositories.ErrTodoNotFound {
+		t.Errorf("Expected ErrTodoNotFound for a trashed todo, got %v", err)
+	}
+	if err := service.UpdateTodoStatus(ctx, todo.ID, "user1", true, 0); err != repositories.ErrTodoNotFound {
+		t.Errorf("Expected ErrTodoNotFound when completing a trashed todo, got %v", err)
+	}
+
+	// Only the owner can restore it
+	if err := service.RestoreTodo(ctx, todo.ID, "user2"); err == nil {
+		t.Errorf("Expected an error when restoring another user's todo")
+	}
+	if err := service.RestoreTodo(ctx, todo.ID, "user1"); err != nil {
+		t.Fatalf("Failed to restore todo: %v", err)
+	}
+
+	todos, err = service.GetUserTodos(ctx, "user1")
+	if err != nil {
+		t.Fatalf("Failed to get user todos: %v", err)
+	}
+	if len(todos) != 1 {
+		t.Errorf("Expected the restored todo to be listed, got %d todos", len(todos))
+	}
+}
+
+func TestTodoService_EmptyTrash(t *testing.T) {
+	activityRepo := repositories.NewMemoryActivityRepository()
+	service := NewTodoService(NewMockTodoRepository(), WithActivityRepository(activityRepo))
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		todo := &models.Todo{UserID: "user1", Title: "Test Todo"}
+		if err := service.CreateTodo(ctx, todo); err != nil {
+			t.Fatalf("Failed to create todo: %v", err)
+		}
+		if i < 2 {
+			if err := service.DeleteTodo(ctx, todo.ID, "user1", 0); err != nil {
+				t.Fatalf("Failed to delete todo: %v", err)
+			}
+		}
+	}
+
+	count, err := service.EmptyTrash(ctx, "user1")
+	if err != nil {
+		t.Fatalf("Failed to empty trash: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 todos to be purged, got %d", count)
+	}
+
+	trashed, err := service.GetTrashedTodos(ctx, "user1")
+	if err != nil {
+		t.Fatalf("Failed to get trashed todos: %v", err)
+	}
+	if len(trashed) != 0 {
+		t.Errorf("Expected an empty trash, got %d todos", len(trashed))
+	}
+
+	page, err := service.GetActivityFeed(ctx, "user1", 0, 0)
+	if err != nil {
+		t.Fatalf("Failed to get activity feed: %v", err)
+	}
+	if page.Items[0].Action != models.ActivityPurged {
+		t.Errorf("Expected newest activity to be a purge, got %s", page.Items[0].Action)
+	}
+}
+
+func TestTodoService_PurgeTrash(t *testing.T) {
+	service := NewTodoService(NewMockTodoRepository())
+	ctx := context.Background()
+
+	todo := &models.Todo{UserID: "user1", Title: "Test Todo"}
+	if err := service.CreateTodo(ctx, todo); err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+	if err := service.DeleteTodo(ctx, todo.ID, "user1", 0); err != nil {
+		t.Fatalf("Failed to delete todo: %v", err)
+	}
+
+	// Recently trashed todos are kept
+	count, err := service.PurgeTrash(ctx, time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to purge trash: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected no todos to be purged, got %d", count)
+	}
+
+	// A zero retention purges everything already in the trash
+	count, err = service.PurgeTrash(ctx, 0)
+	if err != nil {
+		t.Fatalf("Failed to purge trash: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 todo to be purged, got %d", count)
+	}
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
+)
+
+// DefaultUndoWindow is how long a destructive action can be undone when no window is configured
+const DefaultUndoWindow = 15 * time.Second
+
+// ErrUndoExpired is returned when an undo token is used after its window has passed
+var ErrUndoExpired = errors.New("the undo window has expired")
+
+// UndoService performs destructive todo actions that can be reverted for a
+// short time afterwards. The prior state is kept server-side so undo still
+// works after the page is reloaded.
+type UndoService struct {
+	todoService *TodoService
+	undoRepo    repositories.UndoRepository
+	window      time.Duration
+}
+
+// NewUndoService creates a new UndoService
+func NewUndoService(todoService *TodoService, undoRepo repositories.UndoRepository, window time.Duration) *UndoService {
+	if window <= 0 {
+		window = DefaultUndoWindow
+	}
+
+	return &UndoService{
+		todoService: todoService,
+		undoRepo:    undoRepo,
+		window:      window,
+	}
+}
+
+// DeleteTodo moves a todo to the trash and returns a token that restores it.
+// A non-zero expectedVersion must match the stored version, otherwise
+// repositories.ErrConflict is returned.
+func (s *UndoService) DeleteTodo(ctx context.Context, todoID string, userID string, expectedVersion int) (*models.UndoToken, error) {
+	// Capture the prior state, verifying ownership
+	todo, err := s.todoService.GetTodo(ctx, todoID, userID)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := *todo
+
+	if err := s.todoService.DeleteTodo(ctx, todoID, userID, expectedVersion); err != nil {
+		return nil, err
+	}
+
+	return s.issue(ctx, userID, models.ActivityDeleted, &snapshot)
+}
+
+// UpdateTodoStatus changes the completed status of a todo and returns a token
+// that reverts it. A non-zero expectedVersion must match the stored version,
+// otherwise repositories.ErrConflict is returned.
+func (s *UndoService) UpdateTodoStatus(ctx context.Context, todoID string, userID string, completed bool, expectedVersion int) (*models.UndoToken, error) {
+	// Capture the prior state, verifying ownership
+	todo, err := s.todoService.GetTodo(ctx, todoID, userID)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := *todo
+
+	if err := s.todoService.UpdateTodoStatus(ctx, todoID, userID, completed, expectedVersion); err != nil {
+		return nil, err
+	}
+
+	action := models.ActivityReopened
+	if completed {
+		action = models.ActivityCompleted
+	}
+	return s.issue(ctx, userID, action, &snapshot)
+}
+
+// Undo reverts the action captured by the token and returns the restored
+// todo. The token is used up together with the revert, so it stays usable if
+// reverting fails, and is left alone when it belongs to someone else or has
+// expired.
+func (s *UndoService) Undo(ctx context.Context, token string, userID string) (*models.Todo, error) {
+	undo, err := s.undoRepo.GetUndoToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if undo.UserID != userID {
+		return nil, errors.New("you don't have permission to undo this action")
+	}
+
+	if undo.IsExpired() {
+		return nil, ErrUndoExpired
+	}
+
+	err = s.todoService.writeRepos(ctx, func(repos *repositories.Repositories) ([]todoChange, error) {
+		undoRepo := repos.Undo
+		if undoRepo == nil {
+			undoRepo = s.undoRepo
+		}
+		// Only one request gets to consume the token, and only while it is
+		// still unexpired
+		if _, err := undoRepo.ConsumeUndoToken(ctx, token, userID); err != nil {
+			return nil, err
+		}
+
+		switch undo.Action {
+		case models.ActivityDeleted:
+			return s.todoService.restoreTodo(ctx, repos.Todos, undo.TodoID, userID)
+		case models.ActivityCompleted, models.ActivityReopened:
+			return updateTodoStatus(ctx, repos.Todos, undo.TodoID, userID, undo.Snapshot.Completed, 0)
+		default:
+			return nil, fmt.Errorf("cannot undo %s actions", undo.Action)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.todoService.GetTodo(ctx, undo.TodoID, userID)
+}
+
+// GetPendingUndo retrieves the user's most recent action that can still be undone
+func (s *UndoService) GetPendingUndo(ctx context.Context, userID string) (*models.UndoToken, error) {
+	token, err := s.undoRepo.GetLatestUndoToken(ctx, userID)
+	if errors.Is(err, repositories.ErrUndoTokenNotFound) {
+		return nil, nil
+	}
+	return token, err
+}
+
+// issue stores a new undo token for an action that has just been performed
+func (s *UndoService) issue(ctx context.Context, userID string, action models.ActivityAction, snapshot *models.Todo) (*models.UndoToken, error) {
+	token := models.NewUndoToken(userID, action, snapshot, s.window)
+	if err := s.undoRepo.SaveUndoToken(ctx, token); err != nil {
+		return nil, fmt.Errorf("failed to save undo token: %w", err)
+	}
+	return token, nil
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
+)
+
+func TestUndoService_UndoDelete(t *testing.T) {
+	todoService := NewTodoService(NewMockTodoRepository())
+	undoService := NewUndoService(todoService, repositories.NewMemoryUndoRepository(), time.Minute)
+	ctx := context.Background()
+
+	todo := &models.Todo{UserID: "user1", Title: "Test Todo"}
+	if err := todoService.CreateTodo(ctx, todo); err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+
+	undo, err := undoService.DeleteTodo(ctx, todo.ID, "user1", 0)
+	if err != nil {
+		t.Fatalf("Failed to delete todo: %v", err)
+	}
+
+	// The pending undo survives a page reload
+	pending, err := undoService.GetPendingUndo(ctx, "user1")
+	if err != nil {
+		t.Fatalf("Failed to get pending undo: %v", err)
+	}
+	if pending == nil || pending.Token != undo.Token {
+		t.Fatalf("Expected the delete to be pending undo")
+	}
+
+	restored, err := undoService.Undo(ctx, undo.Token, "user1")
+	if err != nil {
+		t.Fatalf("Failed to undo delete: %v", err)
+	}
+	if restored.IsTrashed() {
+		t.Errorf("Expected the todo to be restored from the trash")
+	}
+
+	// Tokens are single use
+	if _, err := undoService.Undo(ctx, undo.Token, "user1"); err != repositories.ErrUndoTokenNotFound {
+		t.Errorf("Expected ErrUndoTokenNotFound when reusing a token, got %v", err)
+	}
+}
+
+func TestUndoService_UndoStatusChange(t *testing.T) {
+	todoService := NewTodoService(NewMockTodoRepository())
+	undoService := NewUndoService(todoService, repositories.NewMemoryUndoRepository(), time.Minute)
+	ctx := context.Background()
+
+	todo := &models.Todo{UserID: "user1", Title: "Test Todo"}
+	if err := todoService.CreateTodo(ctx, todo); err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+
+	undo, err := undoService.UpdateTodoStatus(ctx, todo.ID, "user1", true, 0)
+	if err != nil {
+		t.Fatalf("Failed to complete todo: %v", err)
+	}
+	if undo.Action != models.ActivityCompleted {
+		t.Errorf("Expected a completed undo token, got %s", undo.Action)
+	}
+
+	// Another user cannot use the token
+	if _, err := undoService.Undo(ctx, undo.Token, "user2"); err == nil {
+		t.Errorf("Expected an error when undoing another user's action")
+	}
+	if pending, err := undoService.GetPendingUndo(ctx, "user1"); err != nil || pending == nil || pending.Token != undo.Token {
+		t.Errorf("Expected the token to stay usable by its owner, got %+v (%v)", pending, err)
+	}
+
+	undo, err = undoService.UpdateTodoStatus(ctx, todo.ID, "user1", true, 0)
+	if err != nil {
+		t.Fatalf("Failed to complete todo: %v", err)
+	}
+	restored, err := undoService.Undo(ctx, undo.Token, "user1")
+	if err != nil {
+		t.Fatalf("Failed to undo completion: %v", err)
+	}
+
+	// The snapshot was taken after the first completion, so the todo stays completed
+	if !restored.Completed {
+		t.Errorf("Expected the todo to return to its prior completed state")
+	}
+}
+
+func TestUndoService_ExpectedVersion(t *testing.T) {
+	todoService := NewTodoService(NewMockTodoRepository())
+	undoService := NewUndoService(todoService, repositories.NewMemoryUndoRepository(), time.Minute)
+	ctx := context.Background()
+
+	todo := &models.Todo{UserID: "user1", Title: "Test Todo"}
+	if err := todoService.CreateTodo(ctx, todo); err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+
+	// A stale version changes nothing and leaves nothing to undo
+	if _, err := undoService.UpdateTodoStatus(ctx, todo.ID, "user1", true, 2); err != repositories.ErrConflict {
+		t.Errorf("Expected ErrConflict completing a stale version, got %v", err)
+	}
+	if _, err := undoService.DeleteTodo(ctx, todo.ID, "user1", 2); err != repositories.ErrConflict {
+		t.Errorf("Expected ErrConflict deleting a stale version, got %v", err)
+	}
+	stored, err := todoService.GetTodo(ctx, todo.ID, "user1")
+	if err != nil {
+		t.Fatalf("Failed to get todo: %v", err)
+	}
+	if stored.Completed || stored.Version != 1 {
+		t.Errorf("Expected the todo to be unchanged, got %+v", stored)
+	}
+	if pending, err := undoService.GetPendingUndo(ctx, "user1"); err != nil || pending != nil {
+		t.Errorf("Expected nothing to undo, got %+v (%v)", pending, err)
+	}
+
+	// The current version goes through
+	if _, err := undoService.UpdateTodoStatus(ctx, todo.ID, "user1", true, 1); err != nil {
+		t.Fatalf("Failed to complete todo: %v", err)
+	}
+	if _, err := undoService.DeleteTodo(ctx, todo.ID, "user1", 2); err != nil {
+		t.Fatalf("Failed to delete todo: %v", err)
+	}
+}
+
+func TestUndoService_Expired(t *testing.T) {
+	todoService := NewTodoService(NewMockTodoRepository())
+	undoRepo := repositories.NewMemoryUndoRepository()
+	undoService := NewUndoService(todoService, undoRepo, time.Minute)
+	ctx := context.Background()
+
+	todo := &models.Todo{UserID: "user1", Title: "Test Todo"}
+	if err := todoService.CreateTodo(ctx, todo); err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+
+	// Store a token whose window has already passed
+	expired := models.NewUndoToken("user1", models.ActivityCompleted, todo, -time.Second)
+	if err := undoRepo.SaveUndoToken(ctx, expired); err != nil {
+		t.Fatalf("Failed to save undo token: %v", err)
+	}
+
+	if _, err := undoService.Undo(ctx, expired.Token, "user1"); err != ErrUndoExpired {
+		t.Errorf("Expected ErrUndoExpired, got %v", err)
+	}
+
+	pending, err := undoService.GetPendingUndo(ctx, "user1")
+	if err != nil {
+		t.Fatalf("Failed to get pending undo: %v", err)
+	}
+	if pending != nil {
+		t.Errorf("Expected no pending undo, got %s", pending.Token)
+	}
+}
+
+func TestUndoService_UnitOfWork(t *testing.T) {
+	repos := repositories.NewMemoryRepositories()
+	unitOfWork := &failingActivityUnitOfWork{UnitOfWork: repos.UnitOfWork}
+	todoService := NewTodoService(repos.Todos,
+		WithActivityRepository(repos.Activity),
+		WithUnitOfWork(unitOfWork),
+	)
+	undoService := NewUndoService(todoService, repos.Undo, time.Minute)
+	ctx := context.Background()
+
+	todo := &models.Todo{UserID: "user1", Title: "Test Todo"}
+	if err := todoService.CreateTodo(ctx, todo); err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+	undo, err := undoService.DeleteTodo(ctx, todo.ID, "user1", 0)
+	if err != nil {
+		t.Fatalf("Failed to delete todo: %v", err)
+	}
+
+	// A failed restore keeps the token
+	unitOfWork.fail = true
+	if _, err := undoService.Undo(ctx, undo.Token, "user1"); err == nil {
+		t.Fatal("Expected undoing to fail with the activity log")
+	}
+	if _, err := repos.Undo.GetUndoToken(ctx, undo.Token); err != nil {
+		t.Errorf("Expected the token to survive the failed restore, got %v", err)
+	}
+
+	unitOfWork.fail = false
+	restored, err := undoService.Undo(ctx, undo.Token, "user1")
+	if err != nil {
+		t.Fatalf("Failed to undo delete: %v", err)
+	}
+	if restored.IsTrashed() {
+		t.Errorf("Expected the todo to be restored from the trash")
+	}
+	if _, err := repos.Undo.GetUndoToken(ctx, undo.Token); err != repositories.ErrUndoTokenNotFound {
+		t.Errorf("Expected the token to be used up, got %v", err)
+	}
+}
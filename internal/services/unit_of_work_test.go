@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
+)
+
+// failingActivityRepository fails to append to the activity log
+type failingActivityRepository struct {
+	repositories.ActivityRepository
+}
+
+func (r failingActivityRepository) AppendActivity(ctx context.Context, activity *models.Activity) error {
+	return errors.New("activity log unavailable")
+}
+
+// failingActivityUnitOfWork runs units of work whose activity log fails while fail is set
+type failingActivityUnitOfWork struct {
+	repositories.UnitOfWork
+	fail bool
+}
+
+func (u *failingActivityUnitOfWork) WithTx(ctx context.Context, fn func(repos *repositories.Repositories) error) error {
+	return u.UnitOfWork.WithTx(ctx, func(repos *repositories.Repositories) error {
+		if !u.fail {
+			return fn(repos)
+		}
+		failing := *repos
+		failing.Activity = failingActivityRepository{repos.Activity}
+		return fn(&failing)
+	})
+}
+
+func TestTodoService_UnitOfWork(t *testing.T) {
+	repos := repositories.NewMemoryRepositories()
+	unitOfWork := &failingActivityUnitOfWork{UnitOfWork: repos.UnitOfWork}
+	hub := NewEventHub()
+	service := NewTodoService(repos.Todos,
+		WithActivityRepository(repos.Activity),
+		WithUnitOfWork(unitOfWork),
+		WithEventHub(hub),
+	)
+	ctx := context.Background()
+	userID := uuid.New().String()
+	sub := hub.Subscribe(userID)
+	defer sub.Close()
+
+	// A change is stored together with its activity entry and then announced
+	todo := models.NewTodo(userID, "Call Ann", "")
+	if err := service.CreateTodo(ctx, todo); err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+	history, err := repos.Activity.GetTodoActivity(ctx, todo.ID)
+	if err != nil || len(history) != 1 || history[0].Action != models.ActivityCreated {
+		t.Errorf("Expected the creation to be recorded, got %v (%v)", history, err)
+	}
+	if event := receive(t, sub); event.Type != TodoEventCreated || event.TodoID != todo.ID {
+		t.Errorf("Expected a created event for %s, got %+v", todo.ID, event)
+	}
+
+	// If the activity entry cannot be stored, neither is the change
+	unitOfWork.fail = true
+	failed := models.NewTodo(userID, "Write Bob", "")
+	if err := service.CreateTodo(ctx, failed); err == nil {
+		t.Error("Expected creating a todo to fail with the activity log")
+	}
+	if _, err := repos.Todos.GetTodo(ctx, failed.ID); !errors.Is(err, repositories.ErrTodoNotFound) {
+		t.Errorf("Expected the todo not to be created, got %v", err)
+	}
+	if _, err := service.UpdateTodo(ctx, todo.ID, userID, "Call Bob", "", 0); err == nil {
+		t.Error("Expected updating a todo to fail with the activity log")
+	}
+	if err := service.DeleteTodo(ctx, todo.ID, userID, 0); err == nil {
+		t.Error("Expected trashing a todo to fail with the activity log")
+	}
+	results, err := service.BulkUpdate(ctx, userID, []string{todo.ID}, BulkComplete, "")
+	if err == nil {
+		t.Errorf("Expected the bulk update to fail with the activity log, got %+v", results)
+	}
+
+	stored, err := repos.Todos.GetTodo(ctx, todo.ID)
+	if err != nil {
+		t.Fatalf("Failed to get todo: %v", err)
+	}
+	if stored.Title != "Call Ann" || stored.IsTrashed() || stored.Completed || stored.Version != todo.Version {
+		t.Errorf("Expected the todo to be unchanged, got %+v", stored)
+	}
+	history, _ = repos.Activity.GetTodoActivity(ctx, todo.ID)
+	if len(history) != 1 {
+		t.Errorf("Expected no further activity, got %d entries", len(history))
+	}
+
+	// Nothing is announced for changes that were rolled back
+	select {
+	case event := <-sub.Events():
+		t.Errorf("Expected no event for a failed change, got %+v", event)
+	default:
+	}
+}
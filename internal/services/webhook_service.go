@@ -0,0 +1,592 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
+)
+
+const (
+	// DefaultWebhookMaxAttempts is how often a delivery is attempted before
+	// it is given up as dead
+	DefaultWebhookMaxAttempts = 8
+
+	// DefaultWebhookBackoff is the delay before the first retry of a failed
+	// delivery; it doubles with every further failure
+	DefaultWebhookBackoff = 30 * time.Second
+
+	// DefaultWebhookTimeout is how long an endpoint may take to respond
+	DefaultWebhookTimeout = 10 * time.Second
+
+	// DefaultWebhookPollInterval is how often the worker looks for due retries
+	DefaultWebhookPollInterval = 5 * time.Second
+
+	// DeliveryLogSize is how many recent deliveries the delivery log shows
+	DeliveryLogSize = 20
+
+	// maxWebhookBackoff caps the delay between retries
+	maxWebhookBackoff = time.Hour
+
+	// webhookBatchSize is how many due deliveries a worker claims at once
+	webhookBatchSize = 20
+
+	// maxWebhookDrainLength caps how much of a response is read before the
+	// connection is reused
+	maxWebhookDrainLength = 64 << 10
+)
+
+// Headers sent with every webhook delivery
+const (
+	WebhookEventHeader     = "X-Gottodo-Event"
+	WebhookDeliveryHeader  = "X-Gottodo-Delivery"
+	WebhookTimestampHeader = "X-Gottodo-Timestamp"
+	WebhookSignatureHeader = "X-Gottodo-Signature"
+)
+
+// Errors returned for invalid webhook registrations
+var (
+	ErrInvalidWebhookURL   = errors.New("webhook URL must be an absolute http or https URL")
+	ErrInvalidWebhookEvent = errors.New("webhook events must be one or more of todo.created, todo.completed and todo.deleted")
+
+	// ErrForbiddenWebhookAddress is returned for endpoints on loopback,
+	// private, shared, link-local, multicast, reserved or unspecified
+	// addresses, which would let users reach into the server's own network
+	ErrForbiddenWebhookAddress = errors.New("webhook URL must not point to a private or local address")
+)
+
+// forbiddenWebhookPrefixes are the ranges off the public internet that the
+// netip predicates do not cover
+var forbiddenWebhookPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // this network
+	netip.MustParsePrefix("100.64.0.0/10"),  // shared address space of carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),   // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"),  // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),    // reserved, and the broadcast address
+	netip.MustParsePrefix("64:ff9b:1::/48"), // local-use NAT64
+}
+
+// nat64Prefix is the well-known prefix of NAT64, whose addresses reach the
+// IPv4 address in their last 32 bits
+var nat64Prefix = netip.MustParsePrefix("64:ff9b::/96")
+
+// WebhookPayload is the JSON body sent to webhook endpoints. The ID is shared
+// by the deliveries of an event to several webhooks.
+type WebhookPayload struct {
+	ID        string              `json:"id"`
+	Event     models.WebhookEvent `json:"event"`
+	CreatedAt time.Time           `json:"created_at"`
+	Todo      *models.Todo        `json:"todo,omitempty"`
+}
+
+// SignWebhookPayload computes the signature header value of a payload sent
+// at the given Unix timestamp. Receivers recompute it over the timestamp
+// header, a dot and the raw body to verify a delivery.
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookService handles business logic for webhooks and delivers their
+// events in the background
+type WebhookService struct {
+	repo repositories.WebhookRepository
+
+	// workerRepo is the repository the worker delivers through, which sees
+	// the deliveries of all users
+	workerRepo repositories.WebhookRepository
+
+	client      *http.Client
+	maxAttempts int
+	backoff     time.Duration
+
+	// allowLoopback lets endpoints on loopback addresses receive deliveries
+	allowLoopback bool
+
+	// wake nudges the worker when new deliveries are enqueued
+	wake chan struct{}
+
+	// now returns the current time; replaced in tests
+	now func() time.Time
+}
+
+// WebhookServiceOption configures a WebhookService
+type WebhookServiceOption func(*WebhookService)
+
+// WithWebhookTimeout sets how long an endpoint may take to respond
+func WithWebhookTimeout(timeout time.Duration) WebhookServiceOption {
+	return func(s *WebhookService) {
+		if timeout > 0 {
+			s.client.Timeout = timeout
+		}
+	}
+}
+
+// WithWebhookMaxAttempts sets how often a delivery is attempted before it is
+// given up as dead
+func WithWebhookMaxAttempts(attempts int) WebhookServiceOption {
+	return func(s *WebhookService) {
+		if attempts > 0 {
+			s.maxAttempts = attempts
+		}
+	}
+}
+
+// WithWebhookBackoff sets the delay before the first retry of a failed delivery
+func WithWebhookBackoff(backoff time.Duration) WebhookServiceOption {
+	return func(s *WebhookService) {
+		if backoff > 0 {
+			s.backoff = backoff
+		}
+	}
+}
+
+// WithWebhookLoopback lets endpoints on loopback addresses receive
+// deliveries, for local receivers in tests. Private and link-local addresses
+// stay forbidden.
+func WithWebhookLoopback() WebhookServiceOption {
+	return func(s *WebhookService) {
+		s.allowLoopback = true
+	}
+}
+
+// WithWebhookWorkerRepository sets the repository the worker claims and
+// records the deliveries of all users through, such as the system
+// repository, which bypasses row-level security. It defaults to the
+// repository of the service.
+func WithWebhookWorkerRepository(repo repositories.WebhookRepository) WebhookServiceOption {
+	return func(s *WebhookService) {
+		s.workerRepo = repo
+	}
+}
+
+// NewWebhookService creates a new WebhookService
+func NewWebhookService(repo repositories.WebhookRepository, opts ...WebhookServiceOption) *WebhookService {
+	s := &WebhookService{
+		repo: repo,
+		client: &http.Client{
+			Timeout: DefaultWebhookTimeout,
+			// Redirects are reported as failures rather than followed
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		maxAttempts: DefaultWebhookMaxAttempts,
+		backoff:     DefaultWebhookBackoff,
+		wake:        make(chan struct{}, 1),
+		now:         time.Now,
+	}
+	s.client.Transport = s.transport()
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.workerRepo == nil {
+		s.workerRepo = repo
+	}
+	return s
+}
+
+// transport returns the transport of deliveries, which only connects to
+// allowed addresses
+func (s *WebhookService) transport() http.RoundTripper {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   s.checkAddress,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would connect to the endpoint unchecked
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return transport
+}
+
+// checkAddress rejects connections to forbidden addresses. It runs once the
+// endpoint's name is resolved, so the name cannot be rebound to such an
+// address after the webhook was registered.
+func (s *WebhookService) checkAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !s.allowedAddr(ip) {
+		return fmt.Errorf("%w: %s", ErrForbiddenWebhookAddress, ip)
+	}
+	return nil
+}
+
+// allowedAddr reports whether deliveries may be sent to an address
+func (s *WebhookService) allowedAddr(ip netip.Addr) bool {
+	// IPv4 addresses embedded in IPv6 ones are checked as IPv4
+	ip = ip.Unmap()
+	if nat64Prefix.Contains(ip) {
+		embedded := ip.As16()
+		ip = netip.AddrFrom4([4]byte(embedded[12:]))
+	}
+
+	if ip.IsLoopback() {
+		return s.allowLoopback
+	}
+	if ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	for _, prefix := range forbiddenWebhookPrefixes {
+		if prefix.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// allowedHost reports whether the host of an endpoint may be registered.
+// Names are checked again, once resolved, for every delivery.
+func (s *WebhookService) allowedHost(host string) bool {
+	if ip, err := netip.ParseAddr(host); err == nil {
+		return s.allowedAddr(ip)
+	}
+	name := strings.TrimSuffix(strings.ToLower(host), ".")
+	if name == "localhost" || strings.HasSuffix(name, ".localhost") {
+		return s.allowLoopback
+	}
+	return true
+}
+
+// CreateWebhook registers an endpoint for the given events of a user's todos
+func (s *WebhookService) CreateWebhook(ctx context.Context, userID, rawURL string, events []models.WebhookEvent) (*models.Webhook, error) {
+	if userID == "" {
+		return nil, errors.New("user ID cannot be empty")
+	}
+
+	endpoint, err := url.Parse(rawURL)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return nil, ErrInvalidWebhookURL
+	}
+	if !s.allowedHost(endpoint.Hostname()) {
+		return nil, ErrForbiddenWebhookAddress
+	}
+
+	if len(events) == 0 {
+		return nil, ErrInvalidWebhookEvent
+	}
+	var subscribed []models.WebhookEvent
+	seen := make(map[models.WebhookEvent]bool)
+	for _, event := range events {
+		if !event.IsValid() {
+			return nil, ErrInvalidWebhookEvent
+		}
+		if !seen[event] {
+			seen[event] = true
+			subscribed = append(subscribed, event)
+		}
+	}
+
+	webhook := models.NewWebhook(userID, endpoint.String(), subscribed)
+	if err := s.repo.CreateWebhook(ctx, webhook); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// GetUserWebhooks retrieves the webhooks of a user
+func (s *WebhookService) GetUserWebhooks(ctx context.Context, userID string) ([]*models.Webhook, error) {
+	if userID == "" {
+		return nil, errors.New("user ID cannot be empty")
+	}
+	return s.repo.GetUserWebhooks(ctx, userID)
+}
+
+// GetWebhook retrieves a webhook of the user. Webhooks of other users are
+// reported as not found.
+func (s *WebhookService) GetWebhook(ctx context.Context, webhookID, userID string) (*models.Webhook, error) {
+	webhook, err := s.repo.GetWebhook(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if webhook.UserID != userID {
+		return nil, repositories.ErrWebhookNotFound
+	}
+	return webhook, nil
+}
+
+// DeleteWebhook removes a webhook of the user together with its deliveries
+func (s *WebhookService) DeleteWebhook(ctx context.Context, webhookID, userID string) error {
+	if _, err := s.GetWebhook(ctx, webhookID, userID); err != nil {
+		return err
+	}
+	return s.repo.DeleteWebhook(ctx, webhookID)
+}
+
+// GetDeliveries retrieves the most recent deliveries of a webhook of the user
+func (s *WebhookService) GetDeliveries(ctx context.Context, webhookID, userID string) ([]*models.WebhookDelivery, error) {
+	if _, err := s.GetWebhook(ctx, webhookID, userID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetWebhookDeliveries(ctx, webhookID, DeliveryLogSize)
+}
+
+// SendTestEvent sends a test event to a webhook of the user right away and
+// returns the delivery. A failed test is retried like any other delivery.
+func (s *WebhookService) SendTestEvent(ctx context.Context, webhookID, userID string) (*models.WebhookDelivery, error) {
+	webhook, err := s.GetWebhook(ctx, webhookID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.newDelivery(webhook, uuid.New().String(), models.WebhookTest, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep the worker away while the delivery is attempted here
+	delivery.NextAttemptAt = s.now().Add(s.lease())
+	if err := s.repo.CreateDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+
+	s.attempt(ctx, webhook, delivery)
+	if err := s.repo.UpdateDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// Enqueue schedules the delivery of an event to every webhook of the user
+// subscribed to it. The todo change has already been persisted, so failures
+// are logged rather than returned.
+func (s *WebhookService) Enqueue(ctx context.Context, userID string, event models.WebhookEvent, todo *models.Todo) {
+	// The deliveries belong to the user, also when the change is made by a
+	// background job
+	ctx = repositories.WithUserID(ctx, userID)
+
+	webhooks, err := s.repo.GetUserWebhooks(ctx, userID)
+	if err != nil {
+		log.Printf("Failed to load webhooks of user %s for %s: %v", userID, event, err)
+		return
+	}
+
+	eventID := uuid.New().String()
+	enqueued := false
+	for _, webhook := range webhooks {
+		if !webhook.Subscribes(event) {
+			continue
+		}
+
+		delivery, err := s.newDelivery(webhook, eventID, event, todo)
+		if err == nil {
+			err = s.repo.CreateDelivery(ctx, delivery)
+		}
+		if err != nil {
+			log.Printf("Failed to enqueue %s for webhook %s: %v", event, webhook.ID, err)
+			continue
+		}
+		enqueued = true
+	}
+
+	if enqueued {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// RunWorker delivers due webhook events until the context is cancelled. It
+// checks for due deliveries every interval and right after new ones are
+// enqueued, as long as they are enqueued through this same service; those
+// enqueued elsewhere wait for the next check.
+func (s *WebhookService) RunWorker(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultWebhookPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.ProcessDueDeliveries(ctx); err != nil {
+			log.Printf("Failed to process webhook deliveries: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// ProcessDueDeliveries attempts every delivery that is due and returns how
+// many were attempted
+func (s *WebhookService) ProcessDueDeliveries(ctx context.Context) (int, error) {
+	processed := 0
+	for {
+		deliveries, err := s.workerRepo.ClaimDueDeliveries(ctx, s.now(), s.lease(), webhookBatchSize)
+		if err != nil {
+			return processed, err
+		}
+
+		var wg sync.WaitGroup
+		for _, delivery := range deliveries {
+			wg.Add(1)
+			go func(delivery *models.WebhookDelivery) {
+				defer wg.Done()
+				s.process(ctx, delivery)
+			}(delivery)
+		}
+		wg.Wait()
+
+		processed += len(deliveries)
+		if len(deliveries) < webhookBatchSize || ctx.Err() != nil {
+			return processed, nil
+		}
+	}
+}
+
+// process attempts a claimed delivery and stores the outcome
+func (s *WebhookService) process(ctx context.Context, delivery *models.WebhookDelivery) {
+	webhook, err := s.workerRepo.GetWebhook(ctx, delivery.WebhookID)
+	if err != nil {
+		// Deliveries of deleted webhooks are removed along with them
+		if !errors.Is(err, repositories.ErrWebhookNotFound) {
+			log.Printf("Failed to load webhook %s for delivery %s: %v", delivery.WebhookID, delivery.ID, err)
+		}
+		return
+	}
+
+	s.attempt(ctx, webhook, delivery)
+	if err := s.workerRepo.UpdateDelivery(ctx, delivery); err != nil {
+		log.Printf("Failed to record attempt of webhook delivery %s: %v", delivery.ID, err)
+	}
+}
+
+// attempt sends a delivery to its webhook once and updates its status,
+// scheduling a retry or giving it up as dead when the attempt failed
+func (s *WebhookService) attempt(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) {
+	status, err := s.send(ctx, webhook, delivery)
+
+	now := s.now()
+	delivery.Attempts++
+	delivery.ResponseStatus = status
+	delivery.UpdatedAt = now
+
+	switch {
+	case err == nil:
+		delivery.Status = models.DeliverySucceeded
+		delivery.LastError = ""
+	case delivery.Attempts >= s.maxAttempts:
+		delivery.Status = models.DeliveryDead
+		delivery.LastError = err.Error()
+	default:
+		delivery.Status = models.DeliveryPending
+		delivery.LastError = err.Error()
+		delivery.NextAttemptAt = now.Add(s.retryDelay(delivery.Attempts))
+	}
+}
+
+// send posts the signed payload of a delivery, returning the response status
+// and an error unless the endpoint answered with a 2xx status
+func (s *WebhookService) send(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := s.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gottodo-webhooks")
+	req.Header.Set(WebhookEventHeader, string(delivery.Event))
+	req.Header.Set(WebhookDeliveryHeader, delivery.ID)
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// The response is never shown to the user, so it cannot leak what the
+	// endpoint returned
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxWebhookDrainLength))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// newDelivery creates a pending delivery of an event to a webhook
+func (s *WebhookService) newDelivery(webhook *models.Webhook, eventID string, event models.WebhookEvent, todo *models.Todo) (*models.WebhookDelivery, error) {
+	payload, err := json.Marshal(&WebhookPayload{
+		ID:        eventID,
+		Event:     event,
+		CreatedAt: s.now(),
+		Todo:      todo,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	delivery := models.NewWebhookDelivery(webhook, event, payload)
+	delivery.NextAttemptAt = s.now()
+	return delivery, nil
+}
+
+// retryDelay returns the delay after the given number of failed attempts
+func (s *WebhookService) retryDelay(attempts int) time.Duration {
+	delay := s.backoff
+	for i := 1; i < attempts && delay < maxWebhookBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxWebhookBackoff {
+		delay = maxWebhookBackoff
+	}
+	return delay
+}
+
+// lease returns how long a claimed delivery is kept from other workers,
+// comfortably longer than an attempt may take
+func (s *WebhookService) lease() time.Duration {
+	return 2 * s.client.Timeout
+}
+
+// webhookEvent maps an activity action to the webhook event announcing it,
+// reporting false for actions webhooks are not notified about
+func webhookEvent(action models.ActivityAction) (models.WebhookEvent, bool) {
+	switch action {
+	case models.ActivityCreated:
+		return models.WebhookTodoCreated, true
+	case models.ActivityCompleted:
+		return models.WebhookTodoCompleted, true
+	case models.ActivityDeleted:
+		return models.WebhookTodoDeleted, true
+	default:
+		return "", false
+	}
+}
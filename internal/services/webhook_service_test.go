@@ -0,0 +1,418 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
+)
+
+// receivedWebhook is a request captured by a webhookReceiver
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+// webhookReceiver is a local webhook endpoint answering with a configurable status
+type webhookReceiver struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	status   int
+	received []receivedWebhook
+}
+
+// newWebhookReceiver starts a webhook endpoint that accepts every delivery
+func newWebhookReceiver(t *testing.T) *webhookReceiver {
+	t.Helper()
+
+	receiver := &webhookReceiver{status: http.StatusNoContent}
+	receiver.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		receiver.mu.Lock()
+		defer receiver.mu.Unlock()
+		receiver.received = append(receiver.received, receivedWebhook{header: r.Header.Clone(), body: body})
+		w.WriteHeader(receiver.status)
+		if receiver.status >= 300 {
+			io.WriteString(w, "try again later")
+		}
+	}))
+	t.Cleanup(receiver.Close)
+	return receiver
+}
+
+// respond sets the status of future responses
+func (r *webhookReceiver) respond(status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status = status
+}
+
+// requests returns the requests received so far
+func (r *webhookReceiver) requests() []receivedWebhook {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]receivedWebhook(nil), r.received...)
+}
+
+// testClock is a manually advanced clock for the webhook worker
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// newTestWebhookService creates a WebhookService on a memory repository and
+// a manual clock
+func newTestWebhookService(opts ...WebhookServiceOption) (*WebhookService, repositories.WebhookRepository, *testClock) {
+	repo := repositories.NewMemoryWebhookRepository()
+	service := NewWebhookService(repo, opts...)
+	clock := &testClock{now: time.Now()}
+	service.now = clock.Now
+	return service, repo, clock
+}
+
+func TestWebhookService_AllowedAddr(t *testing.T) {
+	service, _, _ := newTestWebhookService()
+
+	for _, addr := range []string{"93.184.216.34", "100.128.0.1", "2606:2800:220:1::", "64:ff9b::93.184.216.34"} {
+		if !service.allowedAddr(netip.MustParseAddr(addr)) {
+			t.Errorf("Expected public address %s to be allowed", addr)
+		}
+	}
+	for _, addr := range []string{"100.64.0.1", "198.18.0.1", "240.0.0.1", "64:ff9b:1::1", "::ffff:10.0.0.1"} {
+		if service.allowedAddr(netip.MustParseAddr(addr)) {
+			t.Errorf("Expected non-public address %s to be refused", addr)
+		}
+	}
+}
+
+func TestWebhookService_CreateWebhook(t *testing.T) {
+	service, _, _ := newTestWebhookService()
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		url     string
+		events  []models.WebhookEvent
+		wantErr error
+	}{
+		{name: "valid", url: "https://example.com/hook", events: []models.WebhookEvent{models.WebhookTodoCreated, models.WebhookTodoCreated}},
+		{name: "relative URL", url: "/hook", events: models.WebhookEvents, wantErr: ErrInvalidWebhookURL},
+		{name: "unsupported scheme", url: "ftp://example.com/hook", events: models.WebhookEvents, wantErr: ErrInvalidWebhookURL},
+		{name: "no events", url: "https://example.com/hook", wantErr: ErrInvalidWebhookEvent},
+		{name: "unknown event", url: "https://example.com/hook", events: []models.WebhookEvent{"todo.renamed"}, wantErr: ErrInvalidWebhookEvent},
+		{name: "test events cannot be subscribed to", url: "https://example.com/hook", events: []models.WebhookEvent{models.WebhookTest}, wantErr: ErrInvalidWebhookEvent},
+		{name: "loopback", url: "http://127.0.0.1:8080/hook", events: models.WebhookEvents, wantErr: ErrForbiddenWebhookAddress},
+		{name: "IPv6 loopback", url: "http://[::1]/hook", events: models.WebhookEvents, wantErr: ErrForbiddenWebhookAddress},
+		{name: "localhost", url: "http://localhost/hook", events: models.WebhookEvents, wantErr: ErrForbiddenWebhookAddress},
+		{name: "private", url: "http://10.0.0.1/hook", events: models.WebhookEvents, wantErr: ErrForbiddenWebhookAddress},
+		{name: "IPv4-mapped private", url: "http://[::ffff:192.168.1.1]/hook", events: models.WebhookEvents, wantErr: ErrForbiddenWebhookAddress},
+		{name: "link-local", url: "http://169.254.169.254/latest/meta-data", events: models.WebhookEvents, wantErr: ErrForbiddenWebhookAddress},
+		{name: "unspecified", url: "http://0.0.0.0/hook", events: models.WebhookEvents, wantErr: ErrForbiddenWebhookAddress},
+		{name: "this network", url: "http://0.1.2.3/hook", events: models.WebhookEvents, wantErr: ErrForbiddenWebhookAddress},
+		{name: "shared address space", url: "http://100.64.0.1/hook", events: models.WebhookEvents, wantErr: ErrForbiddenWebhookAddress},
+		{name: "IPv4-mapped shared address space", url: "http://[::ffff:100.127.255.254]/hook", events: models.WebhookEvents, wantErr: ErrForbiddenWebhookAddress},
+		{name: "multicast", url: "http://224.0.0.251/hook", events: models.WebhookEvents, wantErr: ErrForbiddenWebhookAddress},
+		{name: "IPv6 multicast", url: "http://[ff0e::1]/hook", events: models.WebhookEvents, wantErr: ErrForbiddenWebhookAddress},
+		{name: "broadcast", url: "http://255.255.255.255/hook", events: models.WebhookEvents, wantErr: ErrForbiddenWebhookAddress},
+		{name: "IPv4-mapped loopback", url: "http://[::ffff:127.0.0.1]/hook", events: models.WebhookEvents, wantErr: ErrForbiddenWebhookAddress},
+		{name: "IPv4-mapped link-local", url: "http://[::ffff:169.254.169.254]/hook", events: models.WebhookEvents, wantErr: ErrForbiddenWebhookAddress},
+		{name: "NAT64 private", url: "http://[64:ff9b::10.0.0.1]/hook", events: models.WebhookEvents, wantErr: ErrForbiddenWebhookAddress},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			webhook, err := service.CreateWebhook(ctx, "user1", tt.url, tt.events)
+			if err != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if len(webhook.Events) != 1 {
+				t.Errorf("Expected duplicate events to be dropped, got %v", webhook.Events)
+			}
+			if webhook.Secret == "" {
+				t.Error("Expected the webhook to get a secret")
+			}
+		})
+	}
+
+	// Webhooks of other users are hidden
+	webhooks, err := service.GetUserWebhooks(ctx, "user1")
+	if err != nil || len(webhooks) != 1 {
+		t.Fatalf("Expected one webhook, got %d (%v)", len(webhooks), err)
+	}
+	if _, err := service.GetDeliveries(ctx, webhooks[0].ID, "user2"); err != repositories.ErrWebhookNotFound {
+		t.Errorf("Expected ErrWebhookNotFound for another user, got %v", err)
+	}
+	if err := service.DeleteWebhook(ctx, webhooks[0].ID, "user2"); err != repositories.ErrWebhookNotFound {
+		t.Errorf("Expected ErrWebhookNotFound for another user, got %v", err)
+	}
+	if err := service.DeleteWebhook(ctx, webhooks[0].ID, "user1"); err != nil {
+		t.Errorf("Failed to delete webhook: %v", err)
+	}
+}
+
+func TestWebhookService_DeliversSignedTodoEvents(t *testing.T) {
+	receiver := newWebhookReceiver(t)
+	webhooks, _, _ := newTestWebhookService(WithWebhookLoopback())
+	todoService := NewTodoService(NewMockTodoRepository(), WithWebhookService(webhooks))
+	ctx := context.Background()
+
+	webhook, err := webhooks.CreateWebhook(ctx, "user1", receiver.URL, []models.WebhookEvent{models.WebhookTodoCreated, models.WebhookTodoCompleted})
+	if err != nil {
+		t.Fatalf("Failed to create webhook: %v", err)
+	}
+
+	todo := &models.Todo{UserID: "user1", Title: "Ship webhooks"}
+	if err := todoService.CreateTodo(ctx, todo); err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+	if err := todoService.UpdateTodoStatus(ctx, todo.ID, "user1", true, 0); err != nil {
+		t.Fatalf("Failed to complete todo: %v", err)
+	}
+	// Not subscribed to deletions
+	if err := todoService.DeleteTodo(ctx, todo.ID, "user1", 0); err != nil {
+		t.Fatalf("Failed to delete todo: %v", err)
+	}
+
+	processed, err := webhooks.ProcessDueDeliveries(ctx)
+	if err != nil || processed != 2 {
+		t.Fatalf("Expected 2 deliveries to be processed, got %d (%v)", processed, err)
+	}
+
+	requests := receiver.requests()
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(requests))
+	}
+
+	events := map[string]bool{}
+	for _, req := range requests {
+		timestamp, err := strconv.ParseInt(req.header.Get(WebhookTimestampHeader), 10, 64)
+		if err != nil {
+			t.Fatalf("Expected a timestamp header, got %q", req.header.Get(WebhookTimestampHeader))
+		}
+		if got, want := req.header.Get(WebhookSignatureHeader), SignWebhookPayload(webhook.Secret, timestamp, req.body); got != want {
+			t.Errorf("Expected signature %q, got %q", want, got)
+		}
+
+		var payload WebhookPayload
+		if err := json.Unmarshal(req.body, &payload); err != nil {
+			t.Fatalf("Failed to decode payload: %v", err)
+		}
+		if string(payload.Event) != req.header.Get(WebhookEventHeader) {
+			t.Errorf("Expected the event header to match the payload, got %q and %q", req.header.Get(WebhookEventHeader), payload.Event)
+		}
+		if payload.Todo == nil || payload.Todo.ID != todo.ID {
+			t.Errorf("Expected the payload to carry the todo, got %+v", payload.Todo)
+		}
+		events[string(payload.Event)] = true
+	}
+	if !events["todo.created"] || !events["todo.completed"] {
+		t.Errorf("Expected created and completed events, got %v", events)
+	}
+
+	deliveries, err := webhooks.GetDeliveries(ctx, webhook.ID, "user1")
+	if err != nil {
+		t.Fatalf("Failed to get deliveries: %v", err)
+	}
+	for _, delivery := range deliveries {
+		if delivery.Status != models.DeliverySucceeded || delivery.Attempts != 1 || delivery.ResponseStatus != http.StatusNoContent {
+			t.Errorf("Expected a successful first attempt, got %+v", delivery)
+		}
+	}
+
+	// Nothing is left to deliver
+	if processed, _ := webhooks.ProcessDueDeliveries(ctx); processed != 0 {
+		t.Errorf("Expected no further deliveries, got %d", processed)
+	}
+}
+
+func TestWebhookService_RunWorker_WakesOnEnqueue(t *testing.T) {
+	receiver := newWebhookReceiver(t)
+	// Set up as the server does, with the memory repository standing in for
+	// the system one
+	repo := repositories.NewMemoryWebhookRepository()
+	service := NewWebhookService(repo, WithWebhookLoopback(), WithWebhookWorkerRepository(repo))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if _, err := service.CreateWebhook(ctx, "user1", receiver.URL, []models.WebhookEvent{models.WebhookTodoCreated}); err != nil {
+		t.Fatalf("Failed to create webhook: %v", err)
+	}
+
+	// Far longer than the test may take, so only the wake delivers in time
+	go service.RunWorker(ctx, time.Hour)
+	service.Enqueue(ctx, "user1", models.WebhookTodoCreated, &models.Todo{ID: "todo1", UserID: "user1"})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(receiver.requests()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the worker to deliver right after the event was enqueued")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWebhookService_RetriesWithBackoff(t *testing.T) {
+	receiver := newWebhookReceiver(t)
+	receiver.respond(http.StatusServiceUnavailable)
+	service, repo, clock := newTestWebhookService(WithWebhookLoopback(), WithWebhookMaxAttempts(3), WithWebhookBackoff(time.Minute))
+	ctx := context.Background()
+
+	webhook, err := service.CreateWebhook(ctx, "user1", receiver.URL, models.WebhookEvents)
+	if err != nil {
+		t.Fatalf("Failed to create webhook: %v", err)
+	}
+	service.Enqueue(ctx, "user1", models.WebhookTodoDeleted, &models.Todo{ID: "todo1", UserID: "user1"})
+
+	delivery := func() *models.WebhookDelivery {
+		t.Helper()
+		deliveries, err := repo.GetWebhookDeliveries(ctx, webhook.ID, 10)
+		if err != nil || len(deliveries) != 1 {
+			t.Fatalf("Expected one delivery, got %d (%v)", len(deliveries), err)
+		}
+		return deliveries[0]
+	}
+
+	// Each failure doubles the delay before the next attempt
+	for attempt, delay := range []time.Duration{time.Minute, 2 * time.Minute} {
+		if processed, err := service.ProcessDueDeliveries(ctx); err != nil || processed != 1 {
+			t.Fatalf("Expected attempt %d, got %d processed (%v)", attempt+1, processed, err)
+		}
+		failed := delivery()
+		if failed.Status != models.DeliveryPending || failed.Attempts != attempt+1 {
+			t.Fatalf("Expected a pending delivery after %d attempts, got %s after %d", attempt+1, failed.Status, failed.Attempts)
+		}
+		// The response body stays out of the delivery log
+		if failed.ResponseStatus != http.StatusServiceUnavailable || failed.LastError != "unexpected status 503" {
+			t.Errorf("Expected the failure to be recorded, got %d %q", failed.ResponseStatus, failed.LastError)
+		}
+		if got := failed.NextAttemptAt.Sub(clock.Now()); got != delay {
+			t.Errorf("Expected a retry in %v, got %v", delay, got)
+		}
+
+		// Not retried before it is due
+		if processed, _ := service.ProcessDueDeliveries(ctx); processed != 0 {
+			t.Errorf("Expected no attempt before the retry is due, got %d", processed)
+		}
+		clock.Advance(delay)
+	}
+
+	// The last failed attempt gives the delivery up
+	if _, err := service.ProcessDueDeliveries(ctx); err != nil {
+		t.Fatalf("Failed to process deliveries: %v", err)
+	}
+	if dead := delivery(); dead.Status != models.DeliveryDead || dead.Attempts != 3 {
+		t.Errorf("Expected a dead delivery after 3 attempts, got %s after %d", dead.Status, dead.Attempts)
+	}
+
+	clock.Advance(time.Hour)
+	if processed, _ := service.ProcessDueDeliveries(ctx); processed != 0 {
+		t.Errorf("Expected dead deliveries not to be retried, got %d", processed)
+	}
+	if len(receiver.requests()) != 3 {
+		t.Errorf("Expected 3 requests, got %d", len(receiver.requests()))
+	}
+}
+
+func TestWebhookService_SendTestEvent(t *testing.T) {
+	receiver := newWebhookReceiver(t)
+	service, _, _ := newTestWebhookService(WithWebhookLoopback())
+	ctx := context.Background()
+
+	webhook, err := service.CreateWebhook(ctx, "user1", receiver.URL, []models.WebhookEvent{models.WebhookTodoCreated})
+	if err != nil {
+		t.Fatalf("Failed to create webhook: %v", err)
+	}
+
+	if _, err := service.SendTestEvent(ctx, webhook.ID, "user2"); err != repositories.ErrWebhookNotFound {
+		t.Errorf("Expected ErrWebhookNotFound for another user, got %v", err)
+	}
+
+	// Test events are sent right away
+	delivery, err := service.SendTestEvent(ctx, webhook.ID, "user1")
+	if err != nil {
+		t.Fatalf("Failed to send test event: %v", err)
+	}
+	if delivery.Event != models.WebhookTest || delivery.Status != models.DeliverySucceeded {
+		t.Errorf("Expected a successful test delivery, got %s %s", delivery.Event, delivery.Status)
+	}
+	if requests := receiver.requests(); len(requests) != 1 || requests[0].header.Get(WebhookEventHeader) != "webhook.test" {
+		t.Fatalf("Expected the test event to be received, got %d requests", len(requests))
+	}
+
+	// Failed tests show up in the delivery log and are retried later
+	receiver.respond(http.StatusInternalServerError)
+	delivery, err = service.SendTestEvent(ctx, webhook.ID, "user1")
+	if err != nil {
+		t.Fatalf("Failed to send test event: %v", err)
+	}
+	if delivery.Status != models.DeliveryPending || delivery.ResponseStatus != http.StatusInternalServerError {
+		t.Errorf("Expected a pending delivery after a failed test, got %s %d", delivery.Status, delivery.ResponseStatus)
+	}
+
+	log, err := service.GetDeliveries(ctx, webhook.ID, "user1")
+	if err != nil || len(log) != 2 {
+		t.Fatalf("Expected 2 deliveries in the log, got %d (%v)", len(log), err)
+	}
+}
+
+func TestWebhookService_RefusesLocalAddresses(t *testing.T) {
+	receiver := newWebhookReceiver(t)
+	service, repo, _ := newTestWebhookService()
+	ctx := context.Background()
+
+	// Names are checked once resolved, so a webhook whose name later points
+	// to a local address receives nothing
+	_, port, _ := strings.Cut(strings.TrimPrefix(receiver.URL, "http://"), ":")
+	for _, url := range []string{receiver.URL, "http://localhost:" + port} {
+		webhook := models.NewWebhook("user1", url, models.WebhookEvents)
+		if err := repo.CreateWebhook(ctx, webhook); err != nil {
+			t.Fatalf("Failed to create webhook: %v", err)
+		}
+
+		delivery, err := service.SendTestEvent(ctx, webhook.ID, "user1")
+		if err != nil {
+			t.Fatalf("Failed to send test event: %v", err)
+		}
+		if delivery.Status != models.DeliveryPending || !strings.Contains(delivery.LastError, ErrForbiddenWebhookAddress.Error()) {
+			t.Errorf("Expected %s to be refused, got %s %q", url, delivery.Status, delivery.LastError)
+		}
+	}
+	if len(receiver.requests()) != 0 {
+		t.Errorf("Expected no request to reach the local receiver, got %d", len(receiver.requests()))
+	}
+}
+
+func TestSignWebhookPayload(t *testing.T) {
+	// Computed with: printf '1700000000.{}' | openssl dgst -sha256 -hmac whsec_test
+	want := "sha256=35495024f4ef3f94e5a93e22221544c4b75e9a42300cd965ab81cb85cd994e91"
+	if got := SignWebhookPayload("whsec_test", 1700000000, []byte("{}")); got != want {
+		t.Errorf("Expected signature %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS todos;
+DROP EXTENSION IF EXISTS "uuid-ossp";
@@ -19,10 +19,13 @@ CREATE INDEX IF NOT EXISTS idx_todos_user_id ON todos(user_id);
 ALTER TABLE todos ENABLE ROW LEVEL SECURITY;
 
 -- Create policy to ensure users can only see and manipulate their own todos
-CREATE POLICY todo_user_policy ON todos
-    USING (user_id = auth.uid())
-    WITH CHECK (user_id = auth.uid());
-
--- Downgrade
--- DROP TABLE IF EXISTS todos;
--- DROP EXTENSION IF EXISTS "uuid-ossp"; 
\ No newline at end of file
+-- auth.uid() only exists on Supabase; plain Postgres skips the policy
+DO $$
+BEGIN
+    IF to_regprocedure('auth.uid()') IS NOT NULL THEN
+        CREATE POLICY todo_user_policy ON todos
+            USING (user_id = auth.uid())
+            WITH CHECK (user_id = auth.uid());
+    END IF;
+END
+$$;
@@ -0,0 +1,30 @@
+-- Create append-only activity log for todo changes
+CREATE TABLE IF NOT EXISTS activity_log (
+    id UUID PRIMARY KEY,
+    actor_id UUID NOT NULL,
+    todo_id UUID NOT NULL,
+    action TEXT NOT NULL,
+    changes JSONB NOT NULL DEFAULT '[]'::jsonb,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Create indexes for the per-todo history and per-user feed
+CREATE INDEX IF NOT EXISTS idx_activity_log_todo_id ON activity_log(todo_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_activity_log_actor_id ON activity_log(actor_id, created_at DESC);
+
+-- Add RLS (Row Level Security) policies
+ALTER TABLE activity_log ENABLE ROW LEVEL SECURITY;
+
+-- Users can read and append their own activity, but never modify it
+-- auth.uid() only exists on Supabase; plain Postgres skips the policies
+DO $$
+BEGIN
+    IF to_regprocedure('auth.uid()') IS NOT NULL THEN
+        CREATE POLICY activity_log_select_policy ON activity_log
+            FOR SELECT USING (actor_id = auth.uid());
+
+        CREATE POLICY activity_log_insert_policy ON activity_log
+            FOR INSERT WITH CHECK (actor_id = auth.uid());
+    END IF;
+END
+$$;
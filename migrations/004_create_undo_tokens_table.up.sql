@@ -0,0 +1,29 @@
+-- Create table holding the prior state of recent destructive actions
+CREATE TABLE IF NOT EXISTS undo_tokens (
+    token TEXT PRIMARY KEY,
+    user_id UUID NOT NULL,
+    todo_id UUID NOT NULL,
+    action TEXT NOT NULL,
+    snapshot JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Create indexes for looking up pending undos and discarding expired tokens
+CREATE INDEX IF NOT EXISTS idx_undo_tokens_user_id ON undo_tokens(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_undo_tokens_expires_at ON undo_tokens(expires_at);
+
+-- Add RLS (Row Level Security) policies
+ALTER TABLE undo_tokens ENABLE ROW LEVEL SECURITY;
+
+-- Create policy to ensure users can only use their own undo tokens
+-- auth.uid() only exists on Supabase; plain Postgres skips the policy
+DO $$
+BEGIN
+    IF to_regprocedure('auth.uid()') IS NOT NULL THEN
+        CREATE POLICY undo_tokens_user_policy ON undo_tokens
+            USING (user_id = auth.uid())
+            WITH CHECK (user_id = auth.uid());
+    END IF;
+END
+$$;
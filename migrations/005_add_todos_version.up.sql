@@ -0,0 +1,2 @@
+-- Add a version counter to todos for optimistic concurrency control
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,30 @@
+-- Create table remembering responses to requests sent with an Idempotency-Key header
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    key TEXT NOT NULL,
+    user_id UUID NOT NULL,
+    request_hash TEXT NOT NULL,
+    status_code INTEGER NOT NULL,
+    content_type TEXT NOT NULL,
+    body BYTEA NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (user_id, key)
+);
+
+-- Create index for discarding expired keys
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
+
+-- Add RLS (Row Level Security) policies
+ALTER TABLE idempotency_keys ENABLE ROW LEVEL SECURITY;
+
+-- Create policy to ensure users can only see their own idempotency keys
+-- auth.uid() only exists on Supabase; plain Postgres skips the policy
+DO $$
+BEGIN
+    IF to_regprocedure('auth.uid()') IS NOT NULL THEN
+        CREATE POLICY idempotency_keys_user_policy ON idempotency_keys
+            USING (user_id = auth.uid())
+            WITH CHECK (user_id = auth.uid());
+    END IF;
+END
+$$;
@@ -0,0 +1,45 @@
+-- Announce todo changes on the todo_events channel so every application
+-- instance can update the live event streams of its clients
+CREATE OR REPLACE FUNCTION notify_todo_change() RETURNS TRIGGER AS $$
+DECLARE
+    event_type TEXT;
+    changed todos;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        event_type := 'created';
+        changed := NEW;
+    ELSIF TG_OP = 'DELETE' THEN
+        -- Todos purged from the trash left the list when they were trashed
+        IF OLD.deleted_at IS NOT NULL THEN
+            RETURN NULL;
+        END IF;
+        event_type := 'deleted';
+        changed := OLD;
+    ELSIF OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN
+        event_type := 'deleted';
+        changed := NEW;
+    ELSIF OLD.deleted_at IS NOT NULL AND NEW.deleted_at IS NULL THEN
+        -- Restored todos reappear in the list
+        event_type := 'created';
+        changed := NEW;
+    ELSIF NEW.deleted_at IS NULL THEN
+        event_type := 'updated';
+        changed := NEW;
+    ELSE
+        RETURN NULL;
+    END IF;
+
+    -- Only identifiers are sent; payloads are limited to 8000 bytes
+    PERFORM pg_notify('todo_events', json_build_object(
+        'type', event_type,
+        'todo_id', changed.id,
+        'user_id', changed.user_id
+    )::TEXT);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS todos_notify_change ON todos;
+CREATE TRIGGER todos_notify_change
+    AFTER INSERT OR UPDATE OR DELETE ON todos
+    FOR EACH ROW EXECUTE FUNCTION notify_todo_change();
@@ -0,0 +1,21 @@
+-- Create table of the secrets in the users' capture URLs
+CREATE TABLE IF NOT EXISTS capture_secrets (
+    user_id UUID PRIMARY KEY,
+    secret TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Add RLS (Row Level Security) policies
+ALTER TABLE capture_secrets ENABLE ROW LEVEL SECURITY;
+
+-- Create policy to ensure users can only see their own capture secret
+-- auth.uid() only exists on Supabase; plain Postgres skips the policy
+DO $$
+BEGIN
+    IF to_regprocedure('auth.uid()') IS NOT NULL THEN
+        CREATE POLICY capture_secrets_user_policy ON capture_secrets
+            USING (user_id = auth.uid())
+            WITH CHECK (user_id = auth.uid());
+    END IF;
+END
+$$;
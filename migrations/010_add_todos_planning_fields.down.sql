@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_todos_due_at;
+ALTER TABLE todos DROP COLUMN IF EXISTS recurrence;
+ALTER TABLE todos DROP COLUMN IF EXISTS tags;
+ALTER TABLE todos DROP COLUMN IF EXISTS project;
+ALTER TABLE todos DROP COLUMN IF EXISTS priority;
+ALTER TABLE todos DROP COLUMN IF EXISTS due_at;
@@ -0,0 +1,11 @@
+-- Add the due date, priority, project, tags and recurrence that quick-add
+-- recognises in todo titles
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS due_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS priority TEXT NOT NULL DEFAULT ''
+    CHECK (priority IN ('', 'low', 'medium', 'high'));
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS project TEXT NOT NULL DEFAULT '';
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS recurrence TEXT NOT NULL DEFAULT '';
+
+-- Create partial index for listing upcoming todos
+CREATE INDEX IF NOT EXISTS idx_todos_due_at ON todos(user_id, due_at) WHERE due_at IS NOT NULL;
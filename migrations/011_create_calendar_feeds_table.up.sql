@@ -0,0 +1,21 @@
+-- Create table of the secrets in the users' calendar feed URLs
+CREATE TABLE IF NOT EXISTS calendar_feeds (
+    user_id UUID PRIMARY KEY,
+    secret TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Add RLS (Row Level Security) policies
+ALTER TABLE calendar_feeds ENABLE ROW LEVEL SECURITY;
+
+-- Create policy to ensure users can only see their own calendar feed
+-- auth.uid() only exists on Supabase; plain Postgres skips the policy
+DO $$
+BEGIN
+    IF to_regprocedure('auth.uid()') IS NOT NULL THEN
+        CREATE POLICY calendar_feeds_user_policy ON calendar_feeds
+            USING (user_id = auth.uid())
+            WITH CHECK (user_id = auth.uid());
+    END IF;
+END
+$$;
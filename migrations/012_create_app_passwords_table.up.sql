@@ -0,0 +1,27 @@
+-- Create table of the app passwords CalDAV clients authenticate with
+CREATE TABLE IF NOT EXISTS app_passwords (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    name TEXT NOT NULL,
+    -- SHA-256 of the password, which is only shown once
+    password_hash TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Create index on user_id for faster queries
+CREATE INDEX IF NOT EXISTS idx_app_passwords_user_id ON app_passwords(user_id);
+
+-- Add RLS (Row Level Security) policies
+ALTER TABLE app_passwords ENABLE ROW LEVEL SECURITY;
+
+-- Create policy to ensure users can only see their own app passwords
+-- auth.uid() only exists on Supabase; plain Postgres skips the policy
+DO $$
+BEGIN
+    IF to_regprocedure('auth.uid()') IS NOT NULL THEN
+        CREATE POLICY app_passwords_user_policy ON app_passwords
+            USING (user_id = auth.uid())
+            WITH CHECK (user_id = auth.uid());
+    END IF;
+END
+$$;
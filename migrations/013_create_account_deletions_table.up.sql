@@ -0,0 +1,28 @@
+-- Create table of the pending account deletions, carried out by the server
+-- once their grace period ends
+CREATE TABLE IF NOT EXISTS account_deletions (
+    user_id UUID PRIMARY KEY,
+    requested_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    delete_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Create index on delete_at for finding due deletions
+CREATE INDEX IF NOT EXISTS idx_account_deletions_delete_at ON account_deletions(delete_at);
+
+-- Add RLS (Row Level Security) policies
+ALTER TABLE account_deletions ENABLE ROW LEVEL SECURITY;
+
+-- Create policy to ensure users can only see their own account deletion
+-- auth.uid() only exists on Supabase; plain Postgres skips the policy
+DO $$
+BEGIN
+    IF to_regprocedure('auth.uid()') IS NOT NULL THEN
+        CREATE POLICY account_deletions_user_policy ON account_deletions
+            USING (user_id = auth.uid())
+            WITH CHECK (user_id = auth.uid());
+    END IF;
+END
+$$;
+
+-- Activity is append-only for users; entries are only removed together with
+-- the account of their actor, which the server does with its own role
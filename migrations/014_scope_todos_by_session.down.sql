@@ -0,0 +1,16 @@
+ALTER TABLE todos NO FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS todo_owner_policy ON todos;
+
+-- Bring back the Supabase policy where auth.uid() exists
+DO $$
+BEGIN
+    IF to_regprocedure('auth.uid()') IS NOT NULL THEN
+        CREATE POLICY todo_user_policy ON todos
+            USING (user_id = auth.uid())
+            WITH CHECK (user_id = auth.uid());
+    END IF;
+END
+$$;
+
+DROP FUNCTION IF EXISTS app_bypass_rls();
+DROP FUNCTION IF EXISTS app_current_user_id();
@@ -0,0 +1,31 @@
+-- Enforce the ownership of todos in the database from the scope the server
+-- sets for every transaction, so that a bug in the service layer cannot leak
+-- another user's todos. Unlike auth.uid() this works on any Postgres.
+
+-- The user a transaction runs for: app.user_id as set by the server, or the
+-- subject of the request.jwt.claims Supabase and PostgREST set
+CREATE OR REPLACE FUNCTION app_current_user_id() RETURNS UUID
+    LANGUAGE sql STABLE
+AS $$
+    SELECT COALESCE(
+        NULLIF(current_setting('app.user_id', true), ''),
+        NULLIF(current_setting('request.jwt.claims', true), '')::json ->> 'sub'
+    )::uuid
+$$;
+
+-- Whether a transaction is background work of the server across all users
+CREATE OR REPLACE FUNCTION app_bypass_rls() RETURNS BOOLEAN
+    LANGUAGE sql STABLE
+AS $$
+    SELECT COALESCE(current_setting('app.bypass_rls', true), '') = 'on'
+$$;
+
+DROP POLICY IF EXISTS todo_user_policy ON todos;
+
+CREATE POLICY todo_owner_policy ON todos
+    USING (app_bypass_rls() OR user_id = app_current_user_id())
+    WITH CHECK (app_bypass_rls() OR user_id = app_current_user_id());
+
+-- Apply the policy to the owner of the table too, which the server usually
+-- connects as. Roles with BYPASSRLS, such as superusers, still bypass it.
+ALTER TABLE todos FORCE ROW LEVEL SECURITY;
@@ -0,0 +1,11 @@
+CREATE OR REPLACE FUNCTION app_bypass_rls() RETURNS BOOLEAN
+    LANGUAGE sql STABLE
+AS $$
+    SELECT COALESCE(current_setting('app.bypass_rls', true), '') = 'on'
+$$;
+
+DROP POLICY IF EXISTS todo_owner_policy ON todos;
+
+CREATE POLICY todo_owner_policy ON todos
+    USING (app_bypass_rls() OR user_id = app_current_user_id())
+    WITH CHECK (app_bypass_rls() OR user_id = app_current_user_id());
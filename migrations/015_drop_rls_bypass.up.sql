@@ -0,0 +1,11 @@
+-- Stop letting a session variable switch off the todo owner policy. Any
+-- statement the server runs could set app.bypass_rls, so a single injected
+-- query would have exposed every user's todos. Background jobs that work
+-- across users connect as a role with BYPASSRLS instead, see system_db_url.
+DROP POLICY IF EXISTS todo_owner_policy ON todos;
+
+CREATE POLICY todo_owner_policy ON todos
+    USING (user_id = app_current_user_id())
+    WITH CHECK (user_id = app_current_user_id());
+
+DROP FUNCTION IF EXISTS app_bypass_rls();
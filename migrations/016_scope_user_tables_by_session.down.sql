@@ -0,0 +1,73 @@
+ALTER TABLE activity_log NO FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS activity_log_owner_select_policy ON activity_log;
+DROP POLICY IF EXISTS activity_log_owner_insert_policy ON activity_log;
+DROP POLICY IF EXISTS activity_log_owner_delete_policy ON activity_log;
+
+ALTER TABLE account_deletions NO FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS account_deletions_owner_policy ON account_deletions;
+
+ALTER TABLE app_passwords NO FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS app_passwords_owner_policy ON app_passwords;
+
+ALTER TABLE calendar_feeds NO FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS calendar_feeds_owner_policy ON calendar_feeds;
+
+ALTER TABLE capture_secrets NO FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS capture_secrets_owner_policy ON capture_secrets;
+
+ALTER TABLE webhook_deliveries NO FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS webhook_deliveries_owner_policy ON webhook_deliveries;
+
+ALTER TABLE webhooks NO FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS webhooks_owner_policy ON webhooks;
+
+ALTER TABLE idempotency_keys NO FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS idempotency_keys_owner_policy ON idempotency_keys;
+
+ALTER TABLE undo_tokens NO FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS undo_tokens_owner_policy ON undo_tokens;
+
+-- Bring back the Supabase policies where auth.uid() exists
+DO $$
+BEGIN
+    IF to_regprocedure('auth.uid()') IS NOT NULL THEN
+        CREATE POLICY activity_log_select_policy ON activity_log
+            FOR SELECT USING (actor_id = auth.uid());
+
+        CREATE POLICY activity_log_insert_policy ON activity_log
+            FOR INSERT WITH CHECK (actor_id = auth.uid());
+
+        CREATE POLICY undo_tokens_user_policy ON undo_tokens
+            USING (user_id = auth.uid())
+            WITH CHECK (user_id = auth.uid());
+
+        CREATE POLICY idempotency_keys_user_policy ON idempotency_keys
+            USING (user_id = auth.uid())
+            WITH CHECK (user_id = auth.uid());
+
+        CREATE POLICY webhooks_user_policy ON webhooks
+            USING (user_id = auth.uid())
+            WITH CHECK (user_id = auth.uid());
+
+        CREATE POLICY webhook_deliveries_user_policy ON webhook_deliveries
+            USING (user_id = auth.uid())
+            WITH CHECK (user_id = auth.uid());
+
+        CREATE POLICY capture_secrets_user_policy ON capture_secrets
+            USING (user_id = auth.uid())
+            WITH CHECK (user_id = auth.uid());
+
+        CREATE POLICY calendar_feeds_user_policy ON calendar_feeds
+            USING (user_id = auth.uid())
+            WITH CHECK (user_id = auth.uid());
+
+        CREATE POLICY app_passwords_user_policy ON app_passwords
+            USING (user_id = auth.uid())
+            WITH CHECK (user_id = auth.uid());
+
+        CREATE POLICY account_deletions_user_policy ON account_deletions
+            USING (user_id = auth.uid())
+            WITH CHECK (user_id = auth.uid());
+    END IF;
+END
+$$;
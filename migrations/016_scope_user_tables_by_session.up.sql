@@ -0,0 +1,86 @@
+-- Enforce the ownership of the rest of the users' data in the database like
+-- that of todos (see 014_scope_todos_by_session.up.sql): every table whose
+-- rows belong to a user is restricted to the user of the scope the server
+-- sets for every transaction, on any Postgres. Background jobs that work
+-- across users, and lookups of the secrets in capture and calendar feed URLs,
+-- connect as a role with BYPASSRLS instead, see system_db_url.
+
+DROP POLICY IF EXISTS undo_tokens_user_policy ON undo_tokens;
+
+CREATE POLICY undo_tokens_owner_policy ON undo_tokens
+    USING (user_id = app_current_user_id())
+    WITH CHECK (user_id = app_current_user_id());
+
+ALTER TABLE undo_tokens FORCE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS idempotency_keys_user_policy ON idempotency_keys;
+
+CREATE POLICY idempotency_keys_owner_policy ON idempotency_keys
+    USING (user_id = app_current_user_id())
+    WITH CHECK (user_id = app_current_user_id());
+
+ALTER TABLE idempotency_keys FORCE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS webhooks_user_policy ON webhooks;
+
+CREATE POLICY webhooks_owner_policy ON webhooks
+    USING (user_id = app_current_user_id())
+    WITH CHECK (user_id = app_current_user_id());
+
+ALTER TABLE webhooks FORCE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS webhook_deliveries_user_policy ON webhook_deliveries;
+
+CREATE POLICY webhook_deliveries_owner_policy ON webhook_deliveries
+    USING (user_id = app_current_user_id())
+    WITH CHECK (user_id = app_current_user_id());
+
+ALTER TABLE webhook_deliveries FORCE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS capture_secrets_user_policy ON capture_secrets;
+
+CREATE POLICY capture_secrets_owner_policy ON capture_secrets
+    USING (user_id = app_current_user_id())
+    WITH CHECK (user_id = app_current_user_id());
+
+ALTER TABLE capture_secrets FORCE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS calendar_feeds_user_policy ON calendar_feeds;
+
+CREATE POLICY calendar_feeds_owner_policy ON calendar_feeds
+    USING (user_id = app_current_user_id())
+    WITH CHECK (user_id = app_current_user_id());
+
+ALTER TABLE calendar_feeds FORCE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS app_passwords_user_policy ON app_passwords;
+
+CREATE POLICY app_passwords_owner_policy ON app_passwords
+    USING (user_id = app_current_user_id())
+    WITH CHECK (user_id = app_current_user_id());
+
+ALTER TABLE app_passwords FORCE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS account_deletions_user_policy ON account_deletions;
+
+CREATE POLICY account_deletions_owner_policy ON account_deletions
+    USING (user_id = app_current_user_id())
+    WITH CHECK (user_id = app_current_user_id());
+
+ALTER TABLE account_deletions FORCE ROW LEVEL SECURITY;
+
+-- Activity stays append-only: users read and add their own, and remove it
+-- only together with their account, but never change it
+DROP POLICY IF EXISTS activity_log_select_policy ON activity_log;
+DROP POLICY IF EXISTS activity_log_insert_policy ON activity_log;
+
+CREATE POLICY activity_log_owner_select_policy ON activity_log
+    FOR SELECT USING (actor_id = app_current_user_id());
+
+CREATE POLICY activity_log_owner_insert_policy ON activity_log
+    FOR INSERT WITH CHECK (actor_id = app_current_user_id());
+
+CREATE POLICY activity_log_owner_delete_policy ON activity_log
+    FOR DELETE USING (actor_id = app_current_user_id());
+
+ALTER TABLE activity_log FORCE ROW LEVEL SECURITY;
@@ -0,0 +1,10 @@
+// Package migrations embeds the versioned schema migrations of the Supabase
+// repository, applied by database.Migrator
+package migrations
+
+import "embed"
+
+// FS holds the migration files, NNN_name.up.sql and NNN_name.down.sql
+//
+//go:embed *.sql
+var FS embed.FS
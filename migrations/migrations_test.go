@@ -0,0 +1,59 @@
+package migrations
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/starbops/gottodo/pkg/database"
+)
+
+func TestMigrations(t *testing.T) {
+	migrations, err := database.LoadMigrations(FS)
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("Expected embedded migrations")
+	}
+
+	for i, migration := range migrations {
+		if migration.Version != int64(i+1) {
+			t.Errorf("Expected migration %d_%s to have version %d", migration.Version, migration.Name, i+1)
+		}
+		if migration.Down == "" {
+			t.Errorf("Expected migration %d_%s to have a down file", migration.Version, migration.Name)
+		}
+	}
+}
+
+func TestMigrations_PlainPostgres(t *testing.T) {
+	migrations, err := database.LoadMigrations(FS)
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	// auth.uid() only exists on Supabase, so every use of it must be skipped
+	// where it is missing for the migrations to apply to any Postgres
+	for _, migration := range migrations {
+		for direction, script := range map[string]string{"up": migration.Up, "down": migration.Down} {
+			for _, block := range strings.SplitAfter(stripComments(script), "END IF;") {
+				uses := strings.Index(block, "auth.uid()")
+				guard := strings.Index(block, "IF to_regprocedure('auth.uid()') IS NOT NULL THEN")
+				if uses >= 0 && (guard < 0 || guard > uses) {
+					t.Errorf("Expected migration %d_%s (%s) to use auth.uid() only where it exists", migration.Version, migration.Name, direction)
+				}
+			}
+		}
+	}
+}
+
+// stripComments removes the -- comments of a script
+func stripComments(script string) string {
+	lines := strings.Split(script, "\n")
+	for i, line := range lines {
+		if code, _, found := strings.Cut(line, "--"); found {
+			lines[i] = code
+		}
+	}
+	return strings.Join(lines, "\n")
+}
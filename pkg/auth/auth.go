@@ -24,9 +24,20 @@ type User struct {
 type Session struct {
 	Token     string    `json:"token"`
 	UserID    string    `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
 	ExpiresAt time.Time `json:"expires_at"`
 }
 
+// ReauthenticationWindow is how recently users without a password must have
+// logged in to confirm sensitive actions
+const ReauthenticationWindow = 10 * time.Minute
+
+// Authentication errors
+var (
+	ErrInvalidCredentials       = errors.New("invalid credentials")
+	ErrReauthenticationRequired = errors.New("log in again to confirm")
+)
+
 // OAuthState represents a state for OAuth flow
 type OAuthState struct {
 	State     string
@@ -95,20 +106,22 @@ func (s *AuthService) Login(ctx context.Context, email, password string) (*Sessi
 	s.mu.RUnlock()
 
 	if !exists {
-		return nil, errors.New("invalid credentials")
+		return nil, ErrInvalidCredentials
 	}
 
 	// Verify the password
 	err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
 	if err != nil {
-		return nil, errors.New("invalid credentials")
+		return nil, ErrInvalidCredentials
 	}
 
 	// Create a new session
+	now := time.Now()
 	session := &Session{
 		Token:     uuid.New().String(),
 		UserID:    user.ID,
-		ExpiresAt: time.Now().Add(24 * time.Hour),
+		CreatedAt: now,
+		ExpiresAt: now.Add(24 * time.Hour),
 	}
 
 	s.mu.Lock()
@@ -131,6 +144,50 @@ func (s *AuthService) Logout(ctx context.Context, token string) error {
 	return nil
 }
 
+// Reauthenticate confirms that the holder of a session is its user before a
+// sensitive action. Users with a password must enter it again; users who log
+// in with GitHub must have done so within the ReauthenticationWindow.
+func (s *AuthService) Reauthenticate(ctx context.Context, token, password string) error {
+	user, err := s.GetUser(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	if user.PasswordHash == "" {
+		s.mu.RLock()
+		session, ok := s.sessions[token]
+		s.mu.RUnlock()
+		// The session may have been revoked since GetUser looked at it
+		if !ok || time.Since(session.CreatedAt) > ReauthenticationWindow {
+			return ErrReauthenticationRequired
+		}
+		return nil
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return ErrInvalidCredentials
+	}
+	return nil
+}
+
+// DeleteUser removes a user together with all their sessions. Deleting a user
+// that does not exist is not an error.
+func (s *AuthService) DeleteUser(ctx context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for email, user := range s.users {
+		if user.ID == userID {
+			delete(s.users, email)
+		}
+	}
+	for token, session := range s.sessions {
+		if session.UserID == userID {
+			delete(s.sessions, token)
+		}
+	}
+	return nil
+}
+
 // GetUser returns the user associated with a session token
 func (s *AuthService) GetUser(ctx context.Context, token string) (*User, error) {
 	s.mu.RLock()
@@ -160,6 +217,18 @@ func (s *AuthService) GetUser(ctx context.Context, token string) (*User, error)
 	return nil, errors.New("user not found")
 }
 
+// GetUserByEmail returns the user registered with an email address
+func (s *AuthService) GetUserByEmail(ctx context.Context, email string) (*User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, exists := s.users[email]
+	if !exists {
+		return nil, errors.New("user not found")
+	}
+	return user, nil
+}
+
 // VerifyToken checks if a token is valid
 func (s *AuthService) VerifyToken(ctx context.Context, token string) (bool, error) {
 	s.mu.RLock()
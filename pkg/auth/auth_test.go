@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/starbops/gottodo/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthService_Reauthenticate(t *testing.T) {
+	authService := NewAuthService(&config.Config{})
+	ctx := context.Background()
+
+	_, err := authService.Register(ctx, "ann@example.com", "secret")
+	assert.NoError(t, err)
+	session, err := authService.Login(ctx, "ann@example.com", "secret")
+	assert.NoError(t, err)
+
+	// Users with a password must enter it
+	assert.NoError(t, authService.Reauthenticate(ctx, session.Token, "secret"))
+	assert.Equal(t, ErrInvalidCredentials, authService.Reauthenticate(ctx, session.Token, "wrong"))
+	assert.Error(t, authService.Reauthenticate(ctx, "unknown", "secret"))
+
+	// Users of GitHub must have logged in recently
+	githubSession, err := authService.CreateSessionFromGitHubUser(&GitHubUser{Email: "bob@example.com"})
+	assert.NoError(t, err)
+	assert.NoError(t, authService.Reauthenticate(ctx, githubSession.Token, ""))
+
+	authService.sessions[githubSession.Token].CreatedAt = time.Now().Add(-ReauthenticationWindow - time.Minute)
+	assert.Equal(t, ErrReauthenticationRequired, authService.Reauthenticate(ctx, githubSession.Token, ""))
+}
+
+func TestAuthService_DeleteUser(t *testing.T) {
+	authService := NewAuthService(&config.Config{})
+	ctx := context.Background()
+
+	user, err := authService.Register(ctx, "ann@example.com", "secret")
+	assert.NoError(t, err)
+	first, err := authService.Login(ctx, "ann@example.com", "secret")
+	assert.NoError(t, err)
+	second, err := authService.Login(ctx, "ann@example.com", "secret")
+	assert.NoError(t, err)
+	other, err := authService.CreateSessionFromGitHubUser(&GitHubUser{Email: "bob@example.com"})
+	assert.NoError(t, err)
+
+	assert.NoError(t, authService.DeleteUser(ctx, user.ID))
+
+	// Every session of the user ends, and the email address is free again
+	for _, session := range []*Session{first, second} {
+		valid, err := authService.VerifyToken(ctx, session.Token)
+		assert.NoError(t, err)
+		assert.False(t, valid)
+	}
+	_, err = authService.GetUserByEmail(ctx, "ann@example.com")
+	assert.Error(t, err)
+	_, err = authService.Register(ctx, "ann@example.com", "new secret")
+	assert.NoError(t, err)
+
+	valid, err := authService.VerifyToken(ctx, other.Token)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	// Deleting a deleted user does nothing
+	assert.NoError(t, authService.DeleteUser(ctx, user.ID))
+}
@@ -264,10 +264,12 @@ func (s *AuthService) CreateSessionFromGitHubUser(gitHubUser *GitHubUser) (*Sess
 	}
 
 	// Create a new session
+	now := time.Now()
 	session := &Session{
 		Token:     uuid.New().String(),
 		UserID:    user.ID,
-		ExpiresAt: time.Now().Add(24 * time.Hour),
+		CreatedAt: now,
+		ExpiresAt: now.Add(24 * time.Hour),
 	}
 
 	s.mu.Lock()
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/starbops/gottodo/internal/models"
+)
+
+// ActivityIterator pages through the user's activity feed, newest first.
+// Pages are fetched lazily as the iterator advances:
+//
+//	it := c.Activity(50)
+//	for it.Next(ctx) {
+//		fmt.Println(it.Activity().Action)
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type ActivityIterator struct {
+	client *Client
+	limit  int
+
+	page    []*models.Activity
+	index   int
+	offset  *int
+	current *models.Activity
+	err     error
+}
+
+// Activity returns an iterator over the activity feed fetching pageSize
+// entries per request. A pageSize of 0 uses the server default.
+func (c *Client) Activity(pageSize int) *ActivityIterator {
+	start := 0
+	return &ActivityIterator{
+		client: c,
+		limit:  pageSize,
+		offset: &start,
+	}
+}
+
+// Next advances to the next activity entry, fetching the next page when
+// needed. It returns false when the feed is exhausted or an error occurred.
+func (it *ActivityIterator) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+
+	for it.index >= len(it.page) {
+		if it.offset == nil {
+			it.current = nil
+			return false
+		}
+		if err := it.fetch(ctx); err != nil {
+			it.err = err
+			it.current = nil
+			return false
+		}
+	}
+
+	it.current = it.page[it.index]
+	it.index++
+	return true
+}
+
+// Activity returns the entry Next advanced to
+func (it *ActivityIterator) Activity() *models.Activity {
+	return it.current
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *ActivityIterator) Err() error {
+	return it.err
+}
+
+// fetch loads the page starting at the current offset
+func (it *ActivityIterator) fetch(ctx context.Context) error {
+	query := url.Values{"offset": {strconv.Itoa(*it.offset)}}
+	if it.limit > 0 {
+		query.Set("limit", strconv.Itoa(it.limit))
+	}
+
+	var page struct {
+		Items      []*models.Activity `json:"items"`
+		NextOffset *int               `json:"next_offset"`
+	}
+	_, err := it.client.do(ctx, &request{
+		method:    http.MethodGet,
+		path:      "/activity",
+		query:     query,
+		retryable: true,
+	}, &page)
+	if err != nil {
+		return err
+	}
+
+	it.page = page.Items
+	it.index = 0
+	it.offset = page.NextOffset
+	return nil
+}
@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/starbops/gottodo/pkg/auth"
+)
+
+// credentials is the body of registration and token requests
+type credentials struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// Register creates a new account
+func (c *Client) Register(ctx context.Context, email, password string) (*auth.User, error) {
+	var user auth.User
+	_, err := c.do(ctx, &request{
+		method: http.MethodPost,
+		path:   "/auth/register",
+		body:   credentials{Email: email, Password: password},
+	}, &user)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Login exchanges email and password for a session token, which is used to
+// authenticate all further requests of the client
+func (c *Client) Login(ctx context.Context, email, password string) (*auth.Session, error) {
+	var session auth.Session
+	_, err := c.do(ctx, &request{
+		method:    http.MethodPost,
+		path:      "/auth/token",
+		body:      credentials{Email: email, Password: password},
+		retryable: true,
+	}, &session)
+	if err != nil {
+		return nil, err
+	}
+
+	c.token = session.Token
+	return &session, nil
+}
+
+// Logout revokes the client's session token
+func (c *Client) Logout(ctx context.Context) error {
+	_, err := c.do(ctx, &request{
+		method: http.MethodPost,
+		path:   "/auth/logout",
+	}, nil)
+	if err != nil {
+		return err
+	}
+
+	c.token = ""
+	return nil
+}
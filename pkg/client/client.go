@@ -0,0 +1,219 @@
+// Package client is a typed Go client for the GotToDo HTTP API
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Default retry behaviour for requests failing with a server error
+const (
+	DefaultMaxRetries = 3
+	DefaultBackoff    = 200 * time.Millisecond
+	maxBackoff        = 5 * time.Second
+)
+
+// Client talks to a GotToDo server on behalf of a single user
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	token      string
+	maxRetries int
+	backoff    time.Duration
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sends requests through the given HTTP client
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithToken authenticates requests with an existing session token
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithRetries sets how often a request failing with a 5xx status or a
+// network error is retried, and the delay before the first retry. The delay
+// doubles with every further attempt.
+func WithRetries(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.backoff = backoff
+	}
+}
+
+// New creates a Client for the server at baseURL, e.g. http://localhost:8080
+func New(baseURL string, opts ...Option) (*Client, error) {
+	parsed, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q: scheme and host are required", baseURL)
+	}
+
+	c := &Client{
+		baseURL:    parsed,
+		httpClient: http.DefaultClient,
+		maxRetries: DefaultMaxRetries,
+		backoff:    DefaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// Token returns the session token used to authenticate requests
+func (c *Client) Token() string {
+	return c.token
+}
+
+// SetToken changes the session token used to authenticate requests
+func (c *Client) SetToken(token string) {
+	c.token = token
+}
+
+// request describes a single API call
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	body        interface{}
+	contentType string
+	header      http.Header
+
+	// retryable marks requests that are safe to send more than once
+	retryable bool
+
+	// notFound is the error a 404 response maps to
+	notFound error
+}
+
+// response is a successful API response
+type response struct {
+	status int
+	header http.Header
+}
+
+// do sends a request, retrying server errors for retryable requests, and
+// decodes a JSON response body into out when out is not nil
+func (c *Client) do(ctx context.Context, req *request, out interface{}) (*response, error) {
+	var body []byte
+	if req.body != nil {
+		var err error
+		body, err = json.Marshal(req.body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request body: %w", err)
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, req, body)
+
+		retry := req.retryable && attempt < c.maxRetries
+		if err != nil {
+			// Give up on cancelled contexts, retry other transport errors
+			if ctx.Err() != nil || !retry {
+				return nil, err
+			}
+		} else if resp.StatusCode >= http.StatusInternalServerError && retry {
+			resp.Body.Close()
+		} else {
+			return c.handle(resp, req, out)
+		}
+
+		if err := sleep(ctx, c.delay(attempt)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// send performs one HTTP round trip
+func (c *Client) send(ctx context.Context, req *request, body []byte) (*http.Response, error) {
+	target := *c.baseURL
+	target.Path += req.path
+	target.RawQuery = req.query.Encode()
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, target.String(), reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for name, values := range req.header {
+		httpReq.Header[name] = values
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	if body != nil {
+		contentType := req.contentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		httpReq.Header.Set("Content-Type", contentType)
+	}
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", req.method, req.path, err)
+	}
+	return resp, nil
+}
+
+// handle turns an HTTP response into a result or a typed error
+func (c *Client) handle(resp *http.Response, req *request, out interface{}) (*response, error) {
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, newAPIError(resp, req.notFound)
+	}
+
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return nil, fmt.Errorf("failed to decode %s %s response: %w", req.method, req.path, err)
+		}
+	}
+	return &response{status: resp.StatusCode, header: resp.Header}, nil
+}
+
+// delay returns how long to wait before the retry following an attempt
+func (c *Client) delay(attempt int) time.Duration {
+	delay := c.backoff << attempt
+	if delay > maxBackoff || delay <= 0 {
+		delay = maxBackoff
+	}
+	return delay
+}
+
+// sleep waits for the given duration unless the context ends first
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
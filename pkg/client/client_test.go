@@ -0,0 +1,283 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/starbops/gottodo/internal/handlers"
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
+	"github.com/starbops/gottodo/internal/services"
+	"github.com/starbops/gottodo/pkg/auth"
+	"github.com/starbops/gottodo/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRouter wires the real application router on top of memory repositories
+func newRouter(t *testing.T) *echo.Echo {
+	t.Helper()
+
+	cfg := config.DefaultConfig()
+	repos, err := repositories.NewRepositories(cfg)
+	require.NoError(t, err)
+
+	todoService := services.NewTodoService(repos.Todos, services.WithActivityRepository(repos.Activity))
+	undoService := services.NewUndoService(todoService, repos.Undo, time.Minute)
+	idempotencyService := services.NewIdempotencyService(repos.Idempotency, time.Hour)
+	authService := auth.NewAuthService(cfg)
+
+	e := echo.New()
+	handlers.RegisterRoutes(e, &handlers.Routes{
+		Todo:        handlers.NewTodoHandler(todoService, undoService),
+		Page:        handlers.NewPageHandler(todoService, undoService, authService),
+		Auth:        handlers.NewAuthHandler(authService),
+		Activity:    handlers.NewActivityHandler(todoService),
+		Docs:        handlers.NewDocsHandler(),
+		Idempotency: idempotencyService,
+	})
+	return e
+}
+
+// newLoggedInClient registers a user against the server and logs in
+func newLoggedInClient(t *testing.T, serverURL string, opts ...Option) *Client {
+	t.Helper()
+	ctx := context.Background()
+
+	c, err := New(serverURL, opts...)
+	require.NoError(t, err)
+
+	_, err = c.Register(ctx, "user@example.com", "secret")
+	require.NoError(t, err)
+	session, err := c.Login(ctx, "user@example.com", "secret")
+	require.NoError(t, err)
+	require.Equal(t, session.Token, c.Token())
+
+	return c
+}
+
+func TestClient_Todos(t *testing.T) {
+	server := httptest.NewServer(newRouter(t))
+	defer server.Close()
+
+	c := newLoggedInClient(t, server.URL)
+	ctx := context.Background()
+
+	// Create and read back
+	todo, err := c.CreateTodo(ctx, "Buy milk", "Two liters")
+	require.NoError(t, err)
+	assert.NotEmpty(t, todo.ID)
+	assert.Equal(t, 1, todo.Version)
+
+	fetched, err := c.GetTodo(ctx, todo.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Buy milk", fetched.Title)
+
+	todos, err := c.ListTodos(ctx)
+	require.NoError(t, err)
+	assert.Len(t, todos, 1)
+
+	// Update with the current version
+	fetched.Title = "Buy oat milk"
+	require.NoError(t, c.UpdateTodo(ctx, fetched))
+	assert.Equal(t, 2, fetched.Version)
+
+	// A stale version is reported as a conflict
+	stale := *todo
+	stale.Title = "Stale edit"
+	err = c.UpdateTodo(ctx, &stale)
+	assert.ErrorIs(t, err, repositories.ErrConflict)
+
+	// Partial update
+	description := "One liter"
+	patched, err := c.PatchTodo(ctx, todo.ID, &models.TodoPatch{Description: &description}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "Buy oat milk", patched.Title)
+	assert.Equal(t, "One liter", patched.Description)
+
+	// Planning fields are patched too, and a nil due date removes it
+	due := time.Date(2025, time.March, 14, 9, 0, 0, 0, time.UTC)
+	dueAt, tags := &due, []string{"groceries"}
+	patched, err = c.PatchTodo(ctx, todo.ID, &models.TodoPatch{DueAt: &dueAt, Tags: &tags}, 0)
+	require.NoError(t, err)
+	require.NotNil(t, patched.DueAt)
+	assert.True(t, due.Equal(*patched.DueAt))
+	assert.Equal(t, tags, patched.Tags)
+
+	dueAt = nil
+	patched, err = c.PatchTodo(ctx, todo.ID, &models.TodoPatch{DueAt: &dueAt}, 0)
+	require.NoError(t, err)
+	assert.Nil(t, patched.DueAt)
+	assert.Equal(t, tags, patched.Tags)
+
+	// Complete and undo
+	completed, undoToken, err := c.CompleteTodo(ctx, todo.ID)
+	require.NoError(t, err)
+	assert.True(t, completed.Completed)
+	assert.NotEmpty(t, undoToken)
+
+	restored, err := c.Undo(ctx, undoToken)
+	require.NoError(t, err)
+	assert.False(t, restored.Completed)
+
+	_, err = c.Undo(ctx, undoToken)
+	assert.ErrorIs(t, err, repositories.ErrUndoTokenNotFound)
+
+	// Delete maps missing todos to the repository error
+	_, err = c.DeleteTodo(ctx, todo.ID)
+	require.NoError(t, err)
+
+	_, err = c.GetTodo(ctx, todo.ID)
+	assert.ErrorIs(t, err, repositories.ErrTodoNotFound)
+
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+}
+
+func TestClient_BulkUpdate(t *testing.T) {
+	server := httptest.NewServer(newRouter(t))
+	defer server.Close()
+
+	c := newLoggedInClient(t, server.URL)
+	ctx := context.Background()
+
+	first, err := c.CreateTodo(ctx, "First", "")
+	require.NoError(t, err)
+	second, err := c.CreateTodo(ctx, "Second", "")
+	require.NoError(t, err)
+
+	results, err := c.BulkUpdate(ctx, []string{first.ID, second.ID, "missing"}, BulkComplete, "")
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.True(t, results[0].OK)
+	assert.True(t, results[1].OK)
+	assert.False(t, results[2].OK)
+	assert.True(t, results[0].Todo.Completed)
+
+	results, err = c.BulkUpdate(ctx, []string{first.ID, second.ID}, BulkAddTag, "errand")
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, []string{"errand"}, results[1].Todo.Tags)
+
+	results, err = c.BulkUpdate(ctx, []string{first.ID}, BulkMoveToProject, "home")
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "home", results[0].Todo.Project)
+}
+
+func TestClient_Auth(t *testing.T) {
+	server := httptest.NewServer(newRouter(t))
+	defer server.Close()
+	ctx := context.Background()
+
+	// Requests without a token are rejected rather than redirected
+	c, err := New(server.URL)
+	require.NoError(t, err)
+	_, err = c.ListTodos(ctx)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	// Wrong credentials
+	_, err = c.Register(ctx, "user@example.com", "secret")
+	require.NoError(t, err)
+	_, err = c.Login(ctx, "user@example.com", "wrong")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	// Logging out revokes the token
+	_, err = c.Login(ctx, "user@example.com", "secret")
+	require.NoError(t, err)
+	token := c.Token()
+	require.NoError(t, c.Logout(ctx))
+	assert.Empty(t, c.Token())
+
+	c.SetToken(token)
+	_, err = c.ListTodos(ctx)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+}
+
+func TestClient_Activity(t *testing.T) {
+	server := httptest.NewServer(newRouter(t))
+	defer server.Close()
+
+	c := newLoggedInClient(t, server.URL)
+	ctx := context.Background()
+
+	for _, title := range []string{"One", "Two", "Three", "Four", "Five"} {
+		_, err := c.CreateTodo(ctx, title, "")
+		require.NoError(t, err)
+	}
+
+	// Iterate across several pages
+	var titles []interface{}
+	it := c.Activity(2)
+	for it.Next(ctx) {
+		assert.Equal(t, models.ActivityCreated, it.Activity().Action)
+		for _, change := range it.Activity().Changes {
+			if change.Field == "title" {
+				titles = append(titles, change.After)
+			}
+		}
+	}
+	require.NoError(t, it.Err())
+	assert.Equal(t, []interface{}{"Five", "Four", "Three", "Two", "One"}, titles)
+}
+
+func TestClient_RetriesServerErrors(t *testing.T) {
+	router := newRouter(t)
+
+	// Fail the first attempt of every create after it reached the server,
+	// as if the response had been lost on the way back
+	var creates int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.URL.Path == "/todos" && atomic.AddInt32(&creates, 1) == 1 {
+			router.ServeHTTP(httptest.NewRecorder(), r)
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		router.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	c := newLoggedInClient(t, server.URL, WithRetries(2, time.Millisecond))
+	ctx := context.Background()
+
+	todo, err := c.CreateTodo(ctx, "Only once", "")
+	require.NoError(t, err)
+	assert.Equal(t, "Only once", todo.Title)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&creates))
+
+	// The idempotency key kept the retry from creating a duplicate
+	todos, err := c.ListTodos(ctx)
+	require.NoError(t, err)
+	assert.Len(t, todos, 1)
+}
+
+func TestClient_GivesUpAfterRetries(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	c, err := New(server.URL, WithToken("token"), WithRetries(2, time.Millisecond))
+	require.NoError(t, err)
+
+	_, err = c.ListTodos(context.Background())
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+
+	// A cancelled context stops the retries
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = c.ListTodos(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,73 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/starbops/gottodo/internal/repositories"
+	"github.com/starbops/gottodo/internal/services"
+)
+
+// ErrUnauthorized is returned when the client is not logged in or its
+// session has expired
+var ErrUnauthorized = errors.New("unauthorized")
+
+// APIError is returned for every error response of the server. It unwraps to
+// the matching repository or service error, so callers can use errors.Is with
+// errors like repositories.ErrTodoNotFound.
+type APIError struct {
+	// StatusCode is the HTTP status of the response
+	StatusCode int
+
+	// Message is the error reported by the server
+	Message string
+
+	kind error
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	return fmt.Sprintf("gottodo: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// Unwrap returns the repository or service error the response stands for
+func (e *APIError) Unwrap() error {
+	return e.kind
+}
+
+// newAPIError reads an error response, mapping 404 responses to notFound
+func newAPIError(resp *http.Response, notFound error) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	var payload struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(body, &payload) == nil && payload.Error != "" {
+		apiErr.Message = payload.Error
+	} else {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		apiErr.kind = ErrUnauthorized
+	case http.StatusNotFound:
+		apiErr.kind = notFound
+	case http.StatusConflict, http.StatusPreconditionFailed:
+		apiErr.kind = repositories.ErrConflict
+		if apiErr.Message == services.ErrIdempotencyKeyInUse.Error() {
+			apiErr.kind = services.ErrIdempotencyKeyInUse
+		}
+	case http.StatusGone:
+		apiErr.kind = services.ErrUndoExpired
+	case http.StatusUnprocessableEntity:
+		if apiErr.Message == services.ErrIdempotencyKeyReused.Error() {
+			apiErr.kind = services.ErrIdempotencyKeyReused
+		}
+	}
+	return apiErr
+}
@@ -0,0 +1,209 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/starbops/gottodo/internal/models"
+	"github.com/starbops/gottodo/internal/repositories"
+)
+
+// BulkAction identifies the change applied by BulkUpdate
+type BulkAction string
+
+// Actions supported by BulkUpdate
+const (
+	BulkComplete   BulkAction = "complete"
+	BulkIncomplete BulkAction = "incomplete"
+	BulkDelete     BulkAction = "delete"
+
+	// The value of BulkUpdate is the project, or no project if empty
+	BulkMoveToProject BulkAction = "move_to_project"
+
+	// The value of BulkUpdate is the tag
+	BulkAddTag    BulkAction = "add_tag"
+	BulkRemoveTag BulkAction = "remove_tag"
+)
+
+// BulkResult reports the outcome of a bulk action for a single todo
+type BulkResult struct {
+	ID    string       `json:"id"`
+	OK    bool         `json:"ok"`
+	Error string       `json:"error,omitempty"`
+	Todo  *models.Todo `json:"todo,omitempty"`
+}
+
+// ListTodos retrieves the user's todos outside the trash
+func (c *Client) ListTodos(ctx context.Context) ([]*models.Todo, error) {
+	var todos []*models.Todo
+	_, err := c.do(ctx, &request{
+		method:    http.MethodGet,
+		path:      "/todos",
+		retryable: true,
+	}, &todos)
+	return todos, err
+}
+
+// GetTodo retrieves a single todo
+func (c *Client) GetTodo(ctx context.Context, id string) (*models.Todo, error) {
+	var todo models.Todo
+	_, err := c.do(ctx, &request{
+		method:    http.MethodGet,
+		path:      todoPath(id),
+		retryable: true,
+		notFound:  repositories.ErrTodoNotFound,
+	}, &todo)
+	if err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
+// CreateTodo creates a new todo. The request carries a generated
+// Idempotency-Key, so retries never create the todo twice.
+func (c *Client) CreateTodo(ctx context.Context, title, description string) (*models.Todo, error) {
+	var todo models.Todo
+	_, err := c.do(ctx, &request{
+		method: http.MethodPost,
+		path:   "/todos",
+		body: map[string]string{
+			"title":       title,
+			"description": description,
+		},
+		header:    http.Header{"Idempotency-Key": {uuid.New().String()}},
+		retryable: true,
+	}, &todo)
+	if err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
+// UpdateTodo saves the title and description of a todo. When todo.Version is
+// set, the update fails with repositories.ErrConflict if the todo was changed
+// in the meantime. The todo is updated in place with the stored state.
+func (c *Client) UpdateTodo(ctx context.Context, todo *models.Todo) error {
+	_, err := c.do(ctx, &request{
+		method: http.MethodPut,
+		path:   todoPath(todo.ID),
+		body: map[string]string{
+			"title":       todo.Title,
+			"description": todo.Description,
+		},
+		header:    ifMatch(todo.Version),
+		retryable: true,
+		notFound:  repositories.ErrTodoNotFound,
+	}, todo)
+	return err
+}
+
+// PatchTodo changes only the fields set in the patch. A non-zero version
+// makes the change conditional on the todo still having that version.
+func (c *Client) PatchTodo(ctx context.Context, id string, patch *models.TodoPatch, version int) (*models.Todo, error) {
+	var todo models.Todo
+	_, err := c.do(ctx, &request{
+		method:      http.MethodPatch,
+		path:        todoPath(id),
+		body:        patch,
+		contentType: "application/merge-patch+json",
+		header:      ifMatch(version),
+		retryable:   true,
+		notFound:    repositories.ErrTodoNotFound,
+	}, &todo)
+	if err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
+// CompleteTodo marks a todo as completed. It returns the updated todo and the
+// token that reverts the change through Undo.
+func (c *Client) CompleteTodo(ctx context.Context, id string) (*models.Todo, string, error) {
+	return c.setStatus(ctx, id, "complete")
+}
+
+// ReopenTodo marks a todo as incomplete. It returns the updated todo and the
+// token that reverts the change through Undo.
+func (c *Client) ReopenTodo(ctx context.Context, id string) (*models.Todo, string, error) {
+	return c.setStatus(ctx, id, "incomplete")
+}
+
+// setStatus changes the completed status of a todo
+func (c *Client) setStatus(ctx context.Context, id, status string) (*models.Todo, string, error) {
+	var todo models.Todo
+	resp, err := c.do(ctx, &request{
+		method:    http.MethodPut,
+		path:      todoPath(id) + "/" + status,
+		retryable: true,
+		notFound:  repositories.ErrTodoNotFound,
+	}, &todo)
+	if err != nil {
+		return nil, "", err
+	}
+	return &todo, resp.header.Get("Undo-Token"), nil
+}
+
+// DeleteTodo moves a todo to the trash and returns the token that restores
+// it through Undo
+func (c *Client) DeleteTodo(ctx context.Context, id string) (string, error) {
+	resp, err := c.do(ctx, &request{
+		method:   http.MethodDelete,
+		path:     todoPath(id),
+		notFound: repositories.ErrTodoNotFound,
+	}, nil)
+	if err != nil {
+		return "", err
+	}
+	return resp.header.Get("Undo-Token"), nil
+}
+
+// Undo reverts the action an undo token was issued for and returns the
+// restored todo
+func (c *Client) Undo(ctx context.Context, token string) (*models.Todo, error) {
+	var todo models.Todo
+	_, err := c.do(ctx, &request{
+		method:   http.MethodPost,
+		path:     "/undo/" + url.PathEscape(token),
+		notFound: repositories.ErrUndoTokenNotFound,
+	}, &todo)
+	if err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
+// BulkUpdate applies one action to several todos at once and reports the
+// outcome per todo. value is the project or tag of the actions that take one.
+func (c *Client) BulkUpdate(ctx context.Context, ids []string, action BulkAction, value string) ([]BulkResult, error) {
+	var result struct {
+		Results []BulkResult `json:"results"`
+	}
+	_, err := c.do(ctx, &request{
+		method: http.MethodPost,
+		path:   "/todos/bulk",
+		body: map[string]interface{}{
+			"ids":    ids,
+			"action": action,
+			"value":  value,
+		},
+		// A repeated delete would report the already trashed todos as missing
+		retryable: action != BulkDelete,
+	}, &result)
+	return result.Results, err
+}
+
+// todoPath returns the path of a single todo
+func todoPath(id string) string {
+	return "/todos/" + url.PathEscape(id)
+}
+
+// ifMatch makes a request conditional on a todo version
+func ifMatch(version int) http.Header {
+	if version == 0 {
+		return nil
+	}
+	return http.Header{"If-Match": {`"` + strconv.Itoa(version) + `"`}}
+}
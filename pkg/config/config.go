@@ -29,6 +29,15 @@ type Config struct {
 	Server struct {
 		// Port is the port number the server will listen on
 		Port string `json:"port"`
+
+		// BaseURL is the URL clients reach the server at, such as
+		// https://todo.example.com. Capture, calendar and CalDAV URLs shown
+		// to users are built on it rather than on the Host of a request.
+		BaseURL string `json:"base_url"`
+
+		// BehindProxy trusts the X-Forwarded-For header set by a reverse
+		// proxy on a private network to tell client addresses
+		BehindProxy bool `json:"behind_proxy"`
 	} `json:"server"`
 
 	// Database configuration
@@ -41,6 +50,15 @@ type Config struct {
 
 		// SupabaseDBURL is the PostgreSQL connection string for Supabase
 		SupabaseDBURL string `json:"supabase_db_url"`
+
+		// SystemDBURL is the connection string of the background jobs and
+		// of the lookups of capture and calendar feed secrets, which work
+		// across users and so need a role that bypasses row-level security.
+		// Empty means they share SupabaseDBURL.
+		SystemDBURL string `json:"system_db_url,omitempty"`
+
+		// AutoMigrate applies pending schema migrations when the server starts
+		AutoMigrate bool `json:"auto_migrate"`
 	} `json:"database"`
 
 	// Authentication configuration
@@ -54,6 +72,78 @@ type Config struct {
 		// GitHubRedirectURL is the callback URL for GitHub OAuth
 		GitHubRedirectURL string `json:"github_redirect_url"`
 	} `json:"auth"`
+
+	// Trash configuration
+	Trash struct {
+		// RetentionDays is how long deleted todos stay in the trash before
+		// they are purged permanently (0 keeps them until the trash is emptied)
+		RetentionDays int `json:"retention_days"`
+	} `json:"trash"`
+
+	// Undo configuration
+	Undo struct {
+		// WindowSeconds is how long a deleted or completed todo can be undone
+		WindowSeconds int `json:"window_seconds"`
+	} `json:"undo"`
+
+	// Idempotency configuration
+	Idempotency struct {
+		// TTLHours is how long responses to requests with an Idempotency-Key
+		// header are kept for replay
+		TTLHours int `json:"ttl_hours"`
+	} `json:"idempotency"`
+
+	// Live update configuration
+	Events struct {
+		// HeartbeatSeconds is how often idle event streams send a keep-alive
+		HeartbeatSeconds int `json:"heartbeat_seconds"`
+
+		// BufferSize is how many events may queue up for a single connection
+		// before the slow connection is dropped
+		BufferSize int `json:"buffer_size"`
+
+		// ReplaySize is how many recent events are kept per user for
+		// reconnecting clients
+		ReplaySize int `json:"replay_size"`
+
+		// ReplayMinutes is how long recent events are kept; users without
+		// connections are forgotten once theirs have expired
+		ReplayMinutes int `json:"replay_minutes"`
+	} `json:"events"`
+
+	// Webhook configuration
+	Webhooks struct {
+		// MaxAttempts is how often a delivery is attempted before it is
+		// given up as dead
+		MaxAttempts int `json:"max_attempts"`
+
+		// BackoffSeconds is the delay before the first retry of a failed
+		// delivery; it doubles with every further failure
+		BackoffSeconds int `json:"backoff_seconds"`
+
+		// TimeoutSeconds is how long an endpoint may take to respond
+		TimeoutSeconds int `json:"timeout_seconds"`
+
+		// PollSeconds is how often the delivery worker looks for due retries
+		PollSeconds int `json:"poll_seconds"`
+	} `json:"webhooks"`
+
+	// Quick capture configuration
+	Capture struct {
+		// RequestsPerMinute is how many captures a client address may send
+		// per minute
+		RequestsPerMinute int `json:"requests_per_minute"`
+
+		// Burst is how many captures a client address may send at once
+		Burst int `json:"burst"`
+	} `json:"capture"`
+
+	// Account configuration
+	Account struct {
+		// DeletionGraceDays is how long a deleted account can still be
+		// restored before its data is removed (0 removes it at once)
+		DeletionGraceDays int `json:"deletion_grace_days"`
+	} `json:"account"`
 }
 
 // DefaultConfig returns the default configuration
@@ -63,12 +153,41 @@ func DefaultConfig() *Config {
 	// Set default repository type to memory
 	cfg.Repository.Type = MemoryRepository
 
-	// Set default server port
+	// Set default server port and URL
 	cfg.Server.Port = "8080"
+	cfg.Server.BaseURL = "http://localhost:8080"
 
 	// Set default GitHub redirect URL
 	cfg.Auth.GitHubRedirectURL = "http://localhost:8080/auth/github/callback"
 
+	// Keep deleted todos in the trash for 30 days
+	cfg.Trash.RetentionDays = 30
+
+	// Allow undoing destructive actions for 15 seconds
+	cfg.Undo.WindowSeconds = 15
+
+	// Replay retried create requests for a day
+	cfg.Idempotency.TTLHours = 24
+
+	// Keep event streams alive through proxies and allow short reconnects
+	cfg.Events.HeartbeatSeconds = 15
+	cfg.Events.BufferSize = 64
+	cfg.Events.ReplaySize = 256
+	cfg.Events.ReplayMinutes = 10
+
+	// Retry failed webhook deliveries for about an hour
+	cfg.Webhooks.MaxAttempts = 8
+	cfg.Webhooks.BackoffSeconds = 30
+	cfg.Webhooks.TimeoutSeconds = 10
+	cfg.Webhooks.PollSeconds = 5
+
+	// Let capture clients send short bursts but not floods
+	cfg.Capture.RequestsPerMinute = 30
+	cfg.Capture.Burst = 10
+
+	// Give users two weeks to change their mind about deleting their account
+	cfg.Account.DeletionGraceDays = 14
+
 	return cfg
 }
 
@@ -136,6 +255,14 @@ func (c *Config) GetSupabaseDBURL() string {
 	return c.Database.SupabaseDBURL
 }
 
+// GetSystemDBURL returns the database URL of the background jobs
+func (c *Config) GetSystemDBURL() string {
+	if c.Database.SystemDBURL != "" {
+		return c.Database.SystemDBURL
+	}
+	return c.Database.SupabaseDBURL
+}
+
 // GetGitHubOAuthConfig returns the GitHub OAuth configuration
 func (c *Config) GetGitHubOAuthConfig() (clientID, clientSecret, redirectURL string) {
 	return c.Auth.GitHubClientID, c.Auth.GitHubClientSecret, c.Auth.GitHubRedirectURL
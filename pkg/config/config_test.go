@@ -16,6 +16,41 @@ func TestDefaultConfig(t *testing.T) {
 	if cfg.Server.Port != "8080" {
 		t.Errorf("Expected default port to be 8080, got %s", cfg.Server.Port)
 	}
+
+	if cfg.Trash.RetentionDays != 30 {
+		t.Errorf("Expected default trash retention to be 30 days, got %d", cfg.Trash.RetentionDays)
+	}
+
+	if cfg.Undo.WindowSeconds != 15 {
+		t.Errorf("Expected default undo window to be 15 seconds, got %d", cfg.Undo.WindowSeconds)
+	}
+
+	if cfg.Idempotency.TTLHours != 24 {
+		t.Errorf("Expected default idempotency TTL to be 24 hours, got %d", cfg.Idempotency.TTLHours)
+	}
+
+	if cfg.Events.HeartbeatSeconds != 15 {
+		t.Errorf("Expected default event heartbeat to be 15 seconds, got %d", cfg.Events.HeartbeatSeconds)
+	}
+
+	if cfg.Webhooks.MaxAttempts != 8 {
+		t.Errorf("Expected default webhook max attempts to be 8, got %d", cfg.Webhooks.MaxAttempts)
+	}
+}
+
+func TestGetSystemDBURL(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Database.SupabaseDBURL = "postgres://gottodo@localhost/gottodo"
+
+	// The background jobs share the server's connection unless told otherwise
+	if url := cfg.GetSystemDBURL(); url != cfg.Database.SupabaseDBURL {
+		t.Errorf("Expected the Supabase database URL, got %s", url)
+	}
+
+	cfg.Database.SystemDBURL = "postgres://gottodo_system@localhost/gottodo"
+	if url := cfg.GetSystemDBURL(); url != cfg.Database.SystemDBURL {
+		t.Errorf("Expected the system database URL, got %s", url)
+	}
 }
 
 func TestLoadConfig(t *testing.T) {
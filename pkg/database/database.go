@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
@@ -22,6 +23,18 @@ func NewSupabaseClient(cfg *config.Config) (*SupabaseClient, error) {
 		return nil, fmt.Errorf("Supabase database URL is not configured")
 	}
 
+	db, err := Connect(dbURL)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("Connected to Supabase PostgreSQL database")
+	return &SupabaseClient{DB: db}, nil
+}
+
+// Connect opens a pool of connections to a PostgreSQL database and checks
+// that it can be reached
+func Connect(dbURL string) (*sql.DB, error) {
 	// Open a connection to the database
 	db, err := sql.Open("postgres", dbURL)
 	if err != nil {
@@ -38,9 +51,18 @@ func NewSupabaseClient(cfg *config.Config) (*SupabaseClient, error) {
 		db.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
+	return db, nil
+}
 
-	log.Println("Connected to Supabase PostgreSQL database")
-	return &SupabaseClient{DB: db}, nil
+// BypassesRowLevelSecurity reports whether the role db connects as skips
+// row-level security policies, as superusers and roles with BYPASSRLS do
+func BypassesRowLevelSecurity(ctx context.Context, db *sql.DB) (bool, error) {
+	var bypasses bool
+	err := db.QueryRowContext(ctx, `SELECT rolsuper OR rolbypassrls FROM pg_roles WHERE rolname = current_user`).Scan(&bypasses)
+	if err != nil {
+		return false, fmt.Errorf("failed to read the role: %w", err)
+	}
+	return bypasses, nil
 }
 
 // ExecWithLogging is a helper function that logs SQL queries and their parameters before execution
@@ -1,8 +1,12 @@
 package database
 
 import (
+	"context"
+	"regexp"
 	"testing"
 
+	"github.com/DATA-DOG/go-sqlmock"
+
 	"github.com/starbops/gottodo/pkg/config"
 )
 
@@ -53,3 +57,28 @@ func TestConnectToSupabase_NoDBURL(t *testing.T) {
 // Note: We're not testing actual database connections here since that would require
 // a real database. In a more comprehensive test suite, you might want to use
 // a test database or a mock.
+
+func TestBypassesRowLevelSecurity(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	query := regexp.QuoteMeta(`SELECT rolsuper OR rolbypassrls FROM pg_roles WHERE rolname = current_user`)
+	mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"bypasses"}).AddRow(true))
+	mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"bypasses"}).AddRow(false))
+
+	for _, want := range []bool{true, false} {
+		bypasses, err := BypassesRowLevelSecurity(context.Background(), db)
+		if err != nil {
+			t.Fatalf("Failed to read the role: %v", err)
+		}
+		if bypasses != want {
+			t.Errorf("Expected %v, got %v", want, bypasses)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
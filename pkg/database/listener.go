@@ -0,0 +1,130 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// DefaultMinReconnectInterval is the first delay before reconnecting a
+	// dropped listener connection
+	DefaultMinReconnectInterval = time.Second
+
+	// DefaultMaxReconnectInterval caps the delay between reconnection attempts
+	DefaultMaxReconnectInterval = time.Minute
+
+	// listenerPingInterval is how often an idle listener checks its connection
+	listenerPingInterval = 90 * time.Second
+)
+
+// NotificationHandler handles the payload of a notification
+type NotificationHandler func(ctx context.Context, payload string)
+
+// notificationSource is the part of pq.Listener a Listener depends on
+type notificationSource interface {
+	Listen(channel string) error
+	NotificationChannel() <-chan *pq.Notification
+	Ping() error
+	Close() error
+}
+
+// Listener receives the notifications sent with NOTIFY or pg_notify on a
+// channel. The connection is re-established with exponential backoff when
+// it drops; since notifications sent in the meantime are lost, the
+// reconnect handler is called once the listener is back.
+type Listener struct {
+	channel              string
+	handler              NotificationHandler
+	onReconnect          func()
+	minReconnectInterval time.Duration
+	maxReconnectInterval time.Duration
+
+	// connect opens the notification connection
+	connect func() notificationSource
+}
+
+// ListenerOption configures a Listener
+type ListenerOption func(*Listener)
+
+// WithReconnectInterval sets the delays between reconnection attempts, which
+// double after each failure from min up to max
+func WithReconnectInterval(min, max time.Duration) ListenerOption {
+	return func(l *Listener) {
+		l.minReconnectInterval = min
+		l.maxReconnectInterval = max
+	}
+}
+
+// WithReconnectHandler sets a function called after a dropped connection was
+// re-established
+func WithReconnectHandler(onReconnect func()) ListenerOption {
+	return func(l *Listener) {
+		l.onReconnect = onReconnect
+	}
+}
+
+// NewListener creates a Listener for a channel of the database at dbURL
+func NewListener(dbURL, channel string, handler NotificationHandler, opts ...ListenerOption) *Listener {
+	l := &Listener{
+		channel:              channel,
+		handler:              handler,
+		minReconnectInterval: DefaultMinReconnectInterval,
+		maxReconnectInterval: DefaultMaxReconnectInterval,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	l.connect = func() notificationSource {
+		return pq.NewListener(dbURL, l.minReconnectInterval, l.maxReconnectInterval, l.logEvent)
+	}
+	return l
+}
+
+// Run listens for notifications until the context is cancelled
+func (l *Listener) Run(ctx context.Context) error {
+	source := l.connect()
+	defer source.Close()
+
+	if err := source.Listen(l.channel); err != nil {
+		return fmt.Errorf("failed to listen on channel %s: %w", l.channel, err)
+	}
+	log.Printf("Listening for notifications on channel %s", l.channel)
+
+	ticker := time.NewTicker(listenerPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case notification := <-source.NotificationChannel():
+			// A nil notification follows a reconnect
+			if notification == nil {
+				if l.onReconnect != nil {
+					l.onReconnect()
+				}
+				continue
+			}
+			l.handler(ctx, notification.Extra)
+		case <-ticker.C:
+			// Detect connections that died without the server noticing
+			go source.Ping()
+		}
+	}
+}
+
+// logEvent logs changes of the connection state
+func (l *Listener) logEvent(event pq.ListenerEventType, err error) {
+	switch event {
+	case pq.ListenerEventDisconnected:
+		log.Printf("Lost notification connection for channel %s: %v", l.channel, err)
+	case pq.ListenerEventReconnected:
+		log.Printf("Reconnected notification connection for channel %s", l.channel)
+	case pq.ListenerEventConnectionAttemptFailed:
+		log.Printf("Failed to connect notification connection for channel %s: %v", l.channel, err)
+	}
+}
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// fakeSource delivers notifications from a test instead of a database
+type fakeSource struct {
+	notifications chan *pq.Notification
+	listened      string
+	listenErr     error
+	closed        chan struct{}
+}
+
+func newFakeSource() *fakeSource {
+	return &fakeSource{
+		notifications: make(chan *pq.Notification),
+		closed:        make(chan struct{}),
+	}
+}
+
+func (s *fakeSource) Listen(channel string) error {
+	s.listened = channel
+	return s.listenErr
+}
+
+func (s *fakeSource) NotificationChannel() <-chan *pq.Notification {
+	return s.notifications
+}
+
+func (s *fakeSource) Ping() error {
+	return nil
+}
+
+func (s *fakeSource) Close() error {
+	close(s.closed)
+	return nil
+}
+
+func TestListener_Run(t *testing.T) {
+	source := newFakeSource()
+	payloads := make(chan string, 1)
+	reconnects := make(chan struct{}, 1)
+
+	listener := NewListener("postgres://unused", "todo_events",
+		func(ctx context.Context, payload string) { payloads <- payload },
+		WithReconnectHandler(func() { reconnects <- struct{}{} }),
+	)
+	listener.connect = func() notificationSource { return source }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- listener.Run(ctx) }()
+
+	// Payloads are handed to the handler
+	source.notifications <- &pq.Notification{Channel: "todo_events", Extra: `{"type": "created"}`}
+	if payload := <-payloads; payload != `{"type": "created"}` {
+		t.Errorf("Expected the notification payload, got %q", payload)
+	}
+	if source.listened != "todo_events" {
+		t.Errorf("Expected to listen on todo_events, got %q", source.listened)
+	}
+
+	// A nil notification signals a reconnect
+	source.notifications <- nil
+	select {
+	case <-reconnects:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the reconnect handler to be called")
+	}
+
+	// Cancelling stops the listener and closes the connection
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Expected no error after cancelling, got %v", err)
+	}
+	select {
+	case <-source.closed:
+	default:
+		t.Error("Expected the connection to be closed")
+	}
+}
+
+func TestListener_RunListenError(t *testing.T) {
+	source := newFakeSource()
+	source.listenErr = errors.New("permission denied")
+
+	listener := NewListener("postgres://unused", "todo_events", func(ctx context.Context, payload string) {})
+	listener.connect = func() notificationSource { return source }
+
+	if err := listener.Run(context.Background()); err == nil {
+		t.Error("Expected an error when LISTEN fails")
+	}
+}
@@ -0,0 +1,293 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// DefaultMigrationLockKey is the key of the advisory lock migrations run
+// under, so that instances starting together do not migrate at once
+const DefaultMigrationLockKey int64 = 0x676f74746f646f // "gottodo"
+
+// migrationFile matches the names of migration files, such as
+// 001_create_todos_table.up.sql and 001_create_todos_table.down.sql
+var migrationFile = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// ErrNoDownMigration is returned when rolling back a migration that has no
+// down file
+var ErrNoDownMigration = errors.New("migration cannot be rolled back")
+
+// Migration is a versioned change of the database schema
+type Migration struct {
+	Version int64
+	Name    string
+
+	// Up applies the migration
+	Up string
+
+	// Down reverts the migration, or is empty if it cannot be reverted
+	Down string
+}
+
+// MigrationStatus tells whether a migration has been applied
+type MigrationStatus struct {
+	Migration
+
+	// AppliedAt is when the migration was applied, or nil if it is pending
+	AppliedAt *time.Time
+}
+
+// LoadMigrations reads the migrations in the root of fsys, ordered by
+// version. Every migration needs an up file; the down file is optional.
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := migrationFile.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version %q: %w", match[1], err)
+		}
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		migration, exists := byVersion[version]
+		if !exists {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migrations %s and %s share version %d", migration.Name, match[2], version)
+		}
+		if match[3] == "up" {
+			migration.Up = string(data)
+		} else {
+			migration.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Migrator applies and reverts migrations, recording the applied versions in
+// the schema_migrations table. Each migration runs in its own transaction,
+// and all of them under a Postgres advisory lock.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	lockKey    int64
+}
+
+// MigratorOption configures a Migrator
+type MigratorOption func(*Migrator)
+
+// WithMigrationLockKey sets the key of the advisory lock migrations run under
+func WithMigrationLockKey(key int64) MigratorOption {
+	return func(m *Migrator) {
+		m.lockKey = key
+	}
+}
+
+// NewMigrator creates a new Migrator for the migrations in the root of fsys
+func NewMigrator(db *sql.DB, fsys fs.FS, opts ...MigratorOption) (*Migrator, error) {
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Migrator{
+		db:         db,
+		migrations: migrations,
+		lockKey:    DefaultMigrationLockKey,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m, nil
+}
+
+// Up applies all pending migrations in order and returns how many it applied
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	count := 0
+	err := m.withLock(ctx, func(conn *sql.Conn, applied map[int64]time.Time) error {
+		for _, migration := range m.migrations {
+			if _, done := applied[migration.Version]; done {
+				continue
+			}
+			log.Printf("Applying migration %d_%s", migration.Version, migration.Name)
+			err := inTx(ctx, conn, func(tx *sql.Tx) error {
+				if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", migration.Version, migration.Name)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("failed to apply migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Down reverts the last steps applied migrations, newest first, and returns
+// how many it reverted
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	count := 0
+	err := m.withLock(ctx, func(conn *sql.Conn, applied map[int64]time.Time) error {
+		for i := len(m.migrations) - 1; i >= 0 && count < steps; i-- {
+			migration := m.migrations[i]
+			if _, done := applied[migration.Version]; !done {
+				continue
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("%w: %d_%s", ErrNoDownMigration, migration.Version, migration.Name)
+			}
+			log.Printf("Reverting migration %d_%s", migration.Version, migration.Name)
+			err := inTx(ctx, conn, func(tx *sql.Tx) error {
+				if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = $1", migration.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("failed to revert migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Baseline records the migrations up to version as applied without running
+// them, for databases that were migrated by hand
+func (m *Migrator) Baseline(ctx context.Context, version int64) (int, error) {
+	count := 0
+	err := m.withLock(ctx, func(conn *sql.Conn, applied map[int64]time.Time) error {
+		for _, migration := range m.migrations {
+			if _, done := applied[migration.Version]; done || migration.Version > version {
+				continue
+			}
+			if _, err := conn.ExecContext(ctx, "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", migration.Version, migration.Name); err != nil {
+				return fmt.Errorf("failed to record migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Status lists every migration and when it was applied
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := m.withLock(ctx, func(conn *sql.Conn, applied map[int64]time.Time) error {
+		for _, migration := range m.migrations {
+			status := MigrationStatus{Migration: migration}
+			if appliedAt, done := applied[migration.Version]; done {
+				status.AppliedAt = &appliedAt
+			}
+			statuses = append(statuses, status)
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+// withLock runs fn on a single connection holding the advisory lock, with
+// the applied versions and when they were applied
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn, applied map[int64]time.Time) error) (err error) {
+	// Advisory locks belong to the session, so everything runs on one connection
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get a database connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", m.lockKey); err != nil {
+		return fmt.Errorf("failed to acquire the migration lock: %w", err)
+	}
+	defer func() {
+		// A cancelled context must not keep the lock held on a pooled connection
+		if _, unlockErr := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", m.lockKey); unlockErr != nil && err == nil {
+			err = fmt.Errorf("failed to release the migration lock: %w", unlockErr)
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+	)`); err != nil {
+		return fmt.Errorf("failed to create the schema_migrations table: %w", err)
+	}
+
+	applied, err := appliedMigrations(ctx, conn)
+	if err != nil {
+		return err
+	}
+	return fn(conn, applied)
+}
+
+// appliedMigrations returns the versions recorded in schema_migrations
+func appliedMigrations(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// inTx runs fn in a transaction on conn, committing it if fn succeeds
+func inTx(ctx context.Context, conn *sql.Conn, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
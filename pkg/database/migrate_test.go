@@ -0,0 +1,171 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// testMigrations are two migrations, the second of which cannot be reverted
+var testMigrations = fstest.MapFS{
+	"001_create_items.up.sql":   {Data: []byte("CREATE TABLE items (id INT);")},
+	"001_create_items.down.sql": {Data: []byte("DROP TABLE items;")},
+	"002_add_name.up.sql":       {Data: []byte("ALTER TABLE items ADD COLUMN name TEXT;")},
+	"README.md":                 {Data: []byte("Not a migration")},
+}
+
+// expectLock expects the migration lock to be taken and the applied versions
+// to be read
+func expectLock(mock sqlmock.Sqlmock, applied ...int64) {
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_lock($1)")).WithArgs(DefaultMigrationLockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE IF NOT EXISTS schema_migrations")).WillReturnResult(sqlmock.NewResult(0, 0))
+	rows := sqlmock.NewRows([]string{"version", "applied_at"})
+	for _, version := range applied {
+		rows.AddRow(version, time.Now())
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT version, applied_at FROM schema_migrations")).WillReturnRows(rows)
+}
+
+// expectUnlock expects the migration lock to be released
+func expectUnlock(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_unlock($1)")).WithArgs(DefaultMigrationLockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func newTestMigrator(t *testing.T) (*Migrator, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := NewMigrator(db, testMigrations)
+	if err != nil {
+		t.Fatalf("Failed to create migrator: %v", err)
+	}
+	return migrator, mock
+}
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := LoadMigrations(testMigrations)
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	if len(migrations) != 2 || migrations[0].Name != "create_items" || migrations[1].Version != 2 {
+		t.Fatalf("Expected both migrations in order, got %+v", migrations)
+	}
+	if migrations[0].Down != "DROP TABLE items;" || migrations[1].Down != "" {
+		t.Errorf("Expected only the first migration to have a down file, got %+v", migrations)
+	}
+
+	for name, fsys := range map[string]fstest.MapFS{
+		"missing up file": {"001_create_items.down.sql": {Data: []byte("DROP TABLE items;")}},
+		"shared version": {
+			"001_create_items.up.sql": {Data: []byte("CREATE TABLE items (id INT);")},
+			"001_create_users.up.sql": {Data: []byte("CREATE TABLE users (id INT);")},
+		},
+	} {
+		if _, err := LoadMigrations(fsys); err == nil {
+			t.Errorf("Expected an error for a %s", name)
+		}
+	}
+}
+
+func TestMigrator_Up(t *testing.T) {
+	migrator, mock := newTestMigrator(t)
+
+	// Only the pending migration runs, together with its record
+	expectLock(mock, 1)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("ALTER TABLE items ADD COLUMN name TEXT;")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO schema_migrations (version, name) VALUES ($1, $2)")).
+		WithArgs(int64(2), "add_name").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectUnlock(mock)
+
+	count, err := migrator.Up(context.Background())
+	if err != nil || count != 1 {
+		t.Fatalf("Expected one migration to be applied, got %d (%v)", count, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestMigrator_Up_Failure(t *testing.T) {
+	migrator, mock := newTestMigrator(t)
+
+	// A failed migration is rolled back and stops the later ones, and the
+	// lock is released all the same
+	expectLock(mock)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE items (id INT);")).WillReturnError(errors.New("syntax error"))
+	mock.ExpectRollback()
+	expectUnlock(mock)
+
+	count, err := migrator.Up(context.Background())
+	if err == nil || count != 0 {
+		t.Fatalf("Expected the migration to fail, got %d (%v)", count, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestMigrator_Down(t *testing.T) {
+	migrator, mock := newTestMigrator(t)
+
+	expectLock(mock, 1)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("DROP TABLE items;")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM schema_migrations WHERE version = $1")).
+		WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectUnlock(mock)
+
+	count, err := migrator.Down(context.Background(), 1)
+	if err != nil || count != 1 {
+		t.Fatalf("Expected one migration to be reverted, got %d (%v)", count, err)
+	}
+
+	// Migrations without a down file cannot be reverted
+	expectLock(mock, 1, 2)
+	expectUnlock(mock)
+	if _, err := migrator.Down(context.Background(), 1); !errors.Is(err, ErrNoDownMigration) {
+		t.Errorf("Expected ErrNoDownMigration, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestMigrator_BaselineAndStatus(t *testing.T) {
+	migrator, mock := newTestMigrator(t)
+
+	expectLock(mock)
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO schema_migrations (version, name) VALUES ($1, $2)")).
+		WithArgs(int64(1), "create_items").WillReturnResult(sqlmock.NewResult(0, 1))
+	expectUnlock(mock)
+	count, err := migrator.Baseline(context.Background(), 1)
+	if err != nil || count != 1 {
+		t.Fatalf("Expected one migration to be recorded, got %d (%v)", count, err)
+	}
+
+	expectLock(mock, 1)
+	expectUnlock(mock)
+	statuses, err := migrator.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get status: %v", err)
+	}
+	if len(statuses) != 2 || statuses[0].AppliedAt == nil || statuses[1].AppliedAt != nil {
+		t.Errorf("Expected only the first migration to be applied, got %+v", statuses)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
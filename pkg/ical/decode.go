@@ -0,0 +1,262 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ErrNoCalendar is returned when the input holds no VCALENDAR
+var ErrNoCalendar = errors.New("ical: no VCALENDAR found")
+
+// Decoder reads calendars from an input stream
+type Decoder struct {
+	r *bufio.Reader
+}
+
+// NewDecoder creates a Decoder reading from r
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// Decode reads a calendar. Lines may end in CRLF or a bare LF, and
+// properties of the VCALENDAR other than PRODID and X-WR-CALNAME are dropped.
+func (d *Decoder) Decode() (*Calendar, error) {
+	lines, err := d.readLines()
+	if err != nil {
+		return nil, err
+	}
+
+	var cal *Calendar
+	// stack holds the components being read, innermost last
+	var stack []*Component
+	for i, line := range lines {
+		property, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("ical: line %d: %w", i+1, err)
+		}
+
+		switch {
+		case property.Name == "BEGIN":
+			name := strings.ToUpper(property.Value)
+			if cal == nil {
+				if name != "VCALENDAR" {
+					return nil, fmt.Errorf("ical: line %d: %s outside of VCALENDAR", i+1, name)
+				}
+				cal = &Calendar{}
+				continue
+			}
+			stack = append(stack, NewComponent(name))
+		case property.Name == "END":
+			name := strings.ToUpper(property.Value)
+			if len(stack) == 0 {
+				if cal == nil || name != "VCALENDAR" {
+					return nil, fmt.Errorf("ical: line %d: unexpected END:%s", i+1, name)
+				}
+				return cal, nil
+			}
+			component := stack[len(stack)-1]
+			if name != component.Name {
+				return nil, fmt.Errorf("ical: line %d: END:%s does not close %s", i+1, name, component.Name)
+			}
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				cal.Components = append(cal.Components, component)
+			} else {
+				parent := stack[len(stack)-1]
+				parent.Components = append(parent.Components, component)
+			}
+		case cal == nil:
+			return nil, fmt.Errorf("ical: line %d: %s outside of VCALENDAR", i+1, property.Name)
+		case len(stack) > 0:
+			component := stack[len(stack)-1]
+			component.Properties = append(component.Properties, property)
+		case property.Name == "PRODID":
+			cal.ProdID = property.Value
+		case property.Name == "X-WR-CALNAME":
+			cal.Name = property.Text()
+		}
+	}
+
+	if cal == nil {
+		return nil, ErrNoCalendar
+	}
+	return nil, errors.New("ical: VCALENDAR is not closed")
+}
+
+// readLines reads all content lines, unfolding continuation lines
+func (d *Decoder) readLines() ([]string, error) {
+	var lines []string
+	for {
+		line, err := d.r.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+		} else if line != "" {
+			lines = append(lines, line)
+		}
+
+		if errors.Is(err, io.EOF) {
+			return lines, nil
+		}
+	}
+}
+
+// parseLine splits a content line into the name, parameters and value of a
+// property
+func parseLine(line string) (Property, error) {
+	var property Property
+
+	end := strings.IndexAny(line, ";:")
+	if end <= 0 {
+		return property, errors.New("missing property name")
+	}
+	property.Name = strings.ToUpper(line[:end])
+
+	rest := line[end:]
+	for rest != "" && rest[0] == ';' {
+		rest = rest[1:]
+		eq := strings.IndexByte(rest, '=')
+		if eq <= 0 {
+			return property, fmt.Errorf("invalid parameter of %s", property.Name)
+		}
+		param := Param{Name: strings.ToUpper(rest[:eq])}
+		rest = rest[eq+1:]
+
+		// Quoted values may contain separators
+		var value strings.Builder
+		for rest != "" && rest[0] != ';' && rest[0] != ':' {
+			if rest[0] == '"' {
+				closing := strings.IndexByte(rest[1:], '"')
+				if closing < 0 {
+					return property, fmt.Errorf("unterminated quote in parameter of %s", property.Name)
+				}
+				value.WriteString(rest[1 : closing+1])
+				rest = rest[closing+2:]
+				continue
+			}
+			value.WriteByte(rest[0])
+			rest = rest[1:]
+		}
+		param.Value = value.String()
+		property.Params = append(property.Params, param)
+	}
+
+	if rest == "" || rest[0] != ':' {
+		return property, fmt.Errorf("missing value of %s", property.Name)
+	}
+	property.Value = rest[1:]
+	return property, nil
+}
+
+// Get returns the first property with the given name, or nil if the
+// component has none
+func (c *Component) Get(name string) *Property {
+	for i := range c.Properties {
+		if strings.EqualFold(c.Properties[i].Name, name) {
+			return &c.Properties[i]
+		}
+	}
+	return nil
+}
+
+// GetAll returns all properties with the given name
+func (c *Component) GetAll(name string) []Property {
+	var properties []Property
+	for _, property := range c.Properties {
+		if strings.EqualFold(property.Name, name) {
+			properties = append(properties, property)
+		}
+	}
+	return properties
+}
+
+// Param returns the value of a parameter, or "" if the property has none
+func (p *Property) Param(name string) string {
+	for _, param := range p.Params {
+		if strings.EqualFold(param.Name, name) {
+			return param.Value
+		}
+	}
+	return ""
+}
+
+// Text returns the value of a TEXT property
+func (p *Property) Text() string {
+	return UnescapeText(p.Value)
+}
+
+// TextList returns the values of a property holding several TEXT values
+func (p *Property) TextList() []string {
+	var texts []string
+	var current strings.Builder
+	for i := 0; i < len(p.Value); i++ {
+		switch ch := p.Value[i]; {
+		case ch == '\\' && i+1 < len(p.Value):
+			current.WriteByte(ch)
+			current.WriteByte(p.Value[i+1])
+			i++
+		case ch == ',':
+			texts = append(texts, UnescapeText(current.String()))
+			current.Reset()
+		default:
+			current.WriteByte(ch)
+		}
+	}
+	return append(texts, UnescapeText(current.String()))
+}
+
+// Time returns the value of a DATE or DATE-TIME property, and whether it is
+// a DATE. Dates are midnight UTC of their day. Date-times with a TZID are in
+// that time zone if it is known, and like floating date-times, in UTC
+// otherwise.
+func (p *Property) Time() (t time.Time, isDate bool, err error) {
+	if strings.EqualFold(p.Param("VALUE"), "DATE") || len(p.Value) == len("20060102") {
+		t, err = time.Parse("20060102", p.Value)
+		return t, true, err
+	}
+
+	if strings.HasSuffix(p.Value, "Z") {
+		t, err = time.Parse("20060102T150405Z", p.Value)
+		return t, false, err
+	}
+
+	loc := time.UTC
+	if tzid := p.Param("TZID"); tzid != "" {
+		if known, err := time.LoadLocation(strings.TrimPrefix(tzid, "/")); err == nil {
+			loc = known
+		}
+	}
+	t, err = time.ParseInLocation("20060102T150405", p.Value, loc)
+	return t, false, err
+}
+
+// UnescapeText reverses EscapeText
+func UnescapeText(text string) string {
+	if !strings.Contains(text, `\`) {
+		return text
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] != '\\' || i+1 == len(text) {
+			b.WriteByte(text[i])
+			continue
+		}
+		i++
+		switch text[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(text[i])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,157 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecode_RoundTrip(t *testing.T) {
+	due := time.Date(2025, time.March, 14, 0, 0, 0, 0, time.UTC)
+	alarm := NewComponent("VALARM").Add("ACTION", "DISPLAY").Add("TRIGGER", "-PT15M")
+	todo := NewComponent("VTODO").
+		AddText("UID", "todo-1").
+		AddText("SUMMARY", "Eggs, milk; bread").
+		AddText("DESCRIPTION", strings.Repeat("Långt ", 30)+"\nslut").
+		AddTextList("CATEGORIES", []string{"home", "a,b"}).
+		AddDate("DUE", due).
+		Add("X-NOTE", "x", Param{Name: "LABEL", Value: "a:b;c"})
+	todo.Components = append(todo.Components, alarm)
+	cal := &Calendar{ProdID: "-//test//EN", Name: "Mine", Components: []*Component{todo}}
+
+	var buf bytes.Buffer
+	require.NoError(t, NewEncoder(&buf).Encode(cal))
+
+	decoded, err := NewDecoder(&buf).Decode()
+	require.NoError(t, err)
+	assert.Equal(t, "-//test//EN", decoded.ProdID)
+	assert.Equal(t, "Mine", decoded.Name)
+	require.Len(t, decoded.Components, 1)
+
+	got := decoded.Components[0]
+	assert.Equal(t, "VTODO", got.Name)
+	assert.Equal(t, "Eggs, milk; bread", got.Get("summary").Text())
+	assert.Equal(t, strings.Repeat("Långt ", 30)+"\nslut", got.Get("DESCRIPTION").Text())
+	assert.Equal(t, []string{"home", "a,b"}, got.Get("CATEGORIES").TextList())
+	assert.Equal(t, "a:b;c", got.Get("X-NOTE").Param("label"))
+
+	dueAt, isDate, err := got.Get("DUE").Time()
+	require.NoError(t, err)
+	assert.True(t, isDate)
+	assert.True(t, due.Equal(dueAt))
+
+	require.Len(t, got.Components, 1)
+	assert.Equal(t, "-PT15M", got.Components[0].Get("TRIGGER").Value)
+}
+
+func TestDecode_LenientInput(t *testing.T) {
+	// Bare LF line endings, lower-case names and tab continuation lines
+	input := "begin:vcalendar\nprodid:-//x//EN\nBEGIN:VTODO\nSUMMARY:Water\n\tplants\nCATEGORIES:garden\nCATEGORIES:weekly\nEND:VTODO\nEND:VCALENDAR\n"
+
+	cal, err := NewDecoder(strings.NewReader(input)).Decode()
+	require.NoError(t, err)
+	require.Len(t, cal.Components, 1)
+	assert.Equal(t, "Waterplants", cal.Components[0].Get("SUMMARY").Text())
+	assert.Len(t, cal.Components[0].GetAll("CATEGORIES"), 2)
+	assert.Nil(t, cal.Components[0].Get("DUE"))
+}
+
+func TestDecode_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "empty", input: ""},
+		{name: "no calendar", input: "BEGIN:VTODO\r\nEND:VTODO\r\n"},
+		{name: "unclosed calendar", input: "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nEND:VTODO\r\n"},
+		{name: "mismatched end", input: "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"},
+		{name: "missing value", input: "BEGIN:VCALENDAR\r\nSUMMARY\r\nEND:VCALENDAR\r\n"},
+		{name: "unterminated quote", input: "BEGIN:VCALENDAR\r\nX-A;B=\"c:d\r\nEND:VCALENDAR\r\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewDecoder(strings.NewReader(tt.input)).Decode()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestProperty_Time(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		property Property
+		want     time.Time
+		wantDate bool
+		wantErr  bool
+	}{
+		{
+			name:     "UTC",
+			property: Property{Value: "20250315T160000Z"},
+			want:     time.Date(2025, time.March, 15, 16, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "date",
+			property: Property{Value: "20250315", Params: []Param{{Name: "VALUE", Value: "DATE"}}},
+			want:     time.Date(2025, time.March, 15, 0, 0, 0, 0, time.UTC),
+			wantDate: true,
+		},
+		{
+			name:     "time zone",
+			property: Property{Value: "20250315T170000", Params: []Param{{Name: "TZID", Value: "Europe/Berlin"}}},
+			want:     time.Date(2025, time.March, 15, 17, 0, 0, 0, berlin),
+		},
+		{
+			name:     "unknown time zone",
+			property: Property{Value: "20250315T170000", Params: []Param{{Name: "TZID", Value: "W. Europe Standard Time"}}},
+			want:     time.Date(2025, time.March, 15, 17, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "floating",
+			property: Property{Value: "20250315T170000"},
+			want:     time.Date(2025, time.March, 15, 17, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "invalid",
+			property: Property{Value: "tomorrow"},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, isDate, err := tt.property.Time()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(got), "expected %v, got %v", tt.want, got)
+			assert.Equal(t, tt.wantDate, isDate)
+		})
+	}
+}
+
+func TestUnescapeText(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Buy milk", "Buy milk"},
+		{`Eggs\, milk\; bread`, "Eggs, milk; bread"},
+		{`C:\\temp`, `C:\temp`},
+		{`First\nSecond\NThird`, "First\nSecond\nThird"},
+		{`Trailing\`, `Trailing\`},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, UnescapeText(tt.text), "unescaping %q", tt.text)
+	}
+}
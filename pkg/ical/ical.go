@@ -0,0 +1,221 @@
+// Package ical encodes and decodes calendars in the iCalendar format of
+// RFC 5545.
+//
+// A Calendar holds components such as VTODO and VEVENT, each a list of
+// properties. The helpers on Component take care of escaping text and
+// formatting dates; Encoder writes the content lines with CRLF endings,
+// folded at 75 octets without splitting UTF-8 characters. Decoder reads
+// them back, and the methods of Property interpret the values.
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ContentType is the media type of iCalendar data
+const ContentType = "text/calendar; charset=utf-8"
+
+// maxLineLength is the longest a content line may be, in octets, before it
+// has to be folded
+const maxLineLength = 75
+
+// Calendar is an iCalendar object
+type Calendar struct {
+	// ProdID identifies the product that created the calendar
+	ProdID string
+
+	// Name is shown by calendar apps subscribing to the calendar, if set
+	Name string
+
+	Components []*Component
+}
+
+// Component is a calendar component such as VTODO or VEVENT
+type Component struct {
+	Name       string
+	Properties []Property
+
+	// Components holds nested components, such as the VALARM of a VTODO
+	Components []*Component
+}
+
+// Property is a content line of a component. Value is written as it is, so
+// it must already be in the format of the property's value type.
+type Property struct {
+	Name   string
+	Params []Param
+	Value  string
+}
+
+// Param is a property parameter such as VALUE=DATE
+type Param struct {
+	Name  string
+	Value string
+}
+
+// NewComponent creates an empty component
+func NewComponent(name string) *Component {
+	return &Component{Name: name}
+}
+
+// Add adds a property with a value that needs no escaping, such as an RRULE
+func (c *Component) Add(name, value string, params ...Param) *Component {
+	c.Properties = append(c.Properties, Property{Name: name, Params: params, Value: value})
+	return c
+}
+
+// AddText adds a TEXT property, escaping the text
+func (c *Component) AddText(name, text string) *Component {
+	return c.Add(name, EscapeText(text))
+}
+
+// AddTextList adds a property holding several TEXT values, such as CATEGORIES
+func (c *Component) AddTextList(name string, texts []string) *Component {
+	escaped := make([]string, len(texts))
+	for i, text := range texts {
+		escaped[i] = EscapeText(text)
+	}
+	return c.Add(name, strings.Join(escaped, ","))
+}
+
+// AddDateTime adds a DATE-TIME property in UTC
+func (c *Component) AddDateTime(name string, t time.Time) *Component {
+	return c.Add(name, FormatDateTime(t))
+}
+
+// AddDate adds a DATE property for the calendar day of t
+func (c *Component) AddDate(name string, t time.Time) *Component {
+	return c.Add(name, FormatDate(t), Param{Name: "VALUE", Value: "DATE"})
+}
+
+// EscapeText escapes backslashes, semicolons, commas and line breaks in a
+// TEXT value
+func EscapeText(text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		switch ch := text[i]; ch {
+		case '\\', ';', ',':
+			b.WriteByte('\\')
+			b.WriteByte(ch)
+		case '\r':
+			if i+1 < len(text) && text[i+1] == '\n' {
+				continue
+			}
+			b.WriteString(`\n`)
+		case '\n':
+			b.WriteString(`\n`)
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	return b.String()
+}
+
+// FormatDateTime formats a DATE-TIME value in UTC
+func FormatDateTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// FormatDate formats a DATE value
+func FormatDate(t time.Time) string {
+	return t.Format("20060102")
+}
+
+// Encoder writes calendars to an output stream
+type Encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+// NewEncoder creates an Encoder writing to w
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: bufio.NewWriter(w)}
+}
+
+// Encode writes a calendar
+func (e *Encoder) Encode(cal *Calendar) error {
+	e.writeLine("BEGIN", nil, "VCALENDAR")
+	e.writeLine("VERSION", nil, "2.0")
+	e.writeLine("PRODID", nil, cal.ProdID)
+	e.writeLine("CALSCALE", nil, "GREGORIAN")
+	if cal.Name != "" {
+		e.writeLine("X-WR-CALNAME", nil, EscapeText(cal.Name))
+	}
+	for _, component := range cal.Components {
+		e.writeComponent(component)
+	}
+	e.writeLine("END", nil, "VCALENDAR")
+
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+// writeComponent writes a component with its properties
+func (e *Encoder) writeComponent(c *Component) {
+	e.writeLine("BEGIN", nil, c.Name)
+	for _, property := range c.Properties {
+		e.writeLine(property.Name, property.Params, property.Value)
+	}
+	for _, child := range c.Components {
+		e.writeComponent(child)
+	}
+	e.writeLine("END", nil, c.Name)
+}
+
+// writeLine writes a folded content line
+func (e *Encoder) writeLine(name string, params []Param, value string) {
+	if e.err != nil {
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString(name)
+	for _, param := range params {
+		b.WriteByte(';')
+		b.WriteString(param.Name)
+		b.WriteByte('=')
+		b.WriteString(quoteParamValue(param.Value))
+	}
+	b.WriteByte(':')
+	b.WriteString(value)
+
+	_, e.err = e.w.WriteString(fold(b.String()))
+}
+
+// fold splits a content line into lines of at most 75 octets, continuing
+// each with a single space, and terminates it with CRLF
+func fold(line string) string {
+	var b strings.Builder
+	limit := maxLineLength
+	for len(line) > limit {
+		cut := limit
+		// Never split a UTF-8 sequence
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// The leading space counts towards the continuation line
+		limit = maxLineLength - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+	return b.String()
+}
+
+// quoteParamValue quotes parameter values containing separators. Double
+// quotes cannot appear in parameter values at all and are dropped.
+func quoteParamValue(value string) string {
+	value = strings.ReplaceAll(value, `"`, "")
+	if strings.ContainsAny(value, ":;,") {
+		return `"` + value + `"`
+	}
+	return value
+}
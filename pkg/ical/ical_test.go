@@ -0,0 +1,175 @@
+package ical
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscapeText(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Buy milk", "Buy milk"},
+		{"Eggs, milk; bread", `Eggs\, milk\; bread`},
+		{`C:\temp`, `C:\\temp`},
+		{"First\nSecond", `First\nSecond`},
+		{"First\r\nSecond\rThird", `First\nSecond\nThird`},
+		{"", ""},
+		{"Grüße 👋", "Grüße 👋"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, EscapeText(tt.text), "escaping %q", tt.text)
+	}
+}
+
+func TestFormatDates(t *testing.T) {
+	taipei := time.FixedZone("UTC+8", 8*60*60)
+	ts := time.Date(2025, time.March, 14, 9, 30, 5, 0, taipei)
+
+	assert.Equal(t, "20250314T013005Z", FormatDateTime(ts))
+	assert.Equal(t, "20250314", FormatDate(ts))
+}
+
+func TestFold(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{
+			name: "short line",
+			line: "SUMMARY:Buy milk",
+			want: "SUMMARY:Buy milk\r\n",
+		},
+		{
+			name: "exactly 75 octets",
+			line: "SUMMARY:" + strings.Repeat("a", 67),
+			want: "SUMMARY:" + strings.Repeat("a", 67) + "\r\n",
+		},
+		{
+			name: "76 octets",
+			line: "SUMMARY:" + strings.Repeat("a", 68),
+			want: "SUMMARY:" + strings.Repeat("a", 67) + "\r\n a\r\n",
+		},
+		{
+			name: "several continuation lines",
+			line: strings.Repeat("b", 75+74+10),
+			want: strings.Repeat("b", 75) + "\r\n " + strings.Repeat("b", 74) + "\r\n " + strings.Repeat("b", 10) + "\r\n",
+		},
+		{
+			name: "multi-byte character at the fold",
+			// 74 octets followed by a two-octet character that would straddle the limit
+			line: strings.Repeat("c", 74) + "é" + "d",
+			want: strings.Repeat("c", 74) + "\r\n éd\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fold(tt.line)
+			assert.Equal(t, tt.want, got)
+			for _, line := range strings.Split(strings.TrimSuffix(got, "\r\n"), "\r\n") {
+				assert.LessOrEqual(t, len(line), maxLineLength)
+				assert.True(t, utf8.ValidString(line), "line %q splits a character", line)
+			}
+		})
+	}
+}
+
+func TestFold_LongUnicodeText(t *testing.T) {
+	line := "DESCRIPTION:" + strings.Repeat("日本語のテキスト", 30)
+	folded := fold(line)
+
+	var unfolded strings.Builder
+	for i, part := range strings.Split(strings.TrimSuffix(folded, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(part), maxLineLength)
+		assert.True(t, utf8.ValidString(part))
+		if i > 0 {
+			assert.True(t, strings.HasPrefix(part, " "))
+			part = part[1:]
+		}
+		unfolded.WriteString(part)
+	}
+	assert.Equal(t, line, unfolded.String())
+}
+
+func TestEncoder_Encode(t *testing.T) {
+	due := time.Date(2025, time.March, 14, 17, 0, 0, 0, time.UTC)
+
+	todo := NewComponent("VTODO").
+		Add("UID", "todo-1").
+		AddDateTime("DTSTAMP", due.Add(-24*time.Hour)).
+		AddText("SUMMARY", "Pay invoice, quickly").
+		AddDateTime("DUE", due).
+		Add("STATUS", "NEEDS-ACTION").
+		AddTextList("CATEGORIES", []string{"finance", "work,home"}).
+		Add("RRULE", "FREQ=MONTHLY")
+	event := NewComponent("VEVENT").
+		Add("UID", "todo-2").
+		AddDate("DTSTART", due).
+		Add("X-TEST", "value", Param{Name: "X-NOTE", Value: "a:b"}, Param{Name: "X-PLAIN", Value: `say "hi"`})
+
+	var buf bytes.Buffer
+	err := NewEncoder(&buf).Encode(&Calendar{
+		ProdID:     "-//gottodo//gottodo//EN",
+		Name:       "My todos; all",
+		Components: []*Component{todo, event},
+	})
+	assert.NoError(t, err)
+
+	want := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//gottodo//gottodo//EN",
+		"CALSCALE:GREGORIAN",
+		`X-WR-CALNAME:My todos\; all`,
+		"BEGIN:VTODO",
+		"UID:todo-1",
+		"DTSTAMP:20250313T170000Z",
+		`SUMMARY:Pay invoice\, quickly`,
+		"DUE:20250314T170000Z",
+		"STATUS:NEEDS-ACTION",
+		`CATEGORIES:finance,work\,home`,
+		"RRULE:FREQ=MONTHLY",
+		"END:VTODO",
+		"BEGIN:VEVENT",
+		"UID:todo-2",
+		"DTSTART;VALUE=DATE:20250314",
+		`X-TEST;X-NOTE="a:b";X-PLAIN=say hi:value`,
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+	assert.Equal(t, want, buf.String())
+}
+
+func TestEncoder_EmptyCalendar(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, NewEncoder(&buf).Encode(&Calendar{ProdID: "-//test//EN"}))
+	assert.Equal(t, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//test//EN\r\nCALSCALE:GREGORIAN\r\nEND:VCALENDAR\r\n", buf.String())
+}
+
+// failingWriter fails every write
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestEncoder_WriteError(t *testing.T) {
+	components := make([]*Component, 200)
+	for i := range components {
+		components[i] = NewComponent("VTODO").AddText("SUMMARY", strings.Repeat("x", 100))
+	}
+
+	err := NewEncoder(failingWriter{}).Encode(&Calendar{ProdID: "-//test//EN", Components: components})
+	assert.EqualError(t, err, "disk full")
+}
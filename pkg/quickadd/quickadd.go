@@ -0,0 +1,810 @@
+// Package quickadd parses the free text typed into the todo form, such as
+// "Pay invoice tomorrow 5pm #finance !high every month", into a title and
+// the due date, tags, priority, project and recurrence it mentions.
+//
+// The recognised syntax is:
+//
+//   - tags: #finance, #home/garden
+//   - project: +work
+//   - priority: !high, !medium, !low, their first letters, !1 to !3, !!! and !!
+//   - dates: today, tonight, tomorrow, day after tomorrow, monday, next friday,
+//     this weekend, next week, next month, next year, end of week, end of month,
+//     end of year, in 3 days, in 2 weeks, 2025-03-15, march 15, 15th march 2025,
+//     optionally introduced by "on", "by" or "due"
+//   - times: 5pm, 5:30 pm, 17:00, noon, optionally introduced by "at", and
+//     in 2 hours or in 30 minutes
+//   - recurrence: every day, every weekday, every weekend, every week,
+//     every other month, every 3 years, every monday and thursday, every mon,fri
+//
+// Text in double quotes is never interpreted. Whatever is not recognised
+// becomes the title. Parsing never fails: in the worst case the whole text
+// is the title.
+package quickadd
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Priorities a quick-add text can set
+const (
+	PriorityHigh   = "high"
+	PriorityMedium = "medium"
+	PriorityLow    = "low"
+)
+
+// Result is what Parse found in a quick-add text
+type Result struct {
+	// Title is the text that was not recognised as anything else
+	Title string `json:"title"`
+
+	// Due is when the todo is due, or nil. Dates without a time of day are
+	// returned as midnight UTC of that date, which stands for the whole day
+	// wherever the todo is looked at, like an iCalendar DATE value.
+	Due *time.Time `json:"due,omitempty"`
+
+	// Tags are the lowercased tags, in order of appearance and without duplicates
+	Tags []string `json:"tags,omitempty"`
+
+	// Priority is PriorityHigh, PriorityMedium, PriorityLow or empty
+	Priority string `json:"priority,omitempty"`
+
+	// Project is the project name as typed, or empty
+	Project string `json:"project,omitempty"`
+
+	// Recurrence is an iCalendar RRULE value such as "FREQ=WEEKLY;BYDAY=MO",
+	// or empty for todos that do not repeat
+	Recurrence string `json:"recurrence,omitempty"`
+}
+
+var (
+	// nameRe matches tag and project names, which need at least one letter
+	nameRe = regexp.MustCompile(`^[\p{L}\p{N}_/-]*\p{L}[\p{L}\p{N}_/-]*$`)
+
+	// clock12Re matches 12-hour times such as "5pm" or "11:30am"
+	clock12Re = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(am|pm)$`)
+
+	// clock24Re matches 24-hour times such as "17:00"
+	clock24Re = regexp.MustCompile(`^(\d{1,2}):(\d{2})$`)
+
+	// hourRe matches the hour of a 12-hour time followed by a separate "am" or "pm"
+	hourRe = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?$`)
+
+	// dayRe matches a day of the month such as "15" or "15th"
+	dayRe = regexp.MustCompile(`^(\d{1,2})(st|nd|rd|th)?$`)
+
+	// yearRe matches a four-digit year
+	yearRe = regexp.MustCompile(`^\d{4}$`)
+)
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday,
+	"wednesday": time.Wednesday, "thursday": time.Thursday, "friday": time.Friday,
+	"saturday": time.Saturday,
+}
+
+// weekdayAbbreviations are only recognised after "on", "by", "due", "next",
+// "this" and "every", since most of them are also ordinary words
+var weekdayAbbreviations = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "tues": time.Tuesday,
+	"wed": time.Wednesday, "thu": time.Thursday, "thur": time.Thursday,
+	"thurs": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+var months = map[string]time.Month{
+	"january": time.January, "jan": time.January, "february": time.February,
+	"feb": time.February, "march": time.March, "mar": time.March,
+	"april": time.April, "apr": time.April, "may": time.May, "june": time.June,
+	"jun": time.June, "july": time.July, "jul": time.July, "august": time.August,
+	"aug": time.August, "september": time.September, "sep": time.September,
+	"sept": time.September, "october": time.October, "oct": time.October,
+	"november": time.November, "nov": time.November, "december": time.December,
+	"dec": time.December,
+}
+
+var counts = map[string]int{
+	"a": 1, "an": 1, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5,
+	"six": 6, "seven": 7, "eight": 8, "nine": 9, "ten": 10, "eleven": 11,
+	"twelve": 12,
+}
+
+var units = map[string]string{
+	"minute": "minute", "minutes": "minute", "min": "minute", "mins": "minute",
+	"hour": "hour", "hours": "hour", "hr": "hour", "hrs": "hour",
+	"day": "day", "days": "day", "week": "week", "weeks": "week",
+	"month": "month", "months": "month", "year": "year", "years": "year",
+}
+
+var priorities = map[string]string{
+	"!high": PriorityHigh, "!h": PriorityHigh, "!1": PriorityHigh, "!!!": PriorityHigh,
+	"!medium": PriorityMedium, "!med": PriorityMedium, "!m": PriorityMedium,
+	"!2": PriorityMedium, "!!": PriorityMedium,
+	"!low": PriorityLow, "!l": PriorityLow, "!3": PriorityLow,
+}
+
+var rruleDays = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// token is a whitespace-separated word of the input, or a quoted phrase
+type token struct {
+	// text is the token as typed, without the quotes of a quoted phrase
+	text string
+
+	// raw is the text without trailing punctuation, and word its lowercase form
+	raw  string
+	word string
+
+	// literal marks quoted phrases, which are never interpreted
+	literal bool
+}
+
+// parser holds the state of a single Parse call
+type parser struct {
+	now    time.Time
+	tokens []token
+	result Result
+	title  []string
+
+	// date is the due day at local midnight, when one was given
+	date    time.Time
+	hasDate bool
+
+	// hour and minute are the time of day, when one was given
+	hour, minute int
+	hasTime      bool
+
+	// tonight asks for an evening time unless one is given
+	tonight bool
+
+	// byDay restricts the first occurrence of a weekly recurrence
+	byDay []time.Weekday
+}
+
+// Parse interprets a quick-add text relative to now, whose location is used
+// for all dates and times. The same text and reference time always give the
+// same result.
+func Parse(text string, now time.Time) Result {
+	p := &parser{now: now, tokens: tokenize(text)}
+
+	for i := 0; i < len(p.tokens); {
+		if n := p.match(i); n > 0 {
+			i += n
+			continue
+		}
+		p.title = append(p.title, p.tokens[i].text)
+		i++
+	}
+
+	p.result.Title = strings.Trim(strings.Join(p.title, " "), " ,;:-–—")
+	p.result.Due = p.due()
+	return p.result
+}
+
+// match recognises the phrase starting at token i and returns how many
+// tokens it spans, or 0 for title text
+func (p *parser) match(i int) int {
+	tok := p.tokens[i]
+	if tok.literal {
+		return 0
+	}
+
+	switch {
+	case strings.HasPrefix(tok.word, "#"):
+		return p.matchTag(tok)
+	case strings.HasPrefix(tok.word, "+"):
+		return p.matchProject(tok)
+	case strings.HasPrefix(tok.word, "!"):
+		return p.matchPriority(tok)
+	}
+
+	if n := p.matchRecurrence(i); n > 0 {
+		return n
+	}
+
+	switch tok.word {
+	case "on", "by", "due":
+		if n := p.matchDate(i+1, true); n > 0 {
+			return n + 1
+		}
+		return 0
+	case "at":
+		if n := p.matchTime(i + 1); n > 0 {
+			return n + 1
+		}
+		return 0
+	}
+
+	if n := p.matchDate(i, false); n > 0 {
+		return n
+	}
+	return p.matchTime(i)
+}
+
+// matchTag adds a tag such as #finance
+func (p *parser) matchTag(tok token) int {
+	name := tok.word[1:]
+	if !nameRe.MatchString(name) {
+		return 0
+	}
+	for _, tag := range p.result.Tags {
+		if tag == name {
+			return 1
+		}
+	}
+	p.result.Tags = append(p.result.Tags, name)
+	return 1
+}
+
+// matchProject sets the project from a name such as +work
+func (p *parser) matchProject(tok token) int {
+	name := tok.raw[1:]
+	if p.result.Project != "" || !nameRe.MatchString(name) {
+		return 0
+	}
+	p.result.Project = name
+	return 1
+}
+
+// matchPriority sets the priority from a marker such as !high
+func (p *parser) matchPriority(tok token) int {
+	priority, ok := priorities[tok.word]
+	if !ok || p.result.Priority != "" {
+		return 0
+	}
+	p.result.Priority = priority
+	return 1
+}
+
+// matchRecurrence recognises a phrase starting with "every"
+func (p *parser) matchRecurrence(i int) int {
+	if p.result.Recurrence != "" || p.word(i) != "every" {
+		return 0
+	}
+
+	next := p.word(i + 1)
+	switch next {
+	case "day":
+		p.result.Recurrence = rrule("DAILY", 1, nil)
+		return 2
+	case "weekday", "weekdays":
+		p.setWeekly([]time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday})
+		return 2
+	case "weekend":
+		p.setWeekly([]time.Weekday{time.Saturday, time.Sunday})
+		return 2
+	case "week", "month", "year":
+		p.result.Recurrence = rrule(frequency(next), 1, nil)
+		return 2
+	case "other":
+		if unit := units[p.word(i+2)]; unit == p.word(i+2) && frequency(unit) != "" {
+			p.result.Recurrence = rrule(frequency(unit), 2, nil)
+			return 3
+		}
+		return 0
+	}
+
+	if n, ok := count(next); ok {
+		if freq := frequency(units[p.word(i+2)]); freq != "" {
+			p.result.Recurrence = rrule(freq, n, nil)
+			return 3
+		}
+		return 0
+	}
+
+	days, n := p.weekdayList(i + 1)
+	if n == 0 {
+		return 0
+	}
+	p.setWeekly(days)
+	return n + 1
+}
+
+// weekdayList reads weekdays such as "monday and thursday" or "mon,fri"
+// starting at token i, returning them and the number of tokens they span
+func (p *parser) weekdayList(i int) ([]time.Weekday, int) {
+	var days []time.Weekday
+	n := 0
+	for j := i; j < len(p.tokens) && !p.tokens[j].literal; j++ {
+		word := p.word(j)
+		if word == "and" && len(days) > 0 {
+			if _, ok := weekdayOf(strings.Split(p.word(j+1), ",")[0], true); ok {
+				continue
+			}
+			break
+		}
+
+		parsed := true
+		var found []time.Weekday
+		for _, part := range strings.Split(word, ",") {
+			if part == "" {
+				continue
+			}
+			day, ok := weekdayOf(part, true)
+			if !ok {
+				parsed = false
+				break
+			}
+			found = append(found, day)
+		}
+		if !parsed || len(found) == 0 {
+			break
+		}
+		days = append(days, found...)
+		n = j - i + 1
+	}
+	return days, n
+}
+
+// setWeekly sets a weekly recurrence on the given days
+func (p *parser) setWeekly(days []time.Weekday) {
+	var sorted []time.Weekday
+	// Order the days from Monday to Sunday, without duplicates
+	for _, day := range []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday} {
+		for _, d := range days {
+			if d == day {
+				sorted = append(sorted, day)
+				break
+			}
+		}
+	}
+	p.byDay = sorted
+	p.result.Recurrence = rrule("WEEKLY", 1, sorted)
+}
+
+// matchDate recognises a date phrase starting at token i. Weekday
+// abbreviations are only accepted when introduced by a connecting word.
+func (p *parser) matchDate(i int, introduced bool) int {
+	if p.hasDate || i >= len(p.tokens) || p.tokens[i].literal {
+		return 0
+	}
+
+	today := p.today()
+	word := p.word(i)
+
+	switch word {
+	case "today":
+		p.setDate(today)
+		return 1
+	case "tonight":
+		p.setDate(today)
+		p.tonight = true
+		return 1
+	case "tomorrow", "tmr", "tmrw":
+		p.setDate(today.AddDate(0, 0, 1))
+		return 1
+	case "day":
+		if p.word(i+1) == "after" && p.word(i+2) == "tomorrow" {
+			p.setDate(today.AddDate(0, 0, 2))
+			return 3
+		}
+		return 0
+	case "eow":
+		p.setDate(endOfWeek(today))
+		return 1
+	case "eom":
+		p.setDate(endOfMonth(today))
+		return 1
+	case "next":
+		return p.matchNext(i, today)
+	case "this":
+		if p.word(i+1) == "weekend" {
+			p.setDate(thisWeekend(today))
+			return 2
+		}
+		if day, ok := weekdayOf(p.word(i+1), true); ok {
+			p.setDate(nextWeekday(today, day))
+			return 2
+		}
+		return 0
+	case "end":
+		if p.word(i+1) != "of" {
+			return 0
+		}
+		switch p.word(i + 2) {
+		case "week":
+			p.setDate(endOfWeek(today))
+		case "month":
+			p.setDate(endOfMonth(today))
+		case "year":
+			p.setDate(time.Date(today.Year(), time.December, 31, 0, 0, 0, 0, today.Location()))
+		default:
+			return 0
+		}
+		return 3
+	case "in":
+		return p.matchRelative(i, today)
+	}
+
+	if day, ok := weekdayOf(word, introduced); ok {
+		p.setDate(nextWeekday(today, day))
+		return 1
+	}
+
+	if date, err := time.ParseInLocation("2006-01-02", word, today.Location()); err == nil {
+		p.setDate(date)
+		return 1
+	}
+
+	return p.matchCalendarDate(i, today)
+}
+
+// matchNext recognises "next week", "next month", "next year" and "next friday"
+func (p *parser) matchNext(i int, today time.Time) int {
+	switch p.word(i + 1) {
+	case "week":
+		p.setDate(nextWeekday(today, time.Monday))
+	case "month":
+		p.setDate(time.Date(today.Year(), today.Month()+1, 1, 0, 0, 0, 0, today.Location()))
+	case "year":
+		p.setDate(time.Date(today.Year()+1, time.January, 1, 0, 0, 0, 0, today.Location()))
+	default:
+		day, ok := weekdayOf(p.word(i+1), true)
+		if !ok {
+			return 0
+		}
+		p.setDate(nextWeekday(today, day))
+	}
+	return 2
+}
+
+// matchRelative recognises "in 3 days", "in a week" or "in 2 hours"
+func (p *parser) matchRelative(i int, today time.Time) int {
+	n, ok := count(p.word(i + 1))
+	if !ok {
+		return 0
+	}
+
+	switch units[p.word(i+2)] {
+	case "minute":
+		p.setExact(p.now.Add(time.Duration(n) * time.Minute))
+	case "hour":
+		p.setExact(p.now.Add(time.Duration(n) * time.Hour))
+	case "day":
+		p.setDate(today.AddDate(0, 0, n))
+	case "week":
+		p.setDate(today.AddDate(0, 0, 7*n))
+	case "month":
+		p.setDate(addMonths(today, n))
+	case "year":
+		p.setDate(addMonths(today, 12*n))
+	default:
+		return 0
+	}
+	return 3
+}
+
+// matchCalendarDate recognises "march 15", "15th march" and either followed
+// by a year. Without a year the next such day, today included, is meant.
+func (p *parser) matchCalendarDate(i int, today time.Time) int {
+	var month time.Month
+	var day int
+
+	if m, ok := months[p.word(i)]; ok {
+		d, ok := dayOf(p.word(i + 1))
+		if !ok {
+			return 0
+		}
+		month, day = m, d
+	} else if d, ok := dayOf(p.word(i)); ok {
+		m, ok := months[p.word(i+1)]
+		if !ok {
+			return 0
+		}
+		month, day = m, d
+	} else {
+		return 0
+	}
+
+	if yearRe.MatchString(p.word(i + 2)) {
+		year, _ := strconv.Atoi(p.word(i + 2))
+		date, ok := calendarDate(year, month, day, today.Location())
+		if !ok {
+			return 0
+		}
+		p.setDate(date)
+		return 3
+	}
+
+	date, ok := calendarDate(today.Year(), month, day, today.Location())
+	if ok && date.Before(today) {
+		date, ok = calendarDate(today.Year()+1, month, day, today.Location())
+	}
+	if !ok {
+		return 0
+	}
+	p.setDate(date)
+	return 2
+}
+
+// matchTime recognises a time of day starting at token i
+func (p *parser) matchTime(i int) int {
+	if p.hasTime || i >= len(p.tokens) || p.tokens[i].literal {
+		return 0
+	}
+
+	word := p.word(i)
+	switch word {
+	case "noon", "midday":
+		p.setTime(12, 0)
+		return 1
+	}
+
+	if m := clock12Re.FindStringSubmatch(word); m != nil {
+		if hour, minute, ok := clock12(m[1], m[2], m[3]); ok {
+			p.setTime(hour, minute)
+			return 1
+		}
+		return 0
+	}
+
+	if m := hourRe.FindStringSubmatch(word); m != nil {
+		if meridiem := p.word(i + 1); meridiem == "am" || meridiem == "pm" {
+			if hour, minute, ok := clock12(m[1], m[2], meridiem); ok {
+				p.setTime(hour, minute)
+				return 2
+			}
+			return 0
+		}
+	}
+
+	if m := clock24Re.FindStringSubmatch(word); m != nil {
+		hour, _ := strconv.Atoi(m[1])
+		minute, _ := strconv.Atoi(m[2])
+		if hour > 23 || minute > 59 {
+			return 0
+		}
+		p.setTime(hour, minute)
+		return 1
+	}
+	return 0
+}
+
+func (p *parser) setDate(date time.Time) {
+	p.date = date
+	p.hasDate = true
+}
+
+func (p *parser) setTime(hour, minute int) {
+	p.hour, p.minute = hour, minute
+	p.hasTime = true
+}
+
+// setExact fixes both the date and the time, to the minute
+func (p *parser) setExact(t time.Time) {
+	t = t.Truncate(time.Minute)
+	p.setDate(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()))
+	p.setTime(t.Hour(), t.Minute())
+}
+
+// due combines the date, time and recurrence into the due time
+func (p *parser) due() *time.Time {
+	if p.tonight && !p.hasTime {
+		p.setTime(20, 0)
+	}
+
+	if p.hasDate {
+		if !p.hasTime {
+			return allDay(p.date)
+		}
+		due := time.Date(p.date.Year(), p.date.Month(), p.date.Day(), p.hour, p.minute, 0, 0, p.date.Location())
+		return &due
+	}
+
+	if !p.hasTime && p.result.Recurrence == "" {
+		return nil
+	}
+
+	// Without a date, the first matching day from today on is meant, skipping
+	// today when the time has already passed
+	today := p.today()
+	for offset := 0; offset <= 7; offset++ {
+		day := today.AddDate(0, 0, offset)
+		if !p.allowedDay(day.Weekday()) {
+			continue
+		}
+		if !p.hasTime {
+			return allDay(day)
+		}
+		due := time.Date(day.Year(), day.Month(), day.Day(), p.hour, p.minute, 0, 0, day.Location())
+		if due.After(p.now) {
+			return &due
+		}
+	}
+	return nil
+}
+
+// allDay returns the whole-day due time of a date
+func allDay(date time.Time) *time.Time {
+	due := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	return &due
+}
+
+// allowedDay reports whether a weekly recurrence may start on the day
+func (p *parser) allowedDay(day time.Weekday) bool {
+	if len(p.byDay) == 0 {
+		return true
+	}
+	for _, d := range p.byDay {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// today is the start of the reference day
+func (p *parser) today() time.Time {
+	return time.Date(p.now.Year(), p.now.Month(), p.now.Day(), 0, 0, 0, 0, p.now.Location())
+}
+
+// word returns the lowercase word of token i, or "" past the end and for
+// quoted phrases
+func (p *parser) word(i int) string {
+	if i >= len(p.tokens) || p.tokens[i].literal {
+		return ""
+	}
+	return p.tokens[i].word
+}
+
+// tokenize splits text into words, keeping double-quoted phrases together
+func tokenize(text string) []token {
+	var tokens []token
+	fields := strings.Fields(text)
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+
+		if strings.HasPrefix(field, `"`) {
+			// Find the end of the quoted phrase, if it is closed at all
+			for j := i; j < len(fields); j++ {
+				if (j > i || len(field) > 1) && strings.HasSuffix(fields[j], `"`) {
+					phrase := strings.Join(fields[i:j+1], " ")
+					if phrase = phrase[1 : len(phrase)-1]; phrase != "" {
+						tokens = append(tokens, token{text: phrase, literal: true})
+					}
+					i = j
+					field = ""
+					break
+				}
+			}
+			if field == "" {
+				continue
+			}
+		}
+
+		raw := strings.TrimRight(field, ",.;:?")
+		if !strings.HasPrefix(raw, "!") {
+			raw = strings.TrimRight(raw, "!")
+		}
+		tokens = append(tokens, token{text: field, raw: raw, word: strings.ToLower(raw)})
+	}
+	return tokens
+}
+
+// weekdayOf parses a weekday name, and its abbreviations if allowed
+func weekdayOf(word string, abbreviated bool) (time.Weekday, bool) {
+	if day, ok := weekdays[word]; ok {
+		return day, true
+	}
+	if abbreviated {
+		day, ok := weekdayAbbreviations[word]
+		return day, ok
+	}
+	return 0, false
+}
+
+// count parses a positive number written in digits or as a word
+func count(word string) (int, bool) {
+	if n, ok := counts[word]; ok {
+		return n, true
+	}
+	n, err := strconv.Atoi(word)
+	if err != nil || n < 1 || n > 999 {
+		return 0, false
+	}
+	return n, true
+}
+
+// dayOf parses a day of the month such as "15" or "15th"
+func dayOf(word string) (int, bool) {
+	m := dayRe.FindStringSubmatch(word)
+	if m == nil {
+		return 0, false
+	}
+	day, _ := strconv.Atoi(m[1])
+	return day, day >= 1 && day <= 31
+}
+
+// clock12 converts a 12-hour time to hours and minutes
+func clock12(hourText, minuteText, meridiem string) (int, int, bool) {
+	hour, _ := strconv.Atoi(hourText)
+	minute := 0
+	if minuteText != "" {
+		minute, _ = strconv.Atoi(minuteText)
+	}
+	if hour < 1 || hour > 12 || minute > 59 {
+		return 0, 0, false
+	}
+	hour %= 12
+	if meridiem == "pm" {
+		hour += 12
+	}
+	return hour, minute, true
+}
+
+// calendarDate builds a date, rejecting days the month does not have
+func calendarDate(year int, month time.Month, day int, loc *time.Location) (time.Time, bool) {
+	date := time.Date(year, month, day, 0, 0, 0, 0, loc)
+	return date, date.Day() == day
+}
+
+// nextWeekday returns the first given weekday after today
+func nextWeekday(today time.Time, day time.Weekday) time.Time {
+	days := (int(day) - int(today.Weekday()) + 7) % 7
+	if days == 0 {
+		days = 7
+	}
+	return today.AddDate(0, 0, days)
+}
+
+// thisWeekend returns the coming Saturday, or today during the weekend
+func thisWeekend(today time.Time) time.Time {
+	if today.Weekday() == time.Sunday {
+		return today
+	}
+	return today.AddDate(0, 0, int(time.Saturday-today.Weekday()))
+}
+
+// endOfWeek returns the coming Friday, today included
+func endOfWeek(today time.Time) time.Time {
+	return today.AddDate(0, 0, (int(time.Friday)-int(today.Weekday())+7)%7)
+}
+
+// endOfMonth returns the last day of today's month
+func endOfMonth(today time.Time) time.Time {
+	return time.Date(today.Year(), today.Month()+1, 0, 0, 0, 0, 0, today.Location())
+}
+
+// addMonths moves a date by whole months, keeping to the last day of shorter
+// months instead of overflowing into the next one
+func addMonths(date time.Time, n int) time.Time {
+	first := time.Date(date.Year(), date.Month()+time.Month(n), 1, 0, 0, 0, 0, date.Location())
+	last := endOfMonth(first).Day()
+	day := date.Day()
+	if day > last {
+		day = last
+	}
+	return time.Date(first.Year(), first.Month(), day, 0, 0, 0, 0, date.Location())
+}
+
+// frequency maps a unit to its RRULE frequency
+func frequency(unit string) string {
+	switch unit {
+	case "day":
+		return "DAILY"
+	case "week":
+		return "WEEKLY"
+	case "month":
+		return "MONTHLY"
+	case "year":
+		return "YEARLY"
+	}
+	return ""
+}
+
+// rrule formats an iCalendar recurrence rule
+func rrule(freq string, interval int, days []time.Weekday) string {
+	rule := "FREQ=" + freq
+	if interval > 1 {
+		rule += fmt.Sprintf(";INTERVAL=%d", interval)
+	}
+	if len(days) > 0 {
+		names := make([]string, len(days))
+		for i, day := range days {
+			names[i] = rruleDays[day]
+		}
+		rule += ";BYDAY=" + strings.Join(names, ",")
+	}
+	return rule
+}
@@ -0,0 +1,356 @@
+package quickadd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// now is the reference time of the tests: Wednesday, 12 March 2025, 10:00
+var now = time.Date(2025, time.March, 12, 10, 0, 0, 0, time.UTC)
+
+// at returns a pointer to a time on the given day of 2025 in the test location
+func at(month time.Month, day, hour, minute int) *time.Time {
+	t := time.Date(2025, month, day, hour, minute, 0, 0, time.UTC)
+	return &t
+}
+
+// inYear returns a pointer to the whole day of a date outside 2025
+func inYear(year int, month time.Month, day int) *time.Time {
+	t := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	return &t
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want Result
+	}{
+		{
+			name: "the example from the form",
+			text: "Pay invoice tomorrow 5pm #finance !high every month",
+			want: Result{
+				Title:      "Pay invoice",
+				Due:        at(time.March, 13, 17, 0),
+				Tags:       []string{"finance"},
+				Priority:   PriorityHigh,
+				Recurrence: "FREQ=MONTHLY",
+			},
+		},
+		{
+			name: "plain title",
+			text: "  Buy   milk ",
+			want: Result{Title: "Buy milk"},
+		},
+		{
+			name: "empty text",
+			text: "",
+			want: Result{},
+		},
+		{
+			name: "only metadata",
+			text: "tomorrow #home",
+			want: Result{Due: at(time.March, 13, 0, 0), Tags: []string{"home"}},
+		},
+
+		// Tags and projects
+		{
+			name: "several tags are lowercased and deduplicated",
+			text: "Plant roses #Garden #home/outside #garden",
+			want: Result{Title: "Plant roses", Tags: []string{"garden", "home/outside"}},
+		},
+		{
+			name: "tag with trailing punctuation",
+			text: "Water plants #garden, please",
+			want: Result{Title: "Water plants please", Tags: []string{"garden"}},
+		},
+		{
+			name: "number signs without letters stay in the title",
+			text: "Fix issue #42 and #",
+			want: Result{Title: "Fix issue #42 and #"},
+		},
+		{
+			name: "project keeps its case",
+			text: "Write report +QuarterlyReview",
+			want: Result{Title: "Write report", Project: "QuarterlyReview"},
+		},
+		{
+			name: "only the first project is used",
+			text: "Sync +work +home",
+			want: Result{Title: "Sync +home", Project: "work"},
+		},
+		{
+			name: "plus signs without letters stay in the title",
+			text: "Vote +1 on the proposal",
+			want: Result{Title: "Vote +1 on the proposal"},
+		},
+
+		// Priorities
+		{name: "high priority", text: "Call bank !high", want: Result{Title: "Call bank", Priority: PriorityHigh}},
+		{name: "high priority letter", text: "Call bank !H", want: Result{Title: "Call bank", Priority: PriorityHigh}},
+		{name: "high priority number", text: "Call bank !1", want: Result{Title: "Call bank", Priority: PriorityHigh}},
+		{name: "high priority marks", text: "Call bank !!!", want: Result{Title: "Call bank", Priority: PriorityHigh}},
+		{name: "medium priority", text: "!medium Call bank", want: Result{Title: "Call bank", Priority: PriorityMedium}},
+		{name: "medium priority short", text: "Call bank !med", want: Result{Title: "Call bank", Priority: PriorityMedium}},
+		{name: "medium priority marks", text: "Call bank !!", want: Result{Title: "Call bank", Priority: PriorityMedium}},
+		{name: "low priority", text: "Call bank !low", want: Result{Title: "Call bank", Priority: PriorityLow}},
+		{name: "low priority number", text: "Call bank !3", want: Result{Title: "Call bank", Priority: PriorityLow}},
+		{
+			name: "only the first priority is used",
+			text: "Call bank !low !high",
+			want: Result{Title: "Call bank !high", Priority: PriorityLow},
+		},
+		{
+			name: "unknown priorities stay in the title",
+			text: "Call bank !urgent !",
+			want: Result{Title: "Call bank !urgent !"},
+		},
+		{
+			name: "exclamation marks after words are not priorities",
+			text: "Ship it today!",
+			want: Result{Title: "Ship it", Due: at(time.March, 12, 0, 0)},
+		},
+
+		// Relative dates
+		{name: "today", text: "Stretch today", want: Result{Title: "Stretch", Due: at(time.March, 12, 0, 0)}},
+		{name: "tonight", text: "Stretch tonight", want: Result{Title: "Stretch", Due: at(time.March, 12, 20, 0)}},
+		{name: "tonight with a time", text: "Stretch tonight at 9pm", want: Result{Title: "Stretch", Due: at(time.March, 12, 21, 0)}},
+		{name: "tomorrow", text: "Stretch tomorrow", want: Result{Title: "Stretch", Due: at(time.March, 13, 0, 0)}},
+		{name: "tomorrow abbreviated", text: "Stretch tmrw", want: Result{Title: "Stretch", Due: at(time.March, 13, 0, 0)}},
+		{name: "day after tomorrow", text: "Stretch day after tomorrow", want: Result{Title: "Stretch", Due: at(time.March, 14, 0, 0)}},
+		{name: "weekday later this week", text: "Stretch friday", want: Result{Title: "Stretch", Due: at(time.March, 14, 0, 0)}},
+		{name: "weekday earlier in the week", text: "Stretch Monday", want: Result{Title: "Stretch", Due: at(time.March, 17, 0, 0)}},
+		{name: "same weekday is next week", text: "Stretch wednesday", want: Result{Title: "Stretch", Due: at(time.March, 19, 0, 0)}},
+		{name: "next weekday", text: "Stretch next friday", want: Result{Title: "Stretch", Due: at(time.March, 14, 0, 0)}},
+		{name: "this weekday abbreviated", text: "Stretch this thu", want: Result{Title: "Stretch", Due: at(time.March, 13, 0, 0)}},
+		{name: "on a weekday", text: "Stretch on sat", want: Result{Title: "Stretch", Due: at(time.March, 15, 0, 0)}},
+		{name: "by a weekday", text: "Stretch by tue", want: Result{Title: "Stretch", Due: at(time.March, 18, 0, 0)}},
+		{name: "due a day", text: "Stretch due tomorrow", want: Result{Title: "Stretch", Due: at(time.March, 13, 0, 0)}},
+		{
+			name: "bare weekday abbreviations are ordinary words",
+			text: "Buy sun cream",
+			want: Result{Title: "Buy sun cream"},
+		},
+		{
+			name: "connecting words without a date stay in the title",
+			text: "Decide on paint by color",
+			want: Result{Title: "Decide on paint by color"},
+		},
+		{name: "this weekend", text: "Hike this weekend", want: Result{Title: "Hike", Due: at(time.March, 15, 0, 0)}},
+		{name: "next week", text: "Hike next week", want: Result{Title: "Hike", Due: at(time.March, 17, 0, 0)}},
+		{name: "next month", text: "Hike next month", want: Result{Title: "Hike", Due: at(time.April, 1, 0, 0)}},
+		{name: "next year", text: "Hike next year", want: Result{Title: "Hike", Due: inYear(2026, time.January, 1)}},
+		{name: "end of week", text: "Report end of week", want: Result{Title: "Report", Due: at(time.March, 14, 0, 0)}},
+		{name: "end of week abbreviated", text: "Report eow", want: Result{Title: "Report", Due: at(time.March, 14, 0, 0)}},
+		{name: "end of month", text: "Report end of month", want: Result{Title: "Report", Due: at(time.March, 31, 0, 0)}},
+		{name: "end of month abbreviated", text: "Report eom", want: Result{Title: "Report", Due: at(time.March, 31, 0, 0)}},
+		{name: "end of year", text: "Report end of year", want: Result{Title: "Report", Due: at(time.December, 31, 0, 0)}},
+		{name: "in days", text: "Renew in 3 days", want: Result{Title: "Renew", Due: at(time.March, 15, 0, 0)}},
+		{name: "in a week", text: "Renew in a week", want: Result{Title: "Renew", Due: at(time.March, 19, 0, 0)}},
+		{name: "in weeks as a word", text: "Renew in two weeks", want: Result{Title: "Renew", Due: at(time.March, 26, 0, 0)}},
+		{name: "in months", text: "Renew in 2 months", want: Result{Title: "Renew", Due: at(time.May, 12, 0, 0)}},
+		{name: "in a year", text: "Renew in 1 year", want: Result{Title: "Renew", Due: inYear(2026, time.March, 12)}},
+		{name: "in hours", text: "Renew in 2 hours", want: Result{Title: "Renew", Due: at(time.March, 12, 12, 0)}},
+		{name: "in minutes", text: "Renew in 45 mins", want: Result{Title: "Renew", Due: at(time.March, 12, 10, 45)}},
+		{name: "in hours past midnight", text: "Renew in 16 hours", want: Result{Title: "Renew", Due: at(time.March, 13, 2, 0)}},
+		{
+			name: "in without an amount stays in the title",
+			text: "Put it in the box",
+			want: Result{Title: "Put it in the box"},
+		},
+
+		// Absolute dates
+		{name: "ISO date", text: "Taxes 2025-04-15", want: Result{Title: "Taxes", Due: at(time.April, 15, 0, 0)}},
+		{name: "month and day", text: "Taxes april 15", want: Result{Title: "Taxes", Due: at(time.April, 15, 0, 0)}},
+		{name: "day and month", text: "Taxes 15 Apr", want: Result{Title: "Taxes", Due: at(time.April, 15, 0, 0)}},
+		{name: "ordinal day", text: "Taxes on the 15th april", want: Result{Title: "Taxes on the", Due: at(time.April, 15, 0, 0)}},
+		{name: "ordinal day after month", text: "Taxes on April 15th", want: Result{Title: "Taxes", Due: at(time.April, 15, 0, 0)}},
+		{name: "with a year", text: "Taxes march 15, 2026", want: Result{Title: "Taxes", Due: inYear(2026, time.March, 15)}},
+		{name: "today by date", text: "Taxes march 12", want: Result{Title: "Taxes", Due: at(time.March, 12, 0, 0)}},
+		{name: "past date is next year", text: "Taxes march 1", want: Result{Title: "Taxes", Due: inYear(2026, time.March, 1)}},
+		{name: "past date with a year", text: "Taxes 1 jan 2024", want: Result{Title: "Taxes", Due: inYear(2024, time.January, 1)}},
+		{
+			name: "days the month does not have are not dates",
+			text: "Party feb 30",
+			want: Result{Title: "Party feb 30"},
+		},
+		{
+			name: "months without a day are ordinary words",
+			text: "You may go",
+			want: Result{Title: "You may go"},
+		},
+		{
+			name: "only the first date is used",
+			text: "Call today about tomorrow",
+			want: Result{Title: "Call about tomorrow", Due: at(time.March, 12, 0, 0)},
+		},
+
+		// Times
+		{name: "time later today", text: "Call mom 5pm", want: Result{Title: "Call mom", Due: at(time.March, 12, 17, 0)}},
+		{name: "time already passed", text: "Call mom 9am", want: Result{Title: "Call mom", Due: at(time.March, 13, 9, 0)}},
+		{name: "time right now", text: "Call mom at 10:00", want: Result{Title: "Call mom", Due: at(time.March, 13, 10, 0)}},
+		{name: "time with minutes", text: "Call mom at 5:30pm", want: Result{Title: "Call mom", Due: at(time.March, 12, 17, 30)}},
+		{name: "separate meridiem", text: "Call mom at 5:30 PM", want: Result{Title: "Call mom", Due: at(time.March, 12, 17, 30)}},
+		{name: "24-hour time", text: "Call mom 17:45", want: Result{Title: "Call mom", Due: at(time.March, 12, 17, 45)}},
+		{name: "noon", text: "Call mom at noon", want: Result{Title: "Call mom", Due: at(time.March, 12, 12, 0)}},
+		{name: "twelve am", text: "Call mom 12am tomorrow", want: Result{Title: "Call mom", Due: at(time.March, 13, 0, 0)}},
+		{name: "twelve pm", text: "Call mom tomorrow 12pm", want: Result{Title: "Call mom", Due: at(time.March, 13, 12, 0)}},
+		{name: "date and time", text: "Call mom friday at 8am", want: Result{Title: "Call mom", Due: at(time.March, 14, 8, 0)}},
+		{name: "time before date", text: "Call mom 8am on friday", want: Result{Title: "Call mom", Due: at(time.March, 14, 8, 0)}},
+		{
+			name: "numbers without a meridiem are not times",
+			text: "Buy 5 apples at 5",
+			want: Result{Title: "Buy 5 apples at 5"},
+		},
+		{
+			name: "invalid times stay in the title",
+			text: "Call 13pm 25:00 7:75",
+			want: Result{Title: "Call 13pm 25:00 7:75"},
+		},
+
+		// Recurrence
+		{name: "every day", text: "Stretch every day", want: Result{Title: "Stretch", Due: at(time.March, 12, 0, 0), Recurrence: "FREQ=DAILY"}},
+		{name: "every day at a time", text: "Stretch every day at 7am", want: Result{Title: "Stretch", Due: at(time.March, 13, 7, 0), Recurrence: "FREQ=DAILY"}},
+		{name: "every week", text: "Review every week", want: Result{Title: "Review", Due: at(time.March, 12, 0, 0), Recurrence: "FREQ=WEEKLY"}},
+		{name: "every year", text: "Review every year", want: Result{Title: "Review", Due: at(time.March, 12, 0, 0), Recurrence: "FREQ=YEARLY"}},
+		{name: "every other week", text: "Review every other week", want: Result{Title: "Review", Due: at(time.March, 12, 0, 0), Recurrence: "FREQ=WEEKLY;INTERVAL=2"}},
+		{name: "every few days", text: "Water every 3 days", want: Result{Title: "Water", Due: at(time.March, 12, 0, 0), Recurrence: "FREQ=DAILY;INTERVAL=3"}},
+		{name: "every few months as a word", text: "Water every six months", want: Result{Title: "Water", Due: at(time.March, 12, 0, 0), Recurrence: "FREQ=MONTHLY;INTERVAL=6"}},
+		{
+			name: "every weekday",
+			text: "Standup every weekday at 9:30am",
+			want: Result{Title: "Standup", Due: at(time.March, 13, 9, 30), Recurrence: "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR"},
+		},
+		{
+			name: "every weekend",
+			text: "Sleep in every weekend",
+			want: Result{Title: "Sleep in", Due: at(time.March, 15, 0, 0), Recurrence: "FREQ=WEEKLY;BYDAY=SA,SU"},
+		},
+		{
+			name: "every weekday name",
+			text: "Trash out every monday",
+			want: Result{Title: "Trash out", Due: at(time.March, 17, 0, 0), Recurrence: "FREQ=WEEKLY;BYDAY=MO"},
+		},
+		{
+			name: "every weekday name including today",
+			text: "Yoga every wednesday at 6pm",
+			want: Result{Title: "Yoga", Due: at(time.March, 12, 18, 0), Recurrence: "FREQ=WEEKLY;BYDAY=WE"},
+		},
+		{
+			name: "several weekday names",
+			text: "Gym every thursday and Monday",
+			want: Result{Title: "Gym", Due: at(time.March, 13, 0, 0), Recurrence: "FREQ=WEEKLY;BYDAY=MO,TH"},
+		},
+		{
+			name: "abbreviated weekday list",
+			text: "Gym every mon,wed,fri 7am",
+			want: Result{Title: "Gym", Due: at(time.March, 14, 7, 0), Recurrence: "FREQ=WEEKLY;BYDAY=MO,WE,FR"},
+		},
+		{
+			name: "weekday list followed by a word",
+			text: "Gym every tue and sat and stretch",
+			want: Result{Title: "Gym and stretch", Due: at(time.March, 15, 0, 0), Recurrence: "FREQ=WEEKLY;BYDAY=TU,SA"},
+		},
+		{
+			name: "recurrence with an explicit start",
+			text: "Rent every month starting april 1",
+			want: Result{Title: "Rent starting", Due: at(time.April, 1, 0, 0), Recurrence: "FREQ=MONTHLY"},
+		},
+		{
+			name: "every without a rule stays in the title",
+			text: "Check every box",
+			want: Result{Title: "Check every box"},
+		},
+
+		// Quoting and punctuation
+		{
+			name: "quoted text is not interpreted",
+			text: `Watch "The Day After Tomorrow" friday`,
+			want: Result{Title: "Watch The Day After Tomorrow", Due: at(time.March, 14, 0, 0)},
+		},
+		{
+			name: "quoted single word",
+			text: `Buy "#1" gift "today"`,
+			want: Result{Title: "Buy #1 gift today"},
+		},
+		{
+			name: "unclosed quote is ordinary text",
+			text: `Read "Dune tomorrow`,
+			want: Result{Title: `Read "Dune`, Due: at(time.March, 13, 0, 0)},
+		},
+		{
+			name: "separators left at the ends are trimmed",
+			text: "Call mom, tomorrow, 5pm",
+			want: Result{Title: "Call mom", Due: at(time.March, 13, 17, 0)},
+		},
+		{
+			name: "everything at once",
+			text: "!2 Plan offsite +Team #planning on march 20 at 2:15pm #Travel",
+			want: Result{
+				Title:    "Plan offsite",
+				Due:      at(time.March, 20, 14, 15),
+				Tags:     []string{"planning", "travel"},
+				Priority: PriorityMedium,
+				Project:  "Team",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Parse(tt.text, now))
+		})
+	}
+}
+
+func TestParse_Deterministic(t *testing.T) {
+	text := "Pay invoice tomorrow 5pm #finance !high every month"
+	first := Parse(text, now)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, first, Parse(text, now))
+	}
+}
+
+func TestParse_Location(t *testing.T) {
+	taipei := time.FixedZone("UTC+8", 8*60*60)
+
+	// 20:00 UTC on Wednesday is already Thursday in Taipei
+	ref := time.Date(2025, time.March, 12, 20, 0, 0, 0, time.UTC).In(taipei)
+	got := Parse("Call tomorrow 9am", ref)
+
+	want := time.Date(2025, time.March, 14, 9, 0, 0, 0, taipei)
+	if assert.NotNil(t, got.Due) {
+		assert.True(t, want.Equal(*got.Due), "expected %v, got %v", want, *got.Due)
+		assert.Equal(t, taipei, got.Due.Location())
+	}
+
+	// Whole days are the Taipei date at midnight UTC
+	got = Parse("Call tomorrow", ref)
+	assert.Equal(t, at(time.March, 14, 0, 0), got.Due)
+}
+
+func TestParse_MonthEnds(t *testing.T) {
+	ref := time.Date(2025, time.January, 31, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		text string
+		want time.Time
+	}{
+		{"in 1 month", time.Date(2025, time.February, 28, 0, 0, 0, 0, time.UTC)},
+		{"in 3 months", time.Date(2025, time.April, 30, 0, 0, 0, 0, time.UTC)},
+		{"next month", time.Date(2025, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{"end of month", time.Date(2025, time.January, 31, 0, 0, 0, 0, time.UTC)},
+		{"tomorrow", time.Date(2025, time.February, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got := Parse("Task "+tt.text, ref)
+			if assert.NotNil(t, got.Due) {
+				assert.Equal(t, tt.want, *got.Due)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package todoimport
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// csvHeaders are the headers a field is looked for under when the column
+// mapping leaves it out, compared case-insensitively
+var csvHeaders = map[string][]string{
+	FieldTitle:       {"title", "name", "task", "todo", "content", "subject", "summary"},
+	FieldDescription: {"description", "notes", "note", "body", "details"},
+	FieldCompleted:   {"completed", "done", "complete", "status"},
+	FieldDue:         {"due", "due date", "due_at", "due_date", "duedate", "deadline", "date"},
+	FieldPriority:    {"priority", "importance"},
+	FieldProject:     {"project", "list", "folder"},
+	FieldTags:        {"tags", "tag", "labels", "label", "categories", "contexts"},
+	FieldRecurrence:  {"recurrence", "repeat", "rrule"},
+}
+
+// formulaPrefixes are the characters starting the cells spreadsheet apps
+// evaluate as formulas
+const formulaPrefixes = "=+-@\t\r"
+
+// EscapeCSVField prefixes a value that spreadsheet apps would evaluate as a
+// formula with an apostrophe, which makes them show it as text. CSV imports
+// remove the apostrophe again, so values survive an export and import.
+func EscapeCSVField(value string) string {
+	if startsWithFormula(value) {
+		return "'" + value
+	}
+	return value
+}
+
+// unescapeCSVField removes the apostrophe added by EscapeCSVField
+func unescapeCSVField(value string) string {
+	if rest, ok := strings.CutPrefix(value, "'"); ok && startsWithFormula(rest) {
+		return rest
+	}
+	return value
+}
+
+// startsWithFormula reports whether a value starts like a formula, also
+// behind apostrophes, so that values already starting with one are escaped
+// too and come back unchanged
+func startsWithFormula(value string) bool {
+	value = strings.TrimLeft(value, "'")
+	return value != "" && strings.ContainsRune(formulaPrefixes, rune(value[0]))
+}
+
+// csvTable is a CSV file with a header row
+type csvTable struct {
+	reader *csv.Reader
+
+	// columns holds the index of the column of each header, lowercased
+	columns map[string]int
+}
+
+// readCSVTable reads the header row of a CSV file. Files whose header only
+// holds semicolons, as written by spreadsheet apps in many languages, are
+// read with semicolons as separators.
+func readCSVTable(data []byte) (*csvTable, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Contains(firstLine, []byte(";")) && !bytes.Contains(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("the CSV file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, exists := columns[name]; !exists {
+			columns[name] = i
+		}
+	}
+	return &csvTable{reader: reader, columns: columns}, nil
+}
+
+// next returns the next record and the line it starts on, or io.EOF
+func (t *csvTable) next() ([]string, int, error) {
+	record, err := t.reader.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, 0, fmt.Errorf("invalid CSV on line %d: %w", parseErr.StartLine, parseErr.Err)
+		}
+		return nil, 0, err
+	}
+	line, _ := t.reader.FieldPos(0)
+	return record, line, nil
+}
+
+// column returns the index of the column with the given header, or -1
+func (t *csvTable) column(header string) int {
+	if i, ok := t.columns[strings.ToLower(strings.TrimSpace(header))]; ok {
+		return i
+	}
+	return -1
+}
+
+// field returns the value of a column in a record, or "" if the record is
+// too short or there is no such column
+func field(record []string, column int) string {
+	if column < 0 || column >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[column])
+}
+
+// parseCSV reads a CSV file with a header row, finding the columns of each
+// field through the mapping of the options or by their headers
+func parseCSV(data []byte, opts Options) ([]Item, error) {
+	table, err := readCSVTable(data)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int, len(Fields))
+	for _, name := range Fields {
+		columns[name] = -1
+		if header := opts.Columns[name]; header != "" {
+			if columns[name] = table.column(header); columns[name] < 0 {
+				return nil, fmt.Errorf("the CSV file has no column %q for the %s", header, name)
+			}
+			continue
+		}
+		for _, header := range csvHeaders[name] {
+			if columns[name] = table.column(header); columns[name] >= 0 {
+				break
+			}
+		}
+	}
+	if columns[FieldTitle] < 0 {
+		return nil, errors.New("no title column found in the CSV file; map one to the title")
+	}
+
+	var items []Item
+	for {
+		record, line, err := table.next()
+		if errors.Is(err, io.EOF) {
+			return items, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		items = append(items, csvItem(record, line, columns, opts))
+	}
+}
+
+// csvItem reads the todo in a record of a CSV file
+func csvItem(record []string, line int, columns map[string]int, opts Options) Item {
+	item := Item{
+		Row:         line,
+		Title:       unescapeCSVField(field(record, columns[FieldTitle])),
+		Description: unescapeCSVField(field(record, columns[FieldDescription])),
+		Project:     unescapeCSVField(field(record, columns[FieldProject])),
+		Tags:        splitTags(unescapeCSVField(field(record, columns[FieldTags]))),
+		Recurrence:  strings.TrimPrefix(field(record, columns[FieldRecurrence]), "RRULE:"),
+	}
+
+	var errs []error
+	if item.Title == "" {
+		errs = append(errs, errors.New("the title is empty"))
+	}
+	completed, err := parseBool(field(record, columns[FieldCompleted]))
+	if err != nil {
+		errs = append(errs, err)
+	}
+	item.Completed = completed
+	if due := field(record, columns[FieldDue]); due != "" {
+		if item.Due, err = parseDate(due, opts.Location); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if item.Priority, err = parsePriority(field(record, columns[FieldPriority])); err != nil {
+		errs = append(errs, err)
+	}
+
+	item.Err = errors.Join(errs...)
+	return item
+}
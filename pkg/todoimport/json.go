@@ -0,0 +1,79 @@
+package todoimport
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// exportedTodo is a todo of a GotToDo JSON export, or of the todo list the
+// API returns
+type exportedTodo struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Completed   bool       `json:"completed"`
+	DueAt       *time.Time `json:"due_at"`
+	Priority    string     `json:"priority"`
+	Project     string     `json:"project"`
+	Tags        []string   `json:"tags"`
+	Recurrence  string     `json:"recurrence"`
+	CreatedAt   *time.Time `json:"created_at"`
+	DeletedAt   *time.Time `json:"deleted_at"`
+}
+
+// parseJSON reads a GotToDo JSON export, or a list of todos. Todos in the
+// trash are reported rather than imported.
+func parseJSON(data []byte, _ Options) ([]Item, error) {
+	if !isJSON(data) {
+		return nil, errors.New("not a JSON export: expected JSON")
+	}
+
+	var export struct {
+		Todos []exportedTodo `json:"todos"`
+	}
+	var err error
+	if trimmed := bytes.TrimSpace(data); trimmed[0] == '[' {
+		err = json.Unmarshal(trimmed, &export.Todos)
+	} else {
+		err = json.Unmarshal(trimmed, &export)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON export: %w", err)
+	}
+
+	items := make([]Item, 0, len(export.Todos))
+	for i, todo := range export.Todos {
+		item := Item{
+			Row:         i + 1,
+			Title:       strings.TrimSpace(todo.Title),
+			Description: todo.Description,
+			Completed:   todo.Completed,
+			Due:         todo.DueAt,
+			Project:     todo.Project,
+			Tags:        normalizeTags(todo.Tags),
+			Recurrence:  todo.Recurrence,
+			CreatedAt:   todo.CreatedAt,
+		}
+		if item.Due != nil {
+			due := item.Due.UTC()
+			item.Due = &due
+		}
+
+		var errs []error
+		if item.Title == "" {
+			errs = append(errs, errors.New("the todo has no title"))
+		}
+		if todo.DeletedAt != nil {
+			errs = append(errs, errors.New("the todo is in the trash"))
+		}
+		if item.Priority, err = parsePriority(todo.Priority); err != nil {
+			errs = append(errs, err)
+		}
+		item.Err = errors.Join(errs...)
+		items = append(items, item)
+	}
+	return items, nil
+}
@@ -0,0 +1,162 @@
+package todoimport
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// htmlTagRe matches the tags of task notes written as HTML
+var htmlTagRe = regexp.MustCompile(`<[^>]*>`)
+
+// msToDoEntry is a task or a list of tasks of a Microsoft To Do export.
+// Lists have a display name, tasks a title.
+type msToDoEntry struct {
+	DisplayName string        `json:"displayName"`
+	Tasks       []msToDoEntry `json:"tasks"`
+
+	Title string `json:"title"`
+	Body  *struct {
+		Content     string `json:"content"`
+		ContentType string `json:"contentType"`
+	} `json:"body"`
+	Status          string      `json:"status"`
+	Importance      string      `json:"importance"`
+	DueDateTime     *msToDoTime `json:"dueDateTime"`
+	Categories      []string    `json:"categories"`
+	CreatedDateTime string      `json:"createdDateTime"`
+	Recurrence      *struct {
+		Pattern struct {
+			Type       string   `json:"type"`
+			Interval   int      `json:"interval"`
+			DaysOfWeek []string `json:"daysOfWeek"`
+		} `json:"pattern"`
+	} `json:"recurrence"`
+}
+
+// msToDoTime is a date and time of the Graph API
+type msToDoTime struct {
+	DateTime string `json:"dateTime"`
+	TimeZone string `json:"timeZone"`
+}
+
+// msToDoExport holds the collections a Microsoft To Do export may wrap its
+// lists or tasks in
+type msToDoExport struct {
+	Value []msToDoEntry `json:"value"`
+	Lists []msToDoEntry `json:"lists"`
+	Tasks []msToDoEntry `json:"tasks"`
+}
+
+// parseMicrosoftToDo reads a Microsoft To Do export: an array of lists with
+// their tasks, or of tasks alone, possibly wrapped in an object as Graph API
+// responses are. Tasks of a list are filed in a project named after it.
+func parseMicrosoftToDo(data []byte, _ Options) ([]Item, error) {
+	if !isJSON(data) {
+		return nil, errors.New("not a Microsoft To Do export: expected JSON")
+	}
+
+	var entries []msToDoEntry
+	var err error
+	if trimmed := bytes.TrimSpace(data); trimmed[0] == '[' {
+		err = json.Unmarshal(trimmed, &entries)
+	} else {
+		var export msToDoExport
+		err = json.Unmarshal(trimmed, &export)
+		entries = append(append(append(entries, export.Value...), export.Lists...), export.Tasks...)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid Microsoft To Do JSON: %w", err)
+	}
+
+	var items []Item
+	for _, entry := range entries {
+		if entry.DisplayName != "" && entry.Title == "" {
+			for _, task := range entry.Tasks {
+				items = append(items, msToDoItem(task, len(items)+1, entry.DisplayName))
+			}
+			continue
+		}
+		items = append(items, msToDoItem(entry, len(items)+1, ""))
+	}
+	return items, nil
+}
+
+// msToDoItem reads a Microsoft To Do task
+func msToDoItem(task msToDoEntry, row int, project string) Item {
+	item := Item{
+		Row:       row,
+		Title:     strings.TrimSpace(task.Title),
+		Completed: strings.EqualFold(task.Status, "completed"),
+		Project:   project,
+		Tags:      normalizeTags(task.Categories),
+	}
+	if task.Body != nil {
+		content := task.Body.Content
+		if strings.EqualFold(task.Body.ContentType, "html") {
+			content = html.UnescapeString(htmlTagRe.ReplaceAllString(content, " "))
+			content = strings.Join(strings.Fields(content), " ")
+		}
+		item.Description = strings.TrimSpace(content)
+	}
+	switch strings.ToLower(task.Importance) {
+	case "high":
+		item.Priority = PriorityHigh
+	case "low":
+		item.Priority = PriorityLow
+	}
+	if created, err := time.Parse(time.RFC3339Nano, task.CreatedDateTime); err == nil {
+		created = created.UTC()
+		item.CreatedAt = &created
+	}
+
+	var errs []error
+	if item.Title == "" {
+		errs = append(errs, errors.New("the task has no title"))
+	}
+	// Due dates are days, sent as midnight in the time zone of the list
+	if task.DueDateTime != nil && task.DueDateTime.DateTime != "" {
+		date, _, _ := strings.Cut(task.DueDateTime.DateTime, "T")
+		due, err := parseDate(date, time.UTC)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		item.Due = due
+	}
+	if task.Recurrence != nil {
+		recurrence, err := msToDoRecurrence(task.Recurrence.Pattern.Type, task.Recurrence.Pattern.Interval, task.Recurrence.Pattern.DaysOfWeek)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		item.Recurrence = recurrence
+	}
+	item.Err = errors.Join(errs...)
+	return item
+}
+
+// msToDoRecurrence converts a recurrence pattern of the Graph API to an RRULE
+func msToDoRecurrence(kind string, interval int, daysOfWeek []string) (string, error) {
+	var byDay []string
+	for _, day := range daysOfWeek {
+		if len(day) >= 2 {
+			byDay = append(byDay, strings.ToUpper(day[:2]))
+		}
+	}
+
+	switch kind {
+	case "daily":
+		return rrule("DAILY", interval, ""), nil
+	case "weekly":
+		return rrule("WEEKLY", interval, strings.Join(byDay, ",")), nil
+	case "absoluteMonthly", "relativeMonthly":
+		return rrule("MONTHLY", interval, ""), nil
+	case "absoluteYearly", "relativeYearly":
+		return rrule("YEARLY", interval, ""), nil
+	}
+	return "", fmt.Errorf("unknown recurrence %q", kind)
+}
@@ -0,0 +1,268 @@
+// Package todoimport reads the todos exported from other todo apps:
+// todo.txt files, CSV files with any columns, Todoist backups and Microsoft
+// To Do exports, as well as GotToDo's own JSON exports.
+//
+// A file that cannot be read at all is an error. Otherwise every todo found
+// is returned as an Item, and todos that could only be read in part carry
+// their own error, so that a single bad row does not spoil a whole import.
+package todoimport
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Format is a kind of export the package reads
+type Format string
+
+const (
+	// FormatTodoTxt is a todo.txt file, see https://github.com/todotxt/todo.txt
+	FormatTodoTxt Format = "todotxt"
+
+	// FormatCSV is a CSV file with a header row, whose columns are mapped
+	// to todo fields by Options.Columns or guessed from their headers
+	FormatCSV Format = "csv"
+
+	// FormatTodoist is a Todoist project exported as CSV, or a Todoist
+	// backup or task list as JSON
+	FormatTodoist Format = "todoist"
+
+	// FormatMicrosoftToDo is a JSON export of Microsoft To Do lists or tasks
+	// as returned by the Microsoft Graph API
+	FormatMicrosoftToDo Format = "mstodo"
+
+	// FormatJSON is a GotToDo JSON export, or a list of todos as returned
+	// by the API
+	FormatJSON Format = "json"
+)
+
+// Formats lists the supported formats
+var Formats = []Format{FormatTodoTxt, FormatCSV, FormatTodoist, FormatMicrosoftToDo, FormatJSON}
+
+// Priorities of imported todos
+const (
+	PriorityHigh   = "high"
+	PriorityMedium = "medium"
+	PriorityLow    = "low"
+)
+
+// Fields of a todo that CSV columns can be mapped to
+const (
+	FieldTitle       = "title"
+	FieldDescription = "description"
+	FieldCompleted   = "completed"
+	FieldDue         = "due"
+	FieldPriority    = "priority"
+	FieldProject     = "project"
+	FieldTags        = "tags"
+	FieldRecurrence  = "recurrence"
+)
+
+// Fields lists the todo fields CSV columns can be mapped to
+var Fields = []string{FieldTitle, FieldDescription, FieldCompleted, FieldDue, FieldPriority, FieldProject, FieldTags, FieldRecurrence}
+
+// MaxItems bounds the number of todos read from a single file
+const MaxItems = 5000
+
+var (
+	// ErrUnknownFormat is returned for formats not in Formats
+	ErrUnknownFormat = errors.New("unknown import format")
+
+	// ErrTooManyItems is returned for files holding more than MaxItems todos
+	ErrTooManyItems = fmt.Errorf("files may hold at most %d todos", MaxItems)
+)
+
+// Item is a todo read from an export
+type Item struct {
+	// Row is where the todo is in the file: its line in todo.txt and CSV
+	// files, and its position among the todos of JSON exports. Rows count
+	// from 1.
+	Row int `json:"row"`
+
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Completed   bool   `json:"completed,omitempty"`
+
+	// Due is when the todo is due. Dates without a time of day are
+	// midnight UTC of that date, which stands for the whole day.
+	Due *time.Time `json:"due,omitempty"`
+
+	// Priority is PriorityHigh, PriorityMedium, PriorityLow or empty
+	Priority string   `json:"priority,omitempty"`
+	Project  string   `json:"project,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+
+	// Recurrence is an iCalendar RRULE value such as "FREQ=WEEKLY;BYDAY=MO"
+	Recurrence string `json:"recurrence,omitempty"`
+
+	// CreatedAt is when the todo was created in the other app, if known
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+
+	// Err explains why the todo could not be read completely
+	Err error `json:"-"`
+}
+
+// Options adjust how exports are read
+type Options struct {
+	// Columns maps todo fields, such as FieldTitle, to the headers of the
+	// CSV columns holding them. Fields left out are looked for under
+	// common header names.
+	Columns map[string]string
+
+	// Location is the time zone of times given without one. It defaults
+	// to UTC.
+	Location *time.Location
+
+	// Now is the time dates like "tomorrow" in Todoist exports are
+	// relative to. It defaults to the current time.
+	Now time.Time
+}
+
+// Parse reads the todos of an export in the given format
+func Parse(format Format, r io.Reader, opts Options) ([]Item, error) {
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	// Spreadsheet apps like to start files with a byte order mark
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+
+	var items []Item
+	switch format {
+	case FormatTodoTxt:
+		items, err = parseTodoTxt(data, opts)
+	case FormatCSV:
+		items, err = parseCSV(data, opts)
+	case FormatTodoist:
+		if isJSON(data) {
+			items, err = parseTodoistJSON(data, opts)
+		} else {
+			items, err = parseTodoistCSV(data, opts)
+		}
+	case FormatMicrosoftToDo:
+		items, err = parseMicrosoftToDo(data, opts)
+	case FormatJSON:
+		items, err = parseJSON(data, opts)
+	default:
+		return nil, ErrUnknownFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(items) > MaxItems {
+		return nil, ErrTooManyItems
+	}
+	return items, nil
+}
+
+// isJSON reports whether data looks like a JSON object or array
+func isJSON(data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	return len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[')
+}
+
+// parsePriority reads the priorities of todo.txt and CSV files: words,
+// their first letters, 1 to 3 as in p1 to p3, and A to C
+func parsePriority(text string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(text)) {
+	case "", "none", "0", "4", "p4":
+		return "", nil
+	case PriorityHigh, "h", "1", "p1", "a", "urgent":
+		return PriorityHigh, nil
+	case PriorityMedium, "med", "m", "2", "p2", "b":
+		return PriorityMedium, nil
+	case PriorityLow, "l", "3", "p3", "c":
+		return PriorityLow, nil
+	}
+	return "", fmt.Errorf("unknown priority %q", text)
+}
+
+// dateTimeLayouts are the layouts of dates with a time of day accepted in
+// CSV files, all in the time zone of the import
+var dateTimeLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+}
+
+// parseDate reads ISO 8601 dates and times. Dates alone are midnight UTC,
+// and times without an offset are in loc.
+func parseDate(text string, loc *time.Location) (*time.Time, error) {
+	text = strings.TrimSpace(text)
+	if date, err := time.Parse("2006-01-02", text); err == nil {
+		return &date, nil
+	}
+	if t, err := time.Parse(time.RFC3339, text); err == nil {
+		t = t.UTC()
+		return &t, nil
+	}
+	for _, layout := range dateTimeLayouts {
+		if t, err := time.ParseInLocation(layout, text, loc); err == nil {
+			t = t.UTC()
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown date %q, expected YYYY-MM-DD", text)
+}
+
+// parseBool reads whether a todo is completed, from yes or no answers as
+// well as statuses
+func parseBool(text string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(text)) {
+	case "true", "yes", "y", "1", "x", "done", "completed", "complete", "checked":
+		return true, nil
+	case "", "false", "no", "n", "0", "open", "todo", "pending", "active", "incomplete",
+		"not started", "notstarted", "in progress", "inprogress", "needs-action":
+		return false, nil
+	}
+	return false, fmt.Errorf("unknown completion status %q", text)
+}
+
+// splitTags splits a list of tags separated by commas or semicolons,
+// lowercasing them and dropping duplicates
+func splitTags(text string) []string {
+	return normalizeTags(strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == ';'
+	}))
+}
+
+// normalizeTags lowercases tags, drops a leading # or @ and removes empty
+// and duplicate tags
+func normalizeTags(tags []string) []string {
+	var normalized []string
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		tag = strings.TrimLeft(tag, "#@")
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// rrule builds an RRULE value repeating every interval units of freq
+func rrule(freq string, interval int, byDay string) string {
+	rule := "FREQ=" + freq
+	if interval > 1 {
+		rule += fmt.Sprintf(";INTERVAL=%d", interval)
+	}
+	if byDay != "" {
+		rule += ";BYDAY=" + byDay
+	}
+	return rule
+}
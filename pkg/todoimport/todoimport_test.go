@@ -0,0 +1,314 @@
+package todoimport
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// now is the reference time of the tests: Wednesday, 12 March 2025, 10:00
+var now = time.Date(2025, time.March, 12, 10, 0, 0, 0, time.UTC)
+
+// day returns a pointer to midnight UTC of a date
+func day(year int, month time.Month, d int) *time.Time {
+	t := time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+	return &t
+}
+
+// parse reads an export relative to now
+func parse(t *testing.T, format Format, data string, opts Options) []Item {
+	t.Helper()
+	opts.Now = now
+	items, err := Parse(format, strings.NewReader(data), opts)
+	require.NoError(t, err)
+	return items
+}
+
+func TestParse_TodoTxt(t *testing.T) {
+	items := parse(t, FormatTodoTxt, strings.Join([]string{
+		"(A) 2025-02-20 Call Ann +Family +Phone @home due:2025-03-14 rec:2w",
+		"",
+		"x 2025-03-02 2025-02-21 File taxes +Admin pri:B",
+		"Water plants rec:b url:https://example.com",
+		"+Orphan @only",
+		"Broken due:soon",
+	}, "\n"), Options{})
+	require.Len(t, items, 5)
+
+	assert.Equal(t, Item{
+		Row:        1,
+		Title:      "Call Ann",
+		Due:        day(2025, time.March, 14),
+		Priority:   PriorityHigh,
+		Project:    "Family",
+		Tags:       []string{"phone", "home"},
+		Recurrence: "FREQ=WEEKLY;INTERVAL=2",
+		CreatedAt:  day(2025, time.February, 20),
+	}, items[0])
+
+	assert.Equal(t, 3, items[1].Row)
+	assert.True(t, items[1].Completed)
+	assert.Equal(t, "File taxes", items[1].Title)
+	assert.Equal(t, PriorityMedium, items[1].Priority)
+	assert.Equal(t, day(2025, time.February, 21), items[1].CreatedAt)
+
+	assert.Equal(t, "Water plants url:https://example.com", items[2].Title)
+	assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR", items[2].Recurrence)
+	assert.NoError(t, items[2].Err)
+
+	assert.EqualError(t, items[3].Err, "the task has no title")
+	assert.ErrorContains(t, items[4].Err, `unknown date "soon"`)
+	assert.Equal(t, "Broken", items[4].Title)
+}
+
+func TestParse_CSV(t *testing.T) {
+	data := "\ufeffName,Notes,Status,Due Date,Priority,List,Tags\n" +
+		"Buy milk,Semi-skimmed,done,2025-03-14,High,Errands,\"food, #shop\"\n" +
+		"Book flights,,not started,2025-03-20 18:30,p2,,\n" +
+		",,,,,,\n" +
+		"Bad row,,maybe,tomorrow,urgentish,,\n"
+
+	items := parse(t, FormatCSV, data, Options{Location: time.FixedZone("CET", 3600)})
+	require.Len(t, items, 3)
+
+	assert.Equal(t, Item{
+		Row:         2,
+		Title:       "Buy milk",
+		Description: "Semi-skimmed",
+		Completed:   true,
+		Due:         day(2025, time.March, 14),
+		Priority:    PriorityHigh,
+		Project:     "Errands",
+		Tags:        []string{"food", "shop"},
+	}, items[0])
+
+	due := time.Date(2025, time.March, 20, 17, 30, 0, 0, time.UTC)
+	assert.Equal(t, &due, items[1].Due, "times without an offset are in the import's time zone")
+	assert.Equal(t, PriorityMedium, items[1].Priority)
+	assert.NoError(t, items[1].Err)
+
+	// The empty row counts as a line but holds no todo
+	assert.Equal(t, 5, items[2].Row)
+	require.Error(t, items[2].Err)
+	assert.Contains(t, items[2].Err.Error(), `unknown completion status "maybe"`)
+	assert.Contains(t, items[2].Err.Error(), `unknown date "tomorrow"`)
+	assert.Contains(t, items[2].Err.Error(), `unknown priority "urgentish"`)
+}
+
+func TestEscapeCSVField(t *testing.T) {
+	for value, want := range map[string]string{
+		"=HYPERLINK(\"https://example.com\")": "'=HYPERLINK(\"https://example.com\")",
+		"+1 555 0100":                         "'+1 555 0100",
+		"-5 degrees":                          "'-5 degrees",
+		"@SUM(A1)":                            "'@SUM(A1)",
+		"'=quoted":                            "''=quoted",
+		"'tis the season":                     "'tis the season",
+		"Call Ann":                            "Call Ann",
+		"":                                    "",
+	} {
+		assert.Equal(t, want, EscapeCSVField(value))
+	}
+
+	// Imports undo the escaping, in every text column
+	data := "title,description,project,tags\n" +
+		"'=1+1,'-offer,'@home,'+urgent\n" +
+		"''=quoted,'tis,,\n"
+	items := parse(t, FormatCSV, data, Options{})
+	require.Len(t, items, 2)
+	assert.Equal(t, "=1+1", items[0].Title)
+	assert.Equal(t, "-offer", items[0].Description)
+	assert.Equal(t, "@home", items[0].Project)
+	assert.Equal(t, []string{"+urgent"}, items[0].Tags)
+	assert.Equal(t, "'=quoted", items[1].Title)
+	assert.Equal(t, "'tis", items[1].Description, "apostrophes in front of plain text are kept")
+}
+
+func TestParse_CSVColumnMapping(t *testing.T) {
+	data := "Was;Wann;Erledigt\nSteuer;2025-04-30;ja\n"
+
+	_, err := Parse(FormatCSV, strings.NewReader(data), Options{})
+	assert.ErrorContains(t, err, "no title column")
+
+	_, err = Parse(FormatCSV, strings.NewReader(data), Options{Columns: map[string]string{FieldTitle: "Titel"}})
+	assert.ErrorContains(t, err, `no column "Titel" for the title`)
+
+	items := parse(t, FormatCSV, data, Options{Columns: map[string]string{
+		FieldTitle: "was",
+		FieldDue:   "Wann",
+	}})
+	require.Len(t, items, 1)
+	assert.Equal(t, "Steuer", items[0].Title)
+	assert.Equal(t, day(2025, time.April, 30), items[0].Due)
+	assert.False(t, items[0].Completed, "unmapped columns are ignored")
+}
+
+func TestParse_TodoistCSV(t *testing.T) {
+	data := "TYPE,CONTENT,DESCRIPTION,PRIORITY,INDENT,AUTHOR,RESPONSIBLE,DATE,DATE_LANG,TIMEZONE\n" +
+		"section,Home,,,,,,,,\n" +
+		"task,Clean the kitchen @chores,Weekly,4,1,Ann,,every monday,en,UTC\n" +
+		"note,Don't forget the oven,,,,,,,,\n" +
+		"task,Renew passport,,1,1,Ann,,2025-06-01,en,UTC\n" +
+		"task,Someday,,2,1,Ann,,when pigs fly,en,UTC\n" +
+		"task,@alone,,1,1,Ann,,,en,UTC\n"
+
+	items := parse(t, FormatTodoist, data, Options{})
+	require.Len(t, items, 4)
+
+	assert.Equal(t, 3, items[0].Row)
+	assert.Equal(t, "Clean the kitchen", items[0].Title)
+	assert.Equal(t, "Weekly\n\nDon't forget the oven", items[0].Description)
+	assert.Equal(t, []string{"chores"}, items[0].Tags)
+	assert.Equal(t, PriorityHigh, items[0].Priority)
+	assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO", items[0].Recurrence)
+	assert.NotNil(t, items[0].Due)
+
+	assert.Equal(t, day(2025, time.June, 1), items[1].Due)
+	assert.Empty(t, items[1].Priority)
+
+	assert.Nil(t, items[2].Due)
+	assert.Equal(t, "Due: when pigs fly", items[2].Description, "unreadable dates are kept")
+	assert.Equal(t, PriorityLow, items[2].Priority)
+	assert.NoError(t, items[2].Err)
+
+	assert.Error(t, items[3].Err)
+
+	_, err := Parse(FormatTodoist, strings.NewReader("Name,Due\nx,y\n"), Options{})
+	assert.ErrorContains(t, err, "not a Todoist CSV export")
+}
+
+func TestParse_TodoistJSON(t *testing.T) {
+	data := `{
+		"projects": [{"id": "220474322", "name": "Inbox"}, {"id": 2, "name": "Work"}],
+		"items": [
+			{"content": "Send report", "description": "Q1", "priority": 3, "project_id": 2,
+			 "labels": ["Office"], "checked": 1, "added_at": "2025-01-05T09:00:00Z",
+			 "due": {"date": "2025-03-14", "is_recurring": false, "string": "Mar 14"}},
+			{"content": "Stand-up", "priority": 1, "project_id": "220474322", "checked": false,
+			 "due": {"date": "2025-03-13T09:30:00", "is_recurring": true, "string": "every weekday 9:30am"}},
+			{"content": "  ", "due": {"date": "someday"}}
+		]
+	}`
+
+	items := parse(t, FormatTodoist, data, Options{Location: time.FixedZone("CET", 3600)})
+	require.Len(t, items, 3)
+
+	created := time.Date(2025, time.January, 5, 9, 0, 0, 0, time.UTC)
+	assert.Equal(t, Item{
+		Row:         1,
+		Title:       "Send report",
+		Description: "Q1",
+		Completed:   true,
+		Due:         day(2025, time.March, 14),
+		Priority:    PriorityMedium,
+		Project:     "Work",
+		Tags:        []string{"office"},
+		CreatedAt:   &created,
+	}, items[0])
+
+	due := time.Date(2025, time.March, 13, 8, 30, 0, 0, time.UTC)
+	assert.Equal(t, &due, items[1].Due)
+	assert.Equal(t, "Inbox", items[1].Project)
+	assert.False(t, items[1].Completed)
+	assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR", items[1].Recurrence)
+
+	assert.Error(t, items[2].Err)
+
+	// Task lists of the REST API are accepted too
+	items = parse(t, FormatTodoist, `[{"content": "Alone", "is_completed": true}]`, Options{})
+	require.Len(t, items, 1)
+	assert.True(t, items[0].Completed)
+
+	_, err := Parse(FormatTodoist, strings.NewReader(`{"items": 3}`), Options{})
+	assert.ErrorContains(t, err, "invalid Todoist JSON")
+}
+
+func TestParse_MicrosoftToDo(t *testing.T) {
+	data := `{"value": [
+		{"displayName": "Groceries", "tasks": [
+			{"title": "Apples", "status": "notStarted", "importance": "normal", "categories": ["Red category"],
+			 "body": {"content": "<p>Granny&nbsp;Smith <b>only</b></p>", "contentType": "html"}},
+			{"title": "Bread", "status": "completed", "importance": "high",
+			 "dueDateTime": {"dateTime": "2025-03-14T00:00:00.0000000", "timeZone": "W. Europe Standard Time"},
+			 "createdDateTime": "2025-03-01T08:15:30.1234567Z"}
+		]},
+		{"title": "Gym", "importance": "low",
+		 "recurrence": {"pattern": {"type": "weekly", "interval": 1, "daysOfWeek": ["tuesday", "thursday"]}}},
+		{"title": "Odd", "recurrence": {"pattern": {"type": "hourly", "interval": 1}}}
+	]}`
+
+	items := parse(t, FormatMicrosoftToDo, data, Options{})
+	require.Len(t, items, 4)
+
+	assert.Equal(t, Item{
+		Row:         1,
+		Title:       "Apples",
+		Description: "Granny Smith only",
+		Project:     "Groceries",
+		Tags:        []string{"red category"},
+	}, items[0])
+
+	assert.Equal(t, 2, items[1].Row)
+	assert.True(t, items[1].Completed)
+	assert.Equal(t, PriorityHigh, items[1].Priority)
+	assert.Equal(t, day(2025, time.March, 14), items[1].Due)
+	assert.Equal(t, time.Date(2025, time.March, 1, 8, 15, 30, 123456700, time.UTC), *items[1].CreatedAt)
+
+	assert.Empty(t, items[2].Project)
+	assert.Equal(t, PriorityLow, items[2].Priority)
+	assert.Equal(t, "FREQ=WEEKLY;BYDAY=TU,TH", items[2].Recurrence)
+
+	assert.ErrorContains(t, items[3].Err, `unknown recurrence "hourly"`)
+
+	_, err := Parse(FormatMicrosoftToDo, strings.NewReader("Title\nApples\n"), Options{})
+	assert.ErrorContains(t, err, "expected JSON")
+}
+
+func TestParse_JSON(t *testing.T) {
+	data := `{"version": 1, "exported_at": "2025-03-12T10:00:00Z", "todos": [
+		{"id": "a", "title": "Call Ann", "description": "About the trip", "completed": true,
+		 "due_at": "2025-03-14T09:30:00+01:00", "priority": "high", "project": "Family", "tags": ["phone"],
+		 "recurrence": "FREQ=WEEKLY", "created_at": "2025-02-20T08:00:00Z",
+		 "history": [{"action": "created"}]},
+		{"id": "b", "title": "Old", "deleted_at": "2025-03-01T00:00:00Z"},
+		{"id": "c", "title": "Odd", "priority": "whenever"}
+	]}`
+
+	items := parse(t, FormatJSON, data, Options{})
+	require.Len(t, items, 3)
+
+	due := time.Date(2025, time.March, 14, 8, 30, 0, 0, time.UTC)
+	created := time.Date(2025, time.February, 20, 8, 0, 0, 0, time.UTC)
+	assert.Equal(t, Item{
+		Row:         1,
+		Title:       "Call Ann",
+		Description: "About the trip",
+		Completed:   true,
+		Due:         &due,
+		Priority:    PriorityHigh,
+		Project:     "Family",
+		Tags:        []string{"phone"},
+		Recurrence:  "FREQ=WEEKLY",
+		CreatedAt:   &created,
+	}, items[0])
+	assert.EqualError(t, items[1].Err, "the todo is in the trash")
+	assert.ErrorContains(t, items[2].Err, `unknown priority "whenever"`)
+
+	// Todo lists returned by the API are accepted too
+	items = parse(t, FormatJSON, `[{"title": "Alone"}]`, Options{})
+	require.Len(t, items, 1)
+	assert.Equal(t, "Alone", items[0].Title)
+}
+
+func TestParse_Limits(t *testing.T) {
+	_, err := Parse("evernote", strings.NewReader(""), Options{})
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+
+	_, err = Parse(FormatTodoTxt, strings.NewReader(strings.Repeat("Task\n", MaxItems+1)), Options{})
+	assert.ErrorIs(t, err, ErrTooManyItems)
+
+	_, err = Parse(FormatCSV, strings.NewReader(""), Options{})
+	assert.ErrorContains(t, err, "empty")
+}
@@ -0,0 +1,226 @@
+package todoimport
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/starbops/gottodo/pkg/quickadd"
+)
+
+// parseTodoistCSV reads a Todoist project exported as CSV. Its rows are
+// tasks, sections and notes, the latter belonging to the task above them.
+func parseTodoistCSV(data []byte, opts Options) ([]Item, error) {
+	table, err := readCSVTable(data)
+	if err != nil {
+		return nil, err
+	}
+	typeColumn, contentColumn := table.column("TYPE"), table.column("CONTENT")
+	if typeColumn < 0 || contentColumn < 0 {
+		return nil, errors.New("not a Todoist CSV export: the TYPE and CONTENT columns are missing")
+	}
+	descriptionColumn := table.column("DESCRIPTION")
+	priorityColumn := table.column("PRIORITY")
+	dateColumn := table.column("DATE")
+
+	var items []Item
+	for {
+		record, line, err := table.next()
+		if errors.Is(err, io.EOF) {
+			return items, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		content := field(record, contentColumn)
+		switch strings.ToLower(field(record, typeColumn)) {
+		case "task":
+			item := Item{Row: line, Description: field(record, descriptionColumn)}
+			item.Title, item.Tags = todoistLabels(content)
+			item.Priority = todoistPriority(field(record, priorityColumn))
+			todoistDate(&item, field(record, dateColumn), opts)
+			if item.Title == "" {
+				item.Err = errors.New("the task has no content")
+			}
+			items = append(items, item)
+		case "note":
+			if len(items) > 0 && content != "" {
+				last := &items[len(items)-1]
+				last.Description = strings.TrimSpace(last.Description + "\n\n" + content)
+			}
+		}
+	}
+}
+
+// todoistLabels separates the @labels from the content of a task
+func todoistLabels(content string) (string, []string) {
+	var title, labels []string
+	for _, word := range strings.Fields(content) {
+		if len(word) > 1 && word[0] == '@' {
+			labels = append(labels, word)
+		} else {
+			title = append(title, word)
+		}
+	}
+	return strings.Join(title, " "), normalizeTags(labels)
+}
+
+// todoistPriority maps Todoist priorities, where 4 is the most urgent p1,
+// to high, medium and low
+func todoistPriority(text string) string {
+	switch strings.TrimSpace(text) {
+	case "4":
+		return PriorityHigh
+	case "3":
+		return PriorityMedium
+	case "2":
+		return PriorityLow
+	default:
+		return ""
+	}
+}
+
+// todoistDate reads the due date of a Todoist task, which is written the way
+// it was typed, like "every monday" or "Mar 14". Dates that cannot be read
+// are kept in the description rather than lost.
+func todoistDate(item *Item, text string, opts Options) {
+	if text == "" {
+		return
+	}
+	if due, err := parseDate(text, opts.Location); err == nil {
+		item.Due = due
+		return
+	}
+
+	result := quickadd.Parse(text, opts.Now.In(opts.Location))
+	if result.Title != "" || (result.Due == nil && result.Recurrence == "") {
+		item.Description = strings.TrimSpace(item.Description + "\n\nDue: " + text)
+		return
+	}
+	item.Due = result.Due
+	item.Recurrence = result.Recurrence
+}
+
+// todoistBackup is a Todoist backup in the format of the Sync API. Task
+// lists of the REST API are read as its items.
+type todoistBackup struct {
+	Projects []struct {
+		ID   flexString `json:"id"`
+		Name string     `json:"name"`
+	} `json:"projects"`
+	Items []todoistTask `json:"items"`
+}
+
+// todoistTask is a task of a Todoist backup
+type todoistTask struct {
+	Content     string     `json:"content"`
+	Description string     `json:"description"`
+	Priority    int        `json:"priority"`
+	ProjectID   flexString `json:"project_id"`
+	Labels      []string   `json:"labels"`
+	Checked     flexBool   `json:"checked"`
+	IsCompleted flexBool   `json:"is_completed"`
+	AddedAt     string     `json:"added_at"`
+	CreatedAt   string     `json:"created_at"`
+	Due         *struct {
+		Date        string `json:"date"`
+		IsRecurring bool   `json:"is_recurring"`
+		String      string `json:"string"`
+	} `json:"due"`
+}
+
+// parseTodoistJSON reads a Todoist backup, or a list of tasks
+func parseTodoistJSON(data []byte, opts Options) ([]Item, error) {
+	var backup todoistBackup
+	var err error
+	if trimmed := bytes.TrimSpace(data); trimmed[0] == '[' {
+		err = json.Unmarshal(trimmed, &backup.Items)
+	} else {
+		err = json.Unmarshal(trimmed, &backup)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid Todoist JSON: %w", err)
+	}
+
+	projects := make(map[flexString]string, len(backup.Projects))
+	for _, project := range backup.Projects {
+		projects[project.ID] = project.Name
+	}
+
+	items := make([]Item, 0, len(backup.Items))
+	for i, task := range backup.Items {
+		item := Item{
+			Row:         i + 1,
+			Title:       strings.TrimSpace(task.Content),
+			Description: strings.TrimSpace(task.Description),
+			Completed:   bool(task.Checked) || bool(task.IsCompleted),
+			Priority:    todoistPriority(strconv.Itoa(task.Priority)),
+			Project:     projects[task.ProjectID],
+			Tags:        normalizeTags(task.Labels),
+		}
+		for _, created := range []string{task.AddedAt, task.CreatedAt} {
+			if t, err := time.Parse(time.RFC3339, created); err == nil {
+				t = t.UTC()
+				item.CreatedAt = &t
+				break
+			}
+		}
+
+		if task.Due != nil && task.Due.Date != "" {
+			due, err := parseDate(task.Due.Date, opts.Location)
+			if err != nil {
+				item.Err = err
+			}
+			item.Due = due
+			if task.Due.IsRecurring {
+				item.Recurrence = quickadd.Parse(task.Due.String, opts.Now.In(opts.Location)).Recurrence
+			}
+		}
+		if item.Title == "" {
+			item.Err = errors.New("the task has no content")
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// flexString is a JSON string that may also be written as a number, as IDs
+// are in older exports
+type flexString string
+
+// UnmarshalJSON reads a string or a number
+func (s *flexString) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*s = flexString(text)
+		return nil
+	}
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return err
+	}
+	*s = flexString(number.String())
+	return nil
+}
+
+// flexBool is a JSON boolean that may also be written as 0 or 1
+type flexBool bool
+
+// UnmarshalJSON reads a boolean or a number
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	switch string(data) {
+	case "true", "1":
+		*b = true
+	case "false", "0", "null":
+		*b = false
+	default:
+		return fmt.Errorf("invalid boolean %s", data)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package todoimport
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// todoTxtPriorityRe matches the priority starting an open todo.txt task
+	todoTxtPriorityRe = regexp.MustCompile(`^\(([A-Z])\)$`)
+
+	// todoTxtDateRe matches the completion and creation dates of a task
+	todoTxtDateRe = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+
+	// todoTxtRecurrenceRe matches the rec: extension, such as 1w or +2d
+	todoTxtRecurrenceRe = regexp.MustCompile(`^\+?(\d*)([dbwmy])$`)
+)
+
+// parseTodoTxt reads a todo.txt file, one task per line
+func parseTodoTxt(data []byte, opts Options) ([]Item, error) {
+	var items []Item
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64<<10), 1<<20)
+	for row := 1; scanner.Scan(); row++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		item := parseTodoTxtLine(line, opts.Location)
+		item.Row = row
+		items = append(items, item)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// parseTodoTxtLine reads a single task such as
+// "x 2025-03-02 2025-02-20 Call Ann +Family @phone due:2025-03-14". The first
+// +project is the project; further projects and @contexts become tags.
+// Extensions other than due:, pri: and rec: stay in the title.
+func parseTodoTxtLine(line string, loc *time.Location) Item {
+	var item Item
+	words := strings.Fields(line)
+
+	if words[0] == "x" {
+		item.Completed = true
+		words = words[1:]
+		// The completion date is not kept
+		if len(words) > 0 && todoTxtDateRe.MatchString(words[0]) {
+			words = words[1:]
+		}
+	} else if match := todoTxtPriorityRe.FindStringSubmatch(words[0]); match != nil {
+		item.Priority = todoTxtPriority(match[1])
+		words = words[1:]
+	}
+	if len(words) > 0 && todoTxtDateRe.MatchString(words[0]) {
+		if created, err := time.Parse("2006-01-02", words[0]); err == nil {
+			item.CreatedAt = &created
+			words = words[1:]
+		}
+	}
+
+	var title, tags []string
+	for _, word := range words {
+		switch {
+		case len(word) > 1 && word[0] == '+':
+			if item.Project == "" {
+				item.Project = word[1:]
+			} else {
+				tags = append(tags, word[1:])
+			}
+		case len(word) > 1 && word[0] == '@':
+			tags = append(tags, word[1:])
+		case strings.HasPrefix(word, "due:"):
+			due, err := parseDate(strings.TrimPrefix(word, "due:"), loc)
+			if err != nil {
+				item.Err = err
+				continue
+			}
+			item.Due = due
+		case strings.HasPrefix(word, "pri:") && len(word) == len("pri:A"):
+			item.Priority = todoTxtPriority(strings.ToUpper(word[len("pri:"):]))
+		case strings.HasPrefix(word, "rec:"):
+			recurrence, err := todoTxtRecurrence(strings.TrimPrefix(word, "rec:"))
+			if err != nil {
+				item.Err = err
+				continue
+			}
+			item.Recurrence = recurrence
+		default:
+			title = append(title, word)
+		}
+	}
+
+	item.Title = strings.Join(title, " ")
+	item.Tags = normalizeTags(tags)
+	if item.Title == "" && item.Err == nil {
+		item.Err = errors.New("the task has no title")
+	}
+	return item
+}
+
+// todoTxtPriority maps todo.txt priorities, A to Z, to high, medium and low
+func todoTxtPriority(letter string) string {
+	switch letter {
+	case "A":
+		return PriorityHigh
+	case "B":
+		return PriorityMedium
+	default:
+		return PriorityLow
+	}
+}
+
+// todoTxtRecurrence converts the rec: extension, such as 1w, 3m or b for
+// every business day, to an RRULE. A leading + repeats from the due date
+// rather than the completion date, which makes no difference here.
+func todoTxtRecurrence(text string) (string, error) {
+	match := todoTxtRecurrenceRe.FindStringSubmatch(text)
+	if match == nil {
+		return "", fmt.Errorf("unknown recurrence rec:%s", text)
+	}
+
+	interval := 1
+	if match[1] != "" {
+		interval, _ = strconv.Atoi(match[1])
+	}
+	switch match[2] {
+	case "d":
+		return rrule("DAILY", interval, ""), nil
+	case "b":
+		return rrule("WEEKLY", 1, "MO,TU,WE,TH,FR"), nil
+	case "w":
+		return rrule("WEEKLY", interval, ""), nil
+	case "m":
+		return rrule("MONTHLY", interval, ""), nil
+	default:
+		return rrule("YEARLY", interval, ""), nil
+	}
+}
@@ -43,7 +43,7 @@ func Layout(title string) templ.Component {
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, " - GotToDo</title><meta name=\"viewport\" content=\"width=device-width, initial-scale=1\"><script src=\"https://cdn.tailwindcss.com\"></script><script src=\"https://unpkg.com/htmx.org@1.9.10\"></script><script src=\"https://unpkg.com/htmx.org/dist/ext/response-targets.js\"></script><style>\n\t\t\t\t.htmx-indicator {\n\t\t\t\t\tdisplay: none;\n\t\t\t\t}\n\t\t\t\t.htmx-request .htmx-indicator {\n\t\t\t\t\tdisplay: inline;\n\t\t\t\t}\n\t\t\t\t.htmx-request.htmx-indicator {\n\t\t\t\t\tdisplay: inline;\n\t\t\t\t}\n\t\t\t</style></head><body class=\"bg-gray-100 min-h-screen\" hx-ext=\"response-targets\" data-hx-boost=\"false\"><div class=\"container mx-auto px-4 py-8\">")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, " - GotToDo</title><meta name=\"viewport\" content=\"width=device-width, initial-scale=1\"><script src=\"https://cdn.tailwindcss.com\"></script><script src=\"https://unpkg.com/htmx.org@1.9.10\"></script><script src=\"https://unpkg.com/htmx.org/dist/ext/response-targets.js\"></script><script src=\"https://unpkg.com/htmx.org@1.9.10/dist/ext/sse.js\"></script><script>\n\t\t\t\t// Show due times and read quick-add dates in the browser's time zone\n\t\t\t\thtmx.onLoad(function(content) {\n\t\t\t\t\tcontent.querySelectorAll('time[data-local]').forEach(function(time) {\n\t\t\t\t\t\ttime.textContent = new Date(time.getAttribute('datetime')).toLocaleString([], {\n\t\t\t\t\t\t\tweekday: 'short', day: 'numeric', month: 'short', year: 'numeric', hour: '2-digit', minute: '2-digit'\n\t\t\t\t\t\t});\n\t\t\t\t\t});\n\t\t\t\t\tcontent.querySelectorAll('input[name=\"time_zone\"]').forEach(function(input) {\n\t\t\t\t\t\tinput.value = Intl.DateTimeFormat().resolvedOptions().timeZone;\n\t\t\t\t\t});\n\t\t\t\t});\n\t\t\t</script><style>\n\t\t\t\t.htmx-indicator {\n\t\t\t\t\tdisplay: none;\n\t\t\t\t}\n\t\t\t\t.htmx-request .htmx-indicator {\n\t\t\t\t\tdisplay: inline;\n\t\t\t\t}\n\t\t\t\t.htmx-request.htmx-indicator {\n\t\t\t\t\tdisplay: inline;\n\t\t\t\t}\n\t\t\t</style></head><body class=\"bg-gray-100 min-h-screen\" hx-ext=\"response-targets\" data-hx-boost=\"false\"><div class=\"container mx-auto px-4 py-8\">")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -100,13 +100,13 @@ func DashboardLayout(userEmail string) templ.Component {
 			var templ_7745c5c3_Var5 string
 			templ_7745c5c3_Var5, templ_7745c5c3_Err = templ.JoinStringErrs(userEmail)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/layout.templ`, Line: 39, Col: 80}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/layout.templ`, Line: 53, Col: 80}
 			}
 			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var5))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 5, "</span></p></div><form action=\"/auth/logout\" method=\"post\" hx-boost=\"false\"><button class=\"bg-red-500 hover:bg-red-600 text-white font-semibold py-2 px-4 rounded\">Logout</button></form></div>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 5, "</span></p></div><div class=\"flex items-center\"><a href=\"/capture\" class=\"text-gray-600 hover:text-gray-800 font-medium mr-4\">Capture</a> <a href=\"/calendar\" class=\"text-gray-600 hover:text-gray-800 font-medium mr-4\">Calendar</a> <a href=\"/app-passwords\" class=\"text-gray-600 hover:text-gray-800 font-medium mr-4\">Sync</a> <a href=\"/import\" class=\"text-gray-600 hover:text-gray-800 font-medium mr-4\">Import &amp; export</a> <a href=\"/webhooks\" class=\"text-gray-600 hover:text-gray-800 font-medium mr-4\">Webhooks</a> <a href=\"/trash\" class=\"text-gray-600 hover:text-gray-800 font-medium mr-4\">Trash</a> <a href=\"/account\" class=\"text-gray-600 hover:text-gray-800 font-medium mr-4\">Account</a><form action=\"/auth/logout\" method=\"post\" hx-boost=\"false\"><button class=\"bg-red-500 hover:bg-red-600 text-white font-semibold py-2 px-4 rounded\">Logout</button></form></div></div>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}